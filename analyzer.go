@@ -16,8 +16,10 @@ package clair
 
 import (
 	"context"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/featurefmt"
@@ -39,6 +41,9 @@ var (
 	// RetrieveBlobError represents an analyze error caused by failure of
 	// downloading or extracting layer blobs.
 	RetrieveBlobError = AnalyzeError("failed to download layer blob.")
+	// LayerPathForbiddenError represents an analyze error caused by a layer
+	// path that is not allowed to be read from the local filesystem.
+	LayerPathForbiddenError = AnalyzeError("layer path is not allowed.")
 	// ExtractBlobError represents an analyzer error caused by failure of
 	// extracting a layer blob by imagefmt.
 	ExtractBlobError = AnalyzeError("failed to extract files from layer blob.")
@@ -48,8 +53,72 @@ var (
 	// NamespaceDetectorError is an error caused by failure of namespace
 	// detection by featurens.
 	NamespaceDetectorError = AnalyzeError("failed to scan namespace from layer blob files.")
+	// AncestrySourceNotStoredError is an error caused by rescanning an
+	// ancestry whose layer sources were not stored.
+	AncestrySourceNotStoredError = AnalyzeError("ancestry layer sources are not stored.")
 )
 
+// LayerBlob locates the blob of a layer to analyze.
+type LayerBlob struct {
+	Hash    string
+	Path    string
+	Headers map[string]string
+}
+
+// AnalyzeLayers analyzes the layers of an ancestry, processing at most
+// layerConcurrency layers at a time, and returns their results in the order of
+// the given layers.
+//
+// Layers sharing the same hash are analyzed once. The first analyze error
+// cancels the layers still being processed and is returned.
+func AnalyzeLayers(ctx context.Context, store database.Datastore, blobFormat string, layers []LayerBlob) ([]*database.LayerScanResult, error) {
+	var (
+		seen      = map[string]struct{}{}
+		results   = map[string]*database.LayerScanResult{}
+		resultsMu sync.Mutex
+		sem       = make(chan struct{}, layerConcurrency)
+	)
+
+	g, analyzerCtx := errgroup.WithContext(ctx)
+	for i := range layers {
+		layer := layers[i]
+		if _, ok := seen[layer.Hash]; ok {
+			continue
+		}
+		seen[layer.Hash] = struct{}{}
+
+		g.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-analyzerCtx.Done():
+				return analyzerCtx.Err()
+			}
+
+			result, err := AnalyzeLayer(analyzerCtx, store, layer.Hash, blobFormat, layer.Path, layer.Headers)
+			if err != nil {
+				return err
+			}
+
+			resultsMu.Lock()
+			results[layer.Hash] = result
+			resultsMu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	scanned := make([]*database.LayerScanResult, 0, len(layers))
+	for _, layer := range layers {
+		scanned = append(scanned, results[layer.Hash])
+	}
+
+	return scanned, nil
+}
+
 // AnalyzeLayer retrieves the clair layer with all extracted features and namespaces.
 // If a layer is already scanned by all enabled detectors in the Clair instance, it returns directly.
 // Otherwise, it re-download the layer blob and scan the features and namespaced again.
@@ -75,7 +144,10 @@ func AnalyzeLayer(ctx context.Context, store database.Datastore, blobSha256 stri
 		log.WithFields(logFields).Debug("layer blob hasn't been scanned yet")
 		layer.NewScanResultLayer = &database.Layer{Hash: blobSha256, By: toScan}
 		blob, err := retrieveLayerBlob(ctx, downloadURI, downloadHeaders)
-		if err != nil {
+		if err == LayerPathForbiddenError {
+			log.WithFields(logFields).WithField("path", downloadURI).Warning("rejected local layer path")
+			return nil, err
+		} else if err != nil {
 			log.WithError(err).WithFields(logFields).Error("failed to retrieve layer blob")
 			return nil, RetrieveBlobError
 		}
@@ -0,0 +1,190 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	archivetar "archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/ext/featurens/osrelease"
+	_ "github.com/quay/clair/v3/ext/imagefmt/docker"
+)
+
+const slowBlobDelay = 100 * time.Millisecond
+
+// slowBlobServer serves a layer blob per path, each containing an os-release
+// file whose version is the path, after a delay, and counts the downloads of
+// every path.
+type slowBlobServer struct {
+	sync.Mutex
+	inFlight    int
+	maxInFlight int
+	downloads   map[string]int
+}
+
+func (s *slowBlobServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	if s.downloads == nil {
+		s.downloads = map[string]int{}
+	}
+	s.downloads[r.URL.Path]++
+	s.Unlock()
+
+	defer func() {
+		s.Lock()
+		s.inFlight--
+		s.Unlock()
+	}()
+
+	time.Sleep(slowBlobDelay)
+	if strings.HasPrefix(r.URL.Path, "/missing") {
+		http.NotFound(w, r)
+		return
+	}
+
+	content := fmt.Sprintf("ID=debian\nVERSION_ID=%q\n", strings.TrimPrefix(r.URL.Path, "/"))
+	var buf bytes.Buffer
+	tw := archivetar.NewWriter(&buf)
+	tw.WriteHeader(&archivetar.Header{Name: "etc/os-release", Mode: 0644, Size: int64(len(content))})
+	tw.Write([]byte(content))
+	tw.Close()
+	w.Write(buf.Bytes())
+}
+
+func newAnalyzerDatastore() *database.MockDatastore {
+	return &database.MockDatastore{
+		FctBegin: func() (database.Session, error) {
+			return &database.MockSession{
+				FctFindLayer: func(name string) (database.Layer, bool, error) {
+					return database.Layer{}, false, nil
+				},
+				FctRollback: func() error { return nil },
+			}, nil
+		},
+	}
+}
+
+func TestAnalyzeLayersConcurrently(t *testing.T) {
+	server := &slowBlobServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	previous := layerConcurrency
+	layerConcurrency = 4
+	defer func() { layerConcurrency = previous }()
+
+	var layers []LayerBlob
+	for i := 1; i <= 8; i++ {
+		layers = append(layers, LayerBlob{
+			Hash: fmt.Sprintf("layer-%d", i),
+			Path: fmt.Sprintf("%s/%d", ts.URL, i),
+		})
+	}
+	// Layers sharing a hash are downloaded once.
+	layers = append(layers, layers[0])
+
+	start := time.Now()
+	results, err := AnalyzeLayers(context.Background(), newAnalyzerDatastore(), "docker", layers)
+	elapsed := time.Since(start)
+	require.Nil(t, err)
+
+	t.Logf("analyzed %d layers in %s", len(layers), elapsed)
+	assert.True(t, elapsed < 8*slowBlobDelay, "layers should be downloaded concurrently")
+	assert.True(t, server.maxInFlight > 1)
+	assert.True(t, server.maxInFlight <= layerConcurrency)
+
+	require.Len(t, results, len(layers))
+	for i, result := range results {
+		require.NotNil(t, result.NewScanResultLayer)
+		assert.Equal(t, layers[i].Hash, result.NewScanResultLayer.Hash)
+		if assert.Len(t, result.NewScanResultLayer.Namespaces, 1) {
+			expected := fmt.Sprintf("debian:%d", i%8+1)
+			assert.Equal(t, expected, result.NewScanResultLayer.Namespaces[0].Name)
+		}
+	}
+}
+
+func TestAnalyzeLayersError(t *testing.T) {
+	ts := httptest.NewServer(&slowBlobServer{})
+	defer ts.Close()
+
+	layers := []LayerBlob{
+		{Hash: "layer-1", Path: ts.URL + "/1"},
+		{Hash: "layer-2", Path: ts.URL + "/missing"},
+		{Hash: "layer-3", Path: ts.URL + "/3"},
+	}
+
+	_, err := AnalyzeLayers(context.Background(), newAnalyzerDatastore(), "docker", layers)
+	assert.Equal(t, RetrieveBlobError, err)
+}
+
+func TestProcessAncestries(t *testing.T) {
+	server := &slowBlobServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	store := openRescanDatastore(t)
+	defer store.Close()
+
+	blobs := func(paths ...string) []LayerBlob {
+		var layers []LayerBlob
+		for _, path := range paths {
+			layers = append(layers, LayerBlob{Hash: "layer-" + path, Path: ts.URL + "/" + path})
+		}
+		return layers
+	}
+
+	// Both ancestries share three of their four layers, and the layer of the
+	// third one can't be downloaded.
+	errs := ProcessAncestries(context.Background(), store, []AncestryBlobs{
+		{Name: "ancestry-1", Format: "docker", Layers: blobs("1", "2", "3", "4")},
+		{Name: "ancestry-2", Format: "docker", Layers: blobs("1", "2", "3", "5")},
+		{Name: "ancestry-3", Format: "docker", Layers: blobs("1", "missing")},
+	})
+	require.Len(t, errs, 3)
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1])
+	assert.Equal(t, RetrieveBlobError, errs[2])
+
+	assert.Equal(t, map[string]int{"/1": 1, "/2": 1, "/3": 1, "/4": 1, "/5": 1, "/missing": 1}, server.downloads)
+
+	for name, last := range map[string]string{"ancestry-1": "layer-4", "ancestry-2": "layer-5"} {
+		ancestry, ok, err := database.FindAncestryAndRollback(store, name)
+		require.Nil(t, err)
+		require.True(t, ok)
+		require.Len(t, ancestry.Layers, 4)
+		assert.Equal(t, last, ancestry.Layers[3].Hash)
+	}
+
+	_, ok, err := database.FindAncestryAndRollback(store, "ancestry-3")
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -15,14 +15,17 @@
 package clair
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/imgpostprocessor"
 )
 
 type layerIndexedFeature struct {
@@ -313,6 +316,177 @@ func SaveAncestry(store database.Datastore, ancestry *database.Ancestry) error {
 	return nil
 }
 
+// ProcessAncestry analyzes the layers of an ancestry with the enabled detectors
+// and saves the resulting ancestry, replacing any previous one.
+//
+// The layer paths and headers are saved along with the ancestry when the
+// worker is configured to store layer sources, so that it can be rescanned.
+func ProcessAncestry(ctx context.Context, store database.Datastore, blobFormat string, name string, layers []LayerBlob) error {
+	scannedLayers, err := AnalyzeLayers(ctx, store, blobFormat, layers)
+	if err != nil {
+		return err
+	}
+
+	return saveScannedAncestry(store, blobFormat, name, layers, scannedLayers)
+}
+
+// AncestryBlobs locates the blobs of the layers of an ancestry to analyze.
+type AncestryBlobs struct {
+	Name   string
+	Format string
+	Layers []LayerBlob
+}
+
+// ProcessAncestries analyzes and saves a batch of ancestries as
+// ProcessAncestry does, analyzing the layers they share once for the whole
+// batch, at most layerConcurrency layers at a time.
+//
+// The errors of the ancestries are returned in the order of the given
+// ancestries, nil for the ones which were saved: an ancestry fails with the
+// error of the first of its layers which failed, without failing the others.
+func ProcessAncestries(ctx context.Context, store database.Datastore, ancestries []AncestryBlobs) []error {
+	type blobKey struct{ format, hash string }
+
+	var (
+		seen    = map[blobKey]struct{}{}
+		results = map[blobKey]*database.LayerScanResult{}
+		errs    = map[blobKey]error{}
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, layerConcurrency)
+	)
+
+	for _, ancestry := range ancestries {
+		for _, layer := range ancestry.Layers {
+			key := blobKey{ancestry.Format, layer.Hash}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			wg.Add(1)
+			go func(format string, layer LayerBlob) {
+				defer wg.Done()
+
+				var (
+					result *database.LayerScanResult
+					err    error
+				)
+
+				select {
+				case sem <- struct{}{}:
+					result, err = AnalyzeLayer(ctx, store, layer.Hash, format, layer.Path, layer.Headers)
+					<-sem
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				results[key], errs[key] = result, err
+			}(ancestry.Format, layer)
+		}
+	}
+	wg.Wait()
+
+	ancestryErrs := make([]error, len(ancestries))
+	for i, ancestry := range ancestries {
+		scannedLayers := make([]*database.LayerScanResult, 0, len(ancestry.Layers))
+		for _, layer := range ancestry.Layers {
+			key := blobKey{ancestry.Format, layer.Hash}
+			if err := errs[key]; err != nil {
+				ancestryErrs[i] = err
+				break
+			}
+
+			// The results are copied since the post-processors may modify
+			// the layers of the ancestry they process.
+			scannedLayers = append(scannedLayers, copyLayerScanResult(results[key]))
+		}
+
+		if ancestryErrs[i] == nil {
+			ancestryErrs[i] = saveScannedAncestry(store, ancestry.Format, ancestry.Name, ancestry.Layers, scannedLayers)
+		}
+	}
+
+	return ancestryErrs
+}
+
+// copyLayerScanResult returns a copy of the scan result whose layers don't
+// share their detectors, namespaces and features with the original.
+func copyLayerScanResult(result *database.LayerScanResult) *database.LayerScanResult {
+	copyLayer := func(layer *database.Layer) *database.Layer {
+		if layer == nil {
+			return nil
+		}
+
+		return &database.Layer{
+			Hash:       layer.Hash,
+			By:         append([]database.Detector(nil), layer.By...),
+			Namespaces: append([]database.LayerNamespace(nil), layer.Namespaces...),
+			Features:   append([]database.LayerFeature(nil), layer.Features...),
+		}
+	}
+
+	return &database.LayerScanResult{
+		ExistingLayer:      copyLayer(result.ExistingLayer),
+		NewScanResultLayer: copyLayer(result.NewScanResultLayer),
+	}
+}
+
+// saveScannedAncestry post-processes the scanned layers of an ancestry, saves
+// their changes and the resulting ancestry, and its source when the worker is
+// configured to store layer sources.
+func saveScannedAncestry(store database.Datastore, blobFormat string, name string, layers []LayerBlob, scannedLayers []*database.LayerScanResult) error {
+	scannedLayers, err := imgpostprocessor.PostProcessImage(scannedLayers)
+	if err != nil {
+		log.WithError(err).WithField("ancestry.Name", name).Error("failed to post-process ancestry layers")
+		return err
+	}
+
+	builder := NewAncestryBuilder(EnabledDetectors())
+	for _, scannedLayer := range scannedLayers {
+		var layer *database.Layer
+		if scannedLayer.NewScanResultLayer != nil {
+			if err := SaveLayerChange(store, scannedLayer.NewScanResultLayer); err != nil {
+				log.WithFields(log.Fields{
+					"layer.Hash": scannedLayer.NewScanResultLayer.Hash,
+				}).WithError(err).Error("failed to store layer change")
+				return err
+			}
+
+			layer = database.MergeLayers(scannedLayer.ExistingLayer, scannedLayer.NewScanResultLayer)
+		} else {
+			layer = scannedLayer.ExistingLayer
+		}
+		builder.AddLeafLayer(layer)
+	}
+
+	if err := SaveAncestry(store, builder.Ancestry(name)); err != nil {
+		return err
+	}
+
+	if !storeLayerSources {
+		return nil
+	}
+
+	source := database.AncestrySource{Name: name, Format: blobFormat}
+	for _, layer := range layers {
+		source.Layers = append(source.Layers, database.LayerSource{
+			Hash:    layer.Hash,
+			Path:    layer.Path,
+			Headers: layer.Headers,
+		})
+	}
+
+	if err := database.UpsertAncestrySourceAndCommit(store, source); err != nil {
+		log.WithError(err).WithField("ancestry.Name", name).Error("failed to store ancestry source")
+		return StorageError
+	}
+
+	return nil
+}
+
 // IsAncestryCached checks if the ancestry is already cached in the database with the current set of detectors.
 func IsAncestryCached(store database.Datastore, name string, layerHashes []string) (bool, error) {
 	if name == "" {
@@ -16,16 +16,24 @@ package api // import "github.com/quay/clair/v3/api"
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"net/http"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/quay/clair/v3"
 	v3 "github.com/quay/clair/v3/api/v3"
 	"github.com/quay/clair/v3/database"
-	"github.com/quay/clair/v3/pkg/stopper"
+	"github.com/quay/clair/v3/pkg/grpcutil"
+	"google.golang.org/grpc"
 )
 
+// shutdownTimeout is how long requests in progress are waited for when
+// shutting down.
+const shutdownTimeout = 10 * time.Second
+
 const timeoutResponse = `{"Error":{"Message":"Clair failed to respond within the configured timeout window.","Type":"Timeout"}}`
 
 // Config is the configuration for the API service.
@@ -34,18 +42,144 @@ type Config struct {
 	HealthAddr                string
 	Timeout                   time.Duration
 	CertFile, KeyFile, CAFile string
+
+	// ClientAuth is how the client certificates are verified against the CA
+	// file: "none", "verify-if-given" or "require". It is "require" when it
+	// is empty and there is a CA file, and "none" otherwise.
+	ClientAuth string
+
+	// Authorization restricts the gRPC methods the clients may call, by the
+	// common name of their certificate or their bearer token. Every method
+	// is allowed to every client when it is empty.
+	Authorization []grpcutil.AuthorizationRule
+
+	// TLSMinVersion is the lowest TLS version negotiated by the API, from
+	// "1.0" to "1.3", which is "1.2" when it is empty.
+	TLSMinVersion string
+
+	// TLSCipherSuites are the cipher suites negotiated by the API up to TLS
+	// 1.2, which are grpcutil.DefaultCipherSuites when there are none.
+	TLSCipherSuites []string
+
+	// UpdaterToken is the bearer token required to trigger the updaters
+	// through the API, which is disabled when it is empty.
+	UpdaterToken string
+
+	// HealthUpdaterMaxAge is how old the last update can be before the gRPC
+	// health service reports the updater service as not serving. The
+	// freshness of the vulnerabilities is not checked when it is zero.
+	HealthUpdaterMaxAge time.Duration
+
+	// Reflection enables the gRPC server reflection service.
+	Reflection bool
+
+	// StatisticsCacheTTL is how long the statistics served by the API are
+	// cached, since computing them scans the database. It is five minutes
+	// when it is 0.
+	StatisticsCacheTTL time.Duration
+
+	// Keepalive is the keepalive configuration of the gRPC server.
+	Keepalive grpcutil.KeepaliveConfig
+
+	// Limits limit the calls of PostAncestry, PostAncestries and
+	// RescanAncestry, by their number processed at once and their rate per
+	// client.
+	Limits grpcutil.LimitConfig
+
+	// MaxRecvMsgSize and MaxSendMsgSize are the sizes in bytes of the largest
+	// gRPC messages received and sent by the API, the gateway included. The
+	// gRPC defaults, 4MB received and 2GB sent, are used when they are 0.
+	MaxRecvMsgSize, MaxSendMsgSize int
 }
 
-func Run(cfg *Config, store database.Datastore) {
-	err := v3.ListenAndServe(cfg.Addr, cfg.CertFile, cfg.KeyFile, cfg.CAFile, store)
+// TLSConfig returns the TLS configuration of the API, which is only used when
+// a certificate file is configured.
+func (cfg *Config) TLSConfig() (*tls.Config, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.TLSMinVersion != "" {
+		var err error
+		if minVersion, err = grpcutil.ParseTLSVersion(cfg.TLSMinVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	cipherSuites, err := grpcutil.ParseCipherSuites(cfg.TLSCipherSuites)
 	if err != nil {
-		log.WithError(err).Fatal("could not initialize gRPC server")
+		return nil, err
+	}
+
+	clientAuth := tls.NoClientCert
+	if cfg.ClientAuth != "" {
+		if clientAuth, err = grpcutil.ParseClientAuth(cfg.ClientAuth); err != nil {
+			return nil, err
+		}
+	} else if cfg.CAFile != "" {
+		clientAuth = tls.RequireAndVerifyClientCert
+	}
+	if clientAuth != tls.NoClientCert && cfg.CAFile == "" {
+		return nil, errors.New("a CA file is required to verify the client certificates")
+	}
+
+	tlsConfig := grpcutil.NewTLSConfig(minVersion, cipherSuites)
+	tlsConfig.ClientAuth = clientAuth
+	return tlsConfig, nil
+}
+
+// messageSizeOptions returns the options of the gRPC server and of the
+// gateway's connection to it applying the maximum message sizes.
+func (cfg *Config) messageSizeOptions() ([]grpc.ServerOption, []grpc.DialOption) {
+	var (
+		serverOpts []grpc.ServerOption
+		callOpts   []grpc.CallOption
+	)
+
+	if cfg.MaxRecvMsgSize > 0 {
+		serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(cfg.MaxRecvMsgSize))
+	}
+	if cfg.MaxSendMsgSize > 0 {
+		serverOpts = append(serverOpts, grpc.MaxSendMsgSize(cfg.MaxSendMsgSize))
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(cfg.MaxSendMsgSize))
 	}
+
+	if len(callOpts) == 0 {
+		return serverOpts, nil
+	}
+
+	return serverOpts, []grpc.DialOption{grpc.WithDefaultCallOptions(callOpts...)}
 }
 
-func RunHealth(cfg *Config, store database.Datastore, st *stopper.Stopper) {
-	defer st.End()
+// Run serves the v3 API until the context is done. The updaters triggered
+// through the API are run by the jobs.
+func Run(ctx context.Context, cfg *Config, store database.Datastore, jobs *clair.UpdaterJobs) {
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		log.WithError(err).Fatal("invalid TLS configuration")
+	}
+
+	authorizer, err := grpcutil.NewAuthorizer(cfg.Authorization)
+	if err != nil {
+		log.WithError(err).Fatal("invalid authorization configuration")
+	}
+
+	serverOpts, dialOpts := cfg.messageSizeOptions()
+	err = v3.ListenAndServe(ctx, cfg.Addr, cfg.CertFile, cfg.KeyFile, cfg.CAFile, tlsConfig, authorizer, store, jobs, cfg.UpdaterToken, v3.Options{
+		UpdaterMaxAge:      cfg.HealthUpdaterMaxAge,
+		Reflection:         cfg.Reflection,
+		StatisticsCacheTTL: cfg.StatisticsCacheTTL,
+		ServerOptions:      append(cfg.Keepalive.ServerOptions(), serverOpts...),
+		GatewayOptions:     dialOpts,
+		Limits:             cfg.Limits,
+	})
+	if err != nil {
+		log.WithError(err).Fatal("could not initialize gRPC server")
+	}
+
+	log.Info("API stopped")
+}
 
+// RunHealth serves the health API until the context is done.
+func RunHealth(ctx context.Context, cfg *Config, store database.Datastore) {
 	// Do not run the API service if there is no config.
 	if cfg == nil {
 		log.Info("health API service is disabled.")
@@ -59,8 +193,10 @@ func RunHealth(cfg *Config, store database.Datastore, st *stopper.Stopper) {
 	}
 
 	go func() {
-		<-st.Chan()
-		srv.Shutdown(context.TODO())
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
 	}()
 
 	err := srv.ListenAndServe()
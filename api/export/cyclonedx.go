@@ -0,0 +1,306 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+	"github.com/quay/clair/v3/database"
+)
+
+const (
+	cycloneDXFormat      = "CycloneDX"
+	cycloneDXSpecVersion = "1.5"
+
+	// CycloneDXContentType is the media type of CycloneDX JSON documents.
+	CycloneDXContentType = "application/vnd.cyclonedx+json"
+)
+
+type cdxBOM struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+}
+
+type cdxMetadata struct {
+	Tools     cdxTools     `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref,omitempty"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version,omitempty"`
+	PURL       string        `json:"purl,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxVulnerability struct {
+	BOMRef         string       `json:"bom-ref"`
+	ID             string       `json:"id"`
+	Source         cdxSource    `json:"source"`
+	Ratings        []cdxRating  `json:"ratings"`
+	Description    string       `json:"description,omitempty"`
+	Recommendation string       `json:"recommendation,omitempty"`
+	Analysis       *cdxAnalysis `json:"analysis,omitempty"`
+	Affects        []cdxAffect  `json:"affects"`
+}
+
+type cdxSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cdxRating struct {
+	Source   cdxSource `json:"source"`
+	Score    float64   `json:"score,omitempty"`
+	Severity string    `json:"severity"`
+	Method   string    `json:"method"`
+	Vector   string    `json:"vector,omitempty"`
+}
+
+type cdxAnalysis struct {
+	State  string `json:"state"`
+	Detail string `json:"detail"`
+}
+
+type cdxAffect struct {
+	Ref      string               `json:"ref"`
+	Versions []cdxAffectedVersion `json:"versions"`
+}
+
+type cdxAffectedVersion struct {
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// cdxSeverity maps the severity of a vulnerability to a CycloneDX severity.
+func cdxSeverity(severity string) string {
+	s, err := database.NewSeverity(severity)
+	if err != nil {
+		return "unknown"
+	}
+
+	switch s {
+	case database.Defcon1Severity, database.CriticalSeverity:
+		return "critical"
+	case database.HighSeverity:
+		return "high"
+	case database.MediumSeverity:
+		return "medium"
+	case database.LowSeverity:
+		return "low"
+	case database.NegligibleSeverity:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// cvssSeverity returns the qualitative severity of a CVSS score, with the
+// "critical" rating only defined by CVSSv3.
+func cvssSeverity(score float64, v3 bool) string {
+	switch {
+	case v3 && score >= 9:
+		return "critical"
+	case score >= 7:
+		return "high"
+	case score >= 4:
+		return "medium"
+	case score > 0:
+		return "low"
+	default:
+		return "none"
+	}
+}
+
+// cdxRatings returns the rating of a vulnerability by its source, followed by
+// the NVD CVSS ratings found in its metadata.
+func cdxRatings(vuln *pb.Vulnerability) []cdxRating {
+	ratings := []cdxRating{{
+		Source:   cdxSource{Name: vuln.NamespaceName, URL: vuln.Link},
+		Severity: cdxSeverity(vuln.Severity),
+		Method:   "other",
+	}}
+
+	if vuln.Metadata == "" {
+		return ratings
+	}
+
+	nvd := cdxSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + vuln.Name}
+	v2, v3 := nvdScores(vuln.Metadata)
+	if v3.Score > 0 {
+		method := "CVSSv3"
+		if strings.HasPrefix(v3.Vectors, "CVSS:3.1/") {
+			method = "CVSSv31"
+		}
+
+		ratings = append(ratings, cdxRating{Source: nvd, Score: v3.Score, Severity: cvssSeverity(v3.Score, true), Method: method, Vector: v3.Vectors})
+	}
+
+	if v2.Score > 0 {
+		ratings = append(ratings, cdxRating{Source: nvd, Score: v2.Score, Severity: cvssSeverity(v2.Score, false), Method: "CVSSv2", Vector: v2.Vectors})
+	}
+
+	return ratings
+}
+
+// cdxFinding is a feature affected by a vulnerability.
+type cdxFinding struct {
+	ref     string
+	feature *pb.Feature
+	vuln    *pb.Vulnerability
+}
+
+// newCDXVulnerability returns the vulnerability affecting the features of the
+// findings, described by the first one.
+//
+// The vulnerability is analyzed as not affecting the ancestry when every
+// finding is suppressed, which makes the document a VEX too.
+func newCDXVulnerability(ref string, findings []cdxFinding) cdxVulnerability {
+	vuln := findings[0].vuln
+	v := cdxVulnerability{
+		BOMRef:      ref,
+		ID:          vuln.Name,
+		Source:      cdxSource{Name: vuln.NamespaceName, URL: vuln.Link},
+		Ratings:     cdxRatings(vuln),
+		Description: vuln.Description,
+	}
+
+	var upgrades []string
+	suppressed := true
+	for _, finding := range findings {
+		status := "affected"
+		if finding.vuln.Suppressed {
+			status = "unknown"
+		} else {
+			suppressed = false
+		}
+
+		v.Affects = append(v.Affects, cdxAffect{
+			Ref:      finding.ref,
+			Versions: []cdxAffectedVersion{{Version: finding.feature.Version, Status: status}},
+		})
+
+		if finding.vuln.FixedBy != "" {
+			upgrades = append(upgrades, fmt.Sprintf("%s to %s", finding.feature.Name, finding.vuln.FixedBy))
+		}
+	}
+
+	if len(upgrades) > 0 {
+		sort.Strings(upgrades)
+		v.Recommendation = "Upgrade " + strings.Join(upgrades, ", ") + "."
+	}
+
+	if suppressed {
+		v.Analysis = &cdxAnalysis{State: "not_affected", Detail: "suppressed by a Clair suppression rule"}
+	}
+
+	return v
+}
+
+// CycloneDX renders the ancestry as a CycloneDX 1.5 JSON document.
+//
+// Every feature is a component, in the order of the layers of the ancestry,
+// identified by its purl when its type of package is known. Every
+// vulnerability, sorted by namespace and name, references the components it
+// affects, and is rated by its source and by the NVD CVSS scores found in its
+// metadata.
+func CycloneDX(ancestry *pb.GetAncestryResponse_Ancestry) ([]byte, error) {
+	bom := cdxBOM{
+		BOMFormat:   cycloneDXFormat,
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: cdxMetadata{
+			Tools:     cdxTools{Components: []cdxComponent{{Type: "application", Name: "Clair"}}},
+			Component: cdxComponent{Type: "container", BOMRef: ancestry.Name, Name: ancestry.Name},
+		},
+		Components:      []cdxComponent{},
+		Vulnerabilities: []cdxVulnerability{},
+	}
+
+	refs := make(map[string]struct{})
+	findings := make(map[string][]cdxFinding)
+	for _, layer := range ancestry.Layers {
+		var hash string
+		if layer.Layer != nil {
+			hash = layer.Layer.Hash
+		}
+
+		for _, feature := range layer.DetectedFeatures {
+			var namespace string
+			if feature.Namespace != nil {
+				namespace = feature.Namespace.Name
+			}
+
+			purl := packageURL(feature)
+			ref := purl
+			if ref == "" {
+				ref = namespace + "/" + feature.Name + "@" + feature.Version
+			}
+
+			// A feature is only introduced once, by its first layer.
+			if _, ok := refs[ref]; ok {
+				continue
+			}
+			refs[ref] = struct{}{}
+
+			bom.Components = append(bom.Components, cdxComponent{
+				Type:    "library",
+				BOMRef:  ref,
+				Name:    feature.Name,
+				Version: feature.Version,
+				PURL:    purl,
+				Properties: []cdxProperty{
+					{Name: "clair:namespace", Value: namespace},
+					{Name: "clair:layer", Value: hash},
+				},
+			})
+
+			for _, vuln := range feature.Vulnerabilities {
+				vulnRef := vuln.NamespaceName + "/" + vuln.Name
+				findings[vulnRef] = append(findings[vulnRef], cdxFinding{ref: ref, feature: feature, vuln: vuln})
+			}
+		}
+	}
+
+	vulnRefs := make([]string, 0, len(findings))
+	for ref := range findings {
+		vulnRefs = append(vulnRefs, ref)
+	}
+	sort.Strings(vulnRefs)
+
+	for _, ref := range vulnRefs {
+		bom.Vulnerabilities = append(bom.Vulnerabilities, newCDXVulnerability(ref, findings[ref]))
+	}
+
+	return marshal(bom)
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+)
+
+func TestCycloneDX(t *testing.T) {
+	debian := &pb.Namespace{Name: "debian:9"}
+	critical := &pb.Vulnerability{
+		Name:          "CVE-2019-0001",
+		NamespaceName: "debian:9",
+		Description:   "A buffer overflow in openssl.",
+		Link:          "https://security-tracker.debian.org/tracker/CVE-2019-0001",
+		Severity:      "Critical",
+		Metadata:      `{"NVD":{"CVSSv2":{"Score":7.5,"Vectors":"AV:N/AC:L/Au:N/C:P/I:P/A:P"},"CVSSv3":{"Score":9.8,"Vectors":"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}}}`,
+		FixedBy:       "1.1",
+	}
+
+	ancestry := &pb.GetAncestryResponse_Ancestry{
+		Name: "ancestry",
+		Layers: []*pb.GetAncestryResponse_AncestryLayer{
+			{
+				Layer: &pb.Layer{Hash: "sha256:base"},
+				DetectedFeatures: []*pb.Feature{
+					{Name: "openssl", Namespace: debian, Version: "1.0", VersionFormat: "dpkg", FeatureType: "source", Vulnerabilities: []*pb.Vulnerability{critical}},
+					{Name: "bash", Namespace: debian, Version: "4.4", VersionFormat: "dpkg", FeatureType: "binary"},
+				},
+			},
+			{
+				Layer: &pb.Layer{Hash: "sha256:app"},
+				DetectedFeatures: []*pb.Feature{
+					{Name: "libssl", Namespace: debian, Version: "1.0", VersionFormat: "dpkg", FeatureType: "binary", Vulnerabilities: []*pb.Vulnerability{
+						critical,
+						{
+							Name:          "CVE-2019-0002",
+							NamespaceName: "debian:9",
+							Severity:      "Negligible",
+							FixedBy:       "1.2",
+							Suppressed:    true,
+						},
+					}},
+					// Features whose type of package is unknown have no purl.
+					{Name: "custom", Namespace: &pb.Namespace{Name: "custom:1"}, Version: "1.0", VersionFormat: "custom"},
+				},
+			},
+		},
+	}
+
+	content, err := CycloneDX(ancestry)
+	require.Nil(t, err)
+	assertGolden(t, "ancestry.cdx.json", content)
+
+	// The vulnerabilities reference the components.
+	var bom cdxBOM
+	require.Nil(t, json.Unmarshal(content, &bom))
+	refs := make(map[string]bool)
+	for _, component := range bom.Components {
+		refs[component.BOMRef] = true
+	}
+	for _, vuln := range bom.Vulnerabilities {
+		for _, affect := range vuln.Affects {
+			assert.True(t, refs[affect.Ref], affect.Ref)
+		}
+	}
+}
+
+func TestCycloneDXEmpty(t *testing.T) {
+	content, err := CycloneDX(&pb.GetAncestryResponse_Ancestry{Name: "ancestry"})
+	require.Nil(t, err)
+	assertGolden(t, "empty.cdx.json", content)
+
+	// The components and vulnerabilities are listed even when there are none.
+	assert.True(t, bytes.Contains(content, []byte(`"components": []`)))
+	assert.True(t, bytes.Contains(content, []byte(`"vulnerabilities": []`)))
+}
+
+func TestCVSSSeverity(t *testing.T) {
+	assert.Equal(t, "critical", cvssSeverity(9.8, true))
+	assert.Equal(t, "high", cvssSeverity(9.8, false))
+	assert.Equal(t, "high", cvssSeverity(7, true))
+	assert.Equal(t, "medium", cvssSeverity(5, false))
+	assert.Equal(t, "low", cvssSeverity(0.1, true))
+	assert.Equal(t, "none", cvssSeverity(0, true))
+}
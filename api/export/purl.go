@@ -0,0 +1,139 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/ext/versionfmt/modulerpm"
+	"github.com/quay/clair/v3/ext/versionfmt/rpm"
+)
+
+// purlDistribution is the type and namespace of the purls of the packages of
+// a distribution.
+type purlDistribution struct {
+	typ    string
+	vendor string
+}
+
+// purlDistributions are the distributions of the packages, by the operating
+// system of their namespace, as reported by the namespace detectors.
+var purlDistributions = map[string]purlDistribution{
+	"alpine":   {"apk", "alpine"},
+	"amzn":     {"rpm", "amazon"},
+	"centos":   {"rpm", "centos"},
+	"debian":   {"deb", "debian"},
+	"fedora":   {"rpm", "fedora"},
+	"ol":       {"rpm", "oracle"},
+	"opensuse": {"rpm", "opensuse"},
+	"oracle":   {"rpm", "oracle"},
+	"rhel":     {"rpm", "redhat"},
+	"sles":     {"rpm", "suse"},
+	"ubuntu":   {"deb", "ubuntu"},
+}
+
+// purlTypes are the types of the purls of the packages whose namespace isn't
+// a known distribution, such as the streams of RPM modules, by version
+// format.
+var purlTypes = map[string]string{
+	dpkg.ParserName:      "deb",
+	rpm.ParserName:       "rpm",
+	modulerpm.ParserName: "rpm",
+}
+
+// packageURL returns the purl of a feature, from the distribution of its
+// namespace or, failing that, from its version format. It is empty when the
+// type of the package is unknown.
+func packageURL(feature *pb.Feature) string {
+	var namespace string
+	if feature.Namespace != nil {
+		namespace = feature.Namespace.Name
+	}
+
+	// The namespaces of some updaters are prefixed, e.g. "tenant/oracle:8".
+	namespace = namespace[strings.LastIndex(namespace, "/")+1:]
+	system, release := namespace, ""
+	if i := strings.Index(namespace, ":"); i >= 0 {
+		system, release = namespace[:i], namespace[i+1:]
+	}
+
+	distribution, ok := purlDistributions[system]
+	if !ok {
+		typ, ok := purlTypes[feature.VersionFormat]
+		if !ok {
+			return ""
+		}
+
+		distribution, release = purlDistribution{typ: typ}, ""
+	}
+
+	qualifiers := make(map[string]string)
+	if release != "" {
+		qualifiers["distro"] = system + "-" + strings.TrimPrefix(release, "v")
+	}
+
+	version := feature.Version
+	switch distribution.typ {
+	case "deb":
+		if feature.FeatureType == string(database.SourcePackage) {
+			qualifiers["arch"] = "source"
+		}
+	case "rpm":
+		if feature.FeatureType == string(database.SourcePackage) {
+			qualifiers["arch"] = "src"
+		}
+
+		// The epoch of RPM packages is a qualifier, and the default one is
+		// left out.
+		if i := strings.Index(version, ":"); i >= 0 {
+			if epoch := version[:i]; epoch != "0" {
+				qualifiers["epoch"] = epoch
+			}
+			version = version[i+1:]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("pkg:" + distribution.typ + "/")
+	if distribution.vendor != "" {
+		b.WriteString(distribution.vendor + "/")
+	}
+	b.WriteString(url.PathEscape(feature.Name))
+	if version != "" {
+		b.WriteString("@" + url.PathEscape(version))
+	}
+
+	keys := make([]string, 0, len(qualifiers))
+	for key := range qualifiers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(key + "=" + url.QueryEscape(qualifiers[key]))
+	}
+
+	return b.String()
+}
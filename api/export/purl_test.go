@@ -0,0 +1,66 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+)
+
+func TestPackageURL(t *testing.T) {
+	for _, test := range []struct {
+		namespace     string
+		name          string
+		version       string
+		versionFormat string
+		featureType   string
+		expected      string
+	}{
+		{"debian:10", "openssl", "1.1.1d-0+deb10u3", "dpkg", "binary", "pkg:deb/debian/openssl@1.1.1d-0+deb10u3?distro=debian-10"},
+		{"debian:10", "openssl", "1.1.1d-0+deb10u3", "dpkg", "source", "pkg:deb/debian/openssl@1.1.1d-0+deb10u3?arch=source&distro=debian-10"},
+		{"ubuntu:18.04", "libc6", "2.27-3ubuntu1", "dpkg", "binary", "pkg:deb/ubuntu/libc6@2.27-3ubuntu1?distro=ubuntu-18.04"},
+		{"alpine:v3.10", "musl", "1.1.22-r3", "dpkg", "binary", "pkg:apk/alpine/musl@1.1.22-r3?distro=alpine-3.10"},
+		{"centos:7", "bash", "4.2.46-33.el7", "rpm", "binary", "pkg:rpm/centos/bash@4.2.46-33.el7?distro=centos-7"},
+		{"rhel:8", "bash", "4.4.19-10.el8", "rpm", "source", "pkg:rpm/redhat/bash@4.4.19-10.el8?arch=src&distro=rhel-8"},
+		{"oracle:8", "glibc", "0:2.28-151.0.1.el8", "rpm", "binary", "pkg:rpm/oracle/glibc@2.28-151.0.1.el8?distro=oracle-8"},
+		{"ol:7", "openssl", "1:1.0.2k-19.0.1.el7", "rpm", "binary", "pkg:rpm/oracle/openssl@1.0.2k-19.0.1.el7?distro=ol-7&epoch=1"},
+		{"amzn:2", "curl", "7.61.1-12.amzn2.0.1", "rpm", "binary", "pkg:rpm/amazon/curl@7.61.1-12.amzn2.0.1?distro=amzn-2"},
+		{"fedora:32", "curl", "7.69.1-1.fc32", "rpm", "binary", "pkg:rpm/fedora/curl@7.69.1-1.fc32?distro=fedora-32"},
+		{"opensuse:15.1", "curl", "7.60.0-lp151.5.9.1", "rpm", "binary", "pkg:rpm/opensuse/curl@7.60.0-lp151.5.9.1?distro=opensuse-15.1"},
+		{"sles:15", "curl", "7.60.0-3.23.1", "rpm", "binary", "pkg:rpm/suse/curl@7.60.0-3.23.1?distro=sles-15"},
+		// Prefixed namespaces are identified by their distribution.
+		{"tenant/oracle:8", "glibc", "2.28-151.0.1.el8", "rpm", "binary", "pkg:rpm/oracle/glibc@2.28-151.0.1.el8?distro=oracle-8"},
+		// The streams of RPM modules aren't distributions.
+		{"nodejs:12", "nodejs", "1:12.18.4-2.module+el8.2.0+7827+50aab9f2", "module-rpm", "binary", "pkg:rpm/nodejs@12.18.4-2.module+el8.2.0+7827+50aab9f2?epoch=1"},
+		// Special characters are percent-encoded.
+		{"debian:10", "a b", "1.0", "dpkg", "binary", "pkg:deb/debian/a%20b@1.0?distro=debian-10"},
+		{"unknown:1", "package", "1.0", "unknown", "binary", ""},
+	} {
+		feature := &pb.Feature{
+			Name:          test.name,
+			Namespace:     &pb.Namespace{Name: test.namespace},
+			Version:       test.version,
+			VersionFormat: test.versionFormat,
+			FeatureType:   test.featureType,
+		}
+		assert.Equal(t, test.expected, packageURL(feature), test.namespace+" "+test.name)
+	}
+
+	// Features without a namespace are identified by their version format.
+	assert.Equal(t, "pkg:deb/openssl@1.0", packageURL(&pb.Feature{Name: "openssl", Version: "1.0", VersionFormat: "dpkg"}))
+}
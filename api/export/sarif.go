@@ -0,0 +1,307 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export renders the vulnerabilities of ancestries in the formats
+// ingested by other tools.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+	"github.com/quay/clair/v3/database"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+
+	// SARIFContentType is the media type of SARIF documents.
+	SARIFContentType = "application/sarif+json"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	ShortDescription     sarifMessage        `json:"shortDescription"`
+	FullDescription      *sarifMessage       `json:"fullDescription,omitempty"`
+	HelpURI              string              `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration  `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	Tags     []string `json:"tags"`
+	Severity string   `json:"severity"`
+	// SecuritySeverity is the CVSS score of the vulnerability, as expected
+	// by GitHub code scanning.
+	SecuritySeverity string          `json:"security-severity,omitempty"`
+	Metadata         json.RawMessage `json:"metadata,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID       string                `json:"ruleId"`
+	RuleIndex    int                   `json:"ruleIndex"`
+	Level        string                `json:"level"`
+	Message      sarifMessage          `json:"message"`
+	Locations    []sarifLocation       `json:"locations"`
+	Suppressions []sarifSuppression    `json:"suppressions,omitempty"`
+	Properties   sarifResultProperties `json:"properties"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+type sarifResultProperties struct {
+	Feature   string `json:"feature"`
+	Version   string `json:"version"`
+	Namespace string `json:"namespace,omitempty"`
+	FixedBy   string `json:"fixedBy,omitempty"`
+}
+
+// sarifLevel maps the severity of a vulnerability to the level of a SARIF
+// result.
+func sarifLevel(severity string) string {
+	s, err := database.NewSeverity(severity)
+	if err != nil {
+		s = database.UnknownSeverity
+	}
+
+	switch {
+	case s.Compare(database.HighSeverity) >= 0:
+		return "error"
+	case s.Compare(database.MediumSeverity) >= 0:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// nvdCVSS is a CVSS score of the NVD metadata of a vulnerability.
+type nvdCVSS struct {
+	Score   float64
+	Vectors string
+}
+
+// nvdScores returns the CVSSv2 and CVSSv3 scores found in the metadata of a
+// vulnerability, which are zero when missing.
+func nvdScores(metadata string) (v2, v3 nvdCVSS) {
+	var sources struct {
+		NVD struct {
+			CVSSv2 nvdCVSS
+			CVSSv3 nvdCVSS
+		}
+	}
+	if err := json.Unmarshal([]byte(metadata), &sources); err != nil {
+		return nvdCVSS{}, nvdCVSS{}
+	}
+
+	return sources.NVD.CVSSv2, sources.NVD.CVSSv3
+}
+
+// cvssScore returns the NVD CVSS score found in the metadata of a
+// vulnerability, preferably the v3 one, empty if there is none.
+func cvssScore(metadata string) string {
+	v2, v3 := nvdScores(metadata)
+	score := v3.Score
+	if score == 0 {
+		score = v2.Score
+	}
+
+	if score == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f", score)
+}
+
+// newSARIFRule returns the rule of a vulnerability.
+func newSARIFRule(vuln *pb.Vulnerability) sarifRule {
+	rule := sarifRule{
+		ID:                   vuln.Name,
+		ShortDescription:     sarifMessage{Text: vuln.Name},
+		HelpURI:              vuln.Link,
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(vuln.Severity)},
+		Properties: sarifRuleProperties{
+			Tags:     []string{"security", "vulnerability"},
+			Severity: vuln.Severity,
+		},
+	}
+
+	if vuln.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: vuln.Description}
+	}
+
+	if vuln.Metadata != "" && json.Valid([]byte(vuln.Metadata)) {
+		rule.Properties.Metadata = json.RawMessage(vuln.Metadata)
+		rule.Properties.SecuritySeverity = cvssScore(vuln.Metadata)
+	}
+
+	return rule
+}
+
+// newSARIFResult returns the result of a vulnerability affecting a feature of
+// a layer.
+func newSARIFResult(layer string, feature *pb.Feature, vuln *pb.Vulnerability, ruleIndex int) sarifResult {
+	fixed := "which has no fix yet"
+	if vuln.FixedBy != "" {
+		fixed = "fixed in " + vuln.FixedBy
+	}
+
+	var namespace string
+	if feature.Namespace != nil {
+		namespace = feature.Namespace.Name
+	}
+
+	result := sarifResult{
+		RuleID:    vuln.Name,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(vuln.Severity),
+		Message:   sarifMessage{Text: fmt.Sprintf("%s %s is affected by %s, %s.", feature.Name, feature.Version, vuln.Name, fixed)},
+		Locations: []sarifLocation{{LogicalLocations: []sarifLogicalLocation{{
+			Name:               layer,
+			FullyQualifiedName: layer + "/" + feature.Name,
+			Kind:               "module",
+		}}}},
+		Properties: sarifResultProperties{
+			Feature:   feature.Name,
+			Version:   feature.Version,
+			Namespace: namespace,
+			FixedBy:   vuln.FixedBy,
+		},
+	}
+
+	if vuln.Suppressed {
+		result.Suppressions = []sarifSuppression{{
+			Kind:          "external",
+			Justification: "suppressed by a Clair suppression rule",
+		}}
+	}
+
+	return result
+}
+
+// SARIF renders the vulnerabilities of the ancestry as a SARIF 2.1.0
+// document.
+//
+// Every vulnerability is a rule, sorted by name, and every feature it affects
+// is a result, in the order of the layers of the ancestry, located by the
+// digest of the layer which introduced the feature. The suppressed
+// vulnerabilities are reported as suppressed results.
+func SARIF(ancestry *pb.GetAncestryResponse_Ancestry) ([]byte, error) {
+	// A vulnerability is described by its first occurrence.
+	rules := make(map[string]sarifRule)
+	for _, layer := range ancestry.Layers {
+		for _, feature := range layer.DetectedFeatures {
+			for _, vuln := range feature.Vulnerabilities {
+				if _, ok := rules[vuln.Name]; !ok {
+					rules[vuln.Name] = newSARIFRule(vuln)
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	driver := sarifDriver{
+		Name:           "Clair",
+		InformationURI: "https://github.com/quay/clair",
+		Rules:          make([]sarifRule, 0, len(names)),
+	}
+	ruleIndexes := make(map[string]int, len(names))
+	for i, name := range names {
+		driver.Rules = append(driver.Rules, rules[name])
+		ruleIndexes[name] = i
+	}
+
+	results := []sarifResult{}
+	for _, layer := range ancestry.Layers {
+		var hash string
+		if layer.Layer != nil {
+			hash = layer.Layer.Hash
+		}
+
+		for _, feature := range layer.DetectedFeatures {
+			for _, vuln := range feature.Vulnerabilities {
+				results = append(results, newSARIFResult(hash, feature, vuln, ruleIndexes[vuln.Name]))
+			}
+		}
+	}
+
+	return marshal(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// marshal returns the indented JSON encoding of a document, without escaping
+// the characters of the URLs it holds.
+func marshal(document interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
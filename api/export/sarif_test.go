@@ -0,0 +1,131 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares the content to the golden file, or updates the golden
+// file with the -update flag.
+func assertGolden(t *testing.T, name string, content []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.Nil(t, ioutil.WriteFile(path, content, 0644))
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, string(expected), string(content))
+}
+
+func TestSARIF(t *testing.T) {
+	debian := &pb.Namespace{Name: "debian:9"}
+	critical := &pb.Vulnerability{
+		Name:          "CVE-2019-0001",
+		NamespaceName: "debian:9",
+		Description:   "A buffer overflow in openssl.",
+		Link:          "https://security-tracker.debian.org/tracker/CVE-2019-0001",
+		Severity:      "Critical",
+		Metadata:      `{"NVD":{"CVSSv2":{"Score":7.5,"Vectors":"AV:N/AC:L/Au:N/C:P/I:P/A:P"},"CVSSv3":{"Score":9.8,"Vectors":"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}}}`,
+		FixedBy:       "1.1",
+	}
+
+	ancestry := &pb.GetAncestryResponse_Ancestry{
+		Name: "ancestry",
+		Layers: []*pb.GetAncestryResponse_AncestryLayer{
+			{
+				Layer: &pb.Layer{Hash: "sha256:base"},
+				DetectedFeatures: []*pb.Feature{
+					{Name: "openssl", Namespace: debian, Version: "1.0", Vulnerabilities: []*pb.Vulnerability{
+						critical,
+						{
+							Name:          "CVE-2019-0003",
+							NamespaceName: "debian:9",
+							Severity:      "Medium",
+							Metadata:      `{"NVD":{"CVSSv2":{"Score":5}}}`,
+						},
+					}},
+					// Features which aren't vulnerable have no result.
+					{Name: "bash", Namespace: debian, Version: "4.4"},
+				},
+			},
+			{
+				Layer: &pb.Layer{Hash: "sha256:app"},
+				DetectedFeatures: []*pb.Feature{
+					{Name: "libssl", Namespace: debian, Version: "1.0", Vulnerabilities: []*pb.Vulnerability{
+						critical,
+						{
+							Name:          "CVE-2019-0002",
+							NamespaceName: "debian:9",
+							Severity:      "Negligible",
+							FixedBy:       "1.2",
+							Suppressed:    true,
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	content, err := SARIF(ancestry)
+	require.Nil(t, err)
+	assertGolden(t, "ancestry.sarif", content)
+
+	// The rules are sorted and the results reference them by index.
+	var log sarifLog
+	require.Nil(t, json.Unmarshal(content, &log))
+	require.Len(t, log.Runs, 1)
+	for _, result := range log.Runs[0].Results {
+		assert.Equal(t, result.RuleID, log.Runs[0].Tool.Driver.Rules[result.RuleIndex].ID)
+	}
+}
+
+func TestSARIFEmpty(t *testing.T) {
+	content, err := SARIF(&pb.GetAncestryResponse_Ancestry{Name: "ancestry"})
+	require.Nil(t, err)
+	assertGolden(t, "empty.sarif", content)
+
+	// The rules and results are listed even when there are none.
+	assert.True(t, bytes.Contains(content, []byte(`"rules": []`)))
+	assert.True(t, bytes.Contains(content, []byte(`"results": []`)))
+}
+
+func TestSARIFLevel(t *testing.T) {
+	for severity, level := range map[string]string{
+		"Defcon1":    "error",
+		"Critical":   "error",
+		"High":       "error",
+		"Medium":     "warning",
+		"Low":        "note",
+		"Negligible": "note",
+		"Unknown":    "note",
+		"":           "note",
+	} {
+		assert.Equal(t, level, sarifLevel(severity), severity)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/quay/clair/v3/database"
 )
@@ -29,6 +30,7 @@ type router map[string]*httprouter.Router
 func newHealthHandler(store database.Datastore) http.Handler {
 	router := httprouter.New()
 	router.GET("/health", healthHandler(store))
+	router.Handler(http.MethodGet, "/metrics", promhttp.Handler())
 	return router
 }
 
@@ -18,13 +18,62 @@ It has these top-level messages:
 	GetAncestryResponse
 	PostAncestryRequest
 	PostAncestryResponse
+	PostAncestriesRequest
+	PostAncestriesResponse
+	DeleteAncestryRequest
+	DeleteAncestryResponse
+	RescanAncestryRequest
+	RescanAncestryResponse
+	IndexSBOMRequest
+	IndexSBOMResponse
 	GetNotificationRequest
 	GetNotificationResponse
 	PagedVulnerableAncestries
+	ListNotificationsRequest
+	ListNotificationsResponse
 	MarkNotificationAsReadRequest
 	MarkNotificationAsReadResponse
+	DeadLetterNotification
+	ListDeadLetterNotificationsRequest
+	ListDeadLetterNotificationsResponse
+	RetryDeadLetterNotificationRequest
+	RetryDeadLetterNotificationResponse
 	GetStatusRequest
 	GetStatusResponse
+	UpdaterRun
+	Updater
+	ListUpdatersRequest
+	ListUpdatersResponse
+	UpdaterJob
+	TriggerUpdaterRequest
+	TriggerUpdaterResponse
+	GetUpdaterJobRequest
+	GetUpdaterJobResponse
+	NamespaceCoverage
+	ListNamespacesRequest
+	ListNamespacesResponse
+	VulnerabilityCount
+	UpdaterFreshness
+	GetStatisticsRequest
+	GetStatisticsResponse
+	SuppressionRule
+	CreateSuppressionRuleRequest
+	CreateSuppressionRuleResponse
+	GetSuppressionRuleRequest
+	GetSuppressionRuleResponse
+	ListSuppressionRulesRequest
+	ListSuppressionRulesResponse
+	UpdateSuppressionRuleRequest
+	UpdateSuppressionRuleResponse
+	DeleteSuppressionRuleRequest
+	DeleteSuppressionRuleResponse
+	GetVulnerabilityRequest
+	GetVulnerabilityResponse
+	ListVulnerabilitiesRequest
+	ListVulnerabilitiesResponse
+	AffectedAncestry
+	GetAffectedAncestriesRequest
+	GetAffectedAncestriesResponse
 */
 package clairpb
 
@@ -91,9 +140,18 @@ type Vulnerability struct {
 	// The feature that fixes this vulnerability.
 	// This field only exists when a vulnerability is a part of a Feature.
 	FixedBy string `protobuf:"bytes,7,opt,name=fixed_by,json=fixedBy" json:"fixed_by,omitempty"`
-	// The Features that are affected by the vulnerability.
-	// This field only exists when a vulnerability is a part of a Notification.
+	// The Features that are affected by the vulnerability, whose versions are
+	// the versions fixing the vulnerability.
+	// This field only exists when a vulnerability is requested by name or
+	// listed.
 	AffectedVersions []*Feature `protobuf:"bytes,8,rep,name=affected_versions,json=affectedVersions" json:"affected_versions,omitempty"`
+	// Whether the findings of the vulnerability are suppressed.
+	// This field only exists when a vulnerability is a part of a Feature.
+	Suppressed bool `protobuf:"varint,9,opt,name=suppressed" json:"suppressed,omitempty"`
+	// The names of the vulnerabilities related to this one, such as the
+	// advisory which fixes it and the other vulnerabilities fixed by the same
+	// advisory.
+	Aliases []string `protobuf:"bytes,10,rep,name=aliases" json:"aliases,omitempty"`
 }
 
 func (m *Vulnerability) Reset()                    { *m = Vulnerability{} }
@@ -157,6 +215,20 @@ func (m *Vulnerability) GetAffectedVersions() []*Feature {
 	return nil
 }
 
+func (m *Vulnerability) GetSuppressed() bool {
+	if m != nil {
+		return m.Suppressed
+	}
+	return false
+}
+
+func (m *Vulnerability) GetAliases() []string {
+	if m != nil {
+		return m.Aliases
+	}
+	return nil
+}
+
 type Detector struct {
 	// The name of the detector.
 	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
@@ -338,6 +410,20 @@ func (m *ClairStatus) GetLastUpdateTime() *google_protobuf.Timestamp {
 type GetAncestryRequest struct {
 	// The name of the desired ancestry.
 	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// Whether the suppressed vulnerabilities are listed, marked as such,
+	// rather than omitted.
+	IncludeSuppressed bool `protobuf:"varint,2,opt,name=include_suppressed,json=includeSuppressed" json:"include_suppressed,omitempty"`
+	// The lowest severity of the listed vulnerabilities, which are all listed
+	// when empty. From lowest to highest, the severities are Unknown,
+	// Negligible, Low, Medium, High, Critical and Defcon1.
+	MinimumSeverity string `protobuf:"bytes,3,opt,name=minimum_severity,json=minimumSeverity" json:"minimum_severity,omitempty"`
+	// Whether only the vulnerabilities fixed in a known version are listed.
+	FixedOnly bool `protobuf:"varint,4,opt,name=fixed_only,json=fixedOnly" json:"fixed_only,omitempty"`
+	// The maximum number of features listed, across the layers. Every feature
+	// is listed at once when it is 0.
+	Limit int32 `protobuf:"varint,5,opt,name=limit" json:"limit,omitempty"`
+	// The page of features to list, the first one when empty.
+	Page string `protobuf:"bytes,6,opt,name=page" json:"page,omitempty"`
 }
 
 func (m *GetAncestryRequest) Reset()                    { *m = GetAncestryRequest{} }
@@ -352,11 +438,53 @@ func (m *GetAncestryRequest) GetAncestryName() string {
 	return ""
 }
 
+func (m *GetAncestryRequest) GetIncludeSuppressed() bool {
+	if m != nil {
+		return m.IncludeSuppressed
+	}
+	return false
+}
+
+func (m *GetAncestryRequest) GetMinimumSeverity() string {
+	if m != nil {
+		return m.MinimumSeverity
+	}
+	return ""
+}
+
+func (m *GetAncestryRequest) GetFixedOnly() bool {
+	if m != nil {
+		return m.FixedOnly
+	}
+	return false
+}
+
+func (m *GetAncestryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *GetAncestryRequest) GetPage() string {
+	if m != nil {
+		return m.Page
+	}
+	return ""
+}
+
 type GetAncestryResponse struct {
-	// The ancestry requested.
+	// The ancestry requested. When the features are paginated, every layer is
+	// listed with the features of the page only, in a deterministic order.
 	Ancestry *GetAncestryResponse_Ancestry `protobuf:"bytes,1,opt,name=ancestry" json:"ancestry,omitempty"`
 	// The status of Clair at the time of the request
 	Status *ClairStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// The page of features listed.
+	CurrentPage string `protobuf:"bytes,3,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+	// The next page of features, which is empty on the last page.
+	NextPage string `protobuf:"bytes,4,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The maximum number of features listed.
+	Limit int32 `protobuf:"varint,5,opt,name=limit" json:"limit,omitempty"`
 }
 
 func (m *GetAncestryResponse) Reset()                    { *m = GetAncestryResponse{} }
@@ -378,6 +506,27 @@ func (m *GetAncestryResponse) GetStatus() *ClairStatus {
 	return nil
 }
 
+func (m *GetAncestryResponse) GetCurrentPage() string {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return ""
+}
+
+func (m *GetAncestryResponse) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
+}
+
+func (m *GetAncestryResponse) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
 type GetAncestryResponse_AncestryLayer struct {
 	// The layer's information.
 	Layer *Layer `protobuf:"bytes,1,opt,name=layer" json:"layer,omitempty"`
@@ -524,6 +673,255 @@ func (m *PostAncestryResponse) GetStatus() *ClairStatus {
 	return nil
 }
 
+type PostAncestriesRequest struct {
+	// The ancestries to be scanned, whose names must be given and unique.
+	Ancestries []*PostAncestryRequest `protobuf:"bytes,1,rep,name=ancestries" json:"ancestries,omitempty"`
+}
+
+func (m *PostAncestriesRequest) Reset()                    { *m = PostAncestriesRequest{} }
+func (m *PostAncestriesRequest) String() string            { return proto.CompactTextString(m) }
+func (*PostAncestriesRequest) ProtoMessage()               {}
+func (*PostAncestriesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *PostAncestriesRequest) GetAncestries() []*PostAncestryRequest {
+	if m != nil {
+		return m.Ancestries
+	}
+	return nil
+}
+
+type PostAncestriesResponse struct {
+	// The statuses of the ancestries, in the order of the request.
+	Ancestries []*PostAncestriesResponse_AncestryStatus `protobuf:"bytes,1,rep,name=ancestries" json:"ancestries,omitempty"`
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *PostAncestriesResponse) Reset()                    { *m = PostAncestriesResponse{} }
+func (m *PostAncestriesResponse) String() string            { return proto.CompactTextString(m) }
+func (*PostAncestriesResponse) ProtoMessage()               {}
+func (*PostAncestriesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *PostAncestriesResponse) GetAncestries() []*PostAncestriesResponse_AncestryStatus {
+	if m != nil {
+		return m.Ancestries
+	}
+	return nil
+}
+
+func (m *PostAncestriesResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type PostAncestriesResponse_AncestryStatus struct {
+	// The name of the ancestry.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The gRPC status code of its scan, 0 (OK) when it was scanned.
+	Code int32 `protobuf:"varint,2,opt,name=code" json:"code,omitempty"`
+	// Why its scan failed, if it did.
+	Message string `protobuf:"bytes,3,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *PostAncestriesResponse_AncestryStatus) Reset()         { *m = PostAncestriesResponse_AncestryStatus{} }
+func (m *PostAncestriesResponse_AncestryStatus) String() string { return proto.CompactTextString(m) }
+func (*PostAncestriesResponse_AncestryStatus) ProtoMessage()    {}
+func (*PostAncestriesResponse_AncestryStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{11, 0}
+}
+
+func (m *PostAncestriesResponse_AncestryStatus) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+func (m *PostAncestriesResponse_AncestryStatus) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *PostAncestriesResponse_AncestryStatus) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type DeleteAncestryRequest struct {
+	// The name of the ancestry to delete.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+}
+
+func (m *DeleteAncestryRequest) Reset()                    { *m = DeleteAncestryRequest{} }
+func (m *DeleteAncestryRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteAncestryRequest) ProtoMessage()               {}
+func (*DeleteAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *DeleteAncestryRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+type DeleteAncestryResponse struct {
+}
+
+func (m *DeleteAncestryResponse) Reset()                    { *m = DeleteAncestryResponse{} }
+func (m *DeleteAncestryResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteAncestryResponse) ProtoMessage()               {}
+func (*DeleteAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+type RescanAncestryRequest struct {
+	// The name of the ancestry to rescan.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+}
+
+func (m *RescanAncestryRequest) Reset()                    { *m = RescanAncestryRequest{} }
+func (m *RescanAncestryRequest) String() string            { return proto.CompactTextString(m) }
+func (*RescanAncestryRequest) ProtoMessage()               {}
+func (*RescanAncestryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *RescanAncestryRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+type RescanAncestryResponse struct {
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *RescanAncestryResponse) Reset()                    { *m = RescanAncestryResponse{} }
+func (m *RescanAncestryResponse) String() string            { return proto.CompactTextString(m) }
+func (*RescanAncestryResponse) ProtoMessage()               {}
+func (*RescanAncestryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *RescanAncestryResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type IndexSBOMRequest struct {
+	// The name of the ancestry being indexed, which replaces any ancestry of
+	// the same name.
+	AncestryName string `protobuf:"bytes,1,opt,name=ancestry_name,json=ancestryName" json:"ancestry_name,omitempty"`
+	// The CycloneDX or SPDX JSON document listing the components of the
+	// ancestry.
+	Sbom string `protobuf:"bytes,2,opt,name=sbom" json:"sbom,omitempty"`
+}
+
+func (m *IndexSBOMRequest) Reset()                    { *m = IndexSBOMRequest{} }
+func (m *IndexSBOMRequest) String() string            { return proto.CompactTextString(m) }
+func (*IndexSBOMRequest) ProtoMessage()               {}
+func (*IndexSBOMRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *IndexSBOMRequest) GetAncestryName() string {
+	if m != nil {
+		return m.AncestryName
+	}
+	return ""
+}
+
+func (m *IndexSBOMRequest) GetSbom() string {
+	if m != nil {
+		return m.Sbom
+	}
+	return ""
+}
+
+type IndexSBOMResponse struct {
+	// The ancestry indexed, as returned by GetAncestry.
+	Ancestry *GetAncestryResponse_Ancestry `protobuf:"bytes,1,opt,name=ancestry" json:"ancestry,omitempty"`
+	// The status of Clair at the time of the request.
+	Status *ClairStatus `protobuf:"bytes,2,opt,name=status" json:"status,omitempty"`
+	// The components which couldn't be mapped to the features of a known
+	// distribution, and thus aren't matched against vulnerabilities.
+	Unmatched []*IndexSBOMResponse_UnmatchedComponent `protobuf:"bytes,3,rep,name=unmatched" json:"unmatched,omitempty"`
+}
+
+func (m *IndexSBOMResponse) Reset()                    { *m = IndexSBOMResponse{} }
+func (m *IndexSBOMResponse) String() string            { return proto.CompactTextString(m) }
+func (*IndexSBOMResponse) ProtoMessage()               {}
+func (*IndexSBOMResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *IndexSBOMResponse) GetAncestry() *GetAncestryResponse_Ancestry {
+	if m != nil {
+		return m.Ancestry
+	}
+	return nil
+}
+
+func (m *IndexSBOMResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func (m *IndexSBOMResponse) GetUnmatched() []*IndexSBOMResponse_UnmatchedComponent {
+	if m != nil {
+		return m.Unmatched
+	}
+	return nil
+}
+
+type IndexSBOMResponse_UnmatchedComponent struct {
+	// The name of the component.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The version of the component.
+	Version string `protobuf:"bytes,2,opt,name=version" json:"version,omitempty"`
+	// The package URL of the component, if any.
+	Purl string `protobuf:"bytes,3,opt,name=purl" json:"purl,omitempty"`
+	// The reason the component isn't matched against vulnerabilities.
+	Reason string `protobuf:"bytes,4,opt,name=reason" json:"reason,omitempty"`
+}
+
+func (m *IndexSBOMResponse_UnmatchedComponent) Reset()         { *m = IndexSBOMResponse_UnmatchedComponent{} }
+func (m *IndexSBOMResponse_UnmatchedComponent) String() string { return proto.CompactTextString(m) }
+func (*IndexSBOMResponse_UnmatchedComponent) ProtoMessage()    {}
+func (*IndexSBOMResponse_UnmatchedComponent) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{17, 0}
+}
+
+func (m *IndexSBOMResponse_UnmatchedComponent) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *IndexSBOMResponse_UnmatchedComponent) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *IndexSBOMResponse_UnmatchedComponent) GetPurl() string {
+	if m != nil {
+		return m.Purl
+	}
+	return ""
+}
+
+func (m *IndexSBOMResponse_UnmatchedComponent) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
 type GetNotificationRequest struct {
 	// The current page of previous vulnerabilities for the ancestry.
 	// This will be empty when it is the first page.
@@ -540,7 +938,7 @@ type GetNotificationRequest struct {
 func (m *GetNotificationRequest) Reset()                    { *m = GetNotificationRequest{} }
 func (m *GetNotificationRequest) String() string            { return proto.CompactTextString(m) }
 func (*GetNotificationRequest) ProtoMessage()               {}
-func (*GetNotificationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+func (*GetNotificationRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 func (m *GetNotificationRequest) GetOldVulnerabilityPage() string {
 	if m != nil {
@@ -578,7 +976,7 @@ type GetNotificationResponse struct {
 func (m *GetNotificationResponse) Reset()                    { *m = GetNotificationResponse{} }
 func (m *GetNotificationResponse) String() string            { return proto.CompactTextString(m) }
 func (*GetNotificationResponse) ProtoMessage()               {}
-func (*GetNotificationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*GetNotificationResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
 func (m *GetNotificationResponse) GetNotification() *GetNotificationResponse_Notification {
 	if m != nil {
@@ -608,7 +1006,7 @@ func (m *GetNotificationResponse_Notification) Reset()         { *m = GetNotific
 func (m *GetNotificationResponse_Notification) String() string { return proto.CompactTextString(m) }
 func (*GetNotificationResponse_Notification) ProtoMessage()    {}
 func (*GetNotificationResponse_Notification) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{11, 0}
+	return fileDescriptor0, []int{19, 0}
 }
 
 func (m *GetNotificationResponse_Notification) GetName() string {
@@ -670,7 +1068,7 @@ type PagedVulnerableAncestries struct {
 func (m *PagedVulnerableAncestries) Reset()                    { *m = PagedVulnerableAncestries{} }
 func (m *PagedVulnerableAncestries) String() string            { return proto.CompactTextString(m) }
 func (*PagedVulnerableAncestries) ProtoMessage()               {}
-func (*PagedVulnerableAncestries) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*PagedVulnerableAncestries) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *PagedVulnerableAncestries) GetCurrentPage() string {
 	if m != nil {
@@ -724,7 +1122,7 @@ func (m *PagedVulnerableAncestries_IndexedAncestryName) String() string {
 }
 func (*PagedVulnerableAncestries_IndexedAncestryName) ProtoMessage() {}
 func (*PagedVulnerableAncestries_IndexedAncestryName) Descriptor() ([]byte, []int) {
-	return fileDescriptor0, []int{12, 0}
+	return fileDescriptor0, []int{20, 0}
 }
 
 func (m *PagedVulnerableAncestries_IndexedAncestryName) GetIndex() int32 {
@@ -741,61 +1139,1315 @@ func (m *PagedVulnerableAncestries_IndexedAncestryName) GetName() string {
 	return ""
 }
 
-type MarkNotificationAsReadRequest struct {
-	// The name of the Notification that has been processed.
-	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+type ListNotificationsRequest struct {
+	// The token of the requested page.
+	// This will be empty when it is the first page.
+	Page string `protobuf:"bytes,1,opt,name=page" json:"page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,2,opt,name=limit" json:"limit,omitempty"`
+	// The state of the listed notifications: "pending" when they are neither
+	// notified nor deleted, "delivered" when they are notified but not
+	// deleted, and "expired" when they are deleted, which they are once marked
+	// as read. Every notification is listed when empty.
+	State string `protobuf:"bytes,3,opt,name=state" json:"state,omitempty"`
 }
 
-func (m *MarkNotificationAsReadRequest) Reset()                    { *m = MarkNotificationAsReadRequest{} }
-func (m *MarkNotificationAsReadRequest) String() string            { return proto.CompactTextString(m) }
-func (*MarkNotificationAsReadRequest) ProtoMessage()               {}
-func (*MarkNotificationAsReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (m *ListNotificationsRequest) Reset()                    { *m = ListNotificationsRequest{} }
+func (m *ListNotificationsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListNotificationsRequest) ProtoMessage()               {}
+func (*ListNotificationsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
 
-func (m *MarkNotificationAsReadRequest) GetName() string {
+func (m *ListNotificationsRequest) GetPage() string {
 	if m != nil {
-		return m.Name
+		return m.Page
 	}
 	return ""
 }
 
-type MarkNotificationAsReadResponse struct {
+func (m *ListNotificationsRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
 }
 
-func (m *MarkNotificationAsReadResponse) Reset()                    { *m = MarkNotificationAsReadResponse{} }
-func (m *MarkNotificationAsReadResponse) String() string            { return proto.CompactTextString(m) }
-func (*MarkNotificationAsReadResponse) ProtoMessage()               {}
-func (*MarkNotificationAsReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (m *ListNotificationsRequest) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
 
-type GetStatusRequest struct {
+type ListNotificationsResponse struct {
+	// The notifications of the page.
+	Notifications []*ListNotificationsResponse_Notification `protobuf:"bytes,1,rep,name=notifications" json:"notifications,omitempty"`
+	// The identifier for the current page.
+	CurrentPage string `protobuf:"bytes,2,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+	// The token used to request the next page.
+	// This will be empty when there are no more pages.
+	NextPage string `protobuf:"bytes,3,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
 }
 
-func (m *GetStatusRequest) Reset()                    { *m = GetStatusRequest{} }
-func (m *GetStatusRequest) String() string            { return proto.CompactTextString(m) }
-func (*GetStatusRequest) ProtoMessage()               {}
-func (*GetStatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (m *ListNotificationsResponse) Reset()                    { *m = ListNotificationsResponse{} }
+func (m *ListNotificationsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListNotificationsResponse) ProtoMessage()               {}
+func (*ListNotificationsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
 
-type GetStatusResponse struct {
-	// The status of the current Clair instance.
-	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+func (m *ListNotificationsResponse) GetNotifications() []*ListNotificationsResponse_Notification {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
 }
 
-func (m *GetStatusResponse) Reset()                    { *m = GetStatusResponse{} }
-func (m *GetStatusResponse) String() string            { return proto.CompactTextString(m) }
-func (*GetStatusResponse) ProtoMessage()               {}
-func (*GetStatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (m *ListNotificationsResponse) GetCurrentPage() string {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return ""
+}
 
-func (m *GetStatusResponse) GetStatus() *ClairStatus {
+func (m *ListNotificationsResponse) GetNextPage() string {
 	if m != nil {
-		return m.Status
+		return m.NextPage
 	}
-	return nil
+	return ""
 }
 
-func init() {
-	proto.RegisterType((*Vulnerability)(nil), "coreos.clair.Vulnerability")
-	proto.RegisterType((*Detector)(nil), "coreos.clair.Detector")
-	proto.RegisterType((*Namespace)(nil), "coreos.clair.Namespace")
-	proto.RegisterType((*Feature)(nil), "coreos.clair.Feature")
+func (m *ListNotificationsResponse) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ListNotificationsResponse_Notification struct {
+	// The name of the notification.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The time at which the notification was created.
+	Created string `protobuf:"bytes,2,opt,name=created" json:"created,omitempty"`
+	// The time at which the notification was last sent out.
+	Notified string `protobuf:"bytes,3,opt,name=notified" json:"notified,omitempty"`
+	// The time at which a notification has been deleted.
+	Deleted string `protobuf:"bytes,4,opt,name=deleted" json:"deleted,omitempty"`
+	// The state of the notification.
+	State string `protobuf:"bytes,5,opt,name=state" json:"state,omitempty"`
+	// The name of the vulnerability whose change is notified, the new one or
+	// the old one when it was removed.
+	VulnerabilityName string `protobuf:"bytes,6,opt,name=vulnerability_name,json=vulnerabilityName" json:"vulnerability_name,omitempty"`
+	// The name of the namespace of the vulnerability.
+	NamespaceName string `protobuf:"bytes,7,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+}
+
+func (m *ListNotificationsResponse_Notification) Reset() {
+	*m = ListNotificationsResponse_Notification{}
+}
+func (m *ListNotificationsResponse_Notification) String() string { return proto.CompactTextString(m) }
+func (*ListNotificationsResponse_Notification) ProtoMessage()    {}
+func (*ListNotificationsResponse_Notification) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{22, 0}
+}
+
+func (m *ListNotificationsResponse_Notification) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetNotified() string {
+	if m != nil {
+		return m.Notified
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetDeleted() string {
+	if m != nil {
+		return m.Deleted
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetVulnerabilityName() string {
+	if m != nil {
+		return m.VulnerabilityName
+	}
+	return ""
+}
+
+func (m *ListNotificationsResponse_Notification) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+type MarkNotificationAsReadRequest struct {
+	// The name of the Notification that has been processed.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *MarkNotificationAsReadRequest) Reset()                    { *m = MarkNotificationAsReadRequest{} }
+func (m *MarkNotificationAsReadRequest) String() string            { return proto.CompactTextString(m) }
+func (*MarkNotificationAsReadRequest) ProtoMessage()               {}
+func (*MarkNotificationAsReadRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *MarkNotificationAsReadRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type MarkNotificationAsReadResponse struct {
+}
+
+func (m *MarkNotificationAsReadResponse) Reset()                    { *m = MarkNotificationAsReadResponse{} }
+func (m *MarkNotificationAsReadResponse) String() string            { return proto.CompactTextString(m) }
+func (*MarkNotificationAsReadResponse) ProtoMessage()               {}
+func (*MarkNotificationAsReadResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+type DeadLetterNotification struct {
+	// The name of the Notification that failed to be sent.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The serialized Notification.
+	Payload string `protobuf:"bytes,2,opt,name=payload" json:"payload,omitempty"`
+	// The error of the last attempt to send the Notification.
+	LastError string `protobuf:"bytes,3,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+	// The time at which the Notification was dead-lettered.
+	Created string `protobuf:"bytes,4,opt,name=created" json:"created,omitempty"`
+}
+
+func (m *DeadLetterNotification) Reset()                    { *m = DeadLetterNotification{} }
+func (m *DeadLetterNotification) String() string            { return proto.CompactTextString(m) }
+func (*DeadLetterNotification) ProtoMessage()               {}
+func (*DeadLetterNotification) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *DeadLetterNotification) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DeadLetterNotification) GetPayload() string {
+	if m != nil {
+		return m.Payload
+	}
+	return ""
+}
+
+func (m *DeadLetterNotification) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+func (m *DeadLetterNotification) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+type ListDeadLetterNotificationsRequest struct {
+}
+
+func (m *ListDeadLetterNotificationsRequest) Reset()         { *m = ListDeadLetterNotificationsRequest{} }
+func (m *ListDeadLetterNotificationsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListDeadLetterNotificationsRequest) ProtoMessage()    {}
+func (*ListDeadLetterNotificationsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{26}
+}
+
+type ListDeadLetterNotificationsResponse struct {
+	// The Notifications that failed to be sent.
+	Notifications []*DeadLetterNotification `protobuf:"bytes,1,rep,name=notifications" json:"notifications,omitempty"`
+}
+
+func (m *ListDeadLetterNotificationsResponse) Reset()         { *m = ListDeadLetterNotificationsResponse{} }
+func (m *ListDeadLetterNotificationsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListDeadLetterNotificationsResponse) ProtoMessage()    {}
+func (*ListDeadLetterNotificationsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{27}
+}
+
+func (m *ListDeadLetterNotificationsResponse) GetNotifications() []*DeadLetterNotification {
+	if m != nil {
+		return m.Notifications
+	}
+	return nil
+}
+
+type RetryDeadLetterNotificationRequest struct {
+	// The name of the dead-lettered Notification to send again.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *RetryDeadLetterNotificationRequest) Reset()         { *m = RetryDeadLetterNotificationRequest{} }
+func (m *RetryDeadLetterNotificationRequest) String() string { return proto.CompactTextString(m) }
+func (*RetryDeadLetterNotificationRequest) ProtoMessage()    {}
+func (*RetryDeadLetterNotificationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{28}
+}
+
+func (m *RetryDeadLetterNotificationRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type RetryDeadLetterNotificationResponse struct {
+}
+
+func (m *RetryDeadLetterNotificationResponse) Reset()         { *m = RetryDeadLetterNotificationResponse{} }
+func (m *RetryDeadLetterNotificationResponse) String() string { return proto.CompactTextString(m) }
+func (*RetryDeadLetterNotificationResponse) ProtoMessage()    {}
+func (*RetryDeadLetterNotificationResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{29}
+}
+
+type GetStatusRequest struct {
+}
+
+func (m *GetStatusRequest) Reset()                    { *m = GetStatusRequest{} }
+func (m *GetStatusRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetStatusRequest) ProtoMessage()               {}
+func (*GetStatusRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+type GetStatusResponse struct {
+	// The status of the current Clair instance.
+	Status *ClairStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *GetStatusResponse) Reset()                    { *m = GetStatusResponse{} }
+func (m *GetStatusResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetStatusResponse) ProtoMessage()               {}
+func (*GetStatusResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{31} }
+
+func (m *GetStatusResponse) GetStatus() *ClairStatus {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type UpdaterRun struct {
+	// The time at which the run started.
+	Started string `protobuf:"bytes,1,opt,name=started" json:"started,omitempty"`
+	// The time at which the run finished.
+	Finished string `protobuf:"bytes,2,opt,name=finished" json:"finished,omitempty"`
+	// The number of vulnerabilities fetched by the run.
+	Vulnerabilities int32 `protobuf:"varint,3,opt,name=vulnerabilities" json:"vulnerabilities,omitempty"`
+	// The error the run failed with, empty when it succeeded.
+	Error string `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *UpdaterRun) Reset()                    { *m = UpdaterRun{} }
+func (m *UpdaterRun) String() string            { return proto.CompactTextString(m) }
+func (*UpdaterRun) ProtoMessage()               {}
+func (*UpdaterRun) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{32} }
+
+func (m *UpdaterRun) GetStarted() string {
+	if m != nil {
+		return m.Started
+	}
+	return ""
+}
+
+func (m *UpdaterRun) GetFinished() string {
+	if m != nil {
+		return m.Finished
+	}
+	return ""
+}
+
+func (m *UpdaterRun) GetVulnerabilities() int32 {
+	if m != nil {
+		return m.Vulnerabilities
+	}
+	return 0
+}
+
+func (m *UpdaterRun) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type Updater struct {
+	// The name of the updater.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The namespaces covered by the updater, empty if the updater cannot
+	// enumerate them.
+	Namespaces []string `protobuf:"bytes,2,rep,name=namespaces" json:"namespaces,omitempty"`
+	// The latest runs of the updater, the most recent first.
+	Runs []*UpdaterRun `protobuf:"bytes,3,rep,name=runs" json:"runs,omitempty"`
+}
+
+func (m *Updater) Reset()                    { *m = Updater{} }
+func (m *Updater) String() string            { return proto.CompactTextString(m) }
+func (*Updater) ProtoMessage()               {}
+func (*Updater) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{33} }
+
+func (m *Updater) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Updater) GetNamespaces() []string {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func (m *Updater) GetRuns() []*UpdaterRun {
+	if m != nil {
+		return m.Runs
+	}
+	return nil
+}
+
+type ListUpdatersRequest struct {
+	// The maximum number of runs listed for every updater, 10 when not
+	// positive.
+	RunLimit int32 `protobuf:"varint,1,opt,name=run_limit,json=runLimit" json:"run_limit,omitempty"`
+}
+
+func (m *ListUpdatersRequest) Reset()                    { *m = ListUpdatersRequest{} }
+func (m *ListUpdatersRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListUpdatersRequest) ProtoMessage()               {}
+func (*ListUpdatersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{34} }
+
+func (m *ListUpdatersRequest) GetRunLimit() int32 {
+	if m != nil {
+		return m.RunLimit
+	}
+	return 0
+}
+
+type ListUpdatersResponse struct {
+	// The updaters enabled in the current Clair instance.
+	Updaters []*Updater `protobuf:"bytes,1,rep,name=updaters" json:"updaters,omitempty"`
+}
+
+func (m *ListUpdatersResponse) Reset()                    { *m = ListUpdatersResponse{} }
+func (m *ListUpdatersResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListUpdatersResponse) ProtoMessage()               {}
+func (*ListUpdatersResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{35} }
+
+func (m *ListUpdatersResponse) GetUpdaters() []*Updater {
+	if m != nil {
+		return m.Updaters
+	}
+	return nil
+}
+
+type UpdaterJob struct {
+	// The identifier of the job.
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	// The name of the updater run by the job.
+	Updater string `protobuf:"bytes,2,opt,name=updater" json:"updater,omitempty"`
+	// The status of the job: running, succeeded or failed.
+	Status string `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+	// The time at which the job started.
+	Started string `protobuf:"bytes,4,opt,name=started" json:"started,omitempty"`
+	// The time at which the job finished, empty while it is running.
+	Finished string `protobuf:"bytes,5,opt,name=finished" json:"finished,omitempty"`
+	// The run of the updater, once it is done fetching.
+	Run *UpdaterRun `protobuf:"bytes,6,opt,name=run" json:"run,omitempty"`
+	// The error the job failed with, which may come from storing the updates
+	// after the updater succeeded.
+	Error string `protobuf:"bytes,7,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *UpdaterJob) Reset()                    { *m = UpdaterJob{} }
+func (m *UpdaterJob) String() string            { return proto.CompactTextString(m) }
+func (*UpdaterJob) ProtoMessage()               {}
+func (*UpdaterJob) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{36} }
+
+func (m *UpdaterJob) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *UpdaterJob) GetUpdater() string {
+	if m != nil {
+		return m.Updater
+	}
+	return ""
+}
+
+func (m *UpdaterJob) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *UpdaterJob) GetStarted() string {
+	if m != nil {
+		return m.Started
+	}
+	return ""
+}
+
+func (m *UpdaterJob) GetFinished() string {
+	if m != nil {
+		return m.Finished
+	}
+	return ""
+}
+
+func (m *UpdaterJob) GetRun() *UpdaterRun {
+	if m != nil {
+		return m.Run
+	}
+	return nil
+}
+
+func (m *UpdaterJob) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+type TriggerUpdaterRequest struct {
+	// The name of the updater to run.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *TriggerUpdaterRequest) Reset()                    { *m = TriggerUpdaterRequest{} }
+func (m *TriggerUpdaterRequest) String() string            { return proto.CompactTextString(m) }
+func (*TriggerUpdaterRequest) ProtoMessage()               {}
+func (*TriggerUpdaterRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{37} }
+
+func (m *TriggerUpdaterRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type TriggerUpdaterResponse struct {
+	// The job running the updater.
+	Job *UpdaterJob `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+}
+
+func (m *TriggerUpdaterResponse) Reset()                    { *m = TriggerUpdaterResponse{} }
+func (m *TriggerUpdaterResponse) String() string            { return proto.CompactTextString(m) }
+func (*TriggerUpdaterResponse) ProtoMessage()               {}
+func (*TriggerUpdaterResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{38} }
+
+func (m *TriggerUpdaterResponse) GetJob() *UpdaterJob {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type GetUpdaterJobRequest struct {
+	// The name of the updater run by the job.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The identifier of the job.
+	Id string `protobuf:"bytes,2,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GetUpdaterJobRequest) Reset()                    { *m = GetUpdaterJobRequest{} }
+func (m *GetUpdaterJobRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdaterJobRequest) ProtoMessage()               {}
+func (*GetUpdaterJobRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{39} }
+
+func (m *GetUpdaterJobRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GetUpdaterJobRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type GetUpdaterJobResponse struct {
+	// The job as requested.
+	Job *UpdaterJob `protobuf:"bytes,1,opt,name=job" json:"job,omitempty"`
+}
+
+func (m *GetUpdaterJobResponse) Reset()                    { *m = GetUpdaterJobResponse{} }
+func (m *GetUpdaterJobResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetUpdaterJobResponse) ProtoMessage()               {}
+func (*GetUpdaterJobResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{40} }
+
+func (m *GetUpdaterJobResponse) GetJob() *UpdaterJob {
+	if m != nil {
+		return m.Job
+	}
+	return nil
+}
+
+type NamespaceCoverage struct {
+	// The name of the namespace.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The format used to parse version numbers in the namespace.
+	VersionFormat string `protobuf:"bytes,2,opt,name=version_format,json=versionFormat" json:"version_format,omitempty"`
+	// The number of vulnerabilities of the namespace.
+	VulnerabilityCount int64 `protobuf:"varint,3,opt,name=vulnerability_count,json=vulnerabilityCount" json:"vulnerability_count,omitempty"`
+}
+
+func (m *NamespaceCoverage) Reset()                    { *m = NamespaceCoverage{} }
+func (m *NamespaceCoverage) String() string            { return proto.CompactTextString(m) }
+func (*NamespaceCoverage) ProtoMessage()               {}
+func (*NamespaceCoverage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{41} }
+
+func (m *NamespaceCoverage) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *NamespaceCoverage) GetVersionFormat() string {
+	if m != nil {
+		return m.VersionFormat
+	}
+	return ""
+}
+
+func (m *NamespaceCoverage) GetVulnerabilityCount() int64 {
+	if m != nil {
+		return m.VulnerabilityCount
+	}
+	return 0
+}
+
+type ListNamespacesRequest struct {
+}
+
+func (m *ListNamespacesRequest) Reset()                    { *m = ListNamespacesRequest{} }
+func (m *ListNamespacesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListNamespacesRequest) ProtoMessage()               {}
+func (*ListNamespacesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{42} }
+
+type ListNamespacesResponse struct {
+	// The namespaces stored in the database, ordered by name.
+	Namespaces []*NamespaceCoverage `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty"`
+	// The namespace and feature detectors enabled in the current Clair
+	// instance.
+	Detectors []*Detector `protobuf:"bytes,2,rep,name=detectors" json:"detectors,omitempty"`
+	// The time at which the namespaces were counted. The response is cached for
+	// a few minutes.
+	Refreshed string `protobuf:"bytes,3,opt,name=refreshed" json:"refreshed,omitempty"`
+}
+
+func (m *ListNamespacesResponse) Reset()                    { *m = ListNamespacesResponse{} }
+func (m *ListNamespacesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListNamespacesResponse) ProtoMessage()               {}
+func (*ListNamespacesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{43} }
+
+func (m *ListNamespacesResponse) GetNamespaces() []*NamespaceCoverage {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func (m *ListNamespacesResponse) GetDetectors() []*Detector {
+	if m != nil {
+		return m.Detectors
+	}
+	return nil
+}
+
+func (m *ListNamespacesResponse) GetRefreshed() string {
+	if m != nil {
+		return m.Refreshed
+	}
+	return ""
+}
+
+type VulnerabilityCount struct {
+	// The name of the namespace.
+	NamespaceName string `protobuf:"bytes,1,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The format used to parse version numbers in the namespace.
+	VersionFormat string `protobuf:"bytes,2,opt,name=version_format,json=versionFormat" json:"version_format,omitempty"`
+	// The severity of the vulnerabilities.
+	Severity string `protobuf:"bytes,3,opt,name=severity" json:"severity,omitempty"`
+	// The number of vulnerabilities of the namespace with the severity.
+	Count int64 `protobuf:"varint,4,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *VulnerabilityCount) Reset()                    { *m = VulnerabilityCount{} }
+func (m *VulnerabilityCount) String() string            { return proto.CompactTextString(m) }
+func (*VulnerabilityCount) ProtoMessage()               {}
+func (*VulnerabilityCount) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{44} }
+
+func (m *VulnerabilityCount) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *VulnerabilityCount) GetVersionFormat() string {
+	if m != nil {
+		return m.VersionFormat
+	}
+	return ""
+}
+
+func (m *VulnerabilityCount) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *VulnerabilityCount) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type UpdaterFreshness struct {
+	// The name of the updater.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The time at which the latest run of the updater finished, empty when it
+	// never ran.
+	LastRun string `protobuf:"bytes,2,opt,name=last_run,json=lastRun" json:"last_run,omitempty"`
+	// The time at which the latest successful run of the updater finished,
+	// among its recorded runs, empty when none succeeded.
+	LastSuccess string `protobuf:"bytes,3,opt,name=last_success,json=lastSuccess" json:"last_success,omitempty"`
+	// The error the latest run failed with, empty when it succeeded.
+	LastError string `protobuf:"bytes,4,opt,name=last_error,json=lastError" json:"last_error,omitempty"`
+}
+
+func (m *UpdaterFreshness) Reset()                    { *m = UpdaterFreshness{} }
+func (m *UpdaterFreshness) String() string            { return proto.CompactTextString(m) }
+func (*UpdaterFreshness) ProtoMessage()               {}
+func (*UpdaterFreshness) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{45} }
+
+func (m *UpdaterFreshness) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *UpdaterFreshness) GetLastRun() string {
+	if m != nil {
+		return m.LastRun
+	}
+	return ""
+}
+
+func (m *UpdaterFreshness) GetLastSuccess() string {
+	if m != nil {
+		return m.LastSuccess
+	}
+	return ""
+}
+
+func (m *UpdaterFreshness) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+type GetStatisticsRequest struct {
+}
+
+func (m *GetStatisticsRequest) Reset()                    { *m = GetStatisticsRequest{} }
+func (m *GetStatisticsRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetStatisticsRequest) ProtoMessage()               {}
+func (*GetStatisticsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{46} }
+
+type GetStatisticsResponse struct {
+	// The number of vulnerabilities per namespace and severity, ordered by
+	// namespace name and from the lowest severity to the highest. The
+	// severities without vulnerabilities are omitted.
+	Vulnerabilities []*VulnerabilityCount `protobuf:"bytes,1,rep,name=vulnerabilities" json:"vulnerabilities,omitempty"`
+	// The number of ancestries stored in the database.
+	AncestryCount int64 `protobuf:"varint,2,opt,name=ancestry_count,json=ancestryCount" json:"ancestry_count,omitempty"`
+	// The number of layers stored in the database.
+	LayerCount int64 `protobuf:"varint,3,opt,name=layer_count,json=layerCount" json:"layer_count,omitempty"`
+	// The number of features stored in the database, per namespace.
+	FeatureCount int64 `protobuf:"varint,4,opt,name=feature_count,json=featureCount" json:"feature_count,omitempty"`
+	// The freshness of the enabled vulnerability updaters, ordered by name.
+	Updaters []*UpdaterFreshness `protobuf:"bytes,5,rep,name=updaters" json:"updaters,omitempty"`
+	// The time at which the vulnerabilities were last updated, empty when they
+	// never were.
+	LastUpdate string `protobuf:"bytes,6,opt,name=last_update,json=lastUpdate" json:"last_update,omitempty"`
+	// The time at which the statistics were computed. The response is cached
+	// for a configurable period.
+	Refreshed string `protobuf:"bytes,7,opt,name=refreshed" json:"refreshed,omitempty"`
+}
+
+func (m *GetStatisticsResponse) Reset()                    { *m = GetStatisticsResponse{} }
+func (m *GetStatisticsResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetStatisticsResponse) ProtoMessage()               {}
+func (*GetStatisticsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{47} }
+
+func (m *GetStatisticsResponse) GetVulnerabilities() []*VulnerabilityCount {
+	if m != nil {
+		return m.Vulnerabilities
+	}
+	return nil
+}
+
+func (m *GetStatisticsResponse) GetAncestryCount() int64 {
+	if m != nil {
+		return m.AncestryCount
+	}
+	return 0
+}
+
+func (m *GetStatisticsResponse) GetLayerCount() int64 {
+	if m != nil {
+		return m.LayerCount
+	}
+	return 0
+}
+
+func (m *GetStatisticsResponse) GetFeatureCount() int64 {
+	if m != nil {
+		return m.FeatureCount
+	}
+	return 0
+}
+
+func (m *GetStatisticsResponse) GetUpdaters() []*UpdaterFreshness {
+	if m != nil {
+		return m.Updaters
+	}
+	return nil
+}
+
+func (m *GetStatisticsResponse) GetLastUpdate() string {
+	if m != nil {
+		return m.LastUpdate
+	}
+	return ""
+}
+
+func (m *GetStatisticsResponse) GetRefreshed() string {
+	if m != nil {
+		return m.Refreshed
+	}
+	return ""
+}
+
+type SuppressionRule struct {
+	// The identifier of the rule, assigned when it is created.
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// The name of the suppressed vulnerability.
+	VulnerabilityName string `protobuf:"bytes,2,opt,name=vulnerability_name,json=vulnerabilityName" json:"vulnerability_name,omitempty"`
+	// The name of the namespace the rule is restricted to. The rule applies to
+	// every namespace when it is empty.
+	NamespaceName string `protobuf:"bytes,3,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The name of the feature the rule is restricted to. The rule applies to
+	// every feature when it is empty.
+	FeatureName string `protobuf:"bytes,4,opt,name=feature_name,json=featureName" json:"feature_name,omitempty"`
+	// Why the findings are suppressed.
+	Reason string `protobuf:"bytes,5,opt,name=reason" json:"reason,omitempty"`
+	// The time at which the rule was created.
+	Created string `protobuf:"bytes,6,opt,name=created" json:"created,omitempty"`
+	// The time at which the rule stops applying. The rule never expires when
+	// it is empty.
+	Expires string `protobuf:"bytes,7,opt,name=expires" json:"expires,omitempty"`
+}
+
+func (m *SuppressionRule) Reset()                    { *m = SuppressionRule{} }
+func (m *SuppressionRule) String() string            { return proto.CompactTextString(m) }
+func (*SuppressionRule) ProtoMessage()               {}
+func (*SuppressionRule) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{48} }
+
+func (m *SuppressionRule) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *SuppressionRule) GetVulnerabilityName() string {
+	if m != nil {
+		return m.VulnerabilityName
+	}
+	return ""
+}
+
+func (m *SuppressionRule) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *SuppressionRule) GetFeatureName() string {
+	if m != nil {
+		return m.FeatureName
+	}
+	return ""
+}
+
+func (m *SuppressionRule) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *SuppressionRule) GetCreated() string {
+	if m != nil {
+		return m.Created
+	}
+	return ""
+}
+
+func (m *SuppressionRule) GetExpires() string {
+	if m != nil {
+		return m.Expires
+	}
+	return ""
+}
+
+type CreateSuppressionRuleRequest struct {
+	// The rule to create, whose id and creation time are ignored.
+	Rule *SuppressionRule `protobuf:"bytes,1,opt,name=rule" json:"rule,omitempty"`
+}
+
+func (m *CreateSuppressionRuleRequest) Reset()                    { *m = CreateSuppressionRuleRequest{} }
+func (m *CreateSuppressionRuleRequest) String() string            { return proto.CompactTextString(m) }
+func (*CreateSuppressionRuleRequest) ProtoMessage()               {}
+func (*CreateSuppressionRuleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{49} }
+
+func (m *CreateSuppressionRuleRequest) GetRule() *SuppressionRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type CreateSuppressionRuleResponse struct {
+	// The created rule.
+	Rule *SuppressionRule `protobuf:"bytes,1,opt,name=rule" json:"rule,omitempty"`
+}
+
+func (m *CreateSuppressionRuleResponse) Reset()                    { *m = CreateSuppressionRuleResponse{} }
+func (m *CreateSuppressionRuleResponse) String() string            { return proto.CompactTextString(m) }
+func (*CreateSuppressionRuleResponse) ProtoMessage()               {}
+func (*CreateSuppressionRuleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{50} }
+
+func (m *CreateSuppressionRuleResponse) GetRule() *SuppressionRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type GetSuppressionRuleRequest struct {
+	// The identifier of the rule.
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *GetSuppressionRuleRequest) Reset()                    { *m = GetSuppressionRuleRequest{} }
+func (m *GetSuppressionRuleRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetSuppressionRuleRequest) ProtoMessage()               {}
+func (*GetSuppressionRuleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{51} }
+
+func (m *GetSuppressionRuleRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type GetSuppressionRuleResponse struct {
+	// The rule as requested.
+	Rule *SuppressionRule `protobuf:"bytes,1,opt,name=rule" json:"rule,omitempty"`
+}
+
+func (m *GetSuppressionRuleResponse) Reset()                    { *m = GetSuppressionRuleResponse{} }
+func (m *GetSuppressionRuleResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetSuppressionRuleResponse) ProtoMessage()               {}
+func (*GetSuppressionRuleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{52} }
+
+func (m *GetSuppressionRuleResponse) GetRule() *SuppressionRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type ListSuppressionRulesRequest struct {
+}
+
+func (m *ListSuppressionRulesRequest) Reset()                    { *m = ListSuppressionRulesRequest{} }
+func (m *ListSuppressionRulesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListSuppressionRulesRequest) ProtoMessage()               {}
+func (*ListSuppressionRulesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{53} }
+
+type ListSuppressionRulesResponse struct {
+	// The suppression rules, ordered by id.
+	Rules []*SuppressionRule `protobuf:"bytes,1,rep,name=rules" json:"rules,omitempty"`
+}
+
+func (m *ListSuppressionRulesResponse) Reset()                    { *m = ListSuppressionRulesResponse{} }
+func (m *ListSuppressionRulesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListSuppressionRulesResponse) ProtoMessage()               {}
+func (*ListSuppressionRulesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{54} }
+
+func (m *ListSuppressionRulesResponse) GetRules() []*SuppressionRule {
+	if m != nil {
+		return m.Rules
+	}
+	return nil
+}
+
+type UpdateSuppressionRuleRequest struct {
+	// The identifier of the rule to replace.
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	// The replacing rule, whose id and creation time are ignored.
+	Rule *SuppressionRule `protobuf:"bytes,2,opt,name=rule" json:"rule,omitempty"`
+}
+
+func (m *UpdateSuppressionRuleRequest) Reset()                    { *m = UpdateSuppressionRuleRequest{} }
+func (m *UpdateSuppressionRuleRequest) String() string            { return proto.CompactTextString(m) }
+func (*UpdateSuppressionRuleRequest) ProtoMessage()               {}
+func (*UpdateSuppressionRuleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{55} }
+
+func (m *UpdateSuppressionRuleRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *UpdateSuppressionRuleRequest) GetRule() *SuppressionRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type UpdateSuppressionRuleResponse struct {
+	// The replaced rule.
+	Rule *SuppressionRule `protobuf:"bytes,1,opt,name=rule" json:"rule,omitempty"`
+}
+
+func (m *UpdateSuppressionRuleResponse) Reset()                    { *m = UpdateSuppressionRuleResponse{} }
+func (m *UpdateSuppressionRuleResponse) String() string            { return proto.CompactTextString(m) }
+func (*UpdateSuppressionRuleResponse) ProtoMessage()               {}
+func (*UpdateSuppressionRuleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{56} }
+
+func (m *UpdateSuppressionRuleResponse) GetRule() *SuppressionRule {
+	if m != nil {
+		return m.Rule
+	}
+	return nil
+}
+
+type DeleteSuppressionRuleRequest struct {
+	// The identifier of the rule to delete.
+	Id int64 `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *DeleteSuppressionRuleRequest) Reset()                    { *m = DeleteSuppressionRuleRequest{} }
+func (m *DeleteSuppressionRuleRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteSuppressionRuleRequest) ProtoMessage()               {}
+func (*DeleteSuppressionRuleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{57} }
+
+func (m *DeleteSuppressionRuleRequest) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+type DeleteSuppressionRuleResponse struct {
+}
+
+func (m *DeleteSuppressionRuleResponse) Reset()                    { *m = DeleteSuppressionRuleResponse{} }
+func (m *DeleteSuppressionRuleResponse) String() string            { return proto.CompactTextString(m) }
+func (*DeleteSuppressionRuleResponse) ProtoMessage()               {}
+func (*DeleteSuppressionRuleResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{58} }
+
+type GetVulnerabilityRequest struct {
+	// The name of the namespace of the vulnerability.
+	NamespaceName string `protobuf:"bytes,1,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The name of the vulnerability.
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *GetVulnerabilityRequest) Reset()                    { *m = GetVulnerabilityRequest{} }
+func (m *GetVulnerabilityRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetVulnerabilityRequest) ProtoMessage()               {}
+func (*GetVulnerabilityRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{59} }
+
+func (m *GetVulnerabilityRequest) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *GetVulnerabilityRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GetVulnerabilityResponse struct {
+	// The vulnerability as requested.
+	Vulnerability *Vulnerability `protobuf:"bytes,1,opt,name=vulnerability" json:"vulnerability,omitempty"`
+}
+
+func (m *GetVulnerabilityResponse) Reset()                    { *m = GetVulnerabilityResponse{} }
+func (m *GetVulnerabilityResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetVulnerabilityResponse) ProtoMessage()               {}
+func (*GetVulnerabilityResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{60} }
+
+func (m *GetVulnerabilityResponse) GetVulnerability() *Vulnerability {
+	if m != nil {
+		return m.Vulnerability
+	}
+	return nil
+}
+
+type ListVulnerabilitiesRequest struct {
+	// The name of the namespace of the vulnerabilities.
+	NamespaceName string `protobuf:"bytes,1,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The token of the requested page.
+	// This will be empty when it is the first page.
+	Page string `protobuf:"bytes,2,opt,name=page" json:"page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *ListVulnerabilitiesRequest) Reset()                    { *m = ListVulnerabilitiesRequest{} }
+func (m *ListVulnerabilitiesRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListVulnerabilitiesRequest) ProtoMessage()               {}
+func (*ListVulnerabilitiesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{61} }
+
+func (m *ListVulnerabilitiesRequest) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *ListVulnerabilitiesRequest) GetPage() string {
+	if m != nil {
+		return m.Page
+	}
+	return ""
+}
+
+func (m *ListVulnerabilitiesRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type ListVulnerabilitiesResponse struct {
+	// The vulnerabilities of the page.
+	Vulnerabilities []*Vulnerability `protobuf:"bytes,1,rep,name=vulnerabilities" json:"vulnerabilities,omitempty"`
+	// The identifier for the current page.
+	CurrentPage string `protobuf:"bytes,2,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+	// The token used to request the next page.
+	// This will be empty when there are no more pages.
+	NextPage string `protobuf:"bytes,3,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *ListVulnerabilitiesResponse) Reset()                    { *m = ListVulnerabilitiesResponse{} }
+func (m *ListVulnerabilitiesResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListVulnerabilitiesResponse) ProtoMessage()               {}
+func (*ListVulnerabilitiesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{62} }
+
+func (m *ListVulnerabilitiesResponse) GetVulnerabilities() []*Vulnerability {
+	if m != nil {
+		return m.Vulnerabilities
+	}
+	return nil
+}
+
+func (m *ListVulnerabilitiesResponse) GetCurrentPage() string {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return ""
+}
+
+func (m *ListVulnerabilitiesResponse) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
+}
+
+func (m *ListVulnerabilitiesResponse) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type AffectedAncestry struct {
+	// The name of the ancestry.
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// The features of the ancestry affected by the vulnerability.
+	Features []*Feature `protobuf:"bytes,2,rep,name=features" json:"features,omitempty"`
+}
+
+func (m *AffectedAncestry) Reset()                    { *m = AffectedAncestry{} }
+func (m *AffectedAncestry) String() string            { return proto.CompactTextString(m) }
+func (*AffectedAncestry) ProtoMessage()               {}
+func (*AffectedAncestry) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{63} }
+
+func (m *AffectedAncestry) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AffectedAncestry) GetFeatures() []*Feature {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
+type GetAffectedAncestriesRequest struct {
+	// The name of the namespace of the vulnerability.
+	NamespaceName string `protobuf:"bytes,1,opt,name=namespace_name,json=namespaceName" json:"namespace_name,omitempty"`
+	// The name of the vulnerability.
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	// The token of the requested page.
+	// This will be empty when it is the first page.
+	Page string `protobuf:"bytes,3,opt,name=page" json:"page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *GetAffectedAncestriesRequest) Reset()                    { *m = GetAffectedAncestriesRequest{} }
+func (m *GetAffectedAncestriesRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetAffectedAncestriesRequest) ProtoMessage()               {}
+func (*GetAffectedAncestriesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{64} }
+
+func (m *GetAffectedAncestriesRequest) GetNamespaceName() string {
+	if m != nil {
+		return m.NamespaceName
+	}
+	return ""
+}
+
+func (m *GetAffectedAncestriesRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *GetAffectedAncestriesRequest) GetPage() string {
+	if m != nil {
+		return m.Page
+	}
+	return ""
+}
+
+func (m *GetAffectedAncestriesRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type GetAffectedAncestriesResponse struct {
+	// The affected ancestries of the page.
+	Ancestries []*AffectedAncestry `protobuf:"bytes,1,rep,name=ancestries" json:"ancestries,omitempty"`
+	// The identifier for the current page.
+	CurrentPage string `protobuf:"bytes,2,opt,name=current_page,json=currentPage" json:"current_page,omitempty"`
+	// The token used to request the next page.
+	// This will be empty when there are no more pages.
+	NextPage string `protobuf:"bytes,3,opt,name=next_page,json=nextPage" json:"next_page,omitempty"`
+	// The requested maximum number of results per page.
+	Limit int32 `protobuf:"varint,4,opt,name=limit" json:"limit,omitempty"`
+}
+
+func (m *GetAffectedAncestriesResponse) Reset()                    { *m = GetAffectedAncestriesResponse{} }
+func (m *GetAffectedAncestriesResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetAffectedAncestriesResponse) ProtoMessage()               {}
+func (*GetAffectedAncestriesResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{65} }
+
+func (m *GetAffectedAncestriesResponse) GetAncestries() []*AffectedAncestry {
+	if m != nil {
+		return m.Ancestries
+	}
+	return nil
+}
+
+func (m *GetAffectedAncestriesResponse) GetCurrentPage() string {
+	if m != nil {
+		return m.CurrentPage
+	}
+	return ""
+}
+
+func (m *GetAffectedAncestriesResponse) GetNextPage() string {
+	if m != nil {
+		return m.NextPage
+	}
+	return ""
+}
+
+func (m *GetAffectedAncestriesResponse) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*Vulnerability)(nil), "coreos.clair.Vulnerability")
+	proto.RegisterType((*Detector)(nil), "coreos.clair.Detector")
+	proto.RegisterType((*Namespace)(nil), "coreos.clair.Namespace")
+	proto.RegisterType((*Feature)(nil), "coreos.clair.Feature")
 	proto.RegisterType((*Layer)(nil), "coreos.clair.Layer")
 	proto.RegisterType((*ClairStatus)(nil), "coreos.clair.ClairStatus")
 	proto.RegisterType((*GetAncestryRequest)(nil), "coreos.clair.GetAncestryRequest")
@@ -805,288 +2457,1163 @@ func init() {
 	proto.RegisterType((*PostAncestryRequest)(nil), "coreos.clair.PostAncestryRequest")
 	proto.RegisterType((*PostAncestryRequest_PostLayer)(nil), "coreos.clair.PostAncestryRequest.PostLayer")
 	proto.RegisterType((*PostAncestryResponse)(nil), "coreos.clair.PostAncestryResponse")
+	proto.RegisterType((*PostAncestriesRequest)(nil), "coreos.clair.PostAncestriesRequest")
+	proto.RegisterType((*PostAncestriesResponse)(nil), "coreos.clair.PostAncestriesResponse")
+	proto.RegisterType((*PostAncestriesResponse_AncestryStatus)(nil), "coreos.clair.PostAncestriesResponse.AncestryStatus")
+	proto.RegisterType((*DeleteAncestryRequest)(nil), "coreos.clair.DeleteAncestryRequest")
+	proto.RegisterType((*DeleteAncestryResponse)(nil), "coreos.clair.DeleteAncestryResponse")
+	proto.RegisterType((*RescanAncestryRequest)(nil), "coreos.clair.RescanAncestryRequest")
+	proto.RegisterType((*RescanAncestryResponse)(nil), "coreos.clair.RescanAncestryResponse")
+	proto.RegisterType((*IndexSBOMRequest)(nil), "coreos.clair.IndexSBOMRequest")
+	proto.RegisterType((*IndexSBOMResponse)(nil), "coreos.clair.IndexSBOMResponse")
+	proto.RegisterType((*IndexSBOMResponse_UnmatchedComponent)(nil), "coreos.clair.IndexSBOMResponse.UnmatchedComponent")
 	proto.RegisterType((*GetNotificationRequest)(nil), "coreos.clair.GetNotificationRequest")
 	proto.RegisterType((*GetNotificationResponse)(nil), "coreos.clair.GetNotificationResponse")
 	proto.RegisterType((*GetNotificationResponse_Notification)(nil), "coreos.clair.GetNotificationResponse.Notification")
 	proto.RegisterType((*PagedVulnerableAncestries)(nil), "coreos.clair.PagedVulnerableAncestries")
 	proto.RegisterType((*PagedVulnerableAncestries_IndexedAncestryName)(nil), "coreos.clair.PagedVulnerableAncestries.IndexedAncestryName")
+	proto.RegisterType((*ListNotificationsRequest)(nil), "coreos.clair.ListNotificationsRequest")
+	proto.RegisterType((*ListNotificationsResponse)(nil), "coreos.clair.ListNotificationsResponse")
+	proto.RegisterType((*ListNotificationsResponse_Notification)(nil), "coreos.clair.ListNotificationsResponse.Notification")
 	proto.RegisterType((*MarkNotificationAsReadRequest)(nil), "coreos.clair.MarkNotificationAsReadRequest")
 	proto.RegisterType((*MarkNotificationAsReadResponse)(nil), "coreos.clair.MarkNotificationAsReadResponse")
+	proto.RegisterType((*DeadLetterNotification)(nil), "coreos.clair.DeadLetterNotification")
+	proto.RegisterType((*ListDeadLetterNotificationsRequest)(nil), "coreos.clair.ListDeadLetterNotificationsRequest")
+	proto.RegisterType((*ListDeadLetterNotificationsResponse)(nil), "coreos.clair.ListDeadLetterNotificationsResponse")
+	proto.RegisterType((*RetryDeadLetterNotificationRequest)(nil), "coreos.clair.RetryDeadLetterNotificationRequest")
+	proto.RegisterType((*RetryDeadLetterNotificationResponse)(nil), "coreos.clair.RetryDeadLetterNotificationResponse")
 	proto.RegisterType((*GetStatusRequest)(nil), "coreos.clair.GetStatusRequest")
 	proto.RegisterType((*GetStatusResponse)(nil), "coreos.clair.GetStatusResponse")
+	proto.RegisterType((*UpdaterRun)(nil), "coreos.clair.UpdaterRun")
+	proto.RegisterType((*Updater)(nil), "coreos.clair.Updater")
+	proto.RegisterType((*ListUpdatersRequest)(nil), "coreos.clair.ListUpdatersRequest")
+	proto.RegisterType((*ListUpdatersResponse)(nil), "coreos.clair.ListUpdatersResponse")
+	proto.RegisterType((*UpdaterJob)(nil), "coreos.clair.UpdaterJob")
+	proto.RegisterType((*TriggerUpdaterRequest)(nil), "coreos.clair.TriggerUpdaterRequest")
+	proto.RegisterType((*TriggerUpdaterResponse)(nil), "coreos.clair.TriggerUpdaterResponse")
+	proto.RegisterType((*GetUpdaterJobRequest)(nil), "coreos.clair.GetUpdaterJobRequest")
+	proto.RegisterType((*GetUpdaterJobResponse)(nil), "coreos.clair.GetUpdaterJobResponse")
+	proto.RegisterType((*NamespaceCoverage)(nil), "coreos.clair.NamespaceCoverage")
+	proto.RegisterType((*ListNamespacesRequest)(nil), "coreos.clair.ListNamespacesRequest")
+	proto.RegisterType((*ListNamespacesResponse)(nil), "coreos.clair.ListNamespacesResponse")
+	proto.RegisterType((*VulnerabilityCount)(nil), "coreos.clair.VulnerabilityCount")
+	proto.RegisterType((*UpdaterFreshness)(nil), "coreos.clair.UpdaterFreshness")
+	proto.RegisterType((*GetStatisticsRequest)(nil), "coreos.clair.GetStatisticsRequest")
+	proto.RegisterType((*GetStatisticsResponse)(nil), "coreos.clair.GetStatisticsResponse")
+	proto.RegisterType((*SuppressionRule)(nil), "coreos.clair.SuppressionRule")
+	proto.RegisterType((*CreateSuppressionRuleRequest)(nil), "coreos.clair.CreateSuppressionRuleRequest")
+	proto.RegisterType((*CreateSuppressionRuleResponse)(nil), "coreos.clair.CreateSuppressionRuleResponse")
+	proto.RegisterType((*GetSuppressionRuleRequest)(nil), "coreos.clair.GetSuppressionRuleRequest")
+	proto.RegisterType((*GetSuppressionRuleResponse)(nil), "coreos.clair.GetSuppressionRuleResponse")
+	proto.RegisterType((*ListSuppressionRulesRequest)(nil), "coreos.clair.ListSuppressionRulesRequest")
+	proto.RegisterType((*ListSuppressionRulesResponse)(nil), "coreos.clair.ListSuppressionRulesResponse")
+	proto.RegisterType((*UpdateSuppressionRuleRequest)(nil), "coreos.clair.UpdateSuppressionRuleRequest")
+	proto.RegisterType((*UpdateSuppressionRuleResponse)(nil), "coreos.clair.UpdateSuppressionRuleResponse")
+	proto.RegisterType((*DeleteSuppressionRuleRequest)(nil), "coreos.clair.DeleteSuppressionRuleRequest")
+	proto.RegisterType((*DeleteSuppressionRuleResponse)(nil), "coreos.clair.DeleteSuppressionRuleResponse")
+	proto.RegisterType((*GetVulnerabilityRequest)(nil), "coreos.clair.GetVulnerabilityRequest")
+	proto.RegisterType((*GetVulnerabilityResponse)(nil), "coreos.clair.GetVulnerabilityResponse")
+	proto.RegisterType((*ListVulnerabilitiesRequest)(nil), "coreos.clair.ListVulnerabilitiesRequest")
+	proto.RegisterType((*ListVulnerabilitiesResponse)(nil), "coreos.clair.ListVulnerabilitiesResponse")
+	proto.RegisterType((*AffectedAncestry)(nil), "coreos.clair.AffectedAncestry")
+	proto.RegisterType((*GetAffectedAncestriesRequest)(nil), "coreos.clair.GetAffectedAncestriesRequest")
+	proto.RegisterType((*GetAffectedAncestriesResponse)(nil), "coreos.clair.GetAffectedAncestriesResponse")
 	proto.RegisterEnum("coreos.clair.Detector_DType", Detector_DType_name, Detector_DType_value)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for AncestryService service
+
+type AncestryServiceClient interface {
+	// The RPC used to read the results of scanning for a particular ancestry.
+	GetAncestry(ctx context.Context, in *GetAncestryRequest, opts ...grpc.CallOption) (*GetAncestryResponse, error)
+	// The RPC used to create a new scan of an ancestry.
+	PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error)
+	// The RPC used to scan a batch of ancestries, the layers they share being
+	// downloaded and analyzed once for the whole batch. Every ancestry succeeds
+	// or fails on its own, as reported by its status.
+	PostAncestries(ctx context.Context, in *PostAncestriesRequest, opts ...grpc.CallOption) (*PostAncestriesResponse, error)
+	// The RPC used to delete an ancestry and the results of its scan. The
+	// layers it shares with other ancestries are kept.
+	DeleteAncestry(ctx context.Context, in *DeleteAncestryRequest, opts ...grpc.CallOption) (*DeleteAncestryResponse, error)
+	// The RPC used to analyze an ancestry again from its stored layer sources
+	// with the current detectors, replacing the results of its previous scan.
+	RescanAncestry(ctx context.Context, in *RescanAncestryRequest, opts ...grpc.CallOption) (*RescanAncestryResponse, error)
+	// The RPC used to index an ancestry from the components listed by its
+	// CycloneDX or SPDX SBOM instead of its layers, which returns the results
+	// of its scan.
+	IndexSBOM(ctx context.Context, in *IndexSBOMRequest, opts ...grpc.CallOption) (*IndexSBOMResponse, error)
+}
+
+type ancestryServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewAncestryServiceClient(cc *grpc.ClientConn) AncestryServiceClient {
+	return &ancestryServiceClient{cc}
+}
+
+func (c *ancestryServiceClient) GetAncestry(ctx context.Context, in *GetAncestryRequest, opts ...grpc.CallOption) (*GetAncestryResponse, error) {
+	out := new(GetAncestryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/GetAncestry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error) {
+	out := new(PostAncestryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostAncestry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) PostAncestries(ctx context.Context, in *PostAncestriesRequest, opts ...grpc.CallOption) (*PostAncestriesResponse, error) {
+	out := new(PostAncestriesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostAncestries", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) DeleteAncestry(ctx context.Context, in *DeleteAncestryRequest, opts ...grpc.CallOption) (*DeleteAncestryResponse, error) {
+	out := new(DeleteAncestryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/DeleteAncestry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) RescanAncestry(ctx context.Context, in *RescanAncestryRequest, opts ...grpc.CallOption) (*RescanAncestryResponse, error) {
+	out := new(RescanAncestryResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/RescanAncestry", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ancestryServiceClient) IndexSBOM(ctx context.Context, in *IndexSBOMRequest, opts ...grpc.CallOption) (*IndexSBOMResponse, error) {
+	out := new(IndexSBOMResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/IndexSBOM", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for AncestryService service
+
+type AncestryServiceServer interface {
+	// The RPC used to read the results of scanning for a particular ancestry.
+	GetAncestry(context.Context, *GetAncestryRequest) (*GetAncestryResponse, error)
+	// The RPC used to create a new scan of an ancestry.
+	PostAncestry(context.Context, *PostAncestryRequest) (*PostAncestryResponse, error)
+	// The RPC used to scan a batch of ancestries, the layers they share being
+	// downloaded and analyzed once for the whole batch. Every ancestry succeeds
+	// or fails on its own, as reported by its status.
+	PostAncestries(context.Context, *PostAncestriesRequest) (*PostAncestriesResponse, error)
+	// The RPC used to delete an ancestry and the results of its scan. The
+	// layers it shares with other ancestries are kept.
+	DeleteAncestry(context.Context, *DeleteAncestryRequest) (*DeleteAncestryResponse, error)
+	// The RPC used to analyze an ancestry again from its stored layer sources
+	// with the current detectors, replacing the results of its previous scan.
+	RescanAncestry(context.Context, *RescanAncestryRequest) (*RescanAncestryResponse, error)
+	// The RPC used to index an ancestry from the components listed by its
+	// CycloneDX or SPDX SBOM instead of its layers, which returns the results
+	// of its scan.
+	IndexSBOM(context.Context, *IndexSBOMRequest) (*IndexSBOMResponse, error)
+}
+
+func RegisterAncestryServiceServer(s *grpc.Server, srv AncestryServiceServer) {
+	s.RegisterService(&_AncestryService_serviceDesc, srv)
+}
+
+func _AncestryService_GetAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAncestryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).GetAncestry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/GetAncestry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).GetAncestry(ctx, req.(*GetAncestryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_PostAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostAncestryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).PostAncestry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/PostAncestry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).PostAncestry(ctx, req.(*PostAncestryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_PostAncestries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PostAncestriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).PostAncestries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/PostAncestries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).PostAncestries(ctx, req.(*PostAncestriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_DeleteAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAncestryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).DeleteAncestry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/DeleteAncestry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).DeleteAncestry(ctx, req.(*DeleteAncestryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_RescanAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RescanAncestryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).RescanAncestry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/RescanAncestry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).RescanAncestry(ctx, req.(*RescanAncestryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AncestryService_IndexSBOM_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IndexSBOMRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AncestryServiceServer).IndexSBOM(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.AncestryService/IndexSBOM",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AncestryServiceServer).IndexSBOM(ctx, req.(*IndexSBOMRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _AncestryService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.AncestryService",
+	HandlerType: (*AncestryServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAncestry",
+			Handler:    _AncestryService_GetAncestry_Handler,
+		},
+		{
+			MethodName: "PostAncestry",
+			Handler:    _AncestryService_PostAncestry_Handler,
+		},
+		{
+			MethodName: "PostAncestries",
+			Handler:    _AncestryService_PostAncestries_Handler,
+		},
+		{
+			MethodName: "DeleteAncestry",
+			Handler:    _AncestryService_DeleteAncestry_Handler,
+		},
+		{
+			MethodName: "RescanAncestry",
+			Handler:    _AncestryService_RescanAncestry_Handler,
+		},
+		{
+			MethodName: "IndexSBOM",
+			Handler:    _AncestryService_IndexSBOM_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/v3/clairpb/clair.proto",
+}
+
+// Client API for StatusService service
+
+type StatusServiceClient interface {
+	// The RPC used to show the internal state of current Clair instance.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// The RPC used to list the vulnerability updaters enabled in the current
+	// Clair instance.
+	ListUpdaters(ctx context.Context, in *ListUpdatersRequest, opts ...grpc.CallOption) (*ListUpdatersResponse, error)
+	// The RPC used to list the namespaces stored in the database, with the
+	// number of their vulnerabilities, and the detectors enabled in the current
+	// Clair instance.
+	ListNamespaces(ctx context.Context, in *ListNamespacesRequest, opts ...grpc.CallOption) (*ListNamespacesResponse, error)
+	// The RPC used to compute aggregate statistics of the database, such as the
+	// number of vulnerabilities per namespace and severity, and the freshness of
+	// the enabled vulnerability updaters, for dashboards.
+	GetStatistics(ctx context.Context, in *GetStatisticsRequest, opts ...grpc.CallOption) (*GetStatisticsResponse, error)
+}
+
+type statusServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStatusServiceClient(cc *grpc.ClientConn) StatusServiceClient {
+	return &statusServiceClient{cc}
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (c *statusServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	out := new(GetStatusResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/GetStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-// Client API for AncestryService service
+func (c *statusServiceClient) ListUpdaters(ctx context.Context, in *ListUpdatersRequest, opts ...grpc.CallOption) (*ListUpdatersResponse, error) {
+	out := new(ListUpdatersResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/ListUpdaters", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type AncestryServiceClient interface {
-	// The RPC used to read the results of scanning for a particular ancestry.
-	GetAncestry(ctx context.Context, in *GetAncestryRequest, opts ...grpc.CallOption) (*GetAncestryResponse, error)
-	// The RPC used to create a new scan of an ancestry.
-	PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error)
+func (c *statusServiceClient) ListNamespaces(ctx context.Context, in *ListNamespacesRequest, opts ...grpc.CallOption) (*ListNamespacesResponse, error) {
+	out := new(ListNamespacesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/ListNamespaces", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-type ancestryServiceClient struct {
+func (c *statusServiceClient) GetStatistics(ctx context.Context, in *GetStatisticsRequest, opts ...grpc.CallOption) (*GetStatisticsResponse, error) {
+	out := new(GetStatisticsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/GetStatistics", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for StatusService service
+
+type StatusServiceServer interface {
+	// The RPC used to show the internal state of current Clair instance.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// The RPC used to list the vulnerability updaters enabled in the current
+	// Clair instance.
+	ListUpdaters(context.Context, *ListUpdatersRequest) (*ListUpdatersResponse, error)
+	// The RPC used to list the namespaces stored in the database, with the
+	// number of their vulnerabilities, and the detectors enabled in the current
+	// Clair instance.
+	ListNamespaces(context.Context, *ListNamespacesRequest) (*ListNamespacesResponse, error)
+	// The RPC used to compute aggregate statistics of the database, such as the
+	// number of vulnerabilities per namespace and severity, and the freshness of
+	// the enabled vulnerability updaters, for dashboards.
+	GetStatistics(context.Context, *GetStatisticsRequest) (*GetStatisticsResponse, error)
+}
+
+func RegisterStatusServiceServer(s *grpc.Server, srv StatusServiceServer) {
+	s.RegisterService(&_StatusService_serviceDesc, srv)
+}
+
+func _StatusService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_ListUpdaters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUpdatersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).ListUpdaters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/ListUpdaters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).ListUpdaters(ctx, req.(*ListUpdatersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_ListNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNamespacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).ListNamespaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/ListNamespaces",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).ListNamespaces(ctx, req.(*ListNamespacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StatusService_GetStatistics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatisticsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StatusServiceServer).GetStatistics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.StatusService/GetStatistics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StatusServiceServer).GetStatistics(ctx, req.(*GetStatisticsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _StatusService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.StatusService",
+	HandlerType: (*StatusServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _StatusService_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListUpdaters",
+			Handler:    _StatusService_ListUpdaters_Handler,
+		},
+		{
+			MethodName: "ListNamespaces",
+			Handler:    _StatusService_ListNamespaces_Handler,
+		},
+		{
+			MethodName: "GetStatistics",
+			Handler:    _StatusService_GetStatistics_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/v3/clairpb/clair.proto",
+}
+
+// Client API for UpdaterService service
+
+type UpdaterServiceClient interface {
+	// The RPC used to run an updater out-of-band, in the background.
+	TriggerUpdater(ctx context.Context, in *TriggerUpdaterRequest, opts ...grpc.CallOption) (*TriggerUpdaterResponse, error)
+	// The RPC used to poll the status of an updater run started by
+	// TriggerUpdater.
+	GetUpdaterJob(ctx context.Context, in *GetUpdaterJobRequest, opts ...grpc.CallOption) (*GetUpdaterJobResponse, error)
+}
+
+type updaterServiceClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewAncestryServiceClient(cc *grpc.ClientConn) AncestryServiceClient {
-	return &ancestryServiceClient{cc}
+func NewUpdaterServiceClient(cc *grpc.ClientConn) UpdaterServiceClient {
+	return &updaterServiceClient{cc}
 }
 
-func (c *ancestryServiceClient) GetAncestry(ctx context.Context, in *GetAncestryRequest, opts ...grpc.CallOption) (*GetAncestryResponse, error) {
-	out := new(GetAncestryResponse)
-	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/GetAncestry", in, out, c.cc, opts...)
+func (c *updaterServiceClient) TriggerUpdater(ctx context.Context, in *TriggerUpdaterRequest, opts ...grpc.CallOption) (*TriggerUpdaterResponse, error) {
+	out := new(TriggerUpdaterResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.UpdaterService/TriggerUpdater", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *updaterServiceClient) GetUpdaterJob(ctx context.Context, in *GetUpdaterJobRequest, opts ...grpc.CallOption) (*GetUpdaterJobResponse, error) {
+	out := new(GetUpdaterJobResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.UpdaterService/GetUpdaterJob", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for UpdaterService service
+
+type UpdaterServiceServer interface {
+	// The RPC used to run an updater out-of-band, in the background.
+	TriggerUpdater(context.Context, *TriggerUpdaterRequest) (*TriggerUpdaterResponse, error)
+	// The RPC used to poll the status of an updater run started by
+	// TriggerUpdater.
+	GetUpdaterJob(context.Context, *GetUpdaterJobRequest) (*GetUpdaterJobResponse, error)
+}
+
+func RegisterUpdaterServiceServer(s *grpc.Server, srv UpdaterServiceServer) {
+	s.RegisterService(&_UpdaterService_serviceDesc, srv)
+}
+
+func _UpdaterService_TriggerUpdater_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerUpdaterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdaterServiceServer).TriggerUpdater(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.UpdaterService/TriggerUpdater",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdaterServiceServer).TriggerUpdater(ctx, req.(*TriggerUpdaterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UpdaterService_GetUpdaterJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUpdaterJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UpdaterServiceServer).GetUpdaterJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.UpdaterService/GetUpdaterJob",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UpdaterServiceServer).GetUpdaterJob(ctx, req.(*GetUpdaterJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _UpdaterService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.UpdaterService",
+	HandlerType: (*UpdaterServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TriggerUpdater",
+			Handler:    _UpdaterService_TriggerUpdater_Handler,
+		},
+		{
+			MethodName: "GetUpdaterJob",
+			Handler:    _UpdaterService_GetUpdaterJob_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/v3/clairpb/clair.proto",
+}
+
+// Client API for NotificationService service
+
+type NotificationServiceClient interface {
+	// The RPC used to get a particularly Notification.
+	GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error)
+	// The RPC used to list the Notifications in a state, page by page.
+	ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationsResponse, error)
+	// The RPC used to mark a Notification as read after it has been processed.
+	MarkNotificationAsRead(ctx context.Context, in *MarkNotificationAsReadRequest, opts ...grpc.CallOption) (*MarkNotificationAsReadResponse, error)
+	// The RPC used to list the Notifications that failed to be sent.
+	ListDeadLetterNotifications(ctx context.Context, in *ListDeadLetterNotificationsRequest, opts ...grpc.CallOption) (*ListDeadLetterNotificationsResponse, error)
+	// The RPC used to send a dead-lettered Notification again.
+	RetryDeadLetterNotification(ctx context.Context, in *RetryDeadLetterNotificationRequest, opts ...grpc.CallOption) (*RetryDeadLetterNotificationResponse, error)
+}
+
+type notificationServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNotificationServiceClient(cc *grpc.ClientConn) NotificationServiceClient {
+	return &notificationServiceClient{cc}
+}
+
+func (c *notificationServiceClient) GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error) {
+	out := new(GetNotificationResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/GetNotification", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationsResponse, error) {
+	out := new(ListNotificationsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/ListNotifications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) MarkNotificationAsRead(ctx context.Context, in *MarkNotificationAsReadRequest, opts ...grpc.CallOption) (*MarkNotificationAsReadResponse, error) {
+	out := new(MarkNotificationAsReadResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/MarkNotificationAsRead", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ListDeadLetterNotifications(ctx context.Context, in *ListDeadLetterNotificationsRequest, opts ...grpc.CallOption) (*ListDeadLetterNotificationsResponse, error) {
+	out := new(ListDeadLetterNotificationsResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/ListDeadLetterNotifications", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) RetryDeadLetterNotification(ctx context.Context, in *RetryDeadLetterNotificationRequest, opts ...grpc.CallOption) (*RetryDeadLetterNotificationResponse, error) {
+	out := new(RetryDeadLetterNotificationResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/RetryDeadLetterNotification", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	return out, nil
+}
+
+// Server API for NotificationService service
+
+type NotificationServiceServer interface {
+	// The RPC used to get a particularly Notification.
+	GetNotification(context.Context, *GetNotificationRequest) (*GetNotificationResponse, error)
+	// The RPC used to list the Notifications in a state, page by page.
+	ListNotifications(context.Context, *ListNotificationsRequest) (*ListNotificationsResponse, error)
+	// The RPC used to mark a Notification as read after it has been processed.
+	MarkNotificationAsRead(context.Context, *MarkNotificationAsReadRequest) (*MarkNotificationAsReadResponse, error)
+	// The RPC used to list the Notifications that failed to be sent.
+	ListDeadLetterNotifications(context.Context, *ListDeadLetterNotificationsRequest) (*ListDeadLetterNotificationsResponse, error)
+	// The RPC used to send a dead-lettered Notification again.
+	RetryDeadLetterNotification(context.Context, *RetryDeadLetterNotificationRequest) (*RetryDeadLetterNotificationResponse, error)
+}
+
+func RegisterNotificationServiceServer(s *grpc.Server, srv NotificationServiceServer) {
+	s.RegisterService(&_NotificationService_serviceDesc, srv)
+}
+
+func _NotificationService_GetNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).GetNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/GetNotification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).GetNotification(ctx, req.(*GetNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *ancestryServiceClient) PostAncestry(ctx context.Context, in *PostAncestryRequest, opts ...grpc.CallOption) (*PostAncestryResponse, error) {
-	out := new(PostAncestryResponse)
-	err := grpc.Invoke(ctx, "/coreos.clair.AncestryService/PostAncestry", in, out, c.cc, opts...)
-	if err != nil {
+func _NotificationService_ListNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationsRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
-}
-
-// Server API for AncestryService service
-
-type AncestryServiceServer interface {
-	// The RPC used to read the results of scanning for a particular ancestry.
-	GetAncestry(context.Context, *GetAncestryRequest) (*GetAncestryResponse, error)
-	// The RPC used to create a new scan of an ancestry.
-	PostAncestry(context.Context, *PostAncestryRequest) (*PostAncestryResponse, error)
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ListNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/ListNotifications",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ListNotifications(ctx, req.(*ListNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterAncestryServiceServer(s *grpc.Server, srv AncestryServiceServer) {
-	s.RegisterService(&_AncestryService_serviceDesc, srv)
+func _NotificationService_MarkNotificationAsRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkNotificationAsReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).MarkNotificationAsRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.NotificationService/MarkNotificationAsRead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).MarkNotificationAsRead(ctx, req.(*MarkNotificationAsReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _AncestryService_GetAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetAncestryRequest)
+func _NotificationService_ListDeadLetterNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLetterNotificationsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AncestryServiceServer).GetAncestry(ctx, in)
+		return srv.(NotificationServiceServer).ListDeadLetterNotifications(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/coreos.clair.AncestryService/GetAncestry",
+		FullMethod: "/coreos.clair.NotificationService/ListDeadLetterNotifications",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AncestryServiceServer).GetAncestry(ctx, req.(*GetAncestryRequest))
+		return srv.(NotificationServiceServer).ListDeadLetterNotifications(ctx, req.(*ListDeadLetterNotificationsRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _AncestryService_PostAncestry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(PostAncestryRequest)
+func _NotificationService_RetryDeadLetterNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetryDeadLetterNotificationRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AncestryServiceServer).PostAncestry(ctx, in)
+		return srv.(NotificationServiceServer).RetryDeadLetterNotification(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/coreos.clair.AncestryService/PostAncestry",
+		FullMethod: "/coreos.clair.NotificationService/RetryDeadLetterNotification",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AncestryServiceServer).PostAncestry(ctx, req.(*PostAncestryRequest))
+		return srv.(NotificationServiceServer).RetryDeadLetterNotification(ctx, req.(*RetryDeadLetterNotificationRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _AncestryService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "coreos.clair.AncestryService",
-	HandlerType: (*AncestryServiceServer)(nil),
+var _NotificationService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.NotificationService",
+	HandlerType: (*NotificationServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetAncestry",
-			Handler:    _AncestryService_GetAncestry_Handler,
+			MethodName: "GetNotification",
+			Handler:    _NotificationService_GetNotification_Handler,
 		},
 		{
-			MethodName: "PostAncestry",
-			Handler:    _AncestryService_PostAncestry_Handler,
+			MethodName: "ListNotifications",
+			Handler:    _NotificationService_ListNotifications_Handler,
+		},
+		{
+			MethodName: "MarkNotificationAsRead",
+			Handler:    _NotificationService_MarkNotificationAsRead_Handler,
+		},
+		{
+			MethodName: "ListDeadLetterNotifications",
+			Handler:    _NotificationService_ListDeadLetterNotifications_Handler,
+		},
+		{
+			MethodName: "RetryDeadLetterNotification",
+			Handler:    _NotificationService_RetryDeadLetterNotification_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v3/clairpb/clair.proto",
 }
 
-// Client API for StatusService service
+// Client API for SuppressionService service
 
-type StatusServiceClient interface {
-	// The RPC used to show the internal state of current Clair instance.
-	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+type SuppressionServiceClient interface {
+	// The RPC used to create a rule suppressing the findings of a
+	// vulnerability.
+	CreateSuppressionRule(ctx context.Context, in *CreateSuppressionRuleRequest, opts ...grpc.CallOption) (*CreateSuppressionRuleResponse, error)
+	// The RPC used to get a suppression rule.
+	GetSuppressionRule(ctx context.Context, in *GetSuppressionRuleRequest, opts ...grpc.CallOption) (*GetSuppressionRuleResponse, error)
+	// The RPC used to list the suppression rules, expired or not.
+	ListSuppressionRules(ctx context.Context, in *ListSuppressionRulesRequest, opts ...grpc.CallOption) (*ListSuppressionRulesResponse, error)
+	// The RPC used to replace a suppression rule.
+	UpdateSuppressionRule(ctx context.Context, in *UpdateSuppressionRuleRequest, opts ...grpc.CallOption) (*UpdateSuppressionRuleResponse, error)
+	// The RPC used to delete a suppression rule.
+	DeleteSuppressionRule(ctx context.Context, in *DeleteSuppressionRuleRequest, opts ...grpc.CallOption) (*DeleteSuppressionRuleResponse, error)
 }
 
-type statusServiceClient struct {
+type suppressionServiceClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewStatusServiceClient(cc *grpc.ClientConn) StatusServiceClient {
-	return &statusServiceClient{cc}
+func NewSuppressionServiceClient(cc *grpc.ClientConn) SuppressionServiceClient {
+	return &suppressionServiceClient{cc}
 }
 
-func (c *statusServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
-	out := new(GetStatusResponse)
-	err := grpc.Invoke(ctx, "/coreos.clair.StatusService/GetStatus", in, out, c.cc, opts...)
+func (c *suppressionServiceClient) CreateSuppressionRule(ctx context.Context, in *CreateSuppressionRuleRequest, opts ...grpc.CallOption) (*CreateSuppressionRuleResponse, error) {
+	out := new(CreateSuppressionRuleResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.SuppressionService/CreateSuppressionRule", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for StatusService service
+func (c *suppressionServiceClient) GetSuppressionRule(ctx context.Context, in *GetSuppressionRuleRequest, opts ...grpc.CallOption) (*GetSuppressionRuleResponse, error) {
+	out := new(GetSuppressionRuleResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.SuppressionService/GetSuppressionRule", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type StatusServiceServer interface {
-	// The RPC used to show the internal state of current Clair instance.
-	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+func (c *suppressionServiceClient) ListSuppressionRules(ctx context.Context, in *ListSuppressionRulesRequest, opts ...grpc.CallOption) (*ListSuppressionRulesResponse, error) {
+	out := new(ListSuppressionRulesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.SuppressionService/ListSuppressionRules", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func RegisterStatusServiceServer(s *grpc.Server, srv StatusServiceServer) {
-	s.RegisterService(&_StatusService_serviceDesc, srv)
+func (c *suppressionServiceClient) UpdateSuppressionRule(ctx context.Context, in *UpdateSuppressionRuleRequest, opts ...grpc.CallOption) (*UpdateSuppressionRuleResponse, error) {
+	out := new(UpdateSuppressionRuleResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.SuppressionService/UpdateSuppressionRule", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func _StatusService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetStatusRequest)
+func (c *suppressionServiceClient) DeleteSuppressionRule(ctx context.Context, in *DeleteSuppressionRuleRequest, opts ...grpc.CallOption) (*DeleteSuppressionRuleResponse, error) {
+	out := new(DeleteSuppressionRuleResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.SuppressionService/DeleteSuppressionRule", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for SuppressionService service
+
+type SuppressionServiceServer interface {
+	// The RPC used to create a rule suppressing the findings of a
+	// vulnerability.
+	CreateSuppressionRule(context.Context, *CreateSuppressionRuleRequest) (*CreateSuppressionRuleResponse, error)
+	// The RPC used to get a suppression rule.
+	GetSuppressionRule(context.Context, *GetSuppressionRuleRequest) (*GetSuppressionRuleResponse, error)
+	// The RPC used to list the suppression rules, expired or not.
+	ListSuppressionRules(context.Context, *ListSuppressionRulesRequest) (*ListSuppressionRulesResponse, error)
+	// The RPC used to replace a suppression rule.
+	UpdateSuppressionRule(context.Context, *UpdateSuppressionRuleRequest) (*UpdateSuppressionRuleResponse, error)
+	// The RPC used to delete a suppression rule.
+	DeleteSuppressionRule(context.Context, *DeleteSuppressionRuleRequest) (*DeleteSuppressionRuleResponse, error)
+}
+
+func RegisterSuppressionServiceServer(s *grpc.Server, srv SuppressionServiceServer) {
+	s.RegisterService(&_SuppressionService_serviceDesc, srv)
+}
+
+func _SuppressionService_CreateSuppressionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSuppressionRuleRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(StatusServiceServer).GetStatus(ctx, in)
+		return srv.(SuppressionServiceServer).CreateSuppressionRule(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/coreos.clair.StatusService/GetStatus",
+		FullMethod: "/coreos.clair.SuppressionService/CreateSuppressionRule",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(StatusServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+		return srv.(SuppressionServiceServer).CreateSuppressionRule(ctx, req.(*CreateSuppressionRuleRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _StatusService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "coreos.clair.StatusService",
-	HandlerType: (*StatusServiceServer)(nil),
+func _SuppressionService_GetSuppressionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSuppressionRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SuppressionServiceServer).GetSuppressionRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.SuppressionService/GetSuppressionRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SuppressionServiceServer).GetSuppressionRule(ctx, req.(*GetSuppressionRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SuppressionService_ListSuppressionRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSuppressionRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SuppressionServiceServer).ListSuppressionRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.SuppressionService/ListSuppressionRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SuppressionServiceServer).ListSuppressionRules(ctx, req.(*ListSuppressionRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SuppressionService_UpdateSuppressionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSuppressionRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SuppressionServiceServer).UpdateSuppressionRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.SuppressionService/UpdateSuppressionRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SuppressionServiceServer).UpdateSuppressionRule(ctx, req.(*UpdateSuppressionRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SuppressionService_DeleteSuppressionRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSuppressionRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SuppressionServiceServer).DeleteSuppressionRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.SuppressionService/DeleteSuppressionRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SuppressionServiceServer).DeleteSuppressionRule(ctx, req.(*DeleteSuppressionRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _SuppressionService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.SuppressionService",
+	HandlerType: (*SuppressionServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetStatus",
-			Handler:    _StatusService_GetStatus_Handler,
+			MethodName: "CreateSuppressionRule",
+			Handler:    _SuppressionService_CreateSuppressionRule_Handler,
+		},
+		{
+			MethodName: "GetSuppressionRule",
+			Handler:    _SuppressionService_GetSuppressionRule_Handler,
+		},
+		{
+			MethodName: "ListSuppressionRules",
+			Handler:    _SuppressionService_ListSuppressionRules_Handler,
+		},
+		{
+			MethodName: "UpdateSuppressionRule",
+			Handler:    _SuppressionService_UpdateSuppressionRule_Handler,
+		},
+		{
+			MethodName: "DeleteSuppressionRule",
+			Handler:    _SuppressionService_DeleteSuppressionRule_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/v3/clairpb/clair.proto",
 }
 
-// Client API for NotificationService service
+// Client API for VulnerabilityService service
 
-type NotificationServiceClient interface {
-	// The RPC used to get a particularly Notification.
-	GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error)
-	// The RPC used to mark a Notification as read after it has been processed.
-	MarkNotificationAsRead(ctx context.Context, in *MarkNotificationAsReadRequest, opts ...grpc.CallOption) (*MarkNotificationAsReadResponse, error)
+type VulnerabilityServiceClient interface {
+	// The RPC used to get a vulnerability of a namespace with the features it
+	// affects.
+	GetVulnerability(ctx context.Context, in *GetVulnerabilityRequest, opts ...grpc.CallOption) (*GetVulnerabilityResponse, error)
+	// The RPC used to list the vulnerabilities of a namespace, page by page.
+	ListVulnerabilities(ctx context.Context, in *ListVulnerabilitiesRequest, opts ...grpc.CallOption) (*ListVulnerabilitiesResponse, error)
+	// The RPC used to list the ancestries affected by a vulnerability of a
+	// namespace, page by page.
+	GetAffectedAncestries(ctx context.Context, in *GetAffectedAncestriesRequest, opts ...grpc.CallOption) (*GetAffectedAncestriesResponse, error)
 }
 
-type notificationServiceClient struct {
+type vulnerabilityServiceClient struct {
 	cc *grpc.ClientConn
 }
 
-func NewNotificationServiceClient(cc *grpc.ClientConn) NotificationServiceClient {
-	return &notificationServiceClient{cc}
+func NewVulnerabilityServiceClient(cc *grpc.ClientConn) VulnerabilityServiceClient {
+	return &vulnerabilityServiceClient{cc}
 }
 
-func (c *notificationServiceClient) GetNotification(ctx context.Context, in *GetNotificationRequest, opts ...grpc.CallOption) (*GetNotificationResponse, error) {
-	out := new(GetNotificationResponse)
-	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/GetNotification", in, out, c.cc, opts...)
+func (c *vulnerabilityServiceClient) GetVulnerability(ctx context.Context, in *GetVulnerabilityRequest, opts ...grpc.CallOption) (*GetVulnerabilityResponse, error) {
+	out := new(GetVulnerabilityResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.VulnerabilityService/GetVulnerability", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *notificationServiceClient) MarkNotificationAsRead(ctx context.Context, in *MarkNotificationAsReadRequest, opts ...grpc.CallOption) (*MarkNotificationAsReadResponse, error) {
-	out := new(MarkNotificationAsReadResponse)
-	err := grpc.Invoke(ctx, "/coreos.clair.NotificationService/MarkNotificationAsRead", in, out, c.cc, opts...)
+func (c *vulnerabilityServiceClient) ListVulnerabilities(ctx context.Context, in *ListVulnerabilitiesRequest, opts ...grpc.CallOption) (*ListVulnerabilitiesResponse, error) {
+	out := new(ListVulnerabilitiesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.VulnerabilityService/ListVulnerabilities", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for NotificationService service
+func (c *vulnerabilityServiceClient) GetAffectedAncestries(ctx context.Context, in *GetAffectedAncestriesRequest, opts ...grpc.CallOption) (*GetAffectedAncestriesResponse, error) {
+	out := new(GetAffectedAncestriesResponse)
+	err := grpc.Invoke(ctx, "/coreos.clair.VulnerabilityService/GetAffectedAncestries", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type NotificationServiceServer interface {
-	// The RPC used to get a particularly Notification.
-	GetNotification(context.Context, *GetNotificationRequest) (*GetNotificationResponse, error)
-	// The RPC used to mark a Notification as read after it has been processed.
-	MarkNotificationAsRead(context.Context, *MarkNotificationAsReadRequest) (*MarkNotificationAsReadResponse, error)
+// Server API for VulnerabilityService service
+
+type VulnerabilityServiceServer interface {
+	// The RPC used to get a vulnerability of a namespace with the features it
+	// affects.
+	GetVulnerability(context.Context, *GetVulnerabilityRequest) (*GetVulnerabilityResponse, error)
+	// The RPC used to list the vulnerabilities of a namespace, page by page.
+	ListVulnerabilities(context.Context, *ListVulnerabilitiesRequest) (*ListVulnerabilitiesResponse, error)
+	// The RPC used to list the ancestries affected by a vulnerability of a
+	// namespace, page by page.
+	GetAffectedAncestries(context.Context, *GetAffectedAncestriesRequest) (*GetAffectedAncestriesResponse, error)
 }
 
-func RegisterNotificationServiceServer(s *grpc.Server, srv NotificationServiceServer) {
-	s.RegisterService(&_NotificationService_serviceDesc, srv)
+func RegisterVulnerabilityServiceServer(s *grpc.Server, srv VulnerabilityServiceServer) {
+	s.RegisterService(&_VulnerabilityService_serviceDesc, srv)
 }
 
-func _NotificationService_GetNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetNotificationRequest)
+func _VulnerabilityService_GetVulnerability_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVulnerabilityRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NotificationServiceServer).GetNotification(ctx, in)
+		return srv.(VulnerabilityServiceServer).GetVulnerability(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/coreos.clair.NotificationService/GetNotification",
+		FullMethod: "/coreos.clair.VulnerabilityService/GetVulnerability",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NotificationServiceServer).GetNotification(ctx, req.(*GetNotificationRequest))
+		return srv.(VulnerabilityServiceServer).GetVulnerability(ctx, req.(*GetVulnerabilityRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _NotificationService_MarkNotificationAsRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(MarkNotificationAsReadRequest)
+func _VulnerabilityService_ListVulnerabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVulnerabilitiesRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NotificationServiceServer).MarkNotificationAsRead(ctx, in)
+		return srv.(VulnerabilityServiceServer).ListVulnerabilities(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/coreos.clair.NotificationService/MarkNotificationAsRead",
+		FullMethod: "/coreos.clair.VulnerabilityService/ListVulnerabilities",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NotificationServiceServer).MarkNotificationAsRead(ctx, req.(*MarkNotificationAsReadRequest))
+		return srv.(VulnerabilityServiceServer).ListVulnerabilities(ctx, req.(*ListVulnerabilitiesRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _NotificationService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "coreos.clair.NotificationService",
-	HandlerType: (*NotificationServiceServer)(nil),
+func _VulnerabilityService_GetAffectedAncestries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAffectedAncestriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VulnerabilityServiceServer).GetAffectedAncestries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/coreos.clair.VulnerabilityService/GetAffectedAncestries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VulnerabilityServiceServer).GetAffectedAncestries(ctx, req.(*GetAffectedAncestriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _VulnerabilityService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "coreos.clair.VulnerabilityService",
+	HandlerType: (*VulnerabilityServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetNotification",
-			Handler:    _NotificationService_GetNotification_Handler,
+			MethodName: "GetVulnerability",
+			Handler:    _VulnerabilityService_GetVulnerability_Handler,
 		},
 		{
-			MethodName: "MarkNotificationAsRead",
-			Handler:    _NotificationService_MarkNotificationAsRead_Handler,
+			MethodName: "ListVulnerabilities",
+			Handler:    _VulnerabilityService_ListVulnerabilities_Handler,
+		},
+		{
+			MethodName: "GetAffectedAncestries",
+			Handler:    _VulnerabilityService_GetAffectedAncestries_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -1096,90 +3623,208 @@ var _NotificationService_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("api/v3/clairpb/clair.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1345 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x4b, 0x6f, 0xdb, 0xc6,
-	0x16, 0xbe, 0x94, 0x23, 0x4b, 0x3a, 0x92, 0x6c, 0x65, 0xec, 0xd8, 0x32, 0x9d, 0x87, 0xcd, 0x7b,
-	0x83, 0x9b, 0x9b, 0x5b, 0x48, 0xa8, 0x92, 0x02, 0x49, 0xba, 0x28, 0x14, 0x5b, 0x76, 0x0d, 0x24,
-	0xae, 0x41, 0x3b, 0x06, 0xda, 0xa2, 0x60, 0xc7, 0xe2, 0x91, 0x4d, 0x98, 0x22, 0x59, 0x72, 0x64,
-	0x47, 0x08, 0xd2, 0x45, 0x77, 0xdd, 0xb6, 0x8b, 0xfe, 0x86, 0x6e, 0xba, 0xe9, 0x4f, 0xe8, 0xbe,
-	0x8b, 0x76, 0xdb, 0xee, 0xba, 0xe8, 0x1f, 0xe8, 0xa2, 0xbb, 0x62, 0x1e, 0xa4, 0x49, 0x9b, 0x7e,
-	0x24, 0x2b, 0xcd, 0x9c, 0x39, 0xcf, 0x6f, 0xbe, 0x39, 0x87, 0x02, 0x9d, 0x06, 0x4e, 0xfb, 0xe8,
-	0x41, 0xbb, 0xef, 0x52, 0x27, 0x0c, 0xf6, 0xe4, 0x6f, 0x2b, 0x08, 0x7d, 0xe6, 0x93, 0x5a, 0xdf,
-	0x0f, 0xd1, 0x8f, 0x5a, 0x42, 0xa6, 0xdf, 0xd9, 0xf7, 0xfd, 0x7d, 0x17, 0xdb, 0xe2, 0x6c, 0x6f,
-	0x34, 0x68, 0x33, 0x67, 0x88, 0x11, 0xa3, 0xc3, 0x40, 0xaa, 0xeb, 0x37, 0x95, 0x02, 0xf7, 0x48,
-	0x3d, 0xcf, 0x67, 0x94, 0x39, 0xbe, 0x17, 0xc9, 0x53, 0xe3, 0xbb, 0x02, 0xd4, 0x77, 0x47, 0xae,
-	0x87, 0x21, 0xdd, 0x73, 0x5c, 0x87, 0x8d, 0x09, 0x81, 0x6b, 0x1e, 0x1d, 0x62, 0x53, 0x5b, 0xd2,
-	0xee, 0x55, 0x4c, 0xb1, 0x26, 0x77, 0x61, 0x8a, 0xff, 0x46, 0x01, 0xed, 0xa3, 0x25, 0x4e, 0x0b,
-	0xe2, 0xb4, 0x9e, 0x48, 0x37, 0xb9, 0xda, 0x12, 0x54, 0x6d, 0x8c, 0xfa, 0xa1, 0x13, 0xf0, 0x10,
-	0xcd, 0x09, 0xa1, 0x93, 0x16, 0x71, 0xe7, 0xae, 0xe3, 0x1d, 0x36, 0xaf, 0x49, 0xe7, 0x7c, 0x4d,
-	0x74, 0x28, 0x47, 0x78, 0x84, 0xa1, 0xc3, 0xc6, 0xcd, 0xa2, 0x90, 0x27, 0x7b, 0x7e, 0x36, 0x44,
-	0x46, 0x6d, 0xca, 0x68, 0x73, 0x52, 0x9e, 0xc5, 0x7b, 0xb2, 0x00, 0xe5, 0x81, 0xf3, 0x12, 0x6d,
-	0x6b, 0x6f, 0xdc, 0x2c, 0x89, 0xb3, 0x92, 0xd8, 0x3f, 0x1d, 0x93, 0xa7, 0x70, 0x9d, 0x0e, 0x06,
-	0xd8, 0x67, 0x68, 0x5b, 0x47, 0x18, 0x46, 0xbc, 0xe0, 0x66, 0x79, 0x69, 0xe2, 0x5e, 0xb5, 0x73,
-	0xa3, 0x95, 0x86, 0xaf, 0xb5, 0x86, 0x94, 0x8d, 0x42, 0x34, 0x1b, 0xb1, 0xfe, 0xae, 0x52, 0x37,
-	0x7e, 0xd6, 0xa0, 0xbc, 0x8a, 0x0c, 0xfb, 0xcc, 0x0f, 0x73, 0x41, 0x69, 0x42, 0x49, 0xf9, 0x56,
-	0x68, 0xc4, 0x5b, 0xd2, 0x81, 0xa2, 0xcd, 0xc6, 0x01, 0x0a, 0x04, 0xa6, 0x3a, 0x37, 0xb3, 0x21,
-	0x63, 0xa7, 0xad, 0xd5, 0x9d, 0x71, 0x80, 0xa6, 0x54, 0x35, 0x3e, 0x87, 0xa2, 0xd8, 0x93, 0x45,
-	0x98, 0x5f, 0xed, 0xed, 0xf4, 0x56, 0x76, 0x3e, 0x32, 0xad, 0x55, 0x6b, 0xe7, 0xe3, 0xad, 0x9e,
-	0xb5, 0xb1, 0xb9, 0xdb, 0x7d, 0xb6, 0xb1, 0xda, 0xf8, 0x17, 0xb9, 0x05, 0x0b, 0xa7, 0x0f, 0x37,
-	0xbb, 0xcf, 0x7b, 0xdb, 0x5b, 0xdd, 0x95, 0x5e, 0x43, 0xcb, 0xb3, 0x5d, 0xeb, 0x75, 0x77, 0x5e,
-	0x98, 0xbd, 0x46, 0xc1, 0xd8, 0x86, 0xca, 0x66, 0x7c, 0x5d, 0xb9, 0x05, 0x75, 0xa0, 0x6c, 0xab,
-	0xdc, 0x44, 0x45, 0xd5, 0xce, 0x5c, 0x7e, 0xe6, 0x66, 0xa2, 0x67, 0xfc, 0x58, 0x80, 0x92, 0xc2,
-	0x30, 0xd7, 0xe7, 0x7b, 0x50, 0x49, 0x38, 0xa2, 0x9c, 0xce, 0x67, 0x9d, 0x26, 0x39, 0x99, 0x27,
-	0x9a, 0x69, 0x6c, 0x27, 0xb2, 0xd8, 0xde, 0x85, 0x29, 0xb5, 0xb4, 0x06, 0x7e, 0x38, 0xa4, 0x4c,
-	0x71, 0xa9, 0xae, 0xa4, 0x6b, 0x42, 0x98, 0xa9, 0xa5, 0x78, 0xb5, 0x5a, 0x48, 0x0f, 0xa6, 0x8f,
-	0x52, 0x4f, 0xc1, 0xc1, 0xa8, 0x39, 0x29, 0x38, 0xb3, 0x98, 0x35, 0xcd, 0xbc, 0x17, 0xf3, 0xb4,
-	0x0d, 0x59, 0x86, 0xda, 0x40, 0x22, 0x62, 0x09, 0x12, 0x48, 0x6e, 0x56, 0x95, 0x8c, 0xdf, 0xb1,
-	0xb1, 0x08, 0xc5, 0x67, 0x74, 0x8c, 0x82, 0x57, 0x07, 0x34, 0x3a, 0x88, 0x21, 0xe3, 0x6b, 0xe3,
-	0x6b, 0x0d, 0xaa, 0x2b, 0x3c, 0xd0, 0x36, 0xa3, 0x6c, 0x14, 0x91, 0x87, 0x50, 0x89, 0x53, 0x8c,
-	0x9a, 0x9a, 0x48, 0xe8, 0xbc, 0x5a, 0x4e, 0x14, 0xc9, 0x2a, 0x34, 0x5c, 0x1a, 0x31, 0x6b, 0x14,
-	0xd8, 0x94, 0xa1, 0xc5, 0xbb, 0x82, 0xc2, 0x5f, 0x6f, 0xc9, 0x8e, 0xd0, 0x8a, 0x5b, 0x46, 0x6b,
-	0x27, 0x6e, 0x19, 0xe6, 0x14, 0xb7, 0x79, 0x21, 0x4c, 0xb8, 0xd0, 0x78, 0x0c, 0x64, 0x1d, 0x59,
-	0xd7, 0xeb, 0x63, 0xc4, 0xc2, 0xb1, 0x89, 0x5f, 0x8c, 0x30, 0x62, 0xe4, 0xdf, 0x50, 0xa7, 0x4a,
-	0x64, 0xa5, 0x6e, 0xbc, 0x16, 0x0b, 0xf9, 0x95, 0x1a, 0x7f, 0x17, 0x60, 0x26, 0x63, 0x1b, 0x05,
-	0xbe, 0x17, 0x21, 0x59, 0x83, 0x72, 0xac, 0x27, 0xec, 0xaa, 0x9d, 0xfb, 0xd9, 0x6a, 0x72, 0x8c,
-	0x5a, 0x89, 0x20, 0xb1, 0x25, 0xef, 0xc2, 0x64, 0x24, 0x00, 0x52, 0x65, 0x2d, 0x64, 0xbd, 0xa4,
-	0x10, 0x34, 0x95, 0xa2, 0xfe, 0x25, 0xd4, 0x63, 0x47, 0x12, 0xfe, 0xff, 0x41, 0xd1, 0xe5, 0x0b,
-	0x95, 0xc8, 0x4c, 0xd6, 0x85, 0xd0, 0x31, 0xa5, 0x06, 0x6f, 0x29, 0x12, 0x5c, 0xb4, 0x2d, 0x75,
-	0x95, 0x3c, 0xf2, 0x45, 0x2d, 0x25, 0xd6, 0x57, 0x82, 0x48, 0xdf, 0x87, 0x72, 0x1c, 0x3f, 0xf7,
-	0xb1, 0xac, 0xc3, 0xa4, 0x08, 0x16, 0x35, 0x27, 0x84, 0xe3, 0xf6, 0xd5, 0x81, 0x91, 0xb9, 0x2a,
-	0x73, 0xe3, 0xf7, 0x02, 0xcc, 0x6c, 0xf9, 0xd1, 0x5b, 0x5d, 0x1c, 0x99, 0x83, 0x49, 0xf5, 0xb2,
-	0x64, 0x5b, 0x53, 0x3b, 0xb2, 0x72, 0x2a, 0xbb, 0xff, 0x67, 0xb3, 0xcb, 0x89, 0x27, 0x64, 0x99,
-	0xcc, 0xf4, 0x9f, 0x34, 0xa8, 0x24, 0xd2, 0x3c, 0xfa, 0x73, 0x59, 0x40, 0xd9, 0x81, 0x0a, 0x2e,
-	0xd6, 0xc4, 0x84, 0xd2, 0x01, 0x52, 0xfb, 0x24, 0xf6, 0xa3, 0x37, 0x88, 0xdd, 0xfa, 0x50, 0x9a,
-	0xf6, 0x3c, 0x7e, 0x1a, 0x3b, 0xd2, 0x9f, 0x40, 0x2d, 0x7d, 0x40, 0x1a, 0x30, 0x71, 0x88, 0x63,
-	0x95, 0x0a, 0x5f, 0x92, 0x59, 0x28, 0x1e, 0x51, 0x77, 0x14, 0x0f, 0x3b, 0xb9, 0x79, 0x52, 0x78,
-	0xa4, 0x19, 0x1b, 0x30, 0x9b, 0x0d, 0xa9, 0xb8, 0x7d, 0xc2, 0x49, 0xed, 0x8a, 0x9c, 0x34, 0x7e,
-	0xd0, 0x60, 0x6e, 0x1d, 0xd9, 0xa6, 0xcf, 0x9c, 0x81, 0xd3, 0x17, 0xb3, 0x39, 0xbe, 0xad, 0x87,
-	0x30, 0xe7, 0xbb, 0xb6, 0x95, 0xee, 0x2f, 0x63, 0x2b, 0xa0, 0xfb, 0xf1, 0xb5, 0xcd, 0xfa, 0xae,
-	0x9d, 0xe9, 0x45, 0x5b, 0x74, 0x1f, 0xb9, 0x95, 0x87, 0xc7, 0x79, 0x56, 0xb2, 0x8c, 0x59, 0x0f,
-	0x8f, 0xcf, 0x5a, 0xcd, 0x42, 0xd1, 0x75, 0x86, 0x0e, 0x13, 0xed, 0xb6, 0x68, 0xca, 0x4d, 0x42,
-	0xd2, 0x6b, 0x27, 0x24, 0x35, 0x7e, 0x2b, 0xc0, 0xfc, 0x99, 0x84, 0x55, 0xfd, 0xbb, 0x50, 0xf3,
-	0x52, 0x72, 0x85, 0x42, 0xe7, 0x0c, 0x8d, 0xf3, 0x8c, 0x5b, 0x19, 0x61, 0xc6, 0x8f, 0xfe, 0xa7,
-	0x06, 0xb5, 0xf4, 0xf1, 0x79, 0xf3, 0xb8, 0x1f, 0x22, 0x65, 0x68, 0xc7, 0xf3, 0x58, 0x6d, 0xf9,
-	0x57, 0x84, 0x74, 0x87, 0xb6, 0x1a, 0x27, 0xc9, 0x9e, 0x5b, 0xd9, 0xe8, 0x22, 0xb7, 0x92, 0x55,
-	0xc6, 0x5b, 0xf2, 0x18, 0x26, 0x7c, 0xd7, 0x56, 0xd3, 0xe3, 0xbf, 0xa7, 0x08, 0x47, 0xf7, 0x31,
-	0xc1, 0xde, 0x45, 0x45, 0x04, 0x07, 0x23, 0x93, 0xdb, 0x70, 0x53, 0x0f, 0x8f, 0xc5, 0x17, 0xcb,
-	0x9b, 0x98, 0x7a, 0x78, 0x6c, 0xfc, 0x52, 0x80, 0x85, 0x73, 0x55, 0xf8, 0x6c, 0xe9, 0x8f, 0xc2,
-	0x10, 0x3d, 0x96, 0x26, 0x42, 0x55, 0xc9, 0xc4, 0x4d, 0x2e, 0x42, 0xc5, 0xc3, 0x97, 0x2c, 0x7d,
-	0xe5, 0x65, 0x2e, 0xb8, 0xe0, 0x9a, 0xbb, 0x50, 0xcf, 0xd0, 0x45, 0x20, 0x71, 0xc9, 0xd8, 0xcb,
-	0x5a, 0x90, 0x4f, 0x01, 0x68, 0x92, 0x66, 0xb3, 0x28, 0x1e, 0xe9, 0xfb, 0x57, 0x2c, 0xbc, 0xb5,
-	0xe1, 0xd9, 0xf8, 0x12, 0xed, 0x6e, 0xaa, 0x0b, 0x99, 0x29, 0x77, 0xfa, 0x07, 0x30, 0x93, 0xa3,
-	0xc2, 0x8b, 0x71, 0xb8, 0x58, 0xa0, 0x50, 0x34, 0xe5, 0x26, 0xa1, 0x46, 0x21, 0xc5, 0xd9, 0x07,
-	0x70, 0xeb, 0x39, 0x0d, 0x0f, 0xd3, 0x14, 0xea, 0x46, 0x26, 0x52, 0x3b, 0x7e, 0x6a, 0x39, 0x7c,
-	0x32, 0x96, 0xe0, 0xf6, 0x79, 0x46, 0x92, 0xb1, 0x06, 0x81, 0xc6, 0x3a, 0x32, 0xf5, 0xa0, 0xa5,
-	0x27, 0x63, 0x0d, 0xae, 0xa7, 0x64, 0x6f, 0xdd, 0x17, 0x3a, 0x7f, 0x69, 0x30, 0x1d, 0x57, 0xbb,
-	0x8d, 0xe1, 0x91, 0xd3, 0x47, 0x32, 0x82, 0x6a, 0x6a, 0x06, 0x90, 0xa5, 0x0b, 0xc6, 0x83, 0x48,
-	0x46, 0x5f, 0xbe, 0x74, 0x80, 0x18, 0xcb, 0x5f, 0xfd, 0xfa, 0xc7, 0xb7, 0x85, 0x45, 0xb2, 0xd0,
-	0x8e, 0x87, 0x40, 0xfb, 0x55, 0x66, 0x46, 0xbc, 0x26, 0x87, 0x50, 0x4b, 0x77, 0x3b, 0xb2, 0x7c,
-	0x69, 0xf3, 0xd5, 0x8d, 0x8b, 0x54, 0x54, 0xe4, 0x59, 0x11, 0x79, 0xca, 0xa8, 0x24, 0x91, 0x9f,
-	0x68, 0xf7, 0x3b, 0x1e, 0xd4, 0x25, 0x12, 0x71, 0xd1, 0x9f, 0x41, 0x25, 0x01, 0x94, 0xdc, 0x3e,
-	0x53, 0x50, 0x06, 0x7d, 0xfd, 0xce, 0xb9, 0xe7, 0x2a, 0xe8, 0xb4, 0x08, 0x5a, 0x21, 0xa5, 0xb6,
-	0xc2, 0xf9, 0xfb, 0x02, 0xcc, 0xa4, 0xaf, 0x38, 0x0e, 0xfb, 0x1a, 0xa6, 0x4f, 0x35, 0x2a, 0xf2,
-	0x9f, 0x4b, 0xfa, 0x98, 0x4c, 0xe1, 0xee, 0x95, 0xba, 0x9d, 0x71, 0x4b, 0x24, 0x32, 0x4f, 0x6e,
-	0xb4, 0xd3, 0x9d, 0x2e, 0x6a, 0xbf, 0x92, 0x98, 0x7f, 0xa3, 0xc1, 0x5c, 0x3e, 0xfb, 0xc8, 0xa9,
-	0xb9, 0x7b, 0x21, 0xb1, 0xf5, 0x77, 0xae, 0xa6, 0x9c, 0x4d, 0xea, 0x7e, 0x7e, 0x52, 0x4f, 0x6f,
-	0xc3, 0x4c, 0xdf, 0x1f, 0x66, 0x3d, 0x06, 0x7b, 0x9f, 0x94, 0xd4, 0xbf, 0xd4, 0xbd, 0x49, 0xf1,
-	0x45, 0xf9, 0xe0, 0x9f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x1b, 0x4b, 0xee, 0xfe, 0xbe, 0x0e, 0x00,
-	0x00,
+	// 3240 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x3a, 0xcd, 0x6f, 0x23, 0x49,
+	0xf5, 0xbf, 0xb6, 0xe3, 0xc4, 0x7e, 0x49, 0x1c, 0xa7, 0xf2, 0x31, 0x4e, 0x27, 0x99, 0x64, 0x2a,
+	0x99, 0xdd, 0x4c, 0xb2, 0x3f, 0x9b, 0xf1, 0x0c, 0xd2, 0x12, 0x10, 0xab, 0x4c, 0x92, 0x19, 0x66,
+	0x77, 0x76, 0x66, 0xe8, 0x64, 0x47, 0xda, 0x41, 0x8b, 0xe9, 0xd8, 0x95, 0xa4, 0x77, 0xec, 0x6e,
+	0x6f, 0x77, 0x3b, 0x33, 0x66, 0xbf, 0xa4, 0xfd, 0x38, 0xb0, 0x42, 0x42, 0x02, 0x0e, 0x1c, 0xb8,
+	0x72, 0x64, 0x2f, 0x88, 0x03, 0xdc, 0x10, 0x12, 0x17, 0x24, 0x16, 0x96, 0xeb, 0xc2, 0x09, 0x21,
+	0xc4, 0x5f, 0x81, 0xea, 0xab, 0xdd, 0xd5, 0x6e, 0xb7, 0x9d, 0x08, 0xb4, 0x27, 0x77, 0xbd, 0x7a,
+	0x55, 0xef, 0xb3, 0x5e, 0xbd, 0xf7, 0xca, 0xa0, 0x9b, 0x2d, 0xab, 0x7c, 0x76, 0xa3, 0x5c, 0x6b,
+	0x98, 0x96, 0xdb, 0x3a, 0xe2, 0xbf, 0xa5, 0x96, 0xeb, 0xf8, 0x0e, 0x9a, 0xa8, 0x39, 0x2e, 0x71,
+	0xbc, 0x12, 0x83, 0xe9, 0x2b, 0x27, 0x8e, 0x73, 0xd2, 0x20, 0x65, 0x36, 0x77, 0xd4, 0x3e, 0x2e,
+	0xfb, 0x56, 0x93, 0x78, 0xbe, 0xd9, 0x6c, 0x71, 0x74, 0x7d, 0x49, 0x20, 0xd0, 0x1d, 0x4d, 0xdb,
+	0x76, 0x7c, 0xd3, 0xb7, 0x1c, 0xdb, 0xe3, 0xb3, 0xf8, 0x2f, 0x29, 0x98, 0x7c, 0xd4, 0x6e, 0xd8,
+	0xc4, 0x35, 0x8f, 0xac, 0x86, 0xe5, 0x77, 0x10, 0x82, 0x11, 0xdb, 0x6c, 0x92, 0xa2, 0xb6, 0xaa,
+	0x6d, 0xe4, 0x0c, 0xf6, 0x8d, 0xae, 0x42, 0x9e, 0xfe, 0x7a, 0x2d, 0xb3, 0x46, 0xaa, 0x6c, 0x36,
+	0xc5, 0x66, 0x27, 0x03, 0xe8, 0x7d, 0x8a, 0xb6, 0x0a, 0xe3, 0x75, 0xe2, 0xd5, 0x5c, 0xab, 0x45,
+	0x49, 0x14, 0xd3, 0x0c, 0x27, 0x0c, 0xa2, 0x9b, 0x37, 0x2c, 0xfb, 0x49, 0x71, 0x84, 0x6f, 0x4e,
+	0xbf, 0x91, 0x0e, 0x59, 0x8f, 0x9c, 0x11, 0xd7, 0xf2, 0x3b, 0xc5, 0x0c, 0x83, 0x07, 0x63, 0x3a,
+	0xd7, 0x24, 0xbe, 0x59, 0x37, 0x7d, 0xb3, 0x38, 0xca, 0xe7, 0xe4, 0x18, 0x2d, 0x40, 0xf6, 0xd8,
+	0x7a, 0x46, 0xea, 0xd5, 0xa3, 0x4e, 0x71, 0x8c, 0xcd, 0x8d, 0xb1, 0xf1, 0xad, 0x0e, 0xba, 0x05,
+	0xd3, 0xe6, 0xf1, 0x31, 0xa9, 0xf9, 0xa4, 0x5e, 0x3d, 0x23, 0xae, 0x47, 0x05, 0x2e, 0x66, 0x57,
+	0xd3, 0x1b, 0xe3, 0x95, 0xb9, 0x52, 0x58, 0x7d, 0xa5, 0xdb, 0xc4, 0xf4, 0xdb, 0x2e, 0x31, 0x0a,
+	0x12, 0xff, 0x91, 0x40, 0x47, 0x97, 0x01, 0xbc, 0x76, 0xab, 0xe5, 0x12, 0xcf, 0x23, 0xf5, 0x62,
+	0x6e, 0x55, 0xdb, 0xc8, 0x1a, 0x21, 0x08, 0x2a, 0xc2, 0x98, 0xd9, 0xb0, 0x4c, 0x8f, 0x78, 0x45,
+	0x58, 0x4d, 0x53, 0xea, 0x62, 0x88, 0xff, 0xa4, 0x41, 0x76, 0x8f, 0xf8, 0xa4, 0xe6, 0x3b, 0x6e,
+	0xac, 0x3a, 0x8b, 0x30, 0x26, 0xb8, 0x12, 0x7a, 0x94, 0x43, 0x54, 0x81, 0x4c, 0xdd, 0xef, 0xb4,
+	0x08, 0xd3, 0x5d, 0xbe, 0xb2, 0xa4, 0x32, 0x2b, 0x37, 0x2d, 0xed, 0x1d, 0x76, 0x5a, 0xc4, 0xe0,
+	0xa8, 0xf8, 0x7b, 0x90, 0x61, 0x63, 0xb4, 0x08, 0x97, 0xf6, 0xf6, 0x0f, 0xf7, 0x77, 0x0f, 0x1f,
+	0x18, 0xd5, 0xbd, 0xea, 0xe1, 0xeb, 0x0f, 0xf7, 0xab, 0x77, 0xef, 0x3f, 0xda, 0xb9, 0x77, 0x77,
+	0xaf, 0xf0, 0x7f, 0x68, 0x19, 0x16, 0xa2, 0x93, 0xf7, 0x77, 0x5e, 0xdd, 0x3f, 0x78, 0xb8, 0xb3,
+	0xbb, 0x5f, 0xd0, 0xe2, 0xd6, 0xde, 0xde, 0xdf, 0x39, 0x7c, 0xcd, 0xd8, 0x2f, 0xa4, 0xf0, 0x01,
+	0xe4, 0xee, 0x4b, 0x43, 0xc7, 0x0a, 0x54, 0x81, 0x6c, 0x5d, 0xf0, 0xc6, 0x24, 0x1a, 0xaf, 0xcc,
+	0xc7, 0x73, 0x6e, 0x04, 0x78, 0xf8, 0x57, 0x29, 0x18, 0x13, 0xda, 0x8f, 0xdd, 0xf3, 0xab, 0x90,
+	0x0b, 0xbc, 0x4b, 0x6c, 0x7a, 0x49, 0xdd, 0x34, 0xe0, 0xc9, 0xe8, 0x62, 0x86, 0x75, 0x9b, 0x56,
+	0x75, 0x7b, 0x15, 0xf2, 0xe2, 0xb3, 0x7a, 0xec, 0xb8, 0x4d, 0xd3, 0x17, 0x5e, 0x38, 0x29, 0xa0,
+	0xb7, 0x19, 0x50, 0x91, 0x25, 0x33, 0x9c, 0x2c, 0x68, 0x1f, 0xa6, 0xce, 0x42, 0x87, 0xc8, 0x22,
+	0x5e, 0x71, 0x94, 0x79, 0xdb, 0xa2, 0xba, 0x54, 0x39, 0x69, 0x46, 0x74, 0x0d, 0xba, 0x02, 0x13,
+	0xc7, 0x5c, 0x23, 0x55, 0xe6, 0x04, 0xdc, 0xab, 0xc7, 0x05, 0x8c, 0xda, 0x18, 0x2f, 0x42, 0xe6,
+	0x9e, 0xd9, 0x21, 0xcc, 0xaf, 0x4e, 0x4d, 0xef, 0x54, 0xaa, 0x8c, 0x7e, 0xe3, 0x1f, 0x68, 0x30,
+	0xbe, 0x4b, 0x09, 0x1d, 0xf8, 0xa6, 0xdf, 0xf6, 0xd0, 0x4d, 0xc8, 0x49, 0x16, 0xbd, 0xa2, 0xb6,
+	0x9a, 0x4e, 0x90, 0xa5, 0x8b, 0x88, 0xf6, 0xa0, 0xd0, 0x30, 0x3d, 0xbf, 0xda, 0x6e, 0xd5, 0x4d,
+	0x9f, 0x54, 0x69, 0x3c, 0x11, 0xfa, 0xd7, 0x4b, 0x3c, 0x96, 0x94, 0x64, 0xb0, 0x29, 0x1d, 0xca,
+	0x60, 0x63, 0xe4, 0xe9, 0x9a, 0xd7, 0xd8, 0x12, 0x0a, 0xc4, 0x5f, 0x68, 0x80, 0xee, 0x10, 0x7f,
+	0xc7, 0xae, 0x11, 0xcf, 0x77, 0x3b, 0x06, 0x79, 0xab, 0x4d, 0x3c, 0x1f, 0xad, 0xc1, 0xa4, 0x29,
+	0x40, 0xd5, 0x90, 0xc9, 0x27, 0x24, 0x90, 0xc5, 0x91, 0xff, 0x07, 0x64, 0xd9, 0xb5, 0x46, 0xbb,
+	0x4e, 0xaa, 0xa1, 0x23, 0x98, 0x62, 0x47, 0x70, 0x5a, 0xcc, 0x1c, 0x04, 0x13, 0xe8, 0x1a, 0x14,
+	0x9a, 0x96, 0x6d, 0x35, 0xdb, 0xcd, 0x6a, 0x10, 0x48, 0xb8, 0xed, 0xa7, 0x04, 0xfc, 0x40, 0x80,
+	0xd1, 0x32, 0x00, 0x8f, 0x19, 0x8e, 0xdd, 0xe8, 0x30, 0xfb, 0x67, 0x8d, 0x1c, 0x83, 0x3c, 0xb0,
+	0x1b, 0x1d, 0x34, 0x0b, 0x99, 0x86, 0xd5, 0xb4, 0x7c, 0x66, 0xf8, 0x8c, 0xc1, 0x07, 0x54, 0xd5,
+	0x2d, 0xf3, 0x84, 0x88, 0x00, 0xc4, 0xbe, 0xf1, 0x67, 0x69, 0x98, 0x51, 0xc4, 0xf3, 0x5a, 0x8e,
+	0xed, 0x11, 0x74, 0x1b, 0xb2, 0x52, 0x14, 0x26, 0xda, 0x78, 0x65, 0x53, 0xd5, 0x78, 0xcc, 0xa2,
+	0x52, 0x00, 0x08, 0xd6, 0xa2, 0xeb, 0x30, 0xea, 0x31, 0x23, 0x0a, 0xd5, 0x2f, 0xa8, 0xbb, 0x84,
+	0xac, 0x6c, 0x08, 0x44, 0xea, 0x3d, 0xb5, 0xb6, 0xeb, 0x12, 0xdb, 0xaf, 0x32, 0x76, 0x45, 0xf8,
+	0x15, 0xb0, 0x87, 0xe6, 0x09, 0x8d, 0x10, 0x39, 0x9b, 0x3c, 0x13, 0xf3, 0xdc, 0xfb, 0xb3, 0x14,
+	0xc0, 0x26, 0x63, 0x85, 0xd7, 0xdf, 0x83, 0x49, 0xc9, 0x1e, 0x77, 0xbc, 0x6b, 0x90, 0x69, 0xd0,
+	0x0f, 0x21, 0xde, 0x8c, 0xca, 0x18, 0xc3, 0x31, 0x38, 0x06, 0x0d, 0xc3, 0xdc, 0xad, 0x48, 0xbd,
+	0x2a, 0x9c, 0x98, 0xca, 0x93, 0x14, 0x86, 0x25, 0xbe, 0x00, 0x78, 0xfa, 0x09, 0x64, 0x25, 0xfd,
+	0xd8, 0x30, 0x71, 0x07, 0x46, 0x19, 0x31, 0xaf, 0x98, 0x66, 0x1b, 0x97, 0x87, 0x57, 0x37, 0xe7,
+	0x55, 0x2c, 0xc7, 0x7f, 0x4b, 0xc1, 0xcc, 0x43, 0xc7, 0xbb, 0x98, 0xc7, 0xce, 0xc3, 0xa8, 0x88,
+	0x29, 0x3c, 0xa0, 0x8b, 0x11, 0xda, 0x8d, 0x70, 0xb7, 0xa5, 0x72, 0x17, 0x43, 0x8f, 0xc1, 0x14,
+	0xce, 0xf4, 0xdf, 0x6b, 0x90, 0x0b, 0xa0, 0x71, 0x07, 0x9f, 0x7b, 0xa8, 0x7f, 0x2a, 0x88, 0xb3,
+	0x6f, 0x64, 0xc0, 0xd8, 0x29, 0x31, 0xeb, 0x5d, 0xda, 0x2f, 0x9e, 0x83, 0x76, 0xe9, 0x5b, 0x7c,
+	0xe9, 0xbe, 0x4d, 0x67, 0xe5, 0x46, 0xfa, 0x36, 0x4c, 0x84, 0x27, 0x50, 0x01, 0xd2, 0x4f, 0x48,
+	0x47, 0xb0, 0x42, 0x3f, 0xa9, 0x13, 0x9d, 0x99, 0x8d, 0xb6, 0x4c, 0x10, 0xf8, 0x60, 0x3b, 0xf5,
+	0xa2, 0x86, 0xef, 0xc2, 0xac, 0x4a, 0x52, 0x9c, 0x98, 0xae, 0xa7, 0x6b, 0x43, 0x7a, 0x3a, 0x7e,
+	0x0c, 0x73, 0xa1, 0xad, 0x2c, 0xe2, 0x49, 0x5b, 0xed, 0x00, 0x98, 0x01, 0x50, 0x44, 0xbc, 0x2b,
+	0x03, 0xc5, 0x36, 0x42, 0x8b, 0xf0, 0xc7, 0x29, 0x98, 0x8f, 0x6e, 0x2e, 0x38, 0x3d, 0x88, 0xd9,
+	0xfd, 0x46, 0xdf, 0xdd, 0x43, 0x2b, 0x03, 0x8f, 0x13, 0x72, 0x84, 0xb6, 0xb9, 0xc0, 0x41, 0xd7,
+	0x6b, 0x90, 0x57, 0x37, 0x1c, 0xce, 0x47, 0x11, 0x8c, 0xd4, 0x9c, 0x3a, 0xb7, 0x4c, 0xc6, 0x60,
+	0xdf, 0xf4, 0xb6, 0x6c, 0x12, 0xcf, 0xeb, 0x86, 0x0b, 0x39, 0xc4, 0xdf, 0x80, 0xb9, 0x3d, 0xd2,
+	0x20, 0x3e, 0xb9, 0xc8, 0x79, 0xc0, 0x45, 0x98, 0x8f, 0xae, 0xe6, 0xaa, 0xa0, 0xfb, 0x1a, 0xc4,
+	0xab, 0x99, 0xf6, 0x85, 0xf6, 0x7d, 0x05, 0xe6, 0xa3, 0xab, 0x2f, 0xee, 0x46, 0xaf, 0x40, 0xe1,
+	0xae, 0x5d, 0x27, 0xcf, 0x0e, 0x6e, 0x3d, 0x78, 0xf5, 0x5c, 0xa7, 0x1d, 0xc1, 0x88, 0x77, 0xe4,
+	0x34, 0xe5, 0x71, 0xa3, 0xdf, 0xf8, 0xcf, 0x29, 0x98, 0x0e, 0xed, 0xf6, 0xe5, 0x5f, 0x07, 0x0f,
+	0x21, 0xd7, 0xb6, 0x9b, 0xa6, 0x5f, 0x3b, 0x25, 0x75, 0x11, 0x01, 0x2a, 0xea, 0xaa, 0x1e, 0x76,
+	0x4b, 0xaf, 0xc9, 0x05, 0xbb, 0x4e, 0xb3, 0xe5, 0xd8, 0xc4, 0xf6, 0x8d, 0xee, 0x26, 0xba, 0x0d,
+	0xa8, 0x17, 0xe1, 0x9c, 0x09, 0x2e, 0x8d, 0x54, 0x6d, 0xb7, 0x21, 0xbc, 0x8d, 0x7d, 0xd3, 0xe0,
+	0xe9, 0x12, 0xd3, 0x73, 0x6c, 0x71, 0x25, 0x89, 0x11, 0xfe, 0x54, 0x83, 0xf9, 0x3b, 0xc4, 0xbf,
+	0xef, 0xf8, 0xd6, 0xb1, 0x55, 0x63, 0x65, 0x8b, 0x34, 0xd3, 0x4d, 0x98, 0x77, 0x1a, 0xf5, 0x6a,
+	0x38, 0x81, 0xea, 0xf0, 0x5b, 0x8d, 0xb3, 0x31, 0xeb, 0x34, 0xea, 0x4a, 0xb2, 0xc5, 0x6e, 0xb8,
+	0x9b, 0x30, 0x6f, 0x93, 0xa7, 0x71, 0xab, 0x38, 0x97, 0xb3, 0x36, 0x79, 0xda, 0xbb, 0x2a, 0xb8,
+	0x17, 0xd3, 0x91, 0xa4, 0x80, 0x89, 0x3d, 0xd2, 0x15, 0x1b, 0x7f, 0x91, 0x82, 0x4b, 0x3d, 0x0c,
+	0x0b, 0x4f, 0x78, 0x04, 0x13, 0x76, 0x08, 0x2e, 0xbc, 0xa1, 0xd2, 0xe3, 0x0d, 0x71, 0x8b, 0x4b,
+	0x0a, 0x50, 0xd9, 0x47, 0xff, 0x97, 0x06, 0x13, 0xe1, 0xe9, 0x7e, 0xf6, 0xa8, 0xb9, 0xc4, 0xf4,
+	0x45, 0x16, 0x95, 0x33, 0xe4, 0x90, 0x16, 0x58, 0x7c, 0x3b, 0xe6, 0x24, 0x3c, 0x21, 0x10, 0x63,
+	0xba, 0xaa, 0xce, 0x0e, 0x71, 0x5d, 0x48, 0x29, 0x87, 0xe8, 0x6b, 0x90, 0x76, 0x1a, 0x75, 0x91,
+	0x1e, 0x3f, 0x1f, 0x09, 0x81, 0xe6, 0x09, 0x09, 0x74, 0xdf, 0x20, 0xa1, 0x68, 0x48, 0xd7, 0xd0,
+	0xa5, 0x36, 0x79, 0x5a, 0x1c, 0x3d, 0xe7, 0x52, 0x9b, 0x3c, 0xc5, 0x9f, 0xa7, 0x60, 0xa1, 0x2f,
+	0x4a, 0x4f, 0xfa, 0xa3, 0x0d, 0x48, 0x7f, 0x52, 0xfd, 0xd2, 0x1f, 0xc5, 0xcc, 0x3b, 0x30, 0xa9,
+	0xb8, 0x0b, 0xd3, 0xc4, 0x80, 0xbc, 0x5e, 0x5d, 0x81, 0xbe, 0xa3, 0x5c, 0x1b, 0x19, 0x76, 0x12,
+	0xbf, 0x3e, 0xa4, 0xe0, 0xfc, 0x8c, 0x92, 0xfa, 0x4e, 0x28, 0xfc, 0x84, 0xaf, 0x0f, 0xfd, 0x25,
+	0x98, 0x89, 0x41, 0xa1, 0xc2, 0x58, 0x14, 0xcc, 0xb4, 0x90, 0x31, 0xf8, 0x20, 0x70, 0x8d, 0x54,
+	0xc8, 0x67, 0x1f, 0x43, 0xf1, 0x9e, 0xe5, 0x29, 0x6e, 0x17, 0x5c, 0xa7, 0x32, 0xf1, 0xd5, 0xba,
+	0x89, 0x6f, 0x57, 0x4d, 0xa9, 0xb0, 0x9a, 0x66, 0x21, 0xe3, 0xf9, 0xa6, 0x2f, 0x6f, 0x11, 0x3e,
+	0xc0, 0x9f, 0xa6, 0x61, 0x21, 0x66, 0x73, 0x71, 0x22, 0x1e, 0xc3, 0x64, 0xd8, 0x93, 0xe5, 0x8d,
+	0x7a, 0x33, 0x92, 0x50, 0xf6, 0x5b, 0xaf, 0x1e, 0x0a, 0x75, 0xab, 0x1e, 0x67, 0x48, 0x0d, 0x70,
+	0x86, 0x74, 0x3f, 0x67, 0x18, 0x09, 0xe7, 0xc2, 0x7f, 0xff, 0x32, 0xce, 0x5a, 0xa0, 0xda, 0x4c,
+	0x48, 0xb5, 0xb4, 0x44, 0x52, 0xc3, 0x18, 0xe3, 0x83, 0x57, 0x28, 0xd3, 0xca, 0xcc, 0xfd, 0xf8,
+	0x06, 0xce, 0x58, 0x4c, 0x03, 0x07, 0xdf, 0x80, 0xe5, 0x57, 0x4d, 0xf7, 0x49, 0x58, 0xc6, 0x1d,
+	0xcf, 0x20, 0x66, 0x3d, 0xe4, 0x11, 0x51, 0x81, 0xf1, 0x2a, 0x5c, 0xee, 0xb7, 0x48, 0xdc, 0xf9,
+	0xef, 0xd3, 0x6c, 0xc0, 0xac, 0xdf, 0x23, 0xbe, 0x4f, 0xdc, 0x61, 0x14, 0xd8, 0x32, 0x3b, 0x0d,
+	0xc7, 0x0c, 0x14, 0x28, 0x86, 0xb4, 0x7a, 0x63, 0x95, 0x29, 0x71, 0x5d, 0xc7, 0x15, 0x2a, 0xcc,
+	0x51, 0xc8, 0x3e, 0x05, 0x84, 0x35, 0x3f, 0xa2, 0x68, 0x1e, 0xaf, 0x03, 0xa6, 0x7e, 0x14, 0xcf,
+	0x84, 0x74, 0x77, 0xfc, 0x16, 0xac, 0x25, 0x62, 0x09, 0xbf, 0x7d, 0x39, 0xde, 0x6f, 0xd7, 0xa3,
+	0x95, 0x75, 0xdc, 0x2e, 0x11, 0x3f, 0xc5, 0x2f, 0x02, 0x36, 0x88, 0xef, 0x76, 0xfa, 0x60, 0x27,
+	0x68, 0xfd, 0x2a, 0xac, 0x25, 0xae, 0x14, 0xaa, 0x47, 0x50, 0xb8, 0x43, 0x7c, 0x91, 0x1a, 0x08,
+	0x39, 0x6f, 0xc3, 0x74, 0x08, 0x76, 0xf1, 0xfc, 0xe9, 0x03, 0x0d, 0x80, 0x57, 0xfc, 0xae, 0xd1,
+	0xb6, 0xa9, 0xfa, 0x3d, 0xdf, 0x74, 0xa9, 0xfa, 0x39, 0xa3, 0x72, 0x48, 0x1d, 0xff, 0xd8, 0xb2,
+	0x2d, 0xef, 0x34, 0x38, 0x13, 0xc1, 0x18, 0x6d, 0xf4, 0xb6, 0x4e, 0x78, 0x00, 0x8e, 0x82, 0xe9,
+	0x41, 0xe0, 0x86, 0xe7, 0xc6, 0xe5, 0x03, 0xfc, 0x04, 0xc6, 0x04, 0x0f, 0xb1, 0xce, 0x74, 0x19,
+	0x20, 0x70, 0x71, 0x5e, 0x7b, 0xe6, 0x8c, 0x10, 0x04, 0xbd, 0x00, 0x23, 0x6e, 0xdb, 0x96, 0x25,
+	0x52, 0x51, 0x15, 0xba, 0x2b, 0x9c, 0xc1, 0xb0, 0x70, 0x05, 0x66, 0xa8, 0x87, 0x08, 0x78, 0x10,
+	0x27, 0x17, 0x21, 0xe7, 0xb6, 0xed, 0x2a, 0x8f, 0x18, 0x3c, 0xe2, 0x66, 0xdd, 0xb6, 0x7d, 0x8f,
+	0x8e, 0x69, 0xdd, 0xa3, 0xae, 0x09, 0x14, 0x9e, 0x6d, 0x0b, 0x58, 0x51, 0x8b, 0xab, 0x89, 0x25,
+	0xf5, 0x00, 0x0d, 0xff, 0xa1, 0xab, 0xf0, 0x97, 0x9d, 0x23, 0x94, 0x87, 0x94, 0x25, 0x75, 0x9d,
+	0xb2, 0x58, 0x0c, 0x11, 0xa8, 0xf2, 0xe0, 0x88, 0x21, 0xcd, 0xb0, 0x84, 0x71, 0xf9, 0xa1, 0x11,
+	0xa3, 0xb0, 0xc9, 0x46, 0xfa, 0x9b, 0x2c, 0x13, 0x31, 0xd9, 0x26, 0xa4, 0xdd, 0xb6, 0x2d, 0xae,
+	0xf0, 0xfe, 0x2a, 0xa3, 0x48, 0x5d, 0xa3, 0x8d, 0x85, 0x8d, 0xb6, 0x05, 0x73, 0x87, 0xae, 0x75,
+	0x72, 0x42, 0x5c, 0x89, 0x9f, 0xe0, 0xe9, 0x7b, 0x30, 0x1f, 0x45, 0x16, 0x2a, 0xdc, 0x84, 0xf4,
+	0x9b, 0xce, 0x51, 0x51, 0x4b, 0x60, 0xe4, 0x65, 0xe7, 0xc8, 0xa0, 0x48, 0x78, 0x1b, 0x66, 0xef,
+	0x10, 0x3f, 0x04, 0xed, 0x4f, 0x51, 0x28, 0x36, 0x25, 0x15, 0x8b, 0x77, 0x61, 0x2e, 0xb2, 0xf6,
+	0x02, 0x0c, 0xbc, 0x0f, 0xd3, 0x41, 0xc3, 0x72, 0xd7, 0x39, 0x23, 0x2e, 0xbd, 0x67, 0xfa, 0x34,
+	0xdb, 0x23, 0x7d, 0xca, 0x54, 0x5c, 0x9f, 0xb2, 0x0c, 0x33, 0xea, 0x0d, 0x50, 0x73, 0xda, 0x36,
+	0xcf, 0x5e, 0xd2, 0x86, 0x7a, 0x39, 0xec, 0xd2, 0x19, 0x7c, 0x09, 0xe6, 0xd8, 0x65, 0x1a, 0x38,
+	0xbf, 0x8c, 0x07, 0xbf, 0xd4, 0x60, 0x3e, 0x3a, 0x23, 0x04, 0x7c, 0x49, 0x39, 0x3e, 0xdc, 0x4d,
+	0x57, 0xfa, 0x74, 0x61, 0xa5, 0x50, 0xca, 0xf9, 0x52, 0x5a, 0x90, 0xa9, 0x61, 0x5b, 0x90, 0x4b,
+	0x90, 0x73, 0xc9, 0xb1, 0x4b, 0xbc, 0xd3, 0xe0, 0xaa, 0xec, 0x02, 0xf0, 0x4f, 0x35, 0x40, 0x8f,
+	0x7a, 0xe4, 0x8b, 0xb9, 0xe3, 0xb4, 0xb8, 0x47, 0x8a, 0x21, 0xd5, 0x1b, 0x7e, 0x95, 0x48, 0x47,
+	0x5e, 0x25, 0x66, 0x21, 0xc3, 0x95, 0x3d, 0xc2, 0x94, 0xcd, 0x07, 0xf8, 0x43, 0x0d, 0x0a, 0xc2,
+	0xe8, 0xb7, 0x29, 0xa7, 0x36, 0xf1, 0xbc, 0x58, 0x03, 0x2f, 0x40, 0x96, 0x5d, 0x63, 0xf4, 0x10,
+	0x89, 0x83, 0x4a, 0xc7, 0x34, 0x86, 0x5e, 0x81, 0x09, 0x36, 0xe5, 0xb5, 0x6b, 0x35, 0xe2, 0xc9,
+	0xe3, 0x3a, 0x4e, 0x61, 0x07, 0x1c, 0x14, 0xb9, 0x04, 0x47, 0x22, 0x97, 0x20, 0x9e, 0x67, 0x7e,
+	0x4e, 0x23, 0xb5, 0xe5, 0xf9, 0x56, 0x2d, 0x30, 0xf2, 0x1f, 0x53, 0x30, 0x17, 0x99, 0x08, 0xee,
+	0xb3, 0x9e, 0x08, 0xcc, 0x0d, 0xbd, 0x9a, 0x90, 0xe4, 0x32, 0x95, 0xf7, 0xc6, 0xe8, 0xab, 0x90,
+	0x0f, 0xca, 0x67, 0xae, 0xa2, 0x14, 0x53, 0x51, 0x50, 0x54, 0x73, 0x53, 0xad, 0xc0, 0x38, 0xeb,
+	0x6d, 0x29, 0x3e, 0x0b, 0x0c, 0xc4, 0x11, 0xd6, 0x60, 0x52, 0x76, 0xc2, 0xc3, 0x9a, 0x96, 0xed,
+	0x71, 0x8e, 0xb4, 0x1d, 0x8a, 0xa0, 0x3c, 0xad, 0xbe, 0x1c, 0x7b, 0x04, 0x03, 0x6b, 0x74, 0x43,
+	0x29, 0xe7, 0x20, 0x68, 0x72, 0x8b, 0xc4, 0x09, 0xba, 0x3d, 0x6c, 0xd5, 0x05, 0xc7, 0xa2, 0x2e,
+	0xf8, 0x4f, 0x0d, 0xa6, 0x64, 0x07, 0x9a, 0x5e, 0xb7, 0xed, 0x06, 0x09, 0x85, 0xe3, 0x34, 0x0b,
+	0xc7, 0xf1, 0x29, 0x5a, 0x6a, 0xf8, 0x14, 0x2d, 0x1d, 0xe7, 0xbe, 0xa1, 0x37, 0x82, 0x50, 0xfd,
+	0x29, 0xdf, 0x08, 0x64, 0x33, 0x52, 0xd4, 0xd3, 0x99, 0x70, 0x3d, 0x1d, 0xce, 0x8f, 0x46, 0xd5,
+	0xcc, 0xb4, 0x08, 0x63, 0xe4, 0x59, 0xcb, 0x72, 0x89, 0x27, 0x5f, 0xd2, 0xc4, 0x10, 0x7f, 0x1b,
+	0x96, 0x76, 0x19, 0x52, 0x44, 0x5a, 0x19, 0x3e, 0xaf, 0xd3, 0xfb, 0xb3, 0x41, 0x44, 0x08, 0x5c,
+	0x56, 0xf5, 0x1f, 0x5d, 0xc3, 0x50, 0xb1, 0x01, 0xcb, 0x7d, 0xb6, 0x0c, 0x6e, 0xc6, 0x73, 0xef,
+	0xb9, 0x05, 0x0b, 0xd4, 0xb9, 0xe3, 0x79, 0x8c, 0x18, 0x06, 0x3f, 0x00, 0x3d, 0x0e, 0xf9, 0xe2,
+	0xd4, 0x97, 0x61, 0x91, 0xc6, 0xcf, 0xc8, 0x64, 0x70, 0xf4, 0x0e, 0x60, 0x29, 0x7e, 0x5a, 0x50,
+	0xbc, 0x01, 0x19, 0xba, 0x8d, 0x3c, 0x76, 0x03, 0x48, 0x72, 0x5c, 0x6c, 0xc2, 0x12, 0xf7, 0xd4,
+	0xe1, 0x84, 0x0e, 0xc4, 0x4a, 0x9d, 0xcb, 0x50, 0x7d, 0x48, 0x5c, 0x5c, 0x55, 0x25, 0x58, 0xe2,
+	0x8d, 0xc1, 0x21, 0x6d, 0xb5, 0x02, 0xcb, 0x7d, 0xf0, 0x45, 0x82, 0x7b, 0xc8, 0x5a, 0x2e, 0x6a,
+	0x01, 0x2e, 0xf6, 0x1a, 0xf2, 0x42, 0x88, 0xab, 0x8a, 0xdf, 0x80, 0x62, 0xef, 0xae, 0x42, 0xea,
+	0x9e, 0x96, 0x80, 0x76, 0xde, 0x96, 0x00, 0x6e, 0x82, 0x4e, 0x3d, 0xe2, 0x91, 0x1a, 0x3d, 0xcf,
+	0xcf, 0x77, 0xa8, 0xb6, 0x8d, 0x54, 0xe7, 0xe1, 0x26, 0x06, 0xfe, 0xad, 0x06, 0x8b, 0xb1, 0xf4,
+	0x84, 0x44, 0xfb, 0xfd, 0x6e, 0x80, 0x73, 0x3f, 0x5f, 0xfe, 0xf7, 0x8b, 0x6e, 0xfc, 0x3a, 0x14,
+	0x76, 0xc4, 0xdb, 0x7c, 0xe2, 0x43, 0xd0, 0x75, 0xc8, 0x0e, 0xf7, 0xc6, 0x14, 0xa0, 0xd1, 0x1b,
+	0x7b, 0x89, 0x36, 0x60, 0xd5, 0xed, 0x2f, 0x64, 0x89, 0xa8, 0x07, 0x05, 0xd6, 0x49, 0xc7, 0x59,
+	0x47, 0x11, 0xf0, 0xd7, 0x1a, 0x2c, 0xf7, 0xe1, 0x42, 0xd8, 0xe7, 0x9b, 0x31, 0x0f, 0x0f, 0x91,
+	0xab, 0x2e, 0xaa, 0x22, 0xe5, 0x8d, 0xe1, 0x7f, 0x62, 0x98, 0xca, 0xe7, 0x19, 0x98, 0x0a, 0xde,
+	0x21, 0x88, 0x7b, 0x66, 0xd5, 0x08, 0x6a, 0xc3, 0x78, 0xa8, 0xa3, 0x8d, 0x56, 0x13, 0x9a, 0xdd,
+	0x4c, 0xc3, 0xfa, 0x95, 0x81, 0xed, 0x70, 0x7c, 0xe5, 0x83, 0xbf, 0xfe, 0xe3, 0x27, 0xa9, 0x45,
+	0xb4, 0x50, 0x96, 0x49, 0x44, 0xf9, 0x6d, 0xa5, 0x47, 0xff, 0x2e, 0x7a, 0x02, 0x13, 0xe1, 0x77,
+	0x1d, 0x34, 0xf8, 0xcd, 0x47, 0xc7, 0x49, 0x28, 0x82, 0xf2, 0x2c, 0xa3, 0x9c, 0xdf, 0xd6, 0x36,
+	0x71, 0x2e, 0x20, 0x8e, 0x3c, 0xc8, 0xab, 0xcf, 0x3c, 0x68, 0x2d, 0xf9, 0x11, 0x88, 0x13, 0x5c,
+	0x1f, 0xe6, 0xa5, 0x08, 0xcf, 0x33, 0x92, 0x05, 0x3c, 0x5e, 0xee, 0xda, 0x6f, 0x5b, 0xdb, 0x44,
+	0xef, 0x41, 0x5e, 0x7d, 0x50, 0x89, 0x12, 0x8d, 0x7d, 0xac, 0xd1, 0xd7, 0x93, 0x91, 0x54, 0x0d,
+	0x6f, 0x26, 0x68, 0xf8, 0x63, 0x0d, 0xf2, 0xea, 0xcb, 0x4b, 0x94, 0x81, 0xd8, 0x57, 0x1d, 0x7d,
+	0x3d, 0x19, 0x49, 0x30, 0xb0, 0xc1, 0x18, 0xc0, 0x78, 0xb5, 0x2f, 0x03, 0x65, 0x97, 0xad, 0x44,
+	0xdf, 0x85, 0x5c, 0xf0, 0x6c, 0x81, 0x2e, 0xf7, 0x7d, 0xcf, 0xe0, 0xc4, 0x57, 0x06, 0xbc, 0x77,
+	0xe0, 0x02, 0xa3, 0x0b, 0x38, 0x53, 0xa6, 0x6f, 0x38, 0xdb, 0xda, 0x66, 0xe5, 0x37, 0x69, 0x98,
+	0xe4, 0x6d, 0x0e, 0xe9, 0xd2, 0x6f, 0x40, 0x2e, 0xe8, 0x96, 0x44, 0x29, 0x46, 0x5b, 0x2b, 0xfa,
+	0x4a, 0xdf, 0x79, 0x41, 0x71, 0x8a, 0x51, 0xcc, 0xa1, 0xb1, 0xb2, 0x28, 0xc1, 0x4f, 0x61, 0x22,
+	0xdc, 0x1e, 0x88, 0xba, 0x6e, 0x4c, 0xbb, 0x41, 0xc7, 0x49, 0x28, 0x82, 0xce, 0x34, 0xa3, 0x33,
+	0x8e, 0x72, 0xe5, 0x20, 0xe5, 0x6d, 0x41, 0x5e, 0xad, 0xf2, 0xa2, 0x16, 0x8c, 0xad, 0x0e, 0xf5,
+	0xf5, 0x64, 0x24, 0x41, 0x6f, 0x86, 0xd1, 0x9b, 0x44, 0xe3, 0xe5, 0x50, 0xf1, 0xd7, 0x84, 0x49,
+	0xa5, 0xe4, 0x40, 0x38, 0x56, 0x3d, 0x4a, 0xa1, 0xa2, 0xaf, 0x25, 0xe2, 0xf4, 0x90, 0xf3, 0x82,
+	0xc9, 0xca, 0x27, 0x29, 0xc8, 0x0b, 0x45, 0x48, 0xe3, 0x7d, 0x1f, 0xf2, 0x6a, 0xef, 0x20, 0x2a,
+	0x73, 0x6c, 0x1b, 0x42, 0x5f, 0x4f, 0x46, 0x12, 0x4c, 0x2c, 0x33, 0x26, 0x2e, 0xe1, 0xb9, 0x40,
+	0xc7, 0xe5, 0xb7, 0xb9, 0xb3, 0xbe, 0xe9, 0x1c, 0x79, 0xe8, 0x1d, 0x26, 0x7d, 0xa8, 0x5f, 0xd3,
+	0x2b, 0x7d, 0x4f, 0x3b, 0x42, 0x5f, 0x4b, 0xc4, 0x11, 0x84, 0x31, 0x23, 0xbc, 0x84, 0xf4, 0x58,
+	0xc2, 0xe5, 0xb7, 0xad, 0xfa, 0xbb, 0x95, 0x7f, 0x67, 0x60, 0x26, 0xdc, 0x11, 0x94, 0x1a, 0x79,
+	0x17, 0xa6, 0x22, 0xaf, 0x4c, 0x68, 0x7d, 0xc0, 0x23, 0x14, 0xe7, 0xec, 0xea, 0x50, 0x4f, 0x55,
+	0x52, 0x29, 0x68, 0xae, 0xac, 0x74, 0x3a, 0x05, 0x83, 0xe8, 0x1d, 0x98, 0xee, 0xe9, 0xe8, 0xa3,
+	0xe7, 0x06, 0xb6, 0xfc, 0x39, 0x0b, 0xcf, 0x0f, 0xf9, 0x34, 0x20, 0xa3, 0x28, 0xca, 0xab, 0x4c,
+	0xa0, 0x1f, 0x6b, 0x30, 0x1f, 0xdf, 0xab, 0x46, 0x91, 0x7f, 0x66, 0x24, 0xb6, 0xc1, 0xf5, 0x17,
+	0x86, 0x43, 0x56, 0x55, 0xb2, 0xd9, 0x47, 0x25, 0x3f, 0x13, 0xd9, 0x59, 0x9f, 0xbe, 0x33, 0xfa,
+	0x4a, 0xaf, 0xd4, 0xc9, 0x8d, 0x6c, 0xfd, 0xfa, 0x39, 0x56, 0xa8, 0x57, 0x1d, 0x9a, 0x28, 0xd7,
+	0x89, 0x59, 0x6f, 0x30, 0x4c, 0x0f, 0xfd, 0x42, 0x83, 0xc5, 0x84, 0x2e, 0x73, 0x94, 0xb5, 0xc1,
+	0xad, 0x6c, 0xfd, 0xfa, 0x39, 0x56, 0xa8, 0xb7, 0x13, 0x5e, 0x08, 0xb3, 0x26, 0x1d, 0xde, 0xa5,
+	0x1b, 0x54, 0x3e, 0xcb, 0x00, 0x0a, 0x15, 0x08, 0xd2, 0xd7, 0x3f, 0xd1, 0x60, 0x2e, 0xb6, 0xd4,
+	0x44, 0x91, 0x57, 0xf8, 0xa4, 0x12, 0x57, 0xdf, 0x1a, 0x0a, 0x57, 0x30, 0x5b, 0x64, 0xcc, 0x22,
+	0x9a, 0x32, 0x4c, 0x96, 0xbd, 0x2e, 0x92, 0x87, 0x3e, 0xe4, 0x7f, 0x88, 0x8b, 0x72, 0xf2, 0x7c,
+	0x6f, 0xb8, 0x8b, 0x67, 0x63, 0x63, 0x30, 0xa2, 0xe0, 0x41, 0x67, 0x3c, 0xcc, 0x22, 0xa4, 0x30,
+	0xc0, 0xc2, 0x02, 0xfa, 0x48, 0xe3, 0xed, 0xe8, 0xc8, 0x5a, 0x0f, 0x5d, 0xeb, 0xf5, 0x99, 0x3e,
+	0xf5, 0xac, 0xbe, 0x39, 0x0c, 0xaa, 0xe0, 0x65, 0x8e, 0xf1, 0x32, 0x85, 0x22, 0xca, 0xf8, 0x91,
+	0x06, 0x73, 0xb1, 0xb5, 0x65, 0xd4, 0x32, 0x49, 0x35, 0xae, 0xbe, 0x35, 0x14, 0xae, 0x7a, 0x0a,
+	0xf5, 0x18, 0xad, 0xd0, 0x04, 0xeb, 0x87, 0x9a, 0xfc, 0xc3, 0xcb, 0x00, 0x8e, 0x92, 0xca, 0x57,
+	0x7d, 0x6b, 0x28, 0x5c, 0xd5, 0x4e, 0x9b, 0x31, 0x1c, 0x55, 0x7e, 0x97, 0x86, 0x59, 0xa5, 0xdc,
+	0x92, 0x3e, 0xfd, 0x81, 0xc6, 0x5e, 0x74, 0x94, 0x39, 0xd4, 0x1b, 0x9b, 0xe3, 0x0a, 0x62, 0xfd,
+	0xb9, 0x41, 0x68, 0x82, 0xb1, 0x15, 0xc6, 0xd8, 0x02, 0xba, 0x54, 0x8e, 0x94, 0x78, 0x32, 0x64,
+	0x7d, 0xa4, 0xf1, 0x87, 0x90, 0x48, 0x41, 0x89, 0x36, 0x7a, 0x3d, 0x23, 0xbe, 0xc6, 0xd5, 0xaf,
+	0x0d, 0x81, 0xa9, 0x1e, 0x29, 0x54, 0x88, 0x72, 0x83, 0x7e, 0xae, 0xb1, 0x9e, 0x66, 0x6f, 0xe5,
+	0x84, 0x62, 0xfe, 0x65, 0xd3, 0xaf, 0xc8, 0xd3, 0xb7, 0x86, 0xc2, 0x15, 0xcc, 0x6c, 0x32, 0x66,
+	0xd6, 0x11, 0xee, 0xa3, 0x9a, 0x50, 0xda, 0x7e, 0xeb, 0x32, 0xcc, 0xd4, 0x9c, 0xa6, 0xba, 0x7b,
+	0xeb, 0xe8, 0xf1, 0x98, 0xf8, 0x53, 0xff, 0xd1, 0x28, 0xfb, 0x1b, 0xed, 0x8d, 0xff, 0x0c, 0x00,
+	0x45, 0xe1, 0x33, 0xb9, 0xed, 0x2f, 0x00, 0x00,
 }
@@ -17,6 +17,8 @@ package clairpb
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/versionfmt"
@@ -99,6 +101,169 @@ func NotificationFromDatabaseModel(dbNotification database.VulnerabilityNotifica
 	return &noti, nil
 }
 
+// NotificationSummaryFromDatabaseModel converts database notification summary
+// to api listed notification.
+func NotificationSummaryFromDatabaseModel(dbNotification database.NotificationSummary) *ListNotificationsResponse_Notification {
+	noti := &ListNotificationsResponse_Notification{
+		Name:              dbNotification.Name,
+		State:             string(dbNotification.State()),
+		VulnerabilityName: dbNotification.Vulnerability.Name,
+		NamespaceName:     dbNotification.Vulnerability.Namespace,
+	}
+
+	if !dbNotification.Created.IsZero() {
+		noti.Created = fmt.Sprintf("%d", dbNotification.Created.Unix())
+	}
+
+	if !dbNotification.Notified.IsZero() {
+		noti.Notified = fmt.Sprintf("%d", dbNotification.Notified.Unix())
+	}
+
+	if !dbNotification.Deleted.IsZero() {
+		noti.Deleted = fmt.Sprintf("%d", dbNotification.Deleted.Unix())
+	}
+
+	return noti
+}
+
+// UpdaterRunFromDatabaseModel converts database updater run to api updater
+// run.
+func UpdaterRunFromDatabaseModel(dbRun database.UpdaterRun) *UpdaterRun {
+	run := &UpdaterRun{
+		Vulnerabilities: int32(dbRun.Vulnerabilities),
+		Error:           dbRun.Error,
+	}
+
+	if !dbRun.Started.IsZero() {
+		run.Started = fmt.Sprintf("%d", dbRun.Started.Unix())
+	}
+
+	if !dbRun.Finished.IsZero() {
+		run.Finished = fmt.Sprintf("%d", dbRun.Finished.Unix())
+	}
+
+	return run
+}
+
+// NamespaceCoverageFromDatabaseModel converts database namespace with its
+// vulnerability count to api NamespaceCoverage.
+func NamespaceCoverageFromDatabaseModel(dbNamespace database.NamespaceWithVulnerabilityCount) *NamespaceCoverage {
+	return &NamespaceCoverage{
+		Name:               dbNamespace.Name,
+		VersionFormat:      dbNamespace.VersionFormat,
+		VulnerabilityCount: int64(dbNamespace.VulnerabilityCount),
+	}
+}
+
+// VulnerabilityCountFromDatabaseModel converts database vulnerability count
+// to api VulnerabilityCount.
+func VulnerabilityCountFromDatabaseModel(dbCount database.VulnerabilityCount) *VulnerabilityCount {
+	return &VulnerabilityCount{
+		NamespaceName: dbCount.Namespace.Name,
+		VersionFormat: dbCount.Namespace.VersionFormat,
+		Severity:      string(dbCount.Severity),
+		Count:         int64(dbCount.Count),
+	}
+}
+
+// UpdaterFreshnessFromDatabaseModel converts the database runs of an updater,
+// the most recent first, to api UpdaterFreshness.
+func UpdaterFreshnessFromDatabaseModel(name string, dbRuns []database.UpdaterRun) *UpdaterFreshness {
+	freshness := &UpdaterFreshness{Name: name}
+	if len(dbRuns) == 0 {
+		return freshness
+	}
+
+	freshness.LastRun = fmt.Sprintf("%d", dbRuns[0].Finished.Unix())
+	freshness.LastError = dbRuns[0].Error
+	for _, run := range dbRuns {
+		if run.Error == "" {
+			freshness.LastSuccess = fmt.Sprintf("%d", run.Finished.Unix())
+			break
+		}
+	}
+
+	return freshness
+}
+
+// DeadLetterNotificationFromDatabaseModel converts database dead-lettered
+// notification to api dead-lettered notification.
+func DeadLetterNotificationFromDatabaseModel(dbDeadLetter database.DeadLetterNotification) *DeadLetterNotification {
+	deadLetter := &DeadLetterNotification{
+		Name:      dbDeadLetter.Name,
+		Payload:   dbDeadLetter.Payload,
+		LastError: dbDeadLetter.LastError,
+	}
+
+	if !dbDeadLetter.Created.IsZero() {
+		deadLetter.Created = fmt.Sprintf("%d", dbDeadLetter.Created.Unix())
+	}
+
+	return deadLetter
+}
+
+// SuppressionRuleFromDatabaseModel converts database suppression rule to api
+// suppression rule.
+func SuppressionRuleFromDatabaseModel(dbRule database.SuppressionRule) *SuppressionRule {
+	rule := &SuppressionRule{
+		Id:                dbRule.ID,
+		VulnerabilityName: dbRule.Vulnerability,
+		NamespaceName:     dbRule.Namespace,
+		FeatureName:       dbRule.Feature,
+		Reason:            dbRule.Reason,
+	}
+
+	if !dbRule.Created.IsZero() {
+		rule.Created = fmt.Sprintf("%d", dbRule.Created.Unix())
+	}
+
+	if !dbRule.Expires.IsZero() {
+		rule.Expires = fmt.Sprintf("%d", dbRule.Expires.Unix())
+	}
+
+	return rule
+}
+
+// SuppressionRuleToDatabaseModel converts api suppression rule to database
+// suppression rule, ignoring its id and creation time.
+func SuppressionRuleToDatabaseModel(rule *SuppressionRule) (database.SuppressionRule, error) {
+	dbRule := database.SuppressionRule{
+		Vulnerability: rule.GetVulnerabilityName(),
+		Namespace:     rule.GetNamespaceName(),
+		Feature:       rule.GetFeatureName(),
+		Reason:        rule.GetReason(),
+	}
+
+	if rule.GetExpires() != "" {
+		expires, err := strconv.ParseInt(rule.GetExpires(), 10, 64)
+		if err != nil {
+			return database.SuppressionRule{}, fmt.Errorf("invalid suppression rule expiration time %q", rule.GetExpires())
+		}
+
+		dbRule.Expires = time.Unix(expires, 0).UTC()
+	}
+
+	return dbRule, nil
+}
+
+// VulnerabilityFilter selects the vulnerabilities converted to api
+// Vulnerability. Its zero value keeps every vulnerability.
+type VulnerabilityFilter struct {
+	// MinimumSeverity is the lowest severity kept, if not empty.
+	MinimumSeverity database.Severity
+	// FixedOnly keeps only the vulnerabilities fixed in a known version.
+	FixedOnly bool
+}
+
+// Keeps returns whether the vulnerability passes the filter.
+func (f VulnerabilityFilter) Keeps(dbVuln database.VulnerabilityWithFixedIn) bool {
+	if f.MinimumSeverity != "" && dbVuln.Severity.Compare(f.MinimumSeverity) < 0 {
+		return false
+	}
+
+	return !f.FixedOnly || dbVuln.FixedInVersion != ""
+}
+
 // VulnerabilityFromDatabaseModel converts database Vulnerability to api Vulnerability.
 func VulnerabilityFromDatabaseModel(dbVuln database.Vulnerability) (*Vulnerability, error) {
 	metaString := ""
@@ -117,6 +282,7 @@ func VulnerabilityFromDatabaseModel(dbVuln database.Vulnerability) (*Vulnerabili
 		Link:          dbVuln.Link,
 		Severity:      string(dbVuln.Severity),
 		Metadata:      metaString,
+		Aliases:       dbVuln.Aliases,
 	}, nil
 }
 
@@ -131,6 +297,48 @@ func VulnerabilityWithFixedInFromDatabaseModel(dbVuln database.VulnerabilityWith
 	return vuln, nil
 }
 
+// VulnerabilityWithAffectedFromDatabaseModel converts database
+// VulnerabilityWithAffected to api Vulnerability with its affected features.
+func VulnerabilityWithAffectedFromDatabaseModel(dbVuln database.VulnerabilityWithAffected) (*Vulnerability, error) {
+	vuln, err := VulnerabilityFromDatabaseModel(dbVuln.Vulnerability)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, affected := range dbVuln.Affected {
+		vuln.AffectedVersions = append(vuln.AffectedVersions, AffectedFeatureFromDatabaseModel(affected))
+	}
+
+	return vuln, nil
+}
+
+// AffectedFeatureFromDatabaseModel converts database AffectedFeature to api
+// Feature, whose version is the version fixing the vulnerability.
+func AffectedFeatureFromDatabaseModel(affected database.AffectedFeature) *Feature {
+	return &Feature{
+		Name:          affected.FeatureName,
+		Namespace:     &Namespace{Name: affected.Namespace.Name},
+		VersionFormat: affected.Namespace.VersionFormat,
+		Version:       affected.FixedInVersion,
+		FeatureType:   string(affected.FeatureType),
+	}
+}
+
+// AffectedAncestryFromDatabaseModel converts database AffectedAncestry to api
+// AffectedAncestry.
+func AffectedAncestryFromDatabaseModel(dbAncestry database.AffectedAncestry) *AffectedAncestry {
+	ancestry := &AffectedAncestry{
+		Name:     dbAncestry.Name,
+		Features: make([]*Feature, 0, len(dbAncestry.Features)),
+	}
+
+	for _, feature := range dbAncestry.Features {
+		ancestry.Features = append(ancestry.Features, NamespacedFeatureFromDatabaseModel(database.AncestryFeature{NamespacedFeature: feature}))
+	}
+
+	return ancestry
+}
+
 // NamespacedFeatureFromDatabaseModel converts database namespacedFeature to api Feature.
 func NamespacedFeatureFromDatabaseModel(feature database.AncestryFeature) *Feature {
 	version := feature.Feature.Version
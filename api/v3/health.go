@@ -0,0 +1,106 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/quay/clair/v3"
+	"github.com/quay/clair/v3/database"
+)
+
+// healthCheckInterval is how often the statuses reported by the health
+// service are refreshed.
+const healthCheckInterval = 10 * time.Second
+
+// updaterServiceName is the name of the service whose health also depends on
+// the freshness of the vulnerabilities.
+const updaterServiceName = "coreos.clair.UpdaterService"
+
+// healthChecker drives the statuses reported by the standard gRPC health
+// service.
+//
+// The server, as the "" service, and every service are serving as long as
+// the datastore is reachable. The updater service is also not serving once
+// the last update is older than updaterMaxAge, if not zero.
+type healthChecker struct {
+	store         database.Datastore
+	updaterMaxAge time.Duration
+	server        *health.Server
+	services      []string
+}
+
+func newHealthChecker(store database.Datastore, updaterMaxAge time.Duration) *healthChecker {
+	return &healthChecker{store: store, updaterMaxAge: updaterMaxAge, server: health.NewServer()}
+}
+
+// register registers the health service on the server, reporting the
+// statuses of the services registered so far.
+func (h *healthChecker) register(gsrv *grpc.Server) {
+	for service := range gsrv.GetServiceInfo() {
+		h.services = append(h.services, service)
+	}
+	healthpb.RegisterHealthServer(gsrv, h.server)
+}
+
+// check refreshes the statuses.
+func (h *healthChecker) check() {
+	reachable := h.store.Ping()
+	fresh := reachable
+	if reachable && h.updaterMaxAge > 0 {
+		lastUpdate, firstUpdate, err := clair.GetLastUpdateTime(h.store)
+		if err != nil {
+			log.WithError(err).Warning("health check: could not get the last update time")
+		}
+		fresh = err == nil && !firstUpdate && time.Since(lastUpdate) <= h.updaterMaxAge
+	}
+
+	h.server.SetServingStatus("", servingStatus(reachable))
+	for _, service := range h.services {
+		if service != updaterServiceName {
+			h.server.SetServingStatus(service, servingStatus(reachable))
+		}
+	}
+	h.server.SetServingStatus(updaterServiceName, servingStatus(fresh))
+}
+
+func servingStatus(serving bool) healthpb.HealthCheckResponse_ServingStatus {
+	if serving {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+// run refreshes the statuses until the context is done.
+func (h *healthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		h.check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/quay/clair/v3"
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/grpcutil"
+)
+
+// pingStore is a datastore whose pings fail once it is down.
+type pingStore struct {
+	database.Datastore
+	down int32
+}
+
+func (s *pingStore) Ping() bool { return atomic.LoadInt32(&s.down) == 0 }
+
+func TestHealthService(t *testing.T) {
+	memory, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer memory.Close()
+	store := &pingStore{Datastore: memory}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	checker := newHealthChecker(store, time.Hour)
+	gsrv := grpcutil.NewServer(nil, nil, nil, registerStandardServices(ctx, registerServices(store, clair.NewUpdaterJobs(ctx, nil, store), "", 0), checker, true))
+	defer gsrv.Stop()
+	assert.Contains(t, gsrv.GetServiceInfo(), "grpc.reflection.v1alpha.ServerReflection")
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go gsrv.Serve(l)
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	statuses := func() map[string]healthpb.HealthCheckResponse_ServingStatus {
+		checker.check()
+
+		statuses := make(map[string]healthpb.HealthCheckResponse_ServingStatus)
+		for _, service := range []string{"", "coreos.clair.AncestryService", updaterServiceName} {
+			resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+			require.Nil(t, err)
+			statuses[service] = resp.Status
+		}
+		return statuses
+	}
+
+	// The vulnerabilities were never updated.
+	assert.Equal(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":                             healthpb.HealthCheckResponse_SERVING,
+		"coreos.clair.AncestryService": healthpb.HealthCheckResponse_SERVING,
+		updaterServiceName:             healthpb.HealthCheckResponse_NOT_SERVING,
+	}, statuses())
+
+	require.Nil(t, database.UpdateKeyValueAndCommit(store, "updater/last", strconv.FormatInt(time.Now().Unix(), 10)))
+	assert.Equal(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":                             healthpb.HealthCheckResponse_SERVING,
+		"coreos.clair.AncestryService": healthpb.HealthCheckResponse_SERVING,
+		updaterServiceName:             healthpb.HealthCheckResponse_SERVING,
+	}, statuses())
+
+	atomic.StoreInt32(&store.down, 1)
+	assert.Equal(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":                             healthpb.HealthCheckResponse_NOT_SERVING,
+		"coreos.clair.AncestryService": healthpb.HealthCheckResponse_NOT_SERVING,
+		updaterServiceName:             healthpb.HealthCheckResponse_NOT_SERVING,
+	}, statuses())
+}
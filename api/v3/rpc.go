@@ -15,10 +15,10 @@
 package v3
 
 import (
-	"sync"
+	"errors"
+	"time"
 
 	"golang.org/x/net/context"
-	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -26,15 +26,34 @@ import (
 	pb "github.com/quay/clair/v3/api/v3/clairpb"
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/imagefmt"
-	"github.com/quay/clair/v3/ext/imgpostprocessor"
+	"github.com/quay/clair/v3/pkg/commonerr"
 	"github.com/quay/clair/v3/pkg/pagination"
-	log "github.com/sirupsen/logrus"
 )
 
+// defaultUpdaterRunLimit is the number of runs listed for every updater when
+// the request doesn't limit them.
+const defaultUpdaterRunLimit = 10
+
 func newRPCErrorWithClairError(code codes.Code, err error) error {
 	return status.Errorf(code, "clair error reason: '%s'", err.Error())
 }
 
+// analyzeErrorCode returns the gRPC code of an error which occurred while
+// analyzing layers.
+//
+// Download and parse errors are reported as internal errors whether they are
+// temporary or not.
+func analyzeErrorCode(err error) codes.Code {
+	switch err {
+	case clair.LayerPathForbiddenError:
+		return codes.PermissionDenied
+	case clair.AncestrySourceNotStoredError:
+		return codes.FailedPrecondition
+	}
+
+	return codes.Internal
+}
+
 // NotificationServer implements NotificationService interface for serving RPC.
 type NotificationServer struct {
 	Store database.Datastore
@@ -48,6 +67,33 @@ type AncestryServer struct {
 // StatusServer implements StatusService interface for serving RPC.
 type StatusServer struct {
 	Store database.Datastore
+
+	// StatisticsCacheTTL is how long the statistics are cached, which is
+	// five minutes when it is 0.
+	StatisticsCacheTTL time.Duration
+
+	namespaces namespacesCache
+	statistics statisticsCache
+}
+
+// SuppressionServer implements SuppressionService interface for serving RPC.
+type SuppressionServer struct {
+	Store database.Datastore
+}
+
+// UpdaterServer implements UpdaterService interface for serving RPC.
+type UpdaterServer struct {
+	Jobs *clair.UpdaterJobs
+
+	// Token is the bearer token authenticating the requests. The service is
+	// disabled when it is empty.
+	Token string
+}
+
+// VulnerabilityServer implements VulnerabilityService interface for serving
+// RPC.
+type VulnerabilityServer struct {
+	Store database.Datastore
 }
 
 // GetStatus implements getting the current status of Clair via the Clair service.
@@ -60,6 +106,44 @@ func (s *StatusServer) GetStatus(ctx context.Context, req *pb.GetStatusRequest)
 	return &pb.GetStatusResponse{Status: clairStatus}, nil
 }
 
+// ListUpdaters implements listing the enabled vulnerability updaters and
+// their latest runs via the Clair service.
+func (s *StatusServer) ListUpdaters(ctx context.Context, req *pb.ListUpdatersRequest) (*pb.ListUpdatersResponse, error) {
+	runLimit := int(req.GetRunLimit())
+	if runLimit <= 0 {
+		runLimit = defaultUpdaterRunLimit
+	}
+
+	updaters, err := GetEnabledUpdaters(s.Store, runLimit)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	return &pb.ListUpdatersResponse{Updaters: updaters}, nil
+}
+
+// ListNamespaces implements listing the namespaces stored in the database and
+// the enabled detectors via the Clair service.
+func (s *StatusServer) ListNamespaces(ctx context.Context, req *pb.ListNamespacesRequest) (*pb.ListNamespacesResponse, error) {
+	resp, err := s.namespaces.get(s.Store)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	return resp, nil
+}
+
+// GetStatistics implements computing the aggregate statistics of the database
+// and the freshness of the enabled updaters via the Clair service.
+func (s *StatusServer) GetStatistics(ctx context.Context, req *pb.GetStatisticsRequest) (*pb.GetStatisticsResponse, error) {
+	resp, err := s.statistics.get(s.Store, s.StatisticsCacheTTL)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	return resp, nil
+}
+
 // PostAncestry implements posting an ancestry via the Clair gRPC service.
 func (s *AncestryServer) PostAncestry(ctx context.Context, req *pb.PostAncestryRequest) (*pb.PostAncestryResponse, error) {
 	blobFormat := req.GetFormat()
@@ -87,74 +171,120 @@ func (s *AncestryServer) PostAncestry(ctx context.Context, req *pb.PostAncestryR
 		return &pb.PostAncestryResponse{Status: clairStatus}, nil
 	}
 
-	builder := clair.NewAncestryBuilder(clair.EnabledDetectors())
-	layerMap := map[string]*database.LayerScanResult{}
-	layerMapLock := sync.RWMutex{}
-	g, analyzerCtx := errgroup.WithContext(ctx)
-	for i := range req.Layers {
-		layer := req.Layers[i]
-		if _, ok := layerMap[layer.Hash]; !ok {
-			layerMap[layer.Hash] = nil
-			if layer == nil {
-				err := status.Error(codes.InvalidArgument, "ancestry layer is invalid")
-				return nil, err
-			}
-
-			if layer.GetHash() == "" {
-				return nil, status.Error(codes.InvalidArgument, "ancestry layer hash should not be empty")
-			}
-
-			if layer.GetPath() == "" {
-				return nil, status.Error(codes.InvalidArgument, "ancestry layer path should not be empty")
-			}
-
-			g.Go(func() error {
-				clairLayer, err := clair.AnalyzeLayer(analyzerCtx, s.Store, layer.Hash, req.Format, layer.Path, layer.Headers)
-				if err != nil {
-					return err
-				}
-
-				layerMapLock.Lock()
-				layerMap[layer.Hash] = clairLayer
-				layerMapLock.Unlock()
-
-				return nil
-			})
+	layers, err := postedLayerBlobs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := clair.ProcessAncestry(ctx, s.Store, req.Format, req.AncestryName, layers); err != nil {
+		return nil, newRPCErrorWithClairError(analyzeErrorCode(err), err)
+	}
+
+	return &pb.PostAncestryResponse{Status: clairStatus}, nil
+}
+
+// postedLayerBlobs returns the blobs of the layers of a posted ancestry, or
+// the gRPC error of the first invalid one.
+func postedLayerBlobs(req *pb.PostAncestryRequest) ([]clair.LayerBlob, error) {
+	layers := make([]clair.LayerBlob, 0, len(req.Layers))
+	for _, layer := range req.Layers {
+		if layer == nil {
+			return nil, status.Error(codes.InvalidArgument, "ancestry layer is invalid")
+		}
+
+		if layer.GetHash() == "" {
+			return nil, status.Error(codes.InvalidArgument, "ancestry layer hash should not be empty")
+		}
+
+		if layer.GetPath() == "" {
+			return nil, status.Error(codes.InvalidArgument, "ancestry layer path should not be empty")
 		}
+
+		layers = append(layers, clair.LayerBlob{
+			Hash:    layer.Hash,
+			Path:    layer.Path,
+			Headers: layer.Headers,
+		})
 	}
 
-	if err = g.Wait(); err != nil {
-		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	return layers, nil
+}
+
+// PostAncestries implements posting a batch of ancestries via the Clair gRPC
+// service.
+//
+// The ancestries which are invalid or fail to be scanned are reported by their
+// status without failing the call, the layers shared by the others being
+// analyzed once.
+func (s *AncestryServer) PostAncestries(ctx context.Context, req *pb.PostAncestriesRequest) (*pb.PostAncestriesResponse, error) {
+	if len(req.GetAncestries()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ancestries should not be empty")
 	}
-	var scannedLayers []*database.LayerScanResult
-	for _, layerRequest := range req.Layers {
-		scannedLayer := layerMap[layerRequest.Hash]
-		scannedLayers = append(scannedLayers, scannedLayer)
+
+	clairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
 	}
-	scannedLayers, err = imgpostprocessor.PostProcessImage(scannedLayers)
 
-	for _, scannedLayer := range scannedLayers {
-		var layer *database.Layer
-		if scannedLayer.NewScanResultLayer != nil {
-			if err = clair.SaveLayerChange(s.Store, scannedLayer.NewScanResultLayer); err != nil {
-				log.WithFields(log.Fields{
-					"layer.Hash": scannedLayer.NewScanResultLayer.Hash,
-				}).WithError(err).Error("failed to store layer change")
-				return nil, err
-			}
+	var (
+		resp    = &pb.PostAncestriesResponse{Status: clairStatus}
+		errs    = make([]error, len(req.Ancestries))
+		names   = map[string]bool{}
+		batch   []clair.AncestryBlobs
+		batched []int
+	)
+
+	for i, ancestry := range req.Ancestries {
+		name := ancestry.GetAncestryName()
+		resp.Ancestries = append(resp.Ancestries, &pb.PostAncestriesResponse_AncestryStatus{AncestryName: name})
+
+		var layers []clair.LayerBlob
+		switch {
+		case name == "":
+			errs[i] = status.Error(codes.InvalidArgument, "ancestry name should not be empty")
+		case names[name]:
+			errs[i] = status.Errorf(codes.InvalidArgument, "ancestry '%s' is posted more than once", name)
+		case !imagefmt.IsSupported(ancestry.GetFormat()):
+			errs[i] = status.Error(codes.InvalidArgument, "image blob format is not supported")
+		default:
+			layers, errs[i] = postedLayerBlobs(ancestry)
+		}
+
+		names[name] = true
+		if errs[i] != nil {
+			continue
+		}
+
+		layerHashes := make([]string, len(layers))
+		for j, layer := range layers {
+			layerHashes[j] = layer.Hash
+		}
+
+		found, err := clair.IsAncestryCached(s.Store, name, layerHashes)
+		if err != nil {
+			errs[i] = newRPCErrorWithClairError(codes.Internal, err)
+			continue
+		}
 
-			layer = database.MergeLayers(scannedLayer.ExistingLayer, scannedLayer.NewScanResultLayer)
-		} else {
-			layer = scannedLayer.ExistingLayer
+		if !found {
+			batch = append(batch, clair.AncestryBlobs{Name: name, Format: ancestry.GetFormat(), Layers: layers})
+			batched = append(batched, i)
 		}
-		builder.AddLeafLayer(layer)
 	}
 
-	if err := clair.SaveAncestry(s.Store, builder.Ancestry(req.AncestryName)); err != nil {
-		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	for j, err := range clair.ProcessAncestries(ctx, s.Store, batch) {
+		if err != nil {
+			errs[batched[j]] = newRPCErrorWithClairError(analyzeErrorCode(err), err)
+		}
 	}
 
-	return &pb.PostAncestryResponse{Status: clairStatus}, nil
+	for i, err := range errs {
+		st := status.Convert(err)
+		resp.Ancestries[i].Code = int32(st.Code())
+		resp.Ancestries[i].Message = st.Message()
+	}
+
+	return resp, nil
 }
 
 // GetAncestry implements retrieving an ancestry via the Clair gRPC service.
@@ -164,6 +294,24 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 		return nil, status.Errorf(codes.InvalidArgument, "ancestry name should not be empty")
 	}
 
+	filter := pb.VulnerabilityFilter{FixedOnly: req.GetFixedOnly()}
+	if req.GetMinimumSeverity() != "" {
+		severity, err := database.NewSeverity(req.GetMinimumSeverity())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown minimum severity '%s'", req.GetMinimumSeverity())
+		}
+		filter.MinimumSeverity = severity
+	}
+
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "feature page limit should not be less than 0")
+	}
+
+	offset, err := decodeFeaturePage(req.GetPage())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: restart pagination from the first page", err)
+	}
+
 	ancestry, ok, err := database.FindAncestryAndRollback(s.Store, name)
 	if err != nil {
 		return nil, newRPCErrorWithClairError(codes.Internal, err)
@@ -173,12 +321,29 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 		return nil, status.Errorf(codes.NotFound, "requested ancestry '%s' is not found", req.GetAncestryName())
 	}
 
+	rules, err := database.FindSuppressionRulesAndRollback(s.Store)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
 	pbAncestry := &pb.GetAncestryResponse_Ancestry{
 		Name: ancestry.Name,
 	}
 
-	for _, layer := range ancestry.Layers {
-		pbLayer, err := s.GetPbAncestryLayer(layer)
+	// The features are paginated across the layers, so that the response of
+	// ancestries with a huge number of features fits in a gRPC message.
+	var (
+		layers     = ancestry.Layers
+		nextOffset = -1
+	)
+
+	if req.GetLimit() > 0 {
+		layers, nextOffset = pageAncestryLayers(ancestry.Layers, offset, int(req.GetLimit()))
+	}
+
+	rules = rules.Active(time.Now())
+	for _, layer := range layers {
+		pbLayer, err := s.GetPbAncestryLayer(layer, rules, req.GetIncludeSuppressed(), filter)
 		if err != nil {
 			return nil, err
 		}
@@ -191,10 +356,103 @@ func (s *AncestryServer) GetAncestry(ctx context.Context, req *pb.GetAncestryReq
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
-	return &pb.GetAncestryResponse{
+	resp := &pb.GetAncestryResponse{
 		Status:   pbClairStatus,
 		Ancestry: pbAncestry,
-	}, nil
+	}
+
+	if req.GetLimit() > 0 {
+		resp.Limit = req.GetLimit()
+		resp.CurrentPage = encodeFeaturePage(offset)
+		if nextOffset >= 0 {
+			resp.NextPage = encodeFeaturePage(nextOffset)
+		}
+	}
+
+	return resp, nil
+}
+
+// DeleteAncestry implements deleting an ancestry via the Clair gRPC service.
+//
+// Notifications don't reference ancestries, but paginate the ancestries
+// affected by their vulnerabilities when read, and thus stop listing the
+// ancestry once deleted.
+func (s *AncestryServer) DeleteAncestry(ctx context.Context, req *pb.DeleteAncestryRequest) (*pb.DeleteAncestryResponse, error) {
+	name := req.GetAncestryName()
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "ancestry name should not be empty")
+	}
+
+	found, err := database.DeleteAncestryAndCommit(s.Store, name)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "requested ancestry '%s' is not found", name)
+	}
+
+	return &pb.DeleteAncestryResponse{}, nil
+}
+
+// RescanAncestry implements analyzing an ancestry again from its stored layer
+// sources via the Clair gRPC service.
+func (s *AncestryServer) RescanAncestry(ctx context.Context, req *pb.RescanAncestryRequest) (*pb.RescanAncestryResponse, error) {
+	name := req.GetAncestryName()
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "ancestry name should not be empty")
+	}
+
+	clairStatus, err := GetClairStatus(s.Store)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if err := clair.RescanAncestry(ctx, s.Store, name); err != nil {
+		if err == commonerr.ErrNotFound {
+			return nil, status.Errorf(codes.NotFound, "requested ancestry '%s' is not found", name)
+		}
+
+		return nil, newRPCErrorWithClairError(analyzeErrorCode(err), err)
+	}
+
+	return &pb.RescanAncestryResponse{Status: clairStatus}, nil
+}
+
+// IndexSBOM implements indexing an ancestry from its SBOM via the Clair gRPC
+// service.
+func (s *AncestryServer) IndexSBOM(ctx context.Context, req *pb.IndexSBOMRequest) (*pb.IndexSBOMResponse, error) {
+	name := req.GetAncestryName()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "ancestry name should not be empty")
+	}
+
+	if req.GetSbom() == "" {
+		return nil, status.Error(codes.InvalidArgument, "SBOM should not be empty")
+	}
+
+	unmatched, err := clair.IndexSBOM(s.Store, name, []byte(req.GetSbom()))
+	var perr *commonerr.ParseError
+	if errors.As(err, &perr) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	ancestry, err := s.GetAncestry(ctx, &pb.GetAncestryRequest{AncestryName: name})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.IndexSBOMResponse{
+		Ancestry: ancestry.Ancestry,
+		Status:   ancestry.Status,
+	}
+	for _, component := range unmatched {
+		resp.Unmatched = append(resp.Unmatched, UnmatchedComponentFromModel(component))
+	}
+
+	return resp, nil
 }
 
 // GetNotification implements retrieving a notification via the Clair gRPC
@@ -216,7 +474,9 @@ func (s *NotificationServer) GetNotification(ctx context.Context, req *pb.GetNot
 		pagination.Token(req.GetNewVulnerabilityPage()),
 	)
 
-	if err != nil {
+	if err == pagination.ErrExpiredToken || err == pagination.ErrInvalidToken {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: restart pagination from the first page", err)
+	} else if err != nil {
 		return nil, newRPCErrorWithClairError(codes.Internal, err)
 	}
 
@@ -232,6 +492,42 @@ func (s *NotificationServer) GetNotification(ctx context.Context, req *pb.GetNot
 	return &pb.GetNotificationResponse{Notification: notification}, nil
 }
 
+// ListNotifications implements listing the notifications in a state, page by
+// page, via the Clair gRPC service.
+func (s *NotificationServer) ListNotifications(ctx context.Context, req *pb.ListNotificationsRequest) (*pb.ListNotificationsResponse, error) {
+	if req.GetLimit() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "notification page limit should not be empty or less than 1")
+	}
+
+	state := database.NotificationState(req.GetState())
+	if state != "" && !state.Valid() {
+		return nil, status.Errorf(codes.InvalidArgument, "notification state '%s' should be one of %v", state, database.NotificationStates)
+	}
+
+	notiPage, err := database.FindNotificationsAndRollback(s.Store, state, int(req.GetLimit()), pagination.Token(req.GetPage()))
+	if err == pagination.ErrExpiredToken || err == pagination.ErrInvalidToken {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: restart pagination from the first page", err)
+	} else if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	resp := &pb.ListNotificationsResponse{
+		Notifications: make([]*pb.ListNotificationsResponse_Notification, 0, len(notiPage.Notifications)),
+		CurrentPage:   string(notiPage.Current),
+		Limit:         int32(notiPage.Limit),
+	}
+
+	if !notiPage.End {
+		resp.NextPage = string(notiPage.Next)
+	}
+
+	for _, noti := range notiPage.Notifications {
+		resp.Notifications = append(resp.Notifications, pb.NotificationSummaryFromDatabaseModel(noti))
+	}
+
+	return resp, nil
+}
+
 // MarkNotificationAsRead implements deleting a notification via the Clair gRPC
 // service.
 func (s *NotificationServer) MarkNotificationAsRead(ctx context.Context, req *pb.MarkNotificationAsReadRequest) (*pb.MarkNotificationAsReadResponse, error) {
@@ -250,3 +546,281 @@ func (s *NotificationServer) MarkNotificationAsRead(ctx context.Context, req *pb
 
 	return &pb.MarkNotificationAsReadResponse{}, nil
 }
+
+// ListDeadLetterNotifications implements listing the notifications which
+// failed to be sent via the Clair gRPC service.
+func (s *NotificationServer) ListDeadLetterNotifications(ctx context.Context, req *pb.ListDeadLetterNotificationsRequest) (*pb.ListDeadLetterNotificationsResponse, error) {
+	deadLetters, err := database.FindDeadLetterNotificationsAndRollback(s.Store)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	notifications := make([]*pb.DeadLetterNotification, 0, len(deadLetters))
+	for _, deadLetter := range deadLetters {
+		notifications = append(notifications, pb.DeadLetterNotificationFromDatabaseModel(deadLetter))
+	}
+
+	return &pb.ListDeadLetterNotificationsResponse{Notifications: notifications}, nil
+}
+
+// RetryDeadLetterNotification implements requeuing a dead-lettered
+// notification via the Clair gRPC service.
+func (s *NotificationServer) RetryDeadLetterNotification(ctx context.Context, req *pb.RetryDeadLetterNotificationRequest) (*pb.RetryDeadLetterNotificationResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "notification name should not be empty")
+	}
+
+	found, err := database.RequeueDeadLetterNotificationAndCommit(s.Store, req.GetName())
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "requested dead-lettered notification '%s' is not found", req.GetName())
+	}
+
+	return &pb.RetryDeadLetterNotificationResponse{}, nil
+}
+
+// CreateSuppressionRule implements creating a suppression rule via the Clair
+// gRPC service.
+func (s *SuppressionServer) CreateSuppressionRule(ctx context.Context, req *pb.CreateSuppressionRuleRequest) (*pb.CreateSuppressionRuleResponse, error) {
+	rule, err := SuppressionRuleFromRequest(req.GetRule())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := database.InsertSuppressionRuleAndCommit(s.Store, rule)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	pbRule, err := GetSuppressionRule(s.Store, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateSuppressionRuleResponse{Rule: pbRule}, nil
+}
+
+// GetSuppressionRule implements retrieving a suppression rule via the Clair
+// gRPC service.
+func (s *SuppressionServer) GetSuppressionRule(ctx context.Context, req *pb.GetSuppressionRuleRequest) (*pb.GetSuppressionRuleResponse, error) {
+	rule, err := GetSuppressionRule(s.Store, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetSuppressionRuleResponse{Rule: rule}, nil
+}
+
+// ListSuppressionRules implements listing the suppression rules via the Clair
+// gRPC service.
+func (s *SuppressionServer) ListSuppressionRules(ctx context.Context, req *pb.ListSuppressionRulesRequest) (*pb.ListSuppressionRulesResponse, error) {
+	rules, err := database.FindSuppressionRulesAndRollback(s.Store)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	pbRules := make([]*pb.SuppressionRule, 0, len(rules))
+	for _, rule := range rules {
+		pbRules = append(pbRules, pb.SuppressionRuleFromDatabaseModel(rule))
+	}
+
+	return &pb.ListSuppressionRulesResponse{Rules: pbRules}, nil
+}
+
+// UpdateSuppressionRule implements replacing a suppression rule via the Clair
+// gRPC service.
+func (s *SuppressionServer) UpdateSuppressionRule(ctx context.Context, req *pb.UpdateSuppressionRuleRequest) (*pb.UpdateSuppressionRuleResponse, error) {
+	rule, err := SuppressionRuleFromRequest(req.GetRule())
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = req.GetId()
+	found, err := database.UpdateSuppressionRuleAndCommit(s.Store, rule)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "requested suppression rule '%d' is not found", req.GetId())
+	}
+
+	pbRule, err := GetSuppressionRule(s.Store, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UpdateSuppressionRuleResponse{Rule: pbRule}, nil
+}
+
+// DeleteSuppressionRule implements deleting a suppression rule via the Clair
+// gRPC service.
+func (s *SuppressionServer) DeleteSuppressionRule(ctx context.Context, req *pb.DeleteSuppressionRuleRequest) (*pb.DeleteSuppressionRuleResponse, error) {
+	found, err := database.DeleteSuppressionRuleAndCommit(s.Store, req.GetId())
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "requested suppression rule '%d' is not found", req.GetId())
+	}
+
+	return &pb.DeleteSuppressionRuleResponse{}, nil
+}
+
+// GetVulnerability implements retrieving a vulnerability of a namespace via
+// the Clair gRPC service.
+func (s *VulnerabilityServer) GetVulnerability(ctx context.Context, req *pb.GetVulnerabilityRequest) (*pb.GetVulnerabilityResponse, error) {
+	if req.GetNamespaceName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability namespace name should not be empty")
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability name should not be empty")
+	}
+
+	vulns, err := database.FindVulnerabilitiesAndRollback(s.Store, []database.VulnerabilityID{{Name: req.GetName(), Namespace: req.GetNamespaceName()}})
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !vulns[0].Valid {
+		return nil, status.Errorf(codes.NotFound, "requested vulnerability '%s' in namespace '%s' is not found", req.GetName(), req.GetNamespaceName())
+	}
+
+	vuln, err := pb.VulnerabilityWithAffectedFromDatabaseModel(vulns[0].VulnerabilityWithAffected)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetVulnerabilityResponse{Vulnerability: vuln}, nil
+}
+
+// ListVulnerabilities implements listing the vulnerabilities of a namespace,
+// page by page, via the Clair gRPC service.
+func (s *VulnerabilityServer) ListVulnerabilities(ctx context.Context, req *pb.ListVulnerabilitiesRequest) (*pb.ListVulnerabilitiesResponse, error) {
+	if req.GetNamespaceName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability namespace name should not be empty")
+	}
+
+	if req.GetLimit() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability page limit should not be empty or less than 1")
+	}
+
+	vulnPage, err := database.FindPagedVulnerabilitiesAndRollback(s.Store, req.GetNamespaceName(), int(req.GetLimit()), pagination.Token(req.GetPage()))
+	if err == pagination.ErrExpiredToken || err == pagination.ErrInvalidToken {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: restart pagination from the first page", err)
+	} else if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	resp := &pb.ListVulnerabilitiesResponse{
+		Vulnerabilities: make([]*pb.Vulnerability, 0, len(vulnPage.Vulnerabilities)),
+		CurrentPage:     string(vulnPage.Current),
+		Limit:           int32(vulnPage.Limit),
+	}
+
+	if !vulnPage.End {
+		resp.NextPage = string(vulnPage.Next)
+	}
+
+	for _, dbVuln := range vulnPage.Vulnerabilities {
+		vuln, err := pb.VulnerabilityWithAffectedFromDatabaseModel(dbVuln)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		resp.Vulnerabilities = append(resp.Vulnerabilities, vuln)
+	}
+
+	return resp, nil
+}
+
+// GetAffectedAncestries implements listing the ancestries affected by a
+// vulnerability of a namespace, page by page, via the Clair gRPC service.
+func (s *VulnerabilityServer) GetAffectedAncestries(ctx context.Context, req *pb.GetAffectedAncestriesRequest) (*pb.GetAffectedAncestriesResponse, error) {
+	if req.GetNamespaceName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability namespace name should not be empty")
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "vulnerability name should not be empty")
+	}
+
+	if req.GetLimit() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "ancestry page limit should not be empty or less than 1")
+	}
+
+	vulnerability := database.VulnerabilityID{Name: req.GetName(), Namespace: req.GetNamespaceName()}
+	ancestryPage, found, err := database.FindAffectedAncestriesAndRollback(s.Store, vulnerability, int(req.GetLimit()), pagination.Token(req.GetPage()))
+	if err == pagination.ErrExpiredToken || err == pagination.ErrInvalidToken {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: restart pagination from the first page", err)
+	} else if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "requested vulnerability '%s' in namespace '%s' is not found", req.GetName(), req.GetNamespaceName())
+	}
+
+	resp := &pb.GetAffectedAncestriesResponse{
+		Ancestries:  make([]*pb.AffectedAncestry, 0, len(ancestryPage.Ancestries)),
+		CurrentPage: string(ancestryPage.Current),
+		Limit:       int32(ancestryPage.Limit),
+	}
+
+	if !ancestryPage.End {
+		resp.NextPage = string(ancestryPage.Next)
+	}
+
+	for _, ancestry := range ancestryPage.Ancestries {
+		resp.Ancestries = append(resp.Ancestries, pb.AffectedAncestryFromDatabaseModel(ancestry))
+	}
+
+	return resp, nil
+}
+
+// TriggerUpdater implements running an updater in the background via the
+// Clair gRPC service.
+func (s *UpdaterServer) TriggerUpdater(ctx context.Context, req *pb.TriggerUpdaterRequest) (*pb.TriggerUpdaterResponse, error) {
+	if err := authenticate(ctx, s.Token); err != nil {
+		return nil, err
+	}
+
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "updater name should not be empty")
+	}
+
+	job, err := s.Jobs.Trigger(req.GetName())
+	if err == clair.ErrUpdaterNotEnabled {
+		return nil, status.Errorf(codes.NotFound, "requested updater '%s' is not enabled", req.GetName())
+	} else if err == clair.ErrUpdateInProgress {
+		return nil, status.Error(codes.Aborted, "an update is already in progress, retry once it is finished")
+	} else if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	return &pb.TriggerUpdaterResponse{Job: UpdaterJobFromModel(job)}, nil
+}
+
+// GetUpdaterJob implements polling the status of an updater run started by
+// TriggerUpdater via the Clair gRPC service.
+func (s *UpdaterServer) GetUpdaterJob(ctx context.Context, req *pb.GetUpdaterJobRequest) (*pb.GetUpdaterJobResponse, error) {
+	if err := authenticate(ctx, s.Token); err != nil {
+		return nil, err
+	}
+
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "updater job id should not be empty")
+	}
+
+	job, ok := s.Jobs.Get(req.GetId())
+	if !ok || job.Updater != req.GetName() {
+		return nil, status.Errorf(codes.NotFound, "requested job '%s' of updater '%s' is not found", req.GetId(), req.GetName())
+	}
+
+	return &pb.GetUpdaterJobResponse{Job: UpdaterJobFromModel(job)}, nil
+}
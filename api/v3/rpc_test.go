@@ -0,0 +1,1114 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/quay/clair/v3"
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func TestAnalyzeErrorCode(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"forbidden path", clair.LayerPathForbiddenError, codes.PermissionDenied},
+		{"source not stored", clair.AncestrySourceNotStoredError, codes.FailedPrecondition},
+		{"temporary download", commonerr.NewDownloadError("https://example.com/layer", errors.New("connection reset")), codes.Internal},
+		{"permanent download", commonerr.NewStatusError("https://example.com/layer", http.StatusNotFound), codes.Internal},
+		{"parse", commonerr.NewParseError("layer", 3, errors.New("unexpected EOF")), codes.Internal},
+		{"wrapped download", fmt.Errorf("analyzing layer: %w", commonerr.NewStatusError("https://example.com/layer", http.StatusBadGateway)), codes.Internal},
+		{"unclassified", errors.New("database is down"), codes.Internal},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.code, analyzeErrorCode(test.err))
+		})
+	}
+}
+
+func TestDeleteAncestry(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	for _, name := range []string{"ancestry", "other"} {
+		require.Nil(t, tx.PersistLayer("layer", nil, nil, nil))
+		require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+			Name:   name,
+			Layers: []database.AncestryLayer{{Hash: "layer"}},
+		}))
+	}
+	require.Nil(t, tx.Commit())
+
+	server := &AncestryServer{Store: store}
+	ctx := context.Background()
+
+	_, err = server.DeleteAncestry(ctx, &pb.DeleteAncestryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.DeleteAncestry(ctx, &pb.DeleteAncestryRequest{AncestryName: "ancestry"})
+	require.Nil(t, err)
+
+	_, err = server.GetAncestry(ctx, &pb.GetAncestryRequest{AncestryName: "ancestry"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Deleting the ancestry again leaves the datastore unchanged.
+	_, err = server.DeleteAncestry(ctx, &pb.DeleteAncestryRequest{AncestryName: "ancestry"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// The ancestry sharing its layer is kept.
+	resp, err := server.GetAncestry(ctx, &pb.GetAncestryRequest{AncestryName: "other"})
+	if assert.Nil(t, err) && assert.Len(t, resp.Ancestry.Layers, 1) {
+		assert.Equal(t, "layer", resp.Ancestry.Layers[0].Layer.Hash)
+	}
+}
+
+func TestRescanAncestry(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistLayer("layer", nil, nil, nil))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name:   "ancestry",
+		Layers: []database.AncestryLayer{{Hash: "layer"}},
+	}))
+	require.Nil(t, tx.Commit())
+
+	server := &AncestryServer{Store: store}
+	ctx := context.Background()
+
+	_, err = server.RescanAncestry(ctx, &pb.RescanAncestryRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.RescanAncestry(ctx, &pb.RescanAncestryRequest{AncestryName: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// The ancestry was posted without storing its layer sources.
+	_, err = server.RescanAncestry(ctx, &pb.RescanAncestryRequest{AncestryName: "ancestry"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	require.Nil(t, database.UpsertAncestrySourceAndCommit(store, database.AncestrySource{
+		Name:   "ancestry",
+		Format: "docker",
+		Layers: []database.LayerSource{{Hash: "layer", Path: "https://example.com/layer"}},
+	}))
+
+	// The layer was scanned by every enabled detector, none being enabled,
+	// so that its blob isn't downloaded again.
+	resp, err := server.RescanAncestry(ctx, &pb.RescanAncestryRequest{AncestryName: "ancestry"})
+	require.Nil(t, err)
+	assert.NotNil(t, resp.Status)
+}
+
+// testUpdater is an Updater which never fetches anything.
+type testUpdater struct{}
+
+func TestPostAncestries(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	for i := 1; i <= 5; i++ {
+		require.Nil(t, tx.PersistLayer(fmt.Sprintf("layer-%d", i), nil, nil, nil))
+	}
+	require.Nil(t, tx.Commit())
+
+	postAncestry := func(name, format string, layers ...int) *pb.PostAncestryRequest {
+		req := &pb.PostAncestryRequest{AncestryName: name, Format: format}
+		for _, layer := range layers {
+			req.Layers = append(req.Layers, &pb.PostAncestryRequest_PostLayer{
+				Hash: fmt.Sprintf("layer-%d", layer),
+				Path: fmt.Sprintf("https://example.com/layer-%d", layer),
+			})
+		}
+		return req
+	}
+
+	server := &AncestryServer{Store: store}
+	ctx := context.Background()
+
+	_, err = server.PostAncestries(ctx, &pb.PostAncestriesRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The invalid ancestries are reported without failing the others.
+	resp, err := server.PostAncestries(ctx, &pb.PostAncestriesRequest{Ancestries: []*pb.PostAncestryRequest{
+		postAncestry("ancestry-1", "docker", 1, 2, 3, 4),
+		postAncestry("ancestry-2", "docker", 1, 2, 3, 5),
+		postAncestry("", "docker", 1),
+		postAncestry("ancestry-1", "docker", 1),
+		postAncestry("ancestry-3", "unknown", 1),
+		{AncestryName: "ancestry-4", Format: "docker", Layers: []*pb.PostAncestryRequest_PostLayer{{Hash: "layer-1"}}},
+	}})
+	require.Nil(t, err)
+	assert.NotNil(t, resp.Status)
+
+	expectedCodes := []codes.Code{codes.OK, codes.OK, codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument, codes.InvalidArgument}
+	expectedNames := []string{"ancestry-1", "ancestry-2", "", "ancestry-1", "ancestry-3", "ancestry-4"}
+	require.Len(t, resp.Ancestries, len(expectedCodes))
+	for i, ancestry := range resp.Ancestries {
+		assert.Equal(t, expectedNames[i], ancestry.AncestryName)
+		assert.Equal(t, int32(expectedCodes[i]), ancestry.Code, ancestry.Message)
+		assert.Equal(t, expectedCodes[i] == codes.OK, ancestry.Message == "")
+	}
+	assert.Contains(t, resp.Ancestries[3].Message, "more than once")
+
+	for name, last := range map[string]string{"ancestry-1": "layer-4", "ancestry-2": "layer-5"} {
+		resp, err := server.GetAncestry(ctx, &pb.GetAncestryRequest{AncestryName: name})
+		require.Nil(t, err)
+		require.Len(t, resp.Ancestry.Layers, 4)
+		assert.Equal(t, last, resp.Ancestry.Layers[3].Layer.Hash)
+	}
+
+	_, err = server.GetAncestry(ctx, &pb.GetAncestryRequest{AncestryName: "ancestry-3"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func (testUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	return vulnsrc.UpdateResponse{}, nil
+}
+
+func (testUpdater) Clean() {}
+
+var registerTestUpdater sync.Once
+
+func TestListUpdatersRuns(t *testing.T) {
+	registerTestUpdater.Do(func() { vulnsrc.RegisterUpdater("api-test", testUpdater{}) })
+
+	enabled := clair.EnabledUpdaters
+	defer func() { clair.EnabledUpdaters = enabled }()
+	clair.EnabledUpdaters = []string{"api-test"}
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	started := time.Unix(1546300800, 0)
+	runs := make([]database.UpdaterRun, 0, defaultUpdaterRunLimit+2)
+	for i := 0; i < cap(runs); i++ {
+		runs = append(runs, database.UpdaterRun{
+			Updater:         "api-test",
+			Started:         started.Add(time.Duration(i) * time.Hour),
+			Finished:        started.Add(time.Duration(i)*time.Hour + time.Minute),
+			Vulnerabilities: i,
+		})
+	}
+	runs[len(runs)-1].Error = "could not download"
+	require.Nil(t, database.InsertUpdaterRunsAndCommit(store, runs))
+
+	server := &StatusServer{Store: store}
+
+	resp, err := server.ListUpdaters(context.Background(), &pb.ListUpdatersRequest{})
+	require.Nil(t, err)
+	require.Len(t, resp.Updaters, 1)
+	assert.Equal(t, "api-test", resp.Updaters[0].Name)
+	if assert.Len(t, resp.Updaters[0].Runs, defaultUpdaterRunLimit) {
+		assert.Equal(t, &pb.UpdaterRun{
+			Started:         "1546340400",
+			Finished:        "1546340460",
+			Vulnerabilities: int32(len(runs) - 1),
+			Error:           "could not download",
+		}, resp.Updaters[0].Runs[0])
+	}
+
+	resp, err = server.ListUpdaters(context.Background(), &pb.ListUpdatersRequest{RunLimit: 2})
+	require.Nil(t, err)
+	require.Len(t, resp.Updaters, 1)
+	assert.Len(t, resp.Updaters[0].Runs, 2)
+}
+
+func TestSuppressionRules(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	server := &SuppressionServer{Store: store}
+	ctx := context.Background()
+
+	_, err = server.CreateSuppressionRule(ctx, &pb.CreateSuppressionRuleRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.CreateSuppressionRule(ctx, &pb.CreateSuppressionRuleRequest{Rule: &pb.SuppressionRule{NamespaceName: "debian:9"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = server.CreateSuppressionRule(ctx, &pb.CreateSuppressionRuleRequest{Rule: &pb.SuppressionRule{VulnerabilityName: "CVE-2019-0001", Expires: "tomorrow"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created, err := server.CreateSuppressionRule(ctx, &pb.CreateSuppressionRuleRequest{Rule: &pb.SuppressionRule{
+		VulnerabilityName: "CVE-2019-0001",
+		FeatureName:       "openssl",
+		Reason:            "accepted risk",
+		Expires:           "1893456000",
+	}})
+	require.Nil(t, err)
+	assert.NotEmpty(t, created.Rule.Created)
+	assert.Equal(t, "1893456000", created.Rule.Expires)
+
+	got, err := server.GetSuppressionRule(ctx, &pb.GetSuppressionRuleRequest{Id: created.Rule.Id})
+	require.Nil(t, err)
+	assert.Equal(t, created.Rule, got.Rule)
+
+	updated, err := server.UpdateSuppressionRule(ctx, &pb.UpdateSuppressionRuleRequest{
+		Id:   created.Rule.Id,
+		Rule: &pb.SuppressionRule{VulnerabilityName: "CVE-2019-0001", Reason: "false positive"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, &pb.SuppressionRule{
+		Id:                created.Rule.Id,
+		VulnerabilityName: "CVE-2019-0001",
+		Reason:            "false positive",
+		Created:           created.Rule.Created,
+	}, updated.Rule)
+
+	_, err = server.UpdateSuppressionRule(ctx, &pb.UpdateSuppressionRuleRequest{Id: 42, Rule: &pb.SuppressionRule{VulnerabilityName: "CVE-2019-0001"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := server.ListSuppressionRules(ctx, &pb.ListSuppressionRulesRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, []*pb.SuppressionRule{updated.Rule}, list.Rules)
+
+	_, err = server.DeleteSuppressionRule(ctx, &pb.DeleteSuppressionRuleRequest{Id: created.Rule.Id})
+	require.Nil(t, err)
+
+	_, err = server.GetSuppressionRule(ctx, &pb.GetSuppressionRuleRequest{Id: created.Rule.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.DeleteSuppressionRule(ctx, &pb.DeleteSuppressionRuleRequest{Id: created.Rule.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// persistVulnerableAncestry stores the ancestry "ancestry", made of a single
+// layer featuring openssl 1.0 in debian:9, and the vulnerabilities of
+// openssl, fixed in 2.0 unless fixedIn is empty, named after their severity.
+func persistVulnerableAncestry(t *testing.T, store database.Datastore, severities map[string]database.Severity, fixedIn string) {
+	var (
+		ns          = database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+		feature     = database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+		nsFeature   = database.NamespacedFeature{Feature: feature, Namespace: ns}
+		pkgDetector = database.NewFeatureDetector("dpkg", "1.0")
+	)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistDetectors([]database.Detector{pkgDetector}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.PersistFeatures([]database.Feature{feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{nsFeature}))
+	require.Nil(t, tx.PersistLayer("layer", []database.LayerFeature{{Feature: feature, By: pkgDetector}}, nil, []database.Detector{pkgDetector}))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name: "ancestry",
+		By:   []database.Detector{pkgDetector},
+		Layers: []database.AncestryLayer{{
+			Hash:     "layer",
+			Features: []database.AncestryFeature{{NamespacedFeature: nsFeature, FeatureBy: pkgDetector}},
+		}},
+	}))
+
+	affectedVersion := fixedIn
+	if fixedIn == "" {
+		affectedVersion = versionfmt.MaxVersion
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for name, severity := range severities {
+		vulnerabilities = append(vulnerabilities, database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: name, Namespace: ns, Severity: severity},
+			Affected: []database.AffectedFeature{{
+				FeatureType:     database.BinaryPackage,
+				Namespace:       ns,
+				FeatureName:     "openssl",
+				AffectedVersion: affectedVersion,
+				FixedInVersion:  fixedIn,
+			}},
+		})
+	}
+	require.Nil(t, tx.InsertVulnerabilities(vulnerabilities))
+	require.Nil(t, tx.Commit())
+}
+
+// listAncestryVulnerabilities gets the ancestry "ancestry" and returns
+// whether its vulnerabilities are suppressed, by name.
+func listAncestryVulnerabilities(t *testing.T, server *AncestryServer, req *pb.GetAncestryRequest) map[string]bool {
+	req.AncestryName = "ancestry"
+	resp, err := server.GetAncestry(context.Background(), req)
+	require.Nil(t, err)
+	require.Len(t, resp.Ancestry.Layers, 1)
+	require.Len(t, resp.Ancestry.Layers[0].DetectedFeatures, 1)
+
+	suppressed := map[string]bool{}
+	for _, vulnerability := range resp.Ancestry.Layers[0].DetectedFeatures[0].Vulnerabilities {
+		suppressed[vulnerability.Name] = vulnerability.Suppressed
+	}
+
+	return suppressed
+}
+
+func TestGetAncestrySuppressed(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistVulnerableAncestry(t, store, map[string]database.Severity{
+		"CVE-2019-0001": database.HighSeverity,
+		"CVE-2019-0002": database.HighSeverity,
+		"CVE-2019-0003": database.HighSeverity,
+	}, "2.0")
+
+	// CVE-2019-0001 is suppressed, the rule of CVE-2019-0002 expired and the
+	// one of CVE-2019-0003 applies to another feature.
+	for _, rule := range []database.SuppressionRule{
+		{Vulnerability: "CVE-2019-0001", Namespace: "debian:9", Feature: "openssl"},
+		{Vulnerability: "CVE-2019-0002", Expires: time.Now().Add(-time.Minute)},
+		{Vulnerability: "CVE-2019-0003", Feature: "curl"},
+	} {
+		_, err := database.InsertSuppressionRuleAndCommit(store, rule)
+		require.Nil(t, err)
+	}
+
+	server := &AncestryServer{Store: store}
+	assert.Equal(t, map[string]bool{"CVE-2019-0002": false, "CVE-2019-0003": false}, listAncestryVulnerabilities(t, server, &pb.GetAncestryRequest{}))
+	assert.Equal(t, map[string]bool{"CVE-2019-0001": true, "CVE-2019-0002": false, "CVE-2019-0003": false}, listAncestryVulnerabilities(t, server, &pb.GetAncestryRequest{IncludeSuppressed: true}))
+}
+
+func TestGetAncestryFilters(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	severities := map[string]database.Severity{}
+	for _, severity := range database.Severities {
+		severities[string(severity)] = severity
+	}
+	persistVulnerableAncestry(t, store, severities, "2.0")
+
+	server := &AncestryServer{Store: store}
+	names := func(req *pb.GetAncestryRequest) []string {
+		var names []string
+		for name := range listAncestryVulnerabilities(t, server, req) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	// Every vulnerability is listed by default, and above the lowest
+	// severity.
+	all := []string{"Critical", "Defcon1", "High", "Low", "Medium", "Negligible", "Unknown"}
+	assert.Equal(t, all, names(&pb.GetAncestryRequest{}))
+	assert.Equal(t, all, names(&pb.GetAncestryRequest{MinimumSeverity: "Unknown"}))
+
+	// The minimum severity is included, and parsed regardless of its case.
+	assert.Equal(t, []string{"Critical", "Defcon1", "High", "Low", "Medium"}, names(&pb.GetAncestryRequest{MinimumSeverity: "Low"}))
+	assert.Equal(t, []string{"Critical", "Defcon1", "High"}, names(&pb.GetAncestryRequest{MinimumSeverity: "high"}))
+	assert.Equal(t, []string{"Defcon1"}, names(&pb.GetAncestryRequest{MinimumSeverity: "Defcon1"}))
+
+	_, err = server.GetAncestry(context.Background(), &pb.GetAncestryRequest{AncestryName: "ancestry", MinimumSeverity: "Important"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The suppressed vulnerabilities are filtered as well.
+	_, err = database.InsertSuppressionRuleAndCommit(store, database.SuppressionRule{Vulnerability: "Critical"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"Defcon1", "High"}, names(&pb.GetAncestryRequest{MinimumSeverity: "High"}))
+	assert.Equal(t, map[string]bool{"Critical": true, "Defcon1": false}, listAncestryVulnerabilities(t, server, &pb.GetAncestryRequest{MinimumSeverity: "Critical", IncludeSuppressed: true}))
+
+	// Only the fixed vulnerabilities are listed with fixed_only, the
+	// features are listed either way.
+	assert.Equal(t, []string{"Defcon1", "High", "Low", "Medium", "Negligible", "Unknown"}, names(&pb.GetAncestryRequest{FixedOnly: true}))
+	persistVulnerableAncestry(t, store, map[string]database.Severity{"Unfixed": database.HighSeverity}, "")
+	assert.Contains(t, names(&pb.GetAncestryRequest{MinimumSeverity: "High"}), "Unfixed")
+	assert.Equal(t, []string{"Defcon1", "High"}, names(&pb.GetAncestryRequest{MinimumSeverity: "High", FixedOnly: true}))
+
+	// The feature is listed even without any vulnerability left.
+	_, err = database.InsertSuppressionRuleAndCommit(store, database.SuppressionRule{Vulnerability: "Defcon1"})
+	require.Nil(t, err)
+	assert.Empty(t, names(&pb.GetAncestryRequest{MinimumSeverity: "Critical"}))
+}
+
+// persistHugeAncestry stores the ancestry "huge", whose layers feature the
+// given numbers of features, in reverse order.
+func persistHugeAncestry(t *testing.T, store database.Datastore, counts ...int) {
+	var (
+		ns          = database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+		pkgDetector = database.NewFeatureDetector("dpkg", "1.0")
+		ancestry    = database.Ancestry{Name: "huge", By: []database.Detector{pkgDetector}}
+	)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistDetectors([]database.Detector{pkgDetector}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+
+	for i, count := range counts {
+		var (
+			hash          = fmt.Sprintf("layer-%d", i)
+			features      = make([]database.Feature, 0, count)
+			nsFeatures    = make([]database.NamespacedFeature, 0, count)
+			layerFeatures = make([]database.LayerFeature, 0, count)
+			layer         = database.AncestryLayer{Hash: hash}
+		)
+
+		for j := count - 1; j >= 0; j-- {
+			feature := database.Feature{Name: fmt.Sprintf("package-%d-%05d", i, j), Version: "1.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+			nsFeature := database.NamespacedFeature{Feature: feature, Namespace: ns}
+			features = append(features, feature)
+			nsFeatures = append(nsFeatures, nsFeature)
+			layerFeatures = append(layerFeatures, database.LayerFeature{Feature: feature, By: pkgDetector})
+			layer.Features = append(layer.Features, database.AncestryFeature{NamespacedFeature: nsFeature, FeatureBy: pkgDetector})
+		}
+
+		require.Nil(t, tx.PersistFeatures(features))
+		require.Nil(t, tx.PersistNamespacedFeatures(nsFeatures))
+		require.Nil(t, tx.PersistLayer(hash, layerFeatures, nil, []database.Detector{pkgDetector}))
+		ancestry.Layers = append(ancestry.Layers, layer)
+	}
+
+	require.Nil(t, tx.UpsertAncestry(ancestry))
+	require.Nil(t, tx.Commit())
+}
+
+func TestGetAncestryPagination(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistHugeAncestry(t, store, 30000, 20000)
+	server := &AncestryServer{Store: store}
+
+	// Without a limit, every feature is listed at once.
+	resp, err := server.GetAncestry(context.Background(), &pb.GetAncestryRequest{AncestryName: "huge"})
+	require.Nil(t, err)
+	require.Len(t, resp.Ancestry.Layers, 2)
+	assert.Len(t, resp.Ancestry.Layers[0].DetectedFeatures, 30000)
+	assert.Len(t, resp.Ancestry.Layers[1].DetectedFeatures, 20000)
+	assert.Empty(t, resp.NextPage)
+
+	// Paged twice, every feature is listed exactly once in the same order,
+	// every layer being listed on every page.
+	listPages := func() []string {
+		var (
+			features []string
+			page     string
+		)
+
+		for pages := 0; ; pages++ {
+			require.True(t, pages < 13, "too many pages")
+
+			resp, err := server.GetAncestry(context.Background(), &pb.GetAncestryRequest{AncestryName: "huge", Limit: 4096, Page: page})
+			require.Nil(t, err)
+			require.Len(t, resp.Ancestry.Layers, 2)
+			assert.Equal(t, int32(4096), resp.Limit)
+			if page == "" {
+				assert.Equal(t, encodeFeaturePage(0), resp.CurrentPage)
+			} else {
+				assert.Equal(t, page, resp.CurrentPage)
+			}
+			assert.True(t, proto.Size(resp) < 4<<20)
+
+			for i, layer := range resp.Ancestry.Layers {
+				assert.Equal(t, fmt.Sprintf("layer-%d", i), layer.Layer.Hash)
+				for _, feature := range layer.DetectedFeatures {
+					features = append(features, layer.Layer.Hash+"/"+feature.Name)
+				}
+			}
+
+			if resp.NextPage == "" {
+				assert.Equal(t, 12, pages)
+				return features
+			}
+			page = resp.NextPage
+		}
+	}
+
+	features := listPages()
+	require.Len(t, features, 50000)
+	assert.Equal(t, features, listPages())
+
+	seen := map[string]bool{}
+	for i, feature := range features {
+		assert.False(t, seen[feature], "%s listed twice", feature)
+		seen[feature] = true
+
+		layer := 0
+		if i >= 30000 {
+			layer, i = 1, i-30000
+		}
+		assert.Equal(t, fmt.Sprintf("layer-%d/package-%d-%05d", layer, layer, i), feature)
+	}
+
+	for _, req := range []*pb.GetAncestryRequest{
+		{AncestryName: "huge", Limit: -1},
+		{AncestryName: "huge", Limit: 10, Page: "invalid"},
+		{AncestryName: "huge", Limit: 10, Page: encodeFeaturePage(-1)},
+	} {
+		_, err := server.GetAncestry(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// Past the last feature, the layers are listed without features.
+	resp, err = server.GetAncestry(context.Background(), &pb.GetAncestryRequest{AncestryName: "huge", Limit: 10, Page: encodeFeaturePage(50000)})
+	require.Nil(t, err)
+	require.Len(t, resp.Ancestry.Layers, 2)
+	assert.Empty(t, resp.Ancestry.Layers[0].DetectedFeatures)
+	assert.Empty(t, resp.Ancestry.Layers[1].DetectedFeatures)
+	assert.Empty(t, resp.NextPage)
+}
+
+func TestVulnerabilities(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, name := range []string{"CVE-2019-0001", "CVE-2019-0002", "CVE-2019-0003"} {
+		vulnerabilities = append(vulnerabilities, database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:      name,
+				Namespace: ns,
+				Link:      "https://security-tracker.debian.org/tracker/" + name,
+				Severity:  database.HighSeverity,
+				Metadata:  database.MetadataMap{"NVD": map[string]interface{}{"CVSSv3": "9.8"}},
+			},
+			Affected: []database.AffectedFeature{{
+				FeatureType:     database.BinaryPackage,
+				Namespace:       ns,
+				FeatureName:     "openssl",
+				AffectedVersion: "2.0",
+				FixedInVersion:  "2.0",
+			}},
+		})
+	}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities(vulnerabilities))
+	require.Nil(t, tx.Commit())
+
+	server := &VulnerabilityServer{Store: store}
+	ctx := context.Background()
+
+	resp, err := server.GetVulnerability(ctx, &pb.GetVulnerabilityRequest{NamespaceName: "debian:9", Name: "CVE-2019-0002"})
+	require.Nil(t, err)
+	assert.Equal(t, &pb.Vulnerability{
+		Name:          "CVE-2019-0002",
+		NamespaceName: "debian:9",
+		Link:          "https://security-tracker.debian.org/tracker/CVE-2019-0002",
+		Severity:      string(database.HighSeverity),
+		Metadata:      `{"NVD":{"CVSSv3":"9.8"}}`,
+		AffectedVersions: []*pb.Feature{{
+			Name:          "openssl",
+			Namespace:     &pb.Namespace{Name: "debian:9"},
+			Version:       "2.0",
+			VersionFormat: dpkg.ParserName,
+			FeatureType:   string(database.BinaryPackage),
+		}},
+	}, resp.Vulnerability)
+
+	for _, req := range []*pb.GetVulnerabilityRequest{
+		{Name: "CVE-2019-0002"},
+		{NamespaceName: "debian:9"},
+	} {
+		_, err = server.GetVulnerability(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	for _, req := range []*pb.GetVulnerabilityRequest{
+		{NamespaceName: "debian:9", Name: "CVE-2019-0004"},
+		{NamespaceName: "debian:10", Name: "CVE-2019-0002"},
+	} {
+		_, err = server.GetVulnerability(ctx, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	// Page through the vulnerabilities of the namespace.
+	var names []string
+	page := ""
+	for pages := 0; ; pages++ {
+		require.True(t, pages < 2, "too many pages")
+
+		list, err := server.ListVulnerabilities(ctx, &pb.ListVulnerabilitiesRequest{NamespaceName: "debian:9", Page: page, Limit: 2})
+		require.Nil(t, err)
+		assert.Equal(t, int32(2), list.Limit)
+		assert.NotEmpty(t, list.CurrentPage)
+
+		for _, vulnerability := range list.Vulnerabilities {
+			assert.Len(t, vulnerability.AffectedVersions, 1)
+			names = append(names, vulnerability.Name)
+		}
+
+		if list.NextPage == "" {
+			break
+		}
+		page = list.NextPage
+	}
+	assert.Equal(t, []string{"CVE-2019-0001", "CVE-2019-0002", "CVE-2019-0003"}, names)
+
+	list, err := server.ListVulnerabilities(ctx, &pb.ListVulnerabilitiesRequest{NamespaceName: "debian:10", Limit: 2})
+	require.Nil(t, err)
+	assert.Empty(t, list.Vulnerabilities)
+	assert.Empty(t, list.NextPage)
+
+	for _, req := range []*pb.ListVulnerabilitiesRequest{
+		{Limit: 2},
+		{NamespaceName: "debian:9"},
+		{NamespaceName: "debian:9", Limit: 2, Page: "invalid"},
+	} {
+		_, err = server.ListVulnerabilities(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestGetAffectedAncestries(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	nsDetector := database.NewNamespaceDetector("os-release", "1.0")
+	pkgDetector := database.NewFeatureDetector("dpkg", "1.0")
+	vulnerable := database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+	fixed := database.Feature{Name: "openssl", Version: "2.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistDetectors([]database.Detector{nsDetector, pkgDetector}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.PersistFeatures([]database.Feature{vulnerable, fixed}))
+	for _, feature := range []database.Feature{vulnerable, fixed} {
+		require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{{Feature: feature, Namespace: ns}}))
+		require.Nil(t, tx.PersistLayer(feature.Version,
+			[]database.LayerFeature{{Feature: feature, By: pkgDetector}},
+			[]database.LayerNamespace{{Namespace: ns, By: nsDetector}},
+			[]database.Detector{nsDetector, pkgDetector},
+		))
+	}
+
+	// Every other ancestry has the vulnerable openssl.
+	const count = 3000
+	for i := 0; i < count; i++ {
+		feature := []database.Feature{vulnerable, fixed}[i%2]
+		require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+			Name: fmt.Sprintf("ancestry-%04d", i),
+			By:   []database.Detector{nsDetector, pkgDetector},
+			Layers: []database.AncestryLayer{{
+				Hash: feature.Version,
+				Features: []database.AncestryFeature{{
+					NamespacedFeature: database.NamespacedFeature{Feature: feature, Namespace: ns},
+					FeatureBy:         pkgDetector,
+					NamespaceBy:       nsDetector,
+				}},
+			}},
+		}))
+	}
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{Name: "CVE-2019-0001", Namespace: ns, Severity: database.HighSeverity},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.BinaryPackage,
+			Namespace:       ns,
+			FeatureName:     "openssl",
+			AffectedVersion: "2.0",
+			FixedInVersion:  "2.0",
+		}},
+	}}))
+	require.Nil(t, tx.Commit())
+
+	server := &VulnerabilityServer{Store: store}
+	ctx := context.Background()
+
+	names := map[string]struct{}{}
+	page := ""
+	for pages := 0; ; pages++ {
+		require.True(t, pages < count/2/100, "too many pages")
+
+		resp, err := server.GetAffectedAncestries(ctx, &pb.GetAffectedAncestriesRequest{NamespaceName: "debian:9", Name: "CVE-2019-0001", Page: page, Limit: 100})
+		require.Nil(t, err)
+		assert.Equal(t, int32(100), resp.Limit)
+		assert.NotEmpty(t, resp.CurrentPage)
+
+		for _, ancestry := range resp.Ancestries {
+			assert.Equal(t, []*pb.Feature{{
+				Name:          "openssl",
+				Namespace:     &pb.Namespace{Name: "debian:9"},
+				Version:       "1.0",
+				VersionFormat: dpkg.ParserName,
+				FeatureType:   string(database.BinaryPackage),
+			}}, ancestry.Features)
+			names[ancestry.Name] = struct{}{}
+		}
+
+		if resp.NextPage == "" {
+			break
+		}
+		page = resp.NextPage
+	}
+	assert.Len(t, names, count/2)
+	assert.Contains(t, names, "ancestry-0000")
+	assert.NotContains(t, names, "ancestry-0001")
+
+	for _, req := range []*pb.GetAffectedAncestriesRequest{
+		{Name: "CVE-2019-0001", Limit: 100},
+		{NamespaceName: "debian:9", Limit: 100},
+		{NamespaceName: "debian:9", Name: "CVE-2019-0001"},
+		{NamespaceName: "debian:9", Name: "CVE-2019-0001", Limit: 100, Page: "invalid"},
+	} {
+		_, err = server.GetAffectedAncestries(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	_, err = server.GetAffectedAncestries(ctx, &pb.GetAffectedAncestriesRequest{NamespaceName: "debian:9", Name: "CVE-2019-0002", Limit: 100})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestListNotifications(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	vulnerability := database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{
+		Name:      "CVE-2019-0001",
+		Namespace: ns,
+		Severity:  database.HighSeverity,
+	}}
+	created := time.Unix(1546300800, 0)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{{
+		NotificationHook: database.NotificationHook{Name: "notification", Created: created},
+		New:              &vulnerability.Vulnerability,
+	}}))
+	require.Nil(t, tx.Commit())
+
+	server := &NotificationServer{Store: store}
+	ctx := context.Background()
+
+	list, err := server.ListNotifications(ctx, &pb.ListNotificationsRequest{State: "pending", Limit: 10})
+	require.Nil(t, err)
+	assert.Equal(t, []*pb.ListNotificationsResponse_Notification{{
+		Name:              "notification",
+		Created:           "1546300800",
+		State:             "pending",
+		VulnerabilityName: "CVE-2019-0001",
+		NamespaceName:     "debian:9",
+	}}, list.Notifications)
+	assert.Equal(t, int32(10), list.Limit)
+	assert.NotEmpty(t, list.CurrentPage)
+	assert.Empty(t, list.NextPage)
+
+	_, err = server.MarkNotificationAsRead(ctx, &pb.MarkNotificationAsReadRequest{Name: "notification"})
+	require.Nil(t, err)
+
+	list, err = server.ListNotifications(ctx, &pb.ListNotificationsRequest{State: "pending", Limit: 10})
+	require.Nil(t, err)
+	assert.Empty(t, list.Notifications)
+
+	list, err = server.ListNotifications(ctx, &pb.ListNotificationsRequest{Limit: 10})
+	require.Nil(t, err)
+	require.Len(t, list.Notifications, 1)
+	assert.Equal(t, "expired", list.Notifications[0].State)
+	assert.NotEmpty(t, list.Notifications[0].Deleted)
+
+	for _, req := range []*pb.ListNotificationsRequest{
+		{State: "pending"},
+		{State: "unknown", Limit: 10},
+		{State: "pending", Limit: 10, Page: "invalid"},
+	} {
+		_, err = server.ListNotifications(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestUpdaterServer(t *testing.T) {
+	registerTestUpdater.Do(func() { vulnsrc.RegisterUpdater("api-test", testUpdater{}) })
+
+	enabled := clair.EnabledUpdaters
+	defer func() { clair.EnabledUpdaters = enabled }()
+	clair.EnabledUpdaters = []string{"api-test"}
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs := clair.NewUpdaterJobs(ctx, nil, store)
+	authorized := metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer token"))
+
+	// The service is disabled without token.
+	_, err = (&UpdaterServer{Jobs: jobs}).TriggerUpdater(authorized, &pb.TriggerUpdaterRequest{Name: "api-test"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	server := &UpdaterServer{Jobs: jobs, Token: "token"}
+	for _, ctx := range []context.Context{
+		ctx,
+		metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer other")),
+		metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "token")),
+	} {
+		_, err = server.TriggerUpdater(ctx, &pb.TriggerUpdaterRequest{Name: "api-test"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		_, err = server.GetUpdaterJob(ctx, &pb.GetUpdaterJobRequest{Name: "api-test", Id: "id"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	_, err = server.TriggerUpdater(authorized, &pb.TriggerUpdaterRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.TriggerUpdater(authorized, &pb.TriggerUpdaterRequest{Name: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Jobs are not started while another update is in progress.
+	acquired, _ := database.AcquireLock(store, "updater", "periodic", time.Minute)
+	require.True(t, acquired)
+	_, err = server.TriggerUpdater(authorized, &pb.TriggerUpdaterRequest{Name: "api-test"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+	database.ReleaseLock(store, "updater", "periodic")
+
+	// The triggered job is polled until it is finished.
+	triggered, err := server.TriggerUpdater(authorized, &pb.TriggerUpdaterRequest{Name: "api-test"})
+	require.Nil(t, err)
+	job := triggered.Job
+	assert.Equal(t, "api-test", job.Updater)
+	assert.NotEmpty(t, job.Started)
+
+	deadline := time.Now().Add(30 * time.Second)
+	for job.Status == string(clair.UpdaterJobRunning) {
+		require.True(t, time.Now().Before(deadline), "the updater job did not finish")
+		time.Sleep(10 * time.Millisecond)
+
+		resp, err := server.GetUpdaterJob(authorized, &pb.GetUpdaterJobRequest{Name: "api-test", Id: job.Id})
+		require.Nil(t, err)
+		job = resp.Job
+	}
+	assert.Equal(t, string(clair.UpdaterJobSucceeded), job.Status)
+	assert.Empty(t, job.Error)
+	assert.NotEmpty(t, job.Finished)
+	if assert.NotNil(t, job.Run) {
+		assert.Empty(t, job.Run.Error)
+	}
+
+	// The run is recorded along the ones of the periodic updates.
+	runs, err := database.FindUpdaterRunsAndRollback(store, "api-test", 10)
+	require.Nil(t, err)
+	assert.Len(t, runs, 1)
+
+	for _, req := range []*pb.GetUpdaterJobRequest{
+		{Name: "api-test", Id: "unknown"},
+		{Name: "other", Id: job.Id},
+	} {
+		_, err = server.GetUpdaterJob(authorized, req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	}
+
+	_, err = server.GetUpdaterJob(authorized, &pb.GetUpdaterJobRequest{Name: "api-test"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListNamespaces(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	debian := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	ubuntu := database.Namespace{Name: "ubuntu:18.04", VersionFormat: dpkg.ParserName}
+	vulnerability := func(name string) database.VulnerabilityWithAffected {
+		return database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: name, Namespace: debian}}
+	}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ubuntu, debian}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability("CVE-2019-0001"), vulnerability("CVE-2019-0002")}))
+	require.Nil(t, tx.Commit())
+
+	server := &StatusServer{Store: store}
+	resp, err := server.ListNamespaces(context.Background(), &pb.ListNamespacesRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, []*pb.NamespaceCoverage{
+		{Name: "debian:9", VersionFormat: dpkg.ParserName, VulnerabilityCount: 2},
+		{Name: "ubuntu:18.04", VersionFormat: dpkg.ParserName},
+	}, resp.Namespaces)
+	assert.Equal(t, pb.DetectorsFromDatabaseModel(clair.EnabledDetectors()), resp.Detectors)
+	assert.NotEmpty(t, resp.Refreshed)
+
+	// The namespaces are cached until they expire.
+	require.Nil(t, database.UpdateVulnerabilitiesAndCommit(store, nil, []database.VulnerabilityWithAffected{vulnerability("CVE-2019-0003")}))
+	cached, err := server.ListNamespaces(context.Background(), &pb.ListNamespacesRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, resp, cached)
+
+	server.namespaces.refreshed = server.namespaces.refreshed.Add(-namespacesCacheTTL)
+	refreshed, err := server.ListNamespaces(context.Background(), &pb.ListNamespacesRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), refreshed.Namespaces[0].VulnerabilityCount)
+}
+
+func TestGetStatistics(t *testing.T) {
+	registerTestUpdater.Do(func() { vulnsrc.RegisterUpdater("api-test", testUpdater{}) })
+
+	enabled := clair.EnabledUpdaters
+	defer func() { clair.EnabledUpdaters = enabled }()
+	clair.EnabledUpdaters = []string{"api-test"}
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistVulnerableAncestry(t, store, map[string]database.Severity{
+		"CVE-2019-0001": database.HighSeverity,
+		"CVE-2019-0002": database.HighSeverity,
+		"CVE-2019-0003": database.LowSeverity,
+	}, "2.0")
+
+	started := time.Unix(1546300800, 0)
+	require.Nil(t, database.InsertUpdaterRunsAndCommit(store, []database.UpdaterRun{
+		{Updater: "api-test", Started: started, Finished: started.Add(time.Minute)},
+		{Updater: "api-test", Started: started.Add(time.Hour), Finished: started.Add(time.Hour + time.Minute), Error: "could not download"},
+	}))
+	require.Nil(t, database.UpdateKeyValueAndCommit(store, "updater/last", "1546300860"))
+
+	server := &StatusServer{Store: store, StatisticsCacheTTL: time.Hour}
+	resp, err := server.GetStatistics(context.Background(), &pb.GetStatisticsRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, []*pb.VulnerabilityCount{
+		{NamespaceName: "debian:9", VersionFormat: dpkg.ParserName, Severity: string(database.LowSeverity), Count: 1},
+		{NamespaceName: "debian:9", VersionFormat: dpkg.ParserName, Severity: string(database.HighSeverity), Count: 2},
+	}, resp.Vulnerabilities)
+	assert.Equal(t, int64(1), resp.AncestryCount)
+	assert.Equal(t, int64(1), resp.LayerCount)
+	assert.Equal(t, int64(1), resp.FeatureCount)
+	assert.Equal(t, []*pb.UpdaterFreshness{{
+		Name:        "api-test",
+		LastRun:     "1546304460",
+		LastSuccess: "1546300860",
+		LastError:   "could not download",
+	}}, resp.Updaters)
+	assert.Equal(t, "1546300860", resp.LastUpdate)
+	assert.NotEmpty(t, resp.Refreshed)
+
+	// The statistics are cached for the configured period.
+	require.Nil(t, database.UpdateVulnerabilitiesAndCommit(store, nil, []database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{
+			Name:      "CVE-2019-0004",
+			Namespace: database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName},
+			Severity:  database.HighSeverity,
+		},
+	}}))
+	cached, err := server.GetStatistics(context.Background(), &pb.GetStatisticsRequest{})
+	require.Nil(t, err)
+	assert.Equal(t, resp, cached)
+
+	server.statistics.refreshed = server.statistics.refreshed.Add(-time.Hour)
+	refreshed, err := server.GetStatistics(context.Background(), &pb.GetStatisticsRequest{})
+	require.Nil(t, err)
+	require.Len(t, refreshed.Vulnerabilities, 2)
+	assert.Equal(t, int64(3), refreshed.Vulnerabilities[1].Count)
+}
+
+func TestIndexSBOM(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ns := database.Namespace{Name: "debian:10", VersionFormat: dpkg.ParserName}
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{Name: "CVE-2019-0001", Namespace: ns, Severity: database.HighSeverity},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.SourcePackage,
+			Namespace:       ns,
+			FeatureName:     "curl",
+			AffectedVersion: "7.64.0-4+deb10u2",
+			FixedInVersion:  "7.64.0-4+deb10u2",
+		}},
+	}}))
+	require.Nil(t, tx.Commit())
+
+	server := &AncestryServer{Store: store}
+	resp, err := server.IndexSBOM(context.Background(), &pb.IndexSBOMRequest{
+		AncestryName: "sbom",
+		Sbom: `{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [
+			{"name": "libcurl4", "version": "7.64.0-4+deb10u1", "purl": "pkg:deb/debian/libcurl4@7.64.0-4%2Bdeb10u1?upstream=curl&distro=debian-10"},
+			{"name": "lodash", "version": "4.17.15", "purl": "pkg:npm/lodash@4.17.15"}
+		]}`,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "sbom", resp.Ancestry.Name)
+	require.Len(t, resp.Ancestry.Layers, 1)
+	require.Len(t, resp.Ancestry.Layers[0].DetectedFeatures, 2)
+
+	// The binary package is reported along with its source package, which
+	// the Debian vulnerabilities affect.
+	binary, feature := resp.Ancestry.Layers[0].DetectedFeatures[0], resp.Ancestry.Layers[0].DetectedFeatures[1]
+	assert.Equal(t, "libcurl4", binary.Name)
+	assert.Empty(t, binary.Vulnerabilities)
+	assert.Equal(t, "curl", feature.Name)
+	assert.Equal(t, "source", feature.FeatureType)
+	assert.Equal(t, "debian:10", feature.Namespace.Name)
+	if assert.Len(t, feature.Vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2019-0001", feature.Vulnerabilities[0].Name)
+		assert.Equal(t, "7.64.0-4+deb10u2", feature.Vulnerabilities[0].FixedBy)
+	}
+
+	if assert.Len(t, resp.Unmatched, 1) {
+		assert.Equal(t, "lodash", resp.Unmatched[0].Name)
+		assert.Equal(t, "pkg:npm/lodash@4.17.15", resp.Unmatched[0].Purl)
+		assert.NotEmpty(t, resp.Unmatched[0].Reason)
+	}
+
+	for _, req := range []*pb.IndexSBOMRequest{
+		{Sbom: `{"spdxVersion": "SPDX-2.3", "packages": []}`},
+		{AncestryName: "sbom"},
+		{AncestryName: "sbom", Sbom: `{}`},
+		{AncestryName: "sbom", Sbom: `{"bomFormat": `},
+	} {
+		_, err := server.IndexSBOM(context.Background(), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "%v", req)
+	}
+}
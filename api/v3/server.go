@@ -15,15 +15,25 @@
 package v3
 
 import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 
+	"github.com/quay/clair/v3"
+	"github.com/quay/clair/v3/api/export"
 	pb "github.com/quay/clair/v3/api/v3/clairpb"
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/pkg/grpcutil"
@@ -32,7 +42,7 @@ import (
 var (
 	promResponseDurationMilliseconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "clair_v3_api_response_duration_milliseconds",
-		Help:    "The duration of time it takes to receive and write a response to an V2 API request",
+		Help:    "The duration of time it takes to receive and write a response to a v3 API request over HTTP, by route and status code.",
 		Buckets: prometheus.ExponentialBuckets(9.375, 2, 10),
 	}, []string{"route", "code"})
 )
@@ -59,12 +69,21 @@ func (w *httpStatusWriter) WriteHeader(code int) {
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// httpRoute returns the first segment of the path, which names the resource
+// without the high cardinality of its identifiers.
+func httpRoute(path string) string {
+	return "/" + strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+}
+
 func loggingHandler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		lrw := &httpStatusWriter{ResponseWriter: w, StatusCode: http.StatusOK}
 
 		h.ServeHTTP(lrw, r)
+		promResponseDurationMilliseconds.
+			WithLabelValues(httpRoute(r.URL.Path), strconv.Itoa(lrw.StatusCode)).
+			Observe(float64(time.Since(start).Nanoseconds()) * 1e-6)
 
 		log.WithFields(log.Fields{
 			"remote addr":       r.RemoteAddr,
@@ -76,31 +95,266 @@ func loggingHandler(h http.Handler) http.Handler {
 	})
 }
 
-// ListenAndServe serves the Clair v3 API over gRPC and the gRPC Gateway.
-func ListenAndServe(addr, certFile, keyFile, caPath string, store database.Datastore) error {
+// The gRPC methods whose requests are also served by the handlers of raw
+// documents, for their authorization.
+const (
+	getAncestryMethod = "/coreos.clair.AncestryService/GetAncestry"
+	indexSBOMMethod   = "/coreos.clair.AncestryService/IndexSBOM"
+)
+
+// exportHandler serves the vulnerabilities of an ancestry as a SARIF document
+// at GET /ancestry/{ancestry_name}/sarif, and as a CycloneDX document at GET
+// /ancestry/{ancestry_name} when it is the accepted media type, with the
+// filters of GetAncestry as query parameters, to the clients the authorizer
+// allows to call GetAncestry. It passes the other requests to h.
+func exportHandler(store database.Datastore, authorizer *grpcutil.Authorizer, h http.Handler) http.Handler {
+	server := &AncestryServer{Store: store}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		var (
+			render      func(*pb.GetAncestryResponse_Ancestry) ([]byte, error)
+			contentType string
+		)
+		name, ok := sarifAncestryName(r.URL.Path)
+		if ok {
+			render, contentType = export.SARIF, export.SARIFContentType
+		} else if name, ok = ancestryName(r.URL.Path); ok && accepts(r, export.CycloneDXContentType) {
+			render, contentType = export.CycloneDX, export.CycloneDXContentType
+		} else {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if err := authorizer.Authorize(grpcutil.ClientFromRequest(r), getAncestryMethod); err != nil {
+			http.Error(w, status.Convert(err).Message(), runtime.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+
+		query := r.URL.Query()
+		fixedOnly, _ := strconv.ParseBool(query.Get("fixed_only"))
+		resp, err := server.GetAncestry(r.Context(), &pb.GetAncestryRequest{
+			AncestryName:      name,
+			IncludeSuppressed: true,
+			MinimumSeverity:   query.Get("minimum_severity"),
+			FixedOnly:         fixedOnly,
+		})
+		if err != nil {
+			http.Error(w, status.Convert(err).Message(), runtime.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+
+		content, err := render(resp.Ancestry)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Write(content)
+	})
+}
+
+// spdxContentType is the media type of SPDX JSON documents.
+const spdxContentType = "application/spdx+json"
+
+// sbomHandler indexes the SBOMs posted at POST /sbom?ancestry_name={name} as
+// raw CycloneDX or SPDX JSON documents, rather than embedded in the request
+// of IndexSBOM, for the clients the authorizer allows to call IndexSBOM. It
+// passes the other requests to h.
+func sbomHandler(store database.Datastore, authorizer *grpcutil.Authorizer, h http.Handler) http.Handler {
+	server := &AncestryServer{Store: store}
+	marshaler := &runtime.JSONPb{OrigName: true}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Method != http.MethodPost || r.URL.Path != "/sbom" || (mediaType != export.CycloneDXContentType && mediaType != spdxContentType) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		if err := authorizer.Authorize(grpcutil.ClientFromRequest(r), indexSBOMMethod); err != nil {
+			http.Error(w, status.Convert(err).Message(), runtime.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+
+		document, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := server.IndexSBOM(r.Context(), &pb.IndexSBOMRequest{
+			AncestryName: r.URL.Query().Get("ancestry_name"),
+			Sbom:         string(document),
+		})
+		if err != nil {
+			http.Error(w, status.Convert(err).Message(), runtime.HTTPStatusFromCode(status.Code(err)))
+			return
+		}
+
+		content, err := marshaler.Marshal(resp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", marshaler.ContentType())
+		w.Write(content)
+	})
+}
+
+// sarifAncestryName returns the name of the ancestry whose SARIF document is
+// requested at the path, if any.
+func sarifAncestryName(path string) (string, bool) {
+	if !strings.HasSuffix(path, "/sarif") {
+		return "", false
+	}
+
+	return ancestryName(strings.TrimSuffix(path, "/sarif"))
+}
+
+// ancestryName returns the name of the ancestry requested at the path, if
+// any.
+func ancestryName(path string) (string, bool) {
+	if !strings.HasPrefix(path, "/ancestry/") {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(path, "/ancestry/")
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+
+	return name, true
+}
+
+// accepts returns whether the media type is listed by the Accept header of
+// the request, ignoring its parameters.
+func accepts(r *http.Request, mediaType string) bool {
+	for _, header := range r.Header["Accept"] {
+		for _, accepted := range strings.Split(header, ",") {
+			if i := strings.Index(accepted, ";"); i >= 0 {
+				accepted = accepted[:i]
+			}
+
+			if strings.EqualFold(strings.TrimSpace(accepted), mediaType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// limitedMethods are the methods analyzing layers, whose calls are limited.
+var limitedMethods = []string{
+	"/coreos.clair.AncestryService/PostAncestry",
+	"/coreos.clair.AncestryService/PostAncestries",
+	"/coreos.clair.AncestryService/RescanAncestry",
+}
+
+// serviceHandlers register the services on the gRPC Gateway, which serves
+// them as JSON over HTTP.
+var serviceHandlers = []grpcutil.RegisterServiceHandlerFunc{
+	pb.RegisterAncestryServiceHandler,
+	pb.RegisterNotificationServiceHandler,
+	pb.RegisterStatusServiceHandler,
+	pb.RegisterSuppressionServiceHandler,
+	pb.RegisterUpdaterServiceHandler,
+	pb.RegisterVulnerabilityServiceHandler,
+}
+
+// registerServices returns the function registering the services backed by
+// the datastore on a gRPC server. The updaters are triggered with the jobs by
+// the requests bearing the updater token, and the statistics are cached for
+// the ttl.
+func registerServices(store database.Datastore, jobs *clair.UpdaterJobs, updaterToken string, statisticsCacheTTL time.Duration) grpcutil.RegisterServicesFunc {
+	return func(gsrv *grpc.Server) {
+		pb.RegisterAncestryServiceServer(gsrv, &AncestryServer{Store: store})
+		pb.RegisterNotificationServiceServer(gsrv, &NotificationServer{Store: store})
+		pb.RegisterStatusServiceServer(gsrv, &StatusServer{Store: store, StatisticsCacheTTL: statisticsCacheTTL})
+		pb.RegisterSuppressionServiceServer(gsrv, &SuppressionServer{Store: store})
+		pb.RegisterUpdaterServiceServer(gsrv, &UpdaterServer{Jobs: jobs, Token: updaterToken})
+		pb.RegisterVulnerabilityServiceServer(gsrv, &VulnerabilityServer{Store: store})
+	}
+}
+
+// Options are the options of the gRPC server.
+type Options struct {
+	// UpdaterMaxAge is how old the last update can be before the health
+	// service reports the updater service as not serving. Its freshness is
+	// not checked when it is zero.
+	UpdaterMaxAge time.Duration
+
+	// Reflection registers the server reflection service.
+	Reflection bool
+
+	// StatisticsCacheTTL is how long the statistics served by the status
+	// service are cached, which is five minutes when it is 0.
+	StatisticsCacheTTL time.Duration
+
+	// ServerOptions are applied to the gRPC server, such as its keepalive
+	// parameters.
+	ServerOptions []grpc.ServerOption
+
+	// GatewayOptions are applied to the connection of the gateway to the
+	// gRPC server, such as its maximum message sizes.
+	GatewayOptions []grpc.DialOption
+
+	// Limits limit the calls of the methods analyzing layers, the read-only
+	// methods being never limited.
+	Limits grpcutil.LimitConfig
+}
+
+// registerStandardServices returns the function registering the services on
+// a gRPC server, then the health service driven by the checker until the
+// context is done, and the reflection service if enabled.
+func registerStandardServices(ctx context.Context, register grpcutil.RegisterServicesFunc, checker *healthChecker, reflect bool) grpcutil.RegisterServicesFunc {
+	return func(gsrv *grpc.Server) {
+		register(gsrv)
+		checker.register(gsrv)
+		go checker.run(ctx)
+
+		if reflect {
+			reflection.Register(gsrv)
+		}
+	}
+}
+
+// ListenAndServe serves the Clair v3 API over gRPC and the gRPC Gateway until
+// the context is done.
+//
+// Both share the listener: requests which aren't gRPC requests are served by
+// the gateway, as JSON, under the TLS configuration of the listener, which is
+// used when the certificate file is not empty. The calls are authorized by
+// the authorizer, if any.
+//
+// The standard gRPC health service is served along with the API.
+func ListenAndServe(ctx context.Context, addr, certFile, keyFile, caPath string, tlsConfig *tls.Config, authorizer *grpcutil.Authorizer, store database.Datastore, jobs *clair.UpdaterJobs, updaterToken string, options Options) error {
+	checker := newHealthChecker(store, options.UpdaterMaxAge)
 	srv := grpcutil.MuxedGRPCServer{
-		Addr: addr,
-		ServicesFunc: func(gsrv *grpc.Server) {
-			pb.RegisterAncestryServiceServer(gsrv, &AncestryServer{Store: store})
-			pb.RegisterNotificationServiceServer(gsrv, &NotificationServer{Store: store})
-			pb.RegisterStatusServiceServer(gsrv, &StatusServer{Store: store})
-		},
-		ServiceHandlerFuncs: []grpcutil.RegisterServiceHandlerFunc{
-			pb.RegisterAncestryServiceHandler,
-			pb.RegisterNotificationServiceHandler,
-			pb.RegisterStatusServiceHandler,
-		},
+		Addr:                addr,
+		TLSConfig:           tlsConfig,
+		Authorizer:          authorizer,
+		Limiter:             grpcutil.NewLimiter(options.Limits, limitedMethods, authorizer),
+		ServerOptions:       options.ServerOptions,
+		GatewayOptions:      options.GatewayOptions,
+		ServicesFunc:        registerStandardServices(ctx, registerServices(store, jobs, updaterToken, options.StatisticsCacheTTL), checker, options.Reflection),
+		ServiceHandlerFuncs: serviceHandlers,
 	}
 
 	middleware := func(h http.Handler) http.Handler {
-		return prometheusHandler(loggingHandler(h))
+		return prometheusHandler(loggingHandler(exportHandler(store, authorizer, sbomHandler(store, authorizer, h))))
 	}
 
 	var err error
-	if caPath == "" {
-		err = srv.ListenAndServe(middleware)
+	if certFile == "" {
+		err = srv.ListenAndServe(ctx, middleware)
 	} else {
-		err = srv.ListenAndServeTLS(certFile, keyFile, caPath, middleware)
+		err = srv.ListenAndServeTLS(ctx, certFile, keyFile, caPath, middleware)
 	}
 	return err
 }
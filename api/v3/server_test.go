@@ -0,0 +1,577 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/quay/clair/v3"
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/ext/imagefmt/docker"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/grpcutil"
+)
+
+// serveTestAPI serves the API backed by the datastore over gRPC and the gRPC
+// Gateway, and returns a client connection to the former and the URL of the
+// latter.
+func serveTestAPI(t *testing.T, store database.Datastore) (*grpc.ClientConn, string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	gsrv := grpcutil.NewServer(nil, nil, nil, registerServices(store, clair.NewUpdaterJobs(context.Background(), nil, store), "token", 0))
+	go gsrv.Serve(l)
+
+	gateway, gatewayConn, err := grpcutil.NewGateway(l.Addr().String(), nil, serviceHandlers)
+	require.Nil(t, err)
+	hsrv := httptest.NewServer(exportHandler(store, nil, sbomHandler(store, nil, gateway)))
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+
+	return conn, hsrv.URL, func() {
+		conn.Close()
+		hsrv.Close()
+		gatewayConn.Close()
+		gsrv.Stop()
+	}
+}
+
+func TestGatewayRoundTrip(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	// No detector is enabled, the layer is not downloaded.
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistLayer("layer", nil, nil, nil))
+	require.Nil(t, tx.Commit())
+
+	conn, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	// The ancestry posted as JSON is read back over gRPC.
+	resp, err := http.Post(url+"/ancestry", "application/json", strings.NewReader(`{
+		"ancestry_name": "ancestry",
+		"format": "Docker",
+		"layers": [{"hash": "layer", "path": "https://example.com/layer"}]
+	}`))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	ancestry, err := pb.NewAncestryServiceClient(conn).GetAncestry(context.Background(), &pb.GetAncestryRequest{AncestryName: "ancestry"})
+	require.Nil(t, err)
+	assert.Equal(t, "ancestry", ancestry.Ancestry.Name)
+	if assert.Len(t, ancestry.Ancestry.Layers, 1) {
+		assert.Equal(t, "layer", ancestry.Ancestry.Layers[0].Layer.Hash)
+	}
+
+	// The JSON field names are the ones of the protobuf messages.
+	resp, err = http.Get(url + "/ancestry/ancestry")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body struct {
+		Ancestry struct {
+			Name   string `json:"name"`
+			Layers []struct {
+				Layer struct {
+					Hash string `json:"hash"`
+				} `json:"layer"`
+			} `json:"layers"`
+		} `json:"ancestry"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "ancestry", body.Ancestry.Name)
+	if assert.Len(t, body.Ancestry.Layers, 1) {
+		assert.Equal(t, "layer", body.Ancestry.Layers[0].Layer.Hash)
+	}
+}
+
+func TestGatewayStatusCodes(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	_, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	for _, test := range []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodGet, "/status", "", http.StatusOK},
+		{http.MethodGet, "/namespaces", "", http.StatusOK},
+		{http.MethodGet, "/ancestry/unknown", "", http.StatusNotFound},
+		{http.MethodPost, "/ancestry", `{"ancestry_name": "ancestry", "format": "unknown"}`, http.StatusBadRequest},
+		{http.MethodPost, "/ancestry", `{"ancestry_name": `, http.StatusBadRequest},
+		// The page limit is a query parameter.
+		{http.MethodGet, "/notifications/unknown", "", http.StatusBadRequest},
+		{http.MethodGet, "/notifications/unknown?limit=10", "", http.StatusNotFound},
+		{http.MethodDelete, "/notifications/unknown", "", http.StatusNotFound},
+		// The namespace of a vulnerability is a query parameter.
+		{http.MethodGet, "/vulnerabilities/CVE-2019-0001", "", http.StatusBadRequest},
+		{http.MethodGet, "/vulnerabilities/CVE-2019-0001?namespace_name=debian:9", "", http.StatusNotFound},
+		{http.MethodGet, "/vulnerabilities?namespace_name=debian:9&limit=10", "", http.StatusOK},
+		{http.MethodGet, "/vulnerabilities/CVE-2019-0001/ancestries?namespace_name=debian:9&limit=10", "", http.StatusNotFound},
+	} {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, url+test.path, strings.NewReader(test.body))
+			require.Nil(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := http.DefaultClient.Do(req)
+			require.Nil(t, err)
+			resp.Body.Close()
+			assert.Equal(t, test.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestGatewayTriggerUpdater(t *testing.T) {
+	registerTestUpdater.Do(func() { vulnsrc.RegisterUpdater("api-test", testUpdater{}) })
+
+	enabled := clair.EnabledUpdaters
+	defer func() { clair.EnabledUpdaters = enabled }()
+	clair.EnabledUpdaters = []string{"api-test"}
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	_, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	do := func(method, path, token string) (int, *pb.UpdaterJob) {
+		req, err := http.NewRequest(method, url+path, nil)
+		require.Nil(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		var body struct {
+			Job *pb.UpdaterJob `json:"job"`
+		}
+		if resp.StatusCode == http.StatusOK {
+			require.Nil(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, body.Job
+	}
+
+	code, _ := do(http.MethodPost, "/updaters/api-test/jobs", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(http.MethodPost, "/updaters/api-test/jobs", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(http.MethodPost, "/updaters/unknown/jobs", "token")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// The triggered job is polled until it is finished.
+	code, job := do(http.MethodPost, "/updaters/api-test/jobs", "token")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "api-test", job.Updater)
+	require.NotEmpty(t, job.Id)
+
+	deadline := time.Now().Add(30 * time.Second)
+	for job.Status == string(clair.UpdaterJobRunning) {
+		require.True(t, time.Now().Before(deadline), "the updater job did not finish")
+		time.Sleep(10 * time.Millisecond)
+
+		code, job = do(http.MethodGet, "/updaters/api-test/jobs/"+job.Id, "token")
+		require.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, string(clair.UpdaterJobSucceeded), job.Status)
+	assert.NotEmpty(t, job.Finished)
+	assert.NotNil(t, job.Run)
+
+	code, _ = do(http.MethodGet, "/updaters/api-test/jobs/"+job.Id, "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = do(http.MethodGet, "/updaters/other/jobs/"+job.Id, "token")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestGatewaySARIF(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistVulnerableAncestry(t, store, map[string]database.Severity{
+		"CVE-2019-0001": database.HighSeverity,
+		"CVE-2019-0002": database.LowSeverity,
+	}, "2.0")
+	persistVulnerableAncestry(t, store, map[string]database.Severity{"CVE-2019-0003": database.HighSeverity}, "")
+
+	_, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	results := func(query string) []string {
+		resp, err := http.Get(url + "/ancestry/ancestry/sarif" + query)
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/sarif+json", resp.Header.Get("Content-Type"))
+
+		var log struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Results []struct {
+					RuleID string `json:"ruleId"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&log))
+		assert.Equal(t, "2.1.0", log.Version)
+		require.Len(t, log.Runs, 1)
+
+		var ids []string
+		for _, result := range log.Runs[0].Results {
+			ids = append(ids, result.RuleID)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	assert.Equal(t, []string{"CVE-2019-0001", "CVE-2019-0002", "CVE-2019-0003"}, results(""))
+	assert.Equal(t, []string{"CVE-2019-0001", "CVE-2019-0003"}, results("?minimum_severity=High"))
+	assert.Equal(t, []string{"CVE-2019-0001"}, results("?minimum_severity=High&fixed_only=true"))
+
+	for path, code := range map[string]int{
+		"/ancestry/unknown/sarif":                         http.StatusNotFound,
+		"/ancestry/ancestry/sarif?minimum_severity=bogus": http.StatusBadRequest,
+		// The other routes are served by the gateway.
+		"/ancestry/ancestry": http.StatusOK,
+	} {
+		resp, err := http.Get(url + path)
+		require.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, code, resp.StatusCode, path)
+	}
+}
+
+func TestGatewayCycloneDX(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistVulnerableAncestry(t, store, map[string]database.Severity{
+		"CVE-2019-0001": database.HighSeverity,
+		"CVE-2019-0002": database.LowSeverity,
+	}, "2.0")
+
+	_, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	get := func(path, accept string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, url+path, nil)
+		require.Nil(t, err)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		return resp
+	}
+
+	resp := get("/ancestry/ancestry?minimum_severity=High", "application/vnd.cyclonedx+json; version=1.5, application/json;q=0.5")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/vnd.cyclonedx+json", resp.Header.Get("Content-Type"))
+
+	var bom struct {
+		BOMFormat       string `json:"bomFormat"`
+		SpecVersion     string `json:"specVersion"`
+		Components      []json.RawMessage
+		Vulnerabilities []struct {
+			ID string `json:"id"`
+		} `json:"vulnerabilities"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&bom))
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.NotEmpty(t, bom.Components)
+	if assert.Len(t, bom.Vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2019-0001", bom.Vulnerabilities[0].ID)
+	}
+
+	unknown := get("/ancestry/unknown", "application/vnd.cyclonedx+json")
+	unknown.Body.Close()
+	assert.Equal(t, http.StatusNotFound, unknown.StatusCode)
+
+	// Other media types are served by the gateway.
+	gateway := get("/ancestry/ancestry", "application/json")
+	defer gateway.Body.Close()
+	assert.Equal(t, http.StatusOK, gateway.StatusCode)
+	assert.Equal(t, "application/json", gateway.Header.Get("Content-Type"))
+}
+
+func TestAccepts(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"application/vnd.cyclonedx+json":                         true,
+		"application/json, Application/VND.CycloneDX+JSON;q=0.9": true,
+		"application/vnd.cyclonedx+json; version=1.5":            true,
+		"application/json":                                       false,
+		"":                                                       false,
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/ancestry/ancestry", nil)
+		r.Header.Set("Accept", accept)
+		assert.Equal(t, expected, accepts(r, "application/vnd.cyclonedx+json"), accept)
+	}
+}
+
+func TestSARIFAncestryName(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/ancestry/ancestry/sarif": "ancestry",
+		"/ancestry//sarif":         "",
+		"/ancestry/a/b/sarif":      "",
+		"/ancestry/ancestry":       "",
+		"/status/sarif":            "",
+	} {
+		name, ok := sarifAncestryName(path)
+		assert.Equal(t, expected != "", ok, path)
+		assert.Equal(t, expected, name, path)
+	}
+}
+
+func TestGatewayIndexSBOM(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	_, url, cleanup := serveTestAPI(t, store)
+	defer cleanup()
+
+	const document = `{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [
+		{"name": "curl", "version": "7.64.0-4", "purl": "pkg:deb/debian/curl@7.64.0-4?distro=debian-10"},
+		{"name": "lodash", "version": "4.17.15", "purl": "pkg:npm/lodash@4.17.15"}
+	]}`
+
+	post := func(path, contentType, body string) (int, []string) {
+		resp, err := http.Post(url+path, contentType, strings.NewReader(body))
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return resp.StatusCode, nil
+		}
+
+		var response struct {
+			Ancestry struct {
+				Name string `json:"name"`
+			} `json:"ancestry"`
+			Unmatched []struct {
+				Purl string `json:"purl"`
+			} `json:"unmatched"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&response))
+		assert.Equal(t, "sbom", response.Ancestry.Name)
+
+		var unmatched []string
+		for _, component := range response.Unmatched {
+			unmatched = append(unmatched, component.Purl)
+		}
+		return resp.StatusCode, unmatched
+	}
+
+	// The SBOM is either embedded in the JSON request or posted as is.
+	request, err := json.Marshal(map[string]string{"ancestry_name": "sbom", "sbom": document})
+	require.Nil(t, err)
+	code, unmatched := post("/sbom", "application/json", string(request))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"pkg:npm/lodash@4.17.15"}, unmatched)
+
+	code, unmatched = post("/sbom?ancestry_name=sbom", "application/vnd.cyclonedx+json; version=1.5", document)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"pkg:npm/lodash@4.17.15"}, unmatched)
+
+	code, _ = post("/sbom?ancestry_name=sbom", "application/spdx+json", `{"spdxVersion": "SPDX-2.3", "packages": []}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = post("/sbom", "application/vnd.cyclonedx+json", document)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = post("/sbom?ancestry_name=sbom", "application/spdx+json", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestDocumentHandlersAuthorization(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	persistVulnerableAncestry(t, store, map[string]database.Severity{"CVE-2019-0001": database.HighSeverity}, "2.0")
+
+	authorizer, err := grpcutil.NewAuthorizer([]grpcutil.AuthorizationRule{
+		{Token: "reader", Methods: []string{getAncestryMethod}},
+		{Token: "ci", Methods: []string{indexSBOMMethod}},
+	})
+	require.Nil(t, err)
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	hsrv := httptest.NewServer(exportHandler(store, authorizer, sbomHandler(store, authorizer, notFound)))
+	defer hsrv.Close()
+
+	do := func(method, path, contentType, token string) int {
+		req, err := http.NewRequest(method, hsrv.URL+path, strings.NewReader(`{"spdxVersion": "SPDX-2.3", "packages": []}`))
+		require.Nil(t, err)
+		req.Header.Set("Content-Type", contentType)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/ancestry/ancestry/sarif", "", ""))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/ancestry/ancestry/sarif", "", "ci"))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/ancestry/ancestry/sarif", "", "reader"))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/sbom?ancestry_name=sbom", "application/spdx+json", "reader"))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/sbom?ancestry_name=sbom", "application/spdx+json", "ci"))
+}
+
+// slowAncestryDatastore finds every ancestry, as already analyzed, after a
+// delay, and records how many are looked up at the same time.
+type slowAncestryDatastore struct {
+	database.MockDatastore
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func newSlowAncestryDatastore(delay time.Duration) *slowAncestryDatastore {
+	store := &slowAncestryDatastore{}
+	store.FctBegin = func() (database.Session, error) {
+		return &database.MockSession{
+			FctRollback: func() error { return nil },
+			FctFindKeyValue: func(string) (string, bool, error) {
+				return "", false, nil
+			},
+			FctFindAncestry: func(name string) (database.Ancestry, bool, error) {
+				store.mu.Lock()
+				store.inFlight++
+				if store.inFlight > store.maxInFlight {
+					store.maxInFlight = store.inFlight
+				}
+				store.mu.Unlock()
+
+				time.Sleep(delay)
+
+				store.mu.Lock()
+				store.inFlight--
+				store.mu.Unlock()
+				return database.Ancestry{Name: name}, true, nil
+			},
+		}, nil
+	}
+	return store
+}
+
+// postAncestries posts the ancestries at once, and returns the codes of the
+// responses.
+func postAncestries(client pb.AncestryServiceClient, count int) []codes.Code {
+	results := make([]codes.Code, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := client.PostAncestry(context.Background(), &pb.PostAncestryRequest{
+				AncestryName: fmt.Sprintf("ancestry-%d", i),
+				Format:       "Docker",
+				Layers:       []*pb.PostAncestryRequest_PostLayer{{Hash: "layer", Path: "https://example.com/layer"}},
+			})
+			results[i] = status.Code(err)
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestPostAncestryConcurrencyLimit(t *testing.T) {
+	const maxConcurrent = 2
+	store := newSlowAncestryDatastore(100 * time.Millisecond)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	limiter := grpcutil.NewLimiter(grpcutil.LimitConfig{MaxConcurrent: maxConcurrent, QueueTimeout: 10 * time.Second}, limitedMethods, nil)
+	gsrv := grpcutil.NewServer(nil, nil, limiter, registerServices(store, clair.NewUpdaterJobs(context.Background(), nil, store), "", 0))
+	go gsrv.Serve(l)
+	defer gsrv.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	// The excess posts wait for the ones being processed.
+	for _, code := range postAncestries(pb.NewAncestryServiceClient(conn), maxConcurrent+5) {
+		assert.Equal(t, codes.OK, code)
+	}
+	assert.Equal(t, maxConcurrent, store.maxInFlight)
+}
+
+func TestPostAncestryQueueTimeout(t *testing.T) {
+	const maxConcurrent = 2
+	store := newSlowAncestryDatastore(200 * time.Millisecond)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	limiter := grpcutil.NewLimiter(grpcutil.LimitConfig{MaxConcurrent: maxConcurrent, QueueTimeout: 50 * time.Millisecond}, limitedMethods, nil)
+	gsrv := grpcutil.NewServer(nil, nil, limiter, registerServices(store, clair.NewUpdaterJobs(context.Background(), nil, store), "", 0))
+	go gsrv.Serve(l)
+	defer gsrv.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+	defer conn.Close()
+
+	// The read-only calls are not limited while the posts are processed.
+	done := make(chan []codes.Code)
+	go func() { done <- postAncestries(pb.NewAncestryServiceClient(conn), maxConcurrent+5) }()
+	time.Sleep(20 * time.Millisecond)
+	_, err = pb.NewStatusServiceClient(conn).GetStatus(context.Background(), &pb.GetStatusRequest{})
+	assert.Nil(t, err)
+
+	counts := map[codes.Code]int{}
+	for _, code := range <-done {
+		counts[code]++
+	}
+	assert.Equal(t, map[codes.Code]int{codes.OK: maxConcurrent, codes.ResourceExhausted: 5}, counts)
+	assert.Equal(t, maxConcurrent, store.maxInFlight)
+}
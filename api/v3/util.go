@@ -15,11 +15,24 @@
 package v3
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/golang/protobuf/ptypes"
 	"github.com/quay/clair/v3"
 	pb "github.com/quay/clair/v3/api/v3/clairpb"
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/sbom"
+	"github.com/quay/clair/v3/ext/vulnsrc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -45,9 +58,373 @@ func GetClairStatus(store database.Datastore) (*pb.ClairStatus, error) {
 	return status, nil
 }
 
+// GetEnabledUpdaters retrieves the enabled vulnerability updaters, sorted by
+// name, along with the namespaces they declare covering and their latest
+// runs, at most runLimit of them.
+func GetEnabledUpdaters(store database.Datastore, runLimit int) ([]*pb.Updater, error) {
+	registered := vulnsrc.Updaters()
+	updaters := make([]*pb.Updater, 0, len(clair.EnabledUpdaters))
+	for _, name := range clair.EnabledUpdaters {
+		updater, ok := registered[name]
+		if !ok {
+			continue
+		}
+
+		dbRuns, err := database.FindUpdaterRunsAndRollback(store, name, runLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		runs := make([]*pb.UpdaterRun, 0, len(dbRuns))
+		for _, run := range dbRuns {
+			runs = append(runs, pb.UpdaterRunFromDatabaseModel(run))
+		}
+
+		updaters = append(updaters, &pb.Updater{
+			Name:       name,
+			Namespaces: vulnsrc.UpdaterNamespaces(updater),
+			Runs:       runs,
+		})
+	}
+
+	sort.Slice(updaters, func(i, j int) bool { return updaters[i].Name < updaters[j].Name })
+	return updaters, nil
+}
+
+// namespacesCacheTTL is how long the listed namespaces are cached, since
+// counting the vulnerabilities of every namespace scans them.
+const namespacesCacheTTL = 5 * time.Minute
+
+// namespacesCache caches the listed namespaces.
+type namespacesCache struct {
+	mu        sync.Mutex
+	resp      *pb.ListNamespacesResponse
+	refreshed time.Time
+}
+
+// get returns the cached namespaces, which are listed again when they are
+// older than namespacesCacheTTL.
+func (c *namespacesCache) get(store database.Datastore) (*pb.ListNamespacesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resp != nil && time.Since(c.refreshed) < namespacesCacheTTL {
+		return c.resp, nil
+	}
+
+	resp, err := GetNamespaces(store)
+	if err != nil {
+		return nil, err
+	}
+
+	c.resp, c.refreshed = resp, time.Now()
+	c.resp.Refreshed = fmt.Sprintf("%d", c.refreshed.Unix())
+	return c.resp, nil
+}
+
+// GetNamespaces retrieves the namespaces stored in the database with the
+// number of their vulnerabilities, and the enabled detectors.
+func GetNamespaces(store database.Datastore) (*pb.ListNamespacesResponse, error) {
+	namespaces, err := database.FindNamespacesAndRollback(store)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListNamespacesResponse{
+		Namespaces: make([]*pb.NamespaceCoverage, 0, len(namespaces)),
+		Detectors:  pb.DetectorsFromDatabaseModel(clair.EnabledDetectors()),
+	}
+
+	for _, namespace := range namespaces {
+		resp.Namespaces = append(resp.Namespaces, pb.NamespaceCoverageFromDatabaseModel(namespace))
+	}
+
+	return resp, nil
+}
+
+// defaultStatisticsCacheTTL is how long the statistics are cached when no
+// period is configured.
+const defaultStatisticsCacheTTL = 5 * time.Minute
+
+// statisticsUpdaterRunLimit is the number of recorded runs of every updater
+// searched for its latest successful run.
+const statisticsUpdaterRunLimit = 100
+
+// statisticsCache caches the computed statistics.
+type statisticsCache struct {
+	mu        sync.Mutex
+	resp      *pb.GetStatisticsResponse
+	refreshed time.Time
+}
+
+// get returns the cached statistics, which are computed again when they are
+// older than the ttl, or defaultStatisticsCacheTTL when it is 0.
+func (c *statisticsCache) get(store database.Datastore, ttl time.Duration) (*pb.GetStatisticsResponse, error) {
+	if ttl <= 0 {
+		ttl = defaultStatisticsCacheTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.resp != nil && time.Since(c.refreshed) < ttl {
+		return c.resp, nil
+	}
+
+	resp, err := GetStatistics(store)
+	if err != nil {
+		return nil, err
+	}
+
+	c.resp, c.refreshed = resp, time.Now()
+	c.resp.Refreshed = fmt.Sprintf("%d", c.refreshed.Unix())
+	return c.resp, nil
+}
+
+// GetStatistics computes the aggregate statistics of the database and the
+// freshness of the enabled updaters.
+func GetStatistics(store database.Datastore) (*pb.GetStatisticsResponse, error) {
+	stats, err := database.FindStatisticsAndRollback(store)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetStatisticsResponse{
+		Vulnerabilities: make([]*pb.VulnerabilityCount, 0, len(stats.Vulnerabilities)),
+		AncestryCount:   int64(stats.Ancestries),
+		LayerCount:      int64(stats.Layers),
+		FeatureCount:    int64(stats.Features),
+		Updaters:        make([]*pb.UpdaterFreshness, 0, len(clair.EnabledUpdaters)),
+	}
+
+	for _, count := range stats.Vulnerabilities {
+		resp.Vulnerabilities = append(resp.Vulnerabilities, pb.VulnerabilityCountFromDatabaseModel(count))
+	}
+
+	registered := vulnsrc.Updaters()
+	for _, name := range clair.EnabledUpdaters {
+		if _, ok := registered[name]; !ok {
+			continue
+		}
+
+		runs, err := database.FindUpdaterRunsAndRollback(store, name, statisticsUpdaterRunLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Updaters = append(resp.Updaters, pb.UpdaterFreshnessFromDatabaseModel(name, runs))
+	}
+
+	sort.Slice(resp.Updaters, func(i, j int) bool { return resp.Updaters[i].Name < resp.Updaters[j].Name })
+
+	lastUpdate, firstUpdate, err := clair.GetLastUpdateTime(store)
+	if err != nil {
+		return nil, err
+	}
+
+	if !firstUpdate {
+		resp.LastUpdate = fmt.Sprintf("%d", lastUpdate.Unix())
+	}
+
+	return resp, nil
+}
+
+// UpdaterJobFromModel converts an updater job to api UpdaterJob.
+func UpdaterJobFromModel(job clair.UpdaterJob) *pb.UpdaterJob {
+	pbJob := &pb.UpdaterJob{
+		Id:      job.ID,
+		Updater: job.Updater,
+		Status:  string(job.Status),
+		Started: fmt.Sprintf("%d", job.Started.Unix()),
+		Error:   job.Error,
+	}
+
+	if !job.Finished.IsZero() {
+		pbJob.Finished = fmt.Sprintf("%d", job.Finished.Unix())
+	}
+
+	if job.Run != nil {
+		pbJob.Run = pb.UpdaterRunFromDatabaseModel(*job.Run)
+	}
+
+	return pbJob
+}
+
+// UnmatchedComponentFromModel converts a component of an SBOM which isn't
+// matched to api UnmatchedComponent.
+func UnmatchedComponentFromModel(unmatched sbom.Unmatched) *pb.IndexSBOMResponse_UnmatchedComponent {
+	return &pb.IndexSBOMResponse_UnmatchedComponent{
+		Name:    unmatched.Name,
+		Version: unmatched.Version,
+		Purl:    unmatched.PURL,
+		Reason:  unmatched.Reason.Error(),
+	}
+}
+
+// authenticate checks that the request bears the token, passed in the
+// "authorization" metadata, or the Authorization header through the gateway.
+func authenticate(ctx context.Context, token string) error {
+	if token == "" {
+		return status.Error(codes.PermissionDenied, "this endpoint is disabled, an API token must be configured")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md["authorization"] {
+		if subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+token)) == 1 {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "a valid bearer token is required")
+}
+
+// SuppressionRuleFromRequest converts the suppression rule of a request to a
+// database suppression rule, reporting invalid rules as invalid arguments.
+func SuppressionRuleFromRequest(rule *pb.SuppressionRule) (database.SuppressionRule, error) {
+	if rule == nil {
+		return database.SuppressionRule{}, status.Error(codes.InvalidArgument, "suppression rule should not be empty")
+	}
+
+	dbRule, err := pb.SuppressionRuleToDatabaseModel(rule)
+	if err != nil {
+		return database.SuppressionRule{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if !dbRule.Valid() {
+		return database.SuppressionRule{}, status.Error(codes.InvalidArgument, "suppression rule vulnerability name should not be empty")
+	}
+
+	return dbRule, nil
+}
+
+// GetSuppressionRule retrieves a stored suppression rule and wraps it inside
+// protobuf struct.
+func GetSuppressionRule(store database.Datastore, id int64) (*pb.SuppressionRule, error) {
+	rule, ok, err := database.FindSuppressionRuleAndRollback(store, id)
+	if err != nil {
+		return nil, newRPCErrorWithClairError(codes.Internal, err)
+	}
+
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "requested suppression rule '%d' is not found", id)
+	}
+
+	return pb.SuppressionRuleFromDatabaseModel(rule), nil
+}
+
+// errInvalidFeaturePage is returned when the page of features requested isn't
+// one returned by GetAncestry.
+var errInvalidFeaturePage = errors.New("invalid feature page")
+
+// encodeFeaturePage returns the page token of the features listed from the
+// offset, across the layers of an ancestry.
+func encodeFeaturePage(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeFeaturePage returns the offset of the page token, 0 being the offset
+// of the first page.
+func decodeFeaturePage(page string) (int, error) {
+	if page == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(page)
+	if err != nil {
+		return 0, errInvalidFeaturePage
+	}
+
+	offset, err := strconv.Atoi(string(b))
+	if err != nil || offset < 0 {
+		return 0, errInvalidFeaturePage
+	}
+
+	return offset, nil
+}
+
+// pageAncestryLayers returns the layers with only the features in the window
+// of limit features from the offset, the features of each layer being sorted
+// so that the pages don't depend on the order the database returned them.
+//
+// Every layer is returned, possibly without features, with the offset of the
+// next page, which is -1 when the window reaches the last feature.
+func pageAncestryLayers(layers []database.AncestryLayer, offset, limit int) ([]database.AncestryLayer, int) {
+	var (
+		paged = make([]database.AncestryLayer, 0, len(layers))
+		first = 0
+		end   = offset + limit
+	)
+
+	for _, layer := range layers {
+		features := make([]database.AncestryFeature, len(layer.Features))
+		copy(features, layer.Features)
+		sort.Slice(features, func(i, j int) bool {
+			return lessAncestryFeature(features[i], features[j])
+		})
+
+		last := first + len(features)
+		var window []database.AncestryFeature
+		if offset < last && end > first {
+			window = features[maxInt(offset-first, 0):minInt(end-first, len(features))]
+		}
+
+		paged = append(paged, database.AncestryLayer{Hash: layer.Hash, Features: window})
+		first = last
+	}
+
+	if end >= first {
+		return paged, -1
+	}
+
+	return paged, end
+}
+
+// lessAncestryFeature orders the ancestry features by namespace, feature and
+// detectors.
+func lessAncestryFeature(a, b database.AncestryFeature) bool {
+	keys := [...][2]string{
+		{a.Namespace.Name, b.Namespace.Name},
+		{a.Namespace.VersionFormat, b.Namespace.VersionFormat},
+		{a.Feature.Name, b.Feature.Name},
+		{a.Feature.Version, b.Feature.Version},
+		{a.Feature.VersionFormat, b.Feature.VersionFormat},
+		{string(a.Feature.Type), string(b.Feature.Type)},
+		{a.FeatureBy.Name, b.FeatureBy.Name},
+		{a.FeatureBy.Version, b.FeatureBy.Version},
+		{a.NamespaceBy.Name, b.NamespaceBy.Name},
+		{a.NamespaceBy.Version, b.NamespaceBy.Version},
+	}
+
+	for _, k := range keys {
+		if k[0] != k[1] {
+			return k[0] < k[1]
+		}
+	}
+
+	return false
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // GetPbAncestryLayer retrieves an ancestry layer with vulnerabilities and
 // features in an ancestry based on the provided database layer.
-func (s *AncestryServer) GetPbAncestryLayer(layer database.AncestryLayer) (*pb.GetAncestryResponse_AncestryLayer, error) {
+//
+// The vulnerabilities suppressed by the rules are omitted, or marked as
+// suppressed when includeSuppressed is true, and the ones which don't pass
+// the filter are omitted. The features are listed either way.
+func (s *AncestryServer) GetPbAncestryLayer(layer database.AncestryLayer, rules database.SuppressionRules, includeSuppressed bool, filter pb.VulnerabilityFilter) (*pb.GetAncestryResponse_AncestryLayer, error) {
 	pbLayer := &pb.GetAncestryResponse_AncestryLayer{
 		Layer: &pb.Layer{
 			Hash: layer.Hash,
@@ -60,16 +437,19 @@ func (s *AncestryServer) GetPbAncestryLayer(layer database.AncestryLayer) (*pb.G
 		return nil, newRPCErrorWithClairError(codes.Internal, err)
 	}
 
+	// Index the detected features, so that ancestries with tens of thousands
+	// of features aren't matched quadratically.
+	detectedFeatures := make(map[database.NamespacedFeature][]database.AncestryFeature, len(layer.Features))
+	for _, detectedFeature := range layer.Features {
+		detectedFeatures[detectedFeature.NamespacedFeature] = append(detectedFeatures[detectedFeature.NamespacedFeature], detectedFeature)
+	}
+
 	for _, feature := range affectedFeatures {
 		if !feature.Valid {
 			panic("feature is missing in the database, it indicates the database is corrupted.")
 		}
 
-		for _, detectedFeature := range layer.Features {
-			if detectedFeature.NamespacedFeature != feature.NamespacedFeature {
-				continue
-			}
-
+		for _, detectedFeature := range detectedFeatures[feature.NamespacedFeature] {
 			var (
 				pbFeature = pb.NamespacedFeatureFromDatabaseModel(detectedFeature)
 				pbVuln    *pb.Vulnerability
@@ -77,10 +457,21 @@ func (s *AncestryServer) GetPbAncestryLayer(layer database.AncestryLayer) (*pb.G
 			)
 
 			for _, vuln := range feature.AffectedBy {
+				if !filter.Keeps(vuln) {
+					continue
+				}
+
+				suppressed := rules.Suppresses(vuln.Name, vuln.Namespace.Name, feature.Name)
+				if suppressed && !includeSuppressed {
+					continue
+				}
+
 				if pbVuln, err = pb.VulnerabilityWithFixedInFromDatabaseModel(vuln); err != nil {
 					return nil, status.Error(codes.Internal, err.Error())
 				}
 
+				pbVuln.Suppressed = suppressed
+
 				pbFeature.Vulnerabilities = append(pbFeature.Vulnerabilities, pbVuln)
 			}
 
@@ -16,14 +16,44 @@ package clair
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/quay/clair/v3/ext/imagefmt"
 	"github.com/quay/clair/v3/pkg/httputil"
 )
 
+var promLayerDownloadBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "clair_layer_download_bytes_total",
+	Help: "Number of bytes of the layer blobs downloaded over HTTP.",
+})
+
+func init() {
+	prometheus.MustRegister(promLayerDownloadBytesTotal)
+}
+
+// countingReadCloser counts the bytes read from a downloaded layer blob.
+type countingReadCloser struct {
+	io.ReadCloser
+}
+
+func (r countingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	promLayerDownloadBytesTotal.Add(float64(n))
+	return n, err
+}
+
+// localLayerRoot is the directory layers given as local file paths are
+// confined to. Local file paths are rejected when it is empty.
+var localLayerRoot string
+
 func retrieveLayerBlob(ctx context.Context, path string, headers map[string]string) (io.ReadCloser, error) {
 	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
 		httpHeaders := make(http.Header)
@@ -31,13 +61,87 @@ func retrieveLayerBlob(ctx context.Context, path string, headers map[string]stri
 			httpHeaders[key] = []string{value}
 		}
 
-		reader, err := httputil.GetWithContext(ctx, path, httpHeaders)
+		u, err := url.Parse(path)
+		if err != nil {
+			return nil, err
+		}
+
+		reader, err := httputil.GetWithTransport(ctx, imagefmt.Transport(u), path, httpHeaders)
 		if err != nil {
 			return nil, err
 		}
 
-		return reader, nil
+		return countingReadCloser{reader}, nil
+	}
+
+	return openLocalLayer(localLayerRoot, strings.TrimPrefix(path, "file://"))
+}
+
+// openLocalLayer opens the layer at the given path, ensuring that it resolves
+// to a file inside of root once every symbolic link is followed.
+//
+// Relative paths are resolved against root.
+func openLocalLayer(root, path string) (io.ReadCloser, error) {
+	if root == "" {
+		return nil, LayerPathForbiddenError
+	}
+
+	for _, element := range strings.Split(filepath.ToSlash(path), "/") {
+		if element == ".." {
+			return nil, LayerPathForbiddenError
+		}
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isWithin(root, resolved) {
+		return nil, LayerPathForbiddenError
+	}
+
+	return os.Open(resolved)
+}
+
+// resolveLocalLayerRoot returns the absolute path of the root directory with
+// every symbolic link followed.
+func resolveLocalLayerRoot(root string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("%q is not a directory", root)
+	}
+
+	return root, nil
+}
+
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
 	}
 
-	return os.Open(path)
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
@@ -0,0 +1,112 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetrieveLocalLayerBlob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-layers")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	require.Nil(t, os.Mkdir(root, 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(root, "layer.tar"), []byte("inside"), 0644))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "secret.tar"), []byte("outside"), 0644))
+	require.Nil(t, os.Symlink(filepath.Join(dir, "secret.tar"), filepath.Join(root, "escape.tar")))
+	require.Nil(t, os.Symlink(filepath.Join(root, "layer.tar"), filepath.Join(root, "link.tar")))
+
+	resolved, err := resolveLocalLayerRoot(root)
+	require.Nil(t, err)
+
+	previous := localLayerRoot
+	localLayerRoot = resolved
+	defer func() { localLayerRoot = previous }()
+
+	for _, path := range []string{
+		"layer.tar",
+		"link.tar",
+		filepath.Join(root, "layer.tar"),
+		"file://" + filepath.Join(root, "layer.tar"),
+	} {
+		blob, err := retrieveLayerBlob(context.Background(), path, nil)
+		if assert.Nil(t, err, path) {
+			content, err := ioutil.ReadAll(blob)
+			blob.Close()
+			assert.Nil(t, err)
+			assert.Equal(t, "inside", string(content), path)
+		}
+	}
+
+	for _, path := range []string{
+		"../secret.tar",
+		"sub/../../secret.tar",
+		filepath.Join(root, "..", "secret.tar"),
+		"file://" + filepath.Join(root, "..", "secret.tar"),
+		filepath.Join(dir, "secret.tar"),
+		"escape.tar",
+	} {
+		_, err := retrieveLayerBlob(context.Background(), path, nil)
+		assert.Equal(t, LayerPathForbiddenError, err, path)
+	}
+
+	_, err = retrieveLayerBlob(context.Background(), "missing.tar", nil)
+	assert.NotNil(t, err)
+	assert.NotEqual(t, LayerPathForbiddenError, err)
+}
+
+func TestRetrieveLocalLayerBlobWithoutRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-layers")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "layer.tar")
+	require.Nil(t, ioutil.WriteFile(path, []byte("inside"), 0644))
+
+	previous := localLayerRoot
+	localLayerRoot = ""
+	defer func() { localLayerRoot = previous }()
+
+	_, err = retrieveLayerBlob(context.Background(), path, nil)
+	assert.Equal(t, LayerPathForbiddenError, err)
+}
+
+func TestResolveLocalLayerRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-layers")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "layer.tar")
+	require.Nil(t, ioutil.WriteFile(file, nil, 0644))
+
+	root, err := resolveLocalLayerRoot("")
+	assert.Nil(t, err)
+	assert.Equal(t, "", root)
+
+	_, err = resolveLocalLayerRoot(file)
+	assert.NotNil(t, err)
+
+	_, err = resolveLocalLayerRoot(filepath.Join(dir, "missing"))
+	assert.NotNil(t, err)
+}
@@ -16,8 +16,11 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -26,8 +29,11 @@ import (
 	"github.com/quay/clair/v3"
 	"github.com/quay/clair/v3/api"
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/imagefmt"
 	"github.com/quay/clair/v3/ext/notification"
 	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/grpcutil"
+	"github.com/quay/clair/v3/pkg/httputil"
 	"github.com/quay/clair/v3/pkg/pagination"
 )
 
@@ -35,6 +41,10 @@ import (
 // configuration file is not loaded properly
 var ErrDatasourceNotLoaded = errors.New("could not load configuration: no database source specified")
 
+// defaultPaginationKeyFile is the file of paginationkeydir the pagination key
+// is read from, unless paginationkeyfile is set.
+const defaultPaginationKeyFile = "key"
+
 // File represents a YAML configuration file that namespaces all Clair
 // configuration under the top-level "clair" key.
 type File struct {
@@ -43,10 +53,13 @@ type File struct {
 
 // Config is the global configuration for an instance of Clair.
 type Config struct {
-	Database database.RegistrableComponentConfig
-	Updater  *clair.UpdaterConfig
-	Notifier *notification.Config
-	API      *api.Config
+	Database   database.RegistrableComponentConfig
+	Worker     *clair.WorkerConfig
+	Updater    *clair.UpdaterConfig
+	Janitor    *clair.JanitorConfig
+	HTTPClient *httputil.Config
+	Notifier   *notification.Config
+	API        *api.Config
 }
 
 // DefaultConfig is a configuration that can be used as a fallback value.
@@ -54,11 +67,27 @@ func DefaultConfig() Config {
 	return Config{
 		Database: database.RegistrableComponentConfig{
 			Type: "pgsql",
+			Options: map[string]interface{}{
+				"maxopenconnections":    10,
+				"maxidleconnections":    2,
+				"connectionmaxlifetime": "30m",
+			},
+		},
+		Worker: &clair.WorkerConfig{
+			ConcurrentLayers: clair.DefaultLayerConcurrency,
 		},
 		Updater: &clair.UpdaterConfig{
 			EnabledUpdaters: vulnsrc.ListUpdaters(),
 			Interval:        1 * time.Hour,
 		},
+		Janitor: &clair.JanitorConfig{
+			Interval: 1 * time.Hour,
+		},
+		HTTPClient: &httputil.Config{
+			DialTimeout:     30 * time.Second,
+			ResponseTimeout: time.Minute,
+			Retries:         3,
+		},
 		API: &api.Config{
 			HealthAddr: "0.0.0.0:6061",
 			Addr:       "0.0.0.0:6060",
@@ -67,47 +96,178 @@ func DefaultConfig() Config {
 		Notifier: &notification.Config{
 			Attempts:         5,
 			RenotifyInterval: 2 * time.Hour,
+			BatchSize:        100,
+			DigestWindow:     5 * time.Minute,
 		},
 	}
 }
 
 // LoadConfig is a shortcut to open a file, read it, and generate a Config.
 //
-// It supports relative and absolute paths. Given "", it returns DefaultConfig.
-func LoadConfig(path string) (config *Config, err error) {
-	var cfgFile File
-	cfgFile.Clair = DefaultConfig()
+// It supports relative and absolute paths, in which the environment variables
+// are expanded. Given "", it starts from DefaultConfig, and given "-", it
+// reads the configuration from the standard input so that it isn't written to
+// a file. Either way, the configuration is overridden by the environment
+// variables of envOverrides and validated as ParseConfig does.
+func LoadConfig(path string) (*Config, error) {
 	if path == "" {
-		return &cfgFile.Clair, nil
+		return parseConfig(nil, os.LookupEnv)
 	}
 
-	f, err := os.Open(os.ExpandEnv(path))
+	data, err := readConfig(path)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer f.Close()
 
-	d, err := ioutil.ReadAll(f)
-	if err != nil {
-		return
+	return parseConfig(data, os.LookupEnv)
+}
+
+// readConfig reads the configuration file of the path, or the standard input
+// given "-".
+func readConfig(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
 	}
+	return ioutil.ReadFile(os.ExpandEnv(path))
+}
 
-	err = yaml.Unmarshal(d, &cfgFile)
-	if err != nil {
-		return
+// ParseConfig parses a YAML configuration, overriding DefaultConfig, and
+// validates it. A pagination key is generated when none is configured.
+//
+// Unlike LoadConfig, it doesn't depend on the environment variables.
+func ParseConfig(data []byte) (*Config, error) {
+	return parseConfig(data, nil)
+}
+
+// parseConfig parses a YAML configuration as ParseConfig does, the
+// environment variables found by lookup, if any, being interpolated and
+// overriding the file before it's validated.
+func parseConfig(data []byte, lookup lookupEnv) (*Config, error) {
+	var cfgFile File
+	cfgFile.Clair = DefaultConfig()
+
+	if err := yaml.Unmarshal(data, &cfgFile); err != nil {
+		return nil, err
+	}
+	config := &cfgFile.Clair
+
+	if lookup != nil {
+		if err := interpolateEnv(config, lookup); err != nil {
+			return nil, err
+		}
+		if err := applyEnvOverrides(config, lookup); err != nil {
+			return nil, err
+		}
+	}
+
+	if problems := checkConfig(config); len(problems) > 0 {
+		return nil, problems[0]
 	}
-	config = &cfgFile.Clair
 
 	// Generate a pagination key if none is provided.
-	if v, ok := config.Database.Options["paginationkey"]; !ok || v == nil || v.(string) == "" {
+	if config.Database.Options == nil {
+		config.Database.Options = make(map[string]interface{})
+	}
+	if err := loadPaginationKeyDir(config.Database.Options); err != nil {
+		return nil, err
+	}
+	key, ok := config.Database.Options["paginationkey"].(string)
+	if !ok && config.Database.Options["paginationkey"] != nil {
+		return nil, pagination.ErrInvalidKeyString
+	}
+	if key == "" {
 		log.Warn("pagination key is empty, generating...")
 		config.Database.Options["paginationkey"] = pagination.Must(pagination.NewKey()).String()
-	} else {
-		_, err = pagination.KeyFromString(config.Database.Options["paginationkey"].(string))
-		if err != nil {
-			return
+	} else if _, err := pagination.KeyFromString(key); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// checkConfig returns the problems of the configuration which prevent Clair
+// from starting.
+func checkConfig(config *Config) (problems []error) {
+	if config.Worker != nil {
+		if err := imagefmt.ValidateRegistries(config.Worker.Registries); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	if config.Updater != nil {
+		if config.Updater.IntervalJitter < 0 || config.Updater.IntervalJitter > clair.MaxIntervalJitter {
+			problems = append(problems, fmt.Errorf("could not load configuration: updater interval jitter must be between 0 and %d", clair.MaxIntervalJitter))
+		}
+		for _, rule := range config.Updater.Suppressions {
+			if !rule.Valid() {
+				problems = append(problems, errors.New("could not load configuration: updater suppressions must name a vulnerability"))
+				break
+			}
+		}
+	}
+
+	if config.Notifier != nil {
+		if err := config.Notifier.Filters.Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("could not load configuration: notifier filters: %v", err))
+		}
+		if config.Notifier.Digest && config.Notifier.DigestWindow <= 0 {
+			problems = append(problems, errors.New("could not load configuration: notifier digest window must be positive"))
+		}
+	}
+
+	if config.API != nil {
+		if _, err := config.API.TLSConfig(); err != nil {
+			problems = append(problems, err)
+		}
+		if _, err := grpcutil.NewAuthorizer(config.API.Authorization); err != nil {
+			problems = append(problems, err)
+		}
+		if config.API.MaxRecvMsgSize < 0 || config.API.MaxSendMsgSize < 0 {
+			problems = append(problems, errors.New("could not load configuration: api message sizes must not be negative"))
 		}
 	}
 
-	return
+	return problems
+}
+
+// loadPaginationKeyDir sets the pagination key of the database options to the
+// content of the paginationkeyfile of the paginationkeydir, e.g. the key of a
+// mounted Kubernetes Secret. Both options are removed from the database
+// options, which are passed to the driver.
+func loadPaginationKeyDir(options map[string]interface{}) error {
+	dir, ok := options["paginationkeydir"].(string)
+	if !ok && options["paginationkeydir"] != nil {
+		return errors.New("could not load configuration: paginationkeydir must be a path")
+	}
+	file, ok := options["paginationkeyfile"].(string)
+	if !ok && options["paginationkeyfile"] != nil {
+		return errors.New("could not load configuration: paginationkeyfile must be a file name")
+	}
+	delete(options, "paginationkeydir")
+	delete(options, "paginationkeyfile")
+
+	if dir == "" {
+		return nil
+	}
+	if key, _ := options["paginationkey"].(string); key != "" {
+		return errors.New("could not load configuration: paginationkey and paginationkeydir are mutually exclusive")
+	}
+	if file == "" {
+		file = defaultPaginationKeyFile
+	}
+
+	path := filepath.Join(dir, file)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not load pagination key: %v", err)
+	}
+
+	// Secrets are often written with a trailing newline.
+	key := strings.TrimSpace(string(content))
+	if _, err := pagination.KeyFromString(key); err != nil {
+		return fmt.Errorf("could not load pagination key from %s: %v", path, err)
+	}
+
+	options["paginationkey"] = key
+	return nil
 }
@@ -0,0 +1,380 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+const testConfig = `
+clair:
+  database:
+    type: memory
+    options:
+      paginationkey:
+  updater:
+    interval: 30m
+    csv:
+      url: https://partner.example.com/advisories.csv
+      versionformat: dpkg
+`
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(testConfig))
+	require.Nil(t, err)
+	assert.Equal(t, "memory", config.Database.Type)
+	assert.Equal(t, 30*time.Minute, config.Updater.Interval)
+	assert.Equal(t, map[interface{}]interface{}{
+		"url":           "https://partner.example.com/advisories.csv",
+		"versionformat": "dpkg",
+	}, config.Updater.Params["csv"])
+
+	// The pagination key is generated, and the defaults are kept.
+	assert.NotEmpty(t, config.Database.Options["paginationkey"])
+	assert.Equal(t, DefaultConfig().API.Addr, config.API.Addr)
+	assert.Equal(t, DefaultConfig().Notifier.Attempts, config.Notifier.Attempts)
+}
+
+func TestParseConfigDatabasePool(t *testing.T) {
+	// The connection pool defaults are kept along with the database options
+	// of the file, which override them.
+	config, err := ParseConfig([]byte(`
+clair:
+  database:
+    type: memory
+    options:
+      maxopenconnections: 20
+`))
+	require.Nil(t, err)
+	assert.Equal(t, 20, config.Database.Options["maxopenconnections"])
+	assert.Equal(t, 2, config.Database.Options["maxidleconnections"])
+	assert.Equal(t, "30m", config.Database.Options["connectionmaxlifetime"])
+}
+
+func TestParseConfigValidation(t *testing.T) {
+	key := pagination.Must(pagination.NewKey()).String()
+
+	for _, test := range []struct {
+		name   string
+		config string
+		valid  bool
+	}{
+		{"empty", ``, true},
+		{"no database options", "clair:\n  database:\n    type: memory\n", true},
+		{"pagination key", "clair:\n  database:\n    options:\n      paginationkey: " + key + "\n", true},
+		{"invalid pagination key", "clair:\n  database:\n    options:\n      paginationkey: invalid\n", false},
+		{"non-string pagination key", "clair:\n  database:\n    options:\n      paginationkey: 42\n", false},
+		{"invalid YAML", "clair: [", false},
+		{"unknown type", "clair:\n  updater:\n    interval: often\n", false},
+		{"updater interval jitter", "clair:\n  updater:\n    intervaljitter: 20\n", true},
+		{"negative updater interval jitter", "clair:\n  updater:\n    intervaljitter: -1\n", false},
+		{"largest updater interval jitter", "clair:\n  updater:\n    intervaljitter: 50\n", true},
+		{"excessive updater interval jitter", "clair:\n  updater:\n    intervaljitter: 51\n", false},
+		{"updater suppressions", "clair:\n  updater:\n    suppressions:\n      - vulnerability: CVE-2019-0001\n        namespace: oracle:8\n        feature: openssl\n        expires: 2030-01-01T00:00:00Z\n", true},
+		{"updater suppression without vulnerability", "clair:\n  updater:\n    suppressions:\n      - namespace: oracle:8\n", false},
+		{"notifier filters", "clair:\n  notifier:\n    filters:\n      minseverity: High\n      namespaces: [\"debian:*\"]\n", true},
+		{"unknown notifier filters severity", "clair:\n  notifier:\n    filters:\n      minseverity: Severe\n", false},
+		{"invalid notifier filters namespace", "clair:\n  notifier:\n    filters:\n      excludednamespaces: [\"debian:[\"]\n", false},
+		{"notifier digest", "clair:\n  notifier:\n    digest: true\n    digestwindow: 10m\n", true},
+		{"notifier digest without window", "clair:\n  notifier:\n    digest: true\n    digestwindow: 0s\n", false},
+		{"api message sizes", "clair:\n  api:\n    maxrecvmsgsize: 16777216\n    maxsendmsgsize: 16777216\n", true},
+		{"negative api message size", "clair:\n  api:\n    maxsendmsgsize: -1\n", false},
+		{"invalid TLS version", "clair:\n  api:\n    tlsminversion: \"0.9\"\n", false},
+		{"client certificates without CA", "clair:\n  api:\n    clientauth: require\n", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := ParseConfig([]byte(test.config))
+			if !test.valid {
+				assert.NotNil(t, err)
+				assert.Nil(t, config)
+				return
+			}
+
+			require.Nil(t, err)
+			assert.NotEmpty(t, config.Database.Options["paginationkey"])
+		})
+	}
+}
+
+func TestParseConfigPaginationKeyDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-secret")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	key := pagination.Must(pagination.NewKey()).String()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "key"), []byte(key+"\n"), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "pagination"), []byte(key), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "invalid"), []byte("invalid"), 0600))
+
+	for _, test := range []struct {
+		name    string
+		options string
+		valid   bool
+	}{
+		{"default file", "paginationkeydir: " + dir, true},
+		{"configured file", "paginationkeydir: " + dir + "\n      paginationkeyfile: pagination", true},
+		{"missing file", "paginationkeydir: " + dir + "\n      paginationkeyfile: missing", false},
+		{"invalid key", "paginationkeydir: " + dir + "\n      paginationkeyfile: invalid", false},
+		{"both keys", "paginationkeydir: " + dir + "\n      paginationkey: " + key, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := ParseConfig([]byte("clair:\n  database:\n    type: memory\n    options:\n      " + test.options + "\n"))
+			if !test.valid {
+				assert.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			assert.Equal(t, key, config.Database.Options["paginationkey"])
+			assert.NotContains(t, config.Database.Options, "paginationkeydir")
+			assert.NotContains(t, config.Database.Options, "paginationkeyfile")
+		})
+	}
+}
+
+func TestLoadConfigFromStdin(t *testing.T) {
+	f, err := ioutil.TempFile("", "clair-config")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(testConfig)
+	require.Nil(t, err)
+	_, err = f.Seek(0, 0)
+	require.Nil(t, err)
+
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin; f.Close() }()
+
+	config, err := LoadConfig("-")
+	require.Nil(t, err)
+	assert.Equal(t, "memory", config.Database.Type)
+	assert.NotEmpty(t, config.Database.Options["paginationkey"])
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "config.yaml"), []byte(testConfig), 0600))
+
+	// The environment variables of the path are expanded.
+	require.Nil(t, os.Setenv("CLAIR_TEST_CONFIG_DIR", dir))
+	defer os.Unsetenv("CLAIR_TEST_CONFIG_DIR")
+
+	config, err := LoadConfig("$CLAIR_TEST_CONFIG_DIR/config.yaml")
+	require.Nil(t, err)
+	assert.Equal(t, "memory", config.Database.Type)
+
+	_, err = LoadConfig(filepath.Join(dir, "missing.yaml"))
+	assert.True(t, os.IsNotExist(err))
+
+	config, err = LoadConfig("")
+	require.Nil(t, err)
+	assert.Equal(t, DefaultConfig().Database.Type, config.Database.Type)
+
+	// A pagination key is generated without a file too.
+	key, _ := config.Database.Options["paginationkey"].(string)
+	_, err = pagination.KeyFromString(key)
+	assert.Nil(t, err)
+}
+
+// setenv sets the environment variables for the duration of a test, and
+// returns a function restoring them.
+func setenv(t *testing.T, env map[string]string) func() {
+	for name, value := range env {
+		require.Nil(t, os.Setenv(name, value))
+	}
+	return func() {
+		for name := range env {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+// envMap looks up the environment variables in a map rather than in the
+// environment of the process.
+func envMap(env map[string]string) lookupEnv {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestParseConfigEnvOverrides(t *testing.T) {
+	const config = `
+clair:
+  database:
+    type: memory
+    options:
+      source: host=file
+  api:
+    addr: 0.0.0.0:7070
+    timeout: 10s
+  updater:
+    interval: 30m
+  notifier:
+    attempts: 2
+    http:
+      endpoint: http://file.example.com/notify
+`
+
+	// The file overrides the defaults.
+	cfg, err := ParseConfig([]byte(config))
+	require.Nil(t, err)
+	assert.Equal(t, "host=file", cfg.Database.Options["source"])
+	assert.Equal(t, "0.0.0.0:7070", cfg.API.Addr)
+	assert.Equal(t, DefaultConfig().API.HealthAddr, cfg.API.HealthAddr)
+
+	// The environment overrides the file and the defaults.
+	key := pagination.Must(pagination.NewKey()).String()
+	env := map[string]string{
+		"CLAIR_DATABASE_SOURCE":           "host=env",
+		"CLAIR_PAGINATION_KEY":            key,
+		"CLAIR_API_ADDR":                  "0.0.0.0:8080",
+		"CLAIR_API_HEALTHADDR":            "0.0.0.0:8081",
+		"CLAIR_UPDATER_INTERVAL":          "2h",
+		"CLAIR_NOTIFIER_ATTEMPTS":         "7",
+		"CLAIR_NOTIFIER_DEADLETTER":       "true",
+		"CLAIR_NOTIFIER_WEBHOOK_ENDPOINT": "http://env.example.com/notify",
+	}
+	cfg, err = parseConfig([]byte(config), envMap(env))
+	require.Nil(t, err)
+	assert.Equal(t, "memory", cfg.Database.Type)
+	assert.Equal(t, "host=env", cfg.Database.Options["source"])
+	assert.Equal(t, key, cfg.Database.Options["paginationkey"])
+	assert.Equal(t, "0.0.0.0:8080", cfg.API.Addr)
+	assert.Equal(t, "0.0.0.0:8081", cfg.API.HealthAddr)
+	assert.Equal(t, 10*time.Second, cfg.API.Timeout)
+	assert.Equal(t, 2*time.Hour, cfg.Updater.Interval)
+	assert.Equal(t, 7, cfg.Notifier.Attempts)
+	assert.True(t, cfg.Notifier.DeadLetter)
+	assert.Equal(t, "http://env.example.com/notify", cfg.Notifier.Params["http"].(map[interface{}]interface{})["endpoint"])
+
+	// ParseConfig ignores the environment of the process, which LoadConfig
+	// applies, even without a file.
+	defer setenv(t, env)()
+	cfg, err = ParseConfig([]byte(config))
+	require.Nil(t, err)
+	assert.Equal(t, "0.0.0.0:7070", cfg.API.Addr)
+
+	cfg, err = LoadConfig("")
+	require.Nil(t, err)
+	assert.Equal(t, "0.0.0.0:8080", cfg.API.Addr)
+	assert.Equal(t, DefaultConfig().Database.Type, cfg.Database.Type)
+	assert.Equal(t, "http://env.example.com/notify", cfg.Notifier.Params["http"].(map[interface{}]interface{})["endpoint"])
+}
+
+func TestParseConfigEnvOverridesPaginationKeyDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-pagination-key")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "key"), []byte(pagination.Must(pagination.NewKey()).String()), 0600))
+
+	key := pagination.Must(pagination.NewKey()).String()
+	env := envMap(map[string]string{"CLAIR_PAGINATION_KEY": key})
+
+	config, err := parseConfig([]byte("clair:\n  database:\n    type: memory\n    options:\n      paginationkeydir: "+dir+"\n"), env)
+	require.Nil(t, err)
+	assert.Equal(t, key, config.Database.Options["paginationkey"])
+	assert.NotContains(t, config.Database.Options, "paginationkeydir")
+}
+
+func TestParseConfigEnvOverridesInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name, value string
+		message     string
+	}{
+		{"CLAIR_API_TIMEOUT", "forever", "CLAIR_API_TIMEOUT"},
+		{"CLAIR_UPDATER_INTERVAL", "60", "CLAIR_UPDATER_INTERVAL"},
+		{"CLAIR_NOTIFIER_RENOTIFYINTERVAL", "2 hours", "CLAIR_NOTIFIER_RENOTIFYINTERVAL"},
+		{"CLAIR_NOTIFIER_ATTEMPTS", "five", "CLAIR_NOTIFIER_ATTEMPTS"},
+		{"CLAIR_NOTIFIER_DEADLETTER", "maybe", "CLAIR_NOTIFIER_DEADLETTER"},
+		{"CLAIR_NOTIFIER_DIGEST", "yes please", "CLAIR_NOTIFIER_DIGEST"},
+		{"CLAIR_PAGINATION_KEY", "not a key", "invalid pagination key"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			env := map[string]string{test.name: test.value}
+			_, err := parseConfig([]byte(testConfig), envMap(env))
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), test.message)
+
+			// Without a file, the configuration is validated all the same.
+			defer setenv(t, env)()
+			_, err = LoadConfig("")
+			require.NotNil(t, err)
+			assert.Contains(t, err.Error(), test.message)
+		})
+	}
+
+	// The notifier parameters of the webhook must be a map to be overridden.
+	env := envMap(map[string]string{"CLAIR_NOTIFIER_WEBHOOK_ENDPOINT": "http://example.com"})
+	_, err := parseConfig([]byte("clair:\n  notifier:\n    http: http://example.com\n"), env)
+	assert.NotNil(t, err)
+}
+
+func TestParseConfigEnvInterpolation(t *testing.T) {
+	env := envMap(map[string]string{
+		"CLAIR_TEST_DB_PASSWORD": "s3cr3t",
+		"CLAIR_TEST_CSV_HOST":    "partner.example.com",
+		"CLAIR_TEST_PROXY":       "proxy.example.com:3128",
+	})
+
+	config, err := parseConfig([]byte(`
+clair:
+  database:
+    type: memory
+    options:
+      source: host=localhost password=${CLAIR_TEST_DB_PASSWORD} sslmode=$disable
+  updater:
+    csv:
+      url: https://${CLAIR_TEST_CSV_HOST}/advisories.csv
+  notifier:
+    http:
+      proxy: http://${CLAIR_TEST_PROXY}
+      headers:
+      - X-Host=${CLAIR_TEST_CSV_HOST}
+      attempts: 3
+`), env)
+	require.Nil(t, err)
+
+	// Bare $ references are kept.
+	assert.Equal(t, "host=localhost password=s3cr3t sslmode=$disable", config.Database.Options["source"])
+	assert.Equal(t, "https://partner.example.com/advisories.csv", config.Updater.Params["csv"].(map[interface{}]interface{})["url"])
+	http := config.Notifier.Params["http"].(map[interface{}]interface{})
+	assert.Equal(t, "http://proxy.example.com:3128", http["proxy"])
+	assert.Equal(t, []interface{}{"X-Host=partner.example.com"}, http["headers"])
+	assert.Equal(t, 3, http["attempts"])
+
+	// Referencing an unset environment variable is an error.
+	const unset = "clair:\n  database:\n    options:\n      source: password=${CLAIR_TEST_UNSET}\n"
+	_, err = parseConfig([]byte(unset), env)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "CLAIR_TEST_UNSET")
+
+	// ParseConfig keeps the references.
+	config, err = ParseConfig([]byte(unset))
+	require.Nil(t, err)
+	assert.Equal(t, "password=${CLAIR_TEST_UNSET}", config.Database.Options["source"])
+}
@@ -0,0 +1,245 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/quay/clair/v3/api"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+// lookupEnv looks up the value of an environment variable, e.g. os.LookupEnv.
+type lookupEnv func(name string) (string, bool)
+
+// envOverride is an environment variable overriding a configuration value.
+type envOverride struct {
+	name  string
+	apply func(config *Config, value string) error
+}
+
+// envOverrides are the environment variables overriding the configuration
+// file, which in turn overrides DefaultConfig.
+var envOverrides = []envOverride{
+	{"CLAIR_DATABASE_TYPE", func(config *Config, value string) error {
+		config.Database.Type = value
+		return nil
+	}},
+	{"CLAIR_DATABASE_SOURCE", func(config *Config, value string) error {
+		databaseOptions(config)["source"] = value
+		return nil
+	}},
+	{"CLAIR_PAGINATION_KEY", func(config *Config, value string) error {
+		options := databaseOptions(config)
+		options["paginationkey"] = value
+		// The key of the environment replaces the one of the paginationkeydir.
+		delete(options, "paginationkeydir")
+		delete(options, "paginationkeyfile")
+		return nil
+	}},
+	{"CLAIR_API_ADDR", func(config *Config, value string) error {
+		apiConfig(config).Addr = value
+		return nil
+	}},
+	{"CLAIR_API_HEALTHADDR", func(config *Config, value string) error {
+		apiConfig(config).HealthAddr = value
+		return nil
+	}},
+	{"CLAIR_API_TIMEOUT", durationOverride(func(config *Config) *time.Duration {
+		return &apiConfig(config).Timeout
+	})},
+	{"CLAIR_UPDATER_INTERVAL", durationOverride(func(config *Config) *time.Duration {
+		if config.Updater == nil {
+			config.Updater = DefaultConfig().Updater
+		}
+		return &config.Updater.Interval
+	})},
+	{"CLAIR_NOTIFIER_ATTEMPTS", func(config *Config, value string) error {
+		attempts, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		notifierConfig(config).Attempts = attempts
+		return nil
+	}},
+	{"CLAIR_NOTIFIER_RENOTIFYINTERVAL", durationOverride(func(config *Config) *time.Duration {
+		return &notifierConfig(config).RenotifyInterval
+	})},
+	{"CLAIR_NOTIFIER_DEADLETTER", boolOverride(func(config *Config) *bool {
+		return &notifierConfig(config).DeadLetter
+	})},
+	{"CLAIR_NOTIFIER_DIGEST", boolOverride(func(config *Config) *bool {
+		return &notifierConfig(config).Digest
+	})},
+	{"CLAIR_NOTIFIER_WEBHOOK_ENDPOINT", func(config *Config, value string) error {
+		notifier := notifierConfig(config)
+		if notifier.Params == nil {
+			notifier.Params = make(map[string]interface{})
+		}
+		webhook, ok := notifier.Params["http"].(map[interface{}]interface{})
+		if !ok {
+			if notifier.Params["http"] != nil {
+				return errors.New("notifier http parameters must be a map")
+			}
+			webhook = make(map[interface{}]interface{})
+			notifier.Params["http"] = webhook
+		}
+		webhook["endpoint"] = value
+		return nil
+	}},
+}
+
+// applyEnvOverrides overrides the configuration with the environment variables
+// of envOverrides which are set.
+func applyEnvOverrides(config *Config, lookup lookupEnv) error {
+	for _, override := range envOverrides {
+		value, ok := lookup(override.name)
+		if !ok {
+			continue
+		}
+		if err := override.apply(config, value); err != nil {
+			return fmt.Errorf("could not load configuration: %s: %v", override.name, err)
+		}
+	}
+	return nil
+}
+
+// durationOverride returns the function overriding the duration of the
+// configuration returned by field, failing if the value isn't a duration.
+func durationOverride(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = d
+		return nil
+	}
+}
+
+// boolOverride returns the function overriding the boolean of the
+// configuration returned by field, failing if the value isn't a boolean.
+func boolOverride(field func(*Config) *bool) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = b
+		return nil
+	}
+}
+
+func databaseOptions(config *Config) map[string]interface{} {
+	if config.Database.Options == nil {
+		config.Database.Options = make(map[string]interface{})
+	}
+	return config.Database.Options
+}
+
+func apiConfig(config *Config) *api.Config {
+	if config.API == nil {
+		config.API = DefaultConfig().API
+	}
+	return config.API
+}
+
+func notifierConfig(config *Config) *notification.Config {
+	if config.Notifier == nil {
+		config.Notifier = DefaultConfig().Notifier
+	}
+	return config.Notifier
+}
+
+// envReference matches the ${ENV_VAR} references of the configuration values.
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolateEnv replaces the ${ENV_VAR} references of the string values of
+// the options maps, e.g. the database source or the notifier parameters, by
+// the values of the environment variables, which must be set. Unlike the path
+// of the configuration, bare $ENV_VAR references are kept so that secrets such
+// as passwords may contain dollar signs.
+func interpolateEnv(config *Config, lookup lookupEnv) error {
+	if err := interpolateMap(config.Database.Options, lookup); err != nil {
+		return err
+	}
+	if config.Updater != nil {
+		if err := interpolateMap(config.Updater.Params, lookup); err != nil {
+			return err
+		}
+	}
+	if config.Notifier != nil {
+		if err := interpolateMap(config.Notifier.Params, lookup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func interpolateMap(m map[string]interface{}, lookup lookupEnv) error {
+	for key, value := range m {
+		interpolated, err := interpolateValue(value, lookup)
+		if err != nil {
+			return err
+		}
+		m[key] = interpolated
+	}
+	return nil
+}
+
+func interpolateValue(value interface{}, lookup lookupEnv) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		var missing string
+		expanded := envReference.ReplaceAllStringFunc(v, func(reference string) string {
+			name := envReference.FindStringSubmatch(reference)[1]
+			value, ok := lookup(name)
+			if !ok && missing == "" {
+				missing = name
+			}
+			return value
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("could not load configuration: environment variable %s is not set", missing)
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		return v, interpolateMap(v, lookup)
+	case map[interface{}]interface{}:
+		for key, element := range v {
+			interpolated, err := interpolateValue(element, lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = interpolated
+		}
+		return v, nil
+	case []interface{}:
+		for i, element := range v {
+			interpolated, err := interpolateValue(element, lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = interpolated
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
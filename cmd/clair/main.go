@@ -15,13 +15,16 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"math/rand"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime/pprof"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -32,10 +35,11 @@ import (
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/vulnsrc"
 	"github.com/quay/clair/v3/pkg/formatter"
-	"github.com/quay/clair/v3/pkg/stopper"
+	"github.com/quay/clair/v3/pkg/httputil"
 	"github.com/quay/clair/v3/pkg/strutil"
 
-	// Register database driver.
+	// Register database drivers.
+	_ "github.com/quay/clair/v3/database/memory"
 	_ "github.com/quay/clair/v3/database/pgsql"
 
 	// Register extensions.
@@ -51,11 +55,18 @@ import (
 	_ "github.com/quay/clair/v3/ext/imagefmt/aci"
 	_ "github.com/quay/clair/v3/ext/imagefmt/docker"
 	_ "github.com/quay/clair/v3/ext/imgpostprocessor/redhatcpe"
+	_ "github.com/quay/clair/v3/ext/notification/email"
+	_ "github.com/quay/clair/v3/ext/notification/kafka"
+	_ "github.com/quay/clair/v3/ext/notification/log"
+	_ "github.com/quay/clair/v3/ext/notification/messagebus"
+	_ "github.com/quay/clair/v3/ext/notification/slack"
 	_ "github.com/quay/clair/v3/ext/notification/stomp"
 	_ "github.com/quay/clair/v3/ext/notification/webhook"
 	_ "github.com/quay/clair/v3/ext/vulnmdsrc/nvd"
 	_ "github.com/quay/clair/v3/ext/vulnsrc/alpine"
 	_ "github.com/quay/clair/v3/ext/vulnsrc/amzn"
+	_ "github.com/quay/clair/v3/ext/vulnsrc/csv"
+	_ "github.com/quay/clair/v3/ext/vulnsrc/cvrf"
 	_ "github.com/quay/clair/v3/ext/vulnsrc/debian"
 	_ "github.com/quay/clair/v3/ext/vulnsrc/oracle"
 	_ "github.com/quay/clair/v3/ext/vulnsrc/redhat"
@@ -75,10 +86,22 @@ var BinaryDependencies = []string{
 	"xz",
 }
 
-func waitForSignals(signals ...os.Signal) {
+// contextWithSignals returns a context cancelled once one of the signals is
+// received.
+func contextWithSignals(signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 	interrupts := make(chan os.Signal, 1)
 	signal.Notify(interrupts, signals...)
-	<-interrupts
+	go func() {
+		defer signal.Stop(interrupts)
+		select {
+		case <-interrupts:
+			log.Info("Received interruption, gracefully stopping ...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
 }
 
 func startCPUProfiling(path string) *os.File {
@@ -104,7 +127,15 @@ func stopCPUProfiling(f *os.File) {
 }
 
 func configClairVersion(config *Config) {
-	clair.EnabledUpdaters = strutil.Intersect(config.Updater.EnabledUpdaters, vulnsrc.ListUpdaters())
+	updaters, err := vulnsrc.ConfigureUpdaters(strutil.Intersect(config.Updater.EnabledUpdaters, vulnsrc.ListUpdaters()), config.Updater.Params)
+	if err != nil {
+		log.WithError(err).Fatal("invalid updater configuration")
+	}
+
+	clair.EnabledUpdaters = updaters
+	if err := vulnsrc.ValidateVersionFormats(clair.EnabledUpdaters); err != nil {
+		log.WithError(err).Fatal("invalid updater")
+	}
 
 	log.WithFields(log.Fields{
 		"Detectors": database.SerializeDetectors(clair.EnabledDetectors()),
@@ -112,10 +143,33 @@ func configClairVersion(config *Config) {
 	}).Info("enabled Clair extensions")
 }
 
-// Boot starts Clair instance with the provided config.
-func Boot(config *Config) {
+// Boot starts Clair instance with the provided config and stops it
+// gracefully once the context is done.
+func Boot(ctx context.Context, config *Config) {
 	rand.Seed(time.Now().UnixNano())
-	st := stopper.NewStopper()
+
+	if err := clair.ConfigureWorker(config.Worker); err != nil {
+		log.WithError(err).Fatal("failed to configure worker")
+	}
+
+	if err := httputil.Configure(config.HTTPClient); err != nil {
+		log.WithError(err).Fatal("failed to configure HTTP client")
+	}
+	httputil.SetContext(ctx)
+
+	if err := clair.ConfigureNotifier(config.Notifier); err != nil {
+		log.WithError(err).Fatal("failed to configure notifier")
+	}
+
+	if config.Updater != nil && config.Updater.ParseFailureLog != "" {
+		f, err := os.OpenFile(config.Updater.ParseFailureLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.WithError(err).Fatal("failed to open the parse failure log")
+		}
+		defer f.Close()
+		vulnsrc.SetParseFailureLog(f)
+		defer vulnsrc.SetParseFailureLog(nil)
+	}
 
 	// Open database
 	var db database.Datastore
@@ -138,24 +192,32 @@ func Boot(config *Config) {
 		clair.RegisterConfiguredDetectors(db)
 	}
 
+	var wg sync.WaitGroup
+	run := func(service func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service()
+		}()
+	}
+
 	// Start notifier
-	st.Begin()
-	go clair.RunNotifier(config.Notifier, db, st)
+	run(func() { clair.RunNotifier(ctx, config.Notifier, db) })
 
 	// Start API
-	go api.Run(config.API, db)
-
-	st.Begin()
-	go api.RunHealth(config.API, db, st)
+	updaterJobs := clair.NewUpdaterJobs(ctx, config.Updater, db)
+	run(func() { api.Run(ctx, config.API, db, updaterJobs) })
+	run(func() { api.RunHealth(ctx, config.API, db) })
 
 	// Start updater
-	st.Begin()
-	go clair.RunUpdater(config.Updater, db, st)
+	run(func() { clair.RunUpdater(ctx, config.Updater, db) })
+
+	// Start janitor
+	run(func() { clair.RunJanitor(ctx, config.Janitor, db) })
 
 	// Wait for interruption and shutdown gracefully.
-	waitForSignals(syscall.SIGINT, syscall.SIGTERM)
-	log.Info("Received interruption, gracefully stopping ...")
-	st.Stop()
+	<-ctx.Done()
+	wg.Wait()
 }
 
 // Initialize logging system
@@ -173,12 +235,34 @@ func configureLogger(flagLogLevel *string) {
 func main() {
 	// Parse command-line arguments
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-	flagConfigPath := flag.String("config", "/etc/clair/config.yaml", "Load configuration from the specified file.")
+	flagConfigPath := flag.String("config", "/etc/clair/config.yaml", "Load configuration from the specified file, or from the standard input if \"-\".")
 	flagCPUProfilePath := flag.String("cpu-profile", "", "Write a CPU profile to the specified file before exiting.")
 	flagLogLevel := flag.String("log-level", "info", "Define the logging level.")
+	flagStrictConfig := flag.Bool("strict-config", false, "Refuse to start unless the configuration passes \"config validate\", e.g. has no unknown keys.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [migrate <action> | rescan <args> | config validate [path]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	configureLogger(flagLogLevel)
+
+	if flag.Arg(0) == "config" {
+		os.Exit(runConfig(*flagConfigPath, flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
+	if flag.Arg(0) == "migrate" {
+		// Keep the standard output for the status of the migrations.
+		log.SetOutput(os.Stderr)
+
+		config, err := loadConfig(*flagConfigPath, *flagStrictConfig)
+		if err != nil {
+			log.WithError(err).Fatal("failed to load configuration")
+		}
+
+		os.Exit(runMigrate(config, flag.Args()[1:], os.Stdout, os.Stderr))
+	}
+
 	// Check for dependencies.
 	for _, bin := range BinaryDependencies {
 		_, err := exec.LookPath(bin)
@@ -187,11 +271,18 @@ func main() {
 		}
 	}
 
-	config, err := LoadConfig(*flagConfigPath)
+	config, err := loadConfig(*flagConfigPath, *flagStrictConfig)
 	if err != nil {
 		log.WithError(err).Fatal("failed to load configuration")
 	}
 
+	if flag.Arg(0) == "rescan" {
+		ctx, cancel := contextWithSignals(syscall.SIGINT, syscall.SIGTERM)
+		code := runRescan(ctx, config, flag.Args()[1:], os.Stderr)
+		cancel()
+		os.Exit(code)
+	}
+
 	// Enable CPU Profiling if specified
 	if *flagCPUProfilePath != "" {
 		defer stopCPUProfiling(startCPUProfiling(*flagCPUProfilePath))
@@ -200,5 +291,7 @@ func main() {
 	// configure updater and worker
 	configClairVersion(config)
 
-	Boot(config)
+	ctx, cancel := contextWithSignals(syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	Boot(ctx, config)
 }
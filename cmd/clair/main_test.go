@@ -0,0 +1,104 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3"
+	"github.com/quay/clair/v3/api"
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+type nopSender struct{}
+
+func (nopSender) Configure(*notification.Config) (bool, error) { return true, nil }
+
+func (nopSender) Send(notificationName string) error { return nil }
+
+func newFakeDatastore(database.RegistrableComponentConfig) (database.Datastore, error) {
+	lastUpdate := strconv.FormatInt(time.Now().Unix(), 10)
+	store := &database.MockDatastore{FctClose: func() {}, FctPing: func() bool { return true }}
+	store.FctBegin = func() (database.Session, error) {
+		session := &database.MockSession{
+			FctCommit:           func() error { return nil },
+			FctRollback:         func() error { return nil },
+			FctPersistDetectors: func([]database.Detector) error { return nil },
+			FctFindNewNotification: func(time.Time) (database.NotificationHook, bool, error) {
+				return database.NotificationHook{}, false, nil
+			},
+			FctFindKeyValue: func(key string) (string, bool, error) {
+				return lastUpdate, true, nil
+			},
+			FctPruneKeyValues: func() (int, error) { return 0, nil },
+			FctPruneLocks:     func() (int, error) { return 0, nil },
+		}
+		return session, nil
+	}
+	return store, nil
+}
+
+func init() {
+	database.Register("fake", newFakeDatastore)
+}
+
+func TestBootStopsWhenContextIsDone(t *testing.T) {
+	notification.RegisterSender("nop", nopSender{})
+	defer notification.UnregisterSender("nop")
+
+	config := DefaultConfig()
+	config.Database.Type = "fake"
+	config.Updater = &clair.UpdaterConfig{EnabledUpdaters: []string{"debian"}, Interval: time.Hour}
+	config.API = &api.Config{Addr: "127.0.0.1:0", HealthAddr: "127.0.0.1:0", Timeout: time.Second}
+
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		Boot(ctx, &config)
+	}()
+
+	// Let every service start and wait.
+	time.Sleep(500 * time.Millisecond)
+	select {
+	case <-stopped:
+		require.FailNow(t, "Boot returned before the context was done")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(15 * time.Second):
+		require.FailNow(t, "Boot did not return after the context was done")
+	}
+
+	// Every goroutine started by the services exits.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, runtime.NumGoroutine() <= goroutines, "%d goroutines left running, expected %d", runtime.NumGoroutine(), goroutines)
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql"
+	"github.com/quay/clair/v3/database/pgsql/migrations"
+)
+
+// Exit codes of the migrate subcommand.
+const (
+	migrateExitOK      = 0
+	migrateExitError   = 1
+	migrateExitUsage   = 2
+	migrateExitPending = 3
+)
+
+const migrateUsage = `usage: clair [flags] migrate <action>
+
+actions:
+  status          print the state of the migrations as JSON, exiting with 3 if
+                  some are pending
+  up [version]    apply the pending migrations, up to version if set
+  down [steps]    revert the latest migration, or the given number of them
+  force <version> record the migrations up to version as applied, without
+                  running any`
+
+// schemaMigrator manages the schema migrations of a database.
+type schemaMigrator interface {
+	Status() (migrations.Status, error)
+	Up(target int) error
+	Down(steps int) error
+	Force(version int) error
+	Close()
+}
+
+// openMigrator connects to the database of the configuration, without
+// migrating it.
+var openMigrator = func(config database.RegistrableComponentConfig) (schemaMigrator, error) {
+	if config.Type != "pgsql" {
+		return nil, fmt.Errorf("database type %q has no migrations", config.Type)
+	}
+
+	return pgsql.OpenMigrator(config)
+}
+
+// runMigrate runs the migrate subcommand with its arguments, and returns its
+// exit code.
+func runMigrate(config *Config, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return migrateExitUsage
+	}
+
+	action := args[0]
+	var argument int
+	if len(args) == 2 {
+		var err error
+		if argument, err = strconv.Atoi(args[1]); err != nil || argument < 0 {
+			fmt.Fprintf(stderr, "invalid argument %q\n%s\n", args[1], migrateUsage)
+			return migrateExitUsage
+		}
+	}
+
+	switch {
+	case action == "status" && len(args) == 1, action == "up", action == "down":
+	case action == "force" && len(args) == 2:
+	default:
+		fmt.Fprintln(stderr, migrateUsage)
+		return migrateExitUsage
+	}
+
+	migrator, err := openMigrator(config.Database)
+	if err != nil {
+		log.WithError(err).Error("failed to open database")
+		return migrateExitError
+	}
+	defer migrator.Close()
+
+	switch action {
+	case "up":
+		err = migrator.Up(argument)
+	case "down":
+		if len(args) == 1 {
+			argument = 1
+		}
+		err = migrator.Down(argument)
+	case "force":
+		err = migrator.Force(argument)
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("action", action).Error("failed to migrate database")
+		return migrateExitError
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		log.WithError(err).Error("failed to get the migrations status")
+		return migrateExitError
+	}
+
+	if action != "status" {
+		log.WithField("version", status.Version).Info("migrated database")
+		return migrateExitOK
+	}
+
+	if err := json.NewEncoder(stdout).Encode(status); err != nil {
+		log.WithError(err).Error("failed to print the migrations status")
+		return migrateExitError
+	}
+
+	if !status.UpToDate() {
+		return migrateExitPending
+	}
+
+	return migrateExitOK
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/migrations"
+)
+
+// fakeMigrator records the migrations applied, out of the migrations 1 to 3.
+type fakeMigrator struct {
+	version int
+	calls   []string
+	err     error
+}
+
+func (m *fakeMigrator) Status() (migrations.Status, error) {
+	status := migrations.Status{Version: m.version, Latest: 3, Applied: []int{}, Pending: []int{}, Unknown: []int{}}
+	for id := 1; id <= 3; id++ {
+		if id <= m.version {
+			status.Applied = append(status.Applied, id)
+		} else {
+			status.Pending = append(status.Pending, id)
+		}
+	}
+	return status, nil
+}
+
+func (m *fakeMigrator) Up(target int) error {
+	m.calls = append(m.calls, "up")
+	if target == 0 {
+		target = 3
+	}
+	m.version = target
+	return m.err
+}
+
+func (m *fakeMigrator) Down(steps int) error {
+	m.calls = append(m.calls, "down")
+	m.version -= steps
+	return m.err
+}
+
+func (m *fakeMigrator) Force(version int) error {
+	m.calls = append(m.calls, "force")
+	m.version = version
+	return m.err
+}
+
+func (m *fakeMigrator) Close() {}
+
+func withFakeMigrator(m *fakeMigrator) func() {
+	open := openMigrator
+	openMigrator = func(database.RegistrableComponentConfig) (schemaMigrator, error) { return m, nil }
+	return func() { openMigrator = open }
+}
+
+func TestMigrateStatus(t *testing.T) {
+	migrator := &fakeMigrator{version: 2}
+	defer withFakeMigrator(migrator)()
+
+	config := DefaultConfig()
+	var stdout bytes.Buffer
+
+	// Pending migrations are reported with a dedicated exit code.
+	assert.Equal(t, migrateExitPending, runMigrate(&config, []string{"status"}, &stdout, ioutil.Discard))
+
+	var status migrations.Status
+	if assert.Nil(t, json.Unmarshal(stdout.Bytes(), &status)) {
+		assert.Equal(t, 2, status.Version)
+		assert.Equal(t, []int{1, 2}, status.Applied)
+		assert.Equal(t, []int{3}, status.Pending)
+	}
+
+	migrator.version = 3
+	stdout.Reset()
+	assert.Equal(t, migrateExitOK, runMigrate(&config, []string{"status"}, &stdout, ioutil.Discard))
+	assert.Contains(t, stdout.String(), `"pending":[]`)
+	assert.Empty(t, migrator.calls)
+}
+
+func TestMigrateActions(t *testing.T) {
+	migrator := &fakeMigrator{}
+	defer withFakeMigrator(migrator)()
+
+	config := DefaultConfig()
+	for _, test := range []struct {
+		args    []string
+		code    int
+		version int
+	}{
+		{[]string{"up", "2"}, migrateExitOK, 2},
+		{[]string{"up"}, migrateExitOK, 3},
+		{[]string{"down"}, migrateExitOK, 2},
+		{[]string{"down", "2"}, migrateExitOK, 0},
+		{[]string{"force", "3"}, migrateExitOK, 3},
+		{[]string{"force"}, migrateExitUsage, 3},
+		{[]string{"status", "1"}, migrateExitUsage, 3},
+		{[]string{"up", "-1"}, migrateExitUsage, 3},
+		{[]string{"down", "one"}, migrateExitUsage, 3},
+		{[]string{"sideways"}, migrateExitUsage, 3},
+		{[]string{}, migrateExitUsage, 3},
+	} {
+		var stdout bytes.Buffer
+		assert.Equal(t, test.code, runMigrate(&config, test.args, &stdout, ioutil.Discard), "%v", test.args)
+		assert.Equal(t, test.version, migrator.version, "%v", test.args)
+		assert.Empty(t, stdout.String(), "%v", test.args)
+	}
+
+	assert.Equal(t, []string{"up", "up", "down", "down", "force"}, migrator.calls)
+
+	migrator.err = errors.New("migration failed")
+	assert.Equal(t, migrateExitError, runMigrate(&config, []string{"up"}, ioutil.Discard, ioutil.Discard))
+}
+
+func TestMigrateRequiresPgsql(t *testing.T) {
+	config := DefaultConfig()
+	config.Database.Type = "memory"
+	assert.Equal(t, migrateExitError, runMigrate(&config, []string{"status"}, ioutil.Discard, ioutil.Discard))
+}
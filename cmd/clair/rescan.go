@@ -0,0 +1,113 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3"
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/httputil"
+)
+
+// Exit codes of the rescan subcommand.
+const (
+	rescanExitOK    = 0
+	rescanExitError = 1
+	rescanExitUsage = 2
+)
+
+const rescanUsage = `usage: clair [flags] rescan [--concurrency n] (--all-outdated | <ancestry>...)
+
+Analyzes ancestries again from their stored layer sources with the enabled
+detectors.
+
+flags:
+  --all-outdated   rescan every ancestry whose detectors differ from the
+                   enabled ones, skipping those without stored layer sources
+  --concurrency n  number of ancestries rescanned at the same time`
+
+// runRescan runs the rescan subcommand with its arguments, and returns its
+// exit code.
+func runRescan(ctx context.Context, config *Config, args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("rescan", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	allOutdated := flags.Bool("all-outdated", false, "")
+	concurrency := flags.Int("concurrency", clair.DefaultRescanConcurrency, "")
+	// Either every outdated ancestry or the named ones are rescanned.
+	if err := flags.Parse(args); err != nil || *concurrency <= 0 || *allOutdated == (flags.NArg() != 0) {
+		fmt.Fprintln(stderr, rescanUsage)
+		return rescanExitUsage
+	}
+
+	if err := clair.ConfigureWorker(config.Worker); err != nil {
+		log.WithError(err).Error("failed to configure worker")
+		return rescanExitError
+	}
+
+	if err := httputil.Configure(config.HTTPClient); err != nil {
+		log.WithError(err).Error("failed to configure HTTP client")
+		return rescanExitError
+	}
+
+	store, err := database.Open(config.Database)
+	if err != nil {
+		log.WithError(err).Error("failed to open database")
+		return rescanExitError
+	}
+	defer store.Close()
+
+	return rescanAncestries(ctx, store, flags.Args(), *concurrency)
+}
+
+// rescanAncestries rescans the named ancestries one after the other, or every
+// outdated ancestry when no name is given, and returns the exit code.
+func rescanAncestries(ctx context.Context, store database.Datastore, names []string, concurrency int) int {
+	if ro, ok := store.(database.ReadOnly); ok && ro.ReadOnly() {
+		log.Error("cannot rescan ancestries with a read-only database")
+		return rescanExitError
+	}
+
+	clair.RegisterConfiguredDetectors(store)
+
+	if len(names) == 0 {
+		if err := clair.RescanOutdatedAncestries(ctx, store, concurrency); err != nil {
+			log.WithError(err).Error("failed to rescan outdated ancestries")
+			return rescanExitError
+		}
+
+		return rescanExitOK
+	}
+
+	code := rescanExitOK
+	for i, name := range names {
+		fields := log.Fields{"ancestry.Name": name, "done": i + 1, "total": len(names)}
+		if err := clair.RescanAncestry(ctx, store, name); err != nil {
+			log.WithError(err).WithFields(fields).Error("failed to rescan ancestry")
+			code = rescanExitError
+			continue
+		}
+
+		log.WithFields(fields).Info("rescanned ancestry")
+	}
+
+	return code
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRescanUsage(t *testing.T) {
+	config := DefaultConfig()
+	config.Database.Type = "memory"
+	for _, args := range [][]string{
+		{},
+		{"--all-outdated", "ancestry"},
+		{"--concurrency", "0", "--all-outdated"},
+		{"--concurrency", "many", "--all-outdated"},
+		{"--sideways"},
+	} {
+		var stderr bytes.Buffer
+		assert.Equal(t, rescanExitUsage, runRescan(context.Background(), &config, args, &stderr), "%v", args)
+		assert.Contains(t, stderr.String(), "usage: clair", "%v", args)
+	}
+}
+
+func TestRescan(t *testing.T) {
+	config := DefaultConfig()
+	config.Database.Type = "memory"
+
+	var stderr bytes.Buffer
+	assert.Equal(t, rescanExitOK, runRescan(context.Background(), &config, []string{"--all-outdated", "--concurrency", "4"}, &stderr))
+	assert.Equal(t, rescanExitError, runRescan(context.Background(), &config, []string{"unknown"}, &stderr))
+	assert.Empty(t, stderr.String())
+}
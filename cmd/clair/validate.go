@@ -0,0 +1,317 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+// Exit codes of the config subcommand.
+const (
+	configExitOK      = 0
+	configExitInvalid = 1
+	configExitUsage   = 2
+)
+
+const configUsage = `usage: clair [flags] config validate [path]
+
+Validates the configuration file, the one of -config unless a path is given,
+printing all of its problems, e.g. unknown keys, and exiting with 1 if any.`
+
+// notifierEndpoints are the keys of the URLs the notifiers send to, by
+// notifier.
+var notifierEndpoints = map[string]string{
+	"http":  "endpoint",
+	"slack": "webhookurl",
+}
+
+// senderParams are the keys of the parameters of the senders which aren't
+// their names.
+var senderParams = map[string]string{
+	"webhook": "http",
+}
+
+// registeredSenders are the senders registered by the imported packages,
+// before the disabled ones are unregistered when the notifier is configured.
+var registeredSenders = notification.Senders()
+
+// configErrors are the problems of a configuration.
+type configErrors []error
+
+func (errs configErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// runConfig runs the config subcommand with its arguments, and returns its
+// exit code.
+func runConfig(path string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || len(args) > 2 || args[0] != "validate" {
+		fmt.Fprintln(stderr, configUsage)
+		return configExitUsage
+	}
+	if len(args) == 2 {
+		path = args[1]
+	}
+
+	data, err := readConfig(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "could not read configuration: %v\n", err)
+		return configExitInvalid
+	}
+
+	problems := validateConfig(data, os.LookupEnv)
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, strings.TrimPrefix(problem.Error(), "could not load configuration: "))
+	}
+	if len(problems) > 0 {
+		return configExitInvalid
+	}
+
+	fmt.Fprintln(stdout, "configuration is valid")
+	return configExitOK
+}
+
+// loadConfig loads the configuration like LoadConfig. If strict, the file is
+// rejected with all of its problems unless validateConfig finds none.
+func loadConfig(path string, strict bool) (*Config, error) {
+	if !strict {
+		return LoadConfig(path)
+	}
+
+	var data []byte
+	if path != "" {
+		var err error
+		if data, err = readConfig(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if problems := validateConfig(data, os.LookupEnv); len(problems) > 0 {
+		return nil, problems
+	}
+
+	return parseConfig(data, os.LookupEnv)
+}
+
+// validateConfig returns all the problems of a YAML configuration, overridden
+// by the environment variables found by lookup, if any: the keys which aren't
+// known, including the names of the updaters and notifiers, the values which
+// can't be decoded, the problems ParseConfig rejects, and the values it
+// accepts although they can't work, e.g. negative durations or enabled
+// updaters which don't exist.
+func validateConfig(data []byte, lookup lookupEnv) configErrors {
+	var (
+		problems configErrors
+		cfgFile  File
+	)
+	cfgFile.Clair = DefaultConfig()
+
+	// The default database options are merged after the decoding, which would
+	// report the options of the file as duplicate keys otherwise.
+	defaultOptions := cfgFile.Clair.Database.Options
+	cfgFile.Clair.Database.Options = nil
+
+	// The decoding goes on after the type errors, which are all reported.
+	if err := yaml.UnmarshalStrict(data, &cfgFile); err != nil {
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return configErrors{err}
+		}
+		for _, message := range typeErr.Errors {
+			problems = append(problems, errors.New(message))
+		}
+	}
+	config := &cfgFile.Clair
+
+	if config.Database.Options == nil {
+		config.Database.Options = make(map[string]interface{})
+	}
+	for key, value := range defaultOptions {
+		if _, ok := config.Database.Options[key]; !ok {
+			config.Database.Options[key] = value
+		}
+	}
+
+	// The options maps of the updaters and notifiers are inlined, so that
+	// their unknown keys aren't reported by the decoding.
+	if config.Updater != nil {
+		updaters := make(map[string]struct{})
+		for _, name := range vulnsrc.ListUpdaters() {
+			updaters[name] = struct{}{}
+		}
+		for _, name := range config.Updater.EnabledUpdaters {
+			if _, ok := updaters[name]; !ok {
+				problems = append(problems, fmt.Errorf("updater %q is enabled but doesn't exist", name))
+			}
+		}
+		for _, key := range sortedKeys(config.Updater.Params) {
+			if _, ok := updaters[key]; !ok {
+				problems = append(problems, fmt.Errorf("field %s not found in updater", key))
+			}
+		}
+	}
+	if config.Notifier != nil {
+		senders := make(map[string]struct{})
+		for name := range registeredSenders {
+			if key, ok := senderParams[name]; ok {
+				name = key
+			}
+			senders[name] = struct{}{}
+		}
+		for _, key := range sortedKeys(config.Notifier.Params) {
+			if _, ok := senders[key]; !ok {
+				problems = append(problems, fmt.Errorf("field %s not found in notifier", key))
+			}
+		}
+	}
+
+	if lookup != nil {
+		if err := interpolateEnv(config, lookup); err != nil {
+			problems = append(problems, err)
+		}
+		if err := applyEnvOverrides(config, lookup); err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	problems = append(problems, checkConfig(config)...)
+	problems = append(problems, checkDurations(config)...)
+	problems = append(problems, checkNotifierEndpoints(config)...)
+	if err := checkPaginationKey(config.Database.Options); err != nil {
+		problems = append(problems, err)
+	}
+
+	return problems
+}
+
+// checkDurations returns the problems of the durations of the configuration,
+// which must be positive, or zero when it disables a feature or a timeout.
+func checkDurations(config *Config) (problems []error) {
+	check := func(name string, d time.Duration, zeroAllowed bool) {
+		if d < 0 || (d == 0 && !zeroAllowed) {
+			problems = append(problems, fmt.Errorf("%s must be positive, got %s", name, d))
+		}
+	}
+
+	if config.Updater != nil {
+		check("updater interval", config.Updater.Interval, true)
+	}
+	if config.Janitor != nil {
+		check("janitor interval", config.Janitor.Interval, true)
+	}
+	if config.HTTPClient != nil {
+		check("httpclient dial timeout", config.HTTPClient.DialTimeout, true)
+		check("httpclient response timeout", config.HTTPClient.ResponseTimeout, true)
+		check("httpclient max retry delay", config.HTTPClient.MaxRetryDelay, true)
+	}
+	if config.Notifier != nil {
+		check("notifier renotify interval", config.Notifier.RenotifyInterval, true)
+	}
+	if config.API != nil {
+		check("api timeout", config.API.Timeout, false)
+	}
+
+	return problems
+}
+
+// checkNotifierEndpoints returns the problems of the URLs of the notifiers,
+// which must be absolute HTTP URLs when set.
+func checkNotifierEndpoints(config *Config) (problems []error) {
+	if config.Notifier == nil {
+		return nil
+	}
+
+	for _, sender := range sortedKeys(config.Notifier.Params) {
+		key, ok := notifierEndpoints[sender]
+		if !ok {
+			continue
+		}
+		params, ok := config.Notifier.Params[sender].(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if params[key] == nil {
+			continue
+		}
+
+		endpoint, ok := params[key].(string)
+		if !ok {
+			problems = append(problems, fmt.Errorf("notifier %s %s must be a URL", sender, key))
+			continue
+		}
+		if endpoint == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("notifier %s %s: %v", sender, key, err))
+		} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, fmt.Errorf("notifier %s %s %q must be an absolute HTTP URL", sender, key, endpoint))
+		}
+	}
+
+	return problems
+}
+
+// checkPaginationKey returns the problem of the pagination key of the database
+// options, if any, without generating one.
+func checkPaginationKey(options map[string]interface{}) error {
+	// Keep the options unchanged.
+	copied := make(map[string]interface{}, len(options))
+	for key, value := range options {
+		copied[key] = value
+	}
+
+	if err := loadPaginationKeyDir(copied); err != nil {
+		return err
+	}
+	key, ok := copied["paginationkey"].(string)
+	if !ok && copied["paginationkey"] != nil {
+		return pagination.ErrInvalidKeyString
+	}
+	if key == "" {
+		return nil
+	}
+	if _, err := pagination.KeyFromString(key); err != nil {
+		return fmt.Errorf("invalid paginationkey: %v", err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
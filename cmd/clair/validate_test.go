@@ -0,0 +1,158 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+func TestValidateConfigSample(t *testing.T) {
+	data, err := ioutil.ReadFile("../../config.yaml.sample")
+	require.Nil(t, err)
+	assert.Empty(t, validateConfig(data, nil))
+	assert.Empty(t, validateConfig([]byte(testConfig), nil))
+}
+
+func TestValidateConfig(t *testing.T) {
+	key := pagination.Must(pagination.NewKey()).String()
+
+	for _, test := range []struct {
+		name   string
+		config string
+		// problems are parts of the expected problems, in order.
+		problems []string
+	}{
+		{"valid", "clair:\n  database:\n    type: memory\n    options:\n      paginationkey: " + key + "\n  updater:\n    enabledupdaters: [debian, oracle]\n  notifier:\n    http:\n      endpoint: https://example.com/notify\n", nil},
+		{"syntax", "clair:\n  database: [\n", []string{"did not find expected node content"}},
+		{"unknown top-level key", "clair:\n  updator:\n    interval: 1h\n", []string{"line 2: field updator not found"}},
+		{"unknown root key", "clairr:\n  updater:\n    interval: 1h\n", []string{"line 1: field clairr not found"}},
+		{"unknown nested key", "clair:\n  api:\n    adress: 0.0.0.0:6060\n  janitor:\n    intervall: 1h\n", []string{"line 3: field adress not found", "line 5: field intervall not found"}},
+		{"duplicate key", "clair:\n  api:\n    addr: 0.0.0.0:6060\n    addr: 0.0.0.0:7070\n", []string{"line 4: field addr already set"}},
+		{"invalid duration", "clair:\n  updater:\n    interval: soon\n", []string{"line 3: cannot unmarshal !!str `soon` into time.Duration"}},
+		{"unknown updater key", "clair:\n  updater:\n    intervall: 1h\n", []string{"field intervall not found in updater"}},
+		{"unknown enabled updater", "clair:\n  updater:\n    enabledupdaters: [debain, ubuntu, redhat]\n", []string{`updater "debain" is enabled but doesn't exist`}},
+		{"unknown notifier key", "clair:\n  notifier:\n    webhook:\n      endpoint: https://example.com\n    atempts: 3\n", []string{"field atempts not found in notifier", "field webhook not found in notifier"}},
+		{"relative webhook endpoint", "clair:\n  notifier:\n    http:\n      endpoint: example.com/notify\n", []string{`notifier http endpoint "example.com/notify" must be an absolute HTTP URL`}},
+		{"invalid webhook endpoint", "clair:\n  notifier:\n    http:\n      endpoint: \"http://exa mple.com\"\n", []string{"notifier http endpoint: parse"}},
+		{"slack webhook url", "clair:\n  notifier:\n    slack:\n      webhookurl: ftp://hooks.slack.com\n", []string{"notifier slack webhookurl"}},
+		{"non-string endpoint", "clair:\n  notifier:\n    http:\n      endpoint: [a, b]\n", []string{"notifier http endpoint must be a URL"}},
+		{"negative interval", "clair:\n  updater:\n    interval: -1h\n", []string{"updater interval must be positive"}},
+		{"negative durations", "clair:\n  janitor:\n    interval: -1m\n  httpclient:\n    dialtimeout: -1s\n  notifier:\n    renotifyinterval: -2h\n", []string{"janitor interval", "httpclient dial timeout", "notifier renotify interval"}},
+		{"zero api timeout", "clair:\n  api:\n    timeout: 0s\n", []string{"api timeout must be positive, got 0s"}},
+		{"invalid pagination key", "clair:\n  database:\n    options:\n      paginationkey: nope\n", []string{"invalid paginationkey"}},
+		{"non-string pagination key", "clair:\n  database:\n    options:\n      paginationkey: 42\n", []string{pagination.ErrInvalidKeyString.Error()}},
+		{"rejected by ParseConfig", "clair:\n  updater:\n    intervaljitter: 200\n  api:\n    maxrecvmsgsize: -1\n", []string{"interval jitter", "message sizes"}},
+		{"unset environment variable", "clair:\n  database:\n    options:\n      source: password=${CLAIR_TEST_UNSET}\n", []string{"CLAIR_TEST_UNSET is not set"}},
+		{"all problems", "clair:\n  updator:\n    interval: 1h\n  updater:\n    interval: -1h\n    enabledupdaters: [debain]\n  api:\n    timeout: 0s\n", []string{"field updator", "debain", "updater interval", "api timeout"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			problems := validateConfig([]byte(test.config), envMap(nil))
+			if assert.Len(t, problems, len(test.problems), "%v", problems) {
+				for i, problem := range problems {
+					assert.Contains(t, problem.Error(), test.problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfigEnv(t *testing.T) {
+	env := envMap(map[string]string{"CLAIR_API_TIMEOUT": "forever"})
+	problems := validateConfig([]byte("clair:\n  api:\n    adress: 0.0.0.0:6060\n"), env)
+	require.Len(t, problems, 2)
+	assert.Contains(t, problems[0].Error(), "field adress not found")
+	assert.Contains(t, problems[1].Error(), "CLAIR_API_TIMEOUT")
+}
+
+func TestRunConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "valid.yaml")
+	require.Nil(t, ioutil.WriteFile(valid, []byte(testConfig), 0600))
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.Nil(t, ioutil.WriteFile(invalid, []byte("clair:\n  updator:\n    interval: 1h\n  api:\n    timeout: 0s\n"), 0600))
+
+	for _, test := range []struct {
+		name   string
+		path   string
+		args   []string
+		code   int
+		stdout string
+	}{
+		{"no action", valid, nil, configExitUsage, ""},
+		{"unknown action", valid, []string{"check"}, configExitUsage, ""},
+		{"too many arguments", valid, []string{"validate", valid, invalid}, configExitUsage, ""},
+		{"valid", valid, []string{"validate"}, configExitOK, "configuration is valid\n"},
+		{"path argument", invalid, []string{"validate", valid}, configExitOK, "configuration is valid\n"},
+		{"invalid", valid, []string{"validate", invalid}, configExitInvalid, "line 2: field updator not found in type main.Config\napi timeout must be positive, got 0s\n"},
+		{"missing", filepath.Join(dir, "missing.yaml"), []string{"validate"}, configExitInvalid, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, test.code, runConfig(test.path, test.args, &stdout, &stderr))
+			assert.Equal(t, test.stdout, stdout.String())
+			if test.code == configExitUsage {
+				assert.True(t, strings.HasPrefix(stderr.String(), "usage:"))
+			}
+		})
+	}
+}
+
+func TestLoadConfigStrict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	require.Nil(t, ioutil.WriteFile(path, []byte(testConfig+"  updator:\n    interval: 1h\n"), 0600))
+
+	// Unknown keys are ignored unless the configuration is strict.
+	config, err := loadConfig(path, false)
+	require.Nil(t, err)
+	assert.Equal(t, "memory", config.Database.Type)
+
+	_, err = loadConfig(path, true)
+	require.IsType(t, configErrors{}, err)
+	assert.Len(t, err.(configErrors), 1)
+	assert.Contains(t, err.Error(), "field updator not found")
+
+	require.Nil(t, ioutil.WriteFile(path, []byte(testConfig), 0600))
+	config, err = loadConfig(path, true)
+	require.Nil(t, err)
+	assert.Equal(t, "memory", config.Database.Type)
+	assert.NotEmpty(t, config.Database.Options["paginationkey"])
+
+	// Without a file, the defaults are validated with the environment.
+	config, err = loadConfig("", true)
+	require.Nil(t, err)
+	assert.Equal(t, DefaultConfig().Database.Type, config.Database.Type)
+
+	defer setenv(t, map[string]string{"CLAIR_API_TIMEOUT": "-1s"})()
+	_, err = loadConfig("", true)
+	require.IsType(t, configErrors{}, err)
+	assert.Contains(t, err.Error(), "timeout")
+}
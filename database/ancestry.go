@@ -27,6 +27,27 @@ type Ancestry struct {
 	Layers []AncestryLayer `json:"layers"`
 }
 
+// AncestrySource locates the blobs of the layers of an ancestry, so that the
+// ancestry can be analyzed again.
+type AncestrySource struct {
+	// Name is the name of the ancestry.
+	Name string `json:"name"`
+	// Format is the format of the layer blobs.
+	Format string `json:"format"`
+	// Layers are ordered as the layers of the ancestry.
+	Layers []LayerSource `json:"layers"`
+}
+
+// LayerSource locates the blob of a layer.
+type LayerSource struct {
+	// Hash is the sha-256 tarsum on the layer's blob content.
+	Hash string `json:"hash"`
+	// Path is the URL or local path of the blob.
+	Path string `json:"path"`
+	// Headers are the HTTP headers sent to download the blob.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
 // Valid checks if the ancestry is compliant to spec.
 func (a *Ancestry) Valid() bool {
 	if a == nil {
@@ -54,8 +54,21 @@ type ReadOnly interface {
 type RegistrableComponentConfig struct {
 	Type    string
 	Options map[string]interface{}
+
+	// Migrations is how the schema of the database is migrated, either
+	// AutoMigrations, the default, or ManualMigrations.
+	Migrations string
 }
 
+const (
+	// AutoMigrations migrates the schema of the database when it is opened.
+	AutoMigrations = "auto"
+
+	// ManualMigrations refuses to open a database whose schema is behind,
+	// leaving its migration to the operator.
+	ManualMigrations = "manual"
+)
+
 var drivers = make(map[string]Driver)
 
 // Driver is a function that opens a Datastore specified by its database driver
@@ -112,6 +125,23 @@ type Session interface {
 	// namespaced features. If the ancestry is not found, return false.
 	FindAncestry(name string) (ancestry Ancestry, found bool, err error)
 
+	// DeleteAncestry removes an ancestry, its source and its associations
+	// with its layers, keeping the layers and features it shares with other
+	// ancestries. If the ancestry is not found, return false.
+	DeleteAncestry(name string) (found bool, err error)
+
+	// UpsertAncestrySource inserts or replaces the source of an ancestry.
+	UpsertAncestrySource(AncestrySource) error
+
+	// FindAncestrySource retrieves the source of an ancestry. If the source
+	// is not found, return false.
+	FindAncestrySource(name string) (source AncestrySource, found bool, err error)
+
+	// FindOutdatedAncestries retrieves the names of the ancestries which are
+	// not scanned by exactly the given detectors, assuming that the detectors
+	// are in the database.
+	FindOutdatedAncestries(detectors []Detector) ([]string, error)
+
 	// PersistDetector inserts a slice of detectors if not in the database.
 	PersistDetectors(detectors []Detector) error
 
@@ -136,6 +166,15 @@ type Session interface {
 	// PersistNamespaces inserts a set of namespaces if not in the database.
 	PersistNamespaces([]Namespace) error
 
+	// FindNamespaces retrieves every namespace, ordered by name and version
+	// format, along with the number of their vulnerabilities which aren't
+	// deleted.
+	FindNamespaces() ([]NamespaceWithVulnerabilityCount, error)
+
+	// FindStatistics computes the aggregate numbers of the vulnerabilities,
+	// ancestries, layers and features stored.
+	FindStatistics() (Statistics, error)
+
 	// PersistLayer appends a layer's content in the database.
 	//
 	// If any feature, namespace, or detector is not in the database, it returns not found error.
@@ -155,8 +194,29 @@ type Session interface {
 	// features.
 	FindVulnerabilities([]VulnerabilityID) ([]NullableVulnerability, error)
 
+	// FindDeletedVulnerabilities retrieves the most recently deleted version
+	// of a set of Vulnerabilities with affected features. A vulnerability
+	// which was never deleted is not valid.
+	FindDeletedVulnerabilities([]VulnerabilityID) ([]NullableVulnerability, error)
+
+	// FindPagedVulnerabilities retrieves a page of the vulnerabilities of a
+	// namespace, with their affected features. The page is specified by the
+	// pagination token, which is empty for the first page.
+	//
+	// A vulnerability updated while it is being paginated is stored again,
+	// after the current page, and may be retrieved twice.
+	FindPagedVulnerabilities(namespace string, limit int, page pagination.Token) (PagedVulnerabilities, error)
+
+	// FindAffectedAncestries retrieves a page of the ancestries affected by a
+	// vulnerability, with their affected features. The page is specified by
+	// the pagination token, which is empty for the first page.
+	FindAffectedAncestries(vulnerability VulnerabilityID, limit int, page pagination.Token) (ancestries PagedAffectedAncestries, found bool, err error)
+
 	// DeleteVulnerability removes a set of Vulnerabilities assuming that the
 	// requested vulnerabilities are in the database.
+	//
+	// Deleted vulnerabilities are kept, along with the features they
+	// affected, as the old side of the notifications of their changes.
 	DeleteVulnerabilities([]VulnerabilityID) error
 
 	// InsertVulnerabilityNotifications inserts a set of unique vulnerability
@@ -175,23 +235,83 @@ type Session interface {
 	// considered first page when it's empty.
 	FindVulnerabilityNotification(name string, limit int, oldVulnerabilityPage pagination.Token, newVulnerabilityPage pagination.Token) (noti VulnerabilityNotificationWithVulnerable, found bool, err error)
 
+	// FindNotifications retrieves a page of the notifications in a state, or
+	// of every notification when the state is empty. The page is specified
+	// by the pagination token, which is empty for the first page.
+	FindNotifications(state NotificationState, limit int, page pagination.Token) (PagedNotifications, error)
+
 	// MarkNotificationAsRead marks a Notification as notified now, assuming
 	// the requested notification is in the database.
 	MarkNotificationAsRead(name string) error
 
+	// MarkNotificationAsFiltered marks a Notification as notified now and
+	// filtered, so that it is never sent, assuming the requested notification
+	// is in the database.
+	MarkNotificationAsFiltered(name string) error
+
 	// DeleteNotification removes a Notification in the database.
 	DeleteNotification(name string) error
 
-	// UpdateKeyValue stores or updates a simple key/value pair.
+	// InsertDeadLetterNotification stores a notification which failed to be
+	// sent, replacing any previous dead letter of the same notification.
+	InsertDeadLetterNotification(DeadLetterNotification) error
+
+	// FindDeadLetterNotifications retrieves every dead-lettered notification.
+	FindDeadLetterNotifications() ([]DeadLetterNotification, error)
+
+	// RequeueDeadLetterNotification removes a dead-lettered notification and
+	// makes the notification available to be sent again. If the dead letter
+	// is not found, return false.
+	RequeueDeadLetterNotification(name string) (found bool, err error)
+
+	// UpdateKeyValue stores or updates a simple key/value pair which never
+	// expires.
 	UpdateKeyValue(key, value string) error
 
-	// FindKeyValue retrieves a value from the given key.
+	// UpdateKeyValueWithTTL stores or updates a simple key/value pair which
+	// expires once the ttl elapsed.
+	UpdateKeyValueWithTTL(key, value string, ttl time.Duration) error
+
+	// FindKeyValue retrieves a value from the given key. Expired key/value
+	// pairs are not found.
 	FindKeyValue(key string) (value string, found bool, err error)
 
+	// PruneKeyValues removes every expired key/value pair and returns how
+	// many were removed.
+	PruneKeyValues() (pruned int, err error)
+
+	// InsertUpdaterRuns stores the summaries of updater runs.
+	InsertUpdaterRuns(runs []UpdaterRun) error
+
+	// FindUpdaterRuns retrieves the summaries of the latest runs of an
+	// updater, at most limit of them, the most recent first.
+	FindUpdaterRuns(updater string, limit int) ([]UpdaterRun, error)
+
+	// InsertSuppressionRule stores a suppression rule, created now, and
+	// returns its ID.
+	InsertSuppressionRule(rule SuppressionRule) (id int64, err error)
+
+	// FindSuppressionRule retrieves a suppression rule, expired or not. If
+	// the rule is not found, return false.
+	FindSuppressionRule(id int64) (rule SuppressionRule, found bool, err error)
+
+	// FindSuppressionRules retrieves every suppression rule, expired or not,
+	// ordered by ID.
+	FindSuppressionRules() (SuppressionRules, error)
+
+	// UpdateSuppressionRule replaces the suppression rule of the same ID,
+	// keeping its creation time. If the rule is not found, return false.
+	UpdateSuppressionRule(rule SuppressionRule) (found bool, err error)
+
+	// DeleteSuppressionRule removes a suppression rule. If the rule is not
+	// found, return false.
+	DeleteSuppressionRule(id int64) (found bool, err error)
+
 	// AcquireLock acquires a brand new lock in the database with a given name
 	// for the given duration.
 	//
-	// A lock can only have one owner.
+	// A lock can only have one owner. An expired lock is taken over
+	// atomically, as if it was released.
 	// This method should NOT block until a lock is acquired.
 	AcquireLock(name, owner string, duration time.Duration) (acquired bool, expiration time.Time, err error)
 
@@ -204,6 +324,10 @@ type Session interface {
 
 	// ReleaseLock releases an existing lock.
 	ReleaseLock(name, owner string) error
+
+	// PruneLocks removes every expired lock and returns how many were
+	// removed.
+	PruneLocks() (pruned int, err error)
 }
 
 // Datastore represents a persistent data store
@@ -211,6 +335,11 @@ type Datastore interface {
 	// Begin starts a session to change.
 	Begin() (Session, error)
 
+	// BeginReadOnly starts a session which only reads. It is a hint allowing
+	// the datastore to serve the session from a read replica, possibly
+	// lagging behind: sessions which also write must be started with Begin.
+	BeginReadOnly() (Session, error)
+
 	// Ping returns the health status of the database.
 	Ping() bool
 
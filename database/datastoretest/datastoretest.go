@@ -0,0 +1,784 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package datastoretest implements the tests every implementation of
+// database.Datastore must pass, whatever its backend.
+package datastoretest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+var (
+	testNamespace   = database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	testNSDetector  = database.NewNamespaceDetector("os-release", "1.0")
+	testPkgDetector = database.NewFeatureDetector("dpkg", "1.0")
+	testFeature     = database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+	testNSFeature   = database.NamespacedFeature{Feature: testFeature, Namespace: testNamespace}
+
+	testVulnerability = database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      "CVE-2019-0001",
+			Namespace: testNamespace,
+			Severity:  database.HighSeverity,
+		},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.BinaryPackage,
+			Namespace:       testNamespace,
+			FeatureName:     "openssl",
+			AffectedVersion: "2.0",
+			FixedInVersion:  "2.0",
+		}},
+	}
+)
+
+// sessionTests are the tests of RunSessionTests, each given an empty
+// datastore.
+var sessionTests = []struct {
+	name string
+	run  func(*testing.T, database.Datastore)
+}{
+	{"SessionRollback", testSessionRollback},
+	{"PersistLayer", testPersistLayer},
+	{"DeleteAncestry", testDeleteAncestry},
+	{"AncestrySource", testAncestrySource},
+	{"FindOutdatedAncestries", testFindOutdatedAncestries},
+	{"AffectedNamespacedFeatures", testAffectedNamespacedFeatures},
+	{"VulnerabilityNotification", testVulnerabilityNotification},
+	{"MarkNotificationAsFiltered", testMarkNotificationAsFiltered},
+	{"FindNotifications", testFindNotifications},
+	{"FindPagedVulnerabilities", testFindPagedVulnerabilities},
+	{"FindNamespaces", testFindNamespaces},
+	{"FindStatistics", testFindStatistics},
+	{"FindAffectedAncestries", testFindAffectedAncestries},
+	{"Lock", testLock},
+	{"KeyValueExpiration", testKeyValueExpiration},
+	{"PruneLocks", testPruneLocks},
+	{"UpdaterRuns", testUpdaterRuns},
+	{"SuppressionRules", testSuppressionRules},
+}
+
+// RunSessionTests runs the tests of the sessions of a backend, each on a new
+// empty datastore returned by open, which is closed once the test is done.
+func RunSessionTests(t *testing.T, open func(t *testing.T) database.Datastore) {
+	for _, test := range sessionTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			store := open(t)
+			defer store.Close()
+
+			test.run(t, store)
+		})
+	}
+}
+
+// PersistAncestry stores the entities of an ancestry made of a single layer
+// featuring openssl 1.0 in debian:9.
+func PersistAncestry(t *testing.T, tx database.Session, name, hash string) {
+	require.Nil(t, tx.PersistDetectors([]database.Detector{testNSDetector, testPkgDetector}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{testNamespace}))
+	require.Nil(t, tx.PersistFeatures([]database.Feature{testFeature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{testNSFeature}))
+	require.Nil(t, tx.PersistLayer(hash,
+		[]database.LayerFeature{{Feature: testFeature, By: testPkgDetector}},
+		[]database.LayerNamespace{{Namespace: testNamespace, By: testNSDetector}},
+		[]database.Detector{testNSDetector, testPkgDetector},
+	))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name: name,
+		By:   []database.Detector{testNSDetector, testPkgDetector},
+		Layers: []database.AncestryLayer{{
+			Hash: hash,
+			Features: []database.AncestryFeature{{
+				NamespacedFeature: testNSFeature,
+				FeatureBy:         testPkgDetector,
+				NamespaceBy:       testNSDetector,
+			}},
+		}},
+	}))
+}
+
+func testSessionRollback(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.UpdateKeyValue("key", "value"))
+	PersistAncestry(t, tx, "ancestry", "layer")
+	require.Nil(t, tx.Rollback())
+
+	// Terminated sessions can't be used anymore, nor committed.
+	assert.Error(t, tx.UpdateKeyValue("key", "value"))
+	assert.Error(t, tx.Commit())
+
+	tx, err = store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	_, ok, err := tx.FindKeyValue("key")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = tx.FindLayer("layer")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = tx.FindAncestry("ancestry")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func testPersistLayer(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry", "layer")
+
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.PersistLayer("", nil, nil, nil))
+	assert.Equal(t, database.ErrInvalidParameters, tx.PersistLayer("layer",
+		[]database.LayerFeature{{Feature: testFeature, By: testPkgDetector}}, nil,
+		[]database.Detector{testNSDetector},
+	))
+	assert.Equal(t, database.ErrMissingEntities, tx.PersistLayer("layer", nil, nil,
+		[]database.Detector{database.NewFeatureDetector("rpm", "1.0")},
+	))
+
+	// Persisting the same content again doesn't duplicate it.
+	require.Nil(t, tx.PersistLayer("layer",
+		[]database.LayerFeature{{Feature: testFeature, By: testPkgDetector}}, nil,
+		[]database.Detector{testPkgDetector},
+	))
+
+	layer, ok, err := tx.FindLayer("layer")
+	require.Nil(t, err)
+	require.True(t, ok)
+	database.AssertLayerEqual(t, &database.Layer{
+		Hash:       "layer",
+		By:         []database.Detector{testNSDetector, testPkgDetector},
+		Features:   []database.LayerFeature{{Feature: testFeature, By: testPkgDetector}},
+		Namespaces: []database.LayerNamespace{{Namespace: testNamespace, By: testNSDetector}},
+	}, &layer)
+
+	ancestry, ok, err := tx.FindAncestry("ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "layer", ancestry.Layers[0].Hash)
+	assert.Equal(t, testNSFeature, ancestry.Layers[0].Features[0].NamespacedFeature)
+}
+
+func testDeleteAncestry(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry", "layer")
+	PersistAncestry(t, tx, "other", "layer")
+
+	ok, err := tx.DeleteAncestry("ancestry")
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	ok, err = tx.DeleteAncestry("ancestry")
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = tx.FindAncestry("ancestry")
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	// The shared layer is kept for the other ancestry.
+	_, ok, err = tx.FindLayer("layer")
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = tx.FindAncestry("other")
+	require.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func testAncestrySource(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry", "layer")
+
+	headers := map[string]string{"Authorization": "Bearer token"}
+	source := database.AncestrySource{
+		Name:   "ancestry",
+		Format: "docker",
+		Layers: []database.LayerSource{{Hash: "layer", Path: "https://example.com/layer", Headers: headers}},
+	}
+	require.Nil(t, tx.UpsertAncestrySource(source))
+	assert.NotNil(t, tx.UpsertAncestrySource(database.AncestrySource{}))
+
+	// The stored source doesn't share the headers.
+	headers["Authorization"] = "changed"
+	found, ok, err := tx.FindAncestrySource("ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Bearer token", found.Layers[0].Headers["Authorization"])
+	assert.Equal(t, "docker", found.Format)
+
+	ok, err = tx.DeleteAncestry("ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	_, ok, err = tx.FindAncestrySource("ancestry")
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func testFindOutdatedAncestries(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry", "layer")
+	PersistAncestry(t, tx, "other", "layer")
+
+	names, err := tx.FindOutdatedAncestries([]database.Detector{testPkgDetector, testNSDetector})
+	require.Nil(t, err)
+	assert.Empty(t, names)
+
+	// A bumped detector version is a different detector.
+	names, err = tx.FindOutdatedAncestries([]database.Detector{testNSDetector, database.NewFeatureDetector("dpkg", "2.0")})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"ancestry", "other"}, names)
+
+	names, err = tx.FindOutdatedAncestries([]database.Detector{testNSDetector})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"ancestry", "other"}, names)
+}
+
+func testAffectedNamespacedFeatures(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry", "layer")
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+
+	vulns, err := tx.FindVulnerabilities([]database.VulnerabilityID{
+		{Name: "CVE-2019-0001", Namespace: "debian:9"},
+		{Name: "CVE-2019-0002", Namespace: "debian:9"},
+	})
+	require.Nil(t, err)
+	require.Len(t, vulns, 2)
+	assert.True(t, vulns[0].Valid)
+	assert.Equal(t, testVulnerability.Affected, vulns[0].Affected)
+	assert.False(t, vulns[1].Valid)
+
+	// Inserting the vulnerability caches the features it affects.
+	features, err := tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{testNSFeature})
+	require.Nil(t, err)
+	require.Len(t, features, 1)
+	assert.True(t, features[0].Valid)
+	require.Len(t, features[0].AffectedBy, 1)
+	assert.Equal(t, "CVE-2019-0001", features[0].AffectedBy[0].Name)
+	assert.Equal(t, "2.0", features[0].AffectedBy[0].FixedInVersion)
+
+	// Deleting the vulnerability invalidates the cache.
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0001", Namespace: "debian:9"}}))
+	assert.Error(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0001", Namespace: "debian:9"}}))
+
+	features, err = tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{testNSFeature})
+	require.Nil(t, err)
+	assert.Empty(t, features[0].AffectedBy)
+
+	// Features stored after the vulnerability are cached on demand.
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+	newer := testFeature
+	newer.Version = "1.5"
+	newerNSFeature := database.NamespacedFeature{Feature: newer, Namespace: testNamespace}
+	require.Nil(t, tx.PersistFeatures([]database.Feature{newer}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{newerNSFeature}))
+	require.Nil(t, tx.CacheAffectedNamespacedFeatures([]database.NamespacedFeature{newerNSFeature}))
+
+	features, err = tx.FindAffectedNamespacedFeatures([]database.NamespacedFeature{newerNSFeature})
+	require.Nil(t, err)
+	assert.Len(t, features[0].AffectedBy, 1)
+}
+
+func testVulnerabilityNotification(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	for _, name := range []string{"ancestry-1", "ancestry-2", "ancestry-3"} {
+		PersistAncestry(t, tx, name, "layer")
+	}
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+	created := time.Now()
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{{
+		NotificationHook: database.NotificationHook{Name: "notification", Created: created},
+		New:              &testVulnerability.Vulnerability,
+	}}))
+
+	hook, ok, err := tx.FindNewNotification(time.Now())
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "notification", hook.Name)
+
+	// Page through the affected ancestries.
+	affected := map[string]struct{}{}
+	token := pagination.FirstPageToken
+	for pages := 0; ; pages++ {
+		require.True(t, pages < 2, "too many pages")
+
+		noti, ok, err := tx.FindVulnerabilityNotification("notification", 2, pagination.FirstPageToken, token)
+		require.Nil(t, err)
+		require.True(t, ok)
+		require.Nil(t, noti.Old)
+		require.NotNil(t, noti.New)
+		assert.Equal(t, "CVE-2019-0001", noti.New.Name)
+
+		for _, name := range noti.New.Affected {
+			affected[name] = struct{}{}
+		}
+
+		if noti.New.End {
+			break
+		}
+		token = noti.New.Next
+	}
+	assert.Len(t, affected, 3)
+
+	_, _, err = tx.FindVulnerabilityNotification("notification", 2, pagination.FirstPageToken, pagination.Token("invalid"))
+	assert.Error(t, err)
+
+	require.Nil(t, tx.InsertDeadLetterNotification(database.DeadLetterNotification{Name: "notification", Created: created}))
+	_, ok, err = tx.FindNewNotification(time.Now())
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	found, err := tx.RequeueDeadLetterNotification("notification")
+	require.Nil(t, err)
+	assert.True(t, found)
+
+	require.Nil(t, tx.DeleteNotification("notification"))
+	assert.Equal(t, commonerr.ErrNotFound, tx.DeleteNotification("notification"))
+
+	_, ok, err = tx.FindNewNotification(time.Now())
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func testMarkNotificationAsFiltered(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{testNamespace}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{{
+		NotificationHook: database.NotificationHook{Name: "notification", Created: time.Now()},
+		New:              &testVulnerability.Vulnerability,
+	}}))
+
+	require.Nil(t, tx.MarkNotificationAsFiltered("notification"))
+	assert.Error(t, tx.MarkNotificationAsFiltered("unknown"))
+
+	// Filtered notifications are delivered, and never renotified.
+	notiPage, err := tx.FindNotifications(database.DeliveredNotification, 1, pagination.FirstPageToken)
+	require.Nil(t, err)
+	require.Len(t, notiPage.Notifications, 1)
+	assert.True(t, notiPage.Notifications[0].Filtered)
+	assert.False(t, notiPage.Notifications[0].Notified.IsZero())
+
+	_, ok, err := tx.FindNewNotification(time.Now().Add(time.Hour))
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func testFindNotifications(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{testNamespace}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+	var notifications []database.VulnerabilityNotification
+	for _, name := range []string{"notification-1", "notification-2", "notification-3"} {
+		notifications = append(notifications, database.VulnerabilityNotification{
+			NotificationHook: database.NotificationHook{Name: name, Created: time.Now()},
+			New:              &testVulnerability.Vulnerability,
+		})
+	}
+	require.Nil(t, tx.InsertVulnerabilityNotifications(notifications))
+	require.Nil(t, tx.MarkNotificationAsRead("notification-2"))
+	require.Nil(t, tx.DeleteNotification("notification-3"))
+
+	// Page through the notifications in every state.
+	names := func(state database.NotificationState) []string {
+		var names []string
+		token := pagination.FirstPageToken
+		for pages := 0; ; pages++ {
+			require.True(t, pages < 3, "too many pages")
+
+			notiPage, err := tx.FindNotifications(state, 1, token)
+			require.Nil(t, err)
+			for _, noti := range notiPage.Notifications {
+				assert.Equal(t, state, noti.State())
+				assert.Equal(t, database.VulnerabilityID{Name: "CVE-2019-0001", Namespace: "debian:9"}, noti.Vulnerability)
+				names = append(names, noti.Name)
+			}
+
+			if notiPage.End {
+				break
+			}
+			token = notiPage.Next
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"notification-1"}, names(database.PendingNotification))
+	assert.Equal(t, []string{"notification-2"}, names(database.DeliveredNotification))
+	assert.Equal(t, []string{"notification-3"}, names(database.ExpiredNotification))
+
+	_, err = tx.FindNotifications("unknown", 1, pagination.FirstPageToken)
+	assert.Error(t, err)
+	_, err = tx.FindNotifications(database.PendingNotification, 1, pagination.Token("invalid"))
+	assert.Error(t, err)
+}
+
+func testFindPagedVulnerabilities(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	otherNamespace := database.Namespace{Name: "debian:10", VersionFormat: dpkg.ParserName}
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{testNamespace, otherNamespace}))
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, name := range []string{"CVE-2019-0001", "CVE-2019-0002", "CVE-2019-0003"} {
+		v := testVulnerability
+		v.Name = name
+		vulnerabilities = append(vulnerabilities, v)
+	}
+	other := testVulnerability
+	other.Namespace = otherNamespace
+	require.Nil(t, tx.InsertVulnerabilities(append(vulnerabilities, other)))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0002", Namespace: "debian:9"}}))
+
+	// Page through the live vulnerabilities of the namespace.
+	var names []string
+	token := pagination.FirstPageToken
+	for pages := 0; ; pages++ {
+		require.True(t, pages < 2, "too many pages")
+
+		page, err := tx.FindPagedVulnerabilities("debian:9", 1, token)
+		require.Nil(t, err)
+		require.Len(t, page.Vulnerabilities, 1)
+		assert.Equal(t, testVulnerability.Affected, page.Vulnerabilities[0].Affected)
+
+		names = append(names, page.Vulnerabilities[0].Name)
+		if page.End {
+			break
+		}
+		token = page.Next
+	}
+	assert.Equal(t, []string{"CVE-2019-0001", "CVE-2019-0003"}, names)
+
+	page, err := tx.FindPagedVulnerabilities("debian:8", 1, pagination.FirstPageToken)
+	require.Nil(t, err)
+	assert.True(t, page.End)
+	assert.Empty(t, page.Vulnerabilities)
+
+	_, err = tx.FindPagedVulnerabilities("debian:9", 1, pagination.Token("invalid"))
+	assert.Error(t, err)
+}
+
+func testFindNamespaces(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	otherNamespace := database.Namespace{Name: "debian:10", VersionFormat: dpkg.ParserName}
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{testNamespace, otherNamespace}))
+
+	deleted := testVulnerability
+	deleted.Name = "CVE-2019-0002"
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability, deleted}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0002", Namespace: "debian:9"}}))
+
+	namespaces, err := tx.FindNamespaces()
+	require.Nil(t, err)
+	assert.Equal(t, []database.NamespaceWithVulnerabilityCount{
+		{Namespace: otherNamespace},
+		{Namespace: testNamespace, VulnerabilityCount: 1},
+	}, namespaces)
+}
+
+func testFindStatistics(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	PersistAncestry(t, tx, "ancestry-1", "layer-1")
+	PersistAncestry(t, tx, "ancestry-2", "layer-2")
+
+	otherNamespace := database.Namespace{Name: "debian:10", VersionFormat: dpkg.ParserName}
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{otherNamespace}))
+
+	vulnerability := func(name string, namespace database.Namespace, severity database.Severity) database.VulnerabilityWithAffected {
+		v := testVulnerability
+		v.Name, v.Namespace, v.Severity = name, namespace, severity
+		return v
+	}
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{
+		vulnerability("CVE-2019-0001", testNamespace, database.HighSeverity),
+		vulnerability("CVE-2019-0002", testNamespace, database.LowSeverity),
+		vulnerability("CVE-2019-0003", testNamespace, database.HighSeverity),
+		vulnerability("CVE-2019-0004", testNamespace, database.CriticalSeverity),
+		vulnerability("CVE-2019-0001", otherNamespace, database.MediumSeverity),
+	}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0004", Namespace: "debian:9"}}))
+
+	stats, err := tx.FindStatistics()
+	require.Nil(t, err)
+	assert.Equal(t, database.Statistics{
+		Vulnerabilities: []database.VulnerabilityCount{
+			{Namespace: otherNamespace, Severity: database.MediumSeverity, Count: 1},
+			{Namespace: testNamespace, Severity: database.LowSeverity, Count: 1},
+			{Namespace: testNamespace, Severity: database.HighSeverity, Count: 2},
+		},
+		Ancestries: 2,
+		Layers:     2,
+		Features:   1,
+	}, stats)
+}
+
+func testFindAffectedAncestries(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	for _, name := range []string{"ancestry-1", "ancestry-2", "ancestry-3"} {
+		PersistAncestry(t, tx, name, "layer")
+	}
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{testVulnerability}))
+
+	vulnerability := database.VulnerabilityID{Name: "CVE-2019-0001", Namespace: "debian:9"}
+	var names []string
+	token := pagination.FirstPageToken
+	for pages := 0; ; pages++ {
+		require.True(t, pages < 2, "too many pages")
+
+		page, found, err := tx.FindAffectedAncestries(vulnerability, 2, token)
+		require.Nil(t, err)
+		require.True(t, found)
+		for _, ancestry := range page.Ancestries {
+			assert.Equal(t, []database.NamespacedFeature{testNSFeature}, ancestry.Features)
+			names = append(names, ancestry.Name)
+		}
+
+		if page.End {
+			break
+		}
+		token = page.Next
+	}
+	assert.Equal(t, []string{"ancestry-1", "ancestry-2", "ancestry-3"}, names)
+
+	_, found, err := tx.FindAffectedAncestries(database.VulnerabilityID{Name: "CVE-2019-0002", Namespace: "debian:9"}, 2, pagination.FirstPageToken)
+	require.Nil(t, err)
+	assert.False(t, found)
+
+	_, _, err = tx.FindAffectedAncestries(vulnerability, 2, pagination.Token("invalid"))
+	assert.Error(t, err)
+}
+
+func testLock(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	acquired, _, err := tx.AcquireLock("lock", "owner-1", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, acquired)
+
+	acquired, _, err = tx.AcquireLock("lock", "owner-2", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, acquired)
+
+	extended, _, err := tx.ExtendLock("lock", "owner-2", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, extended)
+
+	// Expired locks can be acquired by anyone.
+	_, _, err = tx.ExtendLock("lock", "owner-1", -time.Minute)
+	require.Nil(t, err)
+	acquired, _, err = tx.AcquireLock("lock", "owner-2", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, acquired)
+
+	require.Nil(t, tx.ReleaseLock("lock", "owner-1"))
+	acquired, _, err = tx.AcquireLock("lock", "owner-1", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, acquired)
+
+	require.Nil(t, tx.ReleaseLock("lock", "owner-2"))
+	acquired, _, err = tx.AcquireLock("lock", "owner-1", time.Minute)
+	require.Nil(t, err)
+	assert.True(t, acquired)
+}
+
+func testKeyValueExpiration(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	assert.Error(t, tx.UpdateKeyValueWithTTL("key", "value", 0))
+	require.Nil(t, tx.UpdateKeyValue("permanent", "value"))
+	require.Nil(t, tx.UpdateKeyValueWithTTL("live", "value", time.Hour))
+	require.Nil(t, tx.UpdateKeyValueWithTTL("expired", "value", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	// Expired key/values are ignored until they are pruned.
+	_, found, err := tx.FindKeyValue("expired")
+	require.Nil(t, err)
+	assert.False(t, found)
+	value, found, err := tx.FindKeyValue("live")
+	require.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	pruned, err := tx.PruneKeyValues()
+	require.Nil(t, err)
+	assert.Equal(t, 1, pruned)
+
+	// Updating a key/value without a ttl makes it permanent again.
+	require.Nil(t, tx.UpdateKeyValue("live", "value"))
+	_, found, err = tx.FindKeyValue("permanent")
+	require.Nil(t, err)
+	assert.True(t, found)
+}
+
+func testPruneLocks(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	_, _, err = tx.AcquireLock("expired", "owner-1", -time.Minute)
+	require.Nil(t, err)
+	_, _, err = tx.AcquireLock("live", "owner-1", time.Minute)
+	require.Nil(t, err)
+
+	pruned, err := tx.PruneLocks()
+	require.Nil(t, err)
+	assert.Equal(t, 1, pruned)
+
+	acquired, _, err := tx.AcquireLock("live", "owner-2", time.Minute)
+	require.Nil(t, err)
+	assert.False(t, acquired)
+}
+
+func testUpdaterRuns(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	started := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	require.Nil(t, tx.InsertUpdaterRuns([]database.UpdaterRun{
+		{Updater: "debian", Started: started, Finished: started.Add(time.Minute), Vulnerabilities: 10},
+		{Updater: "debian", Started: started.Add(time.Hour), Error: "could not download"},
+		{Updater: "ubuntu", Started: started.Add(2 * time.Hour)},
+	}))
+	assert.IsType(t, &commonerr.ErrBadRequest{}, tx.InsertUpdaterRuns([]database.UpdaterRun{{Started: started}}))
+
+	runs, err := tx.FindUpdaterRuns("debian", 10)
+	require.Nil(t, err)
+	if assert.Len(t, runs, 2) {
+		assert.Equal(t, "could not download", runs[0].Error)
+		assert.Equal(t, 10, runs[1].Vulnerabilities)
+	}
+
+	runs, err = tx.FindUpdaterRuns("debian", 1)
+	require.Nil(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "could not download", runs[0].Error)
+	}
+
+	_, err = tx.FindUpdaterRuns("debian", 0)
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+}
+
+func testSuppressionRules(t *testing.T, store database.Datastore) {
+	tx, err := store.Begin()
+	require.Nil(t, err)
+
+	// Backends may store the times with less than a nanosecond precision.
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	_, err = tx.InsertSuppressionRule(database.SuppressionRule{Feature: "openssl"})
+	assert.IsType(t, &commonerr.ErrBadRequest{}, err)
+
+	id, err := tx.InsertSuppressionRule(database.SuppressionRule{Vulnerability: "CVE-2020-0001", Reason: "accepted risk"})
+	require.Nil(t, err)
+	other, err := tx.InsertSuppressionRule(database.SuppressionRule{Vulnerability: "CVE-2020-0002", Expires: expires})
+	require.Nil(t, err)
+	require.Nil(t, tx.Commit())
+
+	tx, err = store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	rules, err := tx.FindSuppressionRules()
+	require.Nil(t, err)
+	if assert.Len(t, rules, 2) {
+		assert.Equal(t, id, rules[0].ID)
+		assert.False(t, rules[0].Created.IsZero())
+		assert.Equal(t, other, rules[1].ID)
+		assert.True(t, rules[1].Expires.Equal(expires))
+	}
+
+	rule, ok, err := tx.FindSuppressionRule(id)
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	// The creation time is kept.
+	updated := database.SuppressionRule{ID: id, Vulnerability: "CVE-2020-0001", Feature: "openssl"}
+	ok, err = tx.UpdateSuppressionRule(updated)
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	updated.Created = rule.Created
+	rule, _, err = tx.FindSuppressionRule(id)
+	require.Nil(t, err)
+	assert.Equal(t, updated, rule)
+
+	ok, err = tx.UpdateSuppressionRule(database.SuppressionRule{ID: 42, Vulnerability: "CVE-2020-0001"})
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = tx.DeleteSuppressionRule(id)
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = tx.FindSuppressionRule(id)
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = tx.DeleteSuppressionRule(id)
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -136,10 +136,34 @@ func PersistNamespacesAndCommit(datastore Datastore, namespaces []Namespace) err
 	return tx.Commit()
 }
 
+// FindNamespacesAndRollback retrieves every namespace with the number of its
+// vulnerabilities.
+func FindNamespacesAndRollback(datastore Datastore) ([]NamespaceWithVulnerabilityCount, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return tx.FindNamespaces()
+}
+
+// FindStatisticsAndRollback computes the aggregate numbers of the content of
+// the database.
+func FindStatisticsAndRollback(datastore Datastore) (Statistics, error) {
+	tx, err := datastore.BeginReadOnly()
+	if err != nil {
+		return Statistics{}, err
+	}
+	defer tx.Rollback()
+
+	return tx.FindStatistics()
+}
+
 // FindAncestryAndRollback wraps session FindAncestry function with begin and
 // rollback.
 func FindAncestryAndRollback(datastore Datastore, name string) (Ancestry, bool, error) {
-	tx, err := datastore.Begin()
+	tx, err := datastore.BeginReadOnly()
 	if err != nil {
 		return Ancestry{}, false, err
 	}
@@ -148,6 +172,44 @@ func FindAncestryAndRollback(datastore Datastore, name string) (Ancestry, bool,
 	return tx.FindAncestry(name)
 }
 
+// UpsertAncestrySourceAndCommit upserts the source of an ancestry and commits.
+func UpsertAncestrySourceAndCommit(datastore Datastore, source AncestrySource) error {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err := tx.UpsertAncestrySource(source); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindAncestrySourceAndRollback finds the source of an ancestry.
+func FindAncestrySourceAndRollback(datastore Datastore, name string) (AncestrySource, bool, error) {
+	tx, err := datastore.BeginReadOnly()
+	if err != nil {
+		return AncestrySource{}, false, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindAncestrySource(name)
+}
+
+// FindOutdatedAncestriesAndRollback finds the names of the ancestries which
+// are not scanned by exactly the given detectors.
+func FindOutdatedAncestriesAndRollback(datastore Datastore, detectors []Detector) ([]string, error) {
+	tx, err := datastore.BeginReadOnly()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindOutdatedAncestries(detectors)
+}
+
 // FindLayerAndRollback wraps session FindLayer function with begin and rollback.
 func FindLayerAndRollback(datastore Datastore, hash string) (layer *Layer, ok bool, err error) {
 	var tx Session
@@ -214,6 +276,27 @@ func UpsertAncestryAndCommit(datastore Datastore, ancestry *Ancestry) error {
 	return nil
 }
 
+// DeleteAncestryAndCommit wraps session DeleteAncestry function with begin and
+// commit.
+func DeleteAncestryAndCommit(datastore Datastore, name string) (bool, error) {
+	tx, err := datastore.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+	found, err := tx.DeleteAncestry(name)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // PersistNamespacedFeaturesAndCommit wraps session PersistNamespacedFeatures function
 // with begin and commit.
 func PersistNamespacedFeaturesAndCommit(datastore Datastore, features []NamespacedFeature) error {
@@ -437,10 +520,93 @@ func MarkNotificationAsReadAndCommit(store Datastore, name string) (bool, error)
 	return true, nil
 }
 
+// MarkNotificationsAsReadAndCommit marks the notifications as read in a single
+// transaction, so that either every notification is marked or none is. The
+// notifications which don't exist anymore are skipped.
+func MarkNotificationsAsReadAndCommit(store Datastore, names []string) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	for _, name := range names {
+		if err := tx.DeleteNotification(name); err != nil && err != commonerr.ErrNotFound {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MarkNotificationAsFilteredAndCommit marks a notification as filtered.
+func MarkNotificationAsFilteredAndCommit(store Datastore, name string) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err := tx.MarkNotificationAsFiltered(name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InsertDeadLetterNotificationAndCommit stores a notification which failed to
+// be sent.
+func InsertDeadLetterNotificationAndCommit(store Datastore, deadLetter DeadLetterNotification) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err := tx.InsertDeadLetterNotification(deadLetter); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindDeadLetterNotificationsAndRollback finds every dead-lettered
+// notification.
+func FindDeadLetterNotificationsAndRollback(store Datastore) ([]DeadLetterNotification, error) {
+	tx, err := store.BeginReadOnly()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindDeadLetterNotifications()
+}
+
+// RequeueDeadLetterNotificationAndCommit makes a dead-lettered notification
+// available to be sent again.
+func RequeueDeadLetterNotificationAndCommit(store Datastore, name string) (bool, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+	found, err := tx.RequeueDeadLetterNotification(name)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // FindAffectedNamespacedFeaturesAndRollback finds the vulnerabilities on each
 // feature.
 func FindAffectedNamespacedFeaturesAndRollback(store Datastore, features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error) {
-	tx, err := store.Begin()
+	tx, err := store.BeginReadOnly()
 	if err != nil {
 		return nil, err
 	}
@@ -457,7 +623,7 @@ func FindAffectedNamespacedFeaturesAndRollback(store Datastore, features []Names
 // FindVulnerabilityNotificationAndRollback finds the vulnerability notification
 // and rollback.
 func FindVulnerabilityNotificationAndRollback(store Datastore, name string, limit int, oldVulnerabilityPage pagination.Token, newVulnerabilityPage pagination.Token) (VulnerabilityNotificationWithVulnerable, bool, error) {
-	tx, err := store.Begin()
+	tx, err := store.BeginReadOnly()
 	if err != nil {
 		return VulnerabilityNotificationWithVulnerable{}, false, err
 	}
@@ -466,6 +632,17 @@ func FindVulnerabilityNotificationAndRollback(store Datastore, name string, limi
 	return tx.FindVulnerabilityNotification(name, limit, oldVulnerabilityPage, newVulnerabilityPage)
 }
 
+// FindNotificationsAndRollback finds a page of the notifications in a state.
+func FindNotificationsAndRollback(store Datastore, state NotificationState, limit int, page pagination.Token) (PagedNotifications, error) {
+	tx, err := store.BeginReadOnly()
+	if err != nil {
+		return PagedNotifications{}, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindNotifications(state, limit, page)
+}
+
 // FindNewNotification finds notifications either never notified or notified
 // before the given time.
 func FindNewNotification(store Datastore, notifiedBefore time.Time) (NotificationHook, bool, error) {
@@ -493,6 +670,155 @@ func UpdateKeyValueAndCommit(store Datastore, key, value string) error {
 	return tx.Commit()
 }
 
+// UpdateKeyValueWithTTLAndCommit stores the key value to storage, expiring
+// once the ttl elapsed.
+func UpdateKeyValueWithTTLAndCommit(store Datastore, key, value string, ttl time.Duration) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err = tx.UpdateKeyValueWithTTL(key, value, ttl); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PruneExpiredAndCommit removes the expired key/value pairs and locks from
+// storage.
+func PruneExpiredAndCommit(store Datastore) (keyValues int, locks int, err error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer tx.Rollback()
+	if keyValues, err = tx.PruneKeyValues(); err != nil {
+		return 0, 0, err
+	}
+
+	if locks, err = tx.PruneLocks(); err != nil {
+		return 0, 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+
+	return keyValues, locks, nil
+}
+
+// InsertUpdaterRunsAndCommit stores the summaries of updater runs.
+func InsertUpdaterRunsAndCommit(store Datastore, runs []UpdaterRun) error {
+	tx, err := store.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+	if err := tx.InsertUpdaterRuns(runs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindUpdaterRunsAndRollback retrieves the summaries of the latest runs of an
+// updater.
+func FindUpdaterRunsAndRollback(store Datastore, updater string, limit int) ([]UpdaterRun, error) {
+	tx, err := store.BeginReadOnly()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindUpdaterRuns(updater, limit)
+}
+
+// InsertSuppressionRuleAndCommit stores a suppression rule and returns its ID.
+func InsertSuppressionRuleAndCommit(store Datastore, rule SuppressionRule) (int64, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+	id, err := tx.InsertSuppressionRule(rule)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// FindSuppressionRuleAndRollback retrieves a suppression rule.
+func FindSuppressionRuleAndRollback(store Datastore, id int64) (SuppressionRule, bool, error) {
+	tx, err := store.BeginReadOnly()
+	if err != nil {
+		return SuppressionRule{}, false, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindSuppressionRule(id)
+}
+
+// FindSuppressionRulesAndRollback retrieves every suppression rule.
+func FindSuppressionRulesAndRollback(store Datastore) (SuppressionRules, error) {
+	tx, err := store.BeginReadOnly()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindSuppressionRules()
+}
+
+// UpdateSuppressionRuleAndCommit replaces a suppression rule.
+func UpdateSuppressionRuleAndCommit(store Datastore, rule SuppressionRule) (bool, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+	found, err := tx.UpdateSuppressionRule(rule)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// DeleteSuppressionRuleAndCommit removes a suppression rule.
+func DeleteSuppressionRuleAndCommit(store Datastore, id int64) (bool, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	defer tx.Rollback()
+	found, err := tx.DeleteSuppressionRule(id)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // InsertVulnerabilityNotificationsAndCommit inserts the notifications into db
 // and commit.
 func InsertVulnerabilityNotificationsAndCommit(store Datastore, notifications []VulnerabilityNotification) error {
@@ -520,12 +846,49 @@ func FindVulnerabilitiesAndRollback(store Datastore, ids []VulnerabilityID) ([]N
 	return tx.FindVulnerabilities(ids)
 }
 
+// FindDeletedVulnerabilitiesAndRollback finds the most recently deleted
+// version of the vulnerabilities based on given ids.
+func FindDeletedVulnerabilitiesAndRollback(store Datastore, ids []VulnerabilityID) ([]NullableVulnerability, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindDeletedVulnerabilities(ids)
+}
+
+// FindPagedVulnerabilitiesAndRollback finds a page of the vulnerabilities of
+// a namespace.
+func FindPagedVulnerabilitiesAndRollback(store Datastore, namespace string, limit int, page pagination.Token) (PagedVulnerabilities, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return PagedVulnerabilities{}, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindPagedVulnerabilities(namespace, limit, page)
+}
+
+// FindAffectedAncestriesAndRollback finds a page of the ancestries affected
+// by a vulnerability.
+func FindAffectedAncestriesAndRollback(store Datastore, vulnerability VulnerabilityID, limit int, page pagination.Token) (PagedAffectedAncestries, bool, error) {
+	tx, err := store.Begin()
+	if err != nil {
+		return PagedAffectedAncestries{}, false, err
+	}
+
+	defer tx.Rollback()
+	return tx.FindAffectedAncestries(vulnerability, limit, page)
+}
+
 func UpdateVulnerabilitiesAndCommit(store Datastore, toRemove []VulnerabilityID, toAdd []VulnerabilityWithAffected) error {
 	tx, err := store.Begin()
 	if err != nil {
 		return err
 	}
 
+	defer tx.Rollback()
 	if err := tx.DeleteVulnerabilities(toRemove); err != nil {
 		return err
 	}
@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/pkg/commonerr"
 )
 
 func TestTxCreationFailure(t *testing.T) {
@@ -31,3 +33,43 @@ func TestTxCreationFailure(t *testing.T) {
 	_, _, err := FindAncestryAndRollback(mdb, "mockAncestry")
 	assert.Error(t, err)
 }
+
+func TestMarkNotificationsAsReadAndCommit(t *testing.T) {
+	var deleted []string
+	var committed, rolledBack bool
+	mdb := &MockDatastore{}
+	mdb.FctBegin = func() (Session, error) {
+		deleted, committed, rolledBack = nil, false, false
+		session := &MockSession{}
+		session.FctDeleteNotification = func(name string) error {
+			switch name {
+			case "expired":
+				return commonerr.ErrNotFound
+			case "failing":
+				return errors.New("connection reset")
+			}
+			deleted = append(deleted, name)
+			return nil
+		}
+		session.FctCommit = func() error {
+			committed = true
+			return nil
+		}
+		session.FctRollback = func() error {
+			rolledBack = !committed
+			return nil
+		}
+		return session, nil
+	}
+
+	// The notifications which don't exist anymore are skipped.
+	assert.Nil(t, MarkNotificationsAsReadAndCommit(mdb, []string{"a", "expired", "b"}))
+	assert.Equal(t, []string{"a", "b"}, deleted)
+	assert.True(t, committed)
+
+	// A failure rolls back the notifications already marked.
+	assert.Error(t, MarkNotificationsAsReadAndCommit(mdb, []string{"a", "failing", "b"}))
+	assert.Equal(t, []string{"a"}, deleted)
+	assert.False(t, committed)
+	assert.True(t, rolledBack)
+}
@@ -24,6 +24,11 @@ func (e *StorageError) Error() string {
 	return e.reason
 }
 
+// Unwrap returns the internal error, if any.
+func (e *StorageError) Unwrap() error {
+	return e.original
+}
+
 // NewStorageErrorWithInternalError creates a new database error
 func NewStorageErrorWithInternalError(reason string, originalError error) *StorageError {
 	return &StorageError{reason, originalError}
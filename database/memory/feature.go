@@ -0,0 +1,271 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+// affectedKey identifies the namespaced features which a vulnerability
+// affected feature may affect.
+type affectedKey struct {
+	namespace database.Namespace
+	name      string
+	ftype     database.FeatureType
+}
+
+func (s *session) PersistDetectors(detectors []database.Detector) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, d := range detectors {
+		if !d.Valid() {
+			return database.ErrInvalidParameters
+		}
+	}
+
+	for _, d := range detectors {
+		if _, ok := s.detectors[d]; !ok {
+			s.set(s.detectors, d, struct{}{})
+		}
+	}
+
+	return nil
+}
+
+func (s *session) PersistFeatures(features []database.Feature) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, f := range features {
+		if f.Name == "" || f.VersionFormat == "" {
+			return commonerr.NewBadRequestError("Empty feature name or version format is not allowed")
+		}
+
+		if f.Type != database.SourcePackage && f.Type != database.BinaryPackage {
+			return database.ErrInvalidParameters
+		}
+	}
+
+	for _, f := range features {
+		if _, ok := s.features[f]; !ok {
+			s.set(s.features, f, struct{}{})
+		}
+	}
+
+	return nil
+}
+
+func (s *session) PersistNamespaces(namespaces []database.Namespace) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, ns := range namespaces {
+		if ns.Name == "" || ns.VersionFormat == "" {
+			return commonerr.NewBadRequestError("Empty namespace name or version format is not allowed")
+		}
+	}
+
+	for _, ns := range namespaces {
+		if _, ok := s.namespaces[ns]; !ok {
+			s.set(s.namespaces, ns, struct{}{})
+		}
+	}
+
+	return nil
+}
+
+func (s *session) FindNamespaces() ([]database.NamespaceWithVulnerabilityCount, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	counts := map[database.Namespace]int{}
+	for _, row := range s.liveVulnerabilities {
+		counts[s.vulnerabilities[row].Namespace]++
+	}
+
+	namespaces := make([]database.NamespaceWithVulnerabilityCount, 0, len(s.namespaces))
+	for ns := range s.namespaces {
+		namespaces = append(namespaces, database.NamespaceWithVulnerabilityCount{Namespace: ns, VulnerabilityCount: counts[ns]})
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i].Name != namespaces[j].Name {
+			return namespaces[i].Name < namespaces[j].Name
+		}
+		return namespaces[i].VersionFormat < namespaces[j].VersionFormat
+	})
+
+	return namespaces, nil
+}
+
+func (s *session) PersistNamespacedFeatures(features []database.NamespacedFeature) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, f := range features {
+		if !s.hasFeature(f.Feature) || !s.hasNamespace(f.Namespace) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		if _, ok := s.namespacedFeatures[f]; ok {
+			continue
+		}
+
+		s.set(s.namespacedFeatures, f, struct{}{})
+
+		key := affectedKey{f.Namespace, f.Name, f.Type}
+		byName, ok := s.featuresByName[key]
+		if !ok {
+			byName = map[database.NamespacedFeature]struct{}{}
+			s.set(s.featuresByName, key, byName)
+		}
+
+		s.set(byName, f, struct{}{})
+	}
+
+	return nil
+}
+
+func (s *session) CacheAffectedNamespacedFeatures(features []database.NamespacedFeature) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, f := range features {
+		if _, ok := s.namespacedFeatures[f]; !ok {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		for id := range s.affecting[affectedKey{f.Namespace, f.Name, f.Type}] {
+			if err := s.cacheIfAffected(f, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *session) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	if len(features) == 0 {
+		return nil, nil
+	}
+
+	affectedFeatures := make([]database.NullableAffectedNamespacedFeature, len(features))
+	for i, f := range features {
+		if _, ok := s.namespacedFeatures[f]; !ok {
+			continue
+		}
+
+		affectedFeatures[i].Valid = true
+		affectedFeatures[i].NamespacedFeature = f
+
+		ids := make([]int64, 0, len(s.affected[f]))
+		for id := range s.affected[f] {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		for _, id := range ids {
+			affectedFeatures[i].AffectedBy = append(affectedFeatures[i].AffectedBy, database.VulnerabilityWithFixedIn{
+				Vulnerability:  s.vulnerabilities[id].Vulnerability,
+				FixedInVersion: s.affected[f][id],
+			})
+		}
+	}
+
+	return affectedFeatures, nil
+}
+
+// cacheIfAffected caches the namespaced feature f if the vulnerability id
+// affects its version.
+func (s *session) cacheIfAffected(f database.NamespacedFeature, id int64) error {
+	for _, affected := range s.vulnerabilities[id].Affected {
+		if affected.FeatureName != f.Name || affected.FeatureType != f.Type {
+			continue
+		}
+
+		in, err := versionfmt.InRange(f.VersionFormat, f.Version, affected.AffectedVersion)
+		if err != nil {
+			return err
+		}
+
+		if in {
+			s.cacheAffected(f, id, affected.FixedInVersion)
+		}
+	}
+
+	return nil
+}
+
+// cacheAffected records that the vulnerability id affects the namespaced
+// feature f.
+func (s *session) cacheAffected(f database.NamespacedFeature, id int64, fixedIn string) {
+	byFeature, ok := s.affected[f]
+	if !ok {
+		byFeature = map[int64]string{}
+		s.set(s.affected, f, byFeature)
+	}
+	s.set(byFeature, id, fixedIn)
+
+	byVulnerability, ok := s.affectedBy[id]
+	if !ok {
+		byVulnerability = map[database.NamespacedFeature]struct{}{}
+		s.set(s.affectedBy, id, byVulnerability)
+	}
+	s.set(byVulnerability, f, struct{}{})
+}
+
+// retireAffected stops the deleted vulnerability id from affecting the cached
+// namespaced features. The features it affected are kept for the
+// notifications of its changes.
+func (s *session) retireAffected(id int64) {
+	for f := range s.affectedBy[id] {
+		s.remove(s.affected[f], id)
+	}
+}
+
+func (s *session) hasFeature(f database.Feature) bool {
+	_, ok := s.features[f]
+	return ok
+}
+
+func (s *session) hasNamespace(ns database.Namespace) bool {
+	_, ok := s.namespaces[ns]
+	return ok
+}
+
+func (s *session) hasDetector(d database.Detector) bool {
+	_, ok := s.detectors[d]
+	return ok
+}
@@ -0,0 +1,165 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"time"
+
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+// keyValue is a stored value, which expires at expiresAt unless it is zero.
+type keyValue struct {
+	value     string
+	expiresAt time.Time
+}
+
+func (kv keyValue) expired(now time.Time) bool {
+	return !kv.expiresAt.IsZero() && !kv.expiresAt.After(now)
+}
+
+// lock is a stored lock, held by its owner until it expires.
+type lock struct {
+	owner string
+	until time.Time
+}
+
+func (s *session) UpdateKeyValue(key, value string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if key == "" || value == "" {
+		return commonerr.NewBadRequestError("could not insert a flag which has an empty name or value")
+	}
+
+	s.set(s.keyValues, key, keyValue{value: value})
+	return nil
+}
+
+func (s *session) UpdateKeyValueWithTTL(key, value string, ttl time.Duration) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if key == "" || value == "" {
+		return commonerr.NewBadRequestError("could not insert a flag which has an empty name or value")
+	}
+
+	if ttl <= 0 {
+		return commonerr.NewBadRequestError("could not insert a flag which has a non-positive ttl")
+	}
+
+	s.set(s.keyValues, key, keyValue{value: value, expiresAt: time.Now().UTC().Add(ttl)})
+	return nil
+}
+
+func (s *session) FindKeyValue(key string) (string, bool, error) {
+	if err := s.check(); err != nil {
+		return "", false, err
+	}
+
+	kv, ok := s.keyValues[key]
+	if !ok || kv.expired(time.Now().UTC()) {
+		return "", false, nil
+	}
+
+	return kv.value, true, nil
+}
+
+func (s *session) PruneKeyValues() (int, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+
+	now, pruned := time.Now().UTC(), 0
+	for key, kv := range s.keyValues {
+		if kv.expired(now) {
+			s.remove(s.keyValues, key)
+			pruned++
+		}
+	}
+
+	return pruned, nil
+}
+
+func (s *session) AcquireLock(name, owner string, duration time.Duration) (bool, time.Time, error) {
+	if name == "" || owner == "" || duration == 0 {
+		panic("invalid lock parameters")
+	}
+
+	if err := s.check(); err != nil {
+		return false, time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	l, ok := s.locks[name]
+	if !ok || l.until.Before(now) {
+		l = lock{owner: owner, until: now.Add(duration)}
+		s.set(s.locks, name, l)
+	}
+
+	return l.owner == owner, l.until, nil
+}
+
+func (s *session) ExtendLock(name, owner string, duration time.Duration) (bool, time.Time, error) {
+	if name == "" || owner == "" || duration == 0 {
+		panic("invalid lock parameters")
+	}
+
+	if err := s.check(); err != nil {
+		return false, time.Time{}, err
+	}
+
+	until := time.Now().Add(duration)
+	if l, ok := s.locks[name]; !ok || l.owner != owner {
+		return false, until, nil
+	}
+
+	s.set(s.locks, name, lock{owner: owner, until: until})
+	return true, until, nil
+}
+
+func (s *session) ReleaseLock(name, owner string) error {
+	if name == "" || owner == "" {
+		panic("invalid lock parameters")
+	}
+
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if l, ok := s.locks[name]; ok && l.owner == owner {
+		s.remove(s.locks, name)
+	}
+
+	return nil
+}
+
+func (s *session) PruneLocks() (int, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+
+	now, pruned := time.Now().UTC(), 0
+	for name, l := range s.locks {
+		if l.until.Before(now) {
+			s.remove(s.locks, name)
+			pruned++
+		}
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,316 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+// ancestry is a stored ancestry, numbered in insertion order to paginate the
+// ancestries affected by a vulnerability.
+type ancestry struct {
+	database.Ancestry
+
+	id int64
+}
+
+// layerFeatureKey is the unique key of a feature in a layer.
+type layerFeatureKey struct {
+	feature   database.Feature
+	namespace database.Namespace
+}
+
+func (s *session) PersistLayer(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if hash == "" {
+		return commonerr.NewBadRequestError("expected non-empty layer hash")
+	}
+
+	detectedBySet := map[database.Detector]struct{}{}
+	for _, d := range detectedBy {
+		detectedBySet[d] = struct{}{}
+		if !s.hasDetector(d) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, f := range features {
+		if _, ok := detectedBySet[f.By]; !ok {
+			return database.ErrInvalidParameters
+		}
+
+		if !s.hasFeature(f.Feature) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	for _, ns := range namespaces {
+		if _, ok := detectedBySet[ns.By]; !ok {
+			return database.ErrInvalidParameters
+		}
+
+		if !s.hasNamespace(ns.Namespace) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	layer := s.layers[hash]
+	layer.Hash = hash
+
+	existingDetectors := map[database.Detector]struct{}{}
+	by := append([]database.Detector(nil), layer.By...)
+	for _, d := range by {
+		existingDetectors[d] = struct{}{}
+	}
+
+	for _, d := range detectedBy {
+		if _, ok := existingDetectors[d]; !ok {
+			existingDetectors[d] = struct{}{}
+			by = append(by, d)
+		}
+	}
+
+	existingFeatures := map[layerFeatureKey]struct{}{}
+	layerFeatures := append([]database.LayerFeature(nil), layer.Features...)
+	for _, f := range layerFeatures {
+		existingFeatures[layerFeatureKey{f.Feature, f.PotentialNamespace}] = struct{}{}
+	}
+
+	for _, f := range features {
+		// A potential namespace which isn't stored is dropped.
+		if !s.hasNamespace(f.PotentialNamespace) {
+			f.PotentialNamespace = database.Namespace{}
+		}
+
+		key := layerFeatureKey{f.Feature, f.PotentialNamespace}
+		if _, ok := existingFeatures[key]; !ok {
+			existingFeatures[key] = struct{}{}
+			layerFeatures = append(layerFeatures, f)
+		}
+	}
+
+	existingNamespaces := map[database.Namespace]struct{}{}
+	layerNamespaces := append([]database.LayerNamespace(nil), layer.Namespaces...)
+	for _, ns := range layerNamespaces {
+		existingNamespaces[ns.Namespace] = struct{}{}
+	}
+
+	for _, ns := range namespaces {
+		if _, ok := existingNamespaces[ns.Namespace]; !ok {
+			existingNamespaces[ns.Namespace] = struct{}{}
+			layerNamespaces = append(layerNamespaces, ns)
+		}
+	}
+
+	layer.By, layer.Features, layer.Namespaces = by, layerFeatures, layerNamespaces
+	s.set(s.layers, hash, layer)
+	return nil
+}
+
+func (s *session) FindLayer(hash string) (database.Layer, bool, error) {
+	if err := s.check(); err != nil {
+		return database.Layer{}, false, err
+	}
+
+	if hash == "" {
+		return database.Layer{}, false, commonerr.NewBadRequestError("non empty layer hash is expected.")
+	}
+
+	layer, ok := s.layers[hash]
+	if !ok {
+		// As the other drivers, the hash is kept to merge the layer with the
+		// result of its first scan.
+		return database.Layer{Hash: hash}, false, nil
+	}
+
+	layer.By = append([]database.Detector{}, layer.By...)
+	layer.Features = append([]database.LayerFeature{}, layer.Features...)
+	layer.Namespaces = append([]database.LayerNamespace{}, layer.Namespaces...)
+	return layer, true, nil
+}
+
+func (s *session) UpsertAncestry(a database.Ancestry) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if !a.Valid() {
+		return database.ErrInvalidParameters
+	}
+
+	for _, d := range a.By {
+		if !s.hasDetector(d) {
+			return database.ErrMissingEntities
+		}
+	}
+
+	stored := database.Ancestry{
+		Name:   a.Name,
+		By:     append([]database.Detector(nil), a.By...),
+		Layers: make([]database.AncestryLayer, 0, len(a.Layers)),
+	}
+
+	for _, l := range a.Layers {
+		if _, ok := s.layers[l.Hash]; !ok {
+			return database.ErrMissingEntities
+		}
+
+		layer := database.AncestryLayer{
+			Hash:     l.Hash,
+			Features: make([]database.AncestryFeature, 0, len(l.Features)),
+		}
+
+		for _, f := range l.Features {
+			if _, ok := s.namespacedFeatures[f.NamespacedFeature]; !ok {
+				return database.ErrMissingEntities
+			}
+
+			if !s.hasDetector(f.FeatureBy) {
+				return database.ErrMissingEntities
+			}
+
+			// The namespace detector is optional.
+			if !s.hasDetector(f.NamespaceBy) {
+				f.NamespaceBy = database.Detector{}
+			}
+
+			layer.Features = append(layer.Features, f)
+		}
+
+		stored.Layers = append(stored.Layers, layer)
+	}
+
+	// The ancestry is replaced by a new one, and thus paginated as such.
+	s.set(s.ancestries, a.Name, ancestry{Ancestry: stored, id: s.newID()})
+	return nil
+}
+
+func (s *session) FindAncestry(name string) (database.Ancestry, bool, error) {
+	if err := s.check(); err != nil {
+		return database.Ancestry{}, false, err
+	}
+
+	a, ok := s.ancestries[name]
+	if !ok {
+		return database.Ancestry{}, false, nil
+	}
+
+	found := database.Ancestry{
+		Name:   a.Name,
+		By:     append([]database.Detector{}, a.By...),
+		Layers: make([]database.AncestryLayer, 0, len(a.Layers)),
+	}
+
+	for _, l := range a.Layers {
+		found.Layers = append(found.Layers, database.AncestryLayer{
+			Hash:     l.Hash,
+			Features: append([]database.AncestryFeature{}, l.Features...),
+		})
+	}
+
+	return found, true, nil
+}
+
+func (s *session) DeleteAncestry(name string) (bool, error) {
+	if err := s.check(); err != nil {
+		return false, err
+	}
+
+	if _, ok := s.ancestries[name]; !ok {
+		return false, nil
+	}
+
+	// Layers are stored on their own, and thus kept for the other ancestries.
+	s.remove(s.ancestries, name)
+	if _, ok := s.ancestrySources[name]; ok {
+		s.remove(s.ancestrySources, name)
+	}
+
+	return true, nil
+}
+
+func (s *session) UpsertAncestrySource(source database.AncestrySource) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if source.Name == "" {
+		return commonerr.NewBadRequestError("ancestry source should not have empty name")
+	}
+
+	s.set(s.ancestrySources, source.Name, copyAncestrySource(source))
+	return nil
+}
+
+func (s *session) FindAncestrySource(name string) (database.AncestrySource, bool, error) {
+	if err := s.check(); err != nil {
+		return database.AncestrySource{}, false, err
+	}
+
+	source, ok := s.ancestrySources[name]
+	if !ok {
+		return database.AncestrySource{}, false, nil
+	}
+
+	return copyAncestrySource(source), true, nil
+}
+
+// copyAncestrySource returns a copy of the source which doesn't share its
+// layers and headers.
+func copyAncestrySource(source database.AncestrySource) database.AncestrySource {
+	layers := make([]database.LayerSource, 0, len(source.Layers))
+	for _, l := range source.Layers {
+		var headers map[string]string
+		if l.Headers != nil {
+			headers = make(map[string]string, len(l.Headers))
+			for k, v := range l.Headers {
+				headers[k] = v
+			}
+		}
+
+		layers = append(layers, database.LayerSource{Hash: l.Hash, Path: l.Path, Headers: headers})
+	}
+
+	source.Layers = layers
+	return source
+}
+
+func (s *session) FindOutdatedAncestries(detectors []database.Detector) ([]string, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	outdated := []ancestry{}
+	for _, a := range s.ancestries {
+		if len(database.DiffDetectors(detectors, a.By)) != 0 || len(database.DiffDetectors(a.By, detectors)) != 0 {
+			outdated = append(outdated, a)
+		}
+	}
+
+	sort.Slice(outdated, func(i, j int) bool { return outdated[i].id < outdated[j].id })
+
+	names := make([]string, 0, len(outdated))
+	for _, a := range outdated {
+		names = append(names, a.Name)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory implements database.Datastore in memory.
+//
+// It is meant for development and for testing extensions without a
+// PostgreSQL server: nothing is persisted once Clair stops. Sessions are
+// serialized, a session holds the datastore until it is committed or rolled
+// back.
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+var errSessionDone = errors.New("memory: session is already committed or rolled back")
+
+func init() {
+	database.Register("memory", openDatabase)
+}
+
+// Config is the configuration that is used by openDatabase.
+type Config struct {
+	// PaginationKey is the key securing the pagination tokens. It is generated
+	// when empty.
+	PaginationKey string
+	// PaginationTTL is how long pagination tokens stay valid. They never
+	// expire when it is 0.
+	PaginationTTL time.Duration
+}
+
+type memory struct {
+	*store
+
+	key pagination.Key
+}
+
+// openDatabase opens an empty in-memory datastore.
+func openDatabase(registrableComponentConfig database.RegistrableComponentConfig) (database.Datastore, error) {
+	config := Config{PaginationTTL: time.Hour}
+	bytes, err := yaml.Marshal(registrableComponentConfig.Options)
+	if err != nil {
+		return nil, fmt.Errorf("memory: could not load configuration: %v", err)
+	}
+
+	if err := yaml.Unmarshal(bytes, &config); err != nil {
+		return nil, fmt.Errorf("memory: could not load configuration: %v", err)
+	}
+
+	var key pagination.Key
+	if config.PaginationKey == "" {
+		key, err = pagination.NewKey()
+	} else {
+		key, err = pagination.KeyFromString(config.PaginationKey)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("memory: could not load pagination key: %v", err)
+	}
+
+	return &memory{store: newStore(), key: key.WithTTL(config.PaginationTTL)}, nil
+}
+
+// Begin starts a session, waiting for the running one to end.
+func (m *memory) Begin() (database.Session, error) {
+	m.store.Lock()
+	return &session{store: m.store, key: m.key}, nil
+}
+
+// BeginReadOnly is Begin, there is no replica to read from.
+func (m *memory) BeginReadOnly() (database.Session, error) {
+	return m.Begin()
+}
+
+// Ping always succeeds.
+func (m *memory) Ping() bool {
+	return true
+}
+
+// Close does nothing, the data is dropped with the datastore.
+func (m *memory) Close() {}
+
+// store holds every table of the datastore.
+//
+// The stored values are never modified in place: they are replaced so that
+// the previous value can be restored when a session is rolled back.
+type store struct {
+	sync.Mutex
+
+	// nextID numbers the rows which are referenced by ID. Like a sequence, it
+	// is not rolled back.
+	nextID int64
+
+	detectors          map[database.Detector]struct{}
+	features           map[database.Feature]struct{}
+	namespaces         map[database.Namespace]struct{}
+	namespacedFeatures map[database.NamespacedFeature]struct{}
+	// featuresByName indexes the namespaced features by the columns which
+	// vulnerabilities are matched on.
+	featuresByName map[affectedKey]map[database.NamespacedFeature]struct{}
+
+	layers          map[string]database.Layer
+	ancestries      map[string]ancestry
+	ancestrySources map[string]database.AncestrySource
+
+	vulnerabilities        map[int64]database.VulnerabilityWithAffected
+	liveVulnerabilities    map[database.VulnerabilityID]int64
+	deletedVulnerabilities map[database.VulnerabilityID]int64
+	// affecting indexes the live vulnerabilities by the namespaced features
+	// they may affect.
+	affecting map[affectedKey]map[int64]struct{}
+	// affected caches the fixed in version of the vulnerabilities affecting
+	// every namespaced feature, affectedBy is its reverse index.
+	affected   map[database.NamespacedFeature]map[int64]string
+	affectedBy map[int64]map[database.NamespacedFeature]struct{}
+
+	notifications map[string]notification
+	deadLetters   map[string]database.DeadLetterNotification
+	keyValues     map[string]keyValue
+	updaterRuns   map[int64]database.UpdaterRun
+	locks         map[string]lock
+
+	suppressionRules map[int64]database.SuppressionRule
+}
+
+func newStore() *store {
+	return &store{
+		detectors:              map[database.Detector]struct{}{},
+		features:               map[database.Feature]struct{}{},
+		namespaces:             map[database.Namespace]struct{}{},
+		namespacedFeatures:     map[database.NamespacedFeature]struct{}{},
+		featuresByName:         map[affectedKey]map[database.NamespacedFeature]struct{}{},
+		layers:                 map[string]database.Layer{},
+		ancestries:             map[string]ancestry{},
+		ancestrySources:        map[string]database.AncestrySource{},
+		vulnerabilities:        map[int64]database.VulnerabilityWithAffected{},
+		liveVulnerabilities:    map[database.VulnerabilityID]int64{},
+		deletedVulnerabilities: map[database.VulnerabilityID]int64{},
+		affecting:              map[affectedKey]map[int64]struct{}{},
+		affected:               map[database.NamespacedFeature]map[int64]string{},
+		affectedBy:             map[int64]map[database.NamespacedFeature]struct{}{},
+		notifications:          map[string]notification{},
+		deadLetters:            map[string]database.DeadLetterNotification{},
+		keyValues:              map[string]keyValue{},
+		updaterRuns:            map[int64]database.UpdaterRun{},
+		locks:                  map[string]lock{},
+		suppressionRules:       map[int64]database.SuppressionRule{},
+	}
+}
+
+func (s *store) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// session implements database.Session.
+type session struct {
+	*store
+
+	key  pagination.Key
+	done bool
+	// undo restores the changes of the session, in reverse order.
+	undo []func()
+}
+
+// Commit keeps the changes of the session and releases the datastore. Like a
+// transaction, a terminated session can't be committed.
+func (s *session) Commit() error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	s.end()
+	return nil
+}
+
+// Rollback drops the changes of the session and releases the datastore.
+func (s *session) Rollback() error {
+	if s.done {
+		return nil
+	}
+
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		s.undo[i]()
+	}
+
+	s.end()
+	return nil
+}
+
+func (s *session) end() {
+	s.done = true
+	s.undo = nil
+	s.store.Unlock()
+}
+
+// check returns an error when the session is terminated.
+func (s *session) check() error {
+	if s.done {
+		return errSessionDone
+	}
+
+	return nil
+}
+
+// set sets the value of a key of the map m, remembering the previous one to
+// be restored on rollback.
+func (s *session) set(m, key, value interface{}) {
+	s.record(m, key)
+	reflect.ValueOf(m).SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+}
+
+// remove deletes a key of the map m, remembering its value to be restored on
+// rollback.
+func (s *session) remove(m, key interface{}) {
+	s.record(m, key)
+	reflect.ValueOf(m).SetMapIndex(reflect.ValueOf(key), reflect.Value{})
+}
+
+func (s *session) record(m, key interface{}) {
+	mv, kv := reflect.ValueOf(m), reflect.ValueOf(key)
+	previous := mv.MapIndex(kv)
+	s.undo = append(s.undo, func() {
+		mv.SetMapIndex(kv, previous)
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/datastoretest"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+)
+
+func openTestDatastore(t *testing.T) database.Datastore {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	return store
+}
+
+func TestSession(t *testing.T) {
+	datastoretest.RunSessionTests(t, openTestDatastore)
+}
+
+func TestSessionRollback(t *testing.T) {
+	store := openTestDatastore(t)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	datastoretest.PersistAncestry(t, tx, "ancestry", "layer")
+	require.Nil(t, tx.Rollback())
+
+	// Terminated sessions can't be committed.
+	assert.Equal(t, errSessionDone, tx.Commit())
+
+	// The namespaced feature index is restored along with the features.
+	assert.Empty(t, tx.(*session).featuresByName)
+}
+
+func TestInsertVulnerabilitiesTwice(t *testing.T) {
+	store := openTestDatastore(t)
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	vulnerability := database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: "CVE-2019-0001", Namespace: ns}}
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+
+	// A vulnerability can't be inserted again until it is deleted.
+	assert.Error(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2019-0001", Namespace: "debian:9"}}))
+	assert.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+}
@@ -0,0 +1,404 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+var (
+	errNotificationNotFound   = errors.New("requested notification is not found")
+	errDuplicatedNotification = errors.New("notification already exists")
+)
+
+// notification is a stored notification, referencing the rows of its old and
+// new vulnerabilities. A row is 0 when there is no such vulnerability.
+type notification struct {
+	database.NotificationHook
+
+	// id orders the notifications for pagination.
+	id       int64
+	old, new int64
+}
+
+// page is the content of the pagination tokens of the ancestries affected by
+// a vulnerability, of the vulnerabilities of a namespace, and of the
+// notifications.
+type page struct {
+	// StartID is the ID of the first ancestry, or vulnerability, of the
+	// page.
+	StartID int64
+}
+
+func (s *session) InsertVulnerabilityNotifications(notifications []database.VulnerabilityNotification) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	stored := make([]notification, 0, len(notifications))
+	for _, n := range notifications {
+		if n.Name == "" {
+			return commonerr.NewBadRequestError("notification should not have empty name")
+		}
+
+		if n.Created.IsZero() {
+			return commonerr.NewBadRequestError("notification should not have empty created time")
+		}
+
+		if _, ok := s.notifications[n.Name]; ok {
+			return errDuplicatedNotification
+		}
+
+		row := notification{NotificationHook: n.NotificationHook}
+		if n.New != nil {
+			id, ok := s.liveVulnerabilities[vulnerabilityID(*n.New)]
+			if !ok {
+				return errVulnerabilityNotFound
+			}
+			row.new = id
+		}
+
+		if n.Old != nil {
+			id, ok := s.deletedVulnerabilities[vulnerabilityID(*n.Old)]
+			if !ok {
+				return errVulnerabilityNotFound
+			}
+			row.old = id
+		}
+
+		stored = append(stored, row)
+	}
+
+	for _, n := range stored {
+		n.id = s.newID()
+		s.set(s.notifications, n.Name, n)
+	}
+
+	return nil
+}
+
+func (s *session) FindNewNotification(notifiedBefore time.Time) (database.NotificationHook, bool, error) {
+	if err := s.check(); err != nil {
+		return database.NotificationHook{}, false, err
+	}
+
+	var (
+		found database.NotificationHook
+		ok    bool
+		now   = time.Now().UTC()
+	)
+
+	for name, n := range s.notifications {
+		if !n.Deleted.IsZero() || n.Filtered || (!n.Notified.IsZero() && !n.Notified.Before(notifiedBefore)) {
+			continue
+		}
+
+		if l, locked := s.locks[name]; locked && !l.until.Before(now) {
+			continue
+		}
+
+		if _, deadLetter := s.deadLetters[name]; deadLetter {
+			continue
+		}
+
+		// Send the oldest notification first.
+		if !ok || n.Created.Before(found.Created) || (n.Created.Equal(found.Created) && n.Name < found.Name) {
+			found, ok = n.NotificationHook, true
+		}
+	}
+
+	return found, ok, nil
+}
+
+func (s *session) FindVulnerabilityNotification(name string, limit int, oldPageToken pagination.Token, newPageToken pagination.Token) (database.VulnerabilityNotificationWithVulnerable, bool, error) {
+	var noti database.VulnerabilityNotificationWithVulnerable
+	if err := s.check(); err != nil {
+		return noti, false, err
+	}
+
+	if name == "" {
+		return noti, false, commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	n, ok := s.notifications[name]
+	if !ok {
+		return noti, false, nil
+	}
+
+	noti.NotificationHook = n.NotificationHook
+	if n.old != 0 {
+		page, err := s.findPagedVulnerableAncestries(n.old, limit, oldPageToken)
+		if err != nil {
+			return noti, false, err
+		}
+		noti.Old = &page
+	}
+
+	if n.new != 0 {
+		page, err := s.findPagedVulnerableAncestries(n.new, limit, newPageToken)
+		if err != nil {
+			return noti, false, err
+		}
+		noti.New = &page
+	}
+
+	return noti, true, nil
+}
+
+// findPagedVulnerableAncestries returns a page of the ancestries affected by
+// the vulnerability stored at row id.
+func (s *session) findPagedVulnerableAncestries(id int64, limit int, currentToken pagination.Token) (database.PagedVulnerableAncestries, error) {
+	vulnPage := database.PagedVulnerableAncestries{
+		Vulnerability: s.vulnerabilities[id].Vulnerability,
+		Limit:         limit,
+	}
+
+	var currentPage page
+	if currentToken != pagination.FirstPageToken {
+		if err := s.key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return vulnPage, err
+		}
+	}
+
+	ancestries := []ancestry{}
+	for _, a := range s.ancestries {
+		if a.id >= currentPage.StartID && s.affectsAncestry(id, a.Ancestry) {
+			ancestries = append(ancestries, a)
+		}
+	}
+
+	sort.Slice(ancestries, func(i, j int) bool { return ancestries[i].id < ancestries[j].id })
+
+	// The first ancestry after the page is used as the next page's start.
+	var err error
+	if len(ancestries) > limit {
+		vulnPage.Next, err = s.key.MarshalToken(page{ancestries[limit].id})
+		if err != nil {
+			return vulnPage, err
+		}
+
+		ancestries = ancestries[:limit]
+	} else {
+		vulnPage.End = true
+	}
+
+	vulnPage.Affected = map[int]string{}
+	for _, a := range ancestries {
+		vulnPage.Affected[int(a.id)] = a.Name
+	}
+
+	vulnPage.Current, err = s.key.MarshalToken(currentPage)
+	if err != nil {
+		return vulnPage, err
+	}
+
+	return vulnPage, nil
+}
+
+// affectsAncestry returns whether the vulnerability stored at row id affects
+// a feature of the ancestry.
+func (s *session) affectsAncestry(id int64, a database.Ancestry) bool {
+	affected := s.affectedBy[id]
+	for _, l := range a.Layers {
+		for _, f := range l.Features {
+			if _, ok := affected[f.NamespacedFeature]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *session) FindNotifications(state database.NotificationState, limit int, currentToken pagination.Token) (database.PagedNotifications, error) {
+	notiPage := database.PagedNotifications{Limit: limit}
+	if err := s.check(); err != nil {
+		return notiPage, err
+	}
+
+	if state != "" && !state.Valid() {
+		return notiPage, commonerr.NewBadRequestError("unknown notification state")
+	}
+
+	var currentPage page
+	if currentToken != pagination.FirstPageToken {
+		if err := s.key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return notiPage, err
+		}
+	}
+
+	notifications := []notification{}
+	for _, n := range s.notifications {
+		if n.id >= currentPage.StartID && (state == "" || n.State() == state) {
+			notifications = append(notifications, n)
+		}
+	}
+
+	sort.Slice(notifications, func(i, j int) bool { return notifications[i].id < notifications[j].id })
+
+	// The first notification after the page is used as the next page's start.
+	var err error
+	if len(notifications) > limit {
+		notiPage.Next, err = s.key.MarshalToken(page{StartID: notifications[limit].id})
+		if err != nil {
+			return notiPage, err
+		}
+
+		notifications = notifications[:limit]
+	} else {
+		notiPage.End = true
+	}
+
+	for _, n := range notifications {
+		summary := database.NotificationSummary{NotificationHook: n.NotificationHook}
+		row := n.new
+		if row == 0 {
+			row = n.old
+		}
+
+		if row != 0 {
+			summary.Vulnerability = vulnerabilityID(s.vulnerabilities[row].Vulnerability)
+		}
+
+		notiPage.Notifications = append(notiPage.Notifications, summary)
+	}
+
+	notiPage.Current, err = s.key.MarshalToken(currentPage)
+	if err != nil {
+		return notiPage, err
+	}
+
+	return notiPage, nil
+}
+
+func (s *session) MarkNotificationAsRead(name string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	n, ok := s.notifications[name]
+	if !ok {
+		return errNotificationNotFound
+	}
+
+	n.Notified = time.Now()
+	s.set(s.notifications, name, n)
+	return nil
+}
+
+func (s *session) MarkNotificationAsFiltered(name string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	n, ok := s.notifications[name]
+	if !ok {
+		return errNotificationNotFound
+	}
+
+	n.Notified = time.Now()
+	n.Filtered = true
+	s.set(s.notifications, name, n)
+	return nil
+}
+
+func (s *session) DeleteNotification(name string) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	n, ok := s.notifications[name]
+	if !ok || !n.Deleted.IsZero() {
+		return commonerr.ErrNotFound
+	}
+
+	n.Deleted = time.Now()
+	s.set(s.notifications, name, n)
+	return nil
+}
+
+func (s *session) InsertDeadLetterNotification(deadLetter database.DeadLetterNotification) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	if deadLetter.Name == "" {
+		return commonerr.NewBadRequestError("dead letter should not have empty name")
+	}
+
+	if deadLetter.Created.IsZero() {
+		return commonerr.NewBadRequestError("dead letter should not have empty created time")
+	}
+
+	s.set(s.deadLetters, deadLetter.Name, deadLetter)
+	return nil
+}
+
+func (s *session) FindDeadLetterNotifications() ([]database.DeadLetterNotification, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	deadLetters := make([]database.DeadLetterNotification, 0, len(s.deadLetters))
+	for _, deadLetter := range s.deadLetters {
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	sort.Slice(deadLetters, func(i, j int) bool {
+		if !deadLetters[i].Created.Equal(deadLetters[j].Created) {
+			return deadLetters[i].Created.Before(deadLetters[j].Created)
+		}
+
+		return deadLetters[i].Name < deadLetters[j].Name
+	})
+
+	return deadLetters, nil
+}
+
+func (s *session) RequeueDeadLetterNotification(name string) (bool, error) {
+	if err := s.check(); err != nil {
+		return false, err
+	}
+
+	if _, ok := s.deadLetters[name]; !ok {
+		return false, nil
+	}
+
+	s.remove(s.deadLetters, name)
+	if n, ok := s.notifications[name]; ok && n.Deleted.IsZero() {
+		n.Notified = time.Time{}
+		s.set(s.notifications, name, n)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+)
+
+func (s *session) FindStatistics() (database.Statistics, error) {
+	if err := s.check(); err != nil {
+		return database.Statistics{}, err
+	}
+
+	type countKey struct {
+		namespace database.Namespace
+		severity  database.Severity
+	}
+
+	counts := map[countKey]int{}
+	for _, row := range s.liveVulnerabilities {
+		vulnerability := s.vulnerabilities[row]
+		counts[countKey{vulnerability.Namespace, vulnerability.Severity}]++
+	}
+
+	stats := database.Statistics{
+		Vulnerabilities: make([]database.VulnerabilityCount, 0, len(counts)),
+		Ancestries:      len(s.ancestries),
+		Layers:          len(s.layers),
+		Features:        len(s.namespacedFeatures),
+	}
+
+	for key, count := range counts {
+		stats.Vulnerabilities = append(stats.Vulnerabilities, database.VulnerabilityCount{
+			Namespace: key.namespace,
+			Severity:  key.severity,
+			Count:     count,
+		})
+	}
+
+	sort.Slice(stats.Vulnerabilities, func(i, j int) bool {
+		a, b := stats.Vulnerabilities[i], stats.Vulnerabilities[j]
+		if a.Namespace.Name != b.Namespace.Name {
+			return a.Namespace.Name < b.Namespace.Name
+		}
+		if a.Namespace.VersionFormat != b.Namespace.VersionFormat {
+			return a.Namespace.VersionFormat < b.Namespace.VersionFormat
+		}
+		return a.Severity.Compare(b.Severity) < 0
+	})
+
+	return stats, nil
+}
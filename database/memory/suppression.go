@@ -0,0 +1,93 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func (s *session) InsertSuppressionRule(rule database.SuppressionRule) (int64, error) {
+	if err := s.check(); err != nil {
+		return 0, err
+	}
+
+	if !rule.Valid() {
+		return 0, commonerr.NewBadRequestError("suppression rule should not have empty vulnerability name")
+	}
+
+	rule.ID = s.newID()
+	rule.Created = time.Now().UTC()
+	s.set(s.suppressionRules, rule.ID, rule)
+	return rule.ID, nil
+}
+
+func (s *session) FindSuppressionRule(id int64) (database.SuppressionRule, bool, error) {
+	if err := s.check(); err != nil {
+		return database.SuppressionRule{}, false, err
+	}
+
+	rule, ok := s.suppressionRules[id]
+	return rule, ok, nil
+}
+
+func (s *session) FindSuppressionRules() (database.SuppressionRules, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	rules := make(database.SuppressionRules, 0, len(s.suppressionRules))
+	for _, rule := range s.suppressionRules {
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+func (s *session) UpdateSuppressionRule(rule database.SuppressionRule) (bool, error) {
+	if err := s.check(); err != nil {
+		return false, err
+	}
+
+	if !rule.Valid() {
+		return false, commonerr.NewBadRequestError("suppression rule should not have empty vulnerability name")
+	}
+
+	stored, ok := s.suppressionRules[rule.ID]
+	if !ok {
+		return false, nil
+	}
+
+	rule.Created = stored.Created
+	s.set(s.suppressionRules, rule.ID, rule)
+	return true, nil
+}
+
+func (s *session) DeleteSuppressionRule(id int64) (bool, error) {
+	if err := s.check(); err != nil {
+		return false, err
+	}
+
+	if _, ok := s.suppressionRules[id]; !ok {
+		return false, nil
+	}
+
+	s.remove(s.suppressionRules, id)
+	return true, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func (s *session) InsertUpdaterRuns(runs []database.UpdaterRun) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, run := range runs {
+		if run.Updater == "" {
+			return commonerr.NewBadRequestError("updater run should not have empty updater name")
+		}
+	}
+
+	for _, run := range runs {
+		s.set(s.updaterRuns, s.newID(), run)
+	}
+
+	return nil
+}
+
+func (s *session) FindUpdaterRuns(updater string, limit int) ([]database.UpdaterRun, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		return nil, commonerr.NewBadRequestError("updater run limit should be positive")
+	}
+
+	ids := []int64{}
+	for id, run := range s.updaterRuns {
+		if run.Updater == updater {
+			ids = append(ids, id)
+		}
+	}
+
+	// The most recent runs first, in reverse insertion order when they
+	// started at the same time.
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := s.updaterRuns[ids[i]], s.updaterRuns[ids[j]]
+		if !a.Started.Equal(b.Started) {
+			return a.Started.After(b.Started)
+		}
+
+		return ids[i] > ids[j]
+	})
+
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	runs := make([]database.UpdaterRun, 0, len(ids))
+	for _, id := range ids {
+		runs = append(runs, s.updaterRuns[id])
+	}
+
+	return runs, nil
+}
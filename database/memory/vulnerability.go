@@ -0,0 +1,305 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+var (
+	errDuplicatedVulnerability = errors.New("inserting duplicated vulnerabilities is not allowed")
+	errVulnerabilityNotFound   = errors.New("vulnerability is not in database")
+)
+
+func vulnerabilityID(v database.Vulnerability) database.VulnerabilityID {
+	return database.VulnerabilityID{Name: v.Name, Namespace: v.Namespace.Name}
+}
+
+func (s *session) InsertVulnerabilities(vulnerabilities []database.VulnerabilityWithAffected) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	ids := map[database.VulnerabilityID]struct{}{}
+	for _, v := range vulnerabilities {
+		id := vulnerabilityID(v.Vulnerability)
+		if _, ok := ids[id]; ok {
+			return errDuplicatedVulnerability
+		}
+
+		if _, ok := s.liveVulnerabilities[id]; ok {
+			return errDuplicatedVulnerability
+		}
+
+		if !s.hasNamespace(v.Namespace) {
+			return database.ErrMissingEntities
+		}
+
+		ids[id] = struct{}{}
+	}
+
+	for _, v := range vulnerabilities {
+		id := s.newID()
+
+		// The affected features belong to the namespace of the vulnerability.
+		v.Affected = append([]database.AffectedFeature(nil), v.Affected...)
+		for i := range v.Affected {
+			v.Affected[i].Namespace = v.Namespace
+		}
+
+		s.set(s.vulnerabilities, id, v)
+		s.set(s.liveVulnerabilities, vulnerabilityID(v.Vulnerability), id)
+
+		candidates := map[database.NamespacedFeature]struct{}{}
+		for _, affected := range v.Affected {
+			key := affectedKey{v.Namespace, affected.FeatureName, affected.FeatureType}
+			affecting, ok := s.affecting[key]
+			if !ok {
+				affecting = map[int64]struct{}{}
+				s.set(s.affecting, key, affecting)
+			}
+			s.set(affecting, id, struct{}{})
+
+			for f := range s.featuresByName[key] {
+				candidates[f] = struct{}{}
+			}
+		}
+
+		for f := range candidates {
+			if err := s.cacheIfAffected(f, id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *session) FindVulnerabilities(ids []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := make([]database.NullableVulnerability, len(ids))
+	for i, id := range ids {
+		vulnerabilities[i].Name = id.Name
+		vulnerabilities[i].Namespace.Name = id.Namespace
+
+		row, ok := s.liveVulnerabilities[id]
+		if !ok {
+			continue
+		}
+
+		vulnerabilities[i].VulnerabilityWithAffected = s.vulnerabilities[row]
+		vulnerabilities[i].Affected = append([]database.AffectedFeature(nil), s.vulnerabilities[row].Affected...)
+		vulnerabilities[i].Valid = true
+	}
+
+	return vulnerabilities, nil
+}
+
+func (s *session) FindDeletedVulnerabilities(ids []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := make([]database.NullableVulnerability, len(ids))
+	for i, id := range ids {
+		vulnerabilities[i].Name = id.Name
+		vulnerabilities[i].Namespace.Name = id.Namespace
+
+		row, ok := s.deletedVulnerabilities[id]
+		if !ok {
+			continue
+		}
+
+		vulnerabilities[i].VulnerabilityWithAffected = s.vulnerabilities[row]
+		vulnerabilities[i].Affected = append([]database.AffectedFeature(nil), s.vulnerabilities[row].Affected...)
+		vulnerabilities[i].Valid = true
+	}
+
+	return vulnerabilities, nil
+}
+
+func (s *session) FindPagedVulnerabilities(namespace string, limit int, currentToken pagination.Token) (database.PagedVulnerabilities, error) {
+	vulnPage := database.PagedVulnerabilities{Limit: limit}
+	if err := s.check(); err != nil {
+		return vulnPage, err
+	}
+
+	// The rows of the vulnerabilities are their IDs.
+	var currentPage page
+	if currentToken != pagination.FirstPageToken {
+		if err := s.key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return vulnPage, err
+		}
+	}
+
+	rows := []int64{}
+	for id, row := range s.liveVulnerabilities {
+		if id.Namespace == namespace && row >= currentPage.StartID {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i] < rows[j] })
+
+	// The first vulnerability after the page is used as the next page's start.
+	var err error
+	if len(rows) > limit {
+		vulnPage.Next, err = s.key.MarshalToken(page{StartID: rows[limit]})
+		if err != nil {
+			return vulnPage, err
+		}
+
+		rows = rows[:limit]
+	} else {
+		vulnPage.End = true
+	}
+
+	for _, row := range rows {
+		v := s.vulnerabilities[row]
+		v.Affected = append([]database.AffectedFeature(nil), v.Affected...)
+		vulnPage.Vulnerabilities = append(vulnPage.Vulnerabilities, v)
+	}
+
+	vulnPage.Current, err = s.key.MarshalToken(currentPage)
+	if err != nil {
+		return vulnPage, err
+	}
+
+	return vulnPage, nil
+}
+
+func (s *session) FindAffectedAncestries(vulnerability database.VulnerabilityID, limit int, currentToken pagination.Token) (database.PagedAffectedAncestries, bool, error) {
+	ancestryPage := database.PagedAffectedAncestries{Limit: limit}
+	if err := s.check(); err != nil {
+		return ancestryPage, false, err
+	}
+
+	id, ok := s.liveVulnerabilities[vulnerability]
+	if !ok {
+		return ancestryPage, false, nil
+	}
+
+	var currentPage page
+	if currentToken != pagination.FirstPageToken {
+		if err := s.key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return ancestryPage, false, err
+		}
+	}
+
+	ancestries := []ancestry{}
+	for _, a := range s.ancestries {
+		if a.id >= currentPage.StartID && s.affectsAncestry(id, a.Ancestry) {
+			ancestries = append(ancestries, a)
+		}
+	}
+
+	sort.Slice(ancestries, func(i, j int) bool { return ancestries[i].id < ancestries[j].id })
+
+	// The first ancestry after the page is used as the next page's start.
+	var err error
+	if len(ancestries) > limit {
+		ancestryPage.Next, err = s.key.MarshalToken(page{StartID: ancestries[limit].id})
+		if err != nil {
+			return ancestryPage, false, err
+		}
+
+		ancestries = ancestries[:limit]
+	} else {
+		ancestryPage.End = true
+	}
+
+	for _, a := range ancestries {
+		ancestryPage.Ancestries = append(ancestryPage.Ancestries, database.AffectedAncestry{
+			Name:     a.Name,
+			Features: s.affectedAncestryFeatures(id, a.Ancestry),
+		})
+	}
+
+	ancestryPage.Current, err = s.key.MarshalToken(currentPage)
+	if err != nil {
+		return ancestryPage, false, err
+	}
+
+	return ancestryPage, true, nil
+}
+
+// affectedAncestryFeatures returns the features of the ancestry affected by
+// the vulnerability stored at row id, sorted by name and version.
+func (s *session) affectedAncestryFeatures(id int64, a database.Ancestry) []database.NamespacedFeature {
+	affected := s.affectedBy[id]
+	seen := map[database.NamespacedFeature]struct{}{}
+	features := []database.NamespacedFeature{}
+	for _, l := range a.Layers {
+		for _, f := range l.Features {
+			if _, ok := affected[f.NamespacedFeature]; !ok {
+				continue
+			}
+
+			if _, ok := seen[f.NamespacedFeature]; !ok {
+				seen[f.NamespacedFeature] = struct{}{}
+				features = append(features, f.NamespacedFeature)
+			}
+		}
+	}
+
+	sort.Slice(features, func(i, j int) bool {
+		if features[i].Name != features[j].Name {
+			return features[i].Name < features[j].Name
+		}
+		return features[i].Version < features[j].Version
+	})
+
+	return features
+}
+
+func (s *session) DeleteVulnerabilities(ids []database.VulnerabilityID) error {
+	if err := s.check(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, ok := s.liveVulnerabilities[id]; !ok {
+			return errVulnerabilityNotFound
+		}
+	}
+
+	for _, id := range ids {
+		row, ok := s.liveVulnerabilities[id]
+		if !ok {
+			// Deleted twice in the same call.
+			continue
+		}
+
+		s.remove(s.liveVulnerabilities, id)
+		s.set(s.deletedVulnerabilities, id, row)
+
+		v := s.vulnerabilities[row]
+		for _, affected := range v.Affected {
+			key := affectedKey{v.Namespace, affected.FeatureName, affected.FeatureType}
+			s.remove(s.affecting[key], row)
+		}
+
+		s.retireAffected(row)
+	}
+
+	return nil
+}
@@ -27,8 +27,14 @@ type MockSession struct {
 	FctRollback                         func() error
 	FctUpsertAncestry                   func(Ancestry) error
 	FctFindAncestry                     func(name string) (Ancestry, bool, error)
+	FctDeleteAncestry                   func(name string) (bool, error)
+	FctUpsertAncestrySource             func(AncestrySource) error
+	FctFindAncestrySource               func(name string) (AncestrySource, bool, error)
+	FctFindOutdatedAncestries           func(detectors []Detector) ([]string, error)
 	FctFindAffectedNamespacedFeatures   func(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error)
 	FctPersistNamespaces                func([]Namespace) error
+	FctFindNamespaces                   func() ([]NamespaceWithVulnerabilityCount, error)
+	FctFindStatistics                   func() (Statistics, error)
 	FctPersistFeatures                  func([]Feature) error
 	FctPersistDetectors                 func(detectors []Detector) error
 	FctPersistNamespacedFeatures        func([]NamespacedFeature) error
@@ -37,18 +43,36 @@ type MockSession struct {
 	FctFindLayer                        func(name string) (Layer, bool, error)
 	FctInsertVulnerabilities            func([]VulnerabilityWithAffected) error
 	FctFindVulnerabilities              func([]VulnerabilityID) ([]NullableVulnerability, error)
+	FctFindDeletedVulnerabilities       func([]VulnerabilityID) ([]NullableVulnerability, error)
+	FctFindPagedVulnerabilities         func(namespace string, limit int, page pagination.Token) (PagedVulnerabilities, error)
+	FctFindAffectedAncestries           func(vulnerability VulnerabilityID, limit int, page pagination.Token) (PagedAffectedAncestries, bool, error)
 	FctDeleteVulnerabilities            func([]VulnerabilityID) error
 	FctInsertVulnerabilityNotifications func([]VulnerabilityNotification) error
 	FctFindNewNotification              func(lastNotified time.Time) (NotificationHook, bool, error)
 	FctFindVulnerabilityNotification    func(name string, limit int, oldPage pagination.Token, newPage pagination.Token) (
 		vuln VulnerabilityNotificationWithVulnerable, ok bool, err error)
-	FctMarkNotificationAsRead func(name string) error
-	FctDeleteNotification     func(name string) error
-	FctUpdateKeyValue         func(key, value string) error
-	FctFindKeyValue           func(key string) (string, bool, error)
-	FctAcquireLock            func(name, owner string, duration time.Duration) (bool, time.Time, error)
-	FctExtendLock             func(name, owner string, duration time.Duration) (bool, time.Time, error)
-	FctReleaseLock            func(name, owner string) error
+	FctFindNotifications             func(state NotificationState, limit int, page pagination.Token) (PagedNotifications, error)
+	FctMarkNotificationAsRead        func(name string) error
+	FctMarkNotificationAsFiltered    func(name string) error
+	FctDeleteNotification            func(name string) error
+	FctInsertDeadLetterNotification  func(DeadLetterNotification) error
+	FctFindDeadLetterNotifications   func() ([]DeadLetterNotification, error)
+	FctRequeueDeadLetterNotification func(name string) (bool, error)
+	FctUpdateKeyValue                func(key, value string) error
+	FctUpdateKeyValueWithTTL         func(key, value string, ttl time.Duration) error
+	FctFindKeyValue                  func(key string) (string, bool, error)
+	FctPruneKeyValues                func() (int, error)
+	FctInsertUpdaterRuns             func(runs []UpdaterRun) error
+	FctFindUpdaterRuns               func(updater string, limit int) ([]UpdaterRun, error)
+	FctInsertSuppressionRule         func(rule SuppressionRule) (int64, error)
+	FctFindSuppressionRule           func(id int64) (SuppressionRule, bool, error)
+	FctFindSuppressionRules          func() (SuppressionRules, error)
+	FctUpdateSuppressionRule         func(rule SuppressionRule) (bool, error)
+	FctDeleteSuppressionRule         func(id int64) (bool, error)
+	FctAcquireLock                   func(name, owner string, duration time.Duration) (bool, time.Time, error)
+	FctExtendLock                    func(name, owner string, duration time.Duration) (bool, time.Time, error)
+	FctReleaseLock                   func(name, owner string) error
+	FctPruneLocks                    func() (int, error)
 }
 
 func (ms *MockSession) Commit() error {
@@ -79,6 +103,34 @@ func (ms *MockSession) FindAncestry(name string) (Ancestry, bool, error) {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) DeleteAncestry(name string) (bool, error) {
+	if ms.FctDeleteAncestry != nil {
+		return ms.FctDeleteAncestry(name)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) UpsertAncestrySource(source AncestrySource) error {
+	if ms.FctUpsertAncestrySource != nil {
+		return ms.FctUpsertAncestrySource(source)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindAncestrySource(name string) (AncestrySource, bool, error) {
+	if ms.FctFindAncestrySource != nil {
+		return ms.FctFindAncestrySource(name)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindOutdatedAncestries(detectors []Detector) ([]string, error) {
+	if ms.FctFindOutdatedAncestries != nil {
+		return ms.FctFindOutdatedAncestries(detectors)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) FindAffectedNamespacedFeatures(features []NamespacedFeature) ([]NullableAffectedNamespacedFeature, error) {
 	if ms.FctFindAffectedNamespacedFeatures != nil {
 		return ms.FctFindAffectedNamespacedFeatures(features)
@@ -100,6 +152,20 @@ func (ms *MockSession) PersistNamespaces(namespaces []Namespace) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindNamespaces() ([]NamespaceWithVulnerabilityCount, error) {
+	if ms.FctFindNamespaces != nil {
+		return ms.FctFindNamespaces()
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindStatistics() (Statistics, error) {
+	if ms.FctFindStatistics != nil {
+		return ms.FctFindStatistics()
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) PersistFeatures(features []Feature) error {
 	if ms.FctPersistFeatures != nil {
 		return ms.FctPersistFeatures(features)
@@ -149,6 +215,27 @@ func (ms *MockSession) FindVulnerabilities(vulnerabilityIDs []VulnerabilityID) (
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindDeletedVulnerabilities(vulnerabilityIDs []VulnerabilityID) ([]NullableVulnerability, error) {
+	if ms.FctFindDeletedVulnerabilities != nil {
+		return ms.FctFindDeletedVulnerabilities(vulnerabilityIDs)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindPagedVulnerabilities(namespace string, limit int, page pagination.Token) (PagedVulnerabilities, error) {
+	if ms.FctFindPagedVulnerabilities != nil {
+		return ms.FctFindPagedVulnerabilities(namespace, limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindAffectedAncestries(vulnerability VulnerabilityID, limit int, page pagination.Token) (PagedAffectedAncestries, bool, error) {
+	if ms.FctFindAffectedAncestries != nil {
+		return ms.FctFindAffectedAncestries(vulnerability, limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) DeleteVulnerabilities(VulnerabilityIDs []VulnerabilityID) error {
 	if ms.FctDeleteVulnerabilities != nil {
 		return ms.FctDeleteVulnerabilities(VulnerabilityIDs)
@@ -178,6 +265,13 @@ func (ms *MockSession) FindVulnerabilityNotification(name string, limit int, old
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) FindNotifications(state NotificationState, limit int, page pagination.Token) (PagedNotifications, error) {
+	if ms.FctFindNotifications != nil {
+		return ms.FctFindNotifications(state, limit, page)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) MarkNotificationAsRead(name string) error {
 	if ms.FctMarkNotificationAsRead != nil {
 		return ms.FctMarkNotificationAsRead(name)
@@ -185,6 +279,13 @@ func (ms *MockSession) MarkNotificationAsRead(name string) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) MarkNotificationAsFiltered(name string) error {
+	if ms.FctMarkNotificationAsFiltered != nil {
+		return ms.FctMarkNotificationAsFiltered(name)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) DeleteNotification(name string) error {
 	if ms.FctDeleteNotification != nil {
 		return ms.FctDeleteNotification(name)
@@ -192,6 +293,27 @@ func (ms *MockSession) DeleteNotification(name string) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) InsertDeadLetterNotification(deadLetter DeadLetterNotification) error {
+	if ms.FctInsertDeadLetterNotification != nil {
+		return ms.FctInsertDeadLetterNotification(deadLetter)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindDeadLetterNotifications() ([]DeadLetterNotification, error) {
+	if ms.FctFindDeadLetterNotifications != nil {
+		return ms.FctFindDeadLetterNotifications()
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) RequeueDeadLetterNotification(name string) (bool, error) {
+	if ms.FctRequeueDeadLetterNotification != nil {
+		return ms.FctRequeueDeadLetterNotification(name)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) UpdateKeyValue(key, value string) error {
 	if ms.FctUpdateKeyValue != nil {
 		return ms.FctUpdateKeyValue(key, value)
@@ -199,6 +321,13 @@ func (ms *MockSession) UpdateKeyValue(key, value string) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) UpdateKeyValueWithTTL(key, value string, ttl time.Duration) error {
+	if ms.FctUpdateKeyValueWithTTL != nil {
+		return ms.FctUpdateKeyValueWithTTL(key, value, ttl)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) FindKeyValue(key string) (string, bool, error) {
 	if ms.FctFindKeyValue != nil {
 		return ms.FctFindKeyValue(key)
@@ -206,6 +335,62 @@ func (ms *MockSession) FindKeyValue(key string) (string, bool, error) {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) PruneKeyValues() (int, error) {
+	if ms.FctPruneKeyValues != nil {
+		return ms.FctPruneKeyValues()
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) InsertUpdaterRuns(runs []UpdaterRun) error {
+	if ms.FctInsertUpdaterRuns != nil {
+		return ms.FctInsertUpdaterRuns(runs)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindUpdaterRuns(updater string, limit int) ([]UpdaterRun, error) {
+	if ms.FctFindUpdaterRuns != nil {
+		return ms.FctFindUpdaterRuns(updater, limit)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) InsertSuppressionRule(rule SuppressionRule) (int64, error) {
+	if ms.FctInsertSuppressionRule != nil {
+		return ms.FctInsertSuppressionRule(rule)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindSuppressionRule(id int64) (SuppressionRule, bool, error) {
+	if ms.FctFindSuppressionRule != nil {
+		return ms.FctFindSuppressionRule(id)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) FindSuppressionRules() (SuppressionRules, error) {
+	if ms.FctFindSuppressionRules != nil {
+		return ms.FctFindSuppressionRules()
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) UpdateSuppressionRule(rule SuppressionRule) (bool, error) {
+	if ms.FctUpdateSuppressionRule != nil {
+		return ms.FctUpdateSuppressionRule(rule)
+	}
+	panic("required mock function not implemented")
+}
+
+func (ms *MockSession) DeleteSuppressionRule(id int64) (bool, error) {
+	if ms.FctDeleteSuppressionRule != nil {
+		return ms.FctDeleteSuppressionRule(id)
+	}
+	panic("required mock function not implemented")
+}
+
 func (ms *MockSession) AcquireLock(name, owner string, duration time.Duration) (bool, time.Time, error) {
 	if ms.FctAcquireLock != nil {
 		return ms.FctAcquireLock(name, owner, duration)
@@ -227,12 +412,20 @@ func (ms *MockSession) ReleaseLock(name, owner string) error {
 	panic("required mock function not implemented")
 }
 
+func (ms *MockSession) PruneLocks() (int, error) {
+	if ms.FctPruneLocks != nil {
+		return ms.FctPruneLocks()
+	}
+	panic("required mock function not implemented")
+}
+
 // MockDatastore implements Datastore and enables overriding each available method.
 // The default behavior of each method is to simply panic.
 type MockDatastore struct {
-	FctBegin func() (Session, error)
-	FctPing  func() bool
-	FctClose func()
+	FctBegin         func() (Session, error)
+	FctBeginReadOnly func() (Session, error)
+	FctPing          func() bool
+	FctClose         func()
 }
 
 func (mds *MockDatastore) Begin() (Session, error) {
@@ -242,6 +435,14 @@ func (mds *MockDatastore) Begin() (Session, error) {
 	panic("required mock function not implemented")
 }
 
+// BeginReadOnly falls back to Begin when FctBeginReadOnly is not set.
+func (mds *MockDatastore) BeginReadOnly() (Session, error) {
+	if mds.FctBeginReadOnly != nil {
+		return mds.FctBeginReadOnly()
+	}
+	return mds.Begin()
+}
+
 func (mds *MockDatastore) Ping() bool {
 	if mds.FctPing != nil {
 		return mds.FctPing()
@@ -32,3 +32,11 @@ func (ns *Namespace) Valid() bool {
 	}
 	return true
 }
+
+// NamespaceWithVulnerabilityCount is a namespace with the number of its
+// vulnerabilities.
+type NamespaceWithVulnerabilityCount struct {
+	Namespace
+
+	VulnerabilityCount int
+}
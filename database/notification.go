@@ -30,6 +30,91 @@ type NotificationHook struct {
 	Created  time.Time
 	Notified time.Time
 	Deleted  time.Time
+
+	// Filtered is whether the notifier filtered the notification out, marking
+	// it as notified without sending it.
+	Filtered bool
+}
+
+// DeadLetterNotification is a notification hook that the notifier failed to
+// send after exhausting its attempts. It is not sent again until it is
+// requeued.
+type DeadLetterNotification struct {
+	Name string
+
+	// Payload is the serialized notification hook.
+	Payload string
+	// LastError is the error of the last attempt to send the notification.
+	LastError string
+
+	Created time.Time
+}
+
+// NotificationState is the delivery state of a notification.
+type NotificationState string
+
+const (
+	// PendingNotification is the state of the notifications which are neither
+	// marked as notified nor deleted, including the dead-lettered ones.
+	PendingNotification NotificationState = "pending"
+	// DeliveredNotification is the state of the notifications which are
+	// marked as notified but not deleted yet.
+	DeliveredNotification NotificationState = "delivered"
+	// ExpiredNotification is the state of the deleted notifications, which
+	// are marked as read by their client or garbage collected.
+	ExpiredNotification NotificationState = "expired"
+)
+
+// NotificationStates contains all notification states.
+var NotificationStates = []NotificationState{
+	PendingNotification,
+	DeliveredNotification,
+	ExpiredNotification,
+}
+
+// Valid checks if a notification state is defined.
+func (s NotificationState) Valid() bool {
+	for _, state := range NotificationStates {
+		if s == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// State returns the delivery state of the notification.
+func (hook NotificationHook) State() NotificationState {
+	switch {
+	case !hook.Deleted.IsZero():
+		return ExpiredNotification
+	case !hook.Notified.IsZero():
+		return DeliveredNotification
+	default:
+		return PendingNotification
+	}
+}
+
+// NotificationSummary is a notification hook with the vulnerability whose
+// change it notifies: the new vulnerability, or the old one when it was
+// removed.
+type NotificationSummary struct {
+	NotificationHook
+
+	Vulnerability VulnerabilityID
+}
+
+// PagedNotifications is a page of the notifications in a state, ordered by
+// their storage order. The current and next page tokens are for navigation.
+type PagedNotifications struct {
+	Notifications []NotificationSummary
+
+	Limit   int
+	Current pagination.Token
+	Next    pagination.Token
+
+	// End signals the end of the pages.
+	End bool
 }
 
 // VulnerabilityNotification is a notification for vulnerability changes.
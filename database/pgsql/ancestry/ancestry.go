@@ -114,6 +114,26 @@ func UpsertAncestry(tx *sql.Tx, ancestry database.Ancestry) error {
 	return nil
 }
 
+// DeleteAncestry removes an ancestry, its layers, features, detectors and
+// source being removed along with it. The layers themselves are kept.
+func DeleteAncestry(tx *sql.Tx, name string) (bool, error) {
+	if err := RemoveAncestrySource(tx, name); err != nil {
+		return false, err
+	}
+
+	result, err := tx.Exec(removeAncestry, name)
+	if err != nil {
+		return false, util.HandleError("removeAncestry", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, util.HandleError("removeAncestry", err)
+	}
+
+	return affected != 0, nil
+}
+
 func InsertAncestry(tx *sql.Tx, name string) (int64, error) {
 	var id int64
 	err := tx.QueryRow(insertAncestry, name).Scan(&id)
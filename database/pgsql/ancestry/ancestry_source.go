@@ -0,0 +1,128 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ancestry
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/util"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+const (
+	upsertAncestrySource = `
+		INSERT INTO ancestry_source (name, format, layers) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET format = EXCLUDED.format, layers = EXCLUDED.layers`
+
+	findAncestrySource   = `SELECT format, layers FROM ancestry_source WHERE name = $1`
+	removeAncestrySource = `DELETE FROM ancestry_source WHERE name = $1`
+
+	// searchOutdatedAncestries compares the detectors of every ancestry with
+	// the given set of "type:name:version" keys.
+	searchOutdatedAncestries = `
+		SELECT a.name
+		FROM ancestry AS a, LATERAL (
+			SELECT ARRAY(
+				SELECT d.dtype::TEXT || ':' || d.name || ':' || d.version
+				FROM ancestry_detector AS ad, detector AS d
+				WHERE ad.ancestry_id = a.id AND ad.detector_id = d.id) AS keys
+		) AS ad
+		WHERE NOT (ad.keys @> $1::TEXT[] AND ad.keys <@ $1::TEXT[])
+		ORDER BY a.id`
+)
+
+// UpsertAncestrySource stores the layer references of an ancestry, replacing
+// any previous ones.
+func UpsertAncestrySource(tx *sql.Tx, source database.AncestrySource) error {
+	if source.Name == "" {
+		return commonerr.NewBadRequestError("ancestry source should not have empty name")
+	}
+
+	layers, err := json.Marshal(source.Layers)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(upsertAncestrySource, source.Name, source.Format, string(layers)); err != nil {
+		return util.HandleError("upsertAncestrySource", err)
+	}
+
+	return nil
+}
+
+// FindAncestrySource retrieves the layer references of an ancestry.
+func FindAncestrySource(tx *sql.Tx, name string) (database.AncestrySource, bool, error) {
+	source := database.AncestrySource{Name: name}
+
+	var layers string
+	if err := tx.QueryRow(findAncestrySource, name).Scan(&source.Format, &layers); err != nil {
+		if err == sql.ErrNoRows {
+			return database.AncestrySource{}, false, nil
+		}
+
+		return database.AncestrySource{}, false, util.HandleError("findAncestrySource", err)
+	}
+
+	if err := json.Unmarshal([]byte(layers), &source.Layers); err != nil {
+		return database.AncestrySource{}, false, err
+	}
+
+	return source, true, nil
+}
+
+// RemoveAncestrySource removes the layer references of an ancestry, if any.
+func RemoveAncestrySource(tx *sql.Tx, name string) error {
+	if _, err := tx.Exec(removeAncestrySource, name); err != nil {
+		return util.HandleError("removeAncestrySource", err)
+	}
+
+	return nil
+}
+
+// FindOutdatedAncestries returns the names of the ancestries which weren't
+// processed by exactly the given detectors, in insertion order.
+func FindOutdatedAncestries(tx *sql.Tx, detectors []database.Detector) ([]string, error) {
+	keys := make([]string, 0, len(detectors))
+	for _, d := range detectors {
+		keys = append(keys, string(d.DType)+":"+d.Name+":"+d.Version)
+	}
+
+	rows, err := tx.Query(searchOutdatedAncestries, pq.Array(keys))
+	if err != nil {
+		return nil, util.HandleError("searchOutdatedAncestries", err)
+	}
+
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, util.HandleError("searchOutdatedAncestries", err)
+		}
+
+		names = append(names, name)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchOutdatedAncestries", err)
+	}
+
+	return names, nil
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/layer"
 	"github.com/quay/clair/v3/database/pgsql/testutil"
 )
 
@@ -139,3 +140,76 @@ func TestFindAncestry(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteAncestry(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "TestDeleteAncestry")
+	defer cleanup()
+
+	ok, err := DeleteAncestry(tx, "ancestry-1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = FindAncestry(tx, "ancestry-1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// Deleting an ancestry twice doesn't fail.
+	ok, err = DeleteAncestry(tx, "ancestry-1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// The layers are kept, along with the other ancestries using them.
+	_, ok, err = layer.FindLayer(tx, "layer-3a")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	ancestry, ok, err := FindAncestry(tx, "ancestry-2")
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		database.AssertAncestryEqual(t, testutil.TakeAncestryPointerFromMap(testutil.RealAncestries, 2), &ancestry)
+	}
+}
+
+func TestAncestrySource(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "TestAncestrySource")
+	defer cleanup()
+
+	source := database.AncestrySource{
+		Name:   "ancestry-1",
+		Format: "docker",
+		Layers: []database.LayerSource{
+			{Hash: "layer-0", Path: "https://example.com/layer-0", Headers: map[string]string{"Authorization": "Bearer token"}},
+			{Hash: "layer-1", Path: "https://example.com/layer-1"},
+		},
+	}
+	assert.Nil(t, UpsertAncestrySource(tx, source))
+
+	source.Format = "appc"
+	assert.Nil(t, UpsertAncestrySource(tx, source))
+
+	found, ok, err := FindAncestrySource(tx, "ancestry-1")
+	assert.Nil(t, err)
+	if assert.True(t, ok) {
+		assert.Equal(t, source, found)
+	}
+
+	ok, err = DeleteAncestry(tx, "ancestry-1")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = FindAncestrySource(tx, "ancestry-1")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestFindOutdatedAncestries(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "TestFindOutdatedAncestries")
+	defer cleanup()
+
+	names, err := FindOutdatedAncestries(tx, []database.Detector{testutil.RealDetectors[2], testutil.RealDetectors[1]})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ancestry-3", "ancestry-4"}, names)
+
+	names, err = FindOutdatedAncestries(tx, []database.Detector{testutil.RealDetectors[1], testutil.RealDetectors[2], testutil.RealDetectors[3]})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ancestry-1", "ancestry-2", "ancestry-3", "ancestry-4"}, names)
+}
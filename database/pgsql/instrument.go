@@ -0,0 +1,73 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsql
+
+//go:generate go run ./instrumentgen -src ../database.go -o instrumented.go
+
+import (
+	"reflect"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
+)
+
+// Enforce the interface at compile time.
+var _ database.Session = &instrumentedSession{}
+
+// instrumentedSession is a session recording the duration of every operation,
+// and logging the operations slower than its slow query threshold.
+//
+// Its methods are generated from the database.Session interface.
+type instrumentedSession struct {
+	session database.Session
+
+	// slowQueryThreshold is the duration from which operations are logged.
+	// No operation is logged when it is 0.
+	slowQueryThreshold time.Duration
+}
+
+// observe records the duration of an operation started at start. The values
+// of its parameters and results are only computed when it is logged.
+func (s *instrumentedSession) observe(operation string, start time.Time, values func() []interface{}) {
+	elapsed := time.Since(start)
+	monitoring.ObserveOperationTime(operation, elapsed)
+
+	if s.slowQueryThreshold <= 0 || elapsed < s.slowQueryThreshold {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"operation": operation,
+		"duration":  elapsed,
+		"rows":      rowCount(values()...),
+	}).Warning("pgsql: slow datastore operation")
+}
+
+// rowCount returns the number of rows an operation was given or returned,
+// i.e. the elements of its slices and maps.
+func rowCount(values ...interface{}) int {
+	rows := 0
+	for _, v := range values {
+		switch rv := reflect.ValueOf(v); rv.Kind() {
+		case reflect.Slice, reflect.Map:
+			rows += rv.Len()
+		}
+	}
+
+	return rows
+}
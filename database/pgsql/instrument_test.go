@@ -0,0 +1,111 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+)
+
+// operationCount returns how many times an operation was recorded.
+func operationCount(t *testing.T, operation string) uint64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.Nil(t, err)
+
+	for _, family := range families {
+		if family.GetName() != "clair_pgsql_operation_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" && label.GetValue() == operation {
+					return metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestInstrumentedSessionRecordsOperations(t *testing.T) {
+	session := &instrumentedSession{session: &database.MockSession{
+		FctPersistLayer: func(string, []database.LayerFeature, []database.LayerNamespace, []database.Detector) error {
+			return nil
+		},
+		FctFindAncestry: func(string) (database.Ancestry, bool, error) {
+			return database.Ancestry{}, false, nil
+		},
+		FctInsertVulnerabilities: func([]database.VulnerabilityWithAffected) error {
+			return nil
+		},
+	}}
+
+	operations := []string{"persistLayer", "findAncestry", "insertVulnerabilities"}
+	before := make(map[string]uint64)
+	for _, operation := range operations {
+		before[operation] = operationCount(t, operation)
+	}
+
+	require.Nil(t, session.PersistLayer("layer", nil, nil, nil))
+	_, _, err := session.FindAncestry("ancestry")
+	require.Nil(t, err)
+	require.Nil(t, session.InsertVulnerabilities(nil))
+
+	for _, operation := range operations {
+		assert.Equal(t, before[operation]+1, operationCount(t, operation), operation)
+	}
+}
+
+func TestInstrumentedSessionLogsSlowOperations(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	session := &instrumentedSession{slowQueryThreshold: 10 * time.Millisecond, session: &database.MockSession{
+		FctInsertVulnerabilities: func([]database.VulnerabilityWithAffected) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+		FctFindAncestry: func(string) (database.Ancestry, bool, error) {
+			return database.Ancestry{}, false, nil
+		},
+	}}
+
+	// Fast operations aren't logged.
+	_, _, err := session.FindAncestry("ancestry")
+	require.Nil(t, err)
+	assert.Empty(t, hook.AllEntries())
+
+	require.Nil(t, session.InsertVulnerabilities(make([]database.VulnerabilityWithAffected, 3)))
+	if entry := hook.LastEntry(); assert.NotNil(t, entry) {
+		assert.Equal(t, "insertVulnerabilities", entry.Data["operation"])
+		assert.Equal(t, 3, entry.Data["rows"])
+		assert.True(t, entry.Data["duration"].(time.Duration) >= 10*time.Millisecond)
+	}
+}
+
+func TestRowCount(t *testing.T) {
+	assert.Equal(t, 0, rowCount())
+	assert.Equal(t, 0, rowCount("name", 10, true, database.Ancestry{}))
+	assert.Equal(t, 5, rowCount([]int{1, 2}, map[string]int{"a": 1}, "name", []string{"a", "b"}))
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by instrumentgen. DO NOT EDIT.
+
+package pgsql
+
+import (
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+func (s *instrumentedSession) Commit() (r0 error) {
+	defer s.observe("commit", time.Now(), func() []interface{} { return []interface{}{} })
+	return s.session.Commit()
+}
+
+func (s *instrumentedSession) Rollback() (r0 error) {
+	defer s.observe("rollback", time.Now(), func() []interface{} { return []interface{}{} })
+	return s.session.Rollback()
+}
+
+func (s *instrumentedSession) UpsertAncestry(a0 database.Ancestry) (r0 error) {
+	defer s.observe("upsertAncestry", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.UpsertAncestry(a0)
+}
+
+func (s *instrumentedSession) FindAncestry(name string) (r0 database.Ancestry, r1 bool, r2 error) {
+	defer s.observe("findAncestry", time.Now(), func() []interface{} { return []interface{}{name, r0, r1} })
+	return s.session.FindAncestry(name)
+}
+
+func (s *instrumentedSession) DeleteAncestry(name string) (r0 bool, r1 error) {
+	defer s.observe("deleteAncestry", time.Now(), func() []interface{} { return []interface{}{name, r0} })
+	return s.session.DeleteAncestry(name)
+}
+
+func (s *instrumentedSession) UpsertAncestrySource(a0 database.AncestrySource) (r0 error) {
+	defer s.observe("upsertAncestrySource", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.UpsertAncestrySource(a0)
+}
+
+func (s *instrumentedSession) FindAncestrySource(name string) (r0 database.AncestrySource, r1 bool, r2 error) {
+	defer s.observe("findAncestrySource", time.Now(), func() []interface{} { return []interface{}{name, r0, r1} })
+	return s.session.FindAncestrySource(name)
+}
+
+func (s *instrumentedSession) FindOutdatedAncestries(detectors []database.Detector) (r0 []string, r1 error) {
+	defer s.observe("findOutdatedAncestries", time.Now(), func() []interface{} { return []interface{}{detectors, r0} })
+	return s.session.FindOutdatedAncestries(detectors)
+}
+
+func (s *instrumentedSession) PersistDetectors(detectors []database.Detector) (r0 error) {
+	defer s.observe("persistDetectors", time.Now(), func() []interface{} { return []interface{}{detectors} })
+	return s.session.PersistDetectors(detectors)
+}
+
+func (s *instrumentedSession) PersistFeatures(features []database.Feature) (r0 error) {
+	defer s.observe("persistFeatures", time.Now(), func() []interface{} { return []interface{}{features} })
+	return s.session.PersistFeatures(features)
+}
+
+func (s *instrumentedSession) PersistNamespacedFeatures(a0 []database.NamespacedFeature) (r0 error) {
+	defer s.observe("persistNamespacedFeatures", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.PersistNamespacedFeatures(a0)
+}
+
+func (s *instrumentedSession) CacheAffectedNamespacedFeatures(a0 []database.NamespacedFeature) (r0 error) {
+	defer s.observe("cacheAffectedNamespacedFeatures", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.CacheAffectedNamespacedFeatures(a0)
+}
+
+func (s *instrumentedSession) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) (r0 []database.NullableAffectedNamespacedFeature, r1 error) {
+	defer s.observe("findAffectedNamespacedFeatures", time.Now(), func() []interface{} { return []interface{}{features, r0} })
+	return s.session.FindAffectedNamespacedFeatures(features)
+}
+
+func (s *instrumentedSession) PersistNamespaces(a0 []database.Namespace) (r0 error) {
+	defer s.observe("persistNamespaces", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.PersistNamespaces(a0)
+}
+
+func (s *instrumentedSession) FindNamespaces() (r0 []database.NamespaceWithVulnerabilityCount, r1 error) {
+	defer s.observe("findNamespaces", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.FindNamespaces()
+}
+
+func (s *instrumentedSession) FindStatistics() (r0 database.Statistics, r1 error) {
+	defer s.observe("findStatistics", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.FindStatistics()
+}
+
+func (s *instrumentedSession) PersistLayer(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) (r0 error) {
+	defer s.observe("persistLayer", time.Now(), func() []interface{} { return []interface{}{hash, features, namespaces, detectedBy} })
+	return s.session.PersistLayer(hash, features, namespaces, detectedBy)
+}
+
+func (s *instrumentedSession) FindLayer(hash string) (r0 database.Layer, r1 bool, r2 error) {
+	defer s.observe("findLayer", time.Now(), func() []interface{} { return []interface{}{hash, r0, r1} })
+	return s.session.FindLayer(hash)
+}
+
+func (s *instrumentedSession) InsertVulnerabilities(a0 []database.VulnerabilityWithAffected) (r0 error) {
+	defer s.observe("insertVulnerabilities", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.InsertVulnerabilities(a0)
+}
+
+func (s *instrumentedSession) FindVulnerabilities(a0 []database.VulnerabilityID) (r0 []database.NullableVulnerability, r1 error) {
+	defer s.observe("findVulnerabilities", time.Now(), func() []interface{} { return []interface{}{a0, r0} })
+	return s.session.FindVulnerabilities(a0)
+}
+
+func (s *instrumentedSession) FindDeletedVulnerabilities(a0 []database.VulnerabilityID) (r0 []database.NullableVulnerability, r1 error) {
+	defer s.observe("findDeletedVulnerabilities", time.Now(), func() []interface{} { return []interface{}{a0, r0} })
+	return s.session.FindDeletedVulnerabilities(a0)
+}
+
+func (s *instrumentedSession) FindPagedVulnerabilities(namespace string, limit int, page pagination.Token) (r0 database.PagedVulnerabilities, r1 error) {
+	defer s.observe("findPagedVulnerabilities", time.Now(), func() []interface{} { return []interface{}{namespace, limit, page, r0} })
+	return s.session.FindPagedVulnerabilities(namespace, limit, page)
+}
+
+func (s *instrumentedSession) FindAffectedAncestries(vulnerability database.VulnerabilityID, limit int, page pagination.Token) (r0 database.PagedAffectedAncestries, r1 bool, r2 error) {
+	defer s.observe("findAffectedAncestries", time.Now(), func() []interface{} { return []interface{}{vulnerability, limit, page, r0, r1} })
+	return s.session.FindAffectedAncestries(vulnerability, limit, page)
+}
+
+func (s *instrumentedSession) DeleteVulnerabilities(a0 []database.VulnerabilityID) (r0 error) {
+	defer s.observe("deleteVulnerabilities", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.DeleteVulnerabilities(a0)
+}
+
+func (s *instrumentedSession) InsertVulnerabilityNotifications(a0 []database.VulnerabilityNotification) (r0 error) {
+	defer s.observe("insertVulnerabilityNotifications", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.InsertVulnerabilityNotifications(a0)
+}
+
+func (s *instrumentedSession) FindNewNotification(notifiedBefore time.Time) (r0 database.NotificationHook, r1 bool, r2 error) {
+	defer s.observe("findNewNotification", time.Now(), func() []interface{} { return []interface{}{notifiedBefore, r0, r1} })
+	return s.session.FindNewNotification(notifiedBefore)
+}
+
+func (s *instrumentedSession) FindVulnerabilityNotification(name string, limit int, oldVulnerabilityPage pagination.Token, newVulnerabilityPage pagination.Token) (r0 database.VulnerabilityNotificationWithVulnerable, r1 bool, r2 error) {
+	defer s.observe("findVulnerabilityNotification", time.Now(), func() []interface{} {
+		return []interface{}{name, limit, oldVulnerabilityPage, newVulnerabilityPage, r0, r1}
+	})
+	return s.session.FindVulnerabilityNotification(name, limit, oldVulnerabilityPage, newVulnerabilityPage)
+}
+
+func (s *instrumentedSession) FindNotifications(state database.NotificationState, limit int, page pagination.Token) (r0 database.PagedNotifications, r1 error) {
+	defer s.observe("findNotifications", time.Now(), func() []interface{} { return []interface{}{state, limit, page, r0} })
+	return s.session.FindNotifications(state, limit, page)
+}
+
+func (s *instrumentedSession) MarkNotificationAsRead(name string) (r0 error) {
+	defer s.observe("markNotificationAsRead", time.Now(), func() []interface{} { return []interface{}{name} })
+	return s.session.MarkNotificationAsRead(name)
+}
+
+func (s *instrumentedSession) MarkNotificationAsFiltered(name string) (r0 error) {
+	defer s.observe("markNotificationAsFiltered", time.Now(), func() []interface{} { return []interface{}{name} })
+	return s.session.MarkNotificationAsFiltered(name)
+}
+
+func (s *instrumentedSession) DeleteNotification(name string) (r0 error) {
+	defer s.observe("deleteNotification", time.Now(), func() []interface{} { return []interface{}{name} })
+	return s.session.DeleteNotification(name)
+}
+
+func (s *instrumentedSession) InsertDeadLetterNotification(a0 database.DeadLetterNotification) (r0 error) {
+	defer s.observe("insertDeadLetterNotification", time.Now(), func() []interface{} { return []interface{}{a0} })
+	return s.session.InsertDeadLetterNotification(a0)
+}
+
+func (s *instrumentedSession) FindDeadLetterNotifications() (r0 []database.DeadLetterNotification, r1 error) {
+	defer s.observe("findDeadLetterNotifications", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.FindDeadLetterNotifications()
+}
+
+func (s *instrumentedSession) RequeueDeadLetterNotification(name string) (r0 bool, r1 error) {
+	defer s.observe("requeueDeadLetterNotification", time.Now(), func() []interface{} { return []interface{}{name, r0} })
+	return s.session.RequeueDeadLetterNotification(name)
+}
+
+func (s *instrumentedSession) UpdateKeyValue(key string, value string) (r0 error) {
+	defer s.observe("updateKeyValue", time.Now(), func() []interface{} { return []interface{}{key, value} })
+	return s.session.UpdateKeyValue(key, value)
+}
+
+func (s *instrumentedSession) UpdateKeyValueWithTTL(key string, value string, ttl time.Duration) (r0 error) {
+	defer s.observe("updateKeyValueWithTTL", time.Now(), func() []interface{} { return []interface{}{key, value, ttl} })
+	return s.session.UpdateKeyValueWithTTL(key, value, ttl)
+}
+
+func (s *instrumentedSession) FindKeyValue(key string) (r0 string, r1 bool, r2 error) {
+	defer s.observe("findKeyValue", time.Now(), func() []interface{} { return []interface{}{key, r0, r1} })
+	return s.session.FindKeyValue(key)
+}
+
+func (s *instrumentedSession) PruneKeyValues() (r0 int, r1 error) {
+	defer s.observe("pruneKeyValues", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.PruneKeyValues()
+}
+
+func (s *instrumentedSession) InsertUpdaterRuns(runs []database.UpdaterRun) (r0 error) {
+	defer s.observe("insertUpdaterRuns", time.Now(), func() []interface{} { return []interface{}{runs} })
+	return s.session.InsertUpdaterRuns(runs)
+}
+
+func (s *instrumentedSession) FindUpdaterRuns(updater string, limit int) (r0 []database.UpdaterRun, r1 error) {
+	defer s.observe("findUpdaterRuns", time.Now(), func() []interface{} { return []interface{}{updater, limit, r0} })
+	return s.session.FindUpdaterRuns(updater, limit)
+}
+
+func (s *instrumentedSession) InsertSuppressionRule(rule database.SuppressionRule) (r0 int64, r1 error) {
+	defer s.observe("insertSuppressionRule", time.Now(), func() []interface{} { return []interface{}{rule, r0} })
+	return s.session.InsertSuppressionRule(rule)
+}
+
+func (s *instrumentedSession) FindSuppressionRule(id int64) (r0 database.SuppressionRule, r1 bool, r2 error) {
+	defer s.observe("findSuppressionRule", time.Now(), func() []interface{} { return []interface{}{id, r0, r1} })
+	return s.session.FindSuppressionRule(id)
+}
+
+func (s *instrumentedSession) FindSuppressionRules() (r0 database.SuppressionRules, r1 error) {
+	defer s.observe("findSuppressionRules", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.FindSuppressionRules()
+}
+
+func (s *instrumentedSession) UpdateSuppressionRule(rule database.SuppressionRule) (r0 bool, r1 error) {
+	defer s.observe("updateSuppressionRule", time.Now(), func() []interface{} { return []interface{}{rule, r0} })
+	return s.session.UpdateSuppressionRule(rule)
+}
+
+func (s *instrumentedSession) DeleteSuppressionRule(id int64) (r0 bool, r1 error) {
+	defer s.observe("deleteSuppressionRule", time.Now(), func() []interface{} { return []interface{}{id, r0} })
+	return s.session.DeleteSuppressionRule(id)
+}
+
+func (s *instrumentedSession) AcquireLock(name string, owner string, duration time.Duration) (r0 bool, r1 time.Time, r2 error) {
+	defer s.observe("acquireLock", time.Now(), func() []interface{} { return []interface{}{name, owner, duration, r0, r1} })
+	return s.session.AcquireLock(name, owner, duration)
+}
+
+func (s *instrumentedSession) ExtendLock(name string, owner string, duration time.Duration) (r0 bool, r1 time.Time, r2 error) {
+	defer s.observe("extendLock", time.Now(), func() []interface{} { return []interface{}{name, owner, duration, r0, r1} })
+	return s.session.ExtendLock(name, owner, duration)
+}
+
+func (s *instrumentedSession) ReleaseLock(name string, owner string) (r0 error) {
+	defer s.observe("releaseLock", time.Now(), func() []interface{} { return []interface{}{name, owner} })
+	return s.session.ReleaseLock(name, owner)
+}
+
+func (s *instrumentedSession) PruneLocks() (r0 int, r1 error) {
+	defer s.observe("pruneLocks", time.Now(), func() []interface{} { return []interface{}{r0} })
+	return s.session.PruneLocks()
+}
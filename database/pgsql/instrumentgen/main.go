@@ -0,0 +1,226 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command instrumentgen generates the instrumentedSession methods, wrapping
+// every method of database.Session.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// header is the license header of the generated file.
+const header = `// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+`
+
+func main() {
+	src := flag.String("src", "../database.go", "file declaring the database.Session interface")
+	out := flag.String("o", "instrumented.go", "generated file")
+	flag.Parse()
+
+	code, err := generate(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// generate returns the code of the instrumentedSession methods.
+func generate(src string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, src, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	session := findInterface(file, "Session")
+	if session == nil {
+		return nil, fmt.Errorf("%s: no Session interface", src)
+	}
+
+	imports := map[string]string{"time": "time"}
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imports[path[strings.LastIndex(path, "/")+1:]] = path
+	}
+
+	g := generator{fset: fset, used: map[string]string{"database": "github.com/quay/clair/v3/database", "time": "time"}, imports: imports}
+	var methods bytes.Buffer
+	for _, field := range session.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) != 1 {
+			return nil, fmt.Errorf("%s: unsupported Session member", fset.Position(field.Pos()))
+		}
+		g.method(&methods, field.Names[0].Name, fn)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	buf.WriteString("// Code generated by instrumentgen. DO NOT EDIT.\n\npackage pgsql\n\nimport (\n")
+	paths := make([]string, 0, len(g.used))
+	for _, path := range g.used {
+		paths = append(paths, path)
+	}
+	// Standard packages come first, as goimports groups them.
+	sort.Slice(paths, func(i, j int) bool {
+		if std := !strings.Contains(paths[i], "."); std != !strings.Contains(paths[j], ".") {
+			return std
+		}
+		return paths[i] < paths[j]
+	})
+	for i, path := range paths {
+		if i > 0 && strings.Contains(path, ".") && !strings.Contains(paths[i-1], ".") {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "%q\n", path)
+	}
+	buf.WriteString(")\n")
+	buf.Write(methods.Bytes())
+
+	return format.Source(buf.Bytes())
+}
+
+func findInterface(file *ast.File, name string) *ast.InterfaceType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			if ts := spec.(*ast.TypeSpec); ts.Name.Name == name {
+				iface, _ := ts.Type.(*ast.InterfaceType)
+				return iface
+			}
+		}
+	}
+
+	return nil
+}
+
+type generator struct {
+	fset    *token.FileSet
+	imports map[string]string
+	used    map[string]string
+}
+
+// method writes the wrapper of a method, observing its duration and the rows
+// of its parameters and results.
+func (g *generator) method(buf *bytes.Buffer, name string, fn *ast.FuncType) {
+	params, args, _ := g.fields(fn.Params, "a", true)
+	results, values, types := g.fields(fn.Results, "r", false)
+
+	var observed []string
+	for _, arg := range args {
+		observed = append(observed, strings.TrimSuffix(arg, "..."))
+	}
+	for i, value := range values {
+		if types[i] != "error" {
+			observed = append(observed, value)
+		}
+	}
+
+	operation := string(unicode.ToLower(rune(name[0]))) + name[1:]
+	fmt.Fprintf(buf, "\nfunc (s *instrumentedSession) %s(%s) (%s) {\n", name, strings.Join(params, ", "), strings.Join(results, ", "))
+	fmt.Fprintf(buf, "defer s.observe(%q, time.Now(), func() []interface{} { return []interface{}{%s} })\n", operation, strings.Join(observed, ", "))
+	fmt.Fprintf(buf, "return s.session.%s(%s)\n}\n", name, strings.Join(args, ", "))
+}
+
+// fields returns the declarations of a field list, their names and their
+// types. Fields are named after their position, unless they keep their
+// declared names.
+func (g *generator) fields(list *ast.FieldList, prefix string, keepNames bool) (decls, names, types []string) {
+	if list == nil {
+		return nil, nil, nil
+	}
+
+	for _, field := range list.List {
+		typ := g.expr(field.Type)
+		count := len(field.Names)
+		if count == 0 {
+			count = 1
+		}
+
+		for i := 0; i < count; i++ {
+			name := fmt.Sprintf("%s%d", prefix, len(names))
+			if keepNames && len(field.Names) > 0 {
+				name = field.Names[i].Name
+			}
+			decls = append(decls, name+" "+typ)
+			types = append(types, typ)
+			if _, variadic := field.Type.(*ast.Ellipsis); variadic {
+				name += "..."
+			}
+			names = append(names, name)
+		}
+	}
+
+	return decls, names, types
+}
+
+// expr returns the type expression as written in package pgsql.
+func (g *generator) expr(e ast.Expr) string {
+	switch t := e.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return "database." + t.Name
+		}
+		return t.Name
+	case *ast.SelectorExpr:
+		pkg := t.X.(*ast.Ident).Name
+		g.used[pkg] = g.imports[pkg]
+		return pkg + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + g.expr(t.X)
+	case *ast.ArrayType:
+		return "[]" + g.expr(t.Elt)
+	case *ast.MapType:
+		return "map[" + g.expr(t.Key) + "]" + g.expr(t.Value)
+	case *ast.Ellipsis:
+		return "..." + g.expr(t.Elt)
+	case *ast.InterfaceType:
+		return "interface{}"
+	}
+
+	log.Fatalf("%s: unsupported type", g.fset.Position(e.Pos()))
+	return ""
+}
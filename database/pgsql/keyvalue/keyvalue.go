@@ -26,15 +26,34 @@ import (
 )
 
 const (
-	searchKeyValue = `SELECT value FROM KeyValue WHERE key = $1`
+	searchKeyValue = `
+		SELECT value FROM KeyValue
+		WHERE key = $1 AND (expires_at IS NULL OR expires_at > $2)`
 	upsertKeyValue = `
-			INSERT INTO KeyValue(key, value) 
-				VALUES ($1, $2) 
+			INSERT INTO KeyValue(key, value, expires_at) 
+				VALUES ($1, $2, $3) 
 				ON CONFLICT ON CONSTRAINT keyvalue_key_key 
-				DO UPDATE SET key=$1, value=$2`
+				DO UPDATE SET key=$1, value=$2, expires_at=$3`
+	removeKeyValueExpired = `DELETE FROM KeyValue WHERE expires_at <= $1`
 )
 
+// UpdateKeyValue stores or updates a key/value pair which never expires.
 func UpdateKeyValue(tx *sql.Tx, key, value string) (err error) {
+	return updateKeyValue(tx, key, value, nil)
+}
+
+// UpdateKeyValueWithTTL stores or updates a key/value pair which expires once
+// the ttl elapsed.
+func UpdateKeyValueWithTTL(tx *sql.Tx, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return commonerr.NewBadRequestError("could not insert a flag which has a non-positive ttl")
+	}
+
+	expiresAt := time.Now().UTC().Add(ttl)
+	return updateKeyValue(tx, key, value, &expiresAt)
+}
+
+func updateKeyValue(tx *sql.Tx, key, value string, expiresAt *time.Time) (err error) {
 	if key == "" || value == "" {
 		log.Warning("could not insert a flag which has an empty name or value")
 		return commonerr.NewBadRequestError("could not insert a flag which has an empty name or value")
@@ -42,7 +61,7 @@ func UpdateKeyValue(tx *sql.Tx, key, value string) (err error) {
 
 	defer monitoring.ObserveQueryTime("PersistKeyValue", "all", time.Now())
 
-	_, err = tx.Exec(upsertKeyValue, key, value)
+	_, err = tx.Exec(upsertKeyValue, key, value, expiresAt)
 	if err != nil {
 		return util.HandleError("insertKeyValue", err)
 	}
@@ -50,11 +69,12 @@ func UpdateKeyValue(tx *sql.Tx, key, value string) (err error) {
 	return nil
 }
 
+// FindKeyValue retrieves the value of a key, ignoring it once it expired.
 func FindKeyValue(tx *sql.Tx, key string) (string, bool, error) {
 	defer monitoring.ObserveQueryTime("FindKeyValue", "all", time.Now())
 
 	var value string
-	err := tx.QueryRow(searchKeyValue, key).Scan(&value)
+	err := tx.QueryRow(searchKeyValue, key, time.Now().UTC()).Scan(&value)
 
 	if err == sql.ErrNoRows {
 		return "", false, nil
@@ -66,3 +86,20 @@ func FindKeyValue(tx *sql.Tx, key string) (string, bool, error) {
 
 	return value, true, nil
 }
+
+// PruneKeyValues removes every expired key/value pair from the database.
+func PruneKeyValues(tx *sql.Tx) (int, error) {
+	defer monitoring.ObserveQueryTime("PruneKeyValues", "all", time.Now())
+
+	r, err := tx.Exec(removeKeyValueExpired, time.Now().UTC())
+	if err != nil {
+		return 0, util.HandleError("removeKeyValueExpired", err)
+	}
+
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return 0, util.HandleError("removeKeyValueExpired", err)
+	}
+
+	return int(affected), nil
+}
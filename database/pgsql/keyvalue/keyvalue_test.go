@@ -16,6 +16,7 @@ package keyvalue
 
 import (
 	"testing"
+	"time"
 
 	"github.com/quay/clair/v3/database/pgsql/testutil"
 	"github.com/stretchr/testify/assert"
@@ -49,3 +50,27 @@ func TestKeyValue(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, "test2", f)
 }
+
+func TestKeyValueExpiration(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "KeyValue")
+	defer cleanup()
+
+	assert.Error(t, UpdateKeyValueWithTTL(tx, "test", "test", 0))
+	assert.Nil(t, UpdateKeyValueWithTTL(tx, "live", "test", time.Hour))
+	assert.Nil(t, UpdateKeyValueWithTTL(tx, "expired", "test", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	// Expired key/values are ignored until they are pruned.
+	_, ok, err := FindKeyValue(tx, "expired")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	f, ok, err := FindKeyValue(tx, "live")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "test", f)
+
+	pruned, err := PruneKeyValues(tx)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pruned)
+}
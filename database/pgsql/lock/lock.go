@@ -29,16 +29,21 @@ const (
 	removeLock        = `DELETE FROM Lock WHERE name = $1 AND owner = $2`
 	removeLockExpired = `DELETE FROM LOCK WHERE until < $1`
 
+	// soiLock inserts the lock or takes it over when it's expired, in a
+	// single statement so that two instances can't both reap the same
+	// expired lock. The current lock is returned when it's not acquired.
 	soiLock = `
 	WITH new_lock AS (
 		INSERT INTO lock (name, owner, until) 
-		SELECT CAST ($1 AS TEXT), CAST ($2 AS TEXT), CAST ($3 AS TIMESTAMP)
-		WHERE NOT EXISTS (SELECT id FROM lock WHERE name = $1)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET owner = EXCLUDED.owner, until = EXCLUDED.until
+		WHERE lock.until < $4
 		RETURNING owner, until
 	)
 	SELECT * FROM new_lock
-	UNION
-	SELECT owner, until FROM lock WHERE name = $1`
+	UNION ALL
+	SELECT owner, until FROM lock
+	WHERE name = $1 AND NOT EXISTS (SELECT 1 FROM new_lock)`
 )
 
 func AcquireLock(tx *sql.Tx, lockName, whoami string, desiredDuration time.Duration) (bool, time.Time, error) {
@@ -46,19 +51,16 @@ func AcquireLock(tx *sql.Tx, lockName, whoami string, desiredDuration time.Durat
 		panic("invalid lock parameters")
 	}
 
-	if err := PruneLocks(tx); err != nil {
-		return false, time.Time{}, err
-	}
-
 	var (
-		desiredLockedUntil = time.Now().UTC().Add(desiredDuration)
+		now                = time.Now().UTC()
+		desiredLockedUntil = now.Add(desiredDuration)
 
 		lockedUntil time.Time
 		lockOwner   string
 	)
 
 	defer monitoring.ObserveQueryTime("Lock", "soiLock", time.Now())
-	err := tx.QueryRow(soiLock, lockName, whoami, desiredLockedUntil).Scan(&lockOwner, &lockedUntil)
+	err := tx.QueryRow(soiLock, lockName, whoami, desiredLockedUntil, now).Scan(&lockOwner, &lockedUntil)
 	return lockOwner == whoami, lockedUntil, util.HandleError("AcquireLock", err)
 }
 
@@ -93,17 +95,20 @@ func ReleaseLock(tx *sql.Tx, name, owner string) error {
 	return err
 }
 
-// pruneLocks removes every expired locks from the database
-func PruneLocks(tx *sql.Tx) error {
+// PruneLocks removes every expired locks from the database
+func PruneLocks(tx *sql.Tx) (int, error) {
 	defer monitoring.ObserveQueryTime("pruneLocks", "all", time.Now())
 
-	if r, err := tx.Exec(removeLockExpired, time.Now().UTC()); err != nil {
-		return util.HandleError("removeLockExpired", err)
-	} else if affected, err := r.RowsAffected(); err != nil {
-		return util.HandleError("removeLockExpired", err)
-	} else {
-		log.Debugf("Pruned %d Locks", affected)
+	r, err := tx.Exec(removeLockExpired, time.Now().UTC())
+	if err != nil {
+		return 0, util.HandleError("removeLockExpired", err)
+	}
+
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return 0, util.HandleError("removeLockExpired", err)
 	}
 
-	return nil
+	log.Debugf("Pruned %d Locks", affected)
+	return int(affected), nil
 }
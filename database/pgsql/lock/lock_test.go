@@ -98,3 +98,36 @@ func TestLock(t *testing.T) {
 
 	require.Nil(t, tx.Rollback())
 }
+
+func TestAcquireLockReapsExpiredLock(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "Lock")
+	defer cleanup()
+
+	acquired, _, err := AcquireLock(tx, "test1", "owner1", -time.Minute)
+	require.Nil(t, err)
+	require.True(t, acquired)
+
+	// The expired lock is taken over without being pruned first.
+	acquired, expiration, err := AcquireLock(tx, "test1", "owner2", time.Minute)
+	require.Nil(t, err)
+	require.True(t, acquired)
+	require.True(t, expiration.After(time.Now()))
+
+	acquired, _, err = AcquireLock(tx, "test1", "owner1", time.Minute)
+	require.Nil(t, err)
+	require.False(t, acquired)
+}
+
+func TestPruneLocks(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "Lock")
+	defer cleanup()
+
+	_, _, err := AcquireLock(tx, "expired", "owner1", -time.Minute)
+	require.Nil(t, err)
+	_, _, err = AcquireLock(tx, "live", "owner1", time.Minute)
+	require.Nil(t, err)
+
+	pruned, err := PruneLocks(tx)
+	require.Nil(t, err)
+	require.Equal(t, 1, pruned)
+}
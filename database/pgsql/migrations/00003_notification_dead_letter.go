@@ -0,0 +1,40 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// notificationDeadLetter stores the notifications that the notifier
+	// failed to send, for later inspection or requeue.
+	notificationDeadLetter = MigrationQuery{
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS Notification_Dead_Letter (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(64) NOT NULL UNIQUE,
+				payload TEXT NOT NULL,
+				last_error TEXT NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL);`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS Notification_Dead_Letter CASCADE;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(3,
+		[]MigrationQuery{
+			notificationDeadLetter,
+		}))
+}
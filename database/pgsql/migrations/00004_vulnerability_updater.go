@@ -0,0 +1,35 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// vulnerabilityUpdater records the updaters which fetched every
+	// vulnerability, to tell where conflicting data comes from.
+	vulnerabilityUpdater = MigrationQuery{
+		Up: []string{
+			`ALTER TABLE vulnerability ADD COLUMN IF NOT EXISTS updater TEXT NULL;`,
+		},
+		Down: []string{
+			`ALTER TABLE IF EXISTS vulnerability DROP COLUMN IF EXISTS updater;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(4,
+		[]MigrationQuery{
+			vulnerabilityUpdater,
+		}))
+}
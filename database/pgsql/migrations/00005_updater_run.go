@@ -0,0 +1,42 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// updaterRun stores the summary of every updater run as an audit trail
+	// of the updates.
+	updaterRun = MigrationQuery{
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS Updater_Run (
+				id SERIAL PRIMARY KEY,
+				updater TEXT NOT NULL,
+				started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				vulnerabilities INT NOT NULL,
+				error TEXT NOT NULL);`,
+			`CREATE INDEX ON Updater_Run(updater, started_at);`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS Updater_Run CASCADE;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(5,
+		[]MigrationQuery{
+			updaterRun,
+		}))
+}
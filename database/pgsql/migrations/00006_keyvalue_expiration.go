@@ -0,0 +1,39 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// keyValueExpiration lets key/value pairs expire, after which they are
+	// ignored and eventually pruned.
+	keyValueExpiration = MigrationQuery{
+		Up: []string{
+			`ALTER TABLE KeyValue ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE NULL;`,
+			`CREATE INDEX IF NOT EXISTS keyvalue_expires_at_idx ON KeyValue(expires_at);`,
+			`CREATE INDEX IF NOT EXISTS lock_until_idx ON Lock(until);`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS lock_until_idx;`,
+			`DROP INDEX IF EXISTS keyvalue_expires_at_idx;`,
+			`ALTER TABLE IF EXISTS KeyValue DROP COLUMN IF EXISTS expires_at;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(6,
+		[]MigrationQuery{
+			keyValueExpiration,
+		}))
+}
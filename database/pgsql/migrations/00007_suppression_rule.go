@@ -0,0 +1,42 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// suppressionRule stores the rules suppressing the findings of
+	// vulnerabilities.
+	suppressionRule = MigrationQuery{
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS Suppression_Rule (
+				id SERIAL PRIMARY KEY,
+				vulnerability TEXT NOT NULL,
+				namespace TEXT NOT NULL,
+				feature TEXT NOT NULL,
+				reason TEXT NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				expires_at TIMESTAMP WITH TIME ZONE NULL);`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS Suppression_Rule CASCADE;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(7,
+		[]MigrationQuery{
+			suppressionRule,
+		}))
+}
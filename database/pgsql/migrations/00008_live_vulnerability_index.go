@@ -0,0 +1,36 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// liveVulnerabilityIndex indexes the vulnerabilities which aren't deleted
+	// by namespace, so that they are counted without scanning the deleted
+	// ones.
+	liveVulnerabilityIndex = MigrationQuery{
+		Up: []string{
+			`CREATE INDEX IF NOT EXISTS vulnerability_live_namespace_id_idx ON Vulnerability(namespace_id) WHERE deleted_at IS NULL;`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS vulnerability_live_namespace_id_idx;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(8,
+		[]MigrationQuery{
+			liveVulnerabilityIndex,
+		}))
+}
@@ -0,0 +1,36 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// ancestryFeatureIndex indexes the features of the ancestries by
+	// namespaced feature, so that the ancestries affected by a vulnerability
+	// are found without scanning the features of every ancestry.
+	ancestryFeatureIndex = MigrationQuery{
+		Up: []string{
+			`CREATE INDEX IF NOT EXISTS ancestry_feature_namespaced_feature_id_idx ON ancestry_feature(namespaced_feature_id);`,
+		},
+		Down: []string{
+			`DROP INDEX IF EXISTS ancestry_feature_namespaced_feature_id_idx;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(9,
+		[]MigrationQuery{
+			ancestryFeatureIndex,
+		}))
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+var (
+	// ancestrySource stores the layer references an ancestry was analyzed
+	// from, so that it can be rescanned with the current detectors.
+	ancestrySource = MigrationQuery{
+		Up: []string{
+			`CREATE TABLE IF NOT EXISTS ancestry_source (
+				name TEXT PRIMARY KEY,
+				format TEXT NOT NULL,
+				layers TEXT NOT NULL);`,
+		},
+		Down: []string{
+			`DROP TABLE IF EXISTS ancestry_source;`,
+		},
+	}
+)
+
+func init() {
+	RegisterMigration(NewSimpleMigration(11,
+		[]MigrationQuery{
+			ancestrySource,
+		}))
+}
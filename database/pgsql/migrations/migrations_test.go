@@ -15,6 +15,7 @@
 package migrations_test
 
 import (
+	"sort"
 	"testing"
 
 	"github.com/quay/clair/v3/database/pgsql/migrations"
@@ -58,3 +59,53 @@ func TestMigration(t *testing.T) {
 
 	require.True(t, len(tables) == 1 && tables[0] == "schema_migrations", "Only `schema_migrations` should be left")
 }
+
+func TestMigrationStatus(t *testing.T) {
+	db, cleanup := testutil.CreateAndConnectTestDB(t, "TestMigrationStatus")
+	defer cleanup()
+
+	latest := 0
+	var all []int
+	for _, m := range migrations.Migrations {
+		all = append(all, m.ID)
+		if m.ID > latest {
+			latest = m.ID
+		}
+	}
+	sort.Ints(all)
+
+	// A database never migrated has every migration pending.
+	status, err := migrations.GetStatus(db)
+	require.Nil(t, err)
+	require.False(t, status.UpToDate())
+	require.Equal(t, 0, status.Version)
+	require.Equal(t, all, status.Pending)
+
+	require.Nil(t, migrations.Up(db, all[0]))
+	status, err = migrations.GetStatus(db)
+	require.Nil(t, err)
+	require.Equal(t, all[:1], status.Applied)
+	require.Equal(t, all[1:], status.Pending)
+
+	require.Nil(t, migrations.Up(db, 0))
+	status, err = migrations.GetStatus(db)
+	require.Nil(t, err)
+	require.True(t, status.UpToDate())
+	require.Equal(t, latest, status.Version)
+
+	require.Nil(t, migrations.Down(db, 1))
+	status, err = migrations.GetStatus(db)
+	require.Nil(t, err)
+	require.Equal(t, all[len(all)-1:], status.Pending)
+
+	// Forcing records the migrations without running them.
+	require.Nil(t, migrations.Force(db, latest))
+	status, err = migrations.GetStatus(db)
+	require.Nil(t, err)
+	require.True(t, status.UpToDate())
+	require.Equal(t, all, status.Applied)
+
+	require.NotNil(t, migrations.Force(db, latest+1))
+	require.NotNil(t, migrations.Up(db, latest+1))
+	require.NotNil(t, migrations.Down(db, 0))
+}
@@ -0,0 +1,223 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/remind101/migrate"
+)
+
+const (
+	searchMigrationTable = `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.tables
+			WHERE table_schema = current_schema() AND table_name = $1)`
+
+	searchAppliedMigrations = `SELECT version FROM ` + migrate.DefaultTable + ` ORDER BY version`
+
+	createMigrationTable = `CREATE TABLE IF NOT EXISTS ` + migrate.DefaultTable + ` (version integer primary key not null)`
+	removeMigrations     = `DELETE FROM ` + migrate.DefaultTable
+	insertMigration      = `INSERT INTO ` + migrate.DefaultTable + ` (version) VALUES ($1)`
+	lockMigrationTable   = `LOCK TABLE ` + migrate.DefaultTable + ` IN ACCESS EXCLUSIVE MODE`
+)
+
+// Status is the state of the migrations of a database.
+type Status struct {
+	// Version is the ID of the latest migration applied, 0 if there is none.
+	Version int `json:"version"`
+	// Latest is the ID of the latest migration available.
+	Latest int `json:"latest"`
+	// Applied are the IDs of the migrations applied.
+	Applied []int `json:"applied"`
+	// Pending are the IDs of the migrations available but not applied.
+	Pending []int `json:"pending"`
+	// Unknown are the IDs of the migrations applied but not available, e.g.
+	// after a downgrade of Clair.
+	Unknown []int `json:"unknown"`
+}
+
+// UpToDate returns whether every available migration is applied.
+func (s Status) UpToDate() bool {
+	return len(s.Pending) == 0
+}
+
+// available returns the available migrations, sorted by ID.
+func available() []migrate.Migration {
+	sorted := append([]migrate.Migration(nil), Migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	return sorted
+}
+
+// GetStatus returns the state of the migrations of the database.
+func GetStatus(db *sql.DB) (Status, error) {
+	status := Status{Applied: []int{}, Pending: []int{}, Unknown: []int{}}
+
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return status, err
+	}
+
+	known := make(map[int]struct{})
+	for _, m := range available() {
+		known[m.ID] = struct{}{}
+		status.Latest = m.ID
+		if _, ok := applied[m.ID]; !ok {
+			status.Pending = append(status.Pending, m.ID)
+		}
+	}
+
+	for id := range applied {
+		status.Applied = append(status.Applied, id)
+		if _, ok := known[id]; !ok {
+			status.Unknown = append(status.Unknown, id)
+		}
+
+		if id > status.Version {
+			status.Version = id
+		}
+	}
+
+	sort.Ints(status.Applied)
+	sort.Ints(status.Unknown)
+	return status, nil
+}
+
+// appliedMigrations returns the set of the IDs of the migrations applied to
+// the database.
+func appliedMigrations(db *sql.DB) (map[int]struct{}, error) {
+	var exists bool
+	if err := db.QueryRow(searchMigrationTable, migrate.DefaultTable).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]struct{})
+	if !exists {
+		// The database was never migrated.
+		return applied, nil
+	}
+
+	rows, err := db.Query(searchAppliedMigrations)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		applied[id] = struct{}{}
+	}
+
+	return applied, rows.Err()
+}
+
+// Up applies the pending migrations up to the migration of the given ID, or
+// all of them if it is 0.
+func Up(db *sql.DB, target int) error {
+	if target != 0 && !isAvailable(target) {
+		return fmt.Errorf("migrations: migration %d is not available", target)
+	}
+
+	var up []migrate.Migration
+	for _, m := range available() {
+		if target == 0 || m.ID <= target {
+			up = append(up, m)
+		}
+	}
+
+	return migrate.NewPostgresMigrator(db).Exec(migrate.Up, up...)
+}
+
+// Down reverts the given number of the latest migrations applied.
+func Down(db *sql.DB, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("migrations: the number of migrations to revert must be positive, got %d", steps)
+	}
+
+	status, err := GetStatus(db)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[int]migrate.Migration)
+	for _, m := range available() {
+		byID[m.ID] = m
+	}
+
+	var down []migrate.Migration
+	for i := len(status.Applied) - 1; i >= 0 && len(down) < steps; i-- {
+		m, ok := byID[status.Applied[i]]
+		if !ok {
+			return fmt.Errorf("migrations: migration %d is applied but not available", status.Applied[i])
+		}
+		down = append(down, m)
+	}
+
+	return migrate.NewPostgresMigrator(db).Exec(migrate.Down, down...)
+}
+
+// Force records the available migrations up to the migration of the given ID
+// as applied and every other one as not applied, without running any of them.
+//
+// It recovers a database whose recorded migrations don't match its schema,
+// e.g. after the schema was changed by hand.
+func Force(db *sql.DB, version int) error {
+	if version != 0 && !isAvailable(version) {
+		return fmt.Errorf("migrations: migration %d is not available", version)
+	}
+
+	if _, err := db.Exec(createMigrationTable); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{lockMigrationTable, removeMigrations} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range available() {
+		if m.ID > version {
+			break
+		}
+
+		if _, err := tx.Exec(insertMigration, m.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func isAvailable(id int) bool {
+	for _, m := range Migrations {
+		if m.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pgsql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/migrations"
+)
+
+// Migrator manages the schema migrations of a PostgreSQL database, apart from
+// the Datastore.
+type Migrator struct {
+	db *sql.DB
+}
+
+// OpenMigrator connects to the database of the configuration, without
+// migrating it.
+func OpenMigrator(registrableComponentConfig database.RegistrableComponentConfig) (*Migrator, error) {
+	config, err := parseConfig(registrableComponentConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := parseConnectionString(config.Source); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", config.Source)
+	if err != nil {
+		return nil, fmt.Errorf("pgsql: could not open database: %v", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("pgsql: could not open database: %v", err)
+	}
+
+	return &Migrator{db: db}, nil
+}
+
+// Status returns the state of the migrations of the database.
+func (m *Migrator) Status() (migrations.Status, error) {
+	return migrations.GetStatus(m.db)
+}
+
+// Up applies the pending migrations up to the migration of the given ID, or
+// all of them if it is 0.
+func (m *Migrator) Up(target int) error {
+	return migrations.Up(m.db, target)
+}
+
+// Down reverts the given number of the latest migrations applied.
+func (m *Migrator) Down(steps int) error {
+	return migrations.Down(m.db, steps)
+}
+
+// Force records the migrations up to the given ID as applied, without running
+// them.
+func (m *Migrator) Force(version int) error {
+	return migrations.Force(m.db, version)
+}
+
+// Close closes the connection to the database.
+func (m *Migrator) Close() {
+	m.db.Close()
+}
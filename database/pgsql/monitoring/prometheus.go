@@ -41,6 +41,11 @@ var (
 		Help: "Time it takes to execute the database query.",
 	}, []string{"query", "subquery"})
 
+	PromOperationDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "clair_pgsql_operation_duration_seconds",
+		Help: "Time it takes to run a datastore session operation.",
+	}, []string{"operation"})
+
 	PromConcurrentLockVAFV = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "clair_pgsql_concurrent_lock_vafv_total",
 		Help: "Number of transactions trying to hold the exclusive Vulnerability_Affects_Feature lock.",
@@ -52,6 +57,7 @@ func init() {
 	prometheus.MustRegister(PromCacheHitsTotal)
 	prometheus.MustRegister(PromCacheQueriesTotal)
 	prometheus.MustRegister(PromQueryDurationMilliseconds)
+	prometheus.MustRegister(PromOperationDurationSeconds)
 	prometheus.MustRegister(PromConcurrentLockVAFV)
 }
 
@@ -65,3 +71,11 @@ func ObserveQueryTime(query, subquery string, start time.Time) {
 		WithLabelValues(query, subquery).
 		Observe(float64(time.Since(start).Nanoseconds()) / float64(time.Millisecond))
 }
+
+// ObserveOperationTime records the duration of a session operation, named
+// after its pgSession method.
+func ObserveOperationTime(operation string, elapsed time.Duration) {
+	PromOperationDurationSeconds.
+		WithLabelValues(operation).
+		Observe(elapsed.Seconds())
+}
@@ -18,14 +18,28 @@ import (
 	"database/sql"
 	"fmt"
 	"sort"
+	"time"
 
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
 	"github.com/quay/clair/v3/database/pgsql/util"
 	"github.com/quay/clair/v3/pkg/commonerr"
 )
 
 const (
 	searchNamespaceID = `SELECT id FROM Namespace WHERE name = $1 AND version_format = $2`
+
+	// The live vulnerabilities are counted through their partial index.
+	searchNamespaceVulnerabilityCount = `
+		SELECT n.name, n.version_format, COALESCE(v.count, 0)
+		FROM namespace AS n
+		LEFT JOIN (
+			SELECT namespace_id, COUNT(*) AS count
+			FROM vulnerability
+			WHERE deleted_at IS NULL
+			GROUP BY namespace_id
+		) AS v ON v.namespace_id = n.id
+		ORDER BY n.name, n.version_format`
 )
 
 func queryPersistNamespace(count int) string {
@@ -72,6 +86,33 @@ func PersistNamespaces(tx *sql.Tx, namespaces []database.Namespace) error {
 	return nil
 }
 
+// FindNamespaces retrieves every namespace with the number of its
+// vulnerabilities which aren't deleted.
+func FindNamespaces(tx *sql.Tx) ([]database.NamespaceWithVulnerabilityCount, error) {
+	defer monitoring.ObserveQueryTime("findNamespaces", "all", time.Now())
+
+	rows, err := tx.Query(searchNamespaceVulnerabilityCount)
+	if err != nil {
+		return nil, util.HandleError("searchNamespaceVulnerabilityCount", err)
+	}
+	defer rows.Close()
+
+	namespaces := []database.NamespaceWithVulnerabilityCount{}
+	for rows.Next() {
+		var ns database.NamespaceWithVulnerabilityCount
+		if err := rows.Scan(&ns.Name, &ns.VersionFormat, &ns.VulnerabilityCount); err != nil {
+			return nil, util.HandleError("searchNamespaceVulnerabilityCount", err)
+		}
+		namespaces = append(namespaces, ns)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchNamespaceVulnerabilityCount", err)
+	}
+
+	return namespaces, nil
+}
+
 func FindNamespaceIDs(tx *sql.Tx, namespaces []database.Namespace) ([]sql.NullInt64, error) {
 	if len(namespaces) == 0 {
 		return nil, nil
@@ -43,3 +43,19 @@ func TestPersistNamespaces(t *testing.T) {
 	assert.Len(t, nsList, 1)
 	assert.Equal(t, ns2, nsList[0])
 }
+
+func TestFindNamespaces(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindNamespaces")
+	defer cleanup()
+
+	// The deleted vulnerability of debian:7 isn't counted.
+	namespaces, err := FindNamespaces(tx)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []database.NamespaceWithVulnerabilityCount{
+			{Namespace: database.Namespace{Name: "cpe:/o:redhat:enterprise_linux:7::server", VersionFormat: "rpm"}},
+			{Namespace: database.Namespace{Name: "debian:7", VersionFormat: "dpkg"}, VulnerabilityCount: 2},
+			{Namespace: database.Namespace{Name: "debian:8", VersionFormat: "dpkg"}},
+			{Namespace: database.Namespace{Name: "fake:1.0", VersionFormat: "rpm"}},
+		}, namespaces)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
+	"github.com/quay/clair/v3/database/pgsql/util"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+const (
+	upsertDeadLetter = `
+		INSERT INTO Notification_Dead_Letter(name, payload, last_error, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (name)
+		DO UPDATE SET payload = $2, last_error = $3, created_at = $4`
+
+	searchDeadLetters = `
+		SELECT name, payload, last_error, created_at
+		FROM Notification_Dead_Letter
+		ORDER BY created_at, name`
+
+	removeDeadLetter = `DELETE FROM Notification_Dead_Letter WHERE name = $1`
+
+	requeueNotification = `
+		UPDATE Vulnerability_Notification
+		SET notified_at = NULL
+		WHERE name = $1 AND deleted_at IS NULL`
+)
+
+// InsertDeadLetterNotification stores a notification which failed to be sent.
+func InsertDeadLetterNotification(tx *sql.Tx, deadLetter database.DeadLetterNotification) error {
+	if deadLetter.Name == "" {
+		return commonerr.NewBadRequestError("dead letter should not have empty name")
+	}
+
+	if deadLetter.Created.IsZero() {
+		return commonerr.NewBadRequestError("dead letter should not have empty created time")
+	}
+
+	defer monitoring.ObserveQueryTime("insertDeadLetterNotification", "all", time.Now())
+	if _, err := tx.Exec(upsertDeadLetter, deadLetter.Name, deadLetter.Payload, deadLetter.LastError, deadLetter.Created); err != nil {
+		return util.HandleError("upsertDeadLetter", err)
+	}
+
+	return nil
+}
+
+// FindDeadLetterNotifications retrieves every dead-lettered notification, the
+// oldest first.
+func FindDeadLetterNotifications(tx *sql.Tx) ([]database.DeadLetterNotification, error) {
+	defer monitoring.ObserveQueryTime("findDeadLetterNotifications", "all", time.Now())
+	rows, err := tx.Query(searchDeadLetters)
+	if err != nil {
+		return nil, util.HandleError("searchDeadLetters", err)
+	}
+	defer rows.Close()
+
+	deadLetters := []database.DeadLetterNotification{}
+	for rows.Next() {
+		var deadLetter database.DeadLetterNotification
+		if err := rows.Scan(&deadLetter.Name, &deadLetter.Payload, &deadLetter.LastError, &deadLetter.Created); err != nil {
+			return nil, util.HandleError("searchDeadLetters", err)
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchDeadLetters", err)
+	}
+
+	return deadLetters, nil
+}
+
+// RequeueDeadLetterNotification removes a dead-lettered notification and
+// marks the notification as never notified so that it is sent again.
+func RequeueDeadLetterNotification(tx *sql.Tx, name string) (bool, error) {
+	defer monitoring.ObserveQueryTime("requeueDeadLetterNotification", "all", time.Now())
+	result, err := tx.Exec(removeDeadLetter, name)
+	if err != nil {
+		return false, util.HandleError("removeDeadLetter", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, util.HandleError("removeDeadLetter", err)
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(requeueNotification, name); err != nil {
+		return false, util.HandleError("requeueNotification", err)
+	}
+
+	return true, nil
+}
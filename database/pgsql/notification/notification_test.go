@@ -267,6 +267,61 @@ func TestMarkNotificationAsRead(t *testing.T) {
 	assert.Nil(t, MarkNotificationAsRead(tx, "test"))
 }
 
+func TestMarkNotificationAsFiltered(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "MarkNotificationAsFiltered")
+	defer cleanup()
+
+	// invalid case: notification doesn't exist
+	assert.NotNil(t, MarkNotificationAsFiltered(tx, "non-existing"))
+	// valid case
+	assert.Nil(t, MarkNotificationAsFiltered(tx, "test"))
+
+	// filtered notifications are never renotified
+	_, ok, err := FindNewNotification(tx, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	noti, ok, err := FindVulnerabilityNotification(tx, "test", 1, pagination.FirstPageToken, pagination.FirstPageToken, pagination.Must(pagination.NewKey()))
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.True(t, noti.Filtered)
+		assert.NotEqual(t, time.Time{}, noti.Notified)
+	}
+}
+
+func TestFindNotifications(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindNotifications")
+	defer cleanup()
+
+	key := pagination.Must(pagination.NewKey())
+	names := func(state database.NotificationState) []string {
+		notiPage, err := FindNotifications(tx, state, 10, pagination.FirstPageToken, key)
+		require.Nil(t, err)
+		require.True(t, notiPage.End)
+
+		var names []string
+		for _, noti := range notiPage.Notifications {
+			assert.Equal(t, database.VulnerabilityID{Name: "CVE-OPENSSL-1-DEB7", Namespace: "debian:7"}, noti.Vulnerability)
+			names = append(names, noti.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"test"}, names(database.PendingNotification))
+	assert.Empty(t, names(database.DeliveredNotification))
+
+	require.Nil(t, MarkNotificationAsRead(tx, "test"))
+	assert.Empty(t, names(database.PendingNotification))
+	assert.Equal(t, []string{"test"}, names(database.DeliveredNotification))
+
+	require.Nil(t, DeleteNotification(tx, "test"))
+	assert.Empty(t, names(database.DeliveredNotification))
+	assert.Equal(t, []string{"test"}, names(database.ExpiredNotification))
+	assert.Equal(t, []string{"test"}, names(""))
+
+	_, err := FindNotifications(tx, "unknown", 10, pagination.FirstPageToken, key)
+	assert.Error(t, err)
+}
+
 func TestDeleteNotification(t *testing.T) {
 	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "DeleteNotification")
 	defer cleanup()
@@ -278,3 +333,52 @@ func TestDeleteNotification(t *testing.T) {
 	// invalid case: notification is already deleted
 	assert.NotNil(t, DeleteNotification(tx, "test"))
 }
+
+func TestDeadLetterNotification(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "DeadLetterNotification")
+	defer cleanup()
+
+	deadLetter := database.DeadLetterNotification{
+		Name:      "test",
+		Payload:   `{"Name":"test"}`,
+		LastError: "connection refused",
+		Created:   time.Now().UTC().Truncate(time.Second),
+	}
+
+	// invalid case: dead letter without name
+	assert.NotNil(t, InsertDeadLetterNotification(tx, database.DeadLetterNotification{Created: deadLetter.Created}))
+
+	require.Nil(t, InsertDeadLetterNotification(tx, deadLetter))
+	// dead-lettered notifications are not sent again
+	_, ok, err := FindNewNotification(tx, time.Now())
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// dead letters of the same notification are replaced
+	deadLetter.LastError = "timeout"
+	require.Nil(t, InsertDeadLetterNotification(tx, deadLetter))
+	deadLetters, err := FindDeadLetterNotifications(tx)
+	if assert.Nil(t, err) && assert.Len(t, deadLetters, 1) {
+		assert.Equal(t, deadLetter.Name, deadLetters[0].Name)
+		assert.Equal(t, deadLetter.Payload, deadLetters[0].Payload)
+		assert.Equal(t, "timeout", deadLetters[0].LastError)
+		assert.True(t, deadLetter.Created.Equal(deadLetters[0].Created))
+	}
+
+	found, err := RequeueDeadLetterNotification(tx, "non-existing")
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	found, err = RequeueDeadLetterNotification(tx, "test")
+	assert.Nil(t, err)
+	assert.True(t, found)
+
+	deadLetters, err = FindDeadLetterNotifications(tx)
+	assert.Nil(t, err)
+	assert.Len(t, deadLetters, 0)
+
+	noti, ok, err := FindNewNotification(tx, time.Now())
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.Equal(t, "test", noti.Name)
+	}
+}
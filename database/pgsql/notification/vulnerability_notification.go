@@ -22,6 +22,7 @@ import (
 	"github.com/guregu/null/zero"
 
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/page"
 	"github.com/quay/clair/v3/database/pgsql/util"
 	"github.com/quay/clair/v3/database/pgsql/vulnerability"
 	"github.com/quay/clair/v3/pkg/commonerr"
@@ -38,6 +39,11 @@ const (
 		SET notified_at = CURRENT_TIMESTAMP
 		WHERE name = $1`
 
+	updatedNotificationAsFiltered = `
+		UPDATE Vulnerability_Notification
+		SET notified_at = CURRENT_TIMESTAMP, filtered = TRUE
+		WHERE name = $1`
+
 	removeNotification = `
 		UPDATE Vulnerability_Notification
 	  SET deleted_at = CURRENT_TIMESTAMP
@@ -48,16 +54,37 @@ const (
 		FROM Vulnerability_Notification
 		WHERE (notified_at IS NULL OR notified_at < $1)
 					AND deleted_at IS NULL
-					AND name NOT IN (SELECT name FROM Lock)
+					AND NOT filtered
+					AND name NOT IN (SELECT name FROM Lock WHERE until >= $2)
+					AND name NOT IN (SELECT name FROM Notification_Dead_Letter)
 		ORDER BY Random()
 		LIMIT 1`
 
 	searchNotification = `
-		SELECT created_at, notified_at, deleted_at, old_vulnerability_id, new_vulnerability_id
+		SELECT created_at, notified_at, deleted_at, filtered, old_vulnerability_id, new_vulnerability_id
 		FROM Vulnerability_Notification
 		WHERE name = $1`
+
+	searchNotificationsByState = `
+		SELECT noti.id, noti.name, noti.created_at, noti.notified_at, noti.deleted_at, noti.filtered, v.name, ns.name
+		FROM Vulnerability_Notification AS noti
+			LEFT JOIN Vulnerability AS v ON v.id = COALESCE(noti.new_vulnerability_id, noti.old_vulnerability_id)
+			LEFT JOIN Namespace AS ns ON ns.id = v.namespace_id
+		WHERE noti.id >= $1
+			AND CASE $2::TEXT
+				WHEN 'pending' THEN noti.notified_at IS NULL AND noti.deleted_at IS NULL
+				WHEN 'delivered' THEN noti.notified_at IS NOT NULL AND noti.deleted_at IS NULL
+				WHEN 'expired' THEN noti.deleted_at IS NOT NULL
+				ELSE TRUE
+			END
+		ORDER BY noti.id ASC
+		LIMIT $3`
 )
 
+// insertNotificationBatchSize is the number of notifications inserted by a
+// single query, which is limited to 65535 parameters.
+const insertNotificationBatchSize = 10000
+
 func queryInsertNotifications(count int) string {
 	return util.QueryInsert(count,
 		"vulnerability_notification",
@@ -176,9 +203,16 @@ func InsertVulnerabilityNotifications(tx *sql.Tx, notifications []database.Vulne
 	// NOTE(Sida): The data is not sorted before inserting into database under
 	// the fact that there's only one updater running at a time. If there are
 	// multiple updaters, deadlock may happen.
-	_, err = tx.Exec(queryInsertNotifications(len(notifications)), keys...)
-	if err != nil {
-		return util.HandleError("queryInsertNotifications", err)
+	for start := 0; start < len(notifications); start += insertNotificationBatchSize {
+		end := start + insertNotificationBatchSize
+		if end > len(notifications) {
+			end = len(notifications)
+		}
+
+		_, err = tx.Exec(queryInsertNotifications(end-start), keys[4*start:4*end]...)
+		if err != nil {
+			return util.HandleError("queryInsertNotifications", err)
+		}
 	}
 
 	return nil
@@ -192,7 +226,7 @@ func FindNewNotification(tx *sql.Tx, notifiedBefore time.Time) (database.Notific
 		deleted      zero.Time
 	)
 
-	err := tx.QueryRow(searchNotificationAvailable, notifiedBefore).Scan(&notification.Name, &created, &notified, &deleted)
+	err := tx.QueryRow(searchNotificationAvailable, notifiedBefore, time.Now().UTC()).Scan(&notification.Name, &created, &notified, &deleted)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return notification, false, nil
@@ -224,7 +258,7 @@ func FindVulnerabilityNotification(tx *sql.Tx, name string, limit int, oldPageTo
 
 	noti.Name = name
 	err := tx.QueryRow(searchNotification, name).Scan(&created, &notified,
-		&deleted, &oldVulnID, &newVulnID)
+		&deleted, &noti.Filtered, &oldVulnID, &newVulnID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -264,6 +298,76 @@ func FindVulnerabilityNotification(tx *sql.Tx, name string, limit int, oldPageTo
 	return noti, true, nil
 }
 
+// FindNotifications retrieves a page of the notifications in a state, or of
+// every notification when the state is empty, ordered by ID.
+func FindNotifications(tx *sql.Tx, state database.NotificationState, limit int, currentToken pagination.Token, key pagination.Key) (database.PagedNotifications, error) {
+	notiPage := database.PagedNotifications{Limit: limit}
+	if state != "" && !state.Valid() {
+		return notiPage, commonerr.NewBadRequestError("unknown notification state")
+	}
+
+	currentPage := page.Page{}
+	if currentToken != pagination.FirstPageToken {
+		if err := key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return notiPage, err
+		}
+	}
+
+	// the last result is used for the next page's startID
+	rows, err := tx.Query(searchNotificationsByState, currentPage.StartID, string(state), limit+1)
+	if err != nil {
+		return notiPage, util.HandleError("searchNotificationsByState", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var (
+			id            int64
+			noti          database.NotificationSummary
+			created       zero.Time
+			notified      zero.Time
+			deleted       zero.Time
+			vulnerability zero.String
+			namespace     zero.String
+		)
+
+		if err := rows.Scan(&id, &noti.Name, &created, &notified, &deleted, &noti.Filtered, &vulnerability, &namespace); err != nil {
+			return notiPage, util.HandleError("searchNotificationsByState", err)
+		}
+
+		noti.Created = created.Time
+		noti.Notified = notified.Time
+		noti.Deleted = deleted.Time
+		noti.Vulnerability = database.VulnerabilityID{Name: vulnerability.String, Namespace: namespace.String}
+
+		ids = append(ids, id)
+		notiPage.Notifications = append(notiPage.Notifications, noti)
+	}
+
+	if err := rows.Err(); err != nil {
+		return notiPage, util.HandleError("searchNotificationsByState", err)
+	}
+
+	if len(ids) > limit {
+		notiPage.Next, err = key.MarshalToken(page.Page{StartID: ids[limit]})
+		if err != nil {
+			return notiPage, err
+		}
+
+		notiPage.Notifications = notiPage.Notifications[:limit]
+	} else {
+		notiPage.End = true
+	}
+
+	notiPage.Current, err = key.MarshalToken(currentPage)
+	if err != nil {
+		return notiPage, err
+	}
+
+	return notiPage, nil
+}
+
 func MarkNotificationAsRead(tx *sql.Tx, name string) error {
 	if name == "" {
 		return commonerr.NewBadRequestError("Empty notification name is not allowed")
@@ -285,6 +389,27 @@ func MarkNotificationAsRead(tx *sql.Tx, name string) error {
 	return nil
 }
 
+func MarkNotificationAsFiltered(tx *sql.Tx, name string) error {
+	if name == "" {
+		return commonerr.NewBadRequestError("Empty notification name is not allowed")
+	}
+
+	r, err := tx.Exec(updatedNotificationAsFiltered, name)
+	if err != nil {
+		return util.HandleError("updatedNotificationAsFiltered", err)
+	}
+
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return util.HandleError("updatedNotificationAsFiltered", err)
+	}
+
+	if affected <= 0 {
+		return util.HandleError("updatedNotificationAsFiltered", errNotificationNotFound)
+	}
+	return nil
+}
+
 func DeleteNotification(tx *sql.Tx, name string) error {
 	if name == "" {
 		return commonerr.NewBadRequestError("Empty notification name is not allowed")
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/quay/clair/v3/database/pgsql/keyvalue"
+	"github.com/quay/clair/v3/database/pgsql/util"
 	"github.com/quay/clair/v3/database/pgsql/vulnerability"
 
 	"github.com/quay/clair/v3/database"
@@ -29,106 +30,414 @@ import (
 	"github.com/quay/clair/v3/database/pgsql/lock"
 	"github.com/quay/clair/v3/database/pgsql/namespace"
 	"github.com/quay/clair/v3/database/pgsql/notification"
+	"github.com/quay/clair/v3/database/pgsql/statistics"
+	"github.com/quay/clair/v3/database/pgsql/suppression"
+	"github.com/quay/clair/v3/database/pgsql/updater"
 	"github.com/quay/clair/v3/pkg/pagination"
 )
 
+const (
+	// maxTxAttempts is the number of times a transaction is attempted when it
+	// fails with a retryable error.
+	maxTxAttempts = 4
+
+	// txRetryBackoff is the delay before the first retry of a transaction,
+	// doubled at each following retry.
+	txRetryBackoff = 10 * time.Millisecond
+)
+
 // Enforce the interface at compile time.
 var _ database.Session = &pgSession{}
 
+// pgSession is a session whose transaction is transparently retried when it
+// fails with a serialization failure or a deadlock.
+//
+// Every write of the session is recorded and replayed on a new transaction
+// before retrying the failed statement or commit. The writes thus must not
+// have any effect besides their statements. Once an operation whose result
+// depends on concurrent transactions, e.g. acquiring a lock, succeeded, the
+// session can't be replayed anymore since its result could change.
 type pgSession struct {
 	*sql.Tx
 
 	key pagination.Key
+
+	// begin starts the transactions the session is replayed on. The session
+	// is not retried when it is nil.
+	begin func() (*sql.Tx, error)
+	// writes are the writes to replay when retrying the session.
+	writes []func(*sql.Tx) error
+	// unreplayable is set once the session can't be replayed anymore.
+	unreplayable bool
+}
+
+// read runs an operation which doesn't write.
+func (tx *pgSession) read(op func(*sql.Tx) error) error {
+	return tx.retry(func() error { return op(tx.Tx) })
+}
+
+// write runs an operation which writes, recording it to be replayed.
+func (tx *pgSession) write(op func(*sql.Tx) error) error {
+	err := tx.retry(func() error { return op(tx.Tx) })
+	if err == nil {
+		tx.writes = append(tx.writes, op)
+	}
+
+	return err
+}
+
+// writeOnce runs an operation which writes, and whose result depends on
+// concurrent transactions.
+func (tx *pgSession) writeOnce(op func(*sql.Tx) error) error {
+	err := tx.retry(func() error { return op(tx.Tx) })
+	if err == nil {
+		tx.unreplayable = true
+	}
+
+	return err
+}
+
+// retry runs the step, replaying the session and running the step again as
+// long as it fails with a retryable error.
+func (tx *pgSession) retry(step func() error) error {
+	err := step()
+	for attempt := 1; attempt < maxTxAttempts && util.IsErrRetryable(err); attempt++ {
+		if tx.begin == nil || tx.unreplayable {
+			break
+		}
+
+		time.Sleep(txRetryBackoff << uint(attempt-1))
+		if err = tx.replay(); err == nil {
+			err = step()
+		}
+	}
+
+	return err
+}
+
+// replay aborts the transaction of the session and replays its writes on a
+// new one.
+func (tx *pgSession) replay() error {
+	// The transaction was already aborted by the failure, or committed.
+	tx.Tx.Rollback()
+
+	newTx, err := tx.begin()
+	if err != nil {
+		return err
+	}
+	tx.Tx = newTx
+
+	for _, op := range tx.writes {
+		if err := op(tx.Tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Commit commits the transaction, replaying it when the commit fails with a
+// retryable error.
+func (tx *pgSession) Commit() error {
+	return tx.retry(func() error { return tx.Tx.Commit() })
 }
 
 func (tx *pgSession) UpsertAncestry(a database.Ancestry) error {
-	return ancestry.UpsertAncestry(tx.Tx, a)
+	return tx.write(func(t *sql.Tx) error { return ancestry.UpsertAncestry(t, a) })
+}
+
+func (tx *pgSession) FindAncestry(name string) (a database.Ancestry, found bool, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		a, found, err = ancestry.FindAncestry(t, name)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) DeleteAncestry(name string) (found bool, err error) {
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		found, err = ancestry.DeleteAncestry(t, name)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) UpsertAncestrySource(source database.AncestrySource) error {
+	return tx.write(func(t *sql.Tx) error { return ancestry.UpsertAncestrySource(t, source) })
+}
+
+func (tx *pgSession) FindAncestrySource(name string) (source database.AncestrySource, found bool, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		source, found, err = ancestry.FindAncestrySource(t, name)
+		return
+	})
+	return
 }
 
-func (tx *pgSession) FindAncestry(name string) (database.Ancestry, bool, error) {
-	return ancestry.FindAncestry(tx.Tx, name)
+func (tx *pgSession) FindOutdatedAncestries(detectors []database.Detector) (names []string, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		names, err = ancestry.FindOutdatedAncestries(t, detectors)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) PersistDetectors(detectors []database.Detector) error {
-	return detector.PersistDetectors(tx.Tx, detectors)
+	return tx.write(func(t *sql.Tx) error { return detector.PersistDetectors(t, detectors) })
 }
 
 func (tx *pgSession) PersistFeatures(features []database.Feature) error {
-	return feature.PersistFeatures(tx.Tx, features)
+	return tx.write(func(t *sql.Tx) error { return feature.PersistFeatures(t, features) })
 }
 
 func (tx *pgSession) PersistNamespacedFeatures(features []database.NamespacedFeature) error {
-	return feature.PersistNamespacedFeatures(tx.Tx, features)
+	return tx.write(func(t *sql.Tx) error { return feature.PersistNamespacedFeatures(t, features) })
 }
 
 func (tx *pgSession) CacheAffectedNamespacedFeatures(features []database.NamespacedFeature) error {
-	return vulnerability.CacheAffectedNamespacedFeatures(tx.Tx, features)
+	return tx.write(func(t *sql.Tx) error { return vulnerability.CacheAffectedNamespacedFeatures(t, features) })
 }
 
-func (tx *pgSession) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) ([]database.NullableAffectedNamespacedFeature, error) {
-	return vulnerability.FindAffectedNamespacedFeatures(tx.Tx, features)
+func (tx *pgSession) FindAffectedNamespacedFeatures(features []database.NamespacedFeature) (affected []database.NullableAffectedNamespacedFeature, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		affected, err = vulnerability.FindAffectedNamespacedFeatures(t, features)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) PersistNamespaces(namespaces []database.Namespace) error {
-	return namespace.PersistNamespaces(tx.Tx, namespaces)
+	return tx.write(func(t *sql.Tx) error { return namespace.PersistNamespaces(t, namespaces) })
+}
+
+func (tx *pgSession) FindNamespaces() (namespaces []database.NamespaceWithVulnerabilityCount, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		namespaces, err = namespace.FindNamespaces(t)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindStatistics() (stats database.Statistics, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		stats, err = statistics.FindStatistics(t)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) PersistLayer(hash string, features []database.LayerFeature, namespaces []database.LayerNamespace, detectedBy []database.Detector) error {
-	return layer.PersistLayer(tx.Tx, hash, features, namespaces, detectedBy)
+	return tx.write(func(t *sql.Tx) error { return layer.PersistLayer(t, hash, features, namespaces, detectedBy) })
 }
 
-func (tx *pgSession) FindLayer(hash string) (database.Layer, bool, error) {
-	return layer.FindLayer(tx.Tx, hash)
+func (tx *pgSession) FindLayer(hash string) (l database.Layer, found bool, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		l, found, err = layer.FindLayer(t, hash)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) InsertVulnerabilities(vulns []database.VulnerabilityWithAffected) error {
-	return vulnerability.InsertVulnerabilities(tx.Tx, vulns)
+	return tx.write(func(t *sql.Tx) error { return vulnerability.InsertVulnerabilities(t, vulns) })
 }
 
-func (tx *pgSession) FindVulnerabilities(ids []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
-	return vulnerability.FindVulnerabilities(tx.Tx, ids)
+func (tx *pgSession) FindVulnerabilities(ids []database.VulnerabilityID) (vulns []database.NullableVulnerability, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		vulns, err = vulnerability.FindVulnerabilities(t, ids)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindDeletedVulnerabilities(ids []database.VulnerabilityID) (vulns []database.NullableVulnerability, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		vulns, err = vulnerability.FindDeletedVulnerabilities(t, ids)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindPagedVulnerabilities(namespace string, limit int, page pagination.Token) (vulnPage database.PagedVulnerabilities, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		vulnPage, err = vulnerability.FindPagedVulnerabilities(t, namespace, limit, page, tx.key)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindAffectedAncestries(vulnerabilityID database.VulnerabilityID, limit int, page pagination.Token) (ancestries database.PagedAffectedAncestries, found bool, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		ancestries, found, err = vulnerability.FindAffectedAncestries(t, vulnerabilityID, limit, page, tx.key)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) DeleteVulnerabilities(ids []database.VulnerabilityID) error {
-	return vulnerability.DeleteVulnerabilities(tx.Tx, ids)
+	return tx.write(func(t *sql.Tx) error { return vulnerability.DeleteVulnerabilities(t, ids) })
 }
 
 func (tx *pgSession) InsertVulnerabilityNotifications(notifications []database.VulnerabilityNotification) error {
-	return notification.InsertVulnerabilityNotifications(tx.Tx, notifications)
+	return tx.write(func(t *sql.Tx) error { return notification.InsertVulnerabilityNotifications(t, notifications) })
 }
 
 func (tx *pgSession) FindNewNotification(notifiedBefore time.Time) (hook database.NotificationHook, found bool, err error) {
-	return notification.FindNewNotification(tx.Tx, notifiedBefore)
+	err = tx.read(func(t *sql.Tx) (err error) {
+		hook, found, err = notification.FindNewNotification(t, notifiedBefore)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) FindVulnerabilityNotification(name string, limit int, oldVulnerabilityPage pagination.Token, newVulnerabilityPage pagination.Token) (noti database.VulnerabilityNotificationWithVulnerable, found bool, err error) {
-	return notification.FindVulnerabilityNotification(tx.Tx, name, limit, oldVulnerabilityPage, newVulnerabilityPage, tx.key)
+	err = tx.read(func(t *sql.Tx) (err error) {
+		noti, found, err = notification.FindVulnerabilityNotification(t, name, limit, oldVulnerabilityPage, newVulnerabilityPage, tx.key)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindNotifications(state database.NotificationState, limit int, page pagination.Token) (notiPage database.PagedNotifications, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		notiPage, err = notification.FindNotifications(t, state, limit, page, tx.key)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) MarkNotificationAsRead(name string) error {
-	return notification.MarkNotificationAsRead(tx.Tx, name)
+	return tx.write(func(t *sql.Tx) error { return notification.MarkNotificationAsRead(t, name) })
+}
+
+func (tx *pgSession) MarkNotificationAsFiltered(name string) error {
+	return tx.write(func(t *sql.Tx) error { return notification.MarkNotificationAsFiltered(t, name) })
 }
 
 func (tx *pgSession) DeleteNotification(name string) error {
-	return notification.DeleteNotification(tx.Tx, name)
+	return tx.write(func(t *sql.Tx) error { return notification.DeleteNotification(t, name) })
+}
+
+func (tx *pgSession) InsertDeadLetterNotification(deadLetter database.DeadLetterNotification) error {
+	return tx.write(func(t *sql.Tx) error { return notification.InsertDeadLetterNotification(t, deadLetter) })
+}
+
+func (tx *pgSession) FindDeadLetterNotifications() (deadLetters []database.DeadLetterNotification, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		deadLetters, err = notification.FindDeadLetterNotifications(t)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) RequeueDeadLetterNotification(name string) (found bool, err error) {
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		found, err = notification.RequeueDeadLetterNotification(t, name)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) UpdateKeyValue(key, value string) error {
-	return keyvalue.UpdateKeyValue(tx.Tx, key, value)
+	return tx.write(func(t *sql.Tx) error { return keyvalue.UpdateKeyValue(t, key, value) })
+}
+
+func (tx *pgSession) UpdateKeyValueWithTTL(key, value string, ttl time.Duration) error {
+	return tx.write(func(t *sql.Tx) error { return keyvalue.UpdateKeyValueWithTTL(t, key, value, ttl) })
+}
+
+func (tx *pgSession) PruneKeyValues() (pruned int, err error) {
+	err = tx.write(func(t *sql.Tx) (err error) {
+		pruned, err = keyvalue.PruneKeyValues(t)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) FindKeyValue(key string) (value string, found bool, err error) {
-	return keyvalue.FindKeyValue(tx.Tx, key)
+	err = tx.read(func(t *sql.Tx) (err error) {
+		value, found, err = keyvalue.FindKeyValue(t, key)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) InsertUpdaterRuns(runs []database.UpdaterRun) error {
+	return tx.write(func(t *sql.Tx) error { return updater.InsertUpdaterRuns(t, runs) })
+}
+
+func (tx *pgSession) FindUpdaterRuns(name string, limit int) (runs []database.UpdaterRun, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		runs, err = updater.FindUpdaterRuns(t, name, limit)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) InsertSuppressionRule(rule database.SuppressionRule) (id int64, err error) {
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		id, err = suppression.InsertSuppressionRule(t, rule)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindSuppressionRule(id int64) (rule database.SuppressionRule, found bool, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		rule, found, err = suppression.FindSuppressionRule(t, id)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) FindSuppressionRules() (rules database.SuppressionRules, err error) {
+	err = tx.read(func(t *sql.Tx) (err error) {
+		rules, err = suppression.FindSuppressionRules(t)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) UpdateSuppressionRule(rule database.SuppressionRule) (found bool, err error) {
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		found, err = suppression.UpdateSuppressionRule(t, rule)
+		return
+	})
+	return
+}
+
+func (tx *pgSession) DeleteSuppressionRule(id int64) (found bool, err error) {
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		found, err = suppression.DeleteSuppressionRule(t, id)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) AcquireLock(name, owner string, duration time.Duration) (acquired bool, expiration time.Time, err error) {
-	return lock.AcquireLock(tx.Tx, name, owner, duration)
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		acquired, expiration, err = lock.AcquireLock(t, name, owner, duration)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) ExtendLock(name, owner string, duration time.Duration) (extended bool, expiration time.Time, err error) {
-	return lock.ExtendLock(tx.Tx, name, owner, duration)
+	err = tx.writeOnce(func(t *sql.Tx) (err error) {
+		extended, expiration, err = lock.ExtendLock(t, name, owner, duration)
+		return
+	})
+	return
 }
 
 func (tx *pgSession) ReleaseLock(name, owner string) error {
-	return lock.ReleaseLock(tx.Tx, name, owner)
+	return tx.write(func(t *sql.Tx) error { return lock.ReleaseLock(t, name, owner) })
+}
+
+func (tx *pgSession) PruneLocks() (pruned int, err error) {
+	err = tx.write(func(t *sql.Tx) (err error) {
+		pruned, err = lock.PruneLocks(t)
+		return
+	})
+	return
 }
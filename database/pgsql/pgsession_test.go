@@ -15,13 +15,19 @@
 package pgsql
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"sync"
 	"testing"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/quay/clair/v3/database/pgsql/namespace"
 	"github.com/quay/clair/v3/database/pgsql/testutil"
+	"github.com/quay/clair/v3/database/pgsql/util"
 )
 
 const (
@@ -54,3 +60,168 @@ func TestConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+// faultConnector connects to a fake database whose statements and commits fail
+// with the queued errors.
+type faultConnector struct {
+	mu sync.Mutex
+
+	execErrs   []error
+	commitErrs []error
+
+	begins  int
+	execs   int
+	commits int
+}
+
+func (c *faultConnector) Connect(context.Context) (driver.Conn, error) { return faultConn{c}, nil }
+func (c *faultConnector) Driver() driver.Driver                        { return nil }
+
+// pop returns the first queued error, if any.
+func (c *faultConnector) pop(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
+}
+
+type faultConn struct{ c *faultConnector }
+
+func (conn faultConn) Prepare(query string) (driver.Stmt, error) { return faultStmt(conn), nil }
+func (conn faultConn) Close() error                              { return nil }
+func (conn faultConn) Begin() (driver.Tx, error) {
+	conn.c.mu.Lock()
+	defer conn.c.mu.Unlock()
+	conn.c.begins++
+	return faultTx(conn), nil
+}
+
+type faultTx struct{ c *faultConnector }
+
+func (tx faultTx) Rollback() error { return nil }
+func (tx faultTx) Commit() error {
+	tx.c.mu.Lock()
+	defer tx.c.mu.Unlock()
+	tx.c.commits++
+	return tx.c.pop(&tx.c.commitErrs)
+}
+
+type faultStmt struct{ c *faultConnector }
+
+func (stmt faultStmt) Close() error  { return nil }
+func (stmt faultStmt) NumInput() int { return -1 }
+func (stmt faultStmt) Exec([]driver.Value) (driver.Result, error) {
+	stmt.c.mu.Lock()
+	defer stmt.c.mu.Unlock()
+	stmt.c.execs++
+	if err := stmt.c.pop(&stmt.c.execErrs); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+func (stmt faultStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestSessionRetry(t *testing.T) {
+	serializationFailure := &pq.Error{Code: "40001"}
+	deadlock := &pq.Error{Code: "40P01"}
+	uniqueViolation := &pq.Error{Code: "23505"}
+
+	for _, test := range []struct {
+		name       string
+		execErrs   []error
+		commitErrs []error
+
+		err     error
+		begins  int
+		execs   int
+		commits int
+	}{
+		{
+			name:    "success",
+			begins:  1,
+			execs:   2,
+			commits: 1,
+		},
+		{
+			name:       "commit serialization failure",
+			commitErrs: []error{serializationFailure},
+			begins:     2,
+			execs:      4,
+			commits:    2,
+		},
+		{
+			name:     "statement deadlock",
+			execErrs: []error{nil, deadlock},
+			begins:   2,
+			// The first write is replayed before retrying the second one.
+			execs:   4,
+			commits: 1,
+		},
+		{
+			name:       "non-retryable failure",
+			commitErrs: []error{uniqueViolation},
+			err:        uniqueViolation,
+			begins:     1,
+			execs:      2,
+			commits:    1,
+		},
+		{
+			name:       "too many failures",
+			commitErrs: []error{serializationFailure, serializationFailure, serializationFailure, serializationFailure},
+			err:        serializationFailure,
+			begins:     maxTxAttempts,
+			execs:      2 * maxTxAttempts,
+			commits:    maxTxAttempts,
+		},
+	} {
+		connector := &faultConnector{execErrs: test.execErrs, commitErrs: test.commitErrs}
+		db := sql.OpenDB(connector)
+		store := &pgSQL{DB: db, config: Config{PaginationKey: testutil.TestPaginationKey.String()}}
+
+		session, err := store.Begin()
+		if !assert.Nil(t, err, test.name) {
+			continue
+		}
+
+		err = session.UpdateKeyValue("first", "value")
+		if assert.Nil(t, err, test.name) {
+			err = session.UpdateKeyValue("second", "value")
+		}
+		if assert.Nil(t, err, test.name) {
+			err = session.Commit()
+		}
+
+		if test.err == nil {
+			assert.Nil(t, err, test.name)
+		} else {
+			assert.True(t, errors.Is(err, test.err), "%s: %v", test.name, err)
+		}
+		assert.Equal(t, test.begins, connector.begins, test.name)
+		assert.Equal(t, test.execs, connector.execs, test.name)
+		assert.Equal(t, test.commits, connector.commits, test.name)
+
+		db.Close()
+	}
+}
+
+func TestSessionNotReplayedAfterWriteOnce(t *testing.T) {
+	connector := &faultConnector{commitErrs: []error{&pq.Error{Code: "40001"}}}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	store := &pgSQL{DB: db, config: Config{PaginationKey: testutil.TestPaginationKey.String()}}
+	session, err := store.Begin()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// The result of such a write could differ if it was replayed.
+	assert.Nil(t, session.(*instrumentedSession).session.(*pgSession).writeOnce(func(*sql.Tx) error { return nil }))
+	assert.True(t, util.IsErrRetryable(session.Commit()))
+	assert.Equal(t, 1, connector.begins)
+}
@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -42,8 +44,14 @@ func init() {
 type pgSQL struct {
 	*sql.DB
 
+	// replica is the optional read replica serving the read-only sessions.
+	replica *sql.DB
+
 	cache  *lru.ARCCache
 	config Config
+
+	// migrations is the migrations mode of the database.
+	migrations string
 }
 
 // Begin initiates a transaction to database.
@@ -51,18 +59,60 @@ type pgSQL struct {
 // The expected transaction isolation level in this implementation is "Read
 // Committed".
 func (pgSQL *pgSQL) Begin() (database.Session, error) {
+	return pgSQL.begin(pgSQL.DB, pgSQL.config.ReadOnly)
+}
+
+// BeginReadOnly initiates a read-only transaction to the read replica, if
+// any. It falls back to the primary database when the replica is
+// unreachable.
+func (pgSQL *pgSQL) BeginReadOnly() (database.Session, error) {
+	if pgSQL.replica != nil {
+		session, err := pgSQL.begin(pgSQL.replica, true)
+		if err == nil {
+			return session, nil
+		}
+
+		log.WithError(err).Warning("pgsql: could not begin a session on the read replica, falling back to the primary")
+	}
+
+	return pgSQL.begin(pgSQL.DB, true)
+}
+
+func (pgSQL *pgSQL) begin(db *sql.DB, readOnly bool) (database.Session, error) {
+	begin := func() (*sql.Tx, error) { return pgSQL.beginTx(db, readOnly) }
+	tx, err := begin()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &pgSession{
+		Tx:    tx,
+		key:   pagination.Must(pgSQL.config.paginationKey()),
+		begin: begin,
+	}
+
+	return &instrumentedSession{session: session, slowQueryThreshold: pgSQL.config.SlowQueryThreshold}, nil
+}
+
+// beginTx starts a transaction on the database.
+func (pgSQL *pgSQL) beginTx(db *sql.DB, readOnly bool) (*sql.Tx, error) {
 	opts := &sql.TxOptions{
-		ReadOnly: pgSQL.config.ReadOnly,
+		ReadOnly: readOnly,
 	}
 
-	tx, err := pgSQL.DB.BeginTx(context.Background(), opts)
+	tx, err := db.BeginTx(context.Background(), opts)
 	if err != nil {
 		return nil, err
 	}
-	return &pgSession{
-		Tx:  tx,
-		key: pagination.Must(pagination.KeyFromString(pgSQL.config.PaginationKey)),
-	}, nil
+
+	if timeout := pgSQL.config.StatementTimeout; timeout > 0 {
+		if _, err := tx.Exec(setStatementTimeout(timeout)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	return tx, nil
 }
 
 // Close closes the database and destroys if ManageDatabaseLifecycle has been specified in
@@ -72,6 +122,10 @@ func (pgSQL *pgSQL) Close() {
 		pgSQL.DB.Close()
 	}
 
+	if pgSQL.replica != nil {
+		pgSQL.replica.Close()
+	}
+
 	if pgSQL.config.ManageDatabaseLifecycle {
 		dbName, pgSourceURL, _ := parseConnectionString(pgSQL.config.Source)
 		dropDatabase(pgSourceURL, dbName)
@@ -88,6 +142,10 @@ func (pgSQL *pgSQL) ReadOnly() bool {
 }
 
 func (pgSQL *pgSQL) migrateDatabase() error {
+	if pgSQL.migrations == database.ManualMigrations {
+		return checkMigrations(pgSQL.DB)
+	}
+
 	if !pgSQL.ReadOnly() {
 		return pgSQLMigrateFn(pgSQL.DB)
 	}
@@ -95,18 +153,115 @@ func (pgSQL *pgSQL) migrateDatabase() error {
 	return nil
 }
 
-var pgSQLMigrateFn = migrateDatabase
+var (
+	pgSQLMigrateFn         = migrateDatabase
+	pgSQLMigrationStatusFn = migrations.GetStatus
+)
+
+// checkMigrations returns an error if the schema of the database is behind.
+func checkMigrations(db *sql.DB) error {
+	status, err := pgSQLMigrationStatusFn(db)
+	if err != nil {
+		return fmt.Errorf("pgsql: could not check migrations: %v", err)
+	}
+
+	if !status.UpToDate() {
+		return fmt.Errorf("pgsql: database schema is behind, migrations %v are pending: run 'clair migrate up'", status.Pending)
+	}
+
+	return nil
+}
 
 // Config is the configuration that is used by openDatabase.
 type Config struct {
 	Source    string
 	CacheSize int
 
+	// ReplicaSource is the optional connection string of a read replica of
+	// the database, serving the read-only sessions.
+	ReplicaSource string
+
 	ManageDatabaseLifecycle bool
 	FixturePath             string
 	PaginationKey           string
 	MaxOpenConnections      int
 	ReadOnly                bool
+
+	// PreviousPaginationKeys are the pagination keys which were rotated out,
+	// still accepted to decrypt in-flight pagination tokens.
+	PreviousPaginationKeys []string
+	// PaginationTTL is how long pagination tokens stay valid. They never
+	// expire when it is 0.
+	PaginationTTL time.Duration
+
+	// MaxIdleConnections is the maximum number of idle connections kept
+	// open. No idle connection is kept when it is 0.
+	MaxIdleConnections int
+	// ConnectionMaxLifetime is the duration after which a connection is
+	// closed instead of being reused. Connections are reused forever when it
+	// is 0.
+	ConnectionMaxLifetime time.Duration
+	// StatementTimeout is the duration after which a statement is cancelled.
+	// When it is 0, the timeout of the connection string or of the server is
+	// used.
+	StatementTimeout time.Duration
+	// SlowQueryThreshold is the duration from which session operations are
+	// logged as slow. No operation is logged when it is 0.
+	SlowQueryThreshold time.Duration
+}
+
+// validate returns an error if the configuration has invalid values.
+func (c Config) validate() error {
+	switch {
+	case c.MaxOpenConnections < 0:
+		return fmt.Errorf("pgsql: maxopenconnections must not be negative, got %d", c.MaxOpenConnections)
+	case c.MaxIdleConnections < 0:
+		return fmt.Errorf("pgsql: maxidleconnections must not be negative, got %d", c.MaxIdleConnections)
+	case c.MaxOpenConnections > 0 && c.MaxIdleConnections > c.MaxOpenConnections:
+		return fmt.Errorf("pgsql: maxidleconnections (%d) must not exceed maxopenconnections (%d)", c.MaxIdleConnections, c.MaxOpenConnections)
+	case c.ConnectionMaxLifetime < 0:
+		return fmt.Errorf("pgsql: connectionmaxlifetime must not be negative, got %s", c.ConnectionMaxLifetime)
+	case c.StatementTimeout < 0:
+		return fmt.Errorf("pgsql: statementtimeout must not be negative, got %s", c.StatementTimeout)
+	case c.StatementTimeout > 0 && c.StatementTimeout < time.Millisecond:
+		return fmt.Errorf("pgsql: statementtimeout must be at least 1ms, got %s", c.StatementTimeout)
+	case c.SlowQueryThreshold < 0:
+		return fmt.Errorf("pgsql: slowquerythreshold must not be negative, got %s", c.SlowQueryThreshold)
+	}
+
+	return nil
+}
+
+// configurePool applies the connection pool settings to the database.
+func (c Config) configurePool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConnections)
+	db.SetMaxIdleConns(c.MaxIdleConnections)
+	db.SetConnMaxLifetime(c.ConnectionMaxLifetime)
+}
+
+// setStatementTimeout returns the statement setting the timeout of the
+// statements of the current transaction.
+func setStatementTimeout(timeout time.Duration) string {
+	return "SET LOCAL statement_timeout = " + strconv.FormatInt(int64(timeout/time.Millisecond), 10)
+}
+
+// paginationKey returns the key securing the pagination tokens.
+func (c Config) paginationKey() (pagination.Key, error) {
+	key, err := pagination.KeyFromString(c.PaginationKey)
+	if err != nil {
+		return pagination.Key{}, err
+	}
+
+	previous := make([]pagination.Key, 0, len(c.PreviousPaginationKeys))
+	for _, keyString := range c.PreviousPaginationKeys {
+		k, err := pagination.KeyFromString(keyString)
+		if err != nil {
+			return pagination.Key{}, err
+		}
+		previous = append(previous, k)
+	}
+
+	return key.WithPreviousKeys(previous...).WithTTL(c.PaginationTTL), nil
 }
 
 // openDatabase opens a PostgresSQL-backed Datastore using the given
@@ -120,22 +275,20 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 	var err error
 
 	// Parse configuration.
-	pg.config = Config{
-		CacheSize: 16384,
-	}
-	bytes, err := yaml.Marshal(registrableComponentConfig.Options)
-	if err != nil {
-		return nil, fmt.Errorf("pgsql: could not load configuration: %v", err)
-	}
-	err = yaml.Unmarshal(bytes, &pg.config)
+	pg.config, err = parseConfig(registrableComponentConfig)
 	if err != nil {
-		return nil, fmt.Errorf("pgsql: could not load configuration: %v", err)
+		return nil, err
 	}
+	pg.migrations = registrableComponentConfig.Migrations
 
 	if pg.config.PaginationKey == "" {
 		panic("pagination key should be given")
 	}
 
+	if _, err := pg.config.paginationKey(); err != nil {
+		return nil, fmt.Errorf("pgsql: could not load pagination keys: %v", err)
+	}
+
 	dbName, pgSourceURL, err := parseConnectionString(pg.config.Source)
 	if err != nil {
 		return nil, err
@@ -156,14 +309,24 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 		return nil, fmt.Errorf("pgsql: could not open database: %v", err)
 	}
 
+	pg.config.configurePool(pg.DB)
+
 	// Verify database state.
 	if err = pg.DB.Ping(); err != nil {
 		pg.Close()
 		return nil, fmt.Errorf("pgsql: could not open database: %v", err)
 	}
 
-	if pg.config.MaxOpenConnections != 0 {
-		pg.DB.SetMaxOpenConns(pg.config.MaxOpenConnections)
+	// Open the read replica. It is not required to be reachable, the primary
+	// database serves the read-only sessions until it is.
+	if pg.config.ReplicaSource != "" {
+		pg.replica, err = sql.Open("postgres", pg.config.ReplicaSource)
+		if err != nil {
+			pg.Close()
+			return nil, fmt.Errorf("pgsql: could not open read replica: %v", err)
+		}
+
+		pg.config.configurePool(pg.replica)
 	}
 
 	// Run migrations.
@@ -198,6 +361,30 @@ func openDatabase(registrableComponentConfig database.RegistrableComponentConfig
 	return &pg, nil
 }
 
+// parseConfig parses and validates the configuration of the database.
+func parseConfig(registrableComponentConfig database.RegistrableComponentConfig) (Config, error) {
+	config := Config{
+		CacheSize:     16384,
+		PaginationTTL: time.Hour,
+	}
+	bytes, err := yaml.Marshal(registrableComponentConfig.Options)
+	if err != nil {
+		return config, fmt.Errorf("pgsql: could not load configuration: %v", err)
+	}
+	err = yaml.Unmarshal(bytes, &config)
+	if err != nil {
+		return config, fmt.Errorf("pgsql: could not load configuration: %v", err)
+	}
+
+	switch registrableComponentConfig.Migrations {
+	case "", database.AutoMigrations, database.ManualMigrations:
+	default:
+		return config, fmt.Errorf("pgsql: unknown migrations mode %q", registrableComponentConfig.Migrations)
+	}
+
+	return config, config.validate()
+}
+
 func parseConnectionString(source string) (dbName string, pgSourceURL string, err error) {
 	if source == "" {
 		return "", "", commonerr.NewBadRequestError("pgsql: no database connection string specified")
@@ -16,9 +16,17 @@ package pgsql
 
 import (
 	"database/sql"
+	"path"
 	"testing"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/datastoretest"
+	"github.com/quay/clair/v3/database/pgsql/migrations"
+	"github.com/quay/clair/v3/database/pgsql/testutil"
 )
 
 func TestCallsMigrateOnReadWriteDB(t *testing.T) {
@@ -60,3 +68,169 @@ func TestMigrateNotCalledOnReadOnlyDB(t *testing.T) {
 
 	assert.False(t, migrationCalled)
 }
+
+func TestManualMigrations(t *testing.T) {
+	migrate, status := pgSQLMigrateFn, pgSQLMigrationStatusFn
+	defer func() { pgSQLMigrateFn, pgSQLMigrationStatusFn = migrate, status }()
+
+	migrationCalled := false
+	pgSQLMigrateFn = func(db *sql.DB) error {
+		migrationCalled = true
+		return nil
+	}
+
+	testDB := pgSQL{migrations: database.ManualMigrations}
+
+	// A database whose schema is behind is refused.
+	pgSQLMigrationStatusFn = func(*sql.DB) (migrations.Status, error) {
+		return migrations.Status{Version: 2, Latest: 3, Pending: []int{3}}, nil
+	}
+	assert.NotNil(t, testDB.migrateDatabase())
+
+	pgSQLMigrationStatusFn = func(*sql.DB) (migrations.Status, error) {
+		return migrations.Status{Version: 3, Latest: 3}, nil
+	}
+	assert.Nil(t, testDB.migrateDatabase())
+	assert.False(t, migrationCalled)
+
+	// Unknown modes are rejected with the configuration.
+	for mode, valid := range map[string]bool{"": true, "auto": true, "manual": true, "sometimes": false} {
+		_, err := parseConfig(database.RegistrableComponentConfig{Migrations: mode})
+		assert.Equal(t, valid, err == nil, "%q: %v", mode, err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	for _, test := range []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{MaxOpenConnections: 10, MaxIdleConnections: 2, ConnectionMaxLifetime: time.Hour, StatementTimeout: time.Minute}, true},
+		{Config{MaxIdleConnections: 20}, true},
+		{Config{MaxOpenConnections: -1}, false},
+		{Config{MaxIdleConnections: -1}, false},
+		{Config{MaxOpenConnections: 10, MaxIdleConnections: 20}, false},
+		{Config{ConnectionMaxLifetime: -time.Second}, false},
+		{Config{StatementTimeout: -time.Second}, false},
+		{Config{StatementTimeout: time.Microsecond}, false},
+		{Config{SlowQueryThreshold: -time.Second}, false},
+	} {
+		err := test.config.validate()
+		assert.Equal(t, test.valid, err == nil, "%+v: %v", test.config, err)
+	}
+}
+
+func TestConfigurePool(t *testing.T) {
+	db, err := sql.Open("postgres", "postgresql://127.0.0.1:1/clair")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer db.Close()
+
+	Config{MaxOpenConnections: 7, MaxIdleConnections: 3, ConnectionMaxLifetime: time.Minute}.configurePool(db)
+	assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+}
+
+func TestStatementTimeout(t *testing.T) {
+	db, cleanup := testutil.CreateTestDB(t, "StatementTimeout")
+	defer cleanup()
+
+	store := &pgSQL{
+		DB: db,
+		config: Config{
+			PaginationKey:    testutil.TestPaginationKey.String(),
+			StatementTimeout: 100 * time.Millisecond,
+		},
+	}
+
+	session, err := store.Begin()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer session.Rollback()
+
+	_, err = session.(*instrumentedSession).session.(*pgSession).Exec("SELECT pg_sleep(5)")
+	if pqErr, ok := err.(*pq.Error); assert.True(t, ok, "unexpected error %v", err) {
+		// query_canceled
+		assert.Equal(t, pq.ErrorCode("57014"), pqErr.Code)
+	}
+}
+
+func TestBeginReadOnlyReplica(t *testing.T) {
+	primary, cleanupPrimary := testutil.CreateTestDB(t, "ReplicaPrimary")
+	defer cleanupPrimary()
+	replica, cleanupReplica := testutil.CreateTestDB(t, "ReplicaReplica")
+	defer cleanupReplica()
+
+	config := Config{PaginationKey: testutil.TestPaginationKey.String()}
+
+	// Tell the databases apart with a different value for the same key.
+	for db, value := range map[*sql.DB]string{primary: "primary", replica: "replica"} {
+		session, err := (&pgSQL{DB: db, config: config}).Begin()
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Nil(t, session.UpdateKeyValue("database", value))
+		assert.Nil(t, session.Commit())
+	}
+
+	store := &pgSQL{DB: primary, replica: replica, config: config}
+	assertReads := func(expected string, begin func() (database.Session, error)) {
+		session, err := begin()
+		if !assert.Nil(t, err) {
+			return
+		}
+		defer session.Rollback()
+
+		value, ok, err := session.FindKeyValue("database")
+		if assert.Nil(t, err) && assert.True(t, ok) {
+			assert.Equal(t, expected, value)
+		}
+	}
+
+	// Read-only sessions are served by the replica, the others by the
+	// primary.
+	assertReads("replica", store.BeginReadOnly)
+	assertReads("primary", store.Begin)
+
+	// Read-only sessions can't write.
+	session, err := store.BeginReadOnly()
+	if assert.Nil(t, err) {
+		assert.NotNil(t, session.UpdateKeyValue("database", "written"))
+		session.Rollback()
+	}
+
+	// An unreachable replica falls back to the primary.
+	unreachable, err := sql.Open("postgres", "postgresql://127.0.0.1:1/clair?sslmode=disable")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer unreachable.Close()
+
+	store.replica = unreachable
+	assertReads("primary", store.BeginReadOnly)
+
+	// Without a replica, the primary serves every session.
+	store.replica = nil
+	assertReads("primary", store.BeginReadOnly)
+}
+
+// testDatastore is a datastore dropping its test database once closed.
+type testDatastore struct {
+	*pgSQL
+	cleanup func()
+}
+
+func (store testDatastore) Close() {
+	store.pgSQL.Close()
+	store.cleanup()
+}
+
+func TestSession(t *testing.T) {
+	datastoretest.RunSessionTests(t, func(t *testing.T) database.Datastore {
+		db, cleanup := testutil.CreateTestDB(t, "session_"+path.Base(t.Name()))
+		store := &pgSQL{DB: db, config: Config{PaginationKey: testutil.TestPaginationKey.String()}}
+		return testDatastore{store, cleanup}
+	})
+}
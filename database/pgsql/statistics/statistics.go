@@ -0,0 +1,74 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statistics computes the aggregate numbers of the content of the
+// database.
+package statistics
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
+	"github.com/quay/clair/v3/database/pgsql/util"
+)
+
+const (
+	// The severities are ordered as their enum, from the lowest to the
+	// highest.
+	searchVulnerabilityCounts = `
+		SELECT n.name, n.version_format, v.severity, COUNT(*)
+		FROM vulnerability AS v, namespace AS n
+		WHERE v.namespace_id = n.id AND v.deleted_at IS NULL
+		GROUP BY n.name, n.version_format, v.severity
+		ORDER BY n.name, n.version_format, v.severity`
+
+	countContent = `
+		SELECT
+			(SELECT COUNT(*) FROM ancestry),
+			(SELECT COUNT(*) FROM layer),
+			(SELECT COUNT(*) FROM namespaced_feature)`
+)
+
+// FindStatistics computes the aggregate numbers of the vulnerabilities,
+// ancestries, layers and features stored.
+func FindStatistics(tx *sql.Tx) (database.Statistics, error) {
+	defer monitoring.ObserveQueryTime("findStatistics", "all", time.Now())
+
+	stats := database.Statistics{Vulnerabilities: []database.VulnerabilityCount{}}
+	rows, err := tx.Query(searchVulnerabilityCounts)
+	if err != nil {
+		return database.Statistics{}, util.HandleError("searchVulnerabilityCounts", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var count database.VulnerabilityCount
+		if err := rows.Scan(&count.Namespace.Name, &count.Namespace.VersionFormat, &count.Severity, &count.Count); err != nil {
+			return database.Statistics{}, util.HandleError("searchVulnerabilityCounts", err)
+		}
+		stats.Vulnerabilities = append(stats.Vulnerabilities, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return database.Statistics{}, util.HandleError("searchVulnerabilityCounts", err)
+	}
+
+	if err := tx.QueryRow(countContent).Scan(&stats.Ancestries, &stats.Layers, &stats.Features); err != nil {
+		return database.Statistics{}, util.HandleError("countContent", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/testutil"
+)
+
+func TestFindStatistics(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindStatistics")
+	defer cleanup()
+
+	// The deleted vulnerability of debian:7 isn't counted.
+	stats, err := FindStatistics(tx)
+	if assert.Nil(t, err) {
+		debian7 := database.Namespace{Name: "debian:7", VersionFormat: "dpkg"}
+		assert.Equal(t, database.Statistics{
+			Vulnerabilities: []database.VulnerabilityCount{
+				{Namespace: debian7, Severity: database.UnknownSeverity, Count: 1},
+				{Namespace: debian7, Severity: database.HighSeverity, Count: 1},
+			},
+			Ancestries: 4,
+			Layers:     6,
+			Features:   4,
+		}, stats)
+	}
+}
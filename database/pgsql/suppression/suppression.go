@@ -0,0 +1,164 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/guregu/null/zero"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
+	"github.com/quay/clair/v3/database/pgsql/util"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+const (
+	insertSuppressionRule = `
+		INSERT INTO Suppression_Rule(vulnerability, namespace, feature, reason, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	searchSuppressionRule = `
+		SELECT id, vulnerability, namespace, feature, reason, created_at, expires_at
+		FROM Suppression_Rule
+		WHERE id = $1`
+
+	searchSuppressionRules = `
+		SELECT id, vulnerability, namespace, feature, reason, created_at, expires_at
+		FROM Suppression_Rule
+		ORDER BY id`
+
+	updateSuppressionRule = `
+		UPDATE Suppression_Rule
+		SET vulnerability = $2, namespace = $3, feature = $4, reason = $5, expires_at = $6
+		WHERE id = $1`
+
+	removeSuppressionRule = `DELETE FROM Suppression_Rule WHERE id = $1`
+)
+
+var errInvalidRule = commonerr.NewBadRequestError("suppression rule should not have empty vulnerability name")
+
+// InsertSuppressionRule stores a suppression rule, created now, and returns
+// its ID.
+func InsertSuppressionRule(tx *sql.Tx, rule database.SuppressionRule) (int64, error) {
+	if !rule.Valid() {
+		return 0, errInvalidRule
+	}
+
+	defer monitoring.ObserveQueryTime("insertSuppressionRule", "all", time.Now())
+	var id int64
+	if err := tx.QueryRow(insertSuppressionRule, rule.Vulnerability, rule.Namespace, rule.Feature, rule.Reason, time.Now().UTC(), zero.TimeFrom(rule.Expires)).Scan(&id); err != nil {
+		return 0, util.HandleError("insertSuppressionRule", err)
+	}
+
+	return id, nil
+}
+
+// FindSuppressionRule retrieves a suppression rule, expired or not.
+func FindSuppressionRule(tx *sql.Tx, id int64) (database.SuppressionRule, bool, error) {
+	defer monitoring.ObserveQueryTime("findSuppressionRule", "all", time.Now())
+	rule, err := scanSuppressionRule(tx.QueryRow(searchSuppressionRule, id))
+	if err == sql.ErrNoRows {
+		return database.SuppressionRule{}, false, nil
+	} else if err != nil {
+		return database.SuppressionRule{}, false, util.HandleError("searchSuppressionRule", err)
+	}
+
+	return rule, true, nil
+}
+
+// FindSuppressionRules retrieves every suppression rule, expired or not,
+// ordered by ID.
+func FindSuppressionRules(tx *sql.Tx) (database.SuppressionRules, error) {
+	defer monitoring.ObserveQueryTime("findSuppressionRules", "all", time.Now())
+	rows, err := tx.Query(searchSuppressionRules)
+	if err != nil {
+		return nil, util.HandleError("searchSuppressionRules", err)
+	}
+	defer rows.Close()
+
+	rules := database.SuppressionRules{}
+	for rows.Next() {
+		rule, err := scanSuppressionRule(rows)
+		if err != nil {
+			return nil, util.HandleError("searchSuppressionRules", err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchSuppressionRules", err)
+	}
+
+	return rules, nil
+}
+
+// UpdateSuppressionRule replaces the suppression rule of the same ID,
+// keeping its creation time.
+func UpdateSuppressionRule(tx *sql.Tx, rule database.SuppressionRule) (bool, error) {
+	if !rule.Valid() {
+		return false, errInvalidRule
+	}
+
+	defer monitoring.ObserveQueryTime("updateSuppressionRule", "all", time.Now())
+	r, err := tx.Exec(updateSuppressionRule, rule.ID, rule.Vulnerability, rule.Namespace, rule.Feature, rule.Reason, zero.TimeFrom(rule.Expires))
+	if err != nil {
+		return false, util.HandleError("updateSuppressionRule", err)
+	}
+
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return false, util.HandleError("updateSuppressionRule", err)
+	}
+
+	return affected > 0, nil
+}
+
+// DeleteSuppressionRule removes a suppression rule.
+func DeleteSuppressionRule(tx *sql.Tx, id int64) (bool, error) {
+	defer monitoring.ObserveQueryTime("deleteSuppressionRule", "all", time.Now())
+	r, err := tx.Exec(removeSuppressionRule, id)
+	if err != nil {
+		return false, util.HandleError("removeSuppressionRule", err)
+	}
+
+	affected, err := r.RowsAffected()
+	if err != nil {
+		return false, util.HandleError("removeSuppressionRule", err)
+	}
+
+	return affected > 0, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSuppressionRule(row scanner) (database.SuppressionRule, error) {
+	var (
+		rule    database.SuppressionRule
+		expires zero.Time
+	)
+
+	if err := row.Scan(&rule.ID, &rule.Vulnerability, &rule.Namespace, &rule.Feature, &rule.Reason, &rule.Created, &expires); err != nil {
+		return database.SuppressionRule{}, err
+	}
+
+	rule.Expires = expires.Time
+	return rule, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package suppression
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/testutil"
+)
+
+func TestSuppressionRules(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "SuppressionRules")
+	defer cleanup()
+
+	expires := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := InsertSuppressionRule(tx, database.SuppressionRule{Namespace: "debian:10"})
+	assert.Error(t, err)
+
+	id, err := InsertSuppressionRule(tx, database.SuppressionRule{Vulnerability: "CVE-2019-0001", Reason: "accepted risk"})
+	assert.Nil(t, err)
+
+	other, err := InsertSuppressionRule(tx, database.SuppressionRule{Vulnerability: "CVE-2019-0002", Namespace: "debian:10", Feature: "openssl", Expires: expires})
+	assert.Nil(t, err)
+
+	rule, ok, err := FindSuppressionRule(tx, id)
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.Equal(t, "CVE-2019-0001", rule.Vulnerability)
+		assert.Equal(t, "accepted risk", rule.Reason)
+		assert.False(t, rule.Created.IsZero())
+		// The rule never expires.
+		assert.True(t, rule.Expires.IsZero())
+	}
+
+	rules, err := FindSuppressionRules(tx)
+	if assert.Nil(t, err) && assert.Len(t, rules, 2) {
+		assert.Equal(t, id, rules[0].ID)
+		assert.Equal(t, other, rules[1].ID)
+		assert.Equal(t, "openssl", rules[1].Feature)
+		assert.True(t, rules[1].Expires.Equal(expires))
+	}
+
+	rule.Expires = expires
+	ok, err = UpdateSuppressionRule(tx, rule)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	updated, ok, err := FindSuppressionRule(tx, id)
+	if assert.Nil(t, err) && assert.True(t, ok) {
+		assert.True(t, updated.Expires.Equal(expires))
+		assert.True(t, updated.Created.Equal(rule.Created))
+	}
+
+	ok, err = UpdateSuppressionRule(tx, database.SuppressionRule{ID: -1, Vulnerability: "CVE-2019-0001"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = DeleteSuppressionRule(tx, id)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = FindSuppressionRule(tx, id)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = DeleteSuppressionRule(tx, id)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
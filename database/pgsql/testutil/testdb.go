@@ -34,7 +34,7 @@ var TestPaginationKey = pagination.Must(pagination.NewKey())
 
 var userDBCount = `SELECT count(datname) FROM pg_database WHERE datistemplate = FALSE AND datname != 'postgres';`
 
-func CreateAndConnectTestDB(t *testing.T, testName string) (*sql.DB, func()) {
+func CreateAndConnectTestDB(t testing.TB, testName string) (*sql.DB, func()) {
 	uri := "postgres@127.0.0.1:5432"
 	connectionTemplate := "postgresql://%s?sslmode=disable"
 	if envURI := os.Getenv("CLAIR_TEST_PGSQL"); envURI != "" {
@@ -64,7 +64,7 @@ func CreateAndConnectTestDB(t *testing.T, testName string) (*sql.DB, func()) {
 	}
 }
 
-func cleanupTestDB(t *testing.T, name string, db, testDB *sql.DB) {
+func cleanupTestDB(t testing.TB, name string, db, testDB *sql.DB) {
 	t.Logf("cleaning up temporary database %s", name)
 	if db == nil {
 		panic("db is none")
@@ -100,7 +100,7 @@ func cleanupTestDB(t *testing.T, name string, db, testDB *sql.DB) {
 	}
 }
 
-func CreateTestDB(t *testing.T, testName string) (*sql.DB, func()) {
+func CreateTestDB(t testing.TB, testName string) (*sql.DB, func()) {
 	connection, cleanup := CreateAndConnectTestDB(t, testName)
 	err := migrate.NewPostgresMigrator(connection).Exec(migrate.Up, migrations.Migrations...)
 	if err != nil {
@@ -110,7 +110,7 @@ func CreateTestDB(t *testing.T, testName string) (*sql.DB, func()) {
 	return connection, cleanup
 }
 
-func CreateTestDBWithFixture(t *testing.T, testName string) (*sql.DB, func()) {
+func CreateTestDBWithFixture(t testing.TB, testName string) (*sql.DB, func()) {
 	connection, cleanup := CreateTestDB(t, testName)
 	session, err := connection.Begin()
 	if err != nil {
@@ -0,0 +1,87 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updater
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/monitoring"
+	"github.com/quay/clair/v3/database/pgsql/util"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+const (
+	insertUpdaterRun = `
+		INSERT INTO Updater_Run(updater, started_at, finished_at, vulnerabilities, error)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	searchUpdaterRuns = `
+		SELECT updater, started_at, finished_at, vulnerabilities, error
+		FROM Updater_Run
+		WHERE updater = $1
+		ORDER BY started_at DESC, id DESC
+		LIMIT $2`
+)
+
+// InsertUpdaterRuns stores the summaries of updater runs.
+func InsertUpdaterRuns(tx *sql.Tx, runs []database.UpdaterRun) error {
+	for _, run := range runs {
+		if run.Updater == "" {
+			return commonerr.NewBadRequestError("updater run should not have empty updater name")
+		}
+	}
+
+	defer monitoring.ObserveQueryTime("insertUpdaterRuns", "all", time.Now())
+	for _, run := range runs {
+		if _, err := tx.Exec(insertUpdaterRun, run.Updater, run.Started, run.Finished, run.Vulnerabilities, run.Error); err != nil {
+			return util.HandleError("insertUpdaterRun", err)
+		}
+	}
+
+	return nil
+}
+
+// FindUpdaterRuns retrieves the summaries of the latest runs of an updater,
+// the most recent first.
+func FindUpdaterRuns(tx *sql.Tx, updater string, limit int) ([]database.UpdaterRun, error) {
+	if limit <= 0 {
+		return nil, commonerr.NewBadRequestError("updater run limit should be positive")
+	}
+
+	defer monitoring.ObserveQueryTime("findUpdaterRuns", "all", time.Now())
+	rows, err := tx.Query(searchUpdaterRuns, updater, limit)
+	if err != nil {
+		return nil, util.HandleError("searchUpdaterRuns", err)
+	}
+	defer rows.Close()
+
+	runs := []database.UpdaterRun{}
+	for rows.Next() {
+		var run database.UpdaterRun
+		if err := rows.Scan(&run.Updater, &run.Started, &run.Finished, &run.Vulnerabilities, &run.Error); err != nil {
+			return nil, util.HandleError("searchUpdaterRuns", err)
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchUpdaterRuns", err)
+	}
+
+	return runs, nil
+}
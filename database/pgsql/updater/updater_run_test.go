@@ -0,0 +1,61 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package updater
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/database/pgsql/testutil"
+)
+
+func TestUpdaterRuns(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "UpdaterRuns")
+	defer cleanup()
+
+	started := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	runs := []database.UpdaterRun{
+		{Updater: "debian", Started: started, Finished: started.Add(time.Minute), Vulnerabilities: 10},
+		{Updater: "debian", Started: started.Add(time.Hour), Finished: started.Add(time.Hour + time.Minute), Error: "could not download"},
+		{Updater: "ubuntu", Started: started, Finished: started.Add(time.Minute), Vulnerabilities: 5},
+	}
+
+	assert.Error(t, InsertUpdaterRuns(tx, []database.UpdaterRun{{Started: started}}))
+	assert.Nil(t, InsertUpdaterRuns(tx, runs))
+
+	found, err := FindUpdaterRuns(tx, "debian", 10)
+	if assert.Nil(t, err) && assert.Len(t, found, 2) {
+		// The most recent run comes first.
+		assert.Equal(t, "could not download", found[0].Error)
+		assert.True(t, found[0].Started.Equal(runs[1].Started))
+		assert.Equal(t, 10, found[1].Vulnerabilities)
+		assert.True(t, found[1].Finished.Equal(runs[0].Finished))
+	}
+
+	found, err = FindUpdaterRuns(tx, "debian", 1)
+	if assert.Nil(t, err) && assert.Len(t, found, 1) {
+		assert.Equal(t, "could not download", found[0].Error)
+	}
+
+	found, err = FindUpdaterRuns(tx, "alpine", 10)
+	assert.Nil(t, err)
+	assert.Empty(t, found)
+
+	_, err = FindUpdaterRuns(tx, "debian", 0)
+	assert.Error(t, err)
+}
@@ -16,6 +16,7 @@ package util
 
 import (
 	"database/sql"
+	"errors"
 	"strings"
 
 	"github.com/quay/clair/v3/database"
@@ -31,6 +32,13 @@ func IsErrUniqueViolation(err error) bool {
 	return ok && pqErr.Code == "23505"
 }
 
+// IsErrRetryable determines if the given error aborted a transaction which may
+// succeed if retried, i.e. a serialization failure or a deadlock.
+func IsErrRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
+
 // HandleError logs an error with an extra description and masks the error if it's an SQL one.
 // The function ensures we never return plain SQL errors and leak anything.
 // The function should be used for every database query error.
@@ -55,6 +63,11 @@ func HandleError(desc string, err error) error {
 
 	logrus.WithError(err).WithField("Description", desc).Error("database: handled database error")
 	monitoring.PromErrorsTotal.WithLabelValues(desc).Inc()
+	if IsErrRetryable(err) {
+		// The cause is kept for the session to retry the transaction.
+		return database.NewStorageErrorWithInternalError(database.ErrBackendException.Error(), err)
+	}
+
 	if _, o := err.(*pq.Error); o || err == sql.ErrTxDone || strings.HasPrefix(err.Error(), "sql:") {
 		return database.ErrBackendException
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/database/pgsql/feature"
 	"github.com/quay/clair/v3/database/pgsql/monitoring"
+	"github.com/quay/clair/v3/database/pgsql/namespace"
 	"github.com/quay/clair/v3/database/pgsql/page"
 	"github.com/quay/clair/v3/database/pgsql/util"
 	"github.com/quay/clair/v3/ext/versionfmt"
@@ -34,7 +35,7 @@ import (
 
 const (
 	searchVulnerability = `
-		SELECT v.id, v.description, v.link, v.severity, v.metadata, n.version_format 
+		SELECT v.id, v.description, v.link, v.severity, v.metadata, v.aliases, v.content_hash, v.updater, n.version_format
 		FROM vulnerability AS v, namespace AS n
 		WHERE v.namespace_id = n.id
 		AND v.name = $1
@@ -42,19 +43,60 @@ const (
 		AND v.deleted_at IS NULL
 		`
 
+	searchDeletedVulnerability = `
+		SELECT v.id, v.description, v.link, v.severity, v.metadata, v.aliases, v.content_hash, v.updater, n.version_format
+		FROM vulnerability AS v, namespace AS n
+		WHERE v.namespace_id = n.id
+		AND v.name = $1
+		AND n.name = $2
+		AND v.deleted_at IS NOT NULL
+		ORDER BY v.deleted_at DESC, v.id DESC
+		LIMIT 1`
+
 	searchVulnerabilityByID = `
-		SELECT v.name, v.description, v.link, v.severity, v.metadata, n.name, n.version_format
+		SELECT v.name, v.description, v.link, v.severity, v.metadata, v.aliases, n.name, n.version_format
 		FROM vulnerability AS v, namespace AS n
 		WHERE v.namespace_id = n.id
 			AND v.id = $1`
 
-	insertVulnerability = `
-		WITH ns AS (
-			SELECT id FROM namespace WHERE name = $6 AND version_format = $7
-		)
-		INSERT INTO Vulnerability(namespace_id, name, description, link, severity, metadata, created_at)
-		VALUES((SELECT id FROM ns), $1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
-		RETURNING id`
+	searchNamespaceVulnerability = `
+		SELECT v.id, v.name
+		FROM vulnerability AS v, namespace AS n
+		WHERE v.namespace_id = n.id
+			AND n.name = $1
+			AND v.id >= $2
+			AND v.deleted_at IS NULL
+		ORDER BY v.id ASC
+		LIMIT $3`
+
+	searchAffectedAncestry = `
+		SELECT DISTINCT a.id, a.name
+		FROM vulnerability_affected_namespaced_feature AS vanf,
+			ancestry_feature AS af, ancestry_layer AS al, ancestry AS a
+		WHERE vanf.vulnerability_id = $1
+			AND af.namespaced_feature_id = vanf.namespaced_feature_id
+			AND al.id = af.ancestry_layer_id
+			AND a.id = al.ancestry_id
+			AND a.id >= $2
+		ORDER BY a.id ASC
+		LIMIT $3`
+
+	searchAffectedAncestryFeature = `
+		SELECT DISTINCT al.ancestry_id, f.name, f.version, f.version_format, t.name, n.name, n.version_format
+		FROM vulnerability_affected_namespaced_feature AS vanf,
+			ancestry_feature AS af, ancestry_layer AS al,
+			namespaced_feature AS nf, feature AS f, feature_type AS t, namespace AS n
+		WHERE vanf.vulnerability_id = $1
+			AND al.ancestry_id = ANY($2)
+			AND af.namespaced_feature_id = vanf.namespaced_feature_id
+			AND al.id = af.ancestry_layer_id
+			AND nf.id = vanf.namespaced_feature_id
+			AND f.id = nf.feature_id
+			AND t.id = f.type
+			AND n.id = nf.namespace_id
+		ORDER BY al.ancestry_id, f.name, f.version`
+
+	searchCurrentTimestamp = `SELECT CURRENT_TIMESTAMP`
 
 	removeVulnerability = `
 		UPDATE Vulnerability
@@ -78,10 +120,10 @@ const (
 		 LIMIT $3;`
 )
 
-func queryInvalidateVulnerabilityCache(count int) string {
-	return fmt.Sprintf(`DELETE FROM vulnerability_affected_feature 
-		WHERE vulnerability_id IN (%s)`,
-		util.QueryString(1, count))
+// queryAllocateIDs reserves IDs of the serial column id of a table, so that
+// rows can be bulk copied knowing their IDs.
+func queryAllocateIDs(table string) string {
+	return fmt.Sprintf(`SELECT nextval(pg_get_serial_sequence('%s', 'id')) FROM generate_series(1, $1)`, table)
 }
 
 // NOTE(Sida): Every search query can only have count less than postgres set
@@ -105,6 +147,32 @@ func querySearchLastDeletedVulnerabilityID(count int) string {
 		util.QueryString(2, count))
 }
 
+// queryPruneDeletedVulnerabilityAffected deletes the affected features, and
+// so the namespaced features they affected, of the deleted versions of the
+// vulnerabilities but their latest one, which is the old side of the
+// notification of their reintroduction. The versions still referenced by a
+// notification are kept.
+func queryPruneDeletedVulnerabilityAffected(count int) string {
+	return fmt.Sprintf(`
+		DELETE FROM vulnerability_affected_feature AS vaf
+		USING vulnerability AS v, namespace AS n
+		WHERE vaf.vulnerability_id = v.id
+			AND v.namespace_id = n.id
+			AND (v.name, n.name) IN (%s)
+			AND v.deleted_at IS NOT NULL
+			AND EXISTS (
+				SELECT 1 FROM vulnerability AS later
+				WHERE later.namespace_id = v.namespace_id
+					AND later.name = v.name
+					AND later.deleted_at IS NOT NULL
+					AND (later.deleted_at, later.id) > (v.deleted_at, v.id))
+			AND NOT EXISTS (
+				SELECT 1 FROM vulnerability_notification AS noti
+				WHERE noti.deleted_at IS NULL
+					AND v.id IN (noti.old_vulnerability_id, noti.new_vulnerability_id))`,
+		util.QueryString(2, count))
+}
+
 func querySearchNotDeletedVulnerabilityID(count int) string {
 	return fmt.Sprintf(`
 		SELECT v.id, v.name, n.name FROM vulnerability AS v, namespace AS n
@@ -130,11 +198,24 @@ type affectedFeatureRows struct {
 
 func FindVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
 	defer monitoring.ObserveQueryTime("findVulnerabilities", "", time.Now())
+	return findVulnerabilities(tx, searchVulnerability, vulnerabilities)
+}
+
+// FindDeletedVulnerabilities retrieves the most recently deleted version of
+// the vulnerabilities.
+func FindDeletedVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+	defer monitoring.ObserveQueryTime("findDeletedVulnerabilities", "", time.Now())
+	return findVulnerabilities(tx, searchDeletedVulnerability, vulnerabilities)
+}
+
+// findVulnerabilities loads the vulnerabilities found by the search query,
+// which selects at most one version of a vulnerability by name and namespace.
+func findVulnerabilities(tx *sql.Tx, search string, vulnerabilities []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
 	resultVuln := make([]database.NullableVulnerability, len(vulnerabilities))
 	vulnIDMap := map[int64][]*database.NullableVulnerability{}
 
 	//TODO(Sida): Change to bulk search.
-	stmt, err := tx.Prepare(searchVulnerability)
+	stmt, err := tx.Prepare(search)
 	if err != nil {
 		return nil, err
 	}
@@ -142,8 +223,10 @@ func FindVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID)
 	// load vulnerabilities
 	for i, key := range vulnerabilities {
 		var (
-			id   sql.NullInt64
-			vuln = database.NullableVulnerability{
+			id      sql.NullInt64
+			hash    sql.NullString
+			updater sql.NullString
+			vuln    = database.NullableVulnerability{
 				VulnerabilityWithAffected: database.VulnerabilityWithAffected{
 					Vulnerability: database.Vulnerability{
 						Name: key.Name,
@@ -161,6 +244,9 @@ func FindVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID)
 			&vuln.Link,
 			&vuln.Severity,
 			&vuln.Metadata,
+			pq.Array(&vuln.Aliases),
+			&hash,
+			&updater,
 			&vuln.Namespace.VersionFormat,
 		)
 
@@ -169,6 +255,8 @@ func FindVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID)
 			return nil, util.HandleError("searchVulnerability", err)
 		}
 		vuln.Valid = id.Valid
+		vuln.ContentHash = hash.String
+		vuln.Updater = updater.String
 		resultVuln[i] = vuln
 		if id.Valid {
 			vulnIDMap[id.Int64] = append(vulnIDMap[id.Int64], &resultVuln[i])
@@ -210,8 +298,86 @@ func FindVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID)
 	return resultVuln, nil
 }
 
+// FindPagedVulnerabilities retrieves a page of the vulnerabilities of a
+// namespace, ordered by ID.
+func FindPagedVulnerabilities(tx *sql.Tx, namespace string, limit int, currentToken pagination.Token, key pagination.Key) (database.PagedVulnerabilities, error) {
+	defer monitoring.ObserveQueryTime("findPagedVulnerabilities", "", time.Now())
+
+	vulnPage := database.PagedVulnerabilities{Limit: limit}
+	currentPage := page.Page{}
+	if currentToken != pagination.FirstPageToken {
+		if err := key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return vulnPage, err
+		}
+	}
+
+	// the last result is used for the next page's startID
+	rows, err := tx.Query(searchNamespaceVulnerability, namespace, currentPage.StartID, limit+1)
+	if err != nil {
+		return vulnPage, util.HandleError("searchNamespaceVulnerability", err)
+	}
+	defer rows.Close()
+
+	var (
+		ids     []int64
+		vulnIDs []database.VulnerabilityID
+	)
+	for rows.Next() {
+		var (
+			id     int64
+			vulnID = database.VulnerabilityID{Namespace: namespace}
+		)
+
+		if err := rows.Scan(&id, &vulnID.Name); err != nil {
+			return vulnPage, util.HandleError("searchNamespaceVulnerability", err)
+		}
+
+		ids = append(ids, id)
+		vulnIDs = append(vulnIDs, vulnID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return vulnPage, util.HandleError("searchNamespaceVulnerability", err)
+	}
+
+	if len(vulnIDs) > limit {
+		vulnPage.Next, err = key.MarshalToken(page.Page{StartID: ids[limit]})
+		if err != nil {
+			return vulnPage, err
+		}
+
+		vulnIDs = vulnIDs[:limit]
+	} else {
+		vulnPage.End = true
+	}
+
+	vulns, err := findVulnerabilities(tx, searchVulnerability, vulnIDs)
+	if err != nil {
+		return vulnPage, err
+	}
+
+	for _, vuln := range vulns {
+		if vuln.Valid {
+			vulnPage.Vulnerabilities = append(vulnPage.Vulnerabilities, vuln.VulnerabilityWithAffected)
+		}
+	}
+
+	vulnPage.Current, err = key.MarshalToken(currentPage)
+	if err != nil {
+		return vulnPage, err
+	}
+
+	return vulnPage, nil
+}
+
 func InsertVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityWithAffected) error {
 	defer monitoring.ObserveQueryTime("insertVulnerabilities", "all", time.Now())
+	// prune the affected features of the deleted versions of reintroduced
+	// vulnerabilities
+	if err := PruneDeletedVulnerabilityAffected(tx, vulnerabilities); err != nil {
+		return err
+	}
+
 	// bulk insert vulnerabilities
 	vulnIDs, err := insertVulnerabilities(tx, vulnerabilities)
 	if err != nil {
@@ -227,37 +393,75 @@ func InsertVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityW
 	return CacheVulnerabiltyAffectedNamespacedFeature(tx, vulnFeatureMap)
 }
 
+// PruneDeletedVulnerabilityAffected deletes the affected features kept for the
+// older deleted versions of the vulnerabilities, which aren't referenced by
+// any notification, so that they don't accumulate as the vulnerabilities are
+// deleted and reintroduced.
+func PruneDeletedVulnerabilityAffected(tx *sql.Tx, vulnerabilities []database.VulnerabilityWithAffected) error {
+	if len(vulnerabilities) == 0 {
+		return nil
+	}
+
+	// Prevent InsertNamespacedFeatures to modify it.
+	if err := LockFeatureVulnerabilityCache(tx); err != nil {
+		return err
+	}
+
+	keys := make([]interface{}, len(vulnerabilities)*2)
+	for i, vuln := range vulnerabilities {
+		keys[i*2] = vuln.Name
+		keys[i*2+1] = vuln.Namespace.Name
+	}
+
+	if _, err := tx.Exec(queryPruneDeletedVulnerabilityAffected(len(vulnerabilities)), keys...); err != nil {
+		return util.HandleError("pruneDeletedVulnerabilityAffected", err)
+	}
+
+	return nil
+}
+
 // insertVulnerabilityAffected inserts a set of vulnerability affected features for each vulnerability provided.
 //
 // i_th vulnerabilityIDs corresponds to i_th vulnerabilities provided.
 func InsertVulnerabilityAffected(tx *sql.Tx, vulnerabilityIDs []int64, vulnerabilities []database.VulnerabilityWithAffected) (map[int64]affectedFeatureRows, error) {
-	var (
-		vulnFeature = map[int64]affectedFeatureRows{}
-		affectedID  int64
-	)
+	vulnFeature := map[int64]affectedFeatureRows{}
 
 	types, err := feature.GetFeatureTypeMap(tx)
 	if err != nil {
 		return nil, err
 	}
 
-	stmt, err := tx.Prepare(insertVulnerabilityAffected)
+	count := 0
+	for _, vuln := range vulnerabilities {
+		count += len(vuln.Affected)
+	}
+
+	affectedIDs, err := allocateIDs(tx, "vulnerability_affected_feature", count)
 	if err != nil {
-		return nil, util.HandleError("insertVulnerabilityAffected", err)
+		return nil, err
 	}
 
-	defer stmt.Close()
-	for i, vuln := range vulnerabilities {
-		// affected feature row ID -> affected feature
-		affectedFeatures := map[int64]database.AffectedFeature{}
-		for _, f := range vuln.Affected {
-			err := stmt.QueryRow(vulnerabilityIDs[i], f.FeatureName, f.AffectedVersion, types.ByName[f.FeatureType], f.FixedInVersion).Scan(&affectedID)
-			if err != nil {
-				return nil, util.HandleError("insertVulnerabilityAffected", err)
+	err = copyIn(tx, "vulnerability_affected_feature", []string{"id", "vulnerability_id", "feature_name", "affected_version", "feature_type", "fixedin"}, func(stmt *sql.Stmt) error {
+		next := 0
+		for i, vuln := range vulnerabilities {
+			// affected feature row ID -> affected feature
+			affectedFeatures := map[int64]database.AffectedFeature{}
+			for _, f := range vuln.Affected {
+				affectedID := affectedIDs[next]
+				next++
+
+				if _, err := stmt.Exec(affectedID, vulnerabilityIDs[i], f.FeatureName, f.AffectedVersion, types.ByName[f.FeatureType], f.FixedInVersion); err != nil {
+					return err
+				}
+				affectedFeatures[affectedID] = f
 			}
-			affectedFeatures[affectedID] = f
+			vulnFeature[vulnerabilityIDs[i]] = affectedFeatureRows{rows: affectedFeatures}
 		}
-		vulnFeature[vulnerabilityIDs[i]] = affectedFeatureRows{rows: affectedFeatures}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return vulnFeature, nil
@@ -265,13 +469,11 @@ func InsertVulnerabilityAffected(tx *sql.Tx, vulnerabilityIDs []int64, vulnerabi
 
 // insertVulnerabilities inserts a set of unique vulnerabilities into database,
 // under the assumption that all vulnerabilities are valid.
+//
+// The vulnerabilities are copied in bulk, their IDs are reserved beforehand.
 func insertVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityWithAffected) ([]int64, error) {
-	var (
-		vulnID  int64
-		vulnIDs = make([]int64, 0, len(vulnerabilities))
-		vulnMap = map[database.VulnerabilityID]struct{}{}
-	)
-
+	vulnMap := map[database.VulnerabilityID]struct{}{}
+	namespaces := make([]database.Namespace, 0, len(vulnerabilities))
 	for _, v := range vulnerabilities {
 		key := database.VulnerabilityID{
 			Name:      v.Name,
@@ -283,29 +485,106 @@ func insertVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityW
 			return nil, errors.New("inserting duplicated vulnerabilities is not allowed")
 		}
 		vulnMap[key] = struct{}{}
+		namespaces = append(namespaces, v.Namespace)
 	}
 
-	//TODO(Sida): Change to bulk insert.
-	stmt, err := tx.Prepare(insertVulnerability)
+	if len(vulnerabilities) == 0 {
+		return nil, nil
+	}
+
+	// Vulnerabilities of an unknown namespace are stored without namespace.
+	namespaces = database.DeduplicateNamespaces(namespaces...)
+	ids, err := namespace.FindNamespaceIDs(tx, namespaces)
 	if err != nil {
-		return nil, util.HandleError("insertVulnerability", err)
+		return nil, err
 	}
 
-	defer stmt.Close()
-	for _, vuln := range vulnerabilities {
-		err := stmt.QueryRow(vuln.Name, vuln.Description,
-			vuln.Link, &vuln.Severity, &vuln.Metadata,
-			vuln.Namespace.Name, vuln.Namespace.VersionFormat).Scan(&vulnID)
-		if err != nil {
-			return nil, util.HandleError("insertVulnerability", err)
+	namespaceIDs := make(map[database.Namespace]sql.NullInt64, len(namespaces))
+	for i, ns := range namespaces {
+		namespaceIDs[ns] = ids[i]
+	}
+
+	vulnIDs, err := allocateIDs(tx, "vulnerability", len(vulnerabilities))
+	if err != nil {
+		return nil, err
+	}
+
+	var now time.Time
+	if err := tx.QueryRow(searchCurrentTimestamp).Scan(&now); err != nil {
+		return nil, util.HandleError("searchCurrentTimestamp", err)
+	}
+
+	err = copyIn(tx, "vulnerability", []string{"id", "namespace_id", "name", "description", "link", "severity", "metadata", "aliases", "content_hash", "updater", "created_at"}, func(stmt *sql.Stmt) error {
+		for i, vuln := range vulnerabilities {
+			if _, err := stmt.Exec(vulnIDs[i], namespaceIDs[vuln.Namespace], vuln.Name, vuln.Description,
+				vuln.Link, &vuln.Severity, &vuln.Metadata, pq.Array(vuln.Aliases), vuln.ContentHash, vuln.Updater, now); err != nil {
+				return err
+			}
 		}
 
-		vulnIDs = append(vulnIDs, vulnID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return vulnIDs, nil
 }
 
+// allocateIDs reserves count IDs of the serial column id of a table.
+func allocateIDs(tx *sql.Tx, table string, count int) ([]int64, error) {
+	if count == 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(queryAllocateIDs(table), count)
+	if err != nil {
+		return nil, util.HandleError("allocateIDs", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, count)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, util.HandleError("allocateIDs", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("allocateIDs", err)
+	}
+
+	return ids, nil
+}
+
+// copyIn bulk inserts into the columns of a table the rows executed by copy
+// on the COPY statement.
+func copyIn(tx *sql.Tx, table string, columns []string, copy func(stmt *sql.Stmt) error) error {
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		return util.HandleError("copyIn "+table, err)
+	}
+
+	if err := copy(stmt); err != nil {
+		stmt.Close()
+		return util.HandleError("copyIn "+table, err)
+	}
+
+	// Rows are only sent once the COPY statement is flushed.
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return util.HandleError("copyIn "+table, err)
+	}
+
+	if err := stmt.Close(); err != nil {
+		return util.HandleError("copyIn "+table, err)
+	}
+
+	return nil
+}
+
 func LockFeatureVulnerabilityCache(tx *sql.Tx) error {
 	_, err := tx.Exec(lockVulnerabilityAffects)
 	if err != nil {
@@ -371,18 +650,17 @@ func CacheVulnerabiltyAffectedNamespacedFeature(tx *sql.Tx, affected map[int64]a
 		}
 	}
 
-	//TODO(Sida): Change to bulk insert.
-	for _, r := range relation {
-		result, err := tx.Exec(insertVulnerabilityAffectedNamespacedFeature, r.vulnerabilityID, r.namespacedFeatureID, r.addedBy)
-		if err != nil {
-			return util.HandleError("insertVulnerabilityAffectedNamespacedFeature", err)
-		}
-
-		if num, err := result.RowsAffected(); err == nil {
-			if num <= 0 {
-				return errors.New("Nothing cached in database")
+	if len(relation) > 0 {
+		err := copyIn(tx, "vulnerability_affected_namespaced_feature", []string{"vulnerability_id", "namespaced_feature_id", "added_by"}, func(stmt *sql.Stmt) error {
+			for _, r := range relation {
+				if _, err := stmt.Exec(r.vulnerabilityID, r.namespacedFeatureID, r.addedBy); err != nil {
+					return err
+				}
 			}
-		} else {
+
+			return nil
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -391,43 +669,15 @@ func CacheVulnerabiltyAffectedNamespacedFeature(tx *sql.Tx, affected map[int64]a
 	return nil
 }
 
+// DeleteVulnerabilities soft-deletes the vulnerabilities. Their affected
+// features and the namespaced features they affected are kept, so that the
+// notifications of their changes still list the ancestries they affected, but
+// deleted vulnerabilities are ignored when searching what affects features.
 func DeleteVulnerabilities(tx *sql.Tx, vulnerabilities []database.VulnerabilityID) error {
 	defer monitoring.ObserveQueryTime("DeleteVulnerability", "all", time.Now())
 
-	vulnIDs, err := MarkVulnerabilitiesAsDeleted(tx, vulnerabilities)
-	if err != nil {
-		return err
-	}
-
-	if err := InvalidateVulnerabilityCache(tx, vulnIDs); err != nil {
-		return err
-	}
-	return nil
-}
-
-func InvalidateVulnerabilityCache(tx *sql.Tx, vulnerabilityIDs []int64) error {
-	if len(vulnerabilityIDs) == 0 {
-		return nil
-	}
-
-	// Prevent InsertNamespacedFeatures to modify it.
-	err := LockFeatureVulnerabilityCache(tx)
-	if err != nil {
-		return err
-	}
-
-	//TODO(Sida): Make a nicer interface for bulk inserting.
-	keys := make([]interface{}, len(vulnerabilityIDs))
-	for i, id := range vulnerabilityIDs {
-		keys[i] = id
-	}
-
-	_, err = tx.Exec(queryInvalidateVulnerabilityCache(len(vulnerabilityIDs)), keys...)
-	if err != nil {
-		return util.HandleError("removeVulnerabilityAffectedFeature", err)
-	}
-
-	return nil
+	_, err := MarkVulnerabilitiesAsDeleted(tx, vulnerabilities)
+	return err
 }
 
 func MarkVulnerabilitiesAsDeleted(tx *sql.Tx, vulnerabilities []database.VulnerabilityID) ([]int64, error) {
@@ -527,6 +777,7 @@ func FindPagedVulnerableAncestries(tx *sql.Tx, vulnID int64, limit int, currentT
 		&vulnPage.Link,
 		&vulnPage.Severity,
 		&vulnPage.Metadata,
+		pq.Array(&vulnPage.Aliases),
 		&vulnPage.Namespace.Name,
 		&vulnPage.Namespace.VersionFormat,
 	); err != nil {
@@ -575,3 +826,125 @@ func FindPagedVulnerableAncestries(tx *sql.Tx, vulnID int64, limit int, currentT
 
 	return vulnPage, nil
 }
+
+// FindAffectedAncestries retrieves a page of the ancestries affected by a
+// vulnerability which isn't deleted, ordered by ID, with their affected
+// features.
+func FindAffectedAncestries(tx *sql.Tx, vulnerability database.VulnerabilityID, limit int, currentToken pagination.Token, key pagination.Key) (database.PagedAffectedAncestries, bool, error) {
+	defer monitoring.ObserveQueryTime("findAffectedAncestries", "", time.Now())
+
+	ancestryPage := database.PagedAffectedAncestries{Limit: limit}
+	currentPage := page.Page{}
+	if currentToken != pagination.FirstPageToken {
+		if err := key.UnmarshalToken(currentToken, &currentPage); err != nil {
+			return ancestryPage, false, err
+		}
+	}
+
+	ids, err := FindNotDeletedVulnerabilityIDs(tx, []database.VulnerabilityID{vulnerability})
+	if err != nil {
+		return ancestryPage, false, err
+	}
+
+	if !ids[0].Valid {
+		return ancestryPage, false, nil
+	}
+	vulnID := ids[0].Int64
+
+	// the last result is used for the next page's startID
+	rows, err := tx.Query(searchAffectedAncestry, vulnID, currentPage.StartID, limit+1)
+	if err != nil {
+		return ancestryPage, false, util.HandleError("searchAffectedAncestry", err)
+	}
+
+	ancestries := []affectedAncestry{}
+	for rows.Next() {
+		var ancestry affectedAncestry
+		if err := rows.Scan(&ancestry.id, &ancestry.name); err != nil {
+			rows.Close()
+			return ancestryPage, false, util.HandleError("searchAffectedAncestry", err)
+		}
+		ancestries = append(ancestries, ancestry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return ancestryPage, false, util.HandleError("searchAffectedAncestry", err)
+	}
+
+	if len(ancestries) > limit {
+		ancestryPage.Next, err = key.MarshalToken(page.Page{StartID: ancestries[limit].id})
+		if err != nil {
+			return ancestryPage, false, err
+		}
+
+		ancestries = ancestries[:limit]
+	} else {
+		ancestryPage.End = true
+	}
+
+	ancestryIDs := make([]int64, 0, len(ancestries))
+	for _, ancestry := range ancestries {
+		ancestryIDs = append(ancestryIDs, ancestry.id)
+	}
+
+	features, err := findAffectedAncestryFeatures(tx, vulnID, ancestryIDs)
+	if err != nil {
+		return ancestryPage, false, err
+	}
+
+	for _, ancestry := range ancestries {
+		ancestryPage.Ancestries = append(ancestryPage.Ancestries, database.AffectedAncestry{
+			Name:     ancestry.name,
+			Features: features[ancestry.id],
+		})
+	}
+
+	ancestryPage.Current, err = key.MarshalToken(currentPage)
+	if err != nil {
+		return ancestryPage, false, err
+	}
+
+	return ancestryPage, true, nil
+}
+
+// findAffectedAncestryFeatures returns the features of the ancestries which
+// are affected by the vulnerability, by ancestry ID.
+func findAffectedAncestryFeatures(tx *sql.Tx, vulnID int64, ancestryIDs []int64) (map[int64][]database.NamespacedFeature, error) {
+	features := map[int64][]database.NamespacedFeature{}
+	if len(ancestryIDs) == 0 {
+		return features, nil
+	}
+
+	rows, err := tx.Query(searchAffectedAncestryFeature, vulnID, pq.Array(ancestryIDs))
+	if err != nil {
+		return nil, util.HandleError("searchAffectedAncestryFeature", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ancestryID int64
+			feature    database.NamespacedFeature
+		)
+
+		if err := rows.Scan(
+			&ancestryID,
+			&feature.Name,
+			&feature.Version,
+			&feature.VersionFormat,
+			&feature.Type,
+			&feature.Namespace.Name,
+			&feature.Namespace.VersionFormat,
+		); err != nil {
+			return nil, util.HandleError("searchAffectedAncestryFeature", err)
+		}
+
+		features[ancestryID] = append(features[ancestryID], feature)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, util.HandleError("searchAffectedAncestryFeature", err)
+	}
+
+	return features, nil
+}
@@ -36,11 +36,6 @@ const (
 		AND vaf.feature_type = f.type
 		AND vaf.vulnerability_id = v.id
 		AND v.deleted_at IS NULL`
-	insertVulnerabilityAffected = `
-		INSERT INTO vulnerability_affected_feature(vulnerability_id, feature_name, affected_version, feature_type, fixedin)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING ID
-	`
 	searchVulnerabilityAffected = `
 	SELECT vulnerability_id, feature_name, affected_version, t.name, fixedin 
 	FROM vulnerability_affected_feature AS vaf, feature_type AS t
@@ -27,7 +27,7 @@ import (
 const (
 	searchNamespacedFeaturesVulnerabilities = `
 	SELECT vanf.namespaced_feature_id, v.name, v.description, v.link, 
-		v.severity, v.metadata, vaf.fixedin, n.name, n.version_format
+		v.severity, v.metadata, v.aliases, vaf.fixedin, n.name, n.version_format
 	FROM vulnerability_affected_namespaced_feature AS vanf, 
 		Vulnerability AS v,
 		vulnerability_affected_feature AS vaf,
@@ -39,10 +39,6 @@ const (
 		AND v.deleted_at IS NULL`
 
 	lockVulnerabilityAffects = `LOCK vulnerability_affected_namespaced_feature IN SHARE ROW EXCLUSIVE MODE`
-
-	insertVulnerabilityAffectedNamespacedFeature = `
-		INSERT INTO vulnerability_affected_namespaced_feature(vulnerability_id, namespaced_feature_id, added_by)
-		VALUES ($1, $2, $3)`
 )
 
 func queryPersistVulnerabilityAffectedNamespacedFeature(count int) string {
@@ -91,6 +87,7 @@ func FindAffectedNamespacedFeatures(tx *sql.Tx, features []database.NamespacedFe
 			&vuln.Link,
 			&vuln.Severity,
 			&vuln.Metadata,
+			pq.Array(&vuln.Aliases),
 			&vuln.FixedInVersion,
 			&vuln.Namespace.Name,
 			&vuln.Namespace.VersionFormat,
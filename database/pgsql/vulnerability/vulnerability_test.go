@@ -18,7 +18,9 @@ import (
 	"database/sql"
 	"math/rand"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pborman/uuid"
 	"github.com/stretchr/testify/assert"
@@ -27,9 +29,11 @@ import (
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/database/pgsql/feature"
 	"github.com/quay/clair/v3/database/pgsql/namespace"
+	"github.com/quay/clair/v3/database/pgsql/page"
 	"github.com/quay/clair/v3/database/pgsql/testutil"
 	"github.com/quay/clair/v3/ext/versionfmt"
 	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/pkg/pagination"
 	"github.com/quay/clair/v3/pkg/strutil"
 )
 
@@ -61,10 +65,12 @@ func TestInsertVulnerabilities(t *testing.T) {
 		Name:      "valid",
 		Namespace: ns2,
 		Severity:  database.UnknownSeverity,
+		Aliases:   []string{"DSA-0000-1"},
 	}
 
 	vwa2 := database.VulnerabilityWithAffected{
 		Vulnerability: v2,
+		Updater:       "debian",
 	}
 
 	tx, err := store.Begin()
@@ -93,6 +99,8 @@ func TestInsertVulnerabilities(t *testing.T) {
 	vulns, err := FindVulnerabilities(tx, []database.VulnerabilityID{{Name: "valid", Namespace: "debian:7"}})
 	if assert.Nil(t, err) && assert.Len(t, vulns, 1) {
 		assert.True(t, vulns[0].Valid)
+		assert.Equal(t, "debian", vulns[0].Updater)
+		assert.Equal(t, []string{"DSA-0000-1"}, vulns[0].Aliases)
 	}
 
 	tx = testutil.RestartTransaction(store, tx, false)
@@ -106,6 +114,125 @@ func TestInsertVulnerabilities(t *testing.T) {
 	require.Nil(t, tx.Rollback())
 }
 
+// benchmarkVulnerabilities returns a corpus of vulnerabilities, as the updater
+// inserts on its first run.
+func benchmarkVulnerabilities() []database.VulnerabilityWithAffected {
+	ns := database.Namespace{Name: "debian:7", VersionFormat: dpkg.ParserName}
+	vulnerabilities := make([]database.VulnerabilityWithAffected, 10000)
+	for i := range vulnerabilities {
+		vulnerabilities[i] = database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:      "CVE-" + strconv.Itoa(i),
+				Namespace: ns,
+				Severity:  database.UnknownSeverity,
+			},
+		}
+
+		for _, name := range []string{"openssl", "libssl", "wechat"} {
+			vulnerabilities[i].Affected = append(vulnerabilities[i].Affected, database.AffectedFeature{
+				FeatureType:     database.SourcePackage,
+				Namespace:       ns,
+				FeatureName:     name,
+				AffectedVersion: "2.0",
+				FixedInVersion:  "2.0",
+			})
+		}
+	}
+
+	return vulnerabilities
+}
+
+// BenchmarkInsertVulnerabilities measures bootstrapping a corpus of
+// vulnerabilities, as the updater does on its first run.
+func BenchmarkInsertVulnerabilities(b *testing.B) {
+	store, cleanup := testutil.CreateTestDBWithFixture(b, "BenchmarkInsertVulnerabilities")
+	defer cleanup()
+
+	vulnerabilities := benchmarkVulnerabilities()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx, err := store.Begin()
+		require.Nil(b, err)
+		require.Nil(b, InsertVulnerabilities(tx, vulnerabilities))
+		require.Nil(b, tx.Rollback())
+	}
+}
+
+const (
+	insertVulnerabilityRow = `
+		WITH ns AS (
+			SELECT id FROM namespace WHERE name = $6 AND version_format = $7
+		)
+		INSERT INTO Vulnerability(namespace_id, name, description, link, severity, metadata, content_hash, created_at)
+		VALUES((SELECT id FROM ns), $1, $2, $3, $4, $5, $8, CURRENT_TIMESTAMP)
+		RETURNING id`
+
+	insertVulnerabilityAffectedRow = `
+		INSERT INTO vulnerability_affected_feature(vulnerability_id, feature_name, affected_version, feature_type, fixedin)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ID`
+)
+
+// insertVulnerabilityRows inserts the vulnerabilities and their affected
+// features a row at a time, as InsertVulnerabilities did before it copied
+// them in bulk.
+func insertVulnerabilityRows(tx *sql.Tx, vulnerabilities []database.VulnerabilityWithAffected) error {
+	types, err := feature.GetFeatureTypeMap(tx)
+	if err != nil {
+		return err
+	}
+
+	vulnStmt, err := tx.Prepare(insertVulnerabilityRow)
+	if err != nil {
+		return err
+	}
+	defer vulnStmt.Close()
+
+	affectedStmt, err := tx.Prepare(insertVulnerabilityAffectedRow)
+	if err != nil {
+		return err
+	}
+	defer affectedStmt.Close()
+
+	for _, vuln := range vulnerabilities {
+		var vulnID, affectedID int64
+		err := vulnStmt.QueryRow(vuln.Name, vuln.Description, vuln.Link, &vuln.Severity, &vuln.Metadata,
+			vuln.Namespace.Name, vuln.Namespace.VersionFormat, vuln.ContentHash).Scan(&vulnID)
+		if err != nil {
+			return err
+		}
+
+		for _, f := range vuln.Affected {
+			err := affectedStmt.QueryRow(vulnID, f.FeatureName, f.AffectedVersion, types.ByName[f.FeatureType], f.FixedInVersion).Scan(&affectedID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// BenchmarkInsertVulnerabilityRows measures bootstrapping the corpus of
+// BenchmarkInsertVulnerabilities a row at a time, for comparison with the COPY
+// of InsertVulnerabilities. No speedup is asserted: the ratio depends on the
+// PostgreSQL instance the benchmarks run against.
+func BenchmarkInsertVulnerabilityRows(b *testing.B) {
+	store, cleanup := testutil.CreateTestDBWithFixture(b, "BenchmarkInsertVulnerabilityRows")
+	defer cleanup()
+
+	vulnerabilities := benchmarkVulnerabilities()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tx, err := store.Begin()
+		require.Nil(b, err)
+		require.Nil(b, insertVulnerabilityRows(tx, vulnerabilities))
+		require.Nil(b, tx.Rollback())
+	}
+}
+
 func TestCachingVulnerable(t *testing.T) {
 	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "CachingVulnerable")
 	defer cleanup()
@@ -290,6 +417,143 @@ func TestFindVulnerabilities(t *testing.T) {
 	}
 }
 
+func TestFindPagedVulnerabilities(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindPagedVulnerabilities")
+	defer cleanup()
+
+	key := pagination.Must(pagination.NewKey())
+
+	// The deleted vulnerability of the namespace is not paginated.
+	first, err := FindPagedVulnerabilities(tx, "debian:7", 1, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	assert.False(t, first.End)
+	assert.Equal(t, 1, first.Limit)
+	assert.Equal(t, testutil.MustMarshalToken(key, page.Page{StartID: 2}), first.Next)
+	if assert.Len(t, first.Vulnerabilities, 1) {
+		assert.Equal(t, "CVE-OPENSSL-1-DEB7", first.Vulnerabilities[0].Name)
+		assert.Equal(t, "dpkg", first.Vulnerabilities[0].Namespace.VersionFormat)
+		assert.Len(t, first.Vulnerabilities[0].Affected, 2)
+	}
+
+	second, err := FindPagedVulnerabilities(tx, "debian:7", 1, first.Next, key)
+	require.Nil(t, err)
+	assert.True(t, second.End)
+	assert.Equal(t, first.Next, second.Current)
+	if assert.Len(t, second.Vulnerabilities, 1) {
+		assert.Equal(t, "CVE-NOPE", second.Vulnerabilities[0].Name)
+		assert.Empty(t, second.Vulnerabilities[0].Affected)
+	}
+
+	all, err := FindPagedVulnerabilities(tx, "debian:7", 10, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	assert.True(t, all.End)
+	assert.Len(t, all.Vulnerabilities, 2)
+
+	none, err := FindPagedVulnerabilities(tx, "debian:8", 10, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	assert.True(t, none.End)
+	assert.Empty(t, none.Vulnerabilities)
+
+	_, err = FindPagedVulnerabilities(tx, "debian:7", 1, pagination.Token("random non sense"), key)
+	assert.Equal(t, pagination.ErrInvalidToken, err)
+}
+
+func TestFindAffectedAncestries(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindAffectedAncestries")
+	defer cleanup()
+
+	key := pagination.Must(pagination.NewKey())
+	vulnerability := database.VulnerabilityID{Name: "CVE-OPENSSL-1-DEB7", Namespace: "debian:7"}
+	openssl := database.NamespacedFeature{
+		Feature:   database.Feature{Name: "openssl", Version: "1.0", VersionFormat: "dpkg", Type: database.SourcePackage},
+		Namespace: database.Namespace{Name: "debian:7", VersionFormat: "dpkg"},
+	}
+
+	first, found, err := FindAffectedAncestries(tx, vulnerability, 1, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	require.True(t, found)
+	assert.False(t, first.End)
+	assert.Equal(t, testutil.MustMarshalToken(key, page.Page{StartID: 4}), first.Next)
+	assert.Equal(t, []database.AffectedAncestry{{Name: "ancestry-3", Features: []database.NamespacedFeature{openssl}}}, first.Ancestries)
+
+	second, found, err := FindAffectedAncestries(tx, vulnerability, 1, first.Next, key)
+	require.Nil(t, err)
+	require.True(t, found)
+	assert.True(t, second.End)
+	assert.Equal(t, []database.AffectedAncestry{{Name: "ancestry-4", Features: []database.NamespacedFeature{openssl}}}, second.Ancestries)
+
+	// A vulnerability affecting no ancestry is found, a deleted one isn't.
+	none, found, err := FindAffectedAncestries(tx, database.VulnerabilityID{Name: "CVE-NOPE", Namespace: "debian:7"}, 1, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	assert.True(t, found)
+	assert.True(t, none.End)
+	assert.Empty(t, none.Ancestries)
+
+	_, found, err = FindAffectedAncestries(tx, database.VulnerabilityID{Name: "CVE-DELETED", Namespace: "debian:7"}, 1, pagination.FirstPageToken, key)
+	require.Nil(t, err)
+	assert.False(t, found)
+
+	_, _, err = FindAffectedAncestries(tx, vulnerability, 1, pagination.Token("random non sense"), key)
+	assert.Equal(t, pagination.ErrInvalidToken, err)
+}
+
+func TestFindAffectedAncestriesLoad(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindAffectedAncestriesLoad")
+	defer cleanup()
+
+	// Every other generated ancestry has the vulnerable openssl 1.0, the
+	// others only have ourchat 0.5.
+	const count = 4000
+	for _, query := range []string{
+		`INSERT INTO ancestry (name) SELECT 'load-' || i FROM generate_series(1, $1) AS i`,
+		`INSERT INTO ancestry_layer (ancestry_id, layer_id, ancestry_index)
+			SELECT id, 2, 0 FROM ancestry WHERE name LIKE 'load-%'`,
+		`INSERT INTO ancestry_feature (ancestry_layer_id, namespaced_feature_id, feature_detector_id, namespace_detector_id)
+			SELECT al.id, CASE WHEN a.id % 2 = 0 THEN 2 ELSE 1 END, 2, 1
+			FROM ancestry_layer AS al, ancestry AS a
+			WHERE a.id = al.ancestry_id AND a.name LIKE 'load-%'`,
+	} {
+		var err error
+		if strings.Contains(query, "$1") {
+			_, err = tx.Exec(query, count)
+		} else {
+			_, err = tx.Exec(query)
+		}
+		require.Nil(t, err)
+	}
+
+	var expected int
+	require.Nil(t, tx.QueryRow(`SELECT COUNT(*) FROM ancestry WHERE name LIKE 'load-%' AND id % 2 = 0`).Scan(&expected))
+
+	key := pagination.Must(pagination.NewKey())
+	vulnerability := database.VulnerabilityID{Name: "CVE-OPENSSL-1-DEB7", Namespace: "debian:7"}
+
+	start := time.Now()
+	names := map[string]struct{}{}
+	token := pagination.FirstPageToken
+	for pages := 0; ; pages++ {
+		require.True(t, pages <= (expected+2)/100, "too many pages")
+
+		ancestries, found, err := FindAffectedAncestries(tx, vulnerability, 100, token, key)
+		require.Nil(t, err)
+		require.True(t, found)
+
+		for _, ancestry := range ancestries.Ancestries {
+			assert.Len(t, ancestry.Features, 1)
+			names[ancestry.Name] = struct{}{}
+		}
+
+		if ancestries.End {
+			break
+		}
+		token = ancestries.Next
+	}
+	t.Logf("paginated %d affected ancestries in %v", len(names), time.Since(start))
+
+	// ancestry-3 and ancestry-4 are affected too.
+	assert.Len(t, names, expected+2)
+}
+
 func TestDeleteVulnerabilities(t *testing.T) {
 	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "DeleteVulnerabilities")
 	defer cleanup()
@@ -316,6 +580,61 @@ func TestDeleteVulnerabilities(t *testing.T) {
 	}
 }
 
+func TestPruneDeletedVulnerabilityAffected(t *testing.T) {
+	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "PruneDeletedVulnerabilityAffected")
+	defer cleanup()
+
+	ns := database.Namespace{
+		Name:          "debian:8",
+		VersionFormat: dpkg.ParserName,
+	}
+
+	vuln := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      "CVE-REINTRODUCED",
+			Namespace: ns,
+			Severity:  database.HighSeverity,
+		},
+		Affected: []database.AffectedFeature{
+			{
+				Namespace:       ns,
+				FeatureName:     "openssl",
+				FeatureType:     database.SourcePackage,
+				AffectedVersion: "2.0",
+				FixedInVersion:  "2.1",
+			},
+		},
+	}
+
+	id := database.VulnerabilityID{Name: vuln.Name, Namespace: ns.Name}
+	countAffected := func(vulnID int64) int {
+		var count int
+		require.Nil(t, tx.QueryRow(`SELECT COUNT(*) FROM vulnerability_affected_feature WHERE vulnerability_id = $1`, vulnID).Scan(&count))
+		return count
+	}
+
+	// Delete two versions of the vulnerability.
+	var deleted []int64
+	for i := 0; i < 2; i++ {
+		require.Nil(t, InsertVulnerabilities(tx, []database.VulnerabilityWithAffected{vuln}))
+		require.Nil(t, DeleteVulnerabilities(tx, []database.VulnerabilityID{id}))
+
+		ids, err := FindLatestDeletedVulnerabilityIDs(tx, []database.VulnerabilityID{id})
+		require.Nil(t, err)
+		require.True(t, ids[0].Valid)
+		deleted = append(deleted, ids[0].Int64)
+	}
+
+	require.Equal(t, 1, countAffected(deleted[0]))
+	require.Equal(t, 1, countAffected(deleted[1]))
+
+	// Reintroducing it prunes the older deleted version, but keeps the
+	// latest one as the old side of the notification of its reintroduction.
+	require.Nil(t, InsertVulnerabilities(tx, []database.VulnerabilityWithAffected{vuln}))
+	assert.Equal(t, 0, countAffected(deleted[0]))
+	assert.Equal(t, 1, countAffected(deleted[1]))
+}
+
 func TestFindVulnerabilityIDs(t *testing.T) {
 	tx, cleanup := testutil.CreateTestTxWithFixtures(t, "FindVulnerabilityIDs")
 	defer cleanup()
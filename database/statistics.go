@@ -0,0 +1,39 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+// VulnerabilityCount is the number of vulnerabilities of a namespace with a
+// severity.
+type VulnerabilityCount struct {
+	Namespace Namespace
+	Severity  Severity
+	Count     int
+}
+
+// Statistics are aggregate numbers of the content of the database.
+type Statistics struct {
+	// Vulnerabilities are the numbers of vulnerabilities which aren't deleted,
+	// per namespace and severity, ordered by namespace and from the lowest
+	// severity to the highest. The severities without vulnerabilities are
+	// omitted.
+	Vulnerabilities []VulnerabilityCount
+
+	// Ancestries is the number of ancestries scanned.
+	Ancestries int
+	// Layers is the number of layers scanned.
+	Layers int
+	// Features is the number of namespaced features indexed.
+	Features int
+}
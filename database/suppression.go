@@ -0,0 +1,101 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import "time"
+
+// SuppressionRule suppresses the findings of a vulnerability, e.g. because
+// its risk is accepted or because it is a known false positive.
+type SuppressionRule struct {
+	ID int64
+
+	// Vulnerability is the name of the suppressed vulnerability.
+	Vulnerability string
+	// Namespace restricts the rule to the vulnerability of a namespace. The
+	// rule applies to every namespace when it is empty.
+	Namespace string
+	// Feature restricts the rule to the findings of a feature, by name. The
+	// rule applies to every feature when it is empty.
+	Feature string
+	// Reason documents why the findings are suppressed.
+	Reason string
+
+	Created time.Time
+	// Expires is the time at which the rule stops applying. The rule never
+	// expires when it is zero.
+	Expires time.Time
+}
+
+// Valid returns true if the rule names the vulnerability it suppresses.
+func (r SuppressionRule) Valid() bool {
+	return r.Vulnerability != ""
+}
+
+// Expired returns true if the rule doesn't apply anymore at the given time.
+func (r SuppressionRule) Expired(now time.Time) bool {
+	return !r.Expires.IsZero() && !now.Before(r.Expires)
+}
+
+// Matches returns true if the rule suppresses the findings of a vulnerability
+// of a namespace for a feature, whether it expired or not. The feature is
+// empty when the findings are not about a particular feature.
+func (r SuppressionRule) Matches(vulnerability, namespace, feature string) bool {
+	return r.Vulnerability == vulnerability &&
+		(r.Namespace == "" || r.Namespace == namespace) &&
+		(r.Feature == "" || r.Feature == feature)
+}
+
+// SuppressionRules is a set of suppression rules.
+type SuppressionRules []SuppressionRule
+
+// Active returns the rules which did not expire at the given time.
+func (rules SuppressionRules) Active(now time.Time) SuppressionRules {
+	active := make(SuppressionRules, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Expired(now) {
+			active = append(active, rule)
+		}
+	}
+
+	return active
+}
+
+// Suppresses returns true if any rule suppresses the findings of a
+// vulnerability of a namespace for a feature.
+func (rules SuppressionRules) Suppresses(vulnerability, namespace, feature string) bool {
+	for _, rule := range rules {
+		if rule.Matches(vulnerability, namespace, feature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SuppressesVulnerability returns true if the rules suppress the findings of
+// a vulnerability for every feature it affects.
+func (rules SuppressionRules) SuppressesVulnerability(vulnerability VulnerabilityWithAffected) bool {
+	if len(vulnerability.Affected) == 0 {
+		return rules.Suppresses(vulnerability.Name, vulnerability.Namespace.Name, "")
+	}
+
+	for _, affected := range vulnerability.Affected {
+		if !rules.Suppresses(vulnerability.Name, vulnerability.Namespace.Name, affected.FeatureName) {
+			return false
+		}
+	}
+
+	return true
+}
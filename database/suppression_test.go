@@ -0,0 +1,80 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuppressionRuleExpired(t *testing.T) {
+	now := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, SuppressionRule{Vulnerability: "CVE-2020-0001"}.Expired(now))
+	assert.False(t, SuppressionRule{Vulnerability: "CVE-2020-0001", Expires: now.Add(time.Second)}.Expired(now))
+	assert.True(t, SuppressionRule{Vulnerability: "CVE-2020-0001", Expires: now}.Expired(now))
+	assert.True(t, SuppressionRule{Vulnerability: "CVE-2020-0001", Expires: now.Add(-time.Second)}.Expired(now))
+
+	rules := SuppressionRules{
+		{ID: 1, Vulnerability: "CVE-2020-0001"},
+		{ID: 2, Vulnerability: "CVE-2020-0002", Expires: now},
+		{ID: 3, Vulnerability: "CVE-2020-0003", Expires: now.Add(time.Hour)},
+	}
+
+	active := rules.Active(now)
+	if assert.Len(t, active, 2) {
+		assert.Equal(t, int64(1), active[0].ID)
+		assert.Equal(t, int64(3), active[1].ID)
+	}
+
+	assert.Len(t, rules.Active(now.Add(time.Hour)), 1)
+}
+
+func TestSuppressionRuleMatches(t *testing.T) {
+	rule := SuppressionRule{Vulnerability: "CVE-2020-0001"}
+	assert.True(t, rule.Matches("CVE-2020-0001", "debian:10", "openssl"))
+	assert.True(t, rule.Matches("CVE-2020-0001", "debian:10", ""))
+	assert.False(t, rule.Matches("CVE-2020-0002", "debian:10", "openssl"))
+
+	rule = SuppressionRule{Vulnerability: "CVE-2020-0001", Namespace: "debian:10", Feature: "openssl"}
+	assert.True(t, rule.Matches("CVE-2020-0001", "debian:10", "openssl"))
+	assert.False(t, rule.Matches("CVE-2020-0001", "debian:9", "openssl"))
+	assert.False(t, rule.Matches("CVE-2020-0001", "debian:10", "curl"))
+	assert.False(t, rule.Matches("CVE-2020-0001", "debian:10", ""))
+
+	ns := Namespace{Name: "debian:10", VersionFormat: "dpkg"}
+	vulnerability := VulnerabilityWithAffected{
+		Vulnerability: Vulnerability{Name: "CVE-2020-0001", Namespace: ns},
+		Affected: []AffectedFeature{
+			{Namespace: ns, FeatureName: "openssl"},
+			{Namespace: ns, FeatureName: "curl"},
+		},
+	}
+
+	rules := SuppressionRules{rule}
+	assert.True(t, rules.Suppresses("CVE-2020-0001", "debian:10", "openssl"))
+	assert.False(t, rules.SuppressesVulnerability(vulnerability))
+
+	rules = append(rules, SuppressionRule{Vulnerability: "CVE-2020-0001", Feature: "curl"})
+	assert.True(t, rules.SuppressesVulnerability(vulnerability))
+
+	// A vulnerability affecting no feature is only suppressed by the rules
+	// which are not restricted to a feature.
+	vulnerability.Affected = nil
+	assert.False(t, rules.SuppressesVulnerability(vulnerability))
+	assert.True(t, SuppressionRules{{Vulnerability: "CVE-2020-0001", Namespace: "debian:10"}}.SuppressesVulnerability(vulnerability))
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import "time"
+
+// UpdaterRun is the summary of a run of a vulnerability updater, kept as an
+// audit trail of the updates.
+type UpdaterRun struct {
+	Updater string
+
+	Started  time.Time
+	Finished time.Time
+
+	// Vulnerabilities is the number of vulnerabilities fetched by the run.
+	Vulnerabilities int
+	// Error is the error the run failed with, empty when it succeeded.
+	Error string
+}
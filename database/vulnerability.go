@@ -14,6 +14,8 @@
 
 package database
 
+import "github.com/quay/clair/v3/pkg/pagination"
+
 // VulnerabilityID is an identifier for every vulnerability. Every vulnerability
 // has unique namespace and name.
 type VulnerabilityID struct {
@@ -31,6 +33,11 @@ type Vulnerability struct {
 	Severity    Severity
 
 	Metadata MetadataMap
+
+	// Aliases are the names of the vulnerabilities related to this one, such
+	// as the advisory which fixes it and the other vulnerabilities fixed by
+	// the same advisory.
+	Aliases []string
 }
 
 // VulnerabilityWithAffected is a vulnerability with all known affected
@@ -39,6 +46,15 @@ type VulnerabilityWithAffected struct {
 	Vulnerability
 
 	Affected []AffectedFeature
+
+	// ContentHash is the digest of the vulnerability's content recorded when
+	// it was stored. It is empty when the digest is unknown.
+	ContentHash string
+
+	// Updater is the name of the updater which fetched the vulnerability, or
+	// the comma-separated names of the updaters when several of them fetched
+	// it. It is empty when unknown.
+	Updater string
 }
 
 // NullableVulnerability is a vulnerability with whether the vulnerability is
@@ -48,3 +64,39 @@ type NullableVulnerability struct {
 
 	Valid bool
 }
+
+// PagedVulnerabilities is a page of the vulnerabilities of a namespace,
+// ordered by their storage order. The current and next page tokens are for
+// navigation.
+type PagedVulnerabilities struct {
+	Vulnerabilities []VulnerabilityWithAffected
+
+	Limit   int
+	Current pagination.Token
+	Next    pagination.Token
+
+	// End signals the end of the pages.
+	End bool
+}
+
+// AffectedAncestry is an ancestry with its features affected by a
+// vulnerability.
+type AffectedAncestry struct {
+	Name string
+
+	Features []NamespacedFeature
+}
+
+// PagedAffectedAncestries is a page of the ancestries affected by a
+// vulnerability, ordered by their storage order. The current and next page
+// tokens are for navigation.
+type PagedAffectedAncestries struct {
+	Ancestries []AffectedAncestry
+
+	Limit   int
+	Current pagination.Token
+	Next    pagination.Token
+
+	// End signals the end of the pages.
+	End bool
+}
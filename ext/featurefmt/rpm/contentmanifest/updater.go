@@ -26,6 +26,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// updaterName labels the downloads of the mapping file.
+const updaterName = "repo2cpe"
+
 // LocalUpdaterJob periodically updates mapping file and store it in local storage
 type LocalUpdaterJob struct {
 	LocalPath string
@@ -122,7 +125,7 @@ func (updater *LocalUpdaterJob) shouldBeUpdated() bool {
 	// mapping file was updated more then 10 hours ago..
 	// Let's check whether header has changed
 	log.WithField("url", updater.URL).Debug("Fetching repo2cpe last-modified")
-	resp, err := httputil.HeadWithUserAgent(updater.URL)
+	resp, err := httputil.HeadForUpdater(updaterName, updater.URL)
 	if err != nil {
 		return true
 	}
@@ -145,7 +148,7 @@ func (updater *LocalUpdaterJob) shouldBeUpdated() bool {
 
 func (updater *LocalUpdaterJob) fetch() ([]byte, string, error) {
 	log.WithField("url", updater.URL).Info("Fetching repo2cpe mapping file")
-	resp, err := httputil.GetWithUserAgent(updater.URL)
+	resp, err := httputil.GetForUpdater(updaterName, updater.URL)
 	if err != nil {
 		return []byte{}, "", err
 	}
@@ -0,0 +1,150 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagefmt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// RegistryConfig is the TLS configuration used when pulling layers from a
+// single registry host.
+//
+// Exactly one of CAFile or Insecure must be set. Hosts that are not
+// configured are always verified against the system certificate pool.
+type RegistryConfig struct {
+	// Host is the registry host, optionally including the port.
+	Host string
+	// CAFile is the path to a PEM encoded bundle of certificate authorities
+	// trusted for this host in addition to the system pool.
+	CAFile string
+	// Insecure disables the verification of the host's certificate chain and
+	// hostname.
+	Insecure bool
+}
+
+var (
+	transportsM      sync.RWMutex
+	transports       = make(map[string]*http.Transport)
+	defaultTransport = newTransport(&tls.Config{})
+)
+
+func newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: tlsConfig,
+		Proxy:           http.ProxyFromEnvironment,
+	}
+}
+
+// ValidateRegistries ensures that every registry configuration is well formed
+// and that each host is configured at most once.
+func ValidateRegistries(registries []RegistryConfig) error {
+	_, err := buildTransports(registries)
+	return err
+}
+
+// ConfigureRegistries replaces the per-registry transports used to pull
+// layers with the ones described by the provided configuration.
+func ConfigureRegistries(registries []RegistryConfig) error {
+	built, err := buildTransports(registries)
+	if err != nil {
+		return err
+	}
+
+	transportsM.Lock()
+	defer transportsM.Unlock()
+	transports = built
+	return nil
+}
+
+// Transport returns the transport that must be used to pull a layer from the
+// given URL.
+func Transport(layerURL *url.URL) *http.Transport {
+	transportsM.RLock()
+	defer transportsM.RUnlock()
+
+	if tr, ok := transports[strings.ToLower(layerURL.Host)]; ok {
+		return tr
+	}
+
+	if tr, ok := transports[strings.ToLower(layerURL.Hostname())]; ok {
+		return tr
+	}
+
+	return defaultTransport
+}
+
+func buildTransports(registries []RegistryConfig) (map[string]*http.Transport, error) {
+	built := make(map[string]*http.Transport, len(registries))
+	for i, registry := range registries {
+		host := strings.ToLower(registry.Host)
+		if host == "" {
+			return nil, fmt.Errorf("imagefmt: registry #%d: host should not be empty", i)
+		}
+
+		if u, err := url.Parse("//" + host); err != nil || u.Host != host || u.User != nil {
+			return nil, fmt.Errorf("imagefmt: registry %q: host should not contain a scheme, path or credentials", registry.Host)
+		}
+
+		if _, dup := built[host]; dup {
+			return nil, fmt.Errorf("imagefmt: registry %q is configured twice", registry.Host)
+		}
+
+		tlsConfig, err := loadRegistryTLSConfig(registry)
+		if err != nil {
+			return nil, fmt.Errorf("imagefmt: registry %q: %s", registry.Host, err)
+		}
+
+		built[host] = newTransport(tlsConfig)
+	}
+
+	return built, nil
+}
+
+func loadRegistryTLSConfig(registry RegistryConfig) (*tls.Config, error) {
+	if registry.Insecure && registry.CAFile != "" {
+		return nil, fmt.Errorf("cafile and insecure are mutually exclusive")
+	}
+
+	if registry.Insecure {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	if registry.CAFile == "" {
+		return nil, fmt.Errorf("either cafile or insecure should be specified")
+	}
+
+	caCert, err := ioutil.ReadFile(registry.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificate could be parsed from %q", registry.CAFile)
+	}
+
+	return &tls.Config{RootCAs: pool}, nil
+}
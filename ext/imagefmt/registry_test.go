@@ -0,0 +1,118 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imagefmt
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegistryServer(t *testing.T) (*httptest.Server, *url.URL, string) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("layer"))
+	}))
+
+	u, err := url.Parse(srv.URL)
+	require.Nil(t, err)
+
+	dir, err := ioutil.TempDir("", "clair-registry-ca")
+	require.Nil(t, err)
+
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.Nil(t, ioutil.WriteFile(caFile, caPEM, 0600))
+
+	return srv, u, caFile
+}
+
+func get(u *url.URL) error {
+	client := &http.Client{Transport: Transport(u)}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	return nil
+}
+
+func TestRegistryTransports(t *testing.T) {
+	srv, u, caFile := newRegistryServer(t)
+	defer srv.Close()
+	defer os.RemoveAll(filepath.Dir(caFile))
+	defer ConfigureRegistries(nil)
+
+	// Unknown hosts are verified against the system roots.
+	require.Nil(t, ConfigureRegistries(nil))
+	assert.NotNil(t, get(u))
+
+	// The custom CA is trusted for the configured host.
+	require.Nil(t, ConfigureRegistries([]RegistryConfig{{Host: u.Host, CAFile: caFile}}))
+	assert.Nil(t, get(u))
+
+	// The custom CA is not trusted for other hosts.
+	require.Nil(t, ConfigureRegistries([]RegistryConfig{{Host: "registry.example.com", CAFile: caFile}}))
+	assert.NotNil(t, get(u))
+
+	// Insecure hosts are not verified.
+	require.Nil(t, ConfigureRegistries([]RegistryConfig{{Host: u.Hostname(), Insecure: true}}))
+	assert.Nil(t, get(u))
+}
+
+func TestValidateRegistries(t *testing.T) {
+	srv, _, caFile := newRegistryServer(t)
+	srv.Close()
+	defer os.RemoveAll(filepath.Dir(caFile))
+
+	notPEM := filepath.Join(filepath.Dir(caFile), "ca.txt")
+	require.Nil(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	for _, test := range []struct {
+		name       string
+		registries []RegistryConfig
+		valid      bool
+	}{
+		{"empty", nil, true},
+		{"ca", []RegistryConfig{{Host: "registry.example.com:5000", CAFile: caFile}}, true},
+		{"insecure", []RegistryConfig{{Host: "registry.example.com", Insecure: true}}, true},
+		{"no host", []RegistryConfig{{Insecure: true}}, false},
+		{"scheme", []RegistryConfig{{Host: "https://registry.example.com", Insecure: true}}, false},
+		{"path", []RegistryConfig{{Host: "registry.example.com/v2", Insecure: true}}, false},
+		{"neither", []RegistryConfig{{Host: "registry.example.com"}}, false},
+		{"both", []RegistryConfig{{Host: "registry.example.com", CAFile: caFile, Insecure: true}}, false},
+		{"missing ca", []RegistryConfig{{Host: "registry.example.com", CAFile: caFile + ".missing"}}, false},
+		{"invalid ca", []RegistryConfig{{Host: "registry.example.com", CAFile: notPEM}}, false},
+		{"duplicate", []RegistryConfig{
+			{Host: "registry.example.com", Insecure: true},
+			{Host: "Registry.example.com", CAFile: caFile},
+		}, false},
+	} {
+		err := ValidateRegistries(test.registries)
+		if test.valid {
+			assert.Nil(t, err, test.name)
+		} else {
+			assert.NotNil(t, err, test.name)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"time"
+
+	"github.com/quay/clair/v3/database"
+)
+
+// Digest is the payload of the notifications sent together in digest mode,
+// summarizing the vulnerability change of each one.
+type Digest struct {
+	Sent          time.Time
+	Notifications []Summary
+}
+
+// NewDigest returns the digest of the notifications sent at the given time,
+// read from the datastore. The notifications which don't exist anymore are
+// left out.
+func NewDigest(datastore database.Datastore, names []string, sent time.Time) (Digest, error) {
+	digest := Digest{Sent: sent.UTC(), Notifications: make([]Summary, 0, len(names))}
+	for _, name := range names {
+		envelope, ok, err := NewEnvelope(datastore, name, sent)
+		if err != nil {
+			return Digest{}, err
+		}
+
+		if ok {
+			digest.Notifications = append(digest.Notifications, envelope.Notification)
+		}
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+)
+
+func TestNewDigest(t *testing.T) {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: "dpkg"}
+	added := database.Vulnerability{Name: "CVE-2019-0001", Namespace: ns, Severity: database.HighSeverity}
+	removed := database.Vulnerability{Name: "CVE-2019-0002", Namespace: ns, Severity: database.LowSeverity}
+	created := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{Vulnerability: added}, {Vulnerability: removed}}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: removed.Name, Namespace: ns.Name}}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{
+		{NotificationHook: database.NotificationHook{Name: "new", Created: created}, New: &added},
+		{NotificationHook: database.NotificationHook{Name: "removed", Created: created}, Old: &removed},
+	}))
+	require.Nil(t, tx.Commit())
+
+	// The notifications which don't exist anymore are left out.
+	sent := created.Add(time.Hour)
+	digest, err := NewDigest(store, []string{"new", "missing", "removed"}, sent.Local())
+	require.Nil(t, err)
+	assert.Equal(t, Digest{
+		Sent: sent,
+		Notifications: []Summary{
+			{Name: "new", Created: created, Sent: sent, New: &VulnerabilitySummary{Name: "CVE-2019-0001", Namespace: "debian:9", Severity: database.HighSeverity}},
+			{Name: "removed", Created: created, Sent: sent, Old: &VulnerabilitySummary{Name: "CVE-2019-0002", Namespace: "debian:9", Severity: database.LowSeverity}},
+		},
+	}, digest)
+
+	// The digest lists the names and severities of the vulnerabilities.
+	payload, err := json.Marshal(digest)
+	require.Nil(t, err)
+	assert.Contains(t, string(payload), `"New":{"Name":"CVE-2019-0001","Namespace":"debian:9","Severity":"High"}`)
+	assert.Contains(t, string(payload), `"Old":{"Name":"CVE-2019-0002","Namespace":"debian:9","Severity":"Low"}`)
+
+	digest, err = NewDigest(store, nil, sent)
+	require.Nil(t, err)
+	assert.Len(t, digest.Notifications, 0)
+}
@@ -23,6 +23,8 @@ package notification
 import (
 	"sync"
 	"time"
+
+	"github.com/quay/clair/v3/database"
 )
 
 var (
@@ -35,7 +37,33 @@ var (
 type Config struct {
 	Attempts         int
 	RenotifyInterval time.Duration
-	Params           map[string]interface{} `yaml:",inline"`
+
+	// DeadLetter stores the notifications that could not be sent after
+	// Attempts attempts, instead of trying to send them again later.
+	DeadLetter bool
+
+	// BatchWindow is how long the notifier waits for more notifications to
+	// send them together in one batch. Notifications are sent one at a time
+	// when it is zero.
+	BatchWindow time.Duration
+	// BatchSize is the maximum number of notifications in a batch. Batches
+	// are unbounded when it is zero.
+	BatchSize int
+
+	// Digest gathers the notifications found within DigestWindow, regardless
+	// of BatchWindow and BatchSize, into a single digest per sender, see
+	// DigestSender. They are all marked as read at once, only if every sender
+	// sent the digest.
+	Digest bool
+	// DigestWindow is how long the notifier gathers notifications into a
+	// digest once it found a first one.
+	DigestWindow time.Duration
+
+	// Filters select the notifications which are sent, the other ones being
+	// marked as filtered without being sent.
+	Filters Filters
+
+	Params map[string]interface{} `yaml:",inline"`
 }
 
 // Sender represents anything that can transmit notifications.
@@ -48,6 +76,33 @@ type Sender interface {
 	Send(notificationName string) error
 }
 
+// BatchSender is a Sender able to transmit several notifications at once.
+type BatchSender interface {
+	Sender
+
+	// SendBatch informs the existence of the specified notifications.
+	SendBatch(notificationNames []string) error
+}
+
+// DigestSender is a Sender able to transmit a digest of notifications. The
+// Senders which aren't send the notifications of a digest as a batch.
+type DigestSender interface {
+	Sender
+
+	// SendDigest informs the existence of the notifications of the digest.
+	SendDigest(Digest) error
+}
+
+// DatastoreSender is a Sender reading the content of the notifications from
+// the datastore, such as their vulnerabilities, to describe them.
+type DatastoreSender interface {
+	Sender
+
+	// SetDatastore provides the datastore the notifications are read from,
+	// once the Sender is configured.
+	SetDatastore(database.Datastore)
+}
+
 // RegisterSender makes a Sender available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
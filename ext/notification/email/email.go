@@ -0,0 +1,354 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package email implements a notification sender mailing the vulnerability
+// notifications through an SMTP server.
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+const (
+	timeout = 30 * time.Second
+
+	// ancestryLimit is the number of affected ancestries listed by the
+	// description of a notification.
+	ancestryLimit = 10
+)
+
+// The TLS modes of the connection to the SMTP server.
+const (
+	// TLSStartTLS upgrades the connection with the STARTTLS command, failing
+	// if the server doesn't support it.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS, usually to the port 465.
+	TLSImplicit = "implicit"
+	// TLSNone never encrypts the connection.
+	TLSNone = "none"
+)
+
+var defaultPorts = map[string]int{
+	TLSStartTLS: 587,
+	TLSImplicit: 465,
+	TLSNone:     25,
+}
+
+// DefaultSubject is the subject template of the messages, unless configured.
+const DefaultSubject = `[Clair] {{if eq (len .Notifications) 1}}{{with index .Notifications 0}}{{.Kind}} vulnerability {{.Vulnerability.Name}}{{end}}{{else}}{{len .Notifications}} vulnerability changes{{end}}`
+
+// DefaultTemplate is the body template of the messages, unless configured.
+const DefaultTemplate = `{{range .Notifications}}{{.Kind}} vulnerability: {{.Vulnerability.Name}}
+Severity: {{.Vulnerability.Severity}}
+Namespace: {{.Vulnerability.Namespace.Name}}
+{{with .Vulnerability.Link}}Link: {{.}}
+{{end}}Affected ancestries:{{range .Ancestries}}
+  - {{.}}{{else}} none{{end}}{{if .MoreAncestries}}
+  - ...{{end}}
+Notification: {{.Notification}}
+
+{{end}}`
+
+type sender struct {
+	addr, host string
+	tlsMode    string
+	tlsConfig  *tls.Config
+	auth       smtp.Auth
+	from       string
+	to         []string
+	subject    *template.Template
+	body       *template.Template
+	digest     bool
+	datastore  database.Datastore
+}
+
+// Config represents the configuration of an email Sender.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLS is either "starttls" (default), "implicit" or "none".
+	TLS    string
+	CAFile string
+
+	From string
+	To   []string
+	// Subject and Template are the Go templates of the subject and the body
+	// of the messages, rendered with the notifications they describe.
+	Subject  string
+	Template string
+	// Digest sends a single message for all the notifications of a batch
+	// rather than one message per notification.
+	Digest bool
+}
+
+// templateData is the data the templates are rendered with.
+type templateData struct {
+	Notifications []notification.VulnerabilityChange
+}
+
+func init() {
+	notification.RegisterSender("email", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var emailConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["email"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["email"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &emailConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	if emailConfig.Host == "" {
+		return false, nil
+	}
+	if emailConfig.From == "" || len(emailConfig.To) == 0 {
+		return false, errors.New("email sender requires from and to addresses")
+	}
+
+	if emailConfig.TLS == "" {
+		emailConfig.TLS = TLSStartTLS
+	}
+	port, ok := defaultPorts[emailConfig.TLS]
+	if !ok {
+		return false, fmt.Errorf("unknown TLS mode %q", emailConfig.TLS)
+	}
+	if emailConfig.Port != 0 {
+		port = emailConfig.Port
+	}
+
+	if emailConfig.Subject == "" {
+		emailConfig.Subject = DefaultSubject
+	}
+	if emailConfig.Template == "" {
+		emailConfig.Template = DefaultTemplate
+	}
+	subject, err := template.New("subject").Parse(emailConfig.Subject)
+	if err != nil {
+		return false, fmt.Errorf("could not parse subject template: %s", err)
+	}
+	body, err := template.New("body").Parse(emailConfig.Template)
+	if err != nil {
+		return false, fmt.Errorf("could not parse body template: %s", err)
+	}
+
+	tlsConfig := &tls.Config{ServerName: emailConfig.Host}
+	if emailConfig.CAFile != "" {
+		caCert, err := ioutil.ReadFile(emailConfig.CAFile)
+		if err != nil {
+			return false, fmt.Errorf("could not read CA file: %s", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return false, errors.New("could not parse CA file")
+		}
+	}
+
+	s.addr = net.JoinHostPort(emailConfig.Host, strconv.Itoa(port))
+	s.host = emailConfig.Host
+	s.tlsMode, s.tlsConfig = emailConfig.TLS, tlsConfig
+	s.auth = nil
+	if emailConfig.Username != "" {
+		s.auth = smtp.PlainAuth("", emailConfig.Username, emailConfig.Password, emailConfig.Host)
+	}
+	s.from, s.to = emailConfig.From, emailConfig.To
+	s.subject, s.body = subject, body
+	s.digest = emailConfig.Digest
+	return true, nil
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
+func (s *sender) Send(notificationName string) error {
+	return s.SendBatch([]string{notificationName})
+}
+
+// SendBatch mails the notifications over a single connection, either in a
+// digest or one message per notification.
+func (s *sender) SendBatch(notificationNames []string) error {
+	changes := make([]notification.VulnerabilityChange, 0, len(notificationNames))
+	for _, name := range notificationNames {
+		change, ok, err := s.describe(name)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			changes = append(changes, change)
+		}
+	}
+
+	var messages [][]byte
+	if s.digest && len(changes) > 0 {
+		message, err := s.render(changes)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, message)
+	} else {
+		for i := range changes {
+			message, err := s.render(changes[i : i+1])
+			if err != nil {
+				return err
+			}
+			messages = append(messages, message)
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	return s.deliver(messages)
+}
+
+// describe returns the vulnerability change of the notification, or false if
+// the notification doesn't exist anymore.
+func (s *sender) describe(name string) (notification.VulnerabilityChange, bool, error) {
+	if s.datastore == nil {
+		return notification.VulnerabilityChange{Notification: name}, true, nil
+	}
+
+	return notification.FindVulnerabilityChange(s.datastore, name, ancestryLimit)
+}
+
+// render returns the message describing the vulnerability changes.
+func (s *sender) render(changes []notification.VulnerabilityChange) ([]byte, error) {
+	data := templateData{Notifications: changes}
+
+	var subject, body bytes.Buffer
+	if err := s.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("could not render subject: %s", err)
+	}
+	if err := s.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("could not render body: %s", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", s.from)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// deliver sends the messages over a single connection to the SMTP server.
+func (s *sender) deliver(messages [][]byte) error {
+	conn, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("could not connect to SMTP server: %s", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("could not connect to SMTP server: %s", err)
+	}
+	defer c.Close()
+
+	if s.tlsMode == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("could not start TLS: %s", err)
+		}
+	}
+
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("could not authenticate to SMTP server: %s", err)
+		}
+	}
+
+	for _, message := range messages {
+		conn.SetDeadline(time.Now().Add(timeout))
+		if err := s.transmit(c, message); err != nil {
+			return err
+		}
+	}
+
+	return c.Quit()
+}
+
+func (s *sender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if s.tlsMode == TLSImplicit {
+		return tls.DialWithDialer(dialer, "tcp", s.addr, s.tlsConfig)
+	}
+
+	return dialer.Dial("tcp", s.addr)
+}
+
+// transmit sends a message in the SMTP transaction of the client.
+func (s *sender) transmit(c *smtp.Client, message []byte) error {
+	if err := c.Mail(s.from); err != nil {
+		return fmt.Errorf("could not send email: %s", err)
+	}
+	for _, to := range s.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("could not send email to %s: %s", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("could not send email: %s", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		w.Close()
+		return fmt.Errorf("could not send email: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not send email: %s", err)
+	}
+
+	return nil
+}
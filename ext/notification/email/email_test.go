@@ -0,0 +1,329 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/notification/notificationtest"
+)
+
+// smtpServer is a local SMTP server recording the connections and the
+// messages it receives.
+type smtpServer struct {
+	listener  net.Listener
+	dir       string
+	tlsConfig *tls.Config
+	implicit  bool
+	startTLS  bool
+
+	mu          sync.Mutex
+	connections int
+	auth        []string
+	messages    []string
+	// encrypted is whether every message was received over TLS.
+	encrypted bool
+}
+
+// serveSMTP serves a local SMTP server, with a certificate of 127.0.0.1
+// written to the CA file it returns, until it is closed.
+func serveSMTP(t *testing.T, implicit, startTLS bool) (*smtpServer, string) {
+	certificate := issueCertificate(t)
+	dir, err := ioutil.TempDir("", "email")
+	require.Nil(t, err)
+	caFile := filepath.Join(dir, "ca.pem")
+	require.Nil(t, ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	s := &smtpServer{
+		listener:  listener,
+		dir:       dir,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{certificate}},
+		implicit:  implicit,
+		startTLS:  startTLS,
+		encrypted: true,
+	}
+	go s.serve()
+	return s, caFile
+}
+
+func issueCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "smtp"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (s *smtpServer) close() {
+	s.listener.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *smtpServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.connections++
+	s.mu.Unlock()
+
+	encrypted := s.implicit
+	if s.implicit {
+		conn = tls.Server(conn, s.tlsConfig)
+	}
+	text := textproto.NewConn(conn)
+
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			if s.startTLS && !encrypted {
+				text.PrintfLine("250-STARTTLS")
+			}
+			text.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			text.PrintfLine("220 Ready to start TLS")
+			conn = tls.Server(conn, s.tlsConfig)
+			text = textproto.NewConn(conn)
+			encrypted = true
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, line)
+			s.mu.Unlock()
+			text.PrintfLine("235 Authenticated")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, string(data))
+			s.encrypted = s.encrypted && encrypted
+			s.mu.Unlock()
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Unknown command")
+		}
+	}
+}
+
+func TestSendStartTLS(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.HighSeverity, database.LowSeverity)...)
+	defer store.Close()
+	server, caFile := serveSMTP(t, false, true)
+	defer server.close()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "email", store, map[interface{}]interface{}{
+		"host":     "127.0.0.1",
+		"port":     server.port(),
+		"cafile":   caFile,
+		"username": "clair",
+		"password": "secret",
+		"from":     "clair@example.com",
+		"to":       []string{"security@example.com"},
+	})
+	require.Nil(t, s.SendBatch([]string{"notification-0", "notification-1", "notification-9"}))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	// A single connection is reused for every message of the batch.
+	assert.Equal(t, 1, server.connections)
+	assert.True(t, server.encrypted)
+	assert.Len(t, server.auth, 1)
+	require.Len(t, server.messages, 2)
+
+	assert.Contains(t, server.messages[0], "Subject: [Clair] New vulnerability CVE-2019-0000\n")
+	assert.Contains(t, server.messages[0], "To: security@example.com\n")
+	assert.Contains(t, server.messages[0], "New vulnerability: CVE-2019-0000\nSeverity: High\nNamespace: debian:9\n")
+	assert.Contains(t, server.messages[0], "Notification: notification-0\n")
+	assert.Contains(t, server.messages[1], "Subject: [Clair] New vulnerability CVE-2019-0001\n")
+	assert.Contains(t, server.messages[1], "Severity: Low\n")
+}
+
+func TestSendStartTLSUnsupported(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.HighSeverity)...)
+	defer store.Close()
+	server, caFile := serveSMTP(t, false, false)
+	defer server.close()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "email", store, map[interface{}]interface{}{
+		"host":   "127.0.0.1",
+		"port":   server.port(),
+		"cafile": caFile,
+		"from":   "clair@example.com",
+		"to":     []string{"security@example.com"},
+	})
+	assert.NotNil(t, s.Send("notification-0"))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Empty(t, server.messages)
+}
+
+func TestSendImplicitTLS(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.CriticalSeverity)...)
+	defer store.Close()
+	server, caFile := serveSMTP(t, true, false)
+	defer server.close()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "email", store, map[interface{}]interface{}{
+		"host":     "127.0.0.1",
+		"port":     server.port(),
+		"tls":      "implicit",
+		"cafile":   caFile,
+		"from":     "clair@example.com",
+		"to":       []string{"security@example.com", "ops@example.com"},
+		"subject":  "{{range .Notifications}}{{.Vulnerability.Severity}} {{.Vulnerability.Name}}{{end}}",
+		"template": "{{range .Notifications}}{{.Vulnerability.Link}}{{end}}\n",
+	})
+	require.Nil(t, s.Send("notification-0"))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, 1, server.connections)
+	assert.True(t, server.encrypted)
+	assert.Empty(t, server.auth)
+	require.Len(t, server.messages, 1)
+	assert.Contains(t, server.messages[0], "Subject: Critical CVE-2019-0000\n")
+	assert.Contains(t, server.messages[0], "To: security@example.com, ops@example.com\n")
+	assert.True(t, strings.HasSuffix(server.messages[0], "\n\nhttps://security-tracker.debian.org/tracker/CVE-2019-0000\n"))
+}
+
+func TestSendDigest(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.HighSeverity, database.MediumSeverity, database.LowSeverity)...)
+	defer store.Close()
+	server, caFile := serveSMTP(t, false, true)
+	defer server.close()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "email", store, map[interface{}]interface{}{
+		"host":   "127.0.0.1",
+		"port":   server.port(),
+		"cafile": caFile,
+		"from":   "clair@example.com",
+		"to":     []string{"security@example.com"},
+		"digest": true,
+	})
+	require.Nil(t, s.SendBatch([]string{"notification-0", "notification-1", "notification-2"}))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, 1, server.connections)
+	require.Len(t, server.messages, 1)
+	assert.Contains(t, server.messages[0], "Subject: [Clair] 3 vulnerability changes\n")
+	for i := 0; i < 3; i++ {
+		assert.Contains(t, server.messages[0], fmt.Sprintf("New vulnerability: CVE-2019-%04d\n", i))
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	valid := func(extra ...interface{}) map[string]interface{} {
+		params := map[interface{}]interface{}{"host": "smtp.example.com", "from": "clair@example.com", "to": []string{"security@example.com"}}
+		for i := 0; i < len(extra); i += 2 {
+			params[extra[i]] = extra[i+1]
+		}
+		return map[string]interface{}{"email": params}
+	}
+
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"empty", map[string]interface{}{"email": map[interface{}]interface{}{}}, false, false},
+		{"valid", valid(), true, false},
+		{"implicit TLS", valid("tls", "implicit"), true, false},
+		{"unknown TLS mode", valid("tls", "ssl"), false, true},
+		{"no recipient", valid("to", []string{}), false, true},
+		{"invalid subject", valid("subject", "{{.Notifications"), false, true},
+		{"invalid template", valid("template", "{{end}}"), false, true},
+		{"missing CA file", valid("cafile", "/nonexistent/ca.pem"), false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configured, err := (&sender{}).Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"time"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+// Envelope is the JSON payload published by the message broker and log
+// Senders.
+type Envelope struct {
+	Notification Summary
+}
+
+// Summary describes a notification and the vulnerability change it notifies.
+type Summary struct {
+	Name    string
+	Created time.Time
+	Sent    time.Time
+	// Old and New are the vulnerability before and after the change, Old
+	// being omitted for a new vulnerability and New for a removed one.
+	Old *VulnerabilitySummary `json:",omitempty"`
+	New *VulnerabilitySummary `json:",omitempty"`
+}
+
+// VulnerabilitySummary summarizes a vulnerability.
+type VulnerabilitySummary struct {
+	Name      string
+	Namespace string
+	Severity  database.Severity
+}
+
+// NewEnvelope returns the envelope of a notification sent at the given time,
+// read from the datastore unless it is nil. It returns false if the
+// notification doesn't exist anymore.
+func NewEnvelope(datastore database.Datastore, name string, sent time.Time) (Envelope, bool, error) {
+	envelope := Envelope{Notification: Summary{Name: name, Sent: sent.UTC()}}
+	if datastore == nil {
+		return envelope, true, nil
+	}
+
+	n, ok, err := database.FindVulnerabilityNotificationAndRollback(datastore, name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return Envelope{}, false, err
+	}
+
+	envelope.Notification.Created = n.Created
+	if n.Old != nil {
+		envelope.Notification.Old = summarize(n.Old.Vulnerability)
+	}
+	if n.New != nil {
+		envelope.Notification.New = summarize(n.New.Vulnerability)
+	}
+
+	return envelope, true, nil
+}
+
+// VulnerabilityName returns the name of the new vulnerability, or of the
+// removed one, or else the name of the notification.
+func (e Envelope) VulnerabilityName() string {
+	switch {
+	case e.Notification.New != nil:
+		return e.Notification.New.Name
+	case e.Notification.Old != nil:
+		return e.Notification.Old.Name
+	default:
+		return e.Notification.Name
+	}
+}
+
+func summarize(vulnerability database.Vulnerability) *VulnerabilitySummary {
+	return &VulnerabilitySummary{
+		Name:      vulnerability.Name,
+		Namespace: vulnerability.Namespace.Name,
+		Severity:  vulnerability.Severity,
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/quay/clair/v3/database"
+)
+
+// Filters select the notifications which are sent, the other ones being
+// marked as filtered instead. The zero value sends every notification.
+type Filters struct {
+	// MinSeverity is the lowest severity of the vulnerabilities notified. A
+	// change is notified when either its old or its new vulnerability is
+	// severe enough, so that raising a vulnerability above the threshold is
+	// notified, and so is lowering it below.
+	MinSeverity database.Severity
+
+	// Namespaces are the patterns, see path.Match, of the namespaces of the
+	// vulnerabilities notified, e.g. "debian:*". Every namespace is notified
+	// when there is none.
+	Namespaces []string
+	// ExcludedNamespaces are the patterns of the namespaces of the
+	// vulnerabilities which are never notified.
+	ExcludedNamespaces []string
+}
+
+// Validate returns an error if the minimum severity or a pattern is invalid.
+func (f Filters) Validate() error {
+	if f.MinSeverity != "" && !f.MinSeverity.Valid() {
+		return fmt.Errorf("unknown minimum severity %q", f.MinSeverity)
+	}
+
+	for _, patterns := range [][]string{f.Namespaces, f.ExcludedNamespaces} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid namespace pattern %q", pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Enabled returns whether the filters filter any notification.
+func (f Filters) Enabled() bool {
+	return f.MinSeverity != "" || len(f.Namespaces) > 0 || len(f.ExcludedNamespaces) > 0
+}
+
+// Allow returns whether the change of a vulnerability from old to new, either
+// being nil for a new or removed vulnerability, is notified.
+func (f Filters) Allow(old, new *database.Vulnerability) bool {
+	var severe bool
+	for _, v := range []*database.Vulnerability{old, new} {
+		if v == nil {
+			continue
+		}
+
+		if !f.allowNamespace(v.Namespace.Name) {
+			return false
+		}
+
+		if f.MinSeverity == "" || v.Severity.Compare(f.MinSeverity) >= 0 {
+			severe = true
+		}
+	}
+
+	return severe
+}
+
+func (f Filters) allowNamespace(namespace string) bool {
+	for _, pattern := range f.ExcludedNamespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return false
+		}
+	}
+
+	if len(f.Namespaces) == 0 {
+		return true
+	}
+
+	for _, pattern := range f.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+)
+
+func vulnerability(namespace string, severity database.Severity) *database.Vulnerability {
+	return &database.Vulnerability{
+		Name:      "CVE-2019-0001",
+		Namespace: database.Namespace{Name: namespace},
+		Severity:  severity,
+	}
+}
+
+func TestFiltersAllow(t *testing.T) {
+	filters := Filters{
+		MinSeverity:        database.HighSeverity,
+		Namespaces:         []string{"debian:*", "alpine:*"},
+		ExcludedNamespaces: []string{"debian:unstable"},
+	}
+
+	for _, test := range []struct {
+		name     string
+		old, new *database.Vulnerability
+		allowed  bool
+	}{
+		{"raised above the threshold", vulnerability("debian:9", database.LowSeverity), vulnerability("debian:9", database.CriticalSeverity), true},
+		{"lowered below the threshold", vulnerability("debian:9", database.CriticalSeverity), vulnerability("debian:9", database.LowSeverity), true},
+		{"below the threshold", vulnerability("debian:9", database.LowSeverity), vulnerability("debian:9", database.MediumSeverity), false},
+		{"at the threshold", vulnerability("debian:9", database.LowSeverity), vulnerability("debian:9", database.HighSeverity), true},
+		{"new above the threshold", nil, vulnerability("alpine:v3.10", database.CriticalSeverity), true},
+		{"new below the threshold", nil, vulnerability("alpine:v3.10", database.UnknownSeverity), false},
+		{"removed above the threshold", vulnerability("debian:9", database.HighSeverity), nil, true},
+		{"removed below the threshold", vulnerability("debian:9", database.NegligibleSeverity), nil, false},
+		{"namespace not allowed", nil, vulnerability("ubuntu:18.04", database.CriticalSeverity), false},
+		{"namespace excluded", nil, vulnerability("debian:unstable", database.CriticalSeverity), false},
+	} {
+		assert.Equal(t, test.allowed, filters.Allow(test.old, test.new), test.name)
+	}
+
+	// Without filters, every change is notified.
+	var none Filters
+	assert.False(t, none.Enabled())
+	assert.True(t, none.Allow(nil, vulnerability("ubuntu:18.04", database.UnknownSeverity)))
+
+	// A namespace filter alone ignores the severities.
+	excluded := Filters{ExcludedNamespaces: []string{"ubuntu:*"}}
+	assert.True(t, excluded.Enabled())
+	assert.True(t, excluded.Allow(nil, vulnerability("debian:9", database.NegligibleSeverity)))
+	assert.False(t, excluded.Allow(nil, vulnerability("ubuntu:18.04", database.CriticalSeverity)))
+}
+
+func TestFiltersValidate(t *testing.T) {
+	assert.Nil(t, Filters{}.Validate())
+	assert.Nil(t, Filters{MinSeverity: database.MediumSeverity, Namespaces: []string{"debian:*"}}.Validate())
+	assert.Error(t, Filters{MinSeverity: "Severe"}.Validate())
+	assert.Error(t, Filters{Namespaces: []string{"debian:["}}.Validate())
+	assert.Error(t, Filters{ExcludedNamespaces: []string{"[]"}}.Validate())
+}
@@ -0,0 +1,281 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kafka implements a notification sender publishing the vulnerability
+// notifications to a Kafka topic.
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+const timeout = 10 * time.Second
+
+// The key strategies of the messages.
+const (
+	// KeyVulnerability keys the messages by the name of their vulnerability,
+	// so that a compacted topic keeps the latest change of every
+	// vulnerability.
+	KeyVulnerability = "vulnerability"
+	// KeyNotification keys the messages by the name of their notification.
+	KeyNotification = "notification"
+	// KeyNone doesn't key the messages, which are spread over the partitions.
+	KeyNone = "none"
+)
+
+type sender struct {
+	brokers   []string
+	topic     string
+	key       string
+	config    *sarama.Config
+	producer  sarama.SyncProducer
+	datastore database.Datastore
+}
+
+// Config represents the configuration of a Kafka Sender.
+type Config struct {
+	Brokers []string
+	Topic   string
+	// Key is the key strategy of the messages: "vulnerability" (default),
+	// "notification" or "none".
+	Key string
+	// Version is the Kafka version of the brokers, e.g. "2.3.0".
+	Version string
+
+	// TLS encrypts the connections, with the optional client certificate and
+	// CA.
+	TLS      bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+
+	// SASL authenticates with the PLAIN mechanism when the username is set.
+	SASL struct {
+		Username string
+		Password string
+	}
+}
+
+func init() {
+	notification.RegisterSender("kafka", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var kafkaConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["kafka"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["kafka"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &kafkaConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	// Verify at least one broker is available
+	if len(kafkaConfig.Brokers) == 0 {
+		return false, nil
+	}
+	if kafkaConfig.Topic == "" {
+		return false, errors.New("no topic specified for the brokers")
+	}
+
+	switch kafkaConfig.Key {
+	case "":
+		kafkaConfig.Key = KeyVulnerability
+	case KeyVulnerability, KeyNotification, KeyNone:
+	default:
+		return false, fmt.Errorf("unknown key strategy %q", kafkaConfig.Key)
+	}
+
+	saramaConfig, err := newSaramaConfig(&kafkaConfig)
+	if err != nil {
+		return false, err
+	}
+
+	if s.producer != nil {
+		s.producer.Close()
+		s.producer = nil
+	}
+	s.brokers, s.topic, s.key = kafkaConfig.Brokers, kafkaConfig.Topic, kafkaConfig.Key
+	s.config = saramaConfig
+	return true, nil
+}
+
+// newSaramaConfig returns the configuration of a synchronous producer waiting
+// for every in-sync replica to acknowledge the messages.
+func newSaramaConfig(cfg *Config) (*sarama.Config, error) {
+	config := sarama.NewConfig()
+	config.ClientID = "clair"
+	config.Net.DialTimeout = timeout
+	config.Producer.Timeout = timeout
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+
+	if cfg.Version != "" {
+		version, err := sarama.ParseKafkaVersion(cfg.Version)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse Kafka version: %s", err)
+		}
+		config.Version = version
+	}
+
+	if cfg.TLS {
+		tlsConfig, err := loadTLSClientConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("could not load TLS configuration: %s", err)
+		}
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	if cfg.SASL.Username != "" {
+		config.Net.SASL.Enable = true
+		config.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+		config.Net.SASL.User = cfg.SASL.Username
+		config.Net.SASL.Password = cfg.SASL.Password
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func loadTLSClientConfig(cfg *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("could not parse CA file")
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
+func (s *sender) Send(notificationName string) error {
+	return s.SendBatch([]string{notificationName})
+}
+
+// SendBatch publishes the notifications and waits for the brokers to
+// acknowledge all of them, so that the failed notifications are retried by
+// the notifier.
+func (s *sender) SendBatch(notificationNames []string) error {
+	messages := make([]*sarama.ProducerMessage, 0, len(notificationNames))
+	for _, name := range notificationNames {
+		message, ok, err := s.message(name)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			messages = append(messages, message)
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+
+	if err := s.producer.SendMessages(messages); err != nil {
+		if errs, ok := err.(sarama.ProducerErrors); ok && len(errs) > 0 {
+			return fmt.Errorf("could not publish %d of %d notifications: %s", len(errs), len(messages), errs[0].Err)
+		}
+		return fmt.Errorf("could not publish notifications: %s", err)
+	}
+
+	return nil
+}
+
+// connect creates the producer, which then reconnects to the brokers on its
+// own.
+func (s *sender) connect() error {
+	if s.producer != nil {
+		return nil
+	}
+
+	producer, err := sarama.NewSyncProducer(s.brokers, s.config)
+	if err != nil {
+		return fmt.Errorf("could not connect to Kafka brokers: %s", err)
+	}
+
+	s.producer = producer
+	return nil
+}
+
+// message returns the message describing the notification, or false if the
+// notification doesn't exist anymore.
+func (s *sender) message(name string) (*sarama.ProducerMessage, bool, error) {
+	envelope, ok, err := notification.NewEnvelope(s.datastore, name, time.Now())
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	value, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	message := &sarama.ProducerMessage{Topic: s.topic, Value: sarama.ByteEncoder(value)}
+	switch s.key {
+	case KeyVulnerability:
+		message.Key = sarama.StringEncoder(envelope.VulnerabilityName())
+	case KeyNotification:
+		message.Key = sarama.StringEncoder(name)
+	}
+
+	return message, true, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/notification/notificationtest"
+)
+
+var created = time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func decode(t *testing.T, value []byte) notification.Summary {
+	var envelope notification.Envelope
+	require.Nil(t, json.Unmarshal(value, &envelope))
+	return envelope.Notification
+}
+
+func TestMessage(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+
+	for _, test := range []struct {
+		key, notification, expected string
+	}{
+		{"", "new", "CVE-2019-0001"},
+		{"vulnerability", "removed", "CVE-2019-0003"},
+		{"notification", "removed", "removed"},
+		{"none", "new", ""},
+	} {
+		s := &sender{}
+		notificationtest.Configure(t, s, "kafka", store, map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "key": test.key})
+		message, ok, err := s.message(test.notification)
+		require.Nil(t, err)
+		require.True(t, ok)
+		assert.Equal(t, "clair", message.Topic)
+
+		if test.expected == "" {
+			assert.Nil(t, message.Key, "key %q", test.key)
+		} else {
+			key, err := message.Key.Encode()
+			require.Nil(t, err)
+			assert.Equal(t, test.expected, string(key), "key %q", test.key)
+		}
+	}
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "kafka", store, map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair"})
+	message, ok, err := s.message("removed")
+	require.Nil(t, err)
+	require.True(t, ok)
+	value, err := message.Value.Encode()
+	require.Nil(t, err)
+
+	n := decode(t, value)
+	assert.Equal(t, "removed", n.Name)
+	assert.True(t, created.Equal(n.Created))
+	assert.False(t, n.Sent.IsZero())
+	assert.Nil(t, n.New)
+	assert.Equal(t, &notification.VulnerabilitySummary{Name: "CVE-2019-0003", Namespace: "debian:9", Severity: database.LowSeverity}, n.Old)
+
+	_, ok, err = s.message("unknown")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestSendBatch(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+	s := &sender{}
+	notificationtest.Configure(t, s, "kafka", store, map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair"})
+
+	producer := mocks.NewSyncProducer(t, nil)
+	defer producer.Close()
+	s.producer = producer
+
+	var names []string
+	record := func(value []byte) error {
+		names = append(names, decode(t, value).Name)
+		return nil
+	}
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(record)
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(record)
+	require.Nil(t, s.SendBatch([]string{"new", "unknown", "removed"}))
+	assert.Equal(t, []string{"new", "removed"}, names)
+
+	// The failures are returned so that the notifier retries them.
+	producer.ExpectSendMessageAndFail(sarama.ErrOutOfBrokers)
+	assert.NotNil(t, s.Send("new"))
+}
+
+func TestSendBroker(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	handle := func(kerr sarama.KError) {
+		broker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockMetadataResponse(t).
+				SetBroker(broker.Addr(), broker.BrokerID()).
+				SetLeader("clair", 0, broker.BrokerID()),
+			"ProduceRequest": sarama.NewMockProduceResponse(t).SetError("clair", 0, kerr),
+		})
+	}
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "kafka", store, map[interface{}]interface{}{"brokers": []string{broker.Addr()}, "topic": "clair"})
+	handle(sarama.ErrNoError)
+	require.Nil(t, s.SendBatch([]string{"new", "removed"}))
+	defer s.producer.Close()
+
+	var produced int
+	for _, rr := range broker.History() {
+		if request, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+			assert.Equal(t, sarama.WaitForAll, request.RequiredAcks)
+		}
+	}
+	assert.NotZero(t, produced)
+
+	handle(sarama.ErrMessageSizeTooLarge)
+	assert.NotNil(t, s.Send("new"))
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"empty", map[string]interface{}{"kafka": map[interface{}]interface{}{}}, false, false},
+		{"brokers", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair"}}, true, false},
+		{"no topic", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}}}, false, true},
+		{"unknown key", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "key": "severity"}}, false, true},
+		{"version", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "version": "2.3.0"}}, true, false},
+		{"invalid version", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "version": "latest"}}, false, true},
+		{"sasl", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "sasl": map[interface{}]interface{}{"username": "clair", "password": "secret"}}}, true, false},
+		{"missing CA file", map[string]interface{}{"kafka": map[interface{}]interface{}{"brokers": []string{"localhost:9092"}, "topic": "clair", "tls": true, "cafile": "/nonexistent/ca.pem"}}, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configured, err := (&sender{}).Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package log implements a notification sender writing the vulnerability
+// notifications as JSON lines to the standard output or to a file, for
+// pipelines and debugging.
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+// Stdout is the output writing the notifications to the standard output.
+const Stdout = "stdout"
+
+const defaultMaxBackups = 3
+
+type sender struct {
+	output     string
+	maxSize    int64
+	maxBackups int
+
+	// out is the standard output or the opened file, of the given size.
+	out  io.Writer
+	file *os.File
+	size int64
+
+	now       func() time.Time
+	datastore database.Datastore
+}
+
+// Config represents the configuration of a log Sender.
+type Config struct {
+	// Output is "stdout" or the path of the file the notifications are
+	// appended to.
+	Output string
+	// MaxSize is the size in bytes above which the file is rotated, the file
+	// never being rotated when it is zero.
+	MaxSize int64
+	// MaxBackups is the number of rotated files kept, 3 by default.
+	MaxBackups int
+}
+
+func init() {
+	notification.RegisterSender("log", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var logConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["log"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["log"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &logConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	// Verify an output is specified
+	if logConfig.Output == "" {
+		return false, nil
+	}
+	if logConfig.MaxSize < 0 || logConfig.MaxBackups < 0 {
+		return false, errors.New("negative maxsize or maxbackups")
+	}
+	if logConfig.MaxBackups == 0 {
+		logConfig.MaxBackups = defaultMaxBackups
+	}
+
+	s.close()
+	s.output, s.maxSize, s.maxBackups = logConfig.Output, logConfig.MaxSize, logConfig.MaxBackups
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	// Fail the startup rather than every notification when the file can't be
+	// written.
+	if err := s.open(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
+func (s *sender) Send(notificationName string) error {
+	return s.SendBatch([]string{notificationName})
+}
+
+// SendBatch writes every notification as a line of JSON, rotating the file
+// beforehand when the line would make it exceed its maximum size.
+func (s *sender) SendBatch(notificationNames []string) error {
+	for _, name := range notificationNames {
+		envelope, ok, err := notification.NewEnvelope(s.datastore, name, s.now())
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		line, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+
+		if err := s.open(); err != nil {
+			return err
+		}
+
+		if s.file != nil && s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+
+		n, err := s.out.Write(line)
+		s.size += int64(n)
+		if err != nil {
+			return fmt.Errorf("could not write notification: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// open opens the output unless it is already open, appending to the file.
+func (s *sender) open() error {
+	if s.out != nil {
+		return nil
+	}
+
+	if s.output == Stdout {
+		s.out = os.Stdout
+		return nil
+	}
+
+	file, err := os.OpenFile(s.output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open notification log: %s", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("could not open notification log: %s", err)
+	}
+
+	s.out, s.file, s.size = file, file, info.Size()
+	return nil
+}
+
+// close closes the file, if any.
+func (s *sender) close() {
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.out, s.file, s.size = nil, nil, 0
+}
+
+// rotate renames the file to file.1, shifting the previous backups up to
+// file.maxBackups, and opens a new file.
+func (s *sender) rotate() error {
+	s.close()
+
+	for i := s.maxBackups - 1; i > 0; i-- {
+		err := os.Rename(backup(s.output, i), backup(s.output, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not rotate notification log: %s", err)
+		}
+	}
+
+	if err := os.Rename(s.output, backup(s.output, 1)); err != nil {
+		return fmt.Errorf("could not rotate notification log: %s", err)
+	}
+
+	return s.open()
+}
+
+func backup(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/notification/notificationtest"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var (
+	created = time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	sent    = time.Date(2019, 6, 1, 12, 0, 5, 0, time.UTC)
+)
+
+// assertGolden compares the content to the golden file, or updates the golden
+// file with the -update flag.
+func assertGolden(t *testing.T, name string, content []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.Nil(t, ioutil.WriteFile(path, content, 0644))
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assert.Equal(t, string(expected), string(content))
+}
+
+func TestSendGolden(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+
+	dir, err := ioutil.TempDir("", "clair-notification-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"new", "updated", "removed"} {
+		path := filepath.Join(dir, name+".jsonl")
+		s := &sender{now: func() time.Time { return sent }}
+		notificationtest.Configure(t, s, "log", store, map[interface{}]interface{}{"output": path})
+		require.Nil(t, s.Send(name))
+		s.close()
+
+		content, err := ioutil.ReadFile(path)
+		require.Nil(t, err)
+		assertGolden(t, name+".golden", content)
+	}
+}
+
+func TestSendBatch(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+
+	dir, err := ioutil.TempDir("", "clair-notification-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The notifications are appended to the existing file, one per line, and
+	// the ones which don't exist anymore are skipped.
+	path := filepath.Join(dir, "notifications.jsonl")
+	require.Nil(t, ioutil.WriteFile(path, []byte("previous\n"), 0644))
+
+	s := &sender{now: func() time.Time { return sent }}
+	notificationtest.Configure(t, s, "log", store, map[interface{}]interface{}{"output": path})
+	require.Nil(t, s.SendBatch([]string{"new", "unknown", "updated", "removed"}))
+	s.close()
+
+	content, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	assertGolden(t, "batch.golden", content)
+}
+
+func TestRotate(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(created)...)
+	defer store.Close()
+
+	dir, err := ioutil.TempDir("", "clair-notification-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Every notification exceeds the maximum size of the file, which is
+	// rotated before each of them but the first, keeping 2 backups.
+	path := filepath.Join(dir, "notifications.jsonl")
+	s := &sender{now: func() time.Time { return sent }}
+	notificationtest.Configure(t, s, "log", store, map[interface{}]interface{}{"output": path, "maxsize": 10, "maxbackups": 2})
+	for _, name := range []string{"new", "updated", "removed", "new"} {
+		require.Nil(t, s.Send(name))
+	}
+	s.close()
+
+	for file, name := range map[string]string{path: "new", path + ".1": "removed", path + ".2": "updated"} {
+		content, err := ioutil.ReadFile(file)
+		require.Nil(t, err)
+		golden, err := ioutil.ReadFile(filepath.Join("testdata", name+".golden"))
+		require.Nil(t, err)
+		assert.Equal(t, string(golden), string(content), file)
+	}
+
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestConfigure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-notification-log")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"missing", map[string]interface{}{}, false, false},
+		{"empty", map[string]interface{}{"log": map[interface{}]interface{}{}}, false, false},
+		{"stdout", map[string]interface{}{"log": map[interface{}]interface{}{"output": "stdout"}}, true, false},
+		{"file", map[string]interface{}{"log": map[interface{}]interface{}{"output": filepath.Join(dir, "notifications.jsonl"), "maxsize": 1024}}, true, false},
+		{"unwritable", map[string]interface{}{"log": map[interface{}]interface{}{"output": filepath.Join(dir, "missing", "notifications.jsonl")}}, false, true},
+		{"negative", map[string]interface{}{"log": map[interface{}]interface{}{"output": "stdout", "maxsize": -1}}, false, true},
+		{"invalid", map[string]interface{}{"log": map[interface{}]interface{}{"maxsize": "big"}}, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &sender{now: func() time.Time { return sent }}
+			configured, err := s.Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil)
+			s.close()
+		})
+	}
+}
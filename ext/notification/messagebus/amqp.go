@@ -0,0 +1,95 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messagebus
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+type amqpPublisher struct {
+	conn       *amqp.Connection
+	channel    *amqp.Channel
+	confirms   chan amqp.Confirmation
+	exchange   string
+	routingKey string
+}
+
+// connectAMQP connects to the AMQP broker and opens a channel in confirm mode.
+func connectAMQP(config AMQPConfig, tlsConfig *tls.Config) (publisher, error) {
+	conn, err := amqp.DialConfig(config.URL, amqp.Config{
+		Dial:            amqp.DefaultDial(timeout),
+		TLSClientConfig: tlsConfig,
+		Properties:      amqp.Table{"connection_name": "clair"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to AMQP broker: %s", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not open AMQP channel: %s", err)
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("could not enable AMQP publisher confirms: %s", err)
+	}
+
+	return &amqpPublisher{
+		conn:       conn,
+		channel:    channel,
+		confirms:   channel.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		exchange:   config.Exchange,
+		routingKey: config.RoutingKey,
+	}, nil
+}
+
+// Publish publishes the persistent message and waits for the broker to
+// confirm it.
+func (p *amqpPublisher) Publish(name string, message []byte) error {
+	err := p.channel.Publish(p.exchange, p.routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    name,
+		Timestamp:    time.Now(),
+		Body:         message,
+	})
+	if err != nil {
+		return fmt.Errorf("could not publish notification %s: %s", name, err)
+	}
+
+	select {
+	case confirm, ok := <-p.confirms:
+		if !ok {
+			return fmt.Errorf("could not publish notification %s: channel closed", name)
+		}
+		if !confirm.Ack {
+			return fmt.Errorf("could not publish notification %s: rejected by the broker", name)
+		}
+	case <-time.After(timeout):
+		return fmt.Errorf("could not publish notification %s: timed out waiting for confirmation", name)
+	}
+
+	return nil
+}
+
+func (p *amqpPublisher) Close() error {
+	return p.conn.Close()
+}
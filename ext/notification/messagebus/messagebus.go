@@ -0,0 +1,238 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package messagebus implements a notification sender publishing the
+// vulnerability notifications to a NATS subject or an AMQP exchange.
+package messagebus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+const timeout = 10 * time.Second
+
+// publisher publishes the messages to a broker.
+type publisher interface {
+	// Publish publishes the message of the notification and waits for the
+	// broker to acknowledge it.
+	Publish(name string, message []byte) error
+	// Close closes the connection to the broker.
+	Close() error
+}
+
+type sender struct {
+	connect   func() (publisher, error)
+	publisher publisher
+	datastore database.Datastore
+}
+
+// Config represents the configuration of a message bus Sender, publishing to
+// either a NATS or an AMQP broker.
+type Config struct {
+	NATS *NATSConfig
+	AMQP *AMQPConfig
+
+	// Strict fails the startup when the broker is unreachable, rather than
+	// connecting to it when the first notification is sent.
+	Strict bool
+
+	// Optional PKI configuration of the connection to the broker.
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// NATSConfig represents the NATS subject the notifications are published to.
+type NATSConfig struct {
+	URL     string
+	Subject string
+}
+
+// AMQPConfig represents the AMQP 0.9.1 exchange the notifications are
+// published to.
+type AMQPConfig struct {
+	URL        string
+	Exchange   string
+	RoutingKey string
+}
+
+func init() {
+	notification.RegisterSender("messagebus", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var busConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["messagebus"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["messagebus"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &busConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	tlsConfig, err := loadTLSClientConfig(&busConfig)
+	if err != nil {
+		return false, fmt.Errorf("could not load TLS configuration: %s", err)
+	}
+
+	var connect func() (publisher, error)
+	switch {
+	case busConfig.NATS != nil && busConfig.AMQP != nil:
+		return false, errors.New("message bus sender requires either nats or amqp, not both")
+	case busConfig.NATS != nil:
+		if busConfig.NATS.URL == "" {
+			return false, errors.New("no URL specified for the NATS broker")
+		}
+		if busConfig.NATS.Subject == "" {
+			return false, errors.New("no subject specified for the NATS broker")
+		}
+		natsConfig := *busConfig.NATS
+		connect = func() (publisher, error) { return connectNATS(natsConfig, tlsConfig) }
+	case busConfig.AMQP != nil:
+		if busConfig.AMQP.URL == "" {
+			return false, errors.New("no URL specified for the AMQP broker")
+		}
+		if busConfig.AMQP.Exchange == "" && busConfig.AMQP.RoutingKey == "" {
+			return false, errors.New("no exchange nor routing key specified for the AMQP broker")
+		}
+		amqpConfig := *busConfig.AMQP
+		connect = func() (publisher, error) { return connectAMQP(amqpConfig, tlsConfig) }
+	default:
+		return false, nil
+	}
+
+	s.Close()
+	s.connect = connect
+	if busConfig.Strict {
+		if err := s.dial(); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func loadTLSClientConfig(cfg *Config) (*tls.Config, error) {
+	if cfg.CAFile == "" && (cfg.CertFile == "" || cfg.KeyFile == "") {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.CAFile != "" {
+		caCert, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("could not parse CA file")
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
+// Close closes the connection to the broker, if any.
+func (s *sender) Close() {
+	if s.publisher != nil {
+		s.publisher.Close()
+		s.publisher = nil
+	}
+}
+
+// dial connects to the broker unless it is already connected.
+func (s *sender) dial() error {
+	if s.publisher != nil {
+		return nil
+	}
+
+	p, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	s.publisher = p
+	return nil
+}
+
+func (s *sender) Send(notificationName string) error {
+	return s.SendBatch([]string{notificationName})
+}
+
+// SendBatch publishes the notifications and waits for the broker to
+// acknowledge every one of them.
+//
+// The connection is dropped on any failure, and connecting again is left to
+// the next attempt of the notifier, so that an outage of the broker is retried
+// with the backoff of the notifier rather than blocking the sender.
+func (s *sender) SendBatch(notificationNames []string) error {
+	for _, name := range notificationNames {
+		envelope, ok, err := notification.NewEnvelope(s.datastore, name, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		message, err := json.Marshal(envelope)
+		if err != nil {
+			return err
+		}
+
+		if err := s.dial(); err != nil {
+			return err
+		}
+
+		if err := s.publisher.Publish(name, message); err != nil {
+			s.Close()
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messagebus
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/notification/notificationtest"
+)
+
+// runNATS runs an embedded NATS server on the port, or a free one if it is
+// -1.
+func runNATS(port int) *natsserver.Server {
+	opts := natstest.DefaultTestOptions
+	opts.Port = port
+	return natstest.RunServer(&opts)
+}
+
+// subscribe returns the subscription of a client to the subject.
+func subscribe(t *testing.T, server *natsserver.Server, subject string) *nats.Subscription {
+	conn, err := nats.Connect(server.ClientURL())
+	require.Nil(t, err)
+	subscription, err := conn.SubscribeSync(subject)
+	require.Nil(t, err)
+	require.Nil(t, conn.Flush())
+	return subscription
+}
+
+func receive(t *testing.T, subscription *nats.Subscription) notification.Summary {
+	msg, err := subscription.NextMsg(time.Second)
+	require.Nil(t, err)
+
+	var envelope notification.Envelope
+	require.Nil(t, json.Unmarshal(msg.Data, &envelope))
+	return envelope.Notification
+}
+
+func TestSendNATS(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(time.Now())...)
+	defer store.Close()
+	server := runNATS(-1)
+	defer server.Shutdown()
+
+	subscription := subscribe(t, server, "clair.notifications")
+	defer subscription.Unsubscribe()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "messagebus", store, map[interface{}]interface{}{
+		"nats": map[interface{}]interface{}{"url": server.ClientURL(), "subject": "clair.notifications"},
+	})
+	defer s.Close()
+	require.Nil(t, s.SendBatch([]string{"new", "unknown", "removed"}))
+
+	n := receive(t, subscription)
+	assert.Equal(t, "new", n.Name)
+	assert.Equal(t, &notification.VulnerabilitySummary{Name: "CVE-2019-0001", Namespace: "debian:9", Severity: database.HighSeverity}, n.New)
+	assert.Nil(t, n.Old)
+
+	n = receive(t, subscription)
+	assert.Equal(t, "removed", n.Name)
+	assert.Equal(t, "CVE-2019-0003", n.Old.Name)
+	assert.Nil(t, n.New)
+
+	_, err := subscription.NextMsg(100 * time.Millisecond)
+	assert.Equal(t, nats.ErrTimeout, err)
+}
+
+func TestSendNATSOutage(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.Changes(time.Now())...)
+	defer store.Close()
+	server := runNATS(-1)
+	port := server.Addr().(*net.TCPAddr).Port
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "messagebus", store, map[interface{}]interface{}{
+		"nats":   map[interface{}]interface{}{"url": server.ClientURL(), "subject": "clair.notifications"},
+		"strict": true,
+	})
+	defer s.Close()
+	require.Nil(t, s.Send("new"))
+
+	// The failures during the outage are returned without blocking, so that
+	// the notifier retries them later.
+	server.Shutdown()
+	done := make(chan error, 2)
+	go func() {
+		done <- s.Send("new")
+		done <- s.Send("new")
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-done:
+			assert.NotNil(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("sending blocked during the outage")
+		}
+	}
+
+	// The sender reconnects once the server is back.
+	server = runNATS(port)
+	defer server.Shutdown()
+	subscription := subscribe(t, server, "clair.notifications")
+	defer subscription.Unsubscribe()
+
+	require.Nil(t, s.Send("removed"))
+	assert.Equal(t, "removed", receive(t, subscription).Name)
+}
+
+func TestConfigureStrict(t *testing.T) {
+	// Find a port nothing listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	for _, test := range []struct {
+		name       string
+		params     map[interface{}]interface{}
+		configured bool
+		err        bool
+	}{
+		{"lenient NATS", map[interface{}]interface{}{"nats": map[interface{}]interface{}{"url": "nats://" + addr, "subject": "clair"}}, true, false},
+		{"strict NATS", map[interface{}]interface{}{"nats": map[interface{}]interface{}{"url": "nats://" + addr, "subject": "clair"}, "strict": true}, false, true},
+		{"lenient AMQP", map[interface{}]interface{}{"amqp": map[interface{}]interface{}{"url": "amqp://" + addr, "exchange": "clair"}}, true, false},
+		{"strict AMQP", map[interface{}]interface{}{"amqp": map[interface{}]interface{}{"url": "amqp://" + addr, "exchange": "clair"}, "strict": true}, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := &sender{}
+			configured, err := s.Configure(&notification.Config{Params: map[string]interface{}{"messagebus": test.params}})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+
+			if configured {
+				// Lenient senders fail to send instead.
+				assert.NotNil(t, s.Send("new"))
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"empty", map[string]interface{}{"messagebus": map[interface{}]interface{}{}}, false, false},
+		{"nats", map[string]interface{}{"messagebus": map[interface{}]interface{}{"nats": map[interface{}]interface{}{"url": "nats://localhost:4222", "subject": "clair"}}}, true, false},
+		{"nats without subject", map[string]interface{}{"messagebus": map[interface{}]interface{}{"nats": map[interface{}]interface{}{"url": "nats://localhost:4222"}}}, false, true},
+		{"nats without url", map[string]interface{}{"messagebus": map[interface{}]interface{}{"nats": map[interface{}]interface{}{"subject": "clair"}}}, false, true},
+		{"amqp", map[string]interface{}{"messagebus": map[interface{}]interface{}{"amqp": map[interface{}]interface{}{"url": "amqp://localhost:5672", "exchange": "clair", "routingkey": "notifications"}}}, true, false},
+		{"amqp without exchange", map[string]interface{}{"messagebus": map[interface{}]interface{}{"amqp": map[interface{}]interface{}{"url": "amqp://localhost:5672"}}}, false, true},
+		{"both", map[string]interface{}{"messagebus": map[interface{}]interface{}{
+			"nats": map[interface{}]interface{}{"url": "nats://localhost:4222", "subject": "clair"},
+			"amqp": map[interface{}]interface{}{"url": "amqp://localhost:5672", "exchange": "clair"},
+		}}, false, true},
+		{"missing CA file", map[string]interface{}{"messagebus": map[interface{}]interface{}{"nats": map[interface{}]interface{}{"url": "nats://localhost:4222", "subject": "clair"}, "cafile": "/nonexistent/ca.pem"}}, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configured, err := (&sender{}).Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package messagebus
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+type natsPublisher struct {
+	conn    *nats.Conn
+	subject string
+}
+
+// connectNATS connects to the NATS server, which isn't reconnected to by the
+// client on its own.
+func connectNATS(config NATSConfig, tlsConfig *tls.Config) (publisher, error) {
+	options := []nats.Option{nats.Name("clair"), nats.Timeout(timeout), nats.NoReconnect()}
+	if tlsConfig != nil {
+		options = append(options, nats.Secure(tlsConfig))
+	}
+
+	conn, err := nats.Connect(config.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to NATS server: %s", err)
+	}
+
+	return &natsPublisher{conn: conn, subject: config.Subject}, nil
+}
+
+// Publish publishes the message and flushes it, so that it is known to be
+// received by the server.
+func (p *natsPublisher) Publish(name string, message []byte) error {
+	if err := p.conn.Publish(p.subject, message); err != nil {
+		return fmt.Errorf("could not publish notification %s: %s", name, err)
+	}
+
+	if err := p.conn.FlushTimeout(timeout); err != nil {
+		return fmt.Errorf("could not publish notification %s: %s", name, err)
+	}
+
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	p.conn.Close()
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notificationtest implements the datastores and helpers shared by the
+// tests of the notification senders.
+package notificationtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+)
+
+// Namespace is the namespace of the vulnerabilities of the notifications.
+var Namespace = database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+
+// OpenStore returns a memory datastore with the notifications. Their old
+// vulnerabilities are inserted and removed before their new vulnerabilities
+// are inserted, in the namespace of every vulnerability.
+func OpenStore(t *testing.T, notifications ...database.VulnerabilityNotification) database.Datastore {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+
+	var (
+		namespaces []database.Namespace
+		old, added []database.VulnerabilityWithAffected
+		removed    []database.VulnerabilityID
+	)
+	seen := make(map[database.Namespace]bool)
+	addNamespace := func(ns database.Namespace) {
+		if !seen[ns] {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
+		}
+	}
+	for _, n := range notifications {
+		if n.Old != nil {
+			addNamespace(n.Old.Namespace)
+			old = append(old, database.VulnerabilityWithAffected{Vulnerability: *n.Old})
+			removed = append(removed, database.VulnerabilityID{Name: n.Old.Name, Namespace: n.Old.Namespace.Name})
+		}
+		if n.New != nil {
+			addNamespace(n.New.Namespace)
+			added = append(added, database.VulnerabilityWithAffected{Vulnerability: *n.New})
+		}
+	}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces(namespaces))
+	if len(old) > 0 {
+		require.Nil(t, tx.InsertVulnerabilities(old))
+		require.Nil(t, tx.DeleteVulnerabilities(removed))
+	}
+	if len(added) > 0 {
+		require.Nil(t, tx.InsertVulnerabilities(added))
+	}
+	require.Nil(t, tx.InsertVulnerabilityNotifications(notifications))
+	require.Nil(t, tx.Commit())
+	return store
+}
+
+// NewVulnerabilities returns a notification "notification-i" of a new
+// vulnerability CVE-2019-000i of Namespace for every severity.
+func NewVulnerabilities(created time.Time, severities ...database.Severity) []database.VulnerabilityNotification {
+	notifications := make([]database.VulnerabilityNotification, 0, len(severities))
+	for i, severity := range severities {
+		notifications = append(notifications, database.VulnerabilityNotification{
+			NotificationHook: database.NotificationHook{Name: fmt.Sprintf("notification-%d", i), Created: created},
+			New: &database.Vulnerability{
+				Name:      fmt.Sprintf("CVE-2019-%04d", i),
+				Namespace: Namespace,
+				Severity:  severity,
+				Link:      fmt.Sprintf("https://security-tracker.debian.org/tracker/CVE-2019-%04d", i),
+			},
+		})
+	}
+	return notifications
+}
+
+// Changes returns the notifications "new" of the new vulnerability
+// CVE-2019-0001, "updated" of CVE-2019-0002 whose severity is raised from low
+// to high, and "removed" of the removed vulnerability CVE-2019-0003.
+func Changes(created time.Time) []database.VulnerabilityNotification {
+	added := database.Vulnerability{Name: "CVE-2019-0001", Namespace: Namespace, Severity: database.HighSeverity}
+	old := database.Vulnerability{Name: "CVE-2019-0002", Namespace: Namespace, Severity: database.LowSeverity}
+	updated := database.Vulnerability{Name: "CVE-2019-0002", Namespace: Namespace, Severity: database.HighSeverity}
+	removed := database.Vulnerability{Name: "CVE-2019-0003", Namespace: Namespace, Severity: database.LowSeverity}
+
+	return []database.VulnerabilityNotification{
+		{NotificationHook: database.NotificationHook{Name: "new", Created: created}, New: &added},
+		{NotificationHook: database.NotificationHook{Name: "updated", Created: created}, Old: &old, New: &updated},
+		{NotificationHook: database.NotificationHook{Name: "removed", Created: created}, Old: &removed},
+	}
+}
+
+// Configure configures the sender with its parameters, which must enable it,
+// and provides it the datastore.
+func Configure(t *testing.T, s notification.DatastoreSender, name string, store database.Datastore, params map[interface{}]interface{}) {
+	configured, err := s.Configure(&notification.Config{Params: map[string]interface{}{name: params}})
+	require.Nil(t, err)
+	require.True(t, configured)
+	s.SetDatastore(store)
+}
@@ -0,0 +1,268 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slack implements a notification sender posting the vulnerability
+// notifications to a Slack channel.
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+const (
+	timeout = 5 * time.Second
+
+	// maxAttachments is the number of notifications described by a single
+	// message, below the limit of Slack.
+	maxAttachments = 50
+)
+
+// apiURL is the base URL of the Slack Web API.
+var apiURL = "https://slack.com/api"
+
+// severityColors are the colors of the notifications by the severity of their
+// vulnerability.
+var severityColors = map[database.Severity]string{
+	database.Defcon1Severity:    "#7b0000",
+	database.CriticalSeverity:   "#d50000",
+	database.HighSeverity:       "#ff6d00",
+	database.MediumSeverity:     "#ffd600",
+	database.LowSeverity:        "#2962ff",
+	database.NegligibleSeverity: "#9e9e9e",
+	database.UnknownSeverity:    "#9e9e9e",
+}
+
+type sender struct {
+	webhookURL     string
+	token, channel string
+	client         *http.Client
+	datastore      database.Datastore
+}
+
+// Config represents the configuration of a Slack Sender: either the URL of an
+// incoming webhook, or the token of a bot and the channel it posts to.
+type Config struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+}
+
+func init() {
+	notification.RegisterSender("slack", &sender{})
+}
+
+func (s *sender) Configure(config *notification.Config) (bool, error) {
+	// Get configuration
+	var slackConfig Config
+	if config == nil {
+		return false, nil
+	}
+	if _, ok := config.Params["slack"]; !ok {
+		return false, nil
+	}
+	yamlConfig, err := yaml.Marshal(config.Params["slack"])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+	err = yaml.Unmarshal(yamlConfig, &slackConfig)
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	switch {
+	case slackConfig.WebhookURL != "":
+		if _, err := url.ParseRequestURI(slackConfig.WebhookURL); err != nil {
+			return false, fmt.Errorf("could not parse webhook URL: %s", err)
+		}
+	case slackConfig.Token != "":
+		if slackConfig.Channel == "" {
+			return false, errors.New("no channel specified for the token")
+		}
+	default:
+		return false, nil
+	}
+
+	s.webhookURL = slackConfig.WebhookURL
+	s.token, s.channel = slackConfig.Token, slackConfig.Channel
+	s.client = &http.Client{Timeout: timeout}
+	return true, nil
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
+type message struct {
+	Channel     string       `json:"channel,omitempty"`
+	Text        string       `json:"text"`
+	Attachments []attachment `json:"attachments"`
+}
+
+type attachment struct {
+	Fallback  string  `json:"fallback"`
+	Color     string  `json:"color"`
+	Title     string  `json:"title"`
+	TitleLink string  `json:"title_link,omitempty"`
+	Text      string  `json:"text,omitempty"`
+	Fields    []field `json:"fields"`
+	Footer    string  `json:"footer"`
+}
+
+type field struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type apiResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+func (s *sender) Send(notificationName string) error {
+	return s.SendBatch([]string{notificationName})
+}
+
+// SendBatch posts the notifications in as few messages as Slack accepts, so
+// that a burst of notifications isn't rate limited.
+func (s *sender) SendBatch(notificationNames []string) error {
+	attachments := make([]attachment, 0, len(notificationNames))
+	for _, name := range notificationNames {
+		a, ok, err := s.describe(name)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			attachments = append(attachments, a)
+		}
+	}
+
+	for len(attachments) > 0 {
+		n := len(attachments)
+		if n > maxAttachments {
+			n = maxAttachments
+		}
+
+		text := "Clair found a vulnerability change"
+		if n > 1 {
+			text = fmt.Sprintf("Clair found %d vulnerability changes", n)
+		}
+
+		if err := s.post(message{Channel: s.channel, Text: text, Attachments: attachments[:n]}); err != nil {
+			return err
+		}
+
+		attachments = attachments[n:]
+	}
+
+	return nil
+}
+
+// describe returns the attachment describing the vulnerability of the
+// notification, or false if the notification doesn't exist anymore.
+func (s *sender) describe(name string) (attachment, bool, error) {
+	if s.datastore == nil {
+		return attachment{Fallback: name, Color: severityColors[database.UnknownSeverity], Title: name, Footer: name}, true, nil
+	}
+
+	change, ok, err := notification.FindVulnerabilityChange(s.datastore, name, 1)
+	if err != nil || !ok {
+		return attachment{}, false, err
+	}
+
+	vulnerability := change.Vulnerability
+	color, ok := severityColors[vulnerability.Severity]
+	if !ok {
+		color = severityColors[database.UnknownSeverity]
+	}
+
+	return attachment{
+		Fallback:  fmt.Sprintf("%s vulnerability: %s (%s) in %s", change.Kind, vulnerability.Name, vulnerability.Severity, vulnerability.Namespace.Name),
+		Color:     color,
+		Title:     fmt.Sprintf("%s vulnerability: %s", change.Kind, vulnerability.Name),
+		TitleLink: vulnerability.Link,
+		Fields: []field{
+			{Title: "Severity", Value: string(vulnerability.Severity), Short: true},
+			{Title: "Namespace", Value: vulnerability.Namespace.Name, Short: true},
+		},
+		Footer: name,
+	}, true, nil
+}
+
+// post posts the message to the webhook, or with the chat.postMessage method
+// of the Web API. Any failure is returned so that the notifier retries
+// sending the notifications, Slack throttling included.
+func (s *sender) post(msg message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	endpoint := s.webhookURL
+	if endpoint == "" {
+		endpoint = apiURL + "/chat.postMessage"
+	}
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if s.webhookURL == "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("slack: rate limited, retry after %ss", resp.Header.Get("Retry-After"))
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack: got status %d: %s", resp.StatusCode, bytes.TrimSpace(respBody))
+	}
+
+	// The Web API reports its errors in the body of successful responses.
+	if s.webhookURL == "" {
+		var apiResp apiResponse
+		if err := json.Unmarshal(respBody, &apiResp); err != nil {
+			return fmt.Errorf("slack: could not unmarshal the response: %s", err)
+		}
+
+		if !apiResp.OK {
+			return fmt.Errorf("slack: %s", apiResp.Error)
+		}
+	}
+
+	return nil
+}
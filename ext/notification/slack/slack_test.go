@@ -0,0 +1,165 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/ext/notification/notificationtest"
+)
+
+func TestSendWebhook(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.HighSeverity)...)
+	defer store.Close()
+
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		var body map[string]interface{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	s := &sender{}
+	notificationtest.Configure(t, s, "slack", store, map[interface{}]interface{}{"webhookurl": server.URL})
+	require.Nil(t, s.Send("notification-0"))
+	require.Len(t, bodies, 1)
+	assert.Equal(t, map[string]interface{}{
+		"text": "Clair found a vulnerability change",
+		"attachments": []interface{}{map[string]interface{}{
+			"fallback":   "New vulnerability: CVE-2019-0000 (High) in debian:9",
+			"color":      "#ff6d00",
+			"title":      "New vulnerability: CVE-2019-0000",
+			"title_link": "https://security-tracker.debian.org/tracker/CVE-2019-0000",
+			"fields": []interface{}{
+				map[string]interface{}{"title": "Severity", "value": "High", "short": true},
+				map[string]interface{}{"title": "Namespace", "value": "debian:9", "short": true},
+			},
+			"footer": "notification-0",
+		}},
+	}, bodies[0])
+
+	// The notifications which don't exist anymore are not posted.
+	require.Nil(t, s.Send("deleted"))
+	assert.Len(t, bodies, 1)
+}
+
+func TestSendBatch(t *testing.T) {
+	severities := make([]database.Severity, maxAttachments+10)
+	for i := range severities {
+		severities[i] = database.Severities[i%len(database.Severities)]
+	}
+
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), severities...)...)
+	defer store.Close()
+
+	var attachments []int
+	colors := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body message
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		attachments = append(attachments, len(body.Attachments))
+		for _, a := range body.Attachments {
+			colors[a.Color] = true
+		}
+	}))
+	defer server.Close()
+
+	names := make([]string, len(severities))
+	for i := range names {
+		names[i] = fmt.Sprintf("notification-%d", i)
+	}
+
+	// A burst of notifications is posted in as few messages as possible,
+	// colored by severity.
+	s := &sender{}
+	notificationtest.Configure(t, s, "slack", store, map[interface{}]interface{}{"webhookurl": server.URL})
+	require.Nil(t, s.SendBatch(names))
+	assert.Equal(t, []int{maxAttachments, 10}, attachments)
+	assert.Len(t, colors, len(database.Severities)-1)
+}
+
+func TestSendWebAPI(t *testing.T) {
+	store := notificationtest.OpenStore(t, notificationtest.NewVulnerabilities(time.Now(), database.CriticalSeverity)...)
+	defer store.Close()
+
+	responses := []func(w http.ResponseWriter){
+		func(w http.ResponseWriter) { w.Write([]byte(`{"ok": true}`)) },
+		func(w http.ResponseWriter) { w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`)) },
+		func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+		func(w http.ResponseWriter) { w.WriteHeader(http.StatusInternalServerError) },
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+		var body message
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "#security", body.Channel)
+		assert.Len(t, body.Attachments, 1)
+
+		responses[0](w)
+		responses = responses[1:]
+	}))
+	defer server.Close()
+
+	defer func(url string) { apiURL = url }(apiURL)
+	apiURL = server.URL
+
+	// The errors of Slack are returned for the notifier to retry.
+	s := &sender{}
+	notificationtest.Configure(t, s, "slack", store, map[interface{}]interface{}{"token": "xoxb-token", "channel": "#security"})
+	assert.Nil(t, s.Send("notification-0"))
+	assert.EqualError(t, s.Send("notification-0"), "slack: channel_not_found")
+	assert.EqualError(t, s.Send("notification-0"), "slack: rate limited, retry after 30s")
+	assert.NotNil(t, s.Send("notification-0"))
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"empty", map[string]interface{}{"slack": map[interface{}]interface{}{}}, false, false},
+		{"webhook", map[string]interface{}{"slack": map[interface{}]interface{}{"webhookurl": "https://hooks.slack.com/services/T0/B0/X"}}, true, false},
+		{"invalid webhook", map[string]interface{}{"slack": map[interface{}]interface{}{"webhookurl": "hooks"}}, false, true},
+		{"token", map[string]interface{}{"slack": map[interface{}]interface{}{"token": "xoxb-token", "channel": "#security"}}, true, false},
+		{"token without channel", map[string]interface{}{"slack": map[interface{}]interface{}{"token": "xoxb-token"}}, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configured, err := (&sender{}).Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notification
+
+import (
+	"sort"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+// The kinds of vulnerability changes.
+const (
+	NewVulnerability     = "New"
+	UpdatedVulnerability = "Updated"
+	RemovedVulnerability = "Removed"
+)
+
+// VulnerabilityChange describes the vulnerability change of a notification,
+// for the Senders describing the notifications rather than only transmitting
+// their names.
+type VulnerabilityChange struct {
+	// Notification is the name of the notification.
+	Notification string
+	// Kind is NewVulnerability, UpdatedVulnerability or RemovedVulnerability.
+	Kind string
+	// Vulnerability is the new vulnerability, or the removed one.
+	Vulnerability database.Vulnerability
+
+	// Ancestries are the names of the first ancestries affected by the
+	// vulnerability, sorted.
+	Ancestries []string
+	// MoreAncestries is whether more ancestries are affected than listed.
+	MoreAncestries bool
+}
+
+// FindVulnerabilityChange reads the vulnerability change of a notification
+// from the datastore, with up to ancestryLimit affected ancestries. It returns
+// false if the notification doesn't exist anymore.
+func FindVulnerabilityChange(datastore database.Datastore, name string, ancestryLimit int) (VulnerabilityChange, bool, error) {
+	n, ok, err := database.FindVulnerabilityNotificationAndRollback(datastore, name, ancestryLimit, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return VulnerabilityChange{}, false, err
+	}
+
+	change := VulnerabilityChange{Notification: name}
+	var page *database.PagedVulnerableAncestries
+	switch {
+	case n.Old == nil && n.New != nil:
+		change.Kind, page = NewVulnerability, n.New
+	case n.New != nil:
+		change.Kind, page = UpdatedVulnerability, n.New
+	case n.Old != nil:
+		change.Kind, page = RemovedVulnerability, n.Old
+	default:
+		return VulnerabilityChange{}, false, nil
+	}
+
+	change.Vulnerability = page.Vulnerability
+	for _, ancestry := range page.Affected {
+		change.Ancestries = append(change.Ancestries, ancestry)
+	}
+	sort.Strings(change.Ancestries)
+	change.MoreAncestries = !page.End
+
+	return change, true, nil
+}
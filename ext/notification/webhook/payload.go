@@ -0,0 +1,214 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+// The payloads of the webhooks.
+const (
+	// PayloadThin only sends the names of the notifications.
+	PayloadThin = "thin"
+	// PayloadFull embeds the content of the notifications.
+	PayloadFull = "full"
+)
+
+// FullPayloadVersion is the Version of the full payloads, the thin payloads
+// having none.
+const FullPayloadVersion = 2
+
+const (
+	defaultAncestryLimit  = 10
+	defaultMaxPayloadSize = 1 << 20
+)
+
+var errPayloadTooLarge = errors.New("payload exceeds the maximum size")
+
+// FullEnvelope is the full payload of a notification.
+type FullEnvelope struct {
+	Version      int
+	Notification FullNotification
+}
+
+// FullBatchEnvelope is the full payload of a batch of notifications.
+type FullBatchEnvelope struct {
+	Version       int
+	Notifications []FullNotification
+}
+
+// FullNotification is the content of a notification, Old being omitted for a
+// new vulnerability and New for a removed one.
+type FullNotification struct {
+	Name    string
+	Created time.Time
+	Old     *VulnerabilityPage `json:",omitempty"`
+	New     *VulnerabilityPage `json:",omitempty"`
+
+	// Truncated is whether the descriptions and the ancestries were dropped
+	// for the payload not to exceed its maximum size, in which case they are
+	// read from the API.
+	Truncated bool `json:",omitempty"`
+}
+
+// VulnerabilityPage is a vulnerability with the first page of the ancestries
+// it affects.
+type VulnerabilityPage struct {
+	Name        string
+	Namespace   string
+	Severity    database.Severity
+	Link        string `json:",omitempty"`
+	Description string `json:",omitempty"`
+
+	AffectedAncestries []string
+	// NextPage is the page token of the following ancestries, passed as the
+	// old or new vulnerability page of the GetNotification RPC. It is empty
+	// when every affected ancestry is listed.
+	NextPage string `json:",omitempty"`
+}
+
+// sendFull sends the content of the notifications, or only their names if it
+// doesn't fit in the maximum payload size. The notifications which don't
+// exist anymore are skipped.
+func (s *sender) sendFull(notificationNames []string, batch bool) error {
+	var notifications []FullNotification
+	for _, name := range notificationNames {
+		n, ok, err := s.resolve(name)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			notifications = append(notifications, n)
+		}
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	body, err := marshalFull(notifications, batch, s.maxPayloadSize)
+	if err == errPayloadTooLarge {
+		log.WithFields(log.Fields{"notifications": len(notifications), "max": s.maxPayloadSize}).Warning("full webhook payload too large, sending the names of the notifications")
+		if batch {
+			return s.sendThinBatch(notificationNames)
+		}
+		return s.sendThin(notificationNames[0])
+	} else if err != nil {
+		return fmt.Errorf("could not marshal: %s", err)
+	}
+
+	return s.postBody(body)
+}
+
+// resolve reads the content of a notification from the datastore. It returns
+// false if the notification doesn't exist anymore.
+func (s *sender) resolve(name string) (FullNotification, bool, error) {
+	n, ok, err := database.FindVulnerabilityNotificationAndRollback(s.datastore, name, s.ancestryLimit, pagination.FirstPageToken, pagination.FirstPageToken)
+	if err != nil || !ok {
+		return FullNotification{}, false, err
+	}
+
+	return FullNotification{
+		Name:    name,
+		Created: n.Created.UTC(),
+		Old:     newVulnerabilityPage(n.Old),
+		New:     newVulnerabilityPage(n.New),
+	}, true, nil
+}
+
+func newVulnerabilityPage(page *database.PagedVulnerableAncestries) *VulnerabilityPage {
+	if page == nil {
+		return nil
+	}
+
+	// The ancestries are listed in the order of their indexes.
+	indexes := make([]int, 0, len(page.Affected))
+	for index := range page.Affected {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	ancestries := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		ancestries = append(ancestries, page.Affected[index])
+	}
+
+	vulnerabilityPage := &VulnerabilityPage{
+		Name:               page.Name,
+		Namespace:          page.Namespace.Name,
+		Severity:           page.Severity,
+		Link:               page.Link,
+		Description:        page.Description,
+		AffectedAncestries: ancestries,
+	}
+	if !page.End {
+		vulnerabilityPage.NextPage = string(page.Next)
+	}
+
+	return vulnerabilityPage
+}
+
+// marshalFull returns the full payload of the notifications, truncated when
+// it exceeds maxSize bytes. It fails with errPayloadTooLarge when it still
+// exceeds it once truncated.
+func marshalFull(notifications []FullNotification, batch bool, maxSize int) ([]byte, error) {
+	marshal := func() ([]byte, error) {
+		if batch {
+			return json.Marshal(FullBatchEnvelope{Version: FullPayloadVersion, Notifications: notifications})
+		}
+		return json.Marshal(FullEnvelope{Version: FullPayloadVersion, Notification: notifications[0]})
+	}
+
+	body, err := marshal()
+	if err != nil || len(body) <= maxSize {
+		return body, err
+	}
+
+	for i := range notifications {
+		notifications[i].truncate()
+	}
+
+	body, err = marshal()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSize {
+		return nil, errPayloadTooLarge
+	}
+
+	return body, nil
+}
+
+// truncate drops the descriptions and the ancestries of the notification.
+func (n *FullNotification) truncate() {
+	for _, page := range []*VulnerabilityPage{n.Old, n.New} {
+		if page != nil {
+			page.Description = ""
+			page.AffectedAncestries = []string{}
+			page.NextPage = ""
+		}
+	}
+	n.Truncated = true
+}
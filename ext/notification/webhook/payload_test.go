@@ -0,0 +1,221 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+var created = time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// openStore returns a datastore with the ancestries "ancestry-1" to
+// "ancestry-3" featuring openssl 1.0, and the notifications "new" of the new
+// vulnerability CVE-2019-0001 of openssl and "removed" of the removed
+// vulnerability CVE-2019-0002.
+func openStore(t *testing.T) database.Datastore {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+
+	ns := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	nsDetector := database.NewNamespaceDetector("os-release", "1.0")
+	pkgDetector := database.NewFeatureDetector("dpkg", "1.0")
+	feature := database.Feature{Name: "openssl", Version: "1.0", VersionFormat: dpkg.ParserName, Type: database.BinaryPackage}
+	nsFeature := database.NamespacedFeature{Feature: feature, Namespace: ns}
+
+	added := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        "CVE-2019-0001",
+			Namespace:   ns,
+			Severity:    database.HighSeverity,
+			Link:        "https://security-tracker.debian.org/tracker/CVE-2019-0001",
+			Description: "A buffer overflow in openssl.",
+		},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.BinaryPackage,
+			Namespace:       ns,
+			FeatureName:     "openssl",
+			AffectedVersion: "2.0",
+			FixedInVersion:  "2.0",
+		}},
+	}
+	removed := database.Vulnerability{Name: "CVE-2019-0002", Namespace: ns, Severity: database.LowSeverity}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistDetectors([]database.Detector{nsDetector, pkgDetector}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.PersistFeatures([]database.Feature{feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{nsFeature}))
+	require.Nil(t, tx.PersistLayer("layer",
+		[]database.LayerFeature{{Feature: feature, By: pkgDetector}},
+		[]database.LayerNamespace{{Namespace: ns, By: nsDetector}},
+		[]database.Detector{nsDetector, pkgDetector},
+	))
+	for _, name := range []string{"ancestry-1", "ancestry-2", "ancestry-3"} {
+		require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+			Name: name,
+			By:   []database.Detector{nsDetector, pkgDetector},
+			Layers: []database.AncestryLayer{{
+				Hash: "layer",
+				Features: []database.AncestryFeature{{
+					NamespacedFeature: nsFeature,
+					FeatureBy:         pkgDetector,
+					NamespaceBy:       nsDetector,
+				}},
+			}},
+		}))
+	}
+
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{added, {Vulnerability: removed}}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: removed.Name, Namespace: ns.Name}}))
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{
+		{NotificationHook: database.NotificationHook{Name: "new", Created: created}, New: &added.Vulnerability},
+		{NotificationHook: database.NotificationHook{Name: "removed", Created: created}, Old: &removed},
+	}))
+	require.Nil(t, tx.Commit())
+	return store
+}
+
+// receive returns a server recording the bodies of the requests.
+func receive(bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(body))
+	}))
+}
+
+func TestSendFull(t *testing.T) {
+	store := openStore(t)
+	defer store.Close()
+
+	var bodies []string
+	server := receive(&bodies)
+	defer server.Close()
+
+	s := configure(t, map[string]interface{}{"endpoint": server.URL, "payload": "full"})
+	s.SetDatastore(store)
+
+	require.Nil(t, s.Send("new"))
+	require.Nil(t, s.SendBatch([]string{"removed", "unknown"}))
+	// Notifications which don't exist anymore aren't sent.
+	require.Nil(t, s.Send("unknown"))
+
+	require.Len(t, bodies, 2)
+	assert.JSONEq(t, `{
+		"Version": 2,
+		"Notification": {
+			"Name": "new",
+			"Created": "2019-06-01T12:00:00Z",
+			"New": {
+				"Name": "CVE-2019-0001",
+				"Namespace": "debian:9",
+				"Severity": "High",
+				"Link": "https://security-tracker.debian.org/tracker/CVE-2019-0001",
+				"Description": "A buffer overflow in openssl.",
+				"AffectedAncestries": ["ancestry-1", "ancestry-2", "ancestry-3"]
+			}
+		}
+	}`, bodies[0])
+	assert.JSONEq(t, `{
+		"Version": 2,
+		"Notifications": [{
+			"Name": "removed",
+			"Created": "2019-06-01T12:00:00Z",
+			"Old": {
+				"Name": "CVE-2019-0002",
+				"Namespace": "debian:9",
+				"Severity": "Low",
+				"AffectedAncestries": []
+			}
+		}]
+	}`, bodies[1])
+}
+
+func TestSendFullNextPage(t *testing.T) {
+	store := openStore(t)
+	defer store.Close()
+
+	var bodies []string
+	server := receive(&bodies)
+	defer server.Close()
+
+	s := configure(t, map[string]interface{}{"endpoint": server.URL, "payload": "full", "ancestrylimit": 2})
+	s.SetDatastore(store)
+	require.Nil(t, s.Send("new"))
+	require.Len(t, bodies, 1)
+
+	var envelope FullEnvelope
+	require.Nil(t, json.Unmarshal([]byte(bodies[0]), &envelope))
+	require.NotNil(t, envelope.Notification.New)
+	assert.Equal(t, []string{"ancestry-1", "ancestry-2"}, envelope.Notification.New.AffectedAncestries)
+	require.NotEmpty(t, envelope.Notification.New.NextPage)
+
+	// The next page lists the remaining ancestries.
+	n, ok, err := database.FindVulnerabilityNotificationAndRollback(store, "new", 2, pagination.FirstPageToken, pagination.Token(envelope.Notification.New.NextPage))
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.True(t, n.New.End)
+	assert.Equal(t, []string{"ancestry-3"}, newVulnerabilityPage(n.New).AffectedAncestries)
+}
+
+func TestSendFullMaxPayloadSize(t *testing.T) {
+	store := openStore(t)
+	defer store.Close()
+
+	var bodies []string
+	server := receive(&bodies)
+	defer server.Close()
+
+	// The descriptions and the ancestries are dropped from the payloads which
+	// are too large, and only the name is sent when it isn't enough.
+	s := configure(t, map[string]interface{}{"endpoint": server.URL, "payload": "full", "maxpayloadsize": 300})
+	s.SetDatastore(store)
+	require.Nil(t, s.Send("new"))
+
+	s = configure(t, map[string]interface{}{"endpoint": server.URL, "payload": "full", "maxpayloadsize": 100})
+	s.SetDatastore(store)
+	require.Nil(t, s.Send("new"))
+
+	require.Len(t, bodies, 2)
+	assert.JSONEq(t, `{
+		"Version": 2,
+		"Notification": {
+			"Name": "new",
+			"Created": "2019-06-01T12:00:00Z",
+			"New": {
+				"Name": "CVE-2019-0001",
+				"Namespace": "debian:9",
+				"Severity": "High",
+				"Link": "https://security-tracker.debian.org/tracker/CVE-2019-0001",
+				"AffectedAncestries": []
+			},
+			"Truncated": true
+		}
+	}`, bodies[0])
+	assert.JSONEq(t, `{"Notification": {"Name": "new"}}`, bodies[1])
+}
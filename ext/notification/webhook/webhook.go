@@ -13,30 +13,68 @@
 // limitations under the License.
 
 // Package webhook implements a notification sender for HTTP JSON webhooks.
+//
+// Receivers can authenticate the webhooks when a secret is configured: every
+// request then carries its Unix time in the X-Clair-Timestamp header and the
+// HMAC-SHA256 of the timestamp, a dot and the body, keyed by the secret, in
+// the X-Clair-Signature header as "sha256=" followed by the hexadecimal MAC.
+// A receiver computes the same MAC over the exact bytes of the body it read,
+// compares it in constant time, and rejects the timestamps which are too old
+// to prevent replays. Receivers written in Go can use VerifyRequest.
 package webhook
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/notification"
 )
 
 const timeout = 5 * time.Second
 
+// The headers authenticating the webhooks.
+const (
+	// SignatureHeader is the header of the signature of a webhook.
+	SignatureHeader = "X-Clair-Signature"
+	// TimestampHeader is the header of the Unix time a webhook was signed at.
+	TimestampHeader = "X-Clair-Timestamp"
+
+	signaturePrefix = "sha256="
+)
+
+// DefaultTolerance is the maximum age of a webhook accepted by VerifyRequest
+// with no tolerance.
+const DefaultTolerance = 5 * time.Minute
+
 type sender struct {
 	endpoint string
 	client   *http.Client
+	secret   []byte
+	headers  map[string]string
+	username string
+	password string
+	now      func() time.Time
+
+	payload        string
+	ancestryLimit  int
+	maxPayloadSize int
+	datastore      database.Datastore
 }
 
 // Config represents the configuration of a Webhook Sender.
@@ -47,6 +85,26 @@ type Config struct {
 	KeyFile    string
 	CAFile     string
 	Proxy      string
+
+	// Secret signs the requests when it is set.
+	Secret string
+	// Headers are static headers added to the requests, such as an
+	// Authorization bearer token.
+	Headers map[string]string
+	// Username and Password authenticate the requests with basic auth when
+	// the username is set.
+	Username string
+	Password string
+
+	// Payload is "thin" (default) to only send the names of the
+	// notifications, or "full" to embed their content.
+	Payload string
+	// AncestryLimit is the number of affected ancestries listed by the full
+	// payloads, 10 by default.
+	AncestryLimit int
+	// MaxPayloadSize is the size in bytes above which the full payloads are
+	// truncated, 1MiB by default.
+	MaxPayloadSize int
 }
 
 func init() {
@@ -80,6 +138,43 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	}
 	s.endpoint = httpConfig.Endpoint
 
+	// Set authentication.
+	for name := range httpConfig.Headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Type", SignatureHeader, TimestampHeader:
+			return false, fmt.Errorf("header %s can't be overridden", name)
+		case "Authorization":
+			if httpConfig.Username != "" {
+				return false, errors.New("both an Authorization header and basic auth are specified")
+			}
+		}
+	}
+	s.secret = []byte(httpConfig.Secret)
+	s.headers = httpConfig.Headers
+	s.username, s.password = httpConfig.Username, httpConfig.Password
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	// Set payload.
+	switch httpConfig.Payload {
+	case "":
+		httpConfig.Payload = PayloadThin
+	case PayloadThin, PayloadFull:
+	default:
+		return false, fmt.Errorf("unknown payload %q", httpConfig.Payload)
+	}
+	if httpConfig.AncestryLimit < 0 || httpConfig.MaxPayloadSize < 0 {
+		return false, errors.New("negative ancestrylimit or maxpayloadsize")
+	}
+	if httpConfig.AncestryLimit == 0 {
+		httpConfig.AncestryLimit = defaultAncestryLimit
+	}
+	if httpConfig.MaxPayloadSize == 0 {
+		httpConfig.MaxPayloadSize = defaultMaxPayloadSize
+	}
+	s.payload, s.ancestryLimit, s.maxPayloadSize = httpConfig.Payload, httpConfig.AncestryLimit, httpConfig.MaxPayloadSize
+
 	// Setup HTTP client.
 	transport := &http.Transport{}
 	s.client = &http.Client{
@@ -90,7 +185,7 @@ func (s *sender) Configure(config *notification.Config) (bool, error) {
 	// Initialize TLS.
 	transport.TLSClientConfig, err = loadTLSClientConfig(&httpConfig)
 	if err != nil {
-		return false, fmt.Errorf("could not initialize client cert auth: %s\n", err)
+		return false, fmt.Errorf("could not initialize TLS: %s", err)
 	}
 
 	// Set proxy.
@@ -111,15 +206,87 @@ type notificationEnvelope struct {
 	}
 }
 
+type notificationBatchEnvelope struct {
+	Notifications []struct {
+		Name string
+	}
+}
+
+type digestEnvelope struct {
+	Digest notification.Digest
+}
+
+// SetDatastore implements notification.DatastoreSender.
+func (s *sender) SetDatastore(datastore database.Datastore) {
+	s.datastore = datastore
+}
+
 func (s *sender) Send(notificationName string) error {
+	if s.payload == PayloadFull && s.datastore != nil {
+		return s.sendFull([]string{notificationName}, false)
+	}
+
+	return s.sendThin(notificationName)
+}
+
+func (s *sender) sendThin(notificationName string) error {
+	return s.post(notificationEnvelope{struct{ Name string }{notificationName}})
+}
+
+// SendBatch sends the notifications in a single request.
+func (s *sender) SendBatch(notificationNames []string) error {
+	if s.payload == PayloadFull && s.datastore != nil {
+		return s.sendFull(notificationNames, true)
+	}
+
+	return s.sendThinBatch(notificationNames)
+}
+
+// SendDigest sends the digest of the notifications in a single request,
+// whichever the payload.
+func (s *sender) SendDigest(digest notification.Digest) error {
+	return s.post(digestEnvelope{digest})
+}
+
+func (s *sender) sendThinBatch(notificationNames []string) error {
+	var envelope notificationBatchEnvelope
+	for _, name := range notificationNames {
+		envelope.Notifications = append(envelope.Notifications, struct{ Name string }{name})
+	}
+
+	return s.post(envelope)
+}
+
+func (s *sender) post(envelope interface{}) error {
 	// Marshal notification.
-	jsonNotification, err := json.Marshal(notificationEnvelope{struct{ Name string }{notificationName}})
+	jsonNotification, err := json.Marshal(envelope)
 	if err != nil {
 		return fmt.Errorf("could not marshal: %s", err)
 	}
 
+	return s.postBody(jsonNotification)
+}
+
+func (s *sender) postBody(jsonNotification []byte) error {
 	// Send notification via HTTP POST.
-	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewBuffer(jsonNotification))
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(jsonNotification))
+	if err != nil {
+		return err
+	}
+	for name, value := range s.headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.secret) > 0 {
+		timestamp := strconv.FormatInt(s.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(s.secret, timestamp, jsonNotification))
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	resp, err := s.client.Do(req)
 	if err != nil || resp == nil || (resp.StatusCode != 200 && resp.StatusCode != 201) {
 		if resp != nil {
 			return fmt.Errorf("got status %d, expected 200/201", resp.StatusCode)
@@ -131,34 +298,86 @@ func (s *sender) Send(notificationName string) error {
 	return nil
 }
 
+// Sign returns the signature of a webhook body sent at the given Unix
+// timestamp, as sent in the SignatureHeader.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequest reads the body of a webhook request and returns it if the
+// request is signed with the secret less than tolerance ago, DefaultTolerance
+// being used when it is zero. The body of the request is restored, so that it
+// can be read again.
+func VerifyRequest(secret []byte, r *http.Request, tolerance time.Duration) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+
+	timestamp := r.Header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("missing or invalid webhook timestamp")
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return nil, errors.New("webhook timestamp is outside of the tolerance")
+	}
+
+	signature := r.Header.Get(SignatureHeader)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return nil, errors.New("missing or invalid webhook signature")
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return nil, errors.New("webhook signature mismatch")
+	}
+
+	return body, nil
+}
+
 // loadTLSClientConfig initializes a *tls.Config using the given Config.
 //
-// If no certificates are given, (nil, nil) is returned.
-// The CA certificate is optional and falls back to the system default.
+// The client certificate is presented when it is given, with its key. The CA
+// certificate is optional and falls back to the system default, and so is the
+// server name verified, which falls back to the host of the endpoint. If none
+// is given, (nil, nil) is returned.
 func loadTLSClientConfig(cfg *Config) (*tls.Config, error) {
-	if cfg.CertFile == "" || cfg.KeyFile == "" {
+	if cfg.CertFile == "" && cfg.KeyFile == "" && cfg.CAFile == "" && cfg.ServerName == "" {
 		return nil, nil
 	}
 
-	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
-	if err != nil {
-		return nil, err
+	tlsConfig := &tls.Config{ServerName: cfg.ServerName}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("certfile and keyfile must be given together")
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
-	var caCertPool *x509.CertPool
 	if cfg.CAFile != "" {
 		caCert, err := ioutil.ReadFile(cfg.CAFile)
 		if err != nil {
 			return nil, err
 		}
-		caCertPool = x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM(caCert)
-	}
-
-	tlsConfig := &tls.Config{
-		ServerName:   cfg.ServerName,
-		Certificates: []tls.Certificate{cert},
-		RootCAs:      caCertPool,
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("could not load any certificate from %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = caCertPool
 	}
 
 	return tlsConfig, nil
@@ -0,0 +1,332 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/notification"
+)
+
+func TestSendBatch(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	s := &sender{}
+	configured, err := s.Configure(&notification.Config{
+		Params: map[string]interface{}{"http": map[string]interface{}{"endpoint": server.URL}},
+	})
+	require.Nil(t, err)
+	require.True(t, configured)
+
+	require.Nil(t, s.SendBatch([]string{"a", "b", "c"}))
+	require.Len(t, bodies, 1)
+	assert.Equal(t, map[string]interface{}{
+		"Notifications": []interface{}{
+			map[string]interface{}{"Name": "a"},
+			map[string]interface{}{"Name": "b"},
+			map[string]interface{}{"Name": "c"},
+		},
+	}, bodies[0])
+
+	require.Nil(t, s.Send("d"))
+	require.Len(t, bodies, 2)
+	assert.Equal(t, map[string]interface{}{
+		"Notification": map[string]interface{}{"Name": "d"},
+	}, bodies[1])
+}
+
+func configure(t *testing.T, params map[string]interface{}) *sender {
+	s := &sender{}
+	configured, err := s.Configure(&notification.Config{Params: map[string]interface{}{"http": params}})
+	require.Nil(t, err)
+	require.True(t, configured)
+	return s
+}
+
+func TestSendDigest(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	s := configure(t, map[string]interface{}{"endpoint": server.URL})
+	sent := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	require.Nil(t, s.SendDigest(notification.Digest{
+		Sent: sent,
+		Notifications: []notification.Summary{{
+			Name:    "a",
+			Created: sent,
+			Sent:    sent,
+			New:     &notification.VulnerabilitySummary{Name: "CVE-2019-0001", Namespace: "debian:9", Severity: database.HighSeverity},
+		}},
+	}))
+	assert.Equal(t, map[string]interface{}{
+		"Digest": map[string]interface{}{
+			"Sent": "2019-06-01T12:00:00Z",
+			"Notifications": []interface{}{map[string]interface{}{
+				"Name":    "a",
+				"Created": "2019-06-01T12:00:00Z",
+				"Sent":    "2019-06-01T12:00:00Z",
+				"New":     map[string]interface{}{"Name": "CVE-2019-0001", "Namespace": "debian:9", "Severity": "High"},
+			}},
+		},
+	}, body)
+}
+
+func TestSendSigned(t *testing.T) {
+	secret := []byte("secret")
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := VerifyRequest(secret, r, 0)
+		assert.Nil(t, err)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+	}))
+	defer server.Close()
+
+	s := configure(t, map[string]interface{}{"endpoint": server.URL, "secret": string(secret)})
+	s.now = func() time.Time { return time.Now().Add(-time.Minute) }
+	require.Nil(t, s.Send("a"))
+	require.Len(t, requests, 1)
+
+	// The signature is the MAC of the timestamp and of the exact bytes sent.
+	timestamp := requests[0].Header.Get(TimestampHeader)
+	assert.Equal(t, strconv.FormatInt(s.now().Unix(), 10), timestamp)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "." + string(bodies[0])))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), requests[0].Header.Get(SignatureHeader))
+	assert.Equal(t, `{"Notification":{"Name":"a"}}`, string(bodies[0]))
+}
+
+func TestSendHeaders(t *testing.T) {
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+	}))
+	defer server.Close()
+
+	s := configure(t, map[string]interface{}{
+		"endpoint": server.URL,
+		"headers":  map[string]interface{}{"Authorization": "Bearer token", "x-tenant": "tenant"},
+	})
+	require.Nil(t, s.Send("a"))
+
+	s = configure(t, map[string]interface{}{"endpoint": server.URL, "username": "clair", "password": "password"})
+	require.Nil(t, s.Send("b"))
+
+	require.Len(t, requests, 2)
+	assert.Equal(t, "Bearer token", requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "tenant", requests[0].Header.Get("X-Tenant"))
+	assert.Equal(t, "application/json", requests[0].Header.Get("Content-Type"))
+	// Unsigned requests have no signature.
+	assert.Empty(t, requests[0].Header.Get(SignatureHeader))
+	assert.Empty(t, requests[0].Header.Get(TimestampHeader))
+
+	username, password, ok := requests[1].BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "clair", username)
+	assert.Equal(t, "password", password)
+}
+
+func TestVerifyRequest(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"Notification":{"Name":"a"}}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	for _, test := range []struct {
+		name      string
+		timestamp string
+		signature string
+		body      []byte
+		tolerance time.Duration
+		valid     bool
+	}{
+		{"valid", now, Sign(secret, now, body), body, 0, true},
+		{"tampered body", now, Sign(secret, now, body), []byte(`{"Notification":{"Name":"b"}}`), 0, false},
+		{"tampered timestamp", old, Sign(secret, now, body), body, 0, false},
+		{"wrong secret", now, Sign([]byte("other"), now, body), body, 0, false},
+		{"replayed", old, Sign(secret, old, body), body, 0, false},
+		{"tolerated", old, Sign(secret, old, body), body, 2 * time.Hour, true},
+		{"missing signature", now, "", body, 0, false},
+		{"missing timestamp", "", Sign(secret, "", body), body, 0, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			r.Header.Set(TimestampHeader, test.timestamp)
+			r.Header.Set(SignatureHeader, test.signature)
+
+			verified, err := VerifyRequest(secret, r, test.tolerance)
+			assert.Equal(t, test.valid, err == nil, "%v", err)
+			if test.valid {
+				assert.Equal(t, test.body, verified)
+			}
+
+			// The body can be read again.
+			restored, err := ioutil.ReadAll(r.Body)
+			require.Nil(t, err)
+			assert.Equal(t, test.body, restored)
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"missing", map[string]interface{}{}, false, false},
+		{"no endpoint", map[string]interface{}{"http": map[string]interface{}{"secret": "secret"}}, false, false},
+		{"signed", map[string]interface{}{"http": map[string]interface{}{"endpoint": "http://localhost", "secret": "secret"}}, true, false},
+		{"invalid endpoint", map[string]interface{}{"http": map[string]interface{}{"endpoint": "localhost"}}, false, true},
+		{"signature header", map[string]interface{}{"http": map[string]interface{}{"endpoint": "http://localhost", "headers": map[string]interface{}{"x-clair-signature": "forged"}}}, false, true},
+		{"full payload", map[string]interface{}{"http": map[string]interface{}{"endpoint": "http://localhost", "payload": "full"}}, true, false},
+		{"unknown payload", map[string]interface{}{"http": map[string]interface{}{"endpoint": "http://localhost", "payload": "fat"}}, false, true},
+		{"authorization and basic auth", map[string]interface{}{"http": map[string]interface{}{"endpoint": "http://localhost", "username": "clair", "headers": map[string]interface{}{"Authorization": "Bearer token"}}}, false, true},
+		{"missing client certificate", map[string]interface{}{"http": map[string]interface{}{"endpoint": "https://localhost", "certfile": "/does/not/exist.pem", "keyfile": "/does/not/exist.key"}}, false, true},
+		{"client certificate without key", map[string]interface{}{"http": map[string]interface{}{"endpoint": "https://localhost", "certfile": "/does/not/exist.pem"}}, false, true},
+		{"missing CA", map[string]interface{}{"http": map[string]interface{}{"endpoint": "https://localhost", "cafile": "/does/not/exist.pem"}}, false, true},
+		{"server name", map[string]interface{}{"http": map[string]interface{}{"endpoint": "https://localhost", "servername": "webhook.internal"}}, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			configured, err := (&sender{}).Configure(&notification.Config{Params: test.params})
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil)
+		})
+	}
+}
+
+// writeCertificate writes the PEM encoded certificate, signed by the parent
+// or self-signed, and its key to the directory.
+func writeCertificate(t *testing.T, dir, name string, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return cert, key
+}
+
+func TestSendMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "webhook")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// The receiver requires a client certificate signed by a private CA.
+	notAfter := time.Now().Add(time.Hour)
+	ca, caKey := writeCertificate(t, dir, "ca", &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Clair test CA"},
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	writeCertificate(t, dir, "client", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "clair"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+	writeCertificate(t, dir, "rogue", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "rogue"},
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, nil, nil)
+
+	var clients []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clients = append(clients, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	// The server certificate of httptest is valid for example.com, and is
+	// trusted through the CA bundle.
+	serverCA := filepath.Join(dir, "server.pem")
+	require.Nil(t, ioutil.WriteFile(serverCA, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600))
+	params := func(client, serverName string) map[string]interface{} {
+		params := map[string]interface{}{"endpoint": server.URL, "cafile": serverCA, "servername": serverName}
+		if client != "" {
+			params["certfile"] = filepath.Join(dir, client+".pem")
+			params["keyfile"] = filepath.Join(dir, client+".key")
+		}
+		return params
+	}
+
+	require.Nil(t, configure(t, params("client", "example.com")).Send("a"))
+	assert.Equal(t, []string{"clair"}, clients)
+
+	// The receiver rejects the clients without a certificate signed by its
+	// CA, and the server name must match the server certificate.
+	assert.Error(t, configure(t, params("", "example.com")).Send("b"))
+	assert.Error(t, configure(t, params("rogue", "example.com")).Send("c"))
+	assert.Error(t, configure(t, params("client", "webhook.internal")).Send("d"))
+	assert.Equal(t, []string{"clair"}, clients)
+
+	// A CA bundle without any certificate fails at startup.
+	_, err = (&sender{}).Configure(&notification.Config{Params: map[string]interface{}{
+		"http": map[string]interface{}{"endpoint": server.URL, "cafile": filepath.Join(dir, "ca.key")},
+	}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+	"github.com/quay/clair/v3/ext/versionfmt/rpm"
+)
+
+var (
+	// FeatureDetector is the detector of the features listed by SBOMs.
+	FeatureDetector = database.NewFeatureDetector("sbom", "1.0")
+
+	// NamespaceDetector is the detector of the namespaces inferred from the
+	// package URLs of SBOMs.
+	NamespaceDetector = database.NewNamespaceDetector("sbom", "1.0")
+
+	// Detectors are the detectors of the ancestries indexed from SBOMs.
+	Detectors = []database.Detector{FeatureDetector, NamespaceDetector}
+
+	// ErrNoPackageURL is the reason a component without a purl isn't
+	// matched.
+	ErrNoPackageURL = errors.New("no package URL")
+
+	// ErrRedHatPackage is the reason the packages of Red Hat Enterprise Linux
+	// aren't matched: their vulnerabilities are published for the CPEs of
+	// their repositories, which SBOMs don't list.
+	ErrRedHatPackage = errors.New("Red Hat packages are matched by the CPEs of their repositories, which SBOMs don't list")
+
+	// ErrUnknownRelease is the reason a distribution package isn't matched
+	// when the release of its distribution is unknown.
+	ErrUnknownRelease = errors.New("unknown distribution release, expected a distro qualifier such as debian-10")
+)
+
+// distribution describes how the packages of a distribution are matched.
+type distribution struct {
+	// typ is the type of the purls of the packages.
+	typ string
+	// os is the name of the namespaces of the distribution.
+	os string
+	// release returns the release of the namespace of the packages from the
+	// release of their distro qualifier, or false if it is unknown.
+	release func(string) (string, bool)
+	// versionFormat is the version format of the packages.
+	versionFormat string
+}
+
+// distributions are the distributions whose packages are matched, by the
+// identifier of their distro qualifier, mirroring the namespaces of the
+// vulnerabilities published by the updaters.
+var distributions = map[string]distribution{
+	"alpine":        {"apk", "alpine", alpineRelease, dpkg.ParserName},
+	"amzn":          {"rpm", "amzn", anyRelease, rpm.ParserName},
+	"centos":        {"rpm", "centos", majorRelease, rpm.ParserName},
+	"debian":        {"deb", "debian", debianRelease, dpkg.ParserName},
+	"fedora":        {"rpm", "fedora", anyRelease, rpm.ParserName},
+	"ol":            {"rpm", "oracle", majorRelease, rpm.ParserName},
+	"opensuse":      {"rpm", "opensuse", anyRelease, rpm.ParserName},
+	"opensuse-leap": {"rpm", "opensuse", anyRelease, rpm.ParserName},
+	"oracle":        {"rpm", "oracle", majorRelease, rpm.ParserName},
+	"sles":          {"rpm", "sles", anyRelease, rpm.ParserName},
+	"ubuntu":        {"deb", "ubuntu", ubuntuRelease, dpkg.ParserName},
+}
+
+// distributionAliases are the distributions of the vendors of the purls
+// whose distro qualifier is a bare code name, such as "buster".
+var distributionAliases = map[string]string{
+	"alpine":   "alpine",
+	"amazon":   "amzn",
+	"centos":   "centos",
+	"debian":   "debian",
+	"fedora":   "fedora",
+	"opensuse": "opensuse",
+	"oracle":   "ol",
+	"redhat":   "rhel",
+	"suse":     "sles",
+	"ubuntu":   "ubuntu",
+}
+
+func anyRelease(release string) (string, bool) {
+	return release, release != ""
+}
+
+func majorRelease(release string) (string, bool) {
+	major := strings.SplitN(release, ".", 2)[0]
+	return major, major != ""
+}
+
+func alpineRelease(release string) (string, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(release, "v"), ".", 3)
+	if len(parts) < 2 {
+		return "", false
+	}
+
+	return "v" + parts[0] + "." + parts[1], true
+}
+
+func debianRelease(release string) (string, bool) {
+	if version, ok := database.DebianReleasesMapping[release]; ok {
+		return version, true
+	}
+
+	return majorRelease(release)
+}
+
+func ubuntuRelease(release string) (string, bool) {
+	if version, ok := database.UbuntuReleasesMapping[release]; ok {
+		return version, true
+	}
+
+	return release, strings.Contains(release, ".")
+}
+
+// Unmatched is a component which couldn't be mapped to the feature of a
+// known distribution.
+type Unmatched struct {
+	Component
+	Reason error
+}
+
+// Features maps the components to the features of the packages of the known
+// distributions, whose namespace is inferred from their purl, and returns the
+// components which couldn't be mapped along with the reason.
+func Features(components []Component) (features []database.LayerFeature, unmatched []Unmatched) {
+	seen := make(map[database.LayerFeature]struct{})
+	for _, component := range components {
+		componentFeatures, err := componentFeatures(component)
+		if err != nil {
+			unmatched = append(unmatched, Unmatched{Component: component, Reason: err})
+			continue
+		}
+
+		for _, feature := range componentFeatures {
+			if _, ok := seen[feature]; ok {
+				continue
+			}
+			seen[feature] = struct{}{}
+			features = append(features, feature)
+		}
+	}
+
+	return features, unmatched
+}
+
+// componentFeatures returns the features of a component, with its namespace
+// as potential namespace.
+//
+// As the dpkg feature lister does, a binary deb package is also mapped to its
+// source package, which the Debian vulnerabilities affect. The source package
+// is named by the upstream qualifier of the purl, "name" or "name@version",
+// falling back to the name and version of the binary package.
+func componentFeatures(component Component) ([]database.LayerFeature, error) {
+	feature, purl, err := componentFeature(component)
+	if err != nil {
+		return nil, err
+	}
+
+	features := []database.LayerFeature{feature}
+	if purl.Type != "deb" || feature.Type == database.SourcePackage {
+		return features, nil
+	}
+
+	source := feature
+	source.Type = database.SourcePackage
+	if upstream := purl.Qualifiers["upstream"]; upstream != "" {
+		parts := strings.SplitN(upstream, "@", 2)
+		source.Name = parts[0]
+		if len(parts) == 2 && versionfmt.Valid(source.VersionFormat, parts[1]) == nil {
+			source.Version = parts[1]
+		}
+	}
+
+	return append(features, source), nil
+}
+
+// componentFeature returns the feature of the package of a component and its
+// purl.
+func componentFeature(component Component) (database.LayerFeature, PackageURL, error) {
+	if component.PURL == "" {
+		return database.LayerFeature{}, PackageURL{}, ErrNoPackageURL
+	}
+
+	purl, err := ParsePackageURL(component.PURL)
+	if err != nil {
+		return database.LayerFeature{}, PackageURL{}, err
+	}
+
+	if purl.Type != "deb" && purl.Type != "rpm" && purl.Type != "apk" {
+		return database.LayerFeature{}, PackageURL{}, fmt.Errorf("no vulnerability source covers %s packages", purl.Type)
+	}
+
+	// The distro qualifier is either an identifier followed by a release,
+	// such as "debian-10", or a bare release of the vendor, such as "buster".
+	distro := strings.ToLower(purl.Qualifiers["distro"])
+	id, release := distributionAliases[strings.ToLower(purl.Namespace)], distro
+	if i := strings.LastIndex(distro, "-"); i >= 0 {
+		if _, ok := distributions[distro[:i]]; ok {
+			id, release = distro[:i], distro[i+1:]
+		}
+	}
+
+	if id == "rhel" || strings.HasPrefix(distro, "rhel-") {
+		return database.LayerFeature{}, PackageURL{}, ErrRedHatPackage
+	}
+
+	dist, ok := distributions[id]
+	if !ok {
+		return database.LayerFeature{}, PackageURL{}, fmt.Errorf("unknown distribution %q", purl.Namespace)
+	}
+
+	if dist.typ != purl.Type {
+		return database.LayerFeature{}, PackageURL{}, fmt.Errorf("%s packages are not packages of %s", purl.Type, id)
+	}
+
+	namespaceRelease, ok := dist.release(release)
+	if !ok {
+		return database.LayerFeature{}, PackageURL{}, ErrUnknownRelease
+	}
+
+	version := purl.Version
+	if epoch := purl.Qualifiers["epoch"]; epoch != "" && purl.Type == "rpm" {
+		version = epoch + ":" + version
+	}
+
+	if err := versionfmt.Valid(dist.versionFormat, version); err != nil {
+		return database.LayerFeature{}, PackageURL{}, fmt.Errorf("invalid version %q", version)
+	}
+
+	featureType := database.BinaryPackage
+	if arch := purl.Qualifiers["arch"]; arch == "source" || arch == "src" {
+		featureType = database.SourcePackage
+	}
+
+	return database.LayerFeature{
+		Feature: database.Feature{
+			Name:          purl.Name,
+			Version:       version,
+			VersionFormat: dist.versionFormat,
+			Type:          featureType,
+		},
+		By: FeatureDetector,
+		PotentialNamespace: database.Namespace{
+			Name:          dist.os + ":" + namespaceRelease,
+			VersionFormat: dist.versionFormat,
+		},
+	}, purl, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+)
+
+func TestComponentFeature(t *testing.T) {
+	for _, test := range []struct {
+		purl        string
+		name        string
+		version     string
+		format      string
+		featureType database.FeatureType
+		namespace   string
+		// source is the name@version of the source package of a binary deb
+		// package.
+		source string
+	}{
+		// Debian, by release or code name.
+		{"pkg:deb/debian/curl@7.64.0-4%2Bdeb10u1?arch=amd64&distro=debian-10", "curl", "7.64.0-4+deb10u1", "dpkg", database.BinaryPackage, "debian:10", "curl@7.64.0-4+deb10u1"},
+		{"pkg:deb/debian/curl@7.64.0-4?distro=debian-10.5", "curl", "7.64.0-4", "dpkg", database.BinaryPackage, "debian:10", "curl@7.64.0-4"},
+		{"pkg:deb/debian/curl@7.64.0-4?distro=buster", "curl", "7.64.0-4", "dpkg", database.BinaryPackage, "debian:10", "curl@7.64.0-4"},
+		{"pkg:deb/debian/curl@7.64.0-4?distro=debian-stretch", "curl", "7.64.0-4", "dpkg", database.BinaryPackage, "debian:9", "curl@7.64.0-4"},
+		{"pkg:deb/debian/curl@7.64.0-4?arch=source&distro=debian-10", "curl", "7.64.0-4", "dpkg", database.SourcePackage, "debian:10", ""},
+		// The source package is named by the upstream qualifier.
+		{"pkg:deb/debian/libcurl4@7.64.0-4?upstream=curl&distro=debian-10", "libcurl4", "7.64.0-4", "dpkg", database.BinaryPackage, "debian:10", "curl@7.64.0-4"},
+		{"pkg:deb/debian/libc6@2.28-10%2Bb1?upstream=glibc%402.28-10&distro=debian-10", "libc6", "2.28-10+b1", "dpkg", database.BinaryPackage, "debian:10", "glibc@2.28-10"},
+		// Ubuntu.
+		{"pkg:deb/ubuntu/libc6@2.27-3ubuntu1?distro=ubuntu-18.04", "libc6", "2.27-3ubuntu1", "dpkg", database.BinaryPackage, "ubuntu:18.04", "libc6@2.27-3ubuntu1"},
+		{"pkg:deb/ubuntu/libc6@2.31-0ubuntu9?upstream=glibc&distro=focal", "libc6", "2.31-0ubuntu9", "dpkg", database.BinaryPackage, "ubuntu:20.04", "glibc@2.31-0ubuntu9"},
+		// Alpine, whose namespaces are named after the minor release.
+		{"pkg:apk/alpine/musl@1.1.24-r2?arch=x86_64&distro=alpine-3.11.6", "musl", "1.1.24-r2", "dpkg", database.BinaryPackage, "alpine:v3.11", ""},
+		{"pkg:apk/alpine/musl@1.1.22-r3?distro=v3.10", "musl", "1.1.22-r3", "dpkg", database.BinaryPackage, "alpine:v3.10", ""},
+		// RPM distributions, with their epoch.
+		{"pkg:rpm/centos/bash@4.2.46-33.el7?distro=centos-7.8.2003", "bash", "4.2.46-33.el7", "rpm", database.BinaryPackage, "centos:7", ""},
+		{"pkg:rpm/oracle/openssl@1.0.2k-19.0.1.el7?epoch=1&distro=ol-7.9", "openssl", "1:1.0.2k-19.0.1.el7", "rpm", database.BinaryPackage, "oracle:7", ""},
+		{"pkg:rpm/oracle/glibc@2.28-151.0.1.el8?arch=src&distro=oracle-8", "glibc", "2.28-151.0.1.el8", "rpm", database.SourcePackage, "oracle:8", ""},
+		{"pkg:rpm/amazon/curl@7.61.1-12.amzn2.0.1?distro=amzn-2", "curl", "7.61.1-12.amzn2.0.1", "rpm", database.BinaryPackage, "amzn:2", ""},
+		{"pkg:rpm/amazon/curl@7.61.1-12.85.amzn1?distro=amzn-2018.03", "curl", "7.61.1-12.85.amzn1", "rpm", database.BinaryPackage, "amzn:2018.03", ""},
+		{"pkg:rpm/fedora/curl@7.69.1-1.fc32?distro=fedora-32", "curl", "7.69.1-1.fc32", "rpm", database.BinaryPackage, "fedora:32", ""},
+		{"pkg:rpm/suse/curl@7.60.0-3.23.1?distro=sles-15.1", "curl", "7.60.0-3.23.1", "rpm", database.BinaryPackage, "sles:15.1", ""},
+		{"pkg:rpm/opensuse/curl@7.60.0-lp151.5.9.1?distro=opensuse-leap-15.1", "curl", "7.60.0-lp151.5.9.1", "rpm", database.BinaryPackage, "opensuse:15.1", ""},
+	} {
+		features, err := componentFeatures(Component{PURL: test.purl})
+		if !assert.Nil(t, err, test.purl) {
+			continue
+		}
+
+		expected := []database.LayerFeature{{
+			Feature: database.Feature{
+				Name:          test.name,
+				Version:       test.version,
+				VersionFormat: test.format,
+				Type:          test.featureType,
+			},
+			By:                 FeatureDetector,
+			PotentialNamespace: database.Namespace{Name: test.namespace, VersionFormat: test.format},
+		}}
+		if test.source != "" {
+			source := expected[0]
+			source.Type = database.SourcePackage
+			parts := strings.SplitN(test.source, "@", 2)
+			source.Name, source.Version = parts[0], parts[1]
+			expected = append(expected, source)
+		}
+
+		assert.Equal(t, expected, features, test.purl)
+	}
+}
+
+func TestComponentFeatureUnmatched(t *testing.T) {
+	for purl, reason := range map[string]string{
+		"":                            ErrNoPackageURL.Error(),
+		"curl":                        ErrInvalidPackageURL.Error(),
+		"pkg:npm/lodash@4.17.15":      "no vulnerability source covers npm packages",
+		"pkg:pypi/requests@2.22.0":    "no vulnerability source covers pypi packages",
+		"pkg:maven/org.apache/x@1.0":  "no vulnerability source covers maven packages",
+		"pkg:golang/example.com/m@v1": "no vulnerability source covers golang packages",
+		// The release of the distribution is required.
+		"pkg:deb/debian/curl@7.64.0-4":                  ErrUnknownRelease.Error(),
+		"pkg:deb/ubuntu/libc6@2.27?distro=ubuntu-18":    ErrUnknownRelease.Error(),
+		"pkg:apk/alpine/musl@1.1.24-r2?distro=alpine-3": ErrUnknownRelease.Error(),
+		// The type of the purl must be the one of the distribution.
+		"pkg:rpm/debian/curl@7.64.0-4?distro=debian-10":          "rpm packages are not packages of debian",
+		"pkg:deb/gentoo/curl@7.64.0?distro=gentoo-2":             `unknown distribution "gentoo"`,
+		"pkg:rpm/redhat/bash@4.4.19-10.el8?distro=rhel-8.2":      ErrRedHatPackage.Error(),
+		"pkg:deb/debian/curl@not%20a%20version?distro=debian-10": `invalid version "not a version"`,
+	} {
+		_, err := componentFeatures(Component{PURL: purl})
+		if assert.NotNil(t, err, purl) {
+			assert.Equal(t, reason, err.Error(), purl)
+		}
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	components := []Component{
+		{Name: "curl", PURL: "pkg:deb/debian/curl@7.64.0-4?distro=debian-10"},
+		// Duplicate components are mapped to a single feature.
+		{Name: "curl", PURL: "pkg:deb/debian/curl@7.64.0-4?arch=amd64&distro=debian-10"},
+		{Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"},
+		{Name: "/etc/passwd"},
+	}
+
+	features, unmatched := Features(components)
+	if assert.Len(t, features, 2) {
+		assert.Equal(t, "curl", features[0].Name)
+		assert.Equal(t, database.BinaryPackage, features[0].Type)
+		assert.Equal(t, "curl", features[1].Name)
+		assert.Equal(t, database.SourcePackage, features[1].Type)
+	}
+
+	// Unmatched components are reported rather than dropped.
+	if assert.Len(t, unmatched, 2) {
+		assert.Equal(t, components[2], unmatched[0].Component)
+		assert.Equal(t, components[3], unmatched[1].Component)
+		assert.Equal(t, ErrNoPackageURL, unmatched[1].Reason)
+	}
+}
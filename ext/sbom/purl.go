@@ -0,0 +1,88 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidPackageURL is returned when a purl cannot be parsed.
+var ErrInvalidPackageURL = errors.New("sbom: invalid package URL")
+
+// PackageURL is a parsed purl, as specified by
+// https://github.com/package-url/purl-spec.
+type PackageURL struct {
+	Type       string
+	Namespace  string
+	Name       string
+	Version    string
+	Qualifiers map[string]string
+}
+
+// ParsePackageURL parses a purl such as
+// "pkg:deb/debian/curl@7.64.0-4?distro=debian-10". The subpath is ignored.
+func ParsePackageURL(purl string) (PackageURL, error) {
+	var p PackageURL
+	if !strings.HasPrefix(strings.ToLower(purl), "pkg:") {
+		return p, ErrInvalidPackageURL
+	}
+	remainder := strings.TrimLeft(purl[len("pkg:"):], "/")
+
+	if i := strings.Index(remainder, "#"); i >= 0 {
+		remainder = remainder[:i]
+	}
+
+	if i := strings.Index(remainder, "?"); i >= 0 {
+		query, err := url.ParseQuery(remainder[i+1:])
+		if err != nil {
+			return p, ErrInvalidPackageURL
+		}
+
+		p.Qualifiers = make(map[string]string, len(query))
+		for key, values := range query {
+			p.Qualifiers[strings.ToLower(key)] = values[0]
+		}
+		remainder = remainder[:i]
+	}
+
+	if i := strings.LastIndex(remainder, "@"); i >= 0 {
+		version, err := url.PathUnescape(remainder[i+1:])
+		if err != nil {
+			return p, ErrInvalidPackageURL
+		}
+		p.Version = version
+		remainder = remainder[:i]
+	}
+
+	segments := strings.Split(strings.Trim(remainder, "/"), "/")
+	if len(segments) < 2 || segments[0] == "" {
+		return p, ErrInvalidPackageURL
+	}
+	p.Type = strings.ToLower(segments[0])
+
+	for i, segment := range segments[1:] {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil || unescaped == "" {
+			return p, ErrInvalidPackageURL
+		}
+		segments[i+1] = unescaped
+	}
+	p.Name = segments[len(segments)-1]
+	p.Namespace = strings.Join(segments[1:len(segments)-1], "/")
+
+	return p, nil
+}
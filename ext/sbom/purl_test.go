@@ -0,0 +1,60 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePackageURL(t *testing.T) {
+	for purl, expected := range map[string]PackageURL{
+		"pkg:deb/debian/curl@7.64.0-4%2Bdeb10u1?arch=amd64&distro=debian-10": {
+			Type: "deb", Namespace: "debian", Name: "curl", Version: "7.64.0-4+deb10u1",
+			Qualifiers: map[string]string{"arch": "amd64", "distro": "debian-10"},
+		},
+		"pkg:rpm/oracle/openssl@1.0.2k-19.0.1.el7?Epoch=1&distro=ol-7#subpath": {
+			Type: "rpm", Namespace: "oracle", Name: "openssl", Version: "1.0.2k-19.0.1.el7",
+			Qualifiers: map[string]string{"epoch": "1", "distro": "ol-7"},
+		},
+		"PKG:NPM/%40angular/core@9.0.0": {
+			Type: "npm", Namespace: "@angular", Name: "core", Version: "9.0.0",
+		},
+		"pkg:golang/github.com/quay/clair/v3@v3.0.0": {
+			Type: "golang", Namespace: "github.com/quay/clair", Name: "v3", Version: "v3.0.0",
+		},
+		"pkg://pypi/requests": {
+			Type: "pypi", Name: "requests",
+		},
+	} {
+		parsed, err := ParsePackageURL(purl)
+		if assert.Nil(t, err, purl) {
+			assert.Equal(t, expected, parsed, purl)
+		}
+	}
+
+	for _, purl := range []string{
+		"",
+		"deb/debian/curl@7.64.0",
+		"pkg:deb",
+		"pkg:/curl",
+		"pkg:deb/debian/curl%zz@1.0",
+		"pkg:deb/debian/curl@1.0?distro=%zz",
+	} {
+		_, err := ParsePackageURL(purl)
+		assert.Equal(t, ErrInvalidPackageURL, err, purl)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom converts the components listed by the software bills of
+// materials of images into the features of an ancestry, so that images can be
+// indexed without downloading their layers.
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+// ErrUnknownFormat is returned when a document is neither a CycloneDX nor an
+// SPDX JSON document.
+var ErrUnknownFormat = errors.New("sbom: unknown format, expected a CycloneDX or SPDX JSON document")
+
+// Component is a software component listed by an SBOM.
+type Component struct {
+	Name    string
+	Version string
+	// PURL is the package URL of the component, empty if not provided.
+	PURL string
+}
+
+type cycloneDXComponent struct {
+	Name       string               `json:"name"`
+	Version    string               `json:"version"`
+	PURL       string               `json:"purl"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type spdxPackage struct {
+	Name         string `json:"name"`
+	VersionInfo  string `json:"versionInfo"`
+	ExternalRefs []struct {
+		ReferenceType    string `json:"referenceType"`
+		ReferenceLocator string `json:"referenceLocator"`
+	} `json:"externalRefs"`
+}
+
+type document struct {
+	// CycloneDX
+	BOMFormat  string               `json:"bomFormat"`
+	Components []cycloneDXComponent `json:"components"`
+
+	// SPDX
+	SPDXVersion string        `json:"spdxVersion"`
+	Packages    []spdxPackage `json:"packages"`
+}
+
+// Parse returns the components listed by a CycloneDX or SPDX JSON document,
+// including the components nested in other ones.
+func Parse(content []byte) ([]Component, error) {
+	var doc document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, commonerr.NewParseError("SBOM", 0, err)
+	}
+
+	switch {
+	case doc.BOMFormat == "CycloneDX":
+		return flattenCycloneDX(nil, doc.Components), nil
+	case strings.HasPrefix(doc.SPDXVersion, "SPDX-"):
+		components := make([]Component, 0, len(doc.Packages))
+		for _, pkg := range doc.Packages {
+			component := Component{Name: pkg.Name, Version: pkg.VersionInfo}
+			for _, ref := range pkg.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					component.PURL = ref.ReferenceLocator
+					break
+				}
+			}
+			components = append(components, component)
+		}
+
+		return components, nil
+	default:
+		return nil, commonerr.NewParseError("SBOM", 0, ErrUnknownFormat)
+	}
+}
+
+// flattenCycloneDX appends the CycloneDX components and the ones they nest.
+func flattenCycloneDX(components []Component, nested []cycloneDXComponent) []Component {
+	for _, c := range nested {
+		components = append(components, Component{Name: c.Name, Version: c.Version, PURL: c.PURL})
+		components = flattenCycloneDX(components, c.Components)
+	}
+
+	return components
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func TestParseCycloneDX(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/cyclonedx.json")
+	require.Nil(t, err)
+
+	// Nested components are listed after their parent.
+	components, err := Parse(content)
+	require.Nil(t, err)
+	assert.Equal(t, []Component{
+		{Name: "curl", Version: "7.64.0-4+deb10u1", PURL: "pkg:deb/debian/curl@7.64.0-4%2Bdeb10u1?arch=amd64&distro=debian-10"},
+		{Name: "libcurl4", Version: "7.64.0-4+deb10u1", PURL: "pkg:deb/debian/libcurl4@7.64.0-4%2Bdeb10u1?arch=amd64&distro=debian-10"},
+		{Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"},
+		{Name: "/etc/passwd"},
+	}, components)
+}
+
+func TestParseSPDX(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/spdx.json")
+	require.Nil(t, err)
+
+	components, err := Parse(content)
+	require.Nil(t, err)
+	assert.Equal(t, []Component{
+		{Name: "musl", Version: "1.1.24-r2", PURL: "pkg:apk/alpine/musl@1.1.24-r2?arch=x86_64&distro=alpine-3.11.6"},
+		{Name: "busybox", Version: "1.31.1-r9"},
+	}, components)
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, content := range []string{
+		`{"bomFormat": "CycloneDX", "components": {}}`,
+		`{"name": "not an SBOM"}`,
+		`<bom xmlns="http://cyclonedx.org/schema/bom/1.5"/>`,
+	} {
+		_, err := Parse([]byte(content))
+		var perr *commonerr.ParseError
+		assert.True(t, errors.As(err, &perr), content)
+	}
+
+	_, err := Parse([]byte(`{"spdxVersion": "2.3"}`))
+	var perr *commonerr.ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, ErrUnknownFormat, perr.Err)
+	}
+}
@@ -57,7 +57,7 @@ type MitreMetadata struct {
 }
 
 func init() {
-	vulnmdsrc.RegisterAppender("mitre", &appender{})
+	vulnmdsrc.RegisterAppender(appenderName, &appender{})
 	lastUpdate = regexp.MustCompile(`(?ms:^.*?CVE downloads data last generated:(?:\s*)(?:$.)?(\d{4}-\d{2}-\d{2}).*)`)
 }
 
@@ -193,7 +193,7 @@ func getDataFeeds(dataFeedTimestamps map[string]string, localPath string, tsUrl
 func downloadFeed(dataFeedName, fileName string, dfUrl string) error {
 	// Download data feed.
 	url := fmt.Sprintf(dfUrl, dataFeedName)
-	r, err := httputil.GetWithUserAgent(url)
+	r, err := httputil.GetForUpdater(appenderName, url)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{logDataFeedName: dataFeedName, "URL": url}).Error("could not download Mitre data feed")
 		return commonerr.ErrCouldNotDownload
@@ -223,7 +223,7 @@ func downloadFeed(dataFeedName, fileName string, dfUrl string) error {
 }
 
 func getTimestampFromIndexPage(indexURL string) (string, error) {
-	r, err := httputil.GetWithUserAgent(indexURL)
+	r, err := httputil.GetForUpdater(appenderName, indexURL)
 	if err != nil {
 		return "", err
 	}
@@ -195,7 +195,7 @@ func getDataFeeds(dataFeedHashes map[string]string, localPath string) (map[strin
 
 func downloadFeed(dataFeedName, fileName string) error {
 	// Download data feed.
-	r, err := httputil.GetWithUserAgent(fmt.Sprintf(dataFeedURL, dataFeedName))
+	r, err := httputil.GetForUpdater(appenderName, fmt.Sprintf(dataFeedURL, dataFeedName))
 	if err != nil {
 		log.WithError(err).WithField(logDataFeedName, dataFeedName).Error("could not download NVD data feed")
 		return commonerr.ErrCouldNotDownload
@@ -232,7 +232,7 @@ func downloadFeed(dataFeedName, fileName string) error {
 }
 
 func getHashFromMetaURL(metaURL string) (string, error) {
-	r, err := httputil.GetWithUserAgent(metaURL)
+	r, err := httputil.GetForUpdater(appenderName, metaURL)
 	if err != nil {
 		return "", err
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/quay/clair/v3/ext/versionfmt"
 	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
 	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
 	"github.com/quay/clair/v3/pkg/fsutil"
 	log "github.com/sirupsen/logrus"
 	//"github.com/quay/clair/v3/pkg/gitutil"
@@ -34,7 +35,7 @@ import (
 	"encoding/hex"
 	"github.com/PuerkitoBio/goquery"
 	"io/ioutil"
-	"net/http"
+	"github.com/quay/clair/v3/pkg/httputil"
 	"strings"
 )
 
@@ -43,6 +44,7 @@ const (
 	// `origin` field of itself.
 	// secdbGitURL  = "https://github.com/alpinelinux/alpine-secdb" // No longer valid
 	baseURL = "https://secdb.alpinelinux.org/" // Web source for alpine vuln data
+	updaterName  = "alpine"
 	updaterFlag  = "alpine-secdbUpdater"
 	nvdURLPrefix = "https://cve.mitre.org/cgi-bin/cvename.cgi?name="
 	// affected type indicates if the affected feature hint is for binary or
@@ -51,7 +53,7 @@ const (
 )
 
 func init() {
-	vulnsrc.RegisterUpdater("alpine", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 type updater struct {
@@ -66,7 +68,7 @@ func (u *updater) processFile(filename string) {
 		return
 	}
 
-	response, err := http.Get(baseURL + u.currentDir + filename)
+	response, err := httputil.GetForUpdater(updaterName, baseURL+u.currentDir+filename)
 	if err != nil {
 		//log.WithError(err).WithField("package", "Alpine").Error("Failed to get vuln file")
 		return
@@ -104,7 +106,7 @@ func (u *updater) processFiles(index int, element *goquery.Selection) {
 }
 
 func (u *updater) processVersionDir(versionDir string) {
-	response, err := http.Get(baseURL + versionDir)
+	response, err := httputil.GetForUpdater(updaterName, baseURL+versionDir)
 	if err != nil {
 		log.WithError(err).WithField("package", "Alpine").Error("Failed to get version")
 	}
@@ -156,12 +158,18 @@ func (u *updater) getVulnFiles(repoPath, tempDirPrefix string) (commit string, e
 	u.currentDir = ""
 
 	// Get root directory of web server
-	response, err := http.Get(baseURL)
+	response, err := httputil.GetForUpdater(updaterName, baseURL)
 	if err != nil {
+		err = commonerr.NewDownloadError(baseURL, err)
 		return
 	}
 	defer response.Body.Close()
 
+	if !httputil.Status2xx(response) {
+		err = commonerr.NewStatusError(baseURL, response.StatusCode)
+		return
+	}
+
 	document, err := goquery.NewDocumentFromReader(response.Body)
 	if err != nil {
 		log.WithError(err).WithField("package", "Alpine").Fatal("Error loading HTTP response body. ")
@@ -231,6 +239,11 @@ func (u *updater) Clean() {
 	}
 }
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{dpkg.ParserName}
+}
+
 func parseVulnsFromNamespace(repositoryPath, namespace string) (vulns []database.VulnerabilityWithAffected, err error) {
 	nsDir := filepath.Join(repositoryPath, namespace)
 	var dbFilenames []string
@@ -269,7 +282,9 @@ func newSecDB(filePath string) (file *secDB, err error) {
 
 	defer f.Close()
 	file = &secDB{}
-	err = yaml.NewDecoder(f).Decode(file)
+	if err = yaml.NewDecoder(f).Decode(file); err != nil {
+		err = commonerr.NewParseError(filePath, 0, err)
+	}
 	return
 }
 
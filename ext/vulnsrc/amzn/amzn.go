@@ -36,12 +36,14 @@ import (
 )
 
 const (
+	amazonLinux1UpdaterName   = "amzn1"
 	amazonLinux1UpdaterFlag   = "amazonLinux1Updater"
 	amazonLinux1MirrorListURI = "http://repo.us-west-2.amazonaws.com/2018.03/updates/x86_64/mirror.list"
 	amazonLinux1Name          = "Amazon Linux 2018.03"
 	amazonLinux1Namespace     = "amzn:2018.03"
 	amazonLinux1LinkFormat    = "https://alas.aws.amazon.com/%s.html"
 
+	amazonLinux2UpdaterName   = "amzn2"
 	amazonLinux2UpdaterFlag   = "amazonLinux2Updater"
 	amazonLinux2MirrorListURI = "https://cdn.amazonlinux.com/2/core/latest/x86_64/mirror.list"
 	amazonLinux2Name          = "Amazon Linux 2"
@@ -50,6 +52,7 @@ const (
 )
 
 type updater struct {
+	UpdaterName   string
 	UpdaterFlag   string
 	MirrorListURI string
 	Name          string
@@ -60,23 +63,25 @@ type updater struct {
 func init() {
 	// Register updater for Amazon Linux 2018.03.
 	amazonLinux1Updater := updater{
+		UpdaterName:   amazonLinux1UpdaterName,
 		UpdaterFlag:   amazonLinux1UpdaterFlag,
 		MirrorListURI: amazonLinux1MirrorListURI,
 		Name:          amazonLinux1Name,
 		Namespace:     amazonLinux1Namespace,
 		LinkFormat:    amazonLinux1LinkFormat,
 	}
-	vulnsrc.RegisterUpdater("amzn1", &amazonLinux1Updater)
+	vulnsrc.RegisterUpdater(amazonLinux1UpdaterName, &amazonLinux1Updater)
 
 	// Register updater for Amazon Linux 2.
 	amazonLinux2Updater := updater{
+		UpdaterName:   amazonLinux2UpdaterName,
 		UpdaterFlag:   amazonLinux2UpdaterFlag,
 		MirrorListURI: amazonLinux2MirrorListURI,
 		Name:          amazonLinux2Name,
 		Namespace:     amazonLinux2Namespace,
 		LinkFormat:    amazonLinux2LinkFormat,
 	}
-	vulnsrc.RegisterUpdater("amzn2", &amazonLinux2Updater)
+	vulnsrc.RegisterUpdater(amazonLinux2UpdaterName, &amazonLinux2Updater)
 }
 
 func (u *updater) Update(datastore database.Datastore) (vulnsrc.UpdateResponse, error) {
@@ -134,6 +139,11 @@ func (u *updater) Clean() {
 
 }
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{rpm.ParserName}
+}
+
 func (u *updater) getUpdateInfo() (UpdateInfo, error) {
 	// Get the URI of updateinfo.xml.gz.
 	updateInfoURI, err := u.getUpdateInfoURI()
@@ -142,7 +152,7 @@ func (u *updater) getUpdateInfo() (UpdateInfo, error) {
 	}
 
 	// Download updateinfo.xml.gz.
-	updateInfoResponse, err := httputil.GetWithUserAgent(updateInfoURI)
+	updateInfoResponse, err := httputil.GetForUpdater(u.UpdaterName, updateInfoURI)
 	if err != nil {
 		log.WithError(err).Error("could not download updateinfo.xml.gz")
 		return UpdateInfo{}, commonerr.ErrCouldNotDownload
@@ -174,7 +184,7 @@ func (u *updater) getUpdateInfo() (UpdateInfo, error) {
 
 func (u *updater) getUpdateInfoURI() (string, error) {
 	// Download mirror.list
-	mirrorListResponse, err := httputil.GetWithUserAgent(u.MirrorListURI)
+	mirrorListResponse, err := httputil.GetForUpdater(u.UpdaterName, u.MirrorListURI)
 	if err != nil {
 		log.WithError(err).Error("could not download mirror list")
 		return "", commonerr.ErrCouldNotDownload
@@ -196,7 +206,7 @@ func (u *updater) getUpdateInfoURI() (string, error) {
 
 	// Download repomd.xml.
 	repoMdURI := mirrorURI + "/repodata/repomd.xml"
-	repoMdResponse, err := httputil.GetWithUserAgent(repoMdURI)
+	repoMdResponse, err := httputil.GetForUpdater(u.UpdaterName, repoMdURI)
 	if err != nil {
 		log.WithError(err).Error("could not download repomd.xml")
 		return "", commonerr.ErrCouldNotDownload
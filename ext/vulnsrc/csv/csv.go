@@ -0,0 +1,382 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csv implements a vulnerability source updater fetching a feed of
+// advisories published as CSV, whose columns are mapped by the configuration.
+package csv
+
+import (
+	"crypto/sha256"
+	encsv "encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/httputil"
+)
+
+const (
+	updaterName = "csv"
+	updaterFlag = "csvUpdater"
+)
+
+// DefaultColumns are the headers of the columns read when none are
+// configured.
+var DefaultColumns = Columns{
+	Namespace:    "namespace",
+	Package:      "package",
+	FixedVersion: "fixed_version",
+	Severity:     "severity",
+	Name:         "cve",
+}
+
+// Config is the configuration of the updater, under the "csv" key of the
+// updater configuration. The updater is disabled when it has no URL.
+type Config struct {
+	// URL is the address of the feed: an HTTP(S) URL or the path of a local
+	// file.
+	URL string
+
+	// VersionFormat is the versionfmt parser of the versions of every
+	// namespace of the feed.
+	VersionFormat string
+
+	// FeatureType is the type of the packages of the feed, "binary" when it
+	// is empty.
+	FeatureType database.FeatureType
+
+	// Columns are the headers of the columns holding the fields of the
+	// vulnerabilities, DefaultColumns when none are configured.
+	Columns *Columns
+}
+
+// Columns are the headers of the columns of the feed holding the fields of the
+// vulnerabilities. Namespace, Package and Name are required, the other columns
+// are ignored when they are empty.
+type Columns struct {
+	Namespace    string
+	Package      string
+	FixedVersion string
+	Severity     string
+	Name         string
+	Description  string
+	Link         string
+}
+
+type updater struct {
+	config Config
+}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+// Configure implements vulnsrc.Configurable.
+func (u *updater) Configure(params map[string]interface{}) (bool, error) {
+	if _, ok := params[updaterName]; !ok {
+		return false, nil
+	}
+
+	yamlConfig, err := yaml.Marshal(params[updaterName])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	if config.URL == "" {
+		return false, nil
+	}
+
+	if config.VersionFormat == "" {
+		return false, errors.New("no version format specified")
+	}
+
+	switch config.FeatureType {
+	case "":
+		config.FeatureType = database.BinaryPackage
+	case database.BinaryPackage, database.SourcePackage:
+	default:
+		return false, fmt.Errorf("unknown feature type %q", config.FeatureType)
+	}
+
+	if config.Columns == nil {
+		columns := DefaultColumns
+		config.Columns = &columns
+	}
+
+	if config.Columns.Namespace == "" || config.Columns.Package == "" || config.Columns.Name == "" {
+		return false, errors.New("the namespace, package and name columns are required")
+	}
+
+	u.config = config
+	return true, nil
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "CSV").Info("Start fetching vulnerabilities")
+	latestHash, ok, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+
+	if !ok {
+		latestHash = ""
+	}
+
+	feed, err := u.open()
+	if err != nil {
+		return resp, err
+	}
+	defer feed.Close()
+
+	return buildResponse(feed, u.config, latestHash)
+}
+
+// open opens the feed, downloading it when its URL is an HTTP(S) URL.
+func (u *updater) open() (io.ReadCloser, error) {
+	url := u.config.URL
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		f, err := os.Open(url)
+		if err != nil {
+			log.WithError(err).Error("could not open the CSV feed")
+			return nil, vulnsrc.ErrFilesystem
+		}
+
+		return f, nil
+	}
+
+	r, err := httputil.GetForUpdater(updaterName, url)
+	if err != nil {
+		log.WithError(err).Error("could not download the CSV feed")
+		return nil, commonerr.NewDownloadError(url, err)
+	}
+
+	if !httputil.Status2xx(r) {
+		r.Body.Close()
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update the CSV feed")
+		return nil, commonerr.NewStatusError(url, r.StatusCode)
+	}
+
+	return r.Body, nil
+}
+
+func (u *updater) Clean() {}
+
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{u.config.VersionFormat}
+}
+
+// buildResponse parses the feed, unless it was already parsed with the same
+// configuration when it hashed into latestKnownHash.
+func buildResponse(feed io.Reader, config Config, latestKnownHash string) (resp vulnsrc.UpdateResponse, err error) {
+	hash := latestKnownHash
+
+	// Defer the addition of flag information to the response.
+	defer func() {
+		if err == nil {
+			resp.Flags = make(map[string]string)
+			resp.Flags[updaterFlag] = hash
+		}
+	}()
+
+	// The configuration is hashed along with the feed, so that the feed is
+	// parsed again when the columns are mapped differently.
+	sha := sha256.New()
+	fmt.Fprintf(sha, "%s\n%s\n%+v\n", config.VersionFormat, config.FeatureType, *config.Columns)
+
+	vulnerabilities, err := parseFeed(io.TeeReader(feed, sha), config)
+	if err != nil {
+		return resp, err
+	}
+
+	hash = hex.EncodeToString(sha.Sum(nil))
+	if latestKnownHash == hash {
+		log.WithField("package", "CSV").Debug("no update, skip")
+		return resp, nil
+	}
+
+	resp.Vulnerabilities = vulnerabilities
+	return resp, nil
+}
+
+// parseFeed reads the vulnerabilities of the feed, one affected package per
+// row, merging the rows of the same vulnerability in the same namespace. The
+// malformed rows are skipped.
+func parseFeed(feed io.Reader, config Config) ([]database.VulnerabilityWithAffected, error) {
+	reader := encsv.NewReader(feed)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, commonerr.NewParseError(config.URL, 1, err)
+	}
+
+	columns, err := indexColumns(header, *config.Columns)
+	if err != nil {
+		return nil, commonerr.NewParseError(config.URL, 1, err)
+	}
+
+	var (
+		vulnerabilities []database.VulnerabilityWithAffected
+		indexes         = make(map[database.VulnerabilityID]int)
+	)
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			if _, ok := err.(*encsv.ParseError); ok {
+				skipRow(config.URL, row, err.Error())
+				continue
+			}
+
+			return nil, commonerr.NewParseError(config.URL, 0, err)
+		}
+
+		field := func(column int) string {
+			if column < 0 {
+				return ""
+			}
+
+			return strings.TrimSpace(record[column])
+		}
+
+		namespace, pkg, name := field(columns.namespace), field(columns.pkg), field(columns.name)
+		if namespace == "" || pkg == "" || name == "" {
+			skipRow(config.URL, row, "missing namespace, package or name")
+			continue
+		}
+
+		fixedVersion := field(columns.fixedVersion)
+		affectedVersion := fixedVersion
+		if fixedVersion == "" {
+			affectedVersion = versionfmt.MaxVersion
+		} else if err := versionfmt.Valid(config.VersionFormat, fixedVersion); err != nil {
+			skipRow(config.URL, row, fmt.Sprintf("invalid fixed version %q: %s", fixedVersion, err))
+			continue
+		}
+
+		severity := database.UnknownSeverity
+		if s := field(columns.severity); s != "" {
+			if severity, err = database.NewSeverity(s); err != nil {
+				skipRow(config.URL, row, fmt.Sprintf("unknown severity %q", s))
+				continue
+			}
+		}
+
+		ns := database.Namespace{Name: namespace, VersionFormat: config.VersionFormat}
+		id := database.VulnerabilityID{Name: name, Namespace: namespace}
+		i, ok := indexes[id]
+		if !ok {
+			i = len(vulnerabilities)
+			indexes[id] = i
+			vulnerabilities = append(vulnerabilities, database.VulnerabilityWithAffected{
+				Vulnerability: database.Vulnerability{
+					Name:      name,
+					Namespace: ns,
+					Severity:  severity,
+				},
+			})
+		}
+
+		vulnerability := &vulnerabilities[i]
+		if severity.Compare(vulnerability.Severity) > 0 {
+			// The highest severity of the rows is the one set.
+			vulnerability.Severity = severity
+		}
+
+		if vulnerability.Description == "" {
+			vulnerability.Description = field(columns.description)
+		}
+
+		if vulnerability.Link == "" {
+			vulnerability.Link = field(columns.link)
+		}
+
+		vulnerability.Affected = append(vulnerability.Affected, database.AffectedFeature{
+			FeatureType:     config.FeatureType,
+			Namespace:       ns,
+			FeatureName:     pkg,
+			AffectedVersion: affectedVersion,
+			FixedInVersion:  fixedVersion,
+		})
+	}
+
+	return vulnerabilities, nil
+}
+
+// columnIndexes are the indexes of the columns in the records of the feed, -1
+// for the columns which aren't configured.
+type columnIndexes struct {
+	namespace, pkg, fixedVersion, severity, name, description, link int
+}
+
+// indexColumns returns the indexes of the configured columns in the header, or
+// an error if one of them is missing.
+func indexColumns(header []string, columns Columns) (columnIndexes, error) {
+	positions := make(map[string]int, len(header))
+	for i, h := range header {
+		positions[strings.TrimSpace(h)] = i
+	}
+
+	var err error
+	index := func(column string) int {
+		if column == "" {
+			return -1
+		}
+
+		i, ok := positions[column]
+		if !ok && err == nil {
+			err = fmt.Errorf("missing column %q", column)
+		}
+
+		return i
+	}
+
+	indexes := columnIndexes{
+		namespace:    index(columns.Namespace),
+		pkg:          index(columns.Package),
+		fixedVersion: index(columns.FixedVersion),
+		severity:     index(columns.Severity),
+		name:         index(columns.Name),
+		description:  index(columns.Description),
+		link:         index(columns.Link),
+	}
+
+	return indexes, err
+}
+
+// skipRow warns that a malformed row of the feed, counted from the header, is
+// skipped.
+func skipRow(url string, row int, reason string) {
+	vulnsrc.LogParseFailure(updaterName, log.Fields{"url": url, "row": row, "reason": reason}, "skipping a malformed row of the CSV feed")
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
+)
+
+func testConfig(columns Columns) Config {
+	return Config{
+		URL:           "feed.csv",
+		VersionFormat: dpkg.ParserName,
+		FeatureType:   database.BinaryPackage,
+		Columns:       &columns,
+	}
+}
+
+func TestCSVParser(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	testFile, err := os.Open(filepath.Join(filepath.Dir(filename), "testdata", "feed.csv"))
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	vulnerabilities, err := parseFeed(testFile, testConfig(DefaultColumns))
+	require.Nil(t, err)
+
+	debian9 := database.Namespace{Name: "debian:9", VersionFormat: dpkg.ParserName}
+	debian10 := database.Namespace{Name: "debian:10", VersionFormat: dpkg.ParserName}
+	// The malformed rows are skipped, and the rows of the same vulnerability
+	// in the same namespace are merged, with the highest severity.
+	assert.Equal(t, []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2019-1547", Namespace: debian10, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{
+				{FeatureType: database.BinaryPackage, Namespace: debian10, FeatureName: "openssl", AffectedVersion: "1.1.1d-0+deb10u2", FixedInVersion: "1.1.1d-0+deb10u2"},
+				{FeatureType: database.BinaryPackage, Namespace: debian10, FeatureName: "libssl1.1", AffectedVersion: "1.1.1d-0+deb10u2", FixedInVersion: "1.1.1d-0+deb10u2"},
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2019-5481", Namespace: debian10, Severity: database.LowSeverity},
+			Affected: []database.AffectedFeature{
+				{FeatureType: database.BinaryPackage, Namespace: debian10, FeatureName: "curl", AffectedVersion: versionfmt.MaxVersion},
+			},
+		},
+		{
+			Vulnerability: database.Vulnerability{Name: "CVE-2019-5481", Namespace: debian9, Severity: database.UnknownSeverity},
+			Affected: []database.AffectedFeature{
+				{FeatureType: database.BinaryPackage, Namespace: debian9, FeatureName: "curl", AffectedVersion: "7.52.1-5+deb9u10", FixedInVersion: "7.52.1-5+deb9u10"},
+			},
+		},
+	}, vulnerabilities)
+}
+
+func TestCSVParserColumns(t *testing.T) {
+	feed := `Advisory;Distribution;Component;Fixed;Summary;URL
+PA-2020-01;ubuntu:18.04;nginx;1.14.0-0ubuntu1.7;Request smuggling;https://partner.example.com/PA-2020-01
+`
+
+	config := testConfig(Columns{
+		Namespace:    "Distribution",
+		Package:      "Component",
+		FixedVersion: "Fixed",
+		Name:         "Advisory",
+		Description:  "Summary",
+		Link:         "URL",
+	})
+	config.FeatureType = database.SourcePackage
+
+	// The fields are separated by semicolons, which are read as a single
+	// field by the CSV reader: the header misses the columns.
+	_, err := parseFeed(strings.NewReader(feed), config)
+	assert.NotNil(t, err)
+
+	vulnerabilities, err := parseFeed(strings.NewReader(strings.Replace(feed, ";", ",", -1)), config)
+	require.Nil(t, err)
+	ubuntu := database.Namespace{Name: "ubuntu:18.04", VersionFormat: dpkg.ParserName}
+	assert.Equal(t, []database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{
+			Name:        "PA-2020-01",
+			Namespace:   ubuntu,
+			Description: "Request smuggling",
+			Link:        "https://partner.example.com/PA-2020-01",
+			Severity:    database.UnknownSeverity,
+		},
+		Affected: []database.AffectedFeature{
+			{FeatureType: database.SourcePackage, Namespace: ubuntu, FeatureName: "nginx", AffectedVersion: "1.14.0-0ubuntu1.7", FixedInVersion: "1.14.0-0ubuntu1.7"},
+		},
+	}}, vulnerabilities)
+}
+
+func TestCSVParserMissingColumn(t *testing.T) {
+	_, err := parseFeed(strings.NewReader("namespace,package,cve\ndebian:10,curl,CVE-2019-5481\n"), testConfig(DefaultColumns))
+	assert.EqualError(t, err, `updater/fetchers: could not parse feed.csv:1: missing column "fixed_version"`)
+}
+
+func TestBuildResponse(t *testing.T) {
+	feed := "namespace,package,fixed_version,severity,cve\ndebian:10,curl,,Low,CVE-2019-5481\n"
+	config := testConfig(DefaultColumns)
+
+	resp, err := buildResponse(strings.NewReader(feed), config, "")
+	require.Nil(t, err)
+	assert.Len(t, resp.Vulnerabilities, 1)
+	hash := resp.Flags[updaterFlag]
+	assert.NotEmpty(t, hash)
+
+	// The feed isn't parsed again until it changes.
+	resp, err = buildResponse(strings.NewReader(feed), config, hash)
+	require.Nil(t, err)
+	assert.Empty(t, resp.Vulnerabilities)
+	assert.Equal(t, hash, resp.Flags[updaterFlag])
+
+	// Or until its columns are mapped differently.
+	config.Columns.Severity = ""
+	resp, err = buildResponse(strings.NewReader(feed), config, hash)
+	require.Nil(t, err)
+	assert.Len(t, resp.Vulnerabilities, 1)
+	assert.NotEqual(t, hash, resp.Flags[updaterFlag])
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"without url", map[string]interface{}{"csv": map[interface{}]interface{}{"versionformat": "dpkg"}}, false, false},
+		{"without version format", map[string]interface{}{"csv": map[interface{}]interface{}{"url": "feed.csv"}}, false, true},
+		{"unknown feature type", map[string]interface{}{"csv": map[interface{}]interface{}{"url": "feed.csv", "versionformat": "dpkg", "featuretype": "image"}}, false, true},
+		{"without name column", map[string]interface{}{"csv": map[interface{}]interface{}{"url": "feed.csv", "versionformat": "dpkg", "columns": map[interface{}]interface{}{"namespace": "ns", "package": "pkg"}}}, false, true},
+		{"default columns", map[string]interface{}{"csv": map[interface{}]interface{}{"url": "feed.csv", "versionformat": "dpkg"}}, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var u updater
+			configured, err := u.Configure(test.params)
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+			if configured {
+				assert.Equal(t, DefaultColumns, *u.config.Columns)
+				assert.Equal(t, database.BinaryPackage, u.config.FeatureType)
+				assert.Equal(t, []string{dpkg.ParserName}, u.VersionFormats())
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cvrf implements a vulnerability source updater fetching a document of
+// advisories published as CVRF, such as the Red Hat ones, whose platforms are
+// mapped to namespaces by the configuration.
+package cvrf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/httputil"
+)
+
+const (
+	updaterName = "cvrf"
+	updaterFlag = "cvrfUpdater"
+)
+
+// The product statuses of the CVRF documents which are ingested.
+const (
+	statusFixed         = "Fixed"
+	statusKnownAffected = "Known Affected"
+)
+
+// architectures are the suffixes of the packages of the product trees, the
+// source packages having the "src" one.
+var architectures = map[string]struct{}{
+	"aarch64": {}, "i386": {}, "i686": {}, "noarch": {}, "ppc": {}, "ppc64": {},
+	"ppc64le": {}, "s390": {}, "s390x": {}, "src": {}, "x86_64": {},
+}
+
+// Config is the configuration of the updater, under the "cvrf" key of the
+// updater configuration. The updater is disabled when it has no URL.
+type Config struct {
+	// URL is the address of the document: an HTTP(S) URL or the path of a
+	// local file.
+	URL string
+
+	// VersionFormat is the versionfmt parser of the versions of every
+	// namespace of the document.
+	VersionFormat string
+
+	// FeatureType is the type of the packages of the document, "binary" when
+	// it is empty. The source packages are the ones of the "src"
+	// architecture.
+	FeatureType database.FeatureType
+
+	// Namespaces maps the prefixes of the product IDs of the platforms to the
+	// names of their namespaces, the longest prefix winning, e.g. "7Server"
+	// to "rhel:7".
+	Namespaces map[string]string
+
+	// Namespace is the name of the namespace of the platforms matching none
+	// of the Namespaces, which are skipped when it is empty.
+	Namespace string
+}
+
+type updater struct {
+	config Config
+}
+
+func init() {
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
+}
+
+// Configure implements vulnsrc.Configurable.
+func (u *updater) Configure(params map[string]interface{}) (bool, error) {
+	if _, ok := params[updaterName]; !ok {
+		return false, nil
+	}
+
+	yamlConfig, err := yaml.Marshal(params[updaterName])
+	if err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(yamlConfig, &config); err != nil {
+		return false, errors.New("invalid configuration")
+	}
+
+	if config.URL == "" {
+		return false, nil
+	}
+
+	if config.VersionFormat == "" {
+		return false, errors.New("no version format specified")
+	}
+
+	switch config.FeatureType {
+	case "":
+		config.FeatureType = database.BinaryPackage
+	case database.BinaryPackage, database.SourcePackage:
+	default:
+		return false, fmt.Errorf("unknown feature type %q", config.FeatureType)
+	}
+
+	if len(config.Namespaces) == 0 && config.Namespace == "" {
+		return false, errors.New("no namespace specified")
+	}
+
+	u.config = config
+	return true, nil
+}
+
+func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
+	log.WithField("package", "CVRF").Info("Start fetching vulnerabilities")
+	latestHash, ok, err := database.FindKeyValueAndRollback(datastore, updaterFlag)
+	if err != nil {
+		return
+	}
+
+	if !ok {
+		latestHash = ""
+	}
+
+	document, err := u.open()
+	if err != nil {
+		return resp, err
+	}
+	defer document.Close()
+
+	return buildResponse(document, u.config, latestHash)
+}
+
+// open opens the document, downloading it when its URL is an HTTP(S) URL.
+func (u *updater) open() (io.ReadCloser, error) {
+	url := u.config.URL
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		f, err := os.Open(url)
+		if err != nil {
+			log.WithError(err).Error("could not open the CVRF document")
+			return nil, vulnsrc.ErrFilesystem
+		}
+
+		return f, nil
+	}
+
+	r, err := httputil.GetForUpdater(updaterName, url)
+	if err != nil {
+		log.WithError(err).Error("could not download the CVRF document")
+		return nil, commonerr.NewDownloadError(url, err)
+	}
+
+	if !httputil.Status2xx(r) {
+		r.Body.Close()
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update the CVRF document")
+		return nil, commonerr.NewStatusError(url, r.StatusCode)
+	}
+
+	return r.Body, nil
+}
+
+func (u *updater) Clean() {}
+
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{u.config.VersionFormat}
+}
+
+// buildResponse parses the document, unless it was already parsed with the
+// same configuration when it hashed into latestKnownHash.
+func buildResponse(document io.Reader, config Config, latestKnownHash string) (resp vulnsrc.UpdateResponse, err error) {
+	hash := latestKnownHash
+
+	// Defer the addition of flag information to the response.
+	defer func() {
+		if err == nil {
+			resp.Flags = make(map[string]string)
+			resp.Flags[updaterFlag] = hash
+		}
+	}()
+
+	// The configuration is hashed along with the document, so that the
+	// document is parsed again when the platforms are mapped differently.
+	sha := sha256.New()
+	fmt.Fprintf(sha, "%s\n%s\n%v\n%s\n", config.VersionFormat, config.FeatureType, config.Namespaces, config.Namespace)
+
+	vulnerabilities, err := parseCVRF(io.TeeReader(document, sha), config)
+	if err != nil {
+		return resp, err
+	}
+
+	hash = hex.EncodeToString(sha.Sum(nil))
+	if latestKnownHash == hash {
+		log.WithField("package", "CVRF").Debug("no update, skip")
+		return resp, nil
+	}
+
+	resp.Vulnerabilities = vulnerabilities
+	return resp, nil
+}
+
+type cvrfDocument struct {
+	ID                string      `xml:"DocumentTracking>Identification>ID"`
+	Notes             []note      `xml:"DocumentNotes>Note"`
+	AggregateSeverity string      `xml:"AggregateSeverity"`
+	References        []reference `xml:"DocumentReferences>Reference"`
+	ProductTree       struct {
+		Relationships []relationship `xml:"Relationship"`
+	} `xml:"ProductTree"`
+	Vulnerabilities []vulnerability `xml:"Vulnerability"`
+}
+
+type note struct {
+	Title string `xml:"Title,attr"`
+	Type  string `xml:"Type,attr"`
+	Text  string `xml:",chardata"`
+}
+
+type reference struct {
+	URL string `xml:"URL"`
+}
+
+// relationship relates a package to the platform it is a component of, as the
+// product of the ID of its FullProductName.
+type relationship struct {
+	ProductReference          string `xml:"ProductReference,attr"`
+	RelatesToProductReference string `xml:"RelatesToProductReference,attr"`
+	FullProductName           struct {
+		ProductID string `xml:"ProductID,attr"`
+	} `xml:"FullProductName"`
+}
+
+type vulnerability struct {
+	CVE      string      `xml:"CVE"`
+	Notes    []note      `xml:"Notes>Note"`
+	Statuses []status    `xml:"ProductStatuses>Status"`
+	Threats  []threat    `xml:"Threats>Threat"`
+	Refs     []reference `xml:"References>Reference"`
+}
+
+type status struct {
+	Type       string   `xml:"Type,attr"`
+	ProductIDs []string `xml:"ProductID"`
+}
+
+type threat struct {
+	Type        string `xml:"Type,attr"`
+	Description string `xml:"Description"`
+}
+
+// parseCVRF reads the vulnerabilities of the document, one per vulnerability
+// section and namespace, affecting the packages which are fixed or known to be
+// affected on the platforms of the namespace. The products which can't be
+// made sense of are skipped.
+func parseCVRF(document io.Reader, config Config) ([]database.VulnerabilityWithAffected, error) {
+	var doc cvrfDocument
+	if err := xml.NewDecoder(document).Decode(&doc); err != nil {
+		return nil, commonerr.NewParseError(config.URL, 0, err)
+	}
+
+	products := make(map[string]relationship, len(doc.ProductTree.Relationships))
+	for _, r := range doc.ProductTree.Relationships {
+		products[r.FullProductName.ProductID] = r
+	}
+
+	var vulnerabilities []database.VulnerabilityWithAffected
+	for _, v := range doc.Vulnerabilities {
+		name := strings.TrimSpace(v.CVE)
+		if name == "" {
+			name = strings.TrimSpace(doc.ID)
+		}
+		if name == "" {
+			skipProduct(config.URL, "", "vulnerability without CVE nor document ID")
+			continue
+		}
+
+		impact := strings.TrimSpace(doc.AggregateSeverity)
+		for _, t := range v.Threats {
+			if t.Type == "Impact" {
+				impact = t.Description
+				break
+			}
+		}
+
+		var (
+			byNamespace = make(map[string]*database.VulnerabilityWithAffected)
+			namespaces  []string
+			seen        = make(map[string]struct{})
+		)
+		for _, s := range v.Statuses {
+			if s.Type != statusFixed && s.Type != statusKnownAffected {
+				continue
+			}
+
+			for _, id := range s.ProductIDs {
+				id = strings.TrimSpace(id)
+				platform, pkg, ok := resolveProduct(products, id)
+				if !ok {
+					skipProduct(config.URL, id, "could not determine the platform and package of the product")
+					continue
+				}
+
+				namespace := config.namespace(platform)
+				if namespace == "" {
+					continue
+				}
+
+				featureName, version, arch := parsePackage(pkg)
+				if arch != "" && (arch == "src") != (config.FeatureType == database.SourcePackage) {
+					continue
+				}
+
+				fixedVersion, affectedVersion := version, version
+				if s.Type == statusKnownAffected || version == "" {
+					fixedVersion, affectedVersion = "", versionfmt.MaxVersion
+				} else if err := versionfmt.Valid(config.VersionFormat, version); err != nil {
+					skipProduct(config.URL, id, fmt.Sprintf("invalid version %q: %s", version, err))
+					continue
+				}
+
+				// The architectures of a package are the same feature.
+				key := namespace + ":" + featureName
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+
+				vulnerability, ok := byNamespace[namespace]
+				if !ok {
+					vulnerability = &database.VulnerabilityWithAffected{
+						Vulnerability: database.Vulnerability{
+							Name:        name,
+							Namespace:   database.Namespace{Name: namespace, VersionFormat: config.VersionFormat},
+							Link:        link(v, doc),
+							Severity:    severity(impact),
+							Description: description(v, doc),
+						},
+					}
+					byNamespace[namespace] = vulnerability
+					namespaces = append(namespaces, namespace)
+				}
+
+				vulnerability.Affected = append(vulnerability.Affected, database.AffectedFeature{
+					FeatureType:     config.FeatureType,
+					Namespace:       vulnerability.Namespace,
+					FeatureName:     featureName,
+					AffectedVersion: affectedVersion,
+					FixedInVersion:  fixedVersion,
+				})
+			}
+		}
+
+		sort.Strings(namespaces)
+		for _, namespace := range namespaces {
+			vulnerabilities = append(vulnerabilities, *byNamespace[namespace])
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// namespace returns the name of the namespace of a platform, or an empty
+// string if it isn't mapped to any.
+func (config Config) namespace(platform string) string {
+	var prefix string
+	namespace := config.Namespace
+	for p, ns := range config.Namespaces {
+		if strings.HasPrefix(platform, p) && len(p) >= len(prefix) {
+			prefix, namespace = p, ns
+		}
+	}
+
+	return namespace
+}
+
+// resolveProduct returns the platform and the package of a product, either
+// from its relationship or from its ID of the form "platform:package".
+func resolveProduct(products map[string]relationship, id string) (platform, pkg string, ok bool) {
+	if r, ok := products[id]; ok {
+		return r.RelatesToProductReference, r.ProductReference, r.RelatesToProductReference != "" && r.ProductReference != ""
+	}
+
+	i := strings.Index(id, ":")
+	if i <= 0 || i == len(id)-1 {
+		return "", "", false
+	}
+
+	return id[:i], id[i+1:], true
+}
+
+// parsePackage splits a package of the form "name-[epoch:]version-release" with
+// an optional architecture suffix, such as "openssl-1:1.0.2k-19.el7.x86_64".
+// The version is empty when the package is only named.
+func parsePackage(pkg string) (name, version, arch string) {
+	if i := strings.LastIndex(pkg, "."); i > 0 {
+		if _, ok := architectures[pkg[i+1:]]; ok {
+			pkg, arch = pkg[:i], pkg[i+1:]
+		}
+	}
+
+	release := strings.LastIndex(pkg, "-")
+	if release <= 0 {
+		return pkg, "", arch
+	}
+
+	v := strings.LastIndex(pkg[:release], "-")
+	if v <= 0 || !startsWithDigit(pkg[v+1:]) || !startsWithDigit(pkg[release+1:]) {
+		return pkg, "", arch
+	}
+
+	return pkg[:v], pkg[v+1:], arch
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
+
+// link returns the first reference of the vulnerability, or else of the
+// document.
+func link(v vulnerability, doc cvrfDocument) string {
+	for _, references := range [][]reference{v.Refs, doc.References} {
+		for _, r := range references {
+			if url := strings.TrimSpace(r.URL); url != "" {
+				return url
+			}
+		}
+	}
+
+	return ""
+}
+
+// description returns the description note of the vulnerability, or else the
+// summary of the document.
+func description(v vulnerability, doc cvrfDocument) string {
+	for _, n := range v.Notes {
+		if n.Type == "Description" || n.Title == "Vulnerability Description" {
+			return strings.TrimSpace(n.Text)
+		}
+	}
+
+	for _, n := range doc.Notes {
+		if n.Type == "Summary" {
+			return strings.TrimSpace(n.Text)
+		}
+	}
+
+	return ""
+}
+
+// severity maps the impact of a vulnerability, either a Red Hat impact or a
+// Clair severity, to a severity. A missing impact is Unknown.
+func severity(impact string) database.Severity {
+	switch strings.ToLower(strings.TrimSpace(impact)) {
+	case "":
+		return database.UnknownSeverity
+	case "none":
+		return database.NegligibleSeverity
+	case "low":
+		return database.LowSeverity
+	case "moderate", "medium":
+		return database.MediumSeverity
+	case "important", "high":
+		return database.HighSeverity
+	case "critical":
+		return database.CriticalSeverity
+	default:
+		if severity, err := database.NewSeverity(strings.TrimSpace(impact)); err == nil {
+			return severity
+		}
+
+		log.WithField("severity", impact).Warning("could not determine vulnerability severity")
+		return database.UnknownSeverity
+	}
+}
+
+// skipProduct warns that a product of the document is skipped.
+func skipProduct(url, product, reason string) {
+	vulnsrc.LogParseFailure(updaterName, log.Fields{"url": url, "product": product, "reason": reason}, "skipping a product of the CVRF document")
+}
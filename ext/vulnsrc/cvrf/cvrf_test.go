@@ -0,0 +1,204 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cvrf
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
+	"github.com/quay/clair/v3/ext/versionfmt/rpm"
+)
+
+func testConfig() Config {
+	return Config{
+		URL:           "RHSA-2019-2304.xml",
+		VersionFormat: rpm.ParserName,
+		FeatureType:   database.BinaryPackage,
+		Namespaces:    map[string]string{"7Server": "rhel:7", "7Workstation": "rhel:7"},
+	}
+}
+
+func parseTestdata(t *testing.T, config Config) []database.VulnerabilityWithAffected {
+	_, filename, _, _ := runtime.Caller(0)
+	testFile, err := os.Open(filepath.Join(filepath.Dir(filename), "testdata", "RHSA-2019-2304.xml"))
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	vulnerabilities, err := parseCVRF(testFile, config)
+	require.Nil(t, err)
+	return vulnerabilities
+}
+
+func TestCVRFParser(t *testing.T) {
+	vulnerabilities := parseTestdata(t, testConfig())
+
+	rhel7 := database.Namespace{Name: "rhel:7", VersionFormat: rpm.ParserName}
+	openssl := database.AffectedFeature{FeatureType: database.BinaryPackage, Namespace: rhel7, FeatureName: "openssl", AffectedVersion: "1:1.0.2k-19.el7", FixedInVersion: "1:1.0.2k-19.el7"}
+	opensslLibs := database.AffectedFeature{FeatureType: database.BinaryPackage, Namespace: rhel7, FeatureName: "openssl-libs", AffectedVersion: "1:1.0.2k-19.el7", FixedInVersion: "1:1.0.2k-19.el7"}
+
+	// The packages of every architecture and mapped platform are merged, the
+	// source packages and the unmapped platforms being skipped.
+	assert.Equal(t, []database.VulnerabilityWithAffected{
+		{
+			Vulnerability: database.Vulnerability{
+				Name:        "CVE-2018-0734",
+				Namespace:   rhel7,
+				Link:        "https://access.redhat.com/security/cve/CVE-2018-0734",
+				Severity:    database.LowSeverity,
+				Description: "The OpenSSL DSA signature algorithm has been shown to be vulnerable to a timing side channel attack. An attacker could use variations in the signing algorithm to recover the private key.",
+			},
+			Affected: []database.AffectedFeature{openssl, opensslLibs},
+		},
+		{
+			Vulnerability: database.Vulnerability{
+				Name:        "CVE-2019-1559",
+				Namespace:   rhel7,
+				Link:        "https://access.redhat.com/security/cve/CVE-2019-1559",
+				Severity:    database.MediumSeverity,
+				Description: "OpenSSL has been found to be vulnerable to a padding oracle attack when an application encounters a fatal protocol error and then calls SSL_shutdown() twice.",
+			},
+			Affected: []database.AffectedFeature{
+				openssl,
+				opensslLibs,
+				// Known affected packages have no fix.
+				{FeatureType: database.BinaryPackage, Namespace: rhel7, FeatureName: "openssl098e", AffectedVersion: versionfmt.MaxVersion},
+			},
+		},
+	}, vulnerabilities)
+}
+
+func TestCVRFParserNamespaces(t *testing.T) {
+	// The longest prefix wins, and the other platforms are in the default
+	// namespace.
+	config := testConfig()
+	config.FeatureType = database.SourcePackage
+	config.Namespaces = map[string]string{"7": "rhel:7", "7Server": "rhel-server:7"}
+	config.Namespace = "rhel"
+
+	var namespaces []string
+	for _, vulnerability := range parseTestdata(t, config) {
+		namespaces = append(namespaces, vulnerability.Name+" "+vulnerability.Namespace.Name)
+		for _, affected := range vulnerability.Affected {
+			assert.Equal(t, database.SourcePackage, affected.FeatureType)
+		}
+	}
+	// The source package is only a component of the server platform.
+	assert.Equal(t, []string{"CVE-2018-0734 rhel-server:7", "CVE-2019-1559 rhel-server:7"}, namespaces)
+
+	config.FeatureType = database.BinaryPackage
+	namespaces = nil
+	for _, vulnerability := range parseTestdata(t, config) {
+		namespaces = append(namespaces, vulnerability.Name+" "+vulnerability.Namespace.Name)
+	}
+	assert.Equal(t, []string{"CVE-2018-0734 rhel-server:7", "CVE-2018-0734 rhel:7", "CVE-2019-1559 rhel-server:7", "CVE-2019-1559 rhel:7"}, namespaces)
+}
+
+func TestParsePackage(t *testing.T) {
+	for pkg, expected := range map[string][3]string{
+		"openssl-1:1.0.2k-19.el7.x86_64":       {"openssl", "1:1.0.2k-19.el7", "x86_64"},
+		"openssl-libs-1:1.0.2k-19.el7.i686":    {"openssl-libs", "1:1.0.2k-19.el7", "i686"},
+		"openssl-1:1.0.2k-19.el7.src":          {"openssl", "1:1.0.2k-19.el7", "src"},
+		"kernel-3.10.0-1062.el7":               {"kernel", "3.10.0-1062.el7", ""},
+		"openssl098e":                          {"openssl098e", "", ""},
+		"python-urllib3":                       {"python-urllib3", "", ""},
+		"java-1.8.0-openjdk":                   {"java-1.8.0-openjdk", "", ""},
+		"java-1.8.0-openjdk-1:1.8.0.222-2.el7": {"java-1.8.0-openjdk", "1:1.8.0.222-2.el7", ""},
+	} {
+		name, version, arch := parsePackage(pkg)
+		assert.Equal(t, expected, [3]string{name, version, arch}, pkg)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for impact, expected := range map[string]database.Severity{
+		"Low":        database.LowSeverity,
+		" Moderate ": database.MediumSeverity,
+		"Important":  database.HighSeverity,
+		"critical":   database.CriticalSeverity,
+		"None":       database.NegligibleSeverity,
+		"Defcon1":    database.Defcon1Severity,
+		"":           database.UnknownSeverity,
+		"Severe":     database.UnknownSeverity,
+	} {
+		assert.Equal(t, expected, severity(impact), impact)
+	}
+}
+
+func TestCVRFParserInvalid(t *testing.T) {
+	_, err := parseCVRF(strings.NewReader("<cvrfdoc>"), testConfig())
+	assert.NotNil(t, err)
+}
+
+func TestBuildResponse(t *testing.T) {
+	document := `<cvrfdoc><Vulnerability><CVE>CVE-2019-1559</CVE><ProductStatuses><Status Type="Fixed"><ProductID>7Server:openssl-1:1.0.2k-19.el7.x86_64</ProductID></Status></ProductStatuses></Vulnerability></cvrfdoc>`
+	config := testConfig()
+
+	resp, err := buildResponse(strings.NewReader(document), config, "")
+	require.Nil(t, err)
+	assert.Len(t, resp.Vulnerabilities, 1)
+	hash := resp.Flags[updaterFlag]
+	assert.NotEmpty(t, hash)
+
+	// The document isn't parsed again until it changes.
+	resp, err = buildResponse(strings.NewReader(document), config, hash)
+	require.Nil(t, err)
+	assert.Empty(t, resp.Vulnerabilities)
+	assert.Equal(t, hash, resp.Flags[updaterFlag])
+
+	// Or until its platforms are mapped differently.
+	config.Namespaces = map[string]string{"7Server": "centos:7"}
+	resp, err = buildResponse(strings.NewReader(document), config, hash)
+	require.Nil(t, err)
+	if assert.Len(t, resp.Vulnerabilities, 1) {
+		assert.Equal(t, "centos:7", resp.Vulnerabilities[0].Namespace.Name)
+	}
+	assert.NotEqual(t, hash, resp.Flags[updaterFlag])
+}
+
+func TestConfigure(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		params     map[string]interface{}
+		configured bool
+		err        bool
+	}{
+		{"unconfigured", nil, false, false},
+		{"without url", map[string]interface{}{"cvrf": map[interface{}]interface{}{"versionformat": "rpm"}}, false, false},
+		{"without version format", map[string]interface{}{"cvrf": map[interface{}]interface{}{"url": "cvrf.xml", "namespace": "rhel:7"}}, false, true},
+		{"without namespace", map[string]interface{}{"cvrf": map[interface{}]interface{}{"url": "cvrf.xml", "versionformat": "rpm"}}, false, true},
+		{"unknown feature type", map[string]interface{}{"cvrf": map[interface{}]interface{}{"url": "cvrf.xml", "versionformat": "rpm", "namespace": "rhel:7", "featuretype": "image"}}, false, true},
+		{"namespace", map[string]interface{}{"cvrf": map[interface{}]interface{}{"url": "cvrf.xml", "versionformat": "rpm", "namespace": "rhel:7"}}, true, false},
+		{"namespaces", map[string]interface{}{"cvrf": map[interface{}]interface{}{"url": "cvrf.xml", "versionformat": "rpm", "namespaces": map[interface{}]interface{}{"7Server": "rhel:7"}}}, true, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var u updater
+			configured, err := u.Configure(test.params)
+			assert.Equal(t, test.configured, configured)
+			assert.Equal(t, test.err, err != nil, "%v", err)
+			if configured {
+				assert.Equal(t, database.BinaryPackage, u.config.FeatureType)
+				assert.Equal(t, []string{rpm.ParserName}, u.VersionFormats())
+			}
+		})
+	}
+}
@@ -37,6 +37,7 @@ import (
 const (
 	url          = "https://security-tracker.debian.org/tracker/data/json"
 	cveURLPrefix = "https://security-tracker.debian.org/tracker"
+	updaterName  = "debian"
 	updaterFlag  = "debianUpdater"
 	affectedType = database.SourcePackage
 )
@@ -57,7 +58,7 @@ type jsonRel struct {
 type updater struct{}
 
 func init() {
-	vulnsrc.RegisterUpdater("debian", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -72,17 +73,17 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Download JSON.
-	r, err := httputil.GetWithUserAgent(url)
+	r, err := httputil.GetForUpdater(updaterName, url)
 	if err != nil {
 		log.WithError(err).Error("could not download Debian's update")
-		return resp, commonerr.ErrCouldNotDownload
+		return resp, commonerr.NewDownloadError(url, err)
 	}
 
 	defer r.Body.Close()
 
 	if !httputil.Status2xx(r) {
 		log.WithField("StatusCode", r.StatusCode).Error("Failed to update Debian")
-		return resp, commonerr.ErrCouldNotDownload
+		return resp, commonerr.NewStatusError(url, r.StatusCode)
 	}
 
 	// Parse the JSON.
@@ -96,6 +97,11 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 func (u *updater) Clean() {}
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{dpkg.ParserName}
+}
+
 func buildResponse(jsonReader io.Reader, latestKnownHash string) (resp vulnsrc.UpdateResponse, err error) {
 	hash := latestKnownHash
 
@@ -117,7 +123,7 @@ func buildResponse(jsonReader io.Reader, latestKnownHash string) (resp vulnsrc.U
 	err = json.NewDecoder(teedJSONReader).Decode(&data)
 	if err != nil {
 		log.WithError(err).Error("could not unmarshal Debian's JSON")
-		return resp, commonerr.ErrCouldNotParse
+		return resp, commonerr.NewParseError(url, 0, err)
 	}
 
 	// Calculate the hash and skip updating if the hash has been seen before.
@@ -0,0 +1,61 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulnsrc
+
+import (
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	parseFailuresM sync.RWMutex
+	parseFailures  *log.Logger
+)
+
+// SetParseFailureLog routes the diagnostics of the vulnerability data the
+// updaters could not make sense of to w, as JSON lines, rather than to the
+// main log. A nil writer routes them back to the main log.
+func SetParseFailureLog(w io.Writer) {
+	parseFailuresM.Lock()
+	defer parseFailuresM.Unlock()
+
+	if w == nil {
+		parseFailures = nil
+		return
+	}
+
+	parseFailures = log.New()
+	parseFailures.Out = w
+	parseFailures.Formatter = &log.JSONFormatter{}
+}
+
+// LogParseFailure reports a diagnostic about vulnerability data of the source,
+// such as an updater or a namespace, which could not be made sense of, with
+// the data as fields. It is a warning of the main log unless
+// SetParseFailureLog routed the diagnostics elsewhere, along with their
+// source.
+func LogParseFailure(source string, fields log.Fields, message string) {
+	parseFailuresM.RLock()
+	defer parseFailuresM.RUnlock()
+
+	if parseFailures == nil {
+		log.WithFields(fields).Warning(message)
+		return
+	}
+
+	parseFailures.WithFields(fields).WithField("source", source).Warning(message)
+}
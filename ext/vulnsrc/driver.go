@@ -18,9 +18,11 @@ package vulnsrc
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt"
 )
 
 var (
@@ -36,6 +38,10 @@ type UpdateResponse struct {
 	Flags           map[string]string
 	Notes           []string
 	Vulnerabilities []database.VulnerabilityWithAffected
+
+	// Withdrawn are the vulnerabilities rejected or retracted upstream, which
+	// are removed from the database unless fetched again by the same update.
+	Withdrawn []database.VulnerabilityID
 }
 
 // Updater represents anything that can fetch vulnerabilities.
@@ -48,6 +54,105 @@ type Updater interface {
 	Clean()
 }
 
+// NamespaceLister is implemented by the Updaters able to enumerate the
+// namespaces of the vulnerabilities they fetch.
+type NamespaceLister interface {
+	// Namespaces returns the names of the namespaces covered by the Updater,
+	// or nil if they cannot be enumerated statically.
+	Namespaces() []string
+}
+
+// UpdaterNamespaces returns the namespaces covered by the provided Updater,
+// or nil if it does not implement NamespaceLister.
+func UpdaterNamespaces(u Updater) []string {
+	if lister, ok := u.(NamespaceLister); ok {
+		return lister.Namespaces()
+	}
+
+	return nil
+}
+
+// VersionFormatLister is implemented by the Updaters declaring the version
+// formats of the namespaces of the vulnerabilities they fetch.
+type VersionFormatLister interface {
+	// VersionFormats returns the names of the versionfmt parsers of the
+	// namespaces covered by the Updater.
+	VersionFormats() []string
+}
+
+// UpdaterVersionFormats returns the version formats declared by the provided
+// Updater, or nil if it does not implement VersionFormatLister.
+func UpdaterVersionFormats(u Updater) []string {
+	if lister, ok := u.(VersionFormatLister); ok {
+		return lister.VersionFormats()
+	}
+
+	return nil
+}
+
+// ValidateVersionFormats returns an error if one of the named Updaters
+// declares a version format which isn't registered in versionfmt, which would
+// make the vulnerabilities it fetches impossible to match.
+func ValidateVersionFormats(names []string) error {
+	updatersM.RLock()
+	defer updatersM.RUnlock()
+
+	for _, name := range names {
+		u, ok := updaters[name]
+		if !ok {
+			continue
+		}
+
+		for _, format := range UpdaterVersionFormats(u) {
+			if _, exists := versionfmt.GetParser(format); !exists {
+				return fmt.Errorf("vulnsrc: updater %s declares the unknown version format %q", name, format)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Configurable is implemented by the Updaters requiring a configuration, which
+// stay disabled until they are configured.
+type Configurable interface {
+	// Configure initializes the Updater with the parameters of the updater
+	// configuration, by the Updater's own key, and returns whether it is
+	// enabled.
+	Configure(params map[string]interface{}) (bool, error)
+}
+
+// ConfigureUpdaters configures the named Updaters implementing Configurable
+// with the parameters of the updater configuration, and returns the names of
+// the enabled Updaters, in order, without the ones left unconfigured.
+func ConfigureUpdaters(names []string, params map[string]interface{}) ([]string, error) {
+	updatersM.RLock()
+	defer updatersM.RUnlock()
+
+	enabled := make([]string, 0, len(names))
+	for _, name := range names {
+		u, ok := updaters[name]
+		if !ok {
+			continue
+		}
+
+		if c, ok := u.(Configurable); ok {
+			configured, err := c.Configure(params)
+			if err != nil {
+				return nil, fmt.Errorf("vulnsrc: could not configure updater %s: %v", name, err)
+			}
+
+			if !configured {
+				continue
+			}
+		}
+
+		enabled = append(enabled, name)
+	}
+
+	return enabled, nil
+}
+
 // RegisterUpdater makes an Updater available by the provided name.
 //
 // If called twice with the same name, the name is blank, or if the provided
@@ -0,0 +1,87 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulnsrc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/versionfmt/rpm"
+)
+
+type versionFormatUpdater []string
+
+func (versionFormatUpdater) Update(database.Datastore) (UpdateResponse, error) {
+	return UpdateResponse{}, nil
+}
+
+func (versionFormatUpdater) Clean() {}
+
+func (u versionFormatUpdater) VersionFormats() []string { return u }
+
+func TestValidateVersionFormats(t *testing.T) {
+	RegisterUpdater("test-rpm", versionFormatUpdater{rpm.ParserName})
+	RegisterUpdater("test-bogus", versionFormatUpdater{rpm.ParserName, "bogus"})
+
+	assert.Nil(t, ValidateVersionFormats([]string{"test-rpm"}))
+	assert.Equal(t, []string{rpm.ParserName, "bogus"}, UpdaterVersionFormats(Updaters()["test-bogus"]))
+
+	err := ValidateVersionFormats([]string{"test-rpm", "test-bogus"})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), `"bogus"`)
+	}
+
+	// The updaters which aren't registered are ignored.
+	assert.Nil(t, ValidateVersionFormats([]string{"test-rpm", "unregistered"}))
+}
+
+// configurableUpdater is enabled by the parameters under its key, and fails
+// to be configured when they aren't a string.
+type configurableUpdater struct{ versionFormatUpdater }
+
+func (configurableUpdater) Configure(params map[string]interface{}) (bool, error) {
+	param, ok := params["test-configurable"]
+	if !ok {
+		return false, nil
+	}
+
+	if _, ok := param.(string); !ok {
+		return false, errors.New("invalid configuration")
+	}
+
+	return true, nil
+}
+
+func TestConfigureUpdaters(t *testing.T) {
+	RegisterUpdater("test-configurable", configurableUpdater{})
+	RegisterUpdater("test-unconfigurable", versionFormatUpdater{})
+
+	names := []string{"test-unconfigurable", "test-configurable", "unregistered"}
+
+	// The unconfigured updaters are disabled.
+	enabled, err := ConfigureUpdaters(names, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test-unconfigurable"}, enabled)
+
+	enabled, err = ConfigureUpdaters(names, map[string]interface{}{"test-configurable": "feed"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test-unconfigurable", "test-configurable"}, enabled)
+
+	_, err = ConfigureUpdaters(names, map[string]interface{}{"test-configurable": 1})
+	assert.NotNil(t, err)
+}
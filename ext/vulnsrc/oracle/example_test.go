@@ -0,0 +1,56 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oracle_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/quay/clair/v3/ext/vulnsrc/oracle"
+)
+
+func ExampleParseOVAL() {
+	f, err := os.Open("testdata/fetcher_oracle_test.1.xml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	vulnerabilities, err := oracle.ParseOVAL(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, vulnerability := range vulnerabilities {
+		fmt.Println(vulnerability.Name, vulnerability.Severity)
+
+		var fixes []string
+		for _, affected := range vulnerability.Affected {
+			fixes = append(fixes, fmt.Sprintf("%s %s fixed in %s", affected.Namespace.Name, affected.FeatureName, affected.FixedInVersion))
+		}
+		sort.Strings(fixes)
+		for _, fix := range fixes {
+			fmt.Println(fix)
+		}
+	}
+	// Output:
+	// CVE-2015-0252 Medium
+	// oracle:7 xerces-c fixed in 0:3.1.1-7.el7_1
+	// oracle:7 xerces-c-devel fixed in 0:3.1.1-7.el7_1
+	// oracle:7 xerces-c-doc fixed in 0:3.1.1-7.el7_1
+	// ELSA-2015-1193 Medium
+}
@@ -18,9 +18,18 @@ package oracle
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/xml"
+	"errors"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -33,28 +42,135 @@ import (
 	"github.com/quay/clair/v3/ext/versionfmt/rpm"
 	"github.com/quay/clair/v3/ext/vulnsrc"
 	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/envutil"
+	"github.com/quay/clair/v3/pkg/gpgutil"
 	"github.com/quay/clair/v3/pkg/httputil"
 )
 
 const (
 	firstOracle5ELSA = 20070057
+	firstRelease     = 5
+	lastRelease      = 9
 	ovalURI          = "https://linux.oracle.com/oval/"
 	elsaFilePrefix   = "com.oracle.elsa-"
+	updaterName      = "oracle"
 	updaterFlag      = "oracleUpdater"
+	listedFlag       = "oracleListedELSAs"
 	affectedType     = database.BinaryPackage
+
+	// versionFormat is the format the versions of the affected features are
+	// validated against, which must be the one of their namespaces.
+	versionFormat = rpm.ParserName
+
+	// defaultMaxPossibilities is the default cap on the number of
+	// possibilities of a criteria tree.
+	defaultMaxPossibilities = 4096
+
+	// defaultLinkSource is the source of the references linked to by default.
+	defaultLinkSource = "elsa"
+
+	// The modes of the Ksplice packages.
+	kspliceIgnore    = "ignore"
+	kspliceNamespace = "namespace"
 )
 
 var (
-	ignoredCriterions = []string{
-		" is signed with the Oracle Linux",
-		".ksplice1.",
+	// ignoreRules are the built-in rules dropping the criterions whose comment
+	// contains their text, by name.
+	ignoreRules = map[string]string{
+		"signature": " is signed with the Oracle Linux",
+		"ksplice":   ".ksplice1.",
 	}
 
+	// ignoredCriterions are the texts of the enabled ignore rules. Rules are
+	// disabled by listing their comma-separated names, e.g. "ksplice" to
+	// report the advisories of Ksplice packages.
+	ignoredCriterions = enabledIgnoreRules(envutil.GetEnv("ORACLE_DISABLED_IGNORE_RULES", ""))
+
+	// kspliceMode is "ignore" to drop the criterions of Ksplice packages
+	// unless the "ksplice" ignore rule is disabled, or "namespace" to report
+	// them under the separate oracle-ksplice:N namespaces, so that scanning
+	// the Ksplice packages is opted into by their namespace detectors. It is
+	// "ignore" by default.
+	kspliceMode = parseKspliceMode(envutil.GetEnv("ORACLE_KSPLICE", kspliceIgnore))
+
+	// maxIndexLineSize is the longest line of the update list which is
+	// scanned. The list may be served without any newline, as a single line.
+	maxIndexLineSize = 64 << 20
+
 	elsaRegexp = regexp.MustCompile(`com.oracle.elsa-(\d+).xml`)
+
+	// baselineELSA is the ELSA a fresh sync starts after. Raising it, e.g. to
+	// the first OL8 ELSA, skips fetching older advisories.
+	baselineELSA = envutil.GetEnv("ORACLE_FIRST_ELSA", strconv.Itoa(firstOracle5ELSA))
+
+	// checksumURI is the optional location of the SHA256 checksums of the
+	// ELSA files, each one being named after its file with a ".sha256"
+	// suffix. ELSA files are not verified when it is empty.
+	checksumURI = envutil.GetEnv("ORACLE_CHECKSUM_URL", "")
+
+	// gpgKeyring is the optional public keyring verifying the detached
+	// signatures of the ELSA files. ELSA files are not verified when it is
+	// empty.
+	gpgKeyring = envutil.GetEnv("ORACLE_GPG_KEYRING", "")
+
+	// cacheDir is the optional directory where downloaded ELSA files are kept,
+	// keyed by their ETag, so that unchanged files are not downloaded again
+	// after a restart. Nothing is cached when it is empty.
+	cacheDir = envutil.GetEnv("ORACLE_CACHE_DIR", "")
+
+	// namespacePrefix is prepended to the names of the namespaces of the
+	// advisories, e.g. "tenantA/" to key them as "tenantA/oracle:8".
+	//
+	// Advisories only match the features of the images whose namespace has
+	// the very same name, so the namespace detectors of the deployment must
+	// report the prefixed names too: the built-in detectors report
+	// "oracle:8", which never matches prefixed advisories. The prefix is empty
+	// by default.
+	namespacePrefix = envutil.GetEnv("ORACLE_NAMESPACE_PREFIX", "")
+
+	// mirrorURIs are the base URLs of the OVAL feed, tried in order until one
+	// of them serves the update list, e.g.
+	// "https://mirror.example.com/oval/,https://linux.oracle.com/oval/". The
+	// ELSA files of an update are all downloaded from the mirror which served
+	// its update list.
+	mirrorURIs = parseMirrors(envutil.GetEnv("ORACLE_MIRRORS", ovalURI))
+
+	// maxPossibilities caps the number of possibilities of the criteria tree
+	// of a definition. AND criteria compose the possibilities of their
+	// children as a cartesian product, which grows exponentially with the
+	// depth of the tree: the definitions exceeding the cap are skipped.
+	maxPossibilities = parseMaxPossibilities(envutil.GetEnv("ORACLE_MAX_POSSIBILITIES", strconv.Itoa(defaultMaxPossibilities)))
+
+	// unknownSeverity is the severity of the advisories whose impact is not
+	// mapped to a severity, e.g. "Medium" so that they don't silently pass
+	// the policies gating on severity. It is Unknown by default.
+	unknownSeverity = parseUnknownSeverity(envutil.GetEnv("ORACLE_UNKNOWN_SEVERITY", string(database.UnknownSeverity)))
+
+	// linkSources are the sources of the references whose URI is the link of
+	// an advisory, in order of preference, e.g. "cve,elsa" to link each CVE
+	// of an advisory to its own page rather than to the ELSA. The first
+	// source the advisory references is used. It is "elsa" by default.
+	linkSources = parseLinkSources(envutil.GetEnv("ORACLE_LINK_SOURCES", defaultLinkSource))
+
+	// errChecksumMismatch is returned when an ELSA file does not match its
+	// published checksum.
+	errChecksumMismatch = errors.New("oracle: ELSA file does not match its SHA256 checksum")
+
+	// errTooManyPossibilities is returned when a criteria tree has more than
+	// maxPossibilities possibilities.
+	errTooManyPossibilities = errors.New("oracle: criteria have too many possibilities")
+
+	// errUnverifiedSignature is returned when an ELSA file cannot be verified
+	// against its detached signature.
+	errUnverifiedSignature = errors.New("oracle: ELSA file signature could not be verified")
 )
 
 type oval struct {
-	Definitions []definition `xml:"definitions>definition"`
+	Definitions []definition    `xml:"definitions>definition"`
+	Tests       []rpminfoTest   `xml:"tests>rpminfo_test"`
+	Objects     []rpminfoObject `xml:"objects>rpminfo_object"`
+	States      []rpminfoState  `xml:"states>rpminfo_state"`
 }
 
 type definition struct {
@@ -64,6 +180,7 @@ type definition struct {
 	Criteria    criteria    `xml:"criteria"`
 	Severity    string      `xml:"metadata>advisory>severity"`
 	CVEs        []cve       `xml:"metadata>advisory>cve"`
+	Platforms   []string    `xml:"metadata>affected>platform"`
 }
 
 type reference struct {
@@ -78,20 +195,56 @@ type cve struct {
 	ID     string `xml:",chardata"`
 }
 
+// criteria and criterion are applicability checks when they are prerequisites
+// of a definition, such as the release installed, rather than assertions that
+// a package is affected.
 type criteria struct {
-	Operator   string      `xml:"operator,attr"`
-	Criterias  []*criteria `xml:"criteria"`
-	Criterions []criterion `xml:"criterion"`
+	Operator           string      `xml:"operator,attr"`
+	ApplicabilityCheck bool        `xml:"applicability_check,attr"`
+	Criterias          []*criteria `xml:"criteria"`
+	Criterions         []criterion `xml:"criterion"`
 }
 
 type criterion struct {
-	Comment string `xml:"comment,attr"`
+	TestRef            string `xml:"test_ref,attr"`
+	Comment            string `xml:"comment,attr"`
+	ApplicabilityCheck bool   `xml:"applicability_check,attr"`
+}
+
+type rpminfoTest struct {
+	ID     string `xml:"id,attr"`
+	Object struct {
+		Ref string `xml:"object_ref,attr"`
+	} `xml:"object"`
+	State struct {
+		Ref string `xml:"state_ref,attr"`
+	} `xml:"state"`
+}
+
+type rpminfoObject struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"name"`
+}
+
+type rpminfoState struct {
+	ID  string `xml:"id,attr"`
+	EVR struct {
+		Operation string `xml:"operation,attr"`
+		Value     string `xml:",chardata"`
+	} `xml:"evr"`
+}
+
+// packageTest is the package and version an rpminfo test checks the
+// installed package is earlier than.
+type packageTest struct {
+	name    string
+	version string
 }
 
 type updater struct{}
 
 func init() {
-	vulnsrc.RegisterUpdater("oracle", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 func compareELSA(left, right int) int {
@@ -124,6 +277,81 @@ func compareELSA(left, right int) int {
 	return len(lstr) - len(rstr)
 }
 
+// firstELSA returns the last ELSA processed by a previous sync, or the
+// configured baseline when there was none.
+func firstELSA(flagValue, baseline string) int {
+	if first, err := strconv.Atoi(flagValue); err == nil && first != 0 {
+		return first
+	}
+
+	first, err := strconv.Atoi(baseline)
+	if err != nil || first <= 0 {
+		log.WithField("baseline", baseline).Warning("invalid Oracle ELSA baseline, using the first Oracle Linux 5 ELSA")
+		return firstOracle5ELSA
+	}
+
+	return first
+}
+
+// parseMaxPossibilities returns the configured cap on the number of
+// possibilities of a criteria tree, or the default one when it is invalid.
+func parseMaxPossibilities(value string) int {
+	max, err := strconv.Atoi(value)
+	if err != nil || max <= 0 {
+		log.WithField("max", value).Warning("invalid Oracle criteria possibilities cap, using the default one")
+		return defaultMaxPossibilities
+	}
+
+	return max
+}
+
+// parseUnknownSeverity returns the configured severity of the unmapped
+// impacts, or Unknown when it is invalid.
+func parseUnknownSeverity(value string) database.Severity {
+	severity, err := database.NewSeverity(strings.TrimSpace(value))
+	if err != nil {
+		log.WithField("severity", value).Warning("invalid Oracle unknown severity fallback, using Unknown")
+		return database.UnknownSeverity
+	}
+
+	return severity
+}
+
+// parseKspliceMode returns the Ksplice mode of the value, or "ignore" when it
+// is invalid.
+func parseKspliceMode(value string) string {
+	switch mode := strings.ToLower(strings.TrimSpace(value)); mode {
+	case kspliceIgnore, kspliceNamespace:
+		return mode
+	default:
+		log.WithField("mode", value).Warning("invalid Oracle Ksplice mode, using ignore")
+		return kspliceIgnore
+	}
+}
+
+// parseMirrors returns the comma-separated mirror base URLs, each one ending
+// with a slash, or Oracle's when there is none.
+func parseMirrors(value string) []string {
+	var mirrors []string
+	for _, mirror := range strings.Split(value, ",") {
+		mirror = strings.TrimSpace(mirror)
+		if mirror == "" {
+			continue
+		}
+
+		if !strings.HasSuffix(mirror, "/") {
+			mirror += "/"
+		}
+		mirrors = append(mirrors, mirror)
+	}
+
+	if len(mirrors) == 0 {
+		return []string{ovalURI}
+	}
+
+	return mirrors
+}
+
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
 	log.WithField("package", "Oracle Linux").Info("Start fetching vulnerabilities")
 	// Get the first ELSA we have to manage.
@@ -136,85 +364,612 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 		flagValue = ""
 	}
 
-	firstELSA, err := strconv.Atoi(flagValue)
-	if firstELSA == 0 || err != nil {
-		firstELSA = firstOracle5ELSA
+	first := firstELSA(flagValue, baselineELSA)
+
+	// Get the ELSAs listed by the previous update, to find the retracted ones.
+	listedValue, _, err := database.FindKeyValueAndRollback(datastore, listedFlag)
+	if err != nil {
+		return
 	}
 
-	// Fetch the update list.
-	r, err := httputil.GetWithUserAgent(ovalURI)
+	// Get the ELSAs listed upstream, and the mirror serving them.
+	mirror, listed, err := selectMirror(mirrorURIs)
+	if err != nil {
+		return resp, err
+	}
+
+	// Get the list of ELSAs that we have to process.
+	elsaList := elsasAfter(listed, first)
+	resp.Vulnerabilities, err = fetchELSAs(mirror, checksumURI, gpgKeyring, cacheDir, elsaList)
+	if err != nil {
+		return resp, err
+	}
+
+	// Only the ELSAs after the baseline are ever fetched, and so withdrawn.
+	tracked := elsasAfter(listed, firstELSA("", baselineELSA))
+	resp.Withdrawn, err = withdrawnVulnerabilities(datastore, mirror, retractedELSAs(parseELSASet(listedValue), tracked))
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Flags = make(map[string]string)
+	if len(tracked) > 0 {
+		resp.Flags[listedFlag] = formatELSASet(tracked)
+	}
+
+	// Set the flag if we found anything.
+	if len(elsaList) > 0 {
+		resp.Flags[updaterFlag] = strconv.Itoa(elsaList[len(elsaList)-1])
+	} else {
+		log.WithField("package", "Oracle Linux").Debug("no update")
+	}
+
+	return resp, nil
+}
+
+// selectMirror returns the first mirror which serves its update list, along
+// with every ELSA it lists. It fails with the error of the last mirror when
+// none of them does.
+//
+// Only the mirrors which cannot be reached or answer with an unexpected status
+// are skipped: an update list which cannot be parsed fails at once, rather
+// than being masked by the next mirror.
+func selectMirror(mirrors []string) (mirror string, listed []int, err error) {
+	for _, mirror = range mirrors {
+		listed, err = fetchELSAList(mirror, 0)
+		if err == nil {
+			return mirror, listed, nil
+		}
+
+		var derr *commonerr.DownloadError
+		if !errors.As(err, &derr) {
+			return "", nil, err
+		}
+
+		log.WithError(err).WithField("mirror", mirror).Warning("could not fetch Oracle's update list from mirror")
+	}
+
+	return "", nil, err
+}
+
+// fetchELSAList downloads the update list and returns the ELSAs it lists
+// after the first one, without duplicates and sorted in ascending order.
+func fetchELSAList(indexURI string, first int) ([]int, error) {
+	r, err := httputil.GetForUpdater(updaterName, indexURI)
 	if err != nil {
 		log.WithError(err).Error("could not download Oracle's update list")
-		return resp, commonerr.ErrCouldNotDownload
+		return nil, commonerr.NewDownloadError(indexURI, err)
 	}
 	defer r.Body.Close()
 
 	if !httputil.Status2xx(r) {
 		log.WithField("StatusCode", r.StatusCode).Error("Failed to update Oracle")
-		return resp, commonerr.ErrCouldNotDownload
+		return nil, commonerr.NewStatusError(indexURI, r.StatusCode)
 	}
 
-	// Get the list of ELSAs that we have to process.
+	// Mirrors may list a file several times.
+	seen := make(map[int]struct{})
 	var elsaList []int
 	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(nil, maxIndexLineSize)
+	var line int
 	for scanner.Scan() {
-		line := scanner.Text()
-		r := elsaRegexp.FindStringSubmatch(line)
-		if len(r) == 2 {
+		line++
+		// A line may list several ELSAs when the list isn't split in lines.
+		for _, r := range elsaRegexp.FindAllStringSubmatch(scanner.Text(), -1) {
 			elsaNo, _ := strconv.Atoi(r[1])
-			if compareELSA(elsaNo, firstELSA) > 0 {
+			if _, dup := seen[elsaNo]; dup {
+				continue
+			}
+			seen[elsaNo] = struct{}{}
+
+			if compareELSA(elsaNo, first) > 0 {
 				elsaList = append(elsaList, elsaNo)
 			}
 		}
 	}
 
+	if err := scanner.Err(); err == bufio.ErrTooLong {
+		log.WithError(err).Error("could not parse Oracle's update list")
+		return nil, commonerr.NewParseError(indexURI, line+1, err)
+	} else if err != nil {
+		log.WithError(err).Error("could not read Oracle's update list")
+		return nil, commonerr.NewDownloadError(indexURI, err)
+	}
+
+	// Sort the list so that the last ELSA processed is the latest one.
+	sort.Slice(elsaList, func(i, j int) bool { return compareELSA(elsaList[i], elsaList[j]) < 0 })
+	return elsaList, nil
+}
+
+// elsasAfter returns the sorted ELSAs which come after the first one.
+func elsasAfter(elsas []int, first int) []int {
+	var after []int
+	for _, elsa := range elsas {
+		if compareELSA(elsa, first) > 0 {
+			after = append(after, elsa)
+		}
+	}
+
+	return after
+}
+
+// retractedELSAs returns the previously listed ELSAs which aren't listed
+// anymore. The ELSAs after the last one listed are ignored: a lagging mirror
+// doesn't list them yet.
+func retractedELSAs(previous, listed []int) []int {
+	if len(listed) == 0 {
+		return nil
+	}
+
+	current := make(map[int]struct{}, len(listed))
+	for _, elsa := range listed {
+		current[elsa] = struct{}{}
+	}
+
+	var retracted []int
+	for _, elsa := range previous {
+		if _, ok := current[elsa]; !ok && compareELSA(elsa, listed[len(listed)-1]) < 0 {
+			retracted = append(retracted, elsa)
+		}
+	}
+
+	return retracted
+}
+
+// formatELSASet returns the sorted ELSAs as comma-separated ranges of
+// consecutive ELSAs, e.g. "20190001-20190003,20190005", which keeps the flag
+// of the thousands of listed ELSAs short.
+func formatELSASet(elsas []int) string {
+	var b strings.Builder
+	for i := 0; i < len(elsas); i++ {
+		start := elsas[i]
+		for i+1 < len(elsas) && elsas[i+1] == elsas[i]+1 {
+			i++
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(start))
+		if elsas[i] != start {
+			b.WriteByte('-')
+			b.WriteString(strconv.Itoa(elsas[i]))
+		}
+	}
+
+	return b.String()
+}
+
+// parseELSASet returns the ELSAs of the ranges formatted by formatELSASet,
+// skipping the invalid ones.
+func parseELSASet(value string) []int {
+	var elsas []int
+	for _, r := range strings.Split(value, ",") {
+		bounds := strings.SplitN(r, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			continue
+		}
+
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil || end < start {
+				continue
+			}
+		}
+
+		for elsa := start; elsa <= end; elsa++ {
+			elsas = append(elsas, elsa)
+		}
+	}
+
+	return elsas
+}
+
+// withdrawnVulnerabilities returns the vulnerabilities of the retracted ELSAs,
+// read from the cache or from the mirror which may still serve their file.
+// The retracted ELSAs whose file is gone are withdrawn from the advisories
+// stored for them instead.
+//
+// Only the vulnerabilities last written by a retracted ELSA are withdrawn: a
+// CVE also fixed by a later advisory is stored with the description of that
+// one, and is kept.
+func withdrawnVulnerabilities(datastore database.Datastore, mirror string, retracted []int) ([]database.VulnerabilityID, error) {
+	descriptions := make(map[database.VulnerabilityID]string)
+	var removed []string
+	for _, elsa := range retracted {
+		vulnerabilities, err := fetchRetractedELSA(mirror, elsa)
+		if isNotFound(err) {
+			removed = append(removed, elsaName(elsa))
+			continue
+		} else if err == errUnverifiedSignature {
+			log.WithError(err).WithField("elsa", elsa).Warning("could not withdraw the vulnerabilities of Oracle's retracted ELSA")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, vulnerability := range vulnerabilities {
+			// The advisories fixing CVEs are kept in their own namespace.
+			if vulnerability.Namespace.Name != "" {
+				descriptions[database.VulnerabilityID{Name: vulnerability.Name, Namespace: vulnerability.Namespace.Name}] = vulnerability.Description
+			}
+
+			for _, affected := range vulnerability.Affected {
+				id := database.VulnerabilityID{Name: vulnerability.Name, Namespace: affected.Namespace.Name}
+				descriptions[id] = vulnerability.Description
+			}
+		}
+	}
+
+	withdrawn, err := withdrawnAdvisories(datastore, removed)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(descriptions) == 0 {
+		return withdrawn, nil
+	}
+
+	ids := make([]database.VulnerabilityID, 0, len(descriptions))
+	for id := range descriptions {
+		ids = append(ids, id)
+	}
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, vulnerability := range stored {
+		if !vulnerability.Valid {
+			continue
+		}
+
+		id := database.VulnerabilityID{Name: vulnerability.Name, Namespace: vulnerability.Namespace.Name}
+		if vulnerability.Description == descriptions[id] {
+			withdrawn = append(withdrawn, id)
+		}
+	}
+
+	log.WithFields(log.Fields{"package": "Oracle Linux", "retracted": len(retracted), "withdrawn": len(withdrawn)}).Info("withdrawing the vulnerabilities of retracted ELSAs")
+	return withdrawn, nil
+}
+
+// withdrawnAdvisories returns the stored advisories of the given names, along
+// with the CVEs they last wrote: a CVE also fixed by a later advisory is
+// stored with the aliases of that one, and is kept.
+func withdrawnAdvisories(datastore database.Datastore, names []string) ([]database.VulnerabilityID, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	// The advisories are stored in the namespaces of the features they fix.
+	var ids []database.VulnerabilityID
+	for _, name := range names {
+		for _, namespace := range releaseNamespaces() {
+			ids = append(ids, database.VulnerabilityID{Name: name, Namespace: namespace})
+		}
+	}
+
+	advisories, err := database.FindVulnerabilitiesAndRollback(datastore, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var withdrawn, cves []database.VulnerabilityID
+	for _, advisory := range advisories {
+		if !advisory.Valid {
+			continue
+		}
+
+		withdrawn = append(withdrawn, database.VulnerabilityID{Name: advisory.Name, Namespace: advisory.Namespace.Name})
+		for _, alias := range advisory.Aliases {
+			cves = append(cves, database.VulnerabilityID{Name: alias, Namespace: advisory.Namespace.Name})
+		}
+	}
+
+	if len(cves) == 0 {
+		return withdrawn, nil
+	}
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, cves)
+	if err != nil {
+		return nil, err
+	}
+
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	for _, vulnerability := range stored {
+		if !vulnerability.Valid {
+			continue
+		}
+
+		for _, alias := range vulnerability.Aliases {
+			if i := sort.SearchStrings(names, alias); i < len(names) && names[i] == alias {
+				withdrawn = append(withdrawn, database.VulnerabilityID{Name: vulnerability.Name, Namespace: vulnerability.Namespace.Name})
+				break
+			}
+		}
+	}
+
+	return withdrawn, nil
+}
+
+// elsaName returns the name of the advisory of an ELSA number, e.g.
+// "ELSA-2015-1193" for 20151193.
+func elsaName(elsa int) string {
+	number := strconv.Itoa(elsa)
+	if len(number) <= 4 {
+		return "ELSA-" + number
+	}
+
+	return "ELSA-" + number[:4] + "-" + number[4:]
+}
+
+// fetchRetractedELSA returns the vulnerabilities of a retracted ELSA from any
+// version of its file in the cache, or downloads it otherwise. Retracted ELSA
+// files aren't cached.
+func fetchRetractedELSA(mirror string, elsa int) ([]database.VulnerabilityWithAffected, error) {
+	if cacheDir != "" {
+		// The ELSA number is followed by the hashed ETag.
+		cached, _ := filepath.Glob(filepath.Join(cacheDir, elsaFilePrefix+strconv.Itoa(elsa)+"-*.xml"))
+		for _, path := range cached {
+			if content, err := ioutil.ReadFile(path); err == nil {
+				return parseELSAContent(content, path)
+			}
+		}
+	}
+
+	return fetchELSA(mirror, checksumURI, gpgKeyring, "", elsa)
+}
+
+func (u *updater) Clean() {}
+
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{versionFormat}
+}
+
+// Namespaces implements vulnsrc.NamespaceLister, covering the Oracle Linux
+// major releases published in the OVAL feed.
+func (u *updater) Namespaces() []string {
+	return releaseNamespaces()
+}
+
+// releaseNamespaces returns the names of the namespaces of the Oracle Linux
+// major releases, and of their Ksplice packages when they are kept apart.
+func releaseNamespaces() []string {
+	namespaces := make([]string, 0, 2*(lastRelease-firstRelease+1))
+	for release := firstRelease; release <= lastRelease; release++ {
+		namespaces = append(namespaces, namespace(release))
+	}
+	if kspliceMode == kspliceNamespace {
+		for release := firstRelease; release <= lastRelease; release++ {
+			namespaces = append(namespaces, ksplice(release))
+		}
+	}
+
+	return namespaces
+}
+
+// fetchELSAs downloads and parses the listed ELSA files.
+//
+// ELSA files which cannot be verified are skipped, and so are the ones which
+// are not found: the update list may briefly list ELSA files which were
+// removed upstream. Any other failure aborts fetching.
+func fetchELSAs(baseURI, checksumBaseURI, keyring, cacheDir string, elsaList []int) (vulnerabilities []database.VulnerabilityWithAffected, err error) {
 	for _, elsa := range elsaList {
-		// Download the ELSA's XML file.
-		r, err := httputil.GetWithUserAgent(ovalURI + elsaFilePrefix + strconv.Itoa(elsa) + ".xml")
+		vs, err := fetchELSA(baseURI, checksumBaseURI, keyring, cacheDir, elsa)
+		if err == errUnverifiedSignature {
+			// Unverified data is never ingested.
+			continue
+		} else if isNotFound(err) {
+			log.WithError(err).WithField("elsa", elsa).Warning("skipping Oracle's ELSA file missing upstream")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// Collect vulnerabilities.
+		vulnerabilities = append(vulnerabilities, vs...)
+	}
+
+	return vulnerabilities, nil
+}
+
+// isNotFound returns whether the error is a download which failed because the
+// resource was not found.
+func isNotFound(err error) bool {
+	var derr *commonerr.DownloadError
+	return errors.As(err, &derr) && derr.StatusCode == http.StatusNotFound
+}
+
+// fetchELSA downloads and parses an ELSA file, verifying it against its
+// published checksum first if checksumBaseURI is set, and against its detached
+// signature if keyring is set.
+//
+// If cacheDir is set, the ELSA file is read from the cache when it holds the
+// file with its current ETag, and the verified file is cached otherwise.
+func fetchELSA(baseURI, checksumBaseURI, keyring, cacheDir string, elsa int) ([]database.VulnerabilityWithAffected, error) {
+	filename := elsaFilePrefix + strconv.Itoa(elsa) + ".xml"
+
+	var cachePath string
+	if cacheDir != "" {
+		etag, err := fetchETag(baseURI + filename)
 		if err != nil {
-			log.WithError(err).Error("could not download Oracle's update list")
-			return resp, commonerr.ErrCouldNotDownload
+			return nil, err
 		}
-		defer r.Body.Close()
 
-		if !httputil.Status2xx(r) {
-			log.WithField("StatusCode", r.StatusCode).Error("Failed to update Oracle")
-			return resp, commonerr.ErrCouldNotDownload
+		// Files served without an ETag can't be told apart and aren't cached.
+		if etag != "" {
+			cachePath = cacheFile(cacheDir, elsa, etag)
+			if content, err := ioutil.ReadFile(cachePath); err == nil {
+				// Only verified files are cached.
+				return parseELSAContent(content, baseURI+filename)
+			} else if !os.IsNotExist(err) {
+				log.WithError(err).WithField("file", cachePath).Warning("could not read cached Oracle's ELSA file")
+			}
 		}
+	}
+
+	// Download the ELSA's XML file.
+	content, err := download(baseURI + filename)
+	if err != nil {
+		return nil, err
+	}
 
-		// Parse the XML.
-		vs, err := parseELSA(r.Body)
+	if checksumBaseURI != "" {
+		checksum, err := download(checksumBaseURI + filename + ".sha256")
 		if err != nil {
-			return resp, err
+			return nil, err
 		}
 
-		// Collect vulnerabilities.
-		for _, v := range vs {
-			resp.Vulnerabilities = append(resp.Vulnerabilities, v)
+		if err := verifyChecksum(content, checksum); err != nil {
+			log.WithError(err).WithField("file", filename).Error("could not verify Oracle's ELSA file")
+			return nil, err
 		}
 	}
 
-	// Set the flag if we found anything.
-	if len(elsaList) > 0 {
-		resp.Flags = make(map[string]string)
-		resp.Flags[updaterFlag] = strconv.Itoa(largest(elsaList))
-	} else {
-		log.WithField("package", "Oracle Linux").Debug("no update")
+	if keyring != "" {
+		if err := gpgutil.VerifyDownload(updaterName, keyring, baseURI+filename, content); err != nil {
+			log.WithError(err).WithField("file", filename).Error("skipping Oracle's ELSA file with unverified signature")
+			return nil, errUnverifiedSignature
+		}
 	}
 
-	return resp, nil
+	vulnerabilities, err := parseELSAContent(content, baseURI+filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		if err := writeCache(cachePath, content); err != nil {
+			log.WithError(err).WithField("file", cachePath).Warning("could not cache Oracle's ELSA file")
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// parseELSAContent parses the content of the ELSA file from source.
+func parseELSAContent(content []byte, source string) ([]database.VulnerabilityWithAffected, error) {
+	vulnerabilities, err := parseELSA(bytes.NewReader(content))
+	var perr *commonerr.ParseError
+	if errors.As(err, &perr) {
+		perr.Source = source
+	}
+
+	return vulnerabilities, err
+}
+
+// fetchETag returns the ETag of a remote file, without downloading it. It is
+// empty when the server doesn't provide one.
+func fetchETag(uri string) (string, error) {
+	r, err := httputil.HeadForUpdater(updaterName, uri)
+	if err != nil {
+		log.WithError(err).Error("could not check Oracle's ELSA file")
+		return "", commonerr.NewDownloadError(uri, err)
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update Oracle")
+		return "", commonerr.NewStatusError(uri, r.StatusCode)
+	}
+
+	return r.Header.Get("ETag"), nil
+}
+
+// cacheFile returns the path of an ELSA file with the given ETag in the cache.
+// The ETag is hashed since it is an arbitrary quoted string.
+func cacheFile(dir string, elsa int, etag string) string {
+	digest := sha256.Sum256([]byte(etag))
+	return filepath.Join(dir, fmt.Sprintf("%s%d-%s.xml", elsaFilePrefix, elsa, hex.EncodeToString(digest[:8])))
 }
 
-func largest(list []int) (largest int) {
-	for _, element := range list {
-		if element > largest {
-			largest = element
+// writeCache atomically writes the content of an ELSA file to the cache, and
+// removes the previous versions of the file.
+func writeCache(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(dir, ".elsa-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	// The ELSA number is followed by the hashed ETag.
+	stale, _ := filepath.Glob(path[:strings.LastIndex(path, "-")] + "-*.xml")
+	for _, file := range stale {
+		if file != path {
+			os.Remove(file)
 		}
 	}
-	return
+
+	return os.Rename(f.Name(), path)
 }
 
-func (u *updater) Clean() {}
+// verifyChecksum checks the content against a checksum in the format of
+// sha256sum: a hexadecimal digest optionally followed by the file name.
+func verifyChecksum(content, checksum []byte) error {
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		return errChecksumMismatch
+	}
+
+	digest := sha256.Sum256(content)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(digest[:])) {
+		return errChecksumMismatch
+	}
+
+	return nil
+}
+
+func download(uri string) ([]byte, error) {
+	r, err := httputil.GetForUpdater(updaterName, uri)
+	if err != nil {
+		log.WithError(err).Error("could not download Oracle's update list")
+		return nil, commonerr.NewDownloadError(uri, err)
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		log.WithField("StatusCode", r.StatusCode).Error("Failed to update Oracle")
+		return nil, commonerr.NewStatusError(uri, r.StatusCode)
+	}
+
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.WithError(err).Error("could not download Oracle's update list")
+		return nil, commonerr.NewDownloadError(uri, err)
+	}
+
+	return content, nil
+}
+
+// ParseOVAL parses an Oracle Linux OVAL file, such as an ELSA file of the
+// Oracle Linux OVAL Database, into the vulnerabilities it describes, the way
+// the updater does.
+//
+// It fails with a *commonerr.ParseError when the file is not valid XML.
+func ParseOVAL(r io.Reader) ([]database.VulnerabilityWithAffected, error) {
+	return parseELSA(r)
+}
 
 func parseELSA(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWithAffected, err error) {
 	// Decode the XML.
@@ -222,21 +977,36 @@ func parseELSA(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWi
 	err = xml.NewDecoder(ovalReader).Decode(&ov)
 	if err != nil {
 		log.WithError(err).Error("could not decode Oracle's XML")
-		err = commonerr.ErrCouldNotParse
+		var line int
+		if serr, ok := err.(*xml.SyntaxError); ok {
+			line = serr.Line
+		}
+		err = commonerr.NewParseError("Oracle's OVAL", line, err)
 		return
 	}
 
+	tests := packageTests(ov)
+
+	// The vulnerabilities of an advisory fixing several CVEs are mostly
+	// described by the same text, which is kept once.
+	descriptions := make(stringSet)
+
 	// Iterate over the definitions and collect any vulnerabilities that affect
 	// at least one package.
 	for _, definition := range ov.Definitions {
-		pkgs := toFeatures(definition.Criteria)
+		pkgs, err := toFeatures(definition.Criteria, releases(definition), tests)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{"title": strings.TrimSpace(definition.Title), "max": maxPossibilities}).Warning("skipping Oracle definition")
+			continue
+		}
+
 		if len(pkgs) > 0 {
 			vulnerability := database.VulnerabilityWithAffected{
 				Vulnerability: database.Vulnerability{
 					Name:        name(definition),
-					Link:        link(definition),
+					Link:        link(definition, ""),
 					Severity:    severity(definition.Severity),
-					Description: description(definition),
+					Description: descriptions.intern(description(definition)),
 				},
 			}
 			for _, p := range pkgs {
@@ -249,10 +1019,23 @@ func parseELSA(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWi
 				continue
 			}
 
-			// Create one vulnerability per CVE
+			// Create one vulnerability per CVE, described by the changelog
+			// entries mentioning it when the advisory fixes several CVEs.
+			var cveDescs map[string]string
+			if len(definition.CVEs) > 1 {
+				cveDescs = cveDescriptions(definition)
+			}
+
+			defaultDesc := vulnerability.Description
 			for _, currentCVE := range definition.CVEs {
 				vulnerability.Name = currentCVE.ID
-				vulnerability.Link = currentCVE.Href
+				vulnerability.Link = link(definition, currentCVE.ID)
+				vulnerability.Aliases = aliases(definition, currentCVE.ID)
+				if desc, ok := cveDescs[currentCVE.ID]; ok {
+					vulnerability.Description = descriptions.intern(desc)
+				} else {
+					vulnerability.Description = defaultDesc
+				}
 				if currentCVE.Impact != "" {
 					vulnerability.Severity = severity(currentCVE.Impact)
 				} else {
@@ -260,12 +1043,75 @@ func parseELSA(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWi
 				}
 				vulnerabilities = append(vulnerabilities, vulnerability)
 			}
+
+			// The advisory itself affects no feature, as its CVEs do, but is
+			// kept in the namespaces of its features so that it can be looked
+			// up along with the CVEs it fixes.
+			advisory := database.VulnerabilityWithAffected{
+				Vulnerability: database.Vulnerability{
+					Name:        name(definition),
+					Link:        link(definition, ""),
+					Severity:    severity(definition.Severity),
+					Description: defaultDesc,
+					Aliases:     aliases(definition, name(definition)),
+				},
+			}
+			for _, namespace := range affectedNamespaces(pkgs) {
+				advisory.Namespace = namespace
+				vulnerabilities = append(vulnerabilities, advisory)
+			}
 		}
 	}
 
 	return
 }
 
+// affectedNamespaces returns the namespaces of the affected features, sorted by
+// name, without duplicates.
+func affectedNamespaces(affected []database.AffectedFeature) []database.Namespace {
+	seen := make(map[string]struct{})
+	var namespaces []database.Namespace
+	for _, a := range affected {
+		if _, ok := seen[a.Namespace.Name]; ok {
+			continue
+		}
+		seen[a.Namespace.Name] = struct{}{}
+		namespaces = append(namespaces, a.Namespace)
+	}
+	sort.Slice(namespaces, func(i, j int) bool { return namespaces[i].Name < namespaces[j].Name })
+
+	return namespaces
+}
+
+// enabledIgnoreRules returns the texts of the ignore rules which aren't in the
+// comma-separated list of disabled rule names.
+func enabledIgnoreRules(disabled string) []string {
+	disabledRules := make(map[string]struct{})
+	for _, name := range strings.Split(disabled, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, ok := ignoreRules[name]; !ok {
+			log.WithField("rule", name).Warning("unknown Oracle ignore rule")
+			continue
+		}
+
+		disabledRules[name] = struct{}{}
+	}
+
+	var enabled []string
+	for name, text := range ignoreRules {
+		if _, ok := disabledRules[name]; !ok {
+			enabled = append(enabled, text)
+		}
+	}
+	sort.Strings(enabled)
+
+	return enabled
+}
+
 func getCriterions(node criteria) [][]criterion {
 	// Filter useless criterions.
 	var criterions []criterion
@@ -273,6 +1119,11 @@ func getCriterions(node criteria) [][]criterion {
 		ignored := false
 
 		for _, ignoredItem := range ignoredCriterions {
+			// Ksplice packages are reported in their own namespaces.
+			if ignoredItem == ignoreRules["ksplice"] && kspliceMode == kspliceNamespace {
+				continue
+			}
+
 			if strings.Contains(c.Comment, ignoredItem) {
 				ignored = true
 				break
@@ -280,6 +1131,8 @@ func getCriterions(node criteria) [][]criterion {
 		}
 
 		if !ignored {
+			// The criterions of an applicability check are ones too.
+			c.ApplicabilityCheck = c.ApplicabilityCheck || node.ApplicabilityCheck
 			criterions = append(criterions, c)
 		}
 	}
@@ -297,14 +1150,29 @@ func getCriterions(node criteria) [][]criterion {
 	return [][]criterion{}
 }
 
-func getPossibilities(node criteria) [][]criterion {
+// getPossibilities returns the combinations of criterions satisfying a
+// criteria tree. It fails with errTooManyPossibilities as soon as there are
+// more than maxPossibilities of them, before composing them.
+func getPossibilities(node criteria) ([][]criterion, error) {
 	if len(node.Criterias) == 0 {
-		return getCriterions(node)
+		possibilities := getCriterions(node)
+		if len(possibilities) > maxPossibilities {
+			return nil, errTooManyPossibilities
+		}
+
+		return possibilities, nil
 	}
 
 	var possibilitiesToCompose [][][]criterion
 	for _, criteria := range node.Criterias {
-		possibilitiesToCompose = append(possibilitiesToCompose, getPossibilities(*criteria))
+		child := *criteria
+		child.ApplicabilityCheck = child.ApplicabilityCheck || node.ApplicabilityCheck
+
+		possibilities, err := getPossibilities(child)
+		if err != nil {
+			return nil, err
+		}
+		possibilitiesToCompose = append(possibilitiesToCompose, possibilities)
 	}
 	if len(node.Criterions) > 0 {
 		possibilitiesToCompose = append(possibilitiesToCompose, getCriterions(node))
@@ -317,8 +1185,11 @@ func getPossibilities(node criteria) [][]criterion {
 		}
 
 		for _, possibilityGroup := range possibilitiesToCompose[1:] {
-			var newPossibilities [][]criterion
+			if len(possibilities)*len(possibilityGroup) > maxPossibilities {
+				return nil, errTooManyPossibilities
+			}
 
+			var newPossibilities [][]criterion
 			for _, possibility := range possibilities {
 				for _, possibilityInGroup := range possibilityGroup {
 					var p []criterion
@@ -332,42 +1203,144 @@ func getPossibilities(node criteria) [][]criterion {
 		}
 	} else if node.Operator == "OR" {
 		for _, possibilityGroup := range possibilitiesToCompose {
+			if len(possibilities)+len(possibilityGroup) > maxPossibilities {
+				return nil, errTooManyPossibilities
+			}
+
 			for _, possibility := range possibilityGroup {
 				possibilities = append(possibilities, possibility)
 			}
 		}
 	}
 
-	return possibilities
+	return possibilities, nil
+}
+
+// releases returns the Oracle Linux major releases of the platforms affected
+// by a definition.
+func releases(def definition) []int {
+	var releases []int
+	for _, platform := range def.Platforms {
+		release, err := majorRelease(strings.TrimSpace(platform))
+		if err != nil {
+			log.WithError(err).WithField("platform", platform).Warning("could not parse Oracle Linux release version from platform")
+			continue
+		}
+
+		if !containsRelease(releases, release) {
+			releases = append(releases, release)
+		}
+	}
+
+	return releases
+}
+
+func containsRelease(releases []int, release int) bool {
+	for _, r := range releases {
+		if r == release {
+			return true
+		}
+	}
+
+	return false
 }
 
-func toFeatures(criteria criteria) []database.AffectedFeature {
+// packageTests resolves the rpminfo tests checking a package is earlier than
+// a version, by ID, out of their objects and states.
+func packageTests(ov oval) map[string]packageTest {
+	names := make(map[string]string, len(ov.Objects))
+	for _, object := range ov.Objects {
+		names[object.ID] = strings.TrimSpace(object.Name)
+	}
+
+	versions := make(map[string]string, len(ov.States))
+	for _, state := range ov.States {
+		if state.EVR.Operation == "less than" {
+			versions[state.ID] = strings.TrimSpace(state.EVR.Value)
+		}
+	}
+
+	tests := make(map[string]packageTest, len(ov.Tests))
+	for _, test := range ov.Tests {
+		name, version := names[test.Object.Ref], versions[test.State.Ref]
+		if name != "" && version != "" {
+			tests[test.ID] = packageTest{name: name, version: version}
+		}
+	}
+
+	return tests
+}
+
+// criterionPackage returns the package and version a criterion checks the
+// installed package is earlier than.
+//
+// They come from the rpminfo test referenced by the criterion, and are only
+// parsed out of its comment, e.g. "openssl is earlier than 1:1.0.2k-19.el7",
+// when the test cannot be resolved.
+func criterionPackage(c criterion, tests map[string]packageTest) (name, version string, ok bool) {
+	if test, ok := tests[c.TestRef]; ok {
+		return test.name, test.version, true
+	}
+
+	const earlierThan = " is earlier than "
+	i := strings.Index(c.Comment, earlierThan)
+	if i < 0 {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(c.Comment[:i]), c.Comment[i+len(earlierThan):], true
+}
+
+// toFeatures returns the features affected by the criteria of a definition
+// which affects the given Oracle Linux releases.
+//
+// The release of a feature is the one of the definition's platform, and is
+// only parsed out of the criterions when the definition affects several or
+// no platforms. The packages of the applicability checks are prerequisites of
+// the definition, which aren't affected. It fails when the criteria have too
+// many possibilities.
+func toFeatures(criteria criteria, platforms []int, tests map[string]packageTest) ([]database.AffectedFeature, error) {
 	// There are duplicates in Oracle .xml files.
 	// This map is for deduplication.
 	featureVersionParameters := make(map[string]database.AffectedFeature)
 
-	possibilities := getPossibilities(criteria)
+	possibilities, err := getPossibilities(criteria)
+	if err != nil {
+		return nil, err
+	}
 	for _, criterions := range possibilities {
 		var (
 			featureVersion database.AffectedFeature
 			osVersion      int
+			isKsplice      bool
 			err            error
 		)
 
-		// Attempt to parse package data from trees of criterions.
+		if len(platforms) == 1 {
+			osVersion = platforms[0]
+		}
+
+		// Attempt to parse package data from trees of criterions, the
+		// applicability checks only telling the release.
 		for _, c := range criterions {
+			if c.ApplicabilityCheck && !strings.Contains(c.Comment, " is installed") {
+				continue
+			}
+
 			if strings.Contains(c.Comment, " is installed") {
-				const prefixLen = len("Oracle Linux ")
-				osVersion, err = strconv.Atoi(strings.TrimSpace(c.Comment[prefixLen : prefixLen+strings.Index(c.Comment[prefixLen:], " ")]))
+				if len(platforms) == 1 {
+					continue
+				}
+
+				osVersion, err = majorRelease(c.Comment)
 				if err != nil {
 					log.WithError(err).WithField("comment", c.Comment).Warning("could not parse Oracle Linux release version from comment")
 				}
-			} else if strings.Contains(c.Comment, " is earlier than ") {
-				const prefixLen = len(" is earlier than ")
-				featureVersion.FeatureName = strings.TrimSpace(c.Comment[:strings.Index(c.Comment, " is earlier than ")])
+			} else if name, version, ok := criterionPackage(c, tests); ok {
+				featureVersion.FeatureName = name
 				featureVersion.FeatureType = affectedType
-				version := c.Comment[strings.Index(c.Comment, " is earlier than ")+prefixLen:]
-				err := versionfmt.Valid(rpm.ParserName, version)
+				isKsplice = kspliceMode == kspliceNamespace && strings.Contains(version, ignoreRules["ksplice"])
+				err := versionfmt.Valid(versionFormat, version)
 				if err != nil {
 					log.WithError(err).WithField("version", version).Warning("could not parse package version. skipping")
 				} else {
@@ -379,13 +1352,21 @@ func toFeatures(criteria criteria) []database.AffectedFeature {
 			}
 		}
 
-		featureVersion.Namespace.Name = "oracle" + ":" + strconv.Itoa(osVersion)
-		featureVersion.Namespace.VersionFormat = rpm.ParserName
+		if len(platforms) > 1 && osVersion != 0 && !containsRelease(platforms, osVersion) {
+			log.WithFields(log.Fields{"release": osVersion, "platforms": platforms}).Warning("criterions target a release which isn't an affected platform. skipping")
+			continue
+		}
+
+		featureVersion.Namespace.Name = namespace(osVersion)
+		if isKsplice {
+			featureVersion.Namespace.Name = ksplice(osVersion)
+		}
+		featureVersion.Namespace.VersionFormat = versionFormat
 
-		if featureVersion.Namespace.Name != "" && featureVersion.FeatureName != "" && featureVersion.AffectedVersion != "" && featureVersion.FixedInVersion != "" {
+		if osVersion != 0 && featureVersion.FeatureName != "" && featureVersion.AffectedVersion != "" && featureVersion.FixedInVersion != "" {
 			featureVersionParameters[featureVersion.Namespace.Name+":"+featureVersion.FeatureName] = featureVersion
 		} else {
-			log.WithField("criterions", fmt.Sprintf("%v", criterions)).Warning("could not determine a valid package from criterions")
+			vulnsrc.LogParseFailure("oracle", log.Fields{"criterions": criterions}, "could not determine a valid package from criterions")
 		}
 	}
 
@@ -395,7 +1376,56 @@ func toFeatures(criteria criteria) []database.AffectedFeature {
 		featureVersionParametersArray = append(featureVersionParametersArray, fv)
 	}
 
-	return featureVersionParametersArray
+	return featureVersionParametersArray, nil
+}
+
+// namespace returns the name of the namespace of an Oracle Linux major
+// release, prefixed by namespacePrefix.
+func namespace(release int) string {
+	return namespacePrefix + "oracle" + ":" + strconv.Itoa(release)
+}
+
+// ksplice returns the name of the namespace of the Ksplice packages of an
+// Oracle Linux major release, prefixed by namespacePrefix.
+func ksplice(release int) string {
+	return namespacePrefix + "oracle-ksplice" + ":" + strconv.Itoa(release)
+}
+
+// majorRelease parses the Oracle Linux release out of a criterion such as
+// "Oracle Linux 7 is installed".
+//
+// Namespaces are always keyed by the major release, even when an advisory
+// targets a minor release (e.g. "Oracle Linux 7.9 is installed"), because the
+// namespace detectors only report the major release of an image. Advisories
+// for a minor release are therefore matched against every image of that
+// major release.
+func majorRelease(comment string) (int, error) {
+	const prefix = "Oracle Linux "
+	if !strings.HasPrefix(comment, prefix) {
+		return 0, fmt.Errorf("unexpected release criterion %q", comment)
+	}
+
+	release := strings.Fields(comment[len(prefix):])
+	if len(release) == 0 {
+		return 0, fmt.Errorf("unexpected release criterion %q", comment)
+	}
+
+	return strconv.Atoi(strings.SplitN(release[0], ".", 2)[0])
+}
+
+// stringSet interns strings, so that equal strings share their memory.
+type stringSet map[string]string
+
+// intern returns the string of the set equal to s. If there is none, a copy of
+// s is added, so that a substring, e.g. a changelog entry, doesn't keep the
+// whole advisory in memory.
+func (set stringSet) intern(s string) string {
+	if interned, ok := set[s]; ok {
+		return interned
+	}
+	interned := string([]byte(s))
+	set[interned] = interned
+	return interned
 }
 
 func description(def definition) (desc string) {
@@ -406,24 +1436,149 @@ func description(def definition) (desc string) {
 	return
 }
 
+// cveDescriptions returns the description of every CVE of the definition
+// which is mentioned by the entries of its description, an RPM changelog, made
+// of the entries mentioning it.
+func cveDescriptions(def definition) map[string]string {
+	// An entry starts with a dash and continues on the indented lines which
+	// follow it, until the next entry or the next version header.
+	var (
+		entries    []string
+		continuing bool
+	)
+	for _, line := range strings.Split(def.Description, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "["):
+			continuing = false
+		case continuing && (line[0] == ' ' || line[0] == '\t'):
+			entries[len(entries)-1] += " " + trimmed
+		default:
+			entries = append(entries, strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+			continuing = true
+		}
+	}
+
+	descriptions := make(map[string]string)
+	for _, c := range def.CVEs {
+		var mentions []string
+		for _, entry := range entries {
+			if mentionsCVE(entry, c.ID) {
+				mentions = append(mentions, entry)
+			}
+		}
+
+		if len(mentions) > 0 {
+			descriptions[c.ID] = strings.Join(mentions, " ")
+		}
+	}
+
+	return descriptions
+}
+
+// mentionsCVE returns whether the text mentions the CVE, and not another CVE
+// whose ID starts with the same digits.
+func mentionsCVE(text, id string) bool {
+	for i := strings.Index(text, id); i >= 0; {
+		end := i + len(id)
+		if end == len(text) || text[end] < '0' || text[end] > '9' {
+			return true
+		}
+
+		next := strings.Index(text[end:], id)
+		if next < 0 {
+			break
+		}
+		i = end + next
+	}
+
+	return false
+}
+
 func name(def definition) string {
 	return strings.TrimSpace(def.Title[:strings.Index(def.Title, ": ")])
 }
 
-func link(def definition) (link string) {
+// aliases returns the names of the vulnerabilities related to the advisory of
+// the definition or to one of its CVEs, given its name: the advisory and the
+// CVEs it fixes but itself, so that they are all linked to each other.
+func aliases(def definition, id string) []string {
+	var aliases []string
+	if advisory := name(def); advisory != id {
+		aliases = append(aliases, advisory)
+	}
+	for _, c := range def.CVEs {
+		if c.ID != id {
+			aliases = append(aliases, c.ID)
+		}
+	}
+	sort.Strings(aliases)
+
+	return aliases
+}
+
+// link returns the URI of the first reference of the definition whose source
+// is in linkSources, in their order of preference. Given the ID of one of its
+// CVEs, the "cve" source designates that CVE rather than the first one.
+func link(def definition, id string) string {
+	for _, source := range linkSources {
+		if id != "" && strings.EqualFold(source, "cve") {
+			if uri := cveLink(def, id); uri != "" {
+				return uri
+			}
+			continue
+		}
+
+		for _, reference := range def.References {
+			if strings.EqualFold(reference.Source, source) {
+				return reference.URI
+			}
+		}
+	}
+
+	return ""
+}
+
+// cveLink returns the URI of the reference to the CVE of the given ID, or the
+// href of the CVE when the definition doesn't reference it.
+func cveLink(def definition, id string) string {
 	for _, reference := range def.References {
-		if reference.Source == "elsa" {
-			link = reference.URI
-			break
+		if strings.EqualFold(reference.Source, "cve") && reference.ID == id {
+			return reference.URI
 		}
 	}
 
-	return
+	for _, c := range def.CVEs {
+		if c.ID == id {
+			return c.Href
+		}
+	}
+
+	return ""
+}
+
+// parseLinkSources parses the comma-separated sources of the references
+// linked to, falling back to the ELSA when there is none.
+func parseLinkSources(value string) []string {
+	var sources []string
+	for _, source := range strings.Split(value, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+
+	if len(sources) == 0 {
+		return []string{defaultLinkSource}
+	}
+
+	return sources
 }
 
 func severity(sev string) database.Severity {
-	switch strings.ToLower(sev) {
-	case "n/a":
+	switch strings.ToLower(strings.TrimSpace(sev)) {
+	case "n/a", "none", "":
+		// Some ELSAs have "None" or no impact instead of "n/a".
 		return database.NegligibleSeverity
 	case "low":
 		return database.LowSeverity
@@ -434,7 +1589,7 @@ func severity(sev string) database.Severity {
 	case "critical":
 		return database.CriticalSeverity
 	default:
-		log.WithField("severity", sev).Warning("could not determine vulnerability severity")
-		return database.UnknownSeverity
+		log.WithFields(log.Fields{"severity": sev, "fallback": unknownSeverity}).Warning("could not determine vulnerability severity")
+		return unknownSeverity
 	}
 }
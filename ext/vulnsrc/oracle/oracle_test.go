@@ -15,15 +15,35 @@
 package oracle
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"unsafe"
 
 	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/versionfmt"
 	"github.com/quay/clair/v3/ext/versionfmt/rpm"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOracleParserOneCve(t *testing.T) {
@@ -35,9 +55,22 @@ func TestOracleParserOneCve(t *testing.T) {
 	defer testFile.Close()
 
 	vulnerabilities, err := parseELSA(testFile)
-	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		// The advisory is kept in the namespace of its features, without
+		// affecting them itself.
+		assert.Equal(t, database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:        "ELSA-2015-1193",
+				Namespace:   database.Namespace{Name: "oracle:7", VersionFormat: rpm.ParserName},
+				Description: ` [3.1.1-7] Resolves: rhbz#1217104 CVE-2015-0252 `,
+				Link:        "http://linux.oracle.com/errata/ELSA-2015-1193.html",
+				Severity:    database.MediumSeverity,
+				Aliases:     []string{"CVE-2015-0252"},
+			},
+		}, vulnerabilities[1])
+
 		assert.Equal(t, "CVE-2015-0252", vulnerabilities[0].Name)
-		assert.Equal(t, "http://linux.oracle.com/cve/CVE-2015-0252.html", vulnerabilities[0].Link)
+		assert.Equal(t, "http://linux.oracle.com/errata/ELSA-2015-1193.html", vulnerabilities[0].Link)
 		assert.Equal(t, database.MediumSeverity, vulnerabilities[0].Severity)
 		assert.Equal(t, ` [3.1.1-7] Resolves: rhbz#1217104 CVE-2015-0252 `, vulnerabilities[0].Description)
 
@@ -85,6 +118,7 @@ func TestELSAParserMultipleCVE(t *testing.T) {
 	defer testFile.Close()
 
 	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
 
 	// Expected
 	expectedCve := []string{"CVE-2015-2722", "CVE-2015-2724", "CVE-2015-2725", "CVE-2015-2727",
@@ -99,13 +133,529 @@ func TestELSAParserMultipleCVE(t *testing.T) {
 	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, len(expectedCve)) {
 		for i, vulnerability := range vulnerabilities {
 			assert.Equal(t, expectedCve[i], vulnerability.Name)
-			assert.Equal(t, fmt.Sprintf("http://linux.oracle.com/cve/%s.html", expectedCve[i]), vulnerability.Link)
+			assert.Equal(t, "http://linux.oracle.com/errata/ELSA-2015-1207.html", vulnerability.Link)
 			assert.Equal(t, database.Severity(expectedSeverity[i]), vulnerability.Severity)
 			assert.Equal(t, ` [38.1.0-1.0.1.el7_1] - Add firefox-oracle-default-prefs.js and remove the corresponding Red Hat file [38.1.0-1] - Update to 38.1.0 ESR [38.0.1-2] - Fixed rhbz#1222807 by removing preun section `, vulnerability.Description)
 		}
 	}
 }
 
+func TestELSAParserCVEDescriptions(t *testing.T) {
+	testFile, err := os.Open("testdata/fetcher_oracle_test.8.xml")
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	// The CVEs mentioned by the changelog are described by their entries,
+	// the other ones by the whole advisory.
+	vulnerabilities, err := parseELSA(testFile)
+	require.Nil(t, err)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+
+	descriptions := make(map[string]string)
+	for _, vulnerability := range vulnerabilities {
+		descriptions[vulnerability.Name] = vulnerability.Description
+	}
+	assert.Equal(t, map[string]string{
+		"CVE-2019-25013": " [2.28-151.0.1] - Fix CVE-2021-3326: assertion failure in the ISO-2022-JP-3 gconv module - Fix CVE-2020-27618 and CVE-2021-27645: infinite loop in iconv and   double free in nscd netgroup cache - Backport the fix of CVE-2021-33261 from upstream [2.28-151] - Rebase to the latest upstream stable branch ",
+		"CVE-2020-27618": "Fix CVE-2020-27618 and CVE-2021-27645: infinite loop in iconv and double free in nscd netgroup cache",
+		"CVE-2021-27645": "Fix CVE-2020-27618 and CVE-2021-27645: infinite loop in iconv and double free in nscd netgroup cache",
+		"CVE-2021-3326":  "Fix CVE-2021-3326: assertion failure in the ISO-2022-JP-3 gconv module",
+	}, descriptions)
+}
+
+// withoutAdvisories returns the vulnerabilities but the advisories kept
+// along with the CVEs they fix, which affect no feature.
+func withoutAdvisories(vulnerabilities []database.VulnerabilityWithAffected) []database.VulnerabilityWithAffected {
+	var filtered []database.VulnerabilityWithAffected
+	for _, vulnerability := range vulnerabilities {
+		if len(vulnerability.Affected) > 0 {
+			filtered = append(filtered, vulnerability)
+		}
+	}
+
+	return filtered
+}
+
+// stringData returns the address of the bytes of the string.
+func stringData(s string) uintptr {
+	return (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+}
+
+func TestELSAParserSharedDescriptions(t *testing.T) {
+	for _, test := range []struct {
+		file string
+		// shared are the groups of CVEs which share their description.
+		shared [][]string
+	}{
+		{"testdata/fetcher_oracle_test.2.xml", [][]string{{
+			"CVE-2015-2722", "CVE-2015-2724", "CVE-2015-2725", "CVE-2015-2727",
+			"CVE-2015-2728", "CVE-2015-2729", "CVE-2015-2731", "CVE-2015-2733", "CVE-2015-2734",
+			"CVE-2015-2735", "CVE-2015-2736", "CVE-2015-2737", "CVE-2015-2738", "CVE-2015-2739",
+			"CVE-2015-2740", "CVE-2015-2741", "CVE-2015-2743",
+		}}},
+		{"testdata/fetcher_oracle_test.8.xml", [][]string{
+			{"CVE-2020-27618", "CVE-2021-27645"},
+			{"CVE-2019-25013"},
+			{"CVE-2021-3326"},
+		}},
+	} {
+		t.Run(filepath.Base(test.file), func(t *testing.T) {
+			testFile, err := os.Open(test.file)
+			require.Nil(t, err)
+			defer testFile.Close()
+
+			vulnerabilities, err := parseELSA(testFile)
+			require.Nil(t, err)
+
+			data := make(map[string]uintptr)
+			for _, vulnerability := range vulnerabilities {
+				require.NotEmpty(t, vulnerability.Description)
+				data[vulnerability.Name] = stringData(vulnerability.Description)
+			}
+
+			groups := make(map[uintptr]struct{})
+			for _, group := range test.shared {
+				for _, name := range group {
+					require.Contains(t, data, name)
+					assert.Equal(t, data[group[0]], data[name], "%s doesn't share the description of %s", name, group[0])
+				}
+				groups[data[group[0]]] = struct{}{}
+			}
+			assert.Len(t, groups, len(test.shared))
+		})
+	}
+}
+
+func TestELSAParserAliases(t *testing.T) {
+	testFile, err := os.Open("testdata/fetcher_oracle_test.9.xml")
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	// Every CVE of the advisory is linked to the advisory and to the other
+	// CVEs it fixes, and the advisory to its CVEs.
+	vulnerabilities, err := parseELSA(testFile)
+	require.Nil(t, err)
+
+	aliases := make(map[string][]string)
+	for _, vulnerability := range vulnerabilities {
+		aliases[vulnerability.Name] = vulnerability.Aliases
+	}
+	assert.Equal(t, map[string][]string{
+		"CVE-2019-9636":  {"CVE-2019-9740", "CVE-2019-9948", "ELSA-2019-2030"},
+		"CVE-2019-9740":  {"CVE-2019-9636", "CVE-2019-9948", "ELSA-2019-2030"},
+		"CVE-2019-9948":  {"CVE-2019-9636", "CVE-2019-9740", "ELSA-2019-2030"},
+		"ELSA-2019-2030": {"CVE-2019-9636", "CVE-2019-9740", "CVE-2019-9948"},
+	}, aliases)
+
+	for name, related := range aliases {
+		for _, alias := range related {
+			if other, ok := aliases[alias]; ok {
+				assert.Contains(t, other, name)
+			}
+		}
+	}
+}
+
+// TestAffectedVersionFormat checks that the versions of every affected feature
+// parsed out of the test advisories are in the version format of its
+// namespace, so that they can't be validated against one format and compared
+// with another.
+func TestAffectedVersionFormat(t *testing.T) {
+	paths, err := filepath.Glob("testdata/fetcher_oracle_test.*.xml")
+	require.Nil(t, err)
+	require.NotEmpty(t, paths)
+
+	var count int
+	for _, path := range paths {
+		testFile, err := os.Open(path)
+		require.Nil(t, err)
+
+		vulnerabilities, err := parseELSA(testFile)
+		testFile.Close()
+		require.Nil(t, err, path)
+
+		for _, vulnerability := range vulnerabilities {
+			for _, affected := range vulnerability.Affected {
+				count++
+				assert.Equal(t, versionFormat, affected.Namespace.VersionFormat, "%s: %s", path, affected.FeatureName)
+				assert.Nil(t, versionfmt.Valid(affected.Namespace.VersionFormat, affected.AffectedVersion), "%s: %s", path, affected.FeatureName)
+				if affected.FixedInVersion != "" {
+					assert.Nil(t, versionfmt.Valid(affected.Namespace.VersionFormat, affected.FixedInVersion), "%s: %s", path, affected.FeatureName)
+				}
+			}
+		}
+	}
+	assert.NotZero(t, count)
+
+	var updater updater
+	assert.Equal(t, []string{versionFormat}, updater.VersionFormats())
+}
+
+func TestMentionsCVE(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected bool
+	}{
+		{"Fix CVE-2021-3326", true},
+		{"Fix CVE-2021-3326: assertion failure", true},
+		{"Fix CVE-2021-33261", false},
+		{"Fix CVE-2021-33261 and CVE-2021-3326", true},
+		{"Fix CVE-2020-3326", false},
+	} {
+		assert.Equal(t, test.expected, mentionsCVE(test.text, "CVE-2021-3326"), test.text)
+	}
+}
+
+func TestELSAParserMinorRelease(t *testing.T) {
+	testFile, _ := os.Open("testdata/fetcher_oracle_test.3.xml")
+	defer testFile.Close()
+
+	// Advisories for a minor release are keyed by the major release, like
+	// the namespaces reported by the namespace detectors.
+	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2019-20388", vulnerabilities[0].Name)
+		if assert.Len(t, vulnerabilities[0].Affected, 3) {
+			for _, affected := range vulnerabilities[0].Affected {
+				assert.Equal(t, database.Namespace{Name: "oracle:7", VersionFormat: rpm.ParserName}, affected.Namespace)
+				assert.Equal(t, "0:2.9.1-6.0.3.el7.5", affected.FixedInVersion)
+			}
+		}
+	}
+}
+
+func TestELSAParserPlatform(t *testing.T) {
+	testFile, _ := os.Open("testdata/fetcher_oracle_test.4.xml")
+	defer testFile.Close()
+
+	// The release of the affected platform is used over the criterions, which
+	// are only relied on when the definition has no platform.
+	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 2) {
+		expected := map[string]database.AffectedFeature{
+			"CVE-2020-14372": {
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:8", VersionFormat: rpm.ParserName},
+				FeatureName:     "grub2-tools",
+				AffectedVersion: "1:2.02-90.0.1.el8_3.1",
+				FixedInVersion:  "1:2.02-90.0.1.el8_3.1",
+			},
+			"CVE-2021-3156": {
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:6", VersionFormat: rpm.ParserName},
+				FeatureName:     "sudo",
+				AffectedVersion: "0:1.8.6p3-29.0.1.el6_10.3",
+				FixedInVersion:  "0:1.8.6p3-29.0.1.el6_10.3",
+			},
+		}
+
+		for _, vulnerability := range vulnerabilities {
+			if assert.Len(t, vulnerability.Affected, 1, vulnerability.Name) {
+				assert.Equal(t, expected[vulnerability.Name], vulnerability.Affected[0])
+			}
+		}
+	}
+}
+
+func TestELSAParserKsplice(t *testing.T) {
+	defer func(rules []string) { ignoredCriterions = rules }(ignoredCriterions)
+
+	parse := func() []database.VulnerabilityWithAffected {
+		testFile, err := os.Open("testdata/fetcher_oracle_test.5.xml")
+		require.Nil(t, err)
+		defer testFile.Close()
+
+		vulnerabilities, err := parseELSA(testFile)
+		require.Nil(t, err)
+		vulnerabilities = withoutAdvisories(vulnerabilities)
+		return vulnerabilities
+	}
+
+	// Ksplice packages are ignored by default.
+	ignoredCriterions = enabledIgnoreRules("")
+	assert.Empty(t, parse())
+
+	ignoredCriterions = enabledIgnoreRules(" Ksplice, unknown")
+	assert.Equal(t, []string{" is signed with the Oracle Linux"}, ignoredCriterions)
+
+	vulnerabilities := parse()
+	if assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2019-25013", vulnerabilities[0].Name)
+		assert.ElementsMatch(t, []database.AffectedFeature{
+			{
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:7", VersionFormat: rpm.ParserName},
+				FeatureName:     "glibc",
+				AffectedVersion: "2:2.17-317.0.3.ksplice1.el7",
+				FixedInVersion:  "2:2.17-317.0.3.ksplice1.el7",
+			},
+			{
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:7", VersionFormat: rpm.ParserName},
+				FeatureName:     "glibc-common",
+				AffectedVersion: "2:2.17-317.0.3.ksplice1.el7",
+				FixedInVersion:  "2:2.17-317.0.3.ksplice1.el7",
+			},
+		}, vulnerabilities[0].Affected)
+	}
+}
+
+func TestELSAParserKspliceNamespace(t *testing.T) {
+	defer func(mode string) { kspliceMode = mode }(kspliceMode)
+
+	parse := func() []database.VulnerabilityWithAffected {
+		testFile, err := os.Open("testdata/fetcher_oracle_test.11.xml")
+		require.Nil(t, err)
+		defer testFile.Close()
+
+		vulnerabilities, err := parseELSA(testFile)
+		require.Nil(t, err)
+		vulnerabilities = withoutAdvisories(vulnerabilities)
+		require.Len(t, vulnerabilities, 1)
+		return vulnerabilities
+	}
+
+	openssl := database.AffectedFeature{
+		FeatureType:     affectedType,
+		Namespace:       database.Namespace{Name: "oracle:8", VersionFormat: rpm.ParserName},
+		FeatureName:     "openssl",
+		AffectedVersion: "1:1.1.1g-15.el8_3",
+		FixedInVersion:  "1:1.1.1g-15.el8_3",
+	}
+
+	// Ksplice packages are ignored by default.
+	kspliceMode = kspliceIgnore
+	assert.Equal(t, []database.AffectedFeature{openssl}, parse()[0].Affected)
+
+	// They are reported in the Ksplice namespace of their release when
+	// enabled, along with the other packages in their usual namespace.
+	kspliceMode = kspliceNamespace
+	assert.ElementsMatch(t, []database.AffectedFeature{
+		openssl,
+		{
+			FeatureType:     affectedType,
+			Namespace:       database.Namespace{Name: "oracle-ksplice:8", VersionFormat: rpm.ParserName},
+			FeatureName:     "openssl-libs",
+			AffectedVersion: "1:1.1.1g-15.ksplice1.el8_3",
+			FixedInVersion:  "1:1.1.1g-15.ksplice1.el8_3",
+		},
+	}, parse()[0].Affected)
+
+	var u vulnsrc.Updater = &updater{}
+	assert.Equal(t, []string{
+		"oracle:5", "oracle:6", "oracle:7", "oracle:8", "oracle:9",
+		"oracle-ksplice:5", "oracle-ksplice:6", "oracle-ksplice:7", "oracle-ksplice:8", "oracle-ksplice:9",
+	}, vulnsrc.UpdaterNamespaces(u))
+}
+
+func TestParseKspliceMode(t *testing.T) {
+	for value, expected := range map[string]string{
+		"ignore":       kspliceIgnore,
+		" Namespace\n": kspliceNamespace,
+		"report":       kspliceIgnore,
+		"":             kspliceIgnore,
+	} {
+		assert.Equal(t, expected, parseKspliceMode(value), "value %q", value)
+	}
+}
+
+func TestELSAParserTestRef(t *testing.T) {
+	testFile, err := os.Open("testdata/fetcher_oracle_test.6.xml")
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	// The packages and versions come from the tests referenced by the
+	// criterions, whatever their comments say. The comment is only parsed
+	// when the test cannot be resolved.
+	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, "CVE-2020-8625", vulnerabilities[0].Name)
+
+		affected := func(name, version string) database.AffectedFeature {
+			return database.AffectedFeature{
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:8", VersionFormat: rpm.ParserName},
+				FeatureName:     name,
+				AffectedVersion: version,
+				FixedInVersion:  version,
+			}
+		}
+
+		assert.ElementsMatch(t, []database.AffectedFeature{
+			affected("bind", "32:9.11.20-5.el8_3.1"),
+			affected("bind-libs", "32:9.11.20-5.el8_3.1"),
+			affected("bind-export-libs", "32:9.11.20-5.el8_3.2"),
+			affected("bind-utils", "32:9.11.20-5.el8_3.1"),
+		}, vulnerabilities[0].Affected)
+	}
+}
+
+func TestELSAParserApplicabilityCheck(t *testing.T) {
+	testFile, err := os.Open("testdata/fetcher_oracle_test.10.xml")
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	// The kernel and glibc criterions are applicability checks, which gate
+	// the definition without being affected packages, and the release one
+	// still tells the release.
+	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		affected := func(name string) database.AffectedFeature {
+			return database.AffectedFeature{
+				FeatureType:     affectedType,
+				Namespace:       database.Namespace{Name: "oracle:8", VersionFormat: rpm.ParserName},
+				FeatureName:     name,
+				AffectedVersion: "1:1.1.1g-15.el8_3",
+				FixedInVersion:  "1:1.1.1g-15.el8_3",
+			}
+		}
+
+		assert.ElementsMatch(t, []database.AffectedFeature{
+			affected("openssl"),
+			affected("openssl-libs"),
+		}, vulnerabilities[0].Affected)
+	}
+}
+
+func TestParseFailureLog(t *testing.T) {
+	var diagnostics bytes.Buffer
+	vulnsrc.SetParseFailureLog(&diagnostics)
+	defer vulnsrc.SetParseFailureLog(nil)
+
+	// The criterions of the unknown package are reported in full.
+	criterions := []criterion{
+		{TestRef: "oval:com.oracle.elsa:tst:20200001001", Comment: "Oracle Linux 8 is installed"},
+		{TestRef: "oval:com.oracle.elsa:tst:20200001002", Comment: "something unexpected"},
+	}
+	features, err := toFeatures(criteria{Operator: "AND", Criterions: criterions}, []int{8}, nil)
+	require.Nil(t, err)
+	assert.Empty(t, features)
+
+	var diagnostic struct {
+		Message    string      `json:"msg"`
+		Source     string      `json:"source"`
+		Criterions []criterion `json:"criterions"`
+	}
+	require.Nil(t, json.Unmarshal(diagnostics.Bytes(), &diagnostic))
+	assert.Equal(t, "could not determine a valid package from criterions", diagnostic.Message)
+	assert.Equal(t, "oracle", diagnostic.Source)
+	assert.Equal(t, criterions, diagnostic.Criterions)
+}
+
+func TestGetPossibilitiesMax(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/fetcher_oracle_test.7.xml")
+	require.Nil(t, err)
+
+	var ov oval
+	require.Nil(t, xml.Unmarshal(content, &ov))
+	require.Len(t, ov.Definitions, 1)
+
+	// The criteria compose 8^6 possibilities, which exceed the cap: the
+	// definition is skipped.
+	_, err = getPossibilities(ov.Definitions[0].Criteria)
+	assert.Equal(t, errTooManyPossibilities, err)
+
+	vulnerabilities, err := parseELSA(bytes.NewReader(content))
+	assert.Nil(t, err)
+	assert.Empty(t, vulnerabilities)
+
+	// The deeply nested criteria compose 4^4^4 possibilities.
+	_, err = getPossibilities(nestedCriteria(4, 4))
+	assert.Equal(t, errTooManyPossibilities, err)
+
+	possibilities, err := getPossibilities(nestedCriteria(3, 4))
+	assert.Nil(t, err)
+	assert.Len(t, possibilities, 1024)
+
+	// The cap is configurable.
+	defer func(max int) { maxPossibilities = max }(maxPossibilities)
+	maxPossibilities = 1000
+	_, err = getPossibilities(nestedCriteria(3, 4))
+	assert.Equal(t, errTooManyPossibilities, err)
+
+	maxPossibilities = 8 * 8 * 8 * 8 * 8 * 8
+	vulnerabilities, err = parseELSA(bytes.NewReader(content))
+	if assert.Nil(t, err) && assert.Len(t, withoutAdvisories(vulnerabilities), 1) {
+		assert.Equal(t, "CVE-2021-9999", vulnerabilities[0].Name)
+	}
+}
+
+func TestParseMaxPossibilities(t *testing.T) {
+	assert.Equal(t, 100, parseMaxPossibilities("100"))
+	assert.Equal(t, defaultMaxPossibilities, parseMaxPossibilities("0"))
+	assert.Equal(t, defaultMaxPossibilities, parseMaxPossibilities("-1"))
+	assert.Equal(t, defaultMaxPossibilities, parseMaxPossibilities("many"))
+}
+
+func TestReleases(t *testing.T) {
+	def := definition{Platforms: []string{"Oracle Linux 5", "Oracle Linux 7", "Oracle Linux 7.9", "Red Hat Enterprise Linux 7"}}
+	assert.Equal(t, []int{5, 7}, releases(def))
+	assert.Empty(t, releases(definition{}))
+}
+
+func TestMajorRelease(t *testing.T) {
+	var table = []struct {
+		comment  string
+		expected int
+		valid    bool
+	}{
+		{"Oracle Linux 7 is installed", 7, true},
+		{"Oracle Linux 7.9 is installed", 7, true},
+		{"Oracle Linux 8.2 is installed", 8, true},
+		{"Oracle Linux is installed", 0, false},
+		{"Red Hat Enterprise Linux 7 is installed", 0, false},
+	}
+
+	for _, tt := range table {
+		release, err := majorRelease(tt.comment)
+		assert.Equal(t, tt.valid, err == nil, tt.comment)
+		assert.Equal(t, tt.expected, release, tt.comment)
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	for impact, expected := range map[string]database.Severity{
+		"n/a":       database.NegligibleSeverity,
+		"None":      database.NegligibleSeverity,
+		"":          database.NegligibleSeverity,
+		"  ":        database.NegligibleSeverity,
+		" none\n":   database.NegligibleSeverity,
+		"Low":       database.LowSeverity,
+		"MODERATE":  database.MediumSeverity,
+		"high":      database.HighSeverity,
+		"Important": database.HighSeverity,
+		"Critical":  database.CriticalSeverity,
+		"unheard":   database.UnknownSeverity,
+	} {
+		assert.Equal(t, expected, severity(impact), "impact %q", impact)
+	}
+}
+
+func TestSeverityFallback(t *testing.T) {
+	defer func(severity database.Severity) { unknownSeverity = severity }(unknownSeverity)
+	unknownSeverity = parseUnknownSeverity("medium")
+
+	assert.Equal(t, database.MediumSeverity, severity("unheard"))
+	assert.Equal(t, database.LowSeverity, severity("Low"))
+	assert.Equal(t, database.NegligibleSeverity, severity("n/a"))
+}
+
+func TestParseUnknownSeverity(t *testing.T) {
+	for value, expected := range map[string]database.Severity{
+		"Unknown":  database.UnknownSeverity,
+		"Medium":   database.MediumSeverity,
+		" high\n":  database.HighSeverity,
+		"moderate": database.UnknownSeverity,
+		"":         database.UnknownSeverity,
+	} {
+		assert.Equal(t, expected, parseUnknownSeverity(value), "value %q", value)
+	}
+}
+
 func TestELSAComparison(t *testing.T) {
 	var table = []struct {
 		left     int
@@ -125,3 +675,667 @@ func TestELSAComparison(t *testing.T) {
 		assert.Equal(t, tt.expected, compareELSA(tt.left, tt.right))
 	}
 }
+
+func TestFirstELSA(t *testing.T) {
+	var table = []struct {
+		flag     string
+		baseline string
+		expected int
+	}{
+		// Without stored flag, the configured baseline is used.
+		{"", "20190999", 20190999},
+		{"", strconv.Itoa(firstOracle5ELSA), firstOracle5ELSA},
+		{"", "invalid", firstOracle5ELSA},
+		{"", "0", firstOracle5ELSA},
+
+		// The stored flag takes precedence over the baseline.
+		{"20200123", "20190999", 20200123},
+		{"0", "20190999", 20190999},
+	}
+
+	for _, tt := range table {
+		assert.Equal(t, tt.expected, firstELSA(tt.flag, tt.baseline), tt.flag+"/"+tt.baseline)
+	}
+}
+
+func TestFetchELSAChecksum(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), "testdata", "fetcher_oracle_test.1.xml"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	digest := sha256.Sum256(content)
+	checksum := hex.EncodeToString(digest[:]) + "  com.oracle.elsa-20150001.xml\n"
+
+	files := map[string]string{
+		"/oval/com.oracle.elsa-20150001.xml":             string(content),
+		"/checksums/com.oracle.elsa-20150001.xml.sha256": checksum,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if file, ok := files[r.URL.Path]; ok {
+			w.Write([]byte(file))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	// A good body is parsed.
+	vulnerabilities, err := fetchELSA(server.URL+"/oval/", server.URL+"/checksums/", "", "", 20150001)
+	if assert.Nil(t, err) {
+		assert.Len(t, vulnerabilities, 2)
+	}
+
+	// A tampered body is rejected before parsing, unless there is no checksum
+	// source configured.
+	files["/oval/com.oracle.elsa-20150001.xml"] = string(content) + "<garbage/>"
+	_, err = fetchELSA(server.URL+"/oval/", server.URL+"/checksums/", "", "", 20150001)
+	assert.Equal(t, errChecksumMismatch, err)
+
+	files["/oval/com.oracle.elsa-20150001.xml"] = string(content)
+	_, err = fetchELSA(server.URL+"/oval/", "", "", "", 20150001)
+	assert.Nil(t, err)
+}
+
+func TestNamespaces(t *testing.T) {
+	var u vulnsrc.Updater = &updater{}
+	assert.Equal(t, []string{"oracle:5", "oracle:6", "oracle:7", "oracle:8", "oracle:9"}, vulnsrc.UpdaterNamespaces(u))
+}
+
+func TestNamespacePrefix(t *testing.T) {
+	defer func(prefix string) { namespacePrefix = prefix }(namespacePrefix)
+	namespacePrefix = "tenantA/"
+
+	testFile, err := os.Open("testdata/fetcher_oracle_test.3.xml")
+	require.Nil(t, err)
+	defer testFile.Close()
+
+	vulnerabilities, err := parseELSA(testFile)
+	vulnerabilities = withoutAdvisories(vulnerabilities)
+	if assert.Nil(t, err) && assert.Len(t, vulnerabilities, 1) {
+		if assert.Len(t, vulnerabilities[0].Affected, 3) {
+			for _, affected := range vulnerabilities[0].Affected {
+				assert.Equal(t, database.Namespace{Name: "tenantA/oracle:7", VersionFormat: rpm.ParserName}, affected.Namespace)
+			}
+		}
+	}
+
+	// The listed namespaces are the ones of the advisories.
+	var u vulnsrc.Updater = &updater{}
+	assert.Equal(t, []string{"tenantA/oracle:5", "tenantA/oracle:6", "tenantA/oracle:7", "tenantA/oracle:8", "tenantA/oracle:9"}, vulnsrc.UpdaterNamespaces(u))
+}
+
+func TestFetchELSAsSkipsMissingFiles(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), "testdata", "fetcher_oracle_test.1.xml"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var status = http.StatusNotFound
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oval/com.oracle.elsa-20150001.xml", "/oval/com.oracle.elsa-20150003.xml":
+			w.Write(content)
+		default:
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	// An ELSA file removed upstream is skipped, the others are processed.
+	vulnerabilities, err := fetchELSAs(server.URL+"/oval/", "", "", "", []int{20150001, 20150002, 20150003})
+	if assert.Nil(t, err) {
+		assert.Len(t, vulnerabilities, 4)
+	}
+
+	// Server errors abort fetching.
+	status = http.StatusBadGateway
+	_, err = fetchELSAs(server.URL+"/oval/", "", "", "", []int{20150001, 20150002, 20150003})
+	assert.True(t, errors.Is(err, commonerr.ErrCouldNotDownload))
+	assert.True(t, commonerr.Retryable(err))
+
+	// Connection errors abort fetching.
+	server.Close()
+	_, err = fetchELSAs(server.URL+"/oval/", "", "", "", []int{20150001})
+	assert.True(t, errors.Is(err, commonerr.ErrCouldNotDownload))
+}
+
+func TestFetchELSAListDeduplicates(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), "testdata", "fetcher_oracle_test.1.xml"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	index := `<a href="com.oracle.elsa-20150003.xml">com.oracle.elsa-20150003.xml</a>
+<a href="com.oracle.elsa-20150001.xml">com.oracle.elsa-20150001.xml</a>
+<a href="com.oracle.elsa-20150003.xml">com.oracle.elsa-20150003.xml</a>
+<a href="com.oracle.elsa-20140001.xml">com.oracle.elsa-20140001.xml</a>
+<a href="com.oracle.elsa-20150002.xml">com.oracle.elsa-20150002.xml</a>
+<a href="com.oracle.elsa-20150001.xml">com.oracle.elsa-20150001.xml</a>
+`
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+
+		if r.URL.Path == "/oval/" {
+			w.Write([]byte(index))
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	elsaList, err := fetchELSAList(server.URL+"/oval/", 20140001)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []int{20150001, 20150002, 20150003}, elsaList)
+
+	_, err = fetchELSAs(server.URL+"/oval/", "", "", "", elsaList)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{
+		"/oval/":                             1,
+		"/oval/com.oracle.elsa-20150001.xml": 1,
+		"/oval/com.oracle.elsa-20150002.xml": 1,
+		"/oval/com.oracle.elsa-20150003.xml": 1,
+	}, hits)
+}
+
+func TestSelectMirror(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/fetcher_oracle_test.1.xml")
+	require.Nil(t, err)
+
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	serve := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name+" "+r.URL.Path]++
+			mu.Unlock()
+
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+
+			if r.URL.Path == "/oval/" {
+				w.Write([]byte(`<a href="com.oracle.elsa-20150001.xml">com.oracle.elsa-20150001.xml</a>`))
+				return
+			}
+			w.Write(content)
+		}))
+	}
+
+	failing := serve("failing", http.StatusServiceUnavailable)
+	defer failing.Close()
+	working := serve("working", http.StatusOK)
+	defer working.Close()
+	fallback := serve("fallback", http.StatusOK)
+	defer fallback.Close()
+
+	// The unreachable mirror and the failing one are skipped, and the ELSA
+	// files are downloaded from the mirror serving the update list.
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	mirror, elsaList, err := selectMirror([]string{unreachable.URL + "/oval/", failing.URL + "/oval/", working.URL + "/oval/", fallback.URL + "/oval/"})
+	require.Nil(t, err)
+	assert.Equal(t, working.URL+"/oval/", mirror)
+	assert.Equal(t, []int{20150001}, elsaList)
+
+	vulnerabilities, err := fetchELSAs(mirror, "", "", "", elsaList)
+	require.Nil(t, err)
+	assert.Len(t, vulnerabilities, 2)
+	assert.Equal(t, map[string]int{
+		"failing /oval/": 1,
+		"working /oval/": 1,
+		"working /oval/com.oracle.elsa-20150001.xml": 1,
+	}, hits)
+
+	// The error of the last mirror is returned when every mirror fails.
+	_, _, err = selectMirror([]string{unreachable.URL + "/oval/", failing.URL + "/oval/"})
+	var derr *commonerr.DownloadError
+	if assert.True(t, errors.As(err, &derr)) {
+		assert.Equal(t, http.StatusServiceUnavailable, derr.StatusCode)
+	}
+
+	// An update list which cannot be parsed fails without trying the next
+	// mirror.
+	defer func(size int) { maxIndexLineSize = size }(maxIndexLineSize)
+	maxIndexLineSize = 16
+
+	hits = make(map[string]int)
+	_, _, err = selectMirror([]string{working.URL + "/oval/", fallback.URL + "/oval/"})
+	assert.True(t, errors.Is(err, commonerr.ErrCouldNotParse))
+	assert.Equal(t, map[string]int{"working /oval/": 1}, hits)
+}
+
+func TestParseMirrors(t *testing.T) {
+	assert.Equal(t, []string{ovalURI}, parseMirrors(""))
+	assert.Equal(t, []string{ovalURI}, parseMirrors(" , "))
+	assert.Equal(t, []string{"https://mirror.example.com/oval/", ovalURI}, parseMirrors("https://mirror.example.com/oval, "+ovalURI))
+}
+
+func TestLink(t *testing.T) {
+	defer func(sources []string) { linkSources = sources }(linkSources)
+
+	def := definition{References: []reference{
+		{Source: "CVE", URI: "https://linux.oracle.com/cve/CVE-2019-0001.html"},
+		{Source: "CVE", URI: "https://linux.oracle.com/cve/CVE-2019-0002.html"},
+		{Source: "elsa", URI: "https://linux.oracle.com/errata/ELSA-2019-0001.html"},
+		{Source: "bugzilla", URI: "https://bugzilla.oracle.com/show_bug.cgi?id=1"},
+	}}
+
+	for _, test := range []struct {
+		sources  string
+		expected string
+	}{
+		{"", "https://linux.oracle.com/errata/ELSA-2019-0001.html"},
+		{"elsa", "https://linux.oracle.com/errata/ELSA-2019-0001.html"},
+		{"cve,elsa", "https://linux.oracle.com/cve/CVE-2019-0001.html"},
+		{"bugzilla, cve", "https://bugzilla.oracle.com/show_bug.cgi?id=1"},
+		{"redhat,elsa", "https://linux.oracle.com/errata/ELSA-2019-0001.html"},
+		{"redhat", ""},
+	} {
+		linkSources = parseLinkSources(test.sources)
+		assert.Equal(t, test.expected, link(def, ""), "sources %q", test.sources)
+	}
+
+	// The link falls back to the next source the advisory references.
+	linkSources = parseLinkSources("cve,elsa")
+	assert.Equal(t, "https://linux.oracle.com/errata/ELSA-2019-0001.html", link(definition{References: def.References[2:]}, ""))
+}
+
+func TestELSAParserLinkSources(t *testing.T) {
+	defer func(sources []string) { linkSources = sources }(linkSources)
+
+	for _, test := range []struct {
+		sources  string
+		expected []string
+	}{
+		{"", []string{
+			"http://linux.oracle.com/errata/ELSA-2015-1193.html",
+			"http://linux.oracle.com/errata/ELSA-2015-1193.html",
+		}},
+		// Each CVE links to its own page rather than to the first CVE.
+		{"cve,elsa", []string{
+			"http://linux.oracle.com/cve/CVE-2015-0252.html",
+			"http://linux.oracle.com/cve/CVE-2016-0729.html",
+		}},
+		{"bugzilla,elsa,cve", []string{
+			"http://linux.oracle.com/errata/ELSA-2015-1193.html",
+			"http://linux.oracle.com/errata/ELSA-2015-1193.html",
+		}},
+	} {
+		linkSources = parseLinkSources(test.sources)
+
+		testFile, err := os.Open("testdata/fetcher_oracle_test.12.xml")
+		require.Nil(t, err)
+		vulnerabilities, err := parseELSA(testFile)
+		testFile.Close()
+
+		require.Nil(t, err)
+		vulnerabilities = withoutAdvisories(vulnerabilities)
+		if assert.Len(t, vulnerabilities, 2) {
+			assert.Equal(t, "CVE-2015-0252", vulnerabilities[0].Name)
+			assert.Equal(t, "CVE-2016-0729", vulnerabilities[1].Name)
+			assert.Equal(t, test.expected, []string{vulnerabilities[0].Link, vulnerabilities[1].Link}, "sources %q", test.sources)
+		}
+	}
+}
+
+func TestFetchELSAListSingleLine(t *testing.T) {
+	// The index is served as a single line, longer than the default scanner
+	// buffer.
+	var index strings.Builder
+	for i := 1; i <= 5000; i++ {
+		fmt.Fprintf(&index, `<a href="com.oracle.elsa-2015%04d.xml">com.oracle.elsa-2015%04d.xml</a>`, i, i)
+	}
+	require.True(t, index.Len() > bufio.MaxScanTokenSize)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(index.String()))
+	}))
+	defer server.Close()
+
+	elsaList, err := fetchELSAList(server.URL+"/oval/", 20150000)
+	require.Nil(t, err)
+	if assert.Len(t, elsaList, 5000) {
+		assert.Equal(t, 20150001, elsaList[0])
+		assert.Equal(t, 20155000, elsaList[4999])
+	}
+}
+
+func TestFetchELSAListTruncated(t *testing.T) {
+	// The connection is closed before the announced index is fully sent.
+	index := `<a href="com.oracle.elsa-20150001.xml">com.oracle.elsa-20150001.xml</a>
+<a href="com.oracle.elsa-20150002.xml">com.oracle.elsa-20150002.xml</a>
+`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(10*len(index)))
+		w.Write([]byte(index))
+	}))
+	defer server.Close()
+
+	elsaList, err := fetchELSAList(server.URL+"/oval/", 20140001)
+	assert.True(t, errors.Is(err, commonerr.ErrCouldNotDownload), "%v", err)
+	assert.Empty(t, elsaList)
+}
+
+func TestFetchELSACache(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	content, err := ioutil.ReadFile(filepath.Join(filepath.Dir(filename), "testdata", "fetcher_oracle_test.1.xml"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "oracle-cache")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	etag := `"v1"`
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.Method]++
+		mu.Unlock()
+
+		w.Header().Set("ETag", etag)
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	// A cache miss downloads the ELSA file and caches it.
+	vulnerabilities, err := fetchELSA(server.URL+"/oval/", "", "", dir, 20150001)
+	if assert.Nil(t, err) {
+		assert.Len(t, vulnerabilities, 2)
+	}
+	assert.Equal(t, map[string]int{"HEAD": 1, "GET": 1}, hits)
+
+	// A cache hit doesn't download the ELSA file again.
+	cached, err := fetchELSA(server.URL+"/oval/", "", "", dir, 20150001)
+	if assert.Nil(t, err) && assert.Len(t, cached, 2) {
+		// Affected features are in no particular order.
+		assert.Equal(t, vulnerabilities[0].Vulnerability, cached[0].Vulnerability)
+		assert.ElementsMatch(t, vulnerabilities[0].Affected, cached[0].Affected)
+	}
+	assert.Equal(t, map[string]int{"HEAD": 2, "GET": 1}, hits)
+
+	// A changed ELSA file is downloaded again and replaces the cached one.
+	etag = `"v2"`
+	_, err = fetchELSA(server.URL+"/oval/", "", "", dir, 20150001)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"HEAD": 3, "GET": 2}, hits)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{cacheFile(dir, 20150001, `"v2"`)}, files)
+	}
+}
+
+// largeELSA returns a realistic ELSA file, like the ones of the kernel, which
+// fixes the given number of packages on Oracle Linux 6, 7 and 8.
+func largeELSA(packages int) []byte {
+	var b strings.Builder
+	b.WriteString(`<oval_definitions xmlns="http://oval.mitre.org/XMLSchema/oval-definitions-5" xmlns:oval="http://oval.mitre.org/XMLSchema/oval-common-5">
+<definitions>
+<definition id="oval:com.oracle.elsa:def:20219998" version="501" class="patch">
+<metadata>
+<title>ELSA-2021-9998:  kernel security update (IMPORTANT)</title>
+<affected family="unix">
+<platform>Oracle Linux 6</platform>
+<platform>Oracle Linux 7</platform>
+<platform>Oracle Linux 8</platform>
+</affected>
+<reference source="elsa" ref_id="ELSA-2021-9998" ref_url="http://linux.oracle.com/errata/ELSA-2021-9998.html"/>
+`)
+	b.WriteString(`<description>kernel security update</description>
+<advisory>
+<severity>IMPORTANT</severity>
+<issued date="2021-03-01"/>
+`)
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&b, "<cve href=\"http://linux.oracle.com/cve/CVE-2021-%d.html\">CVE-2021-%d</cve>\n", 1000+i, 1000+i)
+	}
+	b.WriteString(`</advisory>
+</metadata>
+<criteria operator="OR">
+`)
+	test := 0
+	for _, release := range []int{6, 7, 8} {
+		test++
+		fmt.Fprintf(&b, "<criteria operator=\"AND\">\n<criterion test_ref=\"oval:com.oracle.elsa:tst:2021999800%d\" comment=\"Oracle Linux %d is installed\"/>\n<criteria operator=\"OR\">\n", test, release)
+		for p := 0; p < packages; p++ {
+			test++
+			fmt.Fprintf(&b, "<criteria operator=\"AND\">\n<criterion test_ref=\"oval:com.oracle.elsa:tst:2021999800%d\" comment=\"kernel-package-%d is earlier than 0:4.18.0-240.el%d\"/>\n", test, p, release)
+			test++
+			fmt.Fprintf(&b, "<criterion test_ref=\"oval:com.oracle.elsa:tst:2021999800%d\" comment=\"kernel-package-%d is signed with the Oracle Linux %d key\"/>\n</criteria>\n", test, p, release)
+		}
+		b.WriteString("</criteria>\n</criteria>\n")
+	}
+	b.WriteString("</criteria>\n</definition>\n</definitions>\n</oval_definitions>\n")
+
+	return []byte(b.String())
+}
+
+// nestedCriteria returns a criteria tree of the given depth, alternating OR
+// and AND nodes which have the given number of children each.
+func nestedCriteria(depth, width int) criteria {
+	operator := "OR"
+	if depth%2 == 0 {
+		operator = "AND"
+	}
+
+	node := criteria{Operator: operator}
+	for i := 0; i < width; i++ {
+		if depth == 1 {
+			node.Criterions = append(node.Criterions, criterion{Comment: fmt.Sprintf("package-%d is earlier than 0:1.0-%d", i, i)})
+		} else {
+			child := nestedCriteria(depth-1, width)
+			node.Criterias = append(node.Criterias, &child)
+		}
+	}
+
+	return node
+}
+
+func BenchmarkParseELSA(b *testing.B) {
+	content := largeELSA(100)
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		vulnerabilities, err := parseELSA(bytes.NewReader(content))
+		if err != nil || len(vulnerabilities) != 50 {
+			b.Fatal("could not parse the ELSA", err)
+		}
+	}
+}
+
+func BenchmarkGetPossibilities(b *testing.B) {
+	for _, bench := range []struct{ depth, width int }{
+		{2, 4}, {3, 4}, {4, 4}, {5, 4},
+	} {
+		node := nestedCriteria(bench.depth, bench.width)
+		b.Run(fmt.Sprintf("depth=%d/width=%d", bench.depth, bench.width), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				getPossibilities(node)
+			}
+		})
+	}
+
+	b.Run("worst-case", func(b *testing.B) {
+		content, err := ioutil.ReadFile("testdata/fetcher_oracle_test.7.xml")
+		require.Nil(b, err)
+
+		var ov oval
+		require.Nil(b, xml.Unmarshal(content, &ov))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			getPossibilities(ov.Definitions[0].Criteria)
+		}
+	})
+}
+
+func TestELSASet(t *testing.T) {
+	elsas := []int{20190001, 20190002, 20190003, 20190005, 20200001, 20200002}
+	assert.Equal(t, "20190001-20190003,20190005,20200001-20200002", formatELSASet(elsas))
+	assert.Equal(t, elsas, parseELSASet(formatELSASet(elsas)))
+	assert.Equal(t, "", formatELSASet(nil))
+	assert.Nil(t, parseELSASet(""))
+
+	// Invalid ranges are skipped.
+	assert.Equal(t, []int{20190005}, parseELSASet("20190003-20190001,nope,20190005"))
+}
+
+func TestRetractedELSAs(t *testing.T) {
+	previous := []int{20190001, 20190002, 20190003, 20190004}
+
+	// The ELSAs after the last one listed are ignored, as a lagging mirror
+	// doesn't list them yet.
+	assert.Equal(t, []int{20190002}, retractedELSAs(previous, []int{20190001, 20190003}))
+	assert.Nil(t, retractedELSAs(previous, previous))
+	assert.Nil(t, retractedELSAs(previous, nil))
+	assert.Nil(t, retractedELSAs(nil, []int{20190001}))
+}
+
+func TestWithdrawnVulnerabilities(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/fetcher_oracle_test.1.xml")
+	require.Nil(t, err)
+
+	// The file of the retracted ELSA 20150001 is still served, but not the
+	// one of the retracted ELSA 20150002.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/oval/com.oracle.elsa-20150001.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	vulnerabilities, err := parseELSA(bytes.NewReader(content))
+	require.Nil(t, err)
+	require.Len(t, vulnerabilities, 2)
+
+	// The advisory is stored along with its CVE.
+	advisory := vulnerabilities[1]
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{advisory.Namespace}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{advisory}))
+	require.Nil(t, tx.Commit())
+
+	persist := func(description string, replace bool) {
+		vulnerability := vulnerabilities[0]
+		vulnerability.Namespace = vulnerability.Affected[0].Namespace
+		vulnerability.Description = description
+
+		tx, err := store.Begin()
+		require.Nil(t, err)
+		require.Nil(t, tx.PersistNamespaces([]database.Namespace{vulnerability.Namespace}))
+		if replace {
+			require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: vulnerability.Name, Namespace: vulnerability.Namespace.Name}}))
+		}
+		require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+		require.Nil(t, tx.Commit())
+	}
+
+	// The vulnerability last written by the retracted ELSA is withdrawn.
+	persist(vulnerabilities[0].Description, false)
+	withdrawn, err := withdrawnVulnerabilities(store, server.URL+"/oval/", []int{20150001, 20150002})
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []database.VulnerabilityID{
+		{Name: "CVE-2015-0252", Namespace: "oracle:7"},
+		{Name: "ELSA-2015-1193", Namespace: "oracle:7"},
+	}, withdrawn)
+
+	// The vulnerability written by a later advisory is kept, unlike the
+	// retracted advisory.
+	persist("fixed again by a later advisory", true)
+	withdrawn, err = withdrawnVulnerabilities(store, server.URL+"/oval/", []int{20150001})
+	require.Nil(t, err)
+	assert.Equal(t, []database.VulnerabilityID{{Name: "ELSA-2015-1193", Namespace: "oracle:7"}}, withdrawn)
+
+	withdrawn, err = withdrawnVulnerabilities(store, server.URL+"/oval/", nil)
+	require.Nil(t, err)
+	assert.Empty(t, withdrawn)
+}
+
+func TestWithdrawnVulnerabilitiesNotFound(t *testing.T) {
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = ""
+
+	// The file of the retracted ELSA isn't served anymore, nor cached.
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer store.Close()
+
+	content, err := ioutil.ReadFile("testdata/fetcher_oracle_test.1.xml")
+	require.Nil(t, err)
+	vulnerabilities, err := parseELSA(bytes.NewReader(content))
+	require.Nil(t, err)
+	require.Len(t, vulnerabilities, 2)
+
+	advisory := vulnerabilities[1]
+	persist := func(aliases []string, replace bool) {
+		vulnerability := vulnerabilities[0]
+		vulnerability.Namespace = vulnerability.Affected[0].Namespace
+		vulnerability.Aliases = aliases
+
+		tx, err := store.Begin()
+		require.Nil(t, err)
+		require.Nil(t, tx.PersistNamespaces([]database.Namespace{vulnerability.Namespace}))
+		if replace {
+			require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: vulnerability.Name, Namespace: vulnerability.Namespace.Name}}))
+		} else {
+			require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{advisory}))
+		}
+		require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{vulnerability}))
+		require.Nil(t, tx.Commit())
+	}
+
+	// The stored advisory is withdrawn along with the CVE it last wrote.
+	persist(vulnerabilities[0].Aliases, false)
+	withdrawn, err := withdrawnVulnerabilities(store, server.URL+"/oval/", []int{20151193})
+	require.Nil(t, err)
+	assert.ElementsMatch(t, []database.VulnerabilityID{
+		{Name: "CVE-2015-0252", Namespace: "oracle:7"},
+		{Name: "ELSA-2015-1193", Namespace: "oracle:7"},
+	}, withdrawn)
+
+	// The CVE written by a later advisory is kept.
+	persist([]string{"ELSA-2016-0001"}, true)
+	withdrawn, err = withdrawnVulnerabilities(store, server.URL+"/oval/", []int{20151193})
+	require.Nil(t, err)
+	assert.Equal(t, []database.VulnerabilityID{{Name: "ELSA-2015-1193", Namespace: "oracle:7"}}, withdrawn)
+
+	// A retracted ELSA which was never stored withdraws nothing.
+	withdrawn, err = withdrawnVulnerabilities(store, server.URL+"/oval/", []int{20150002})
+	require.Nil(t, err)
+	assert.Empty(t, withdrawn)
+}
+
+func TestElsaName(t *testing.T) {
+	assert.Equal(t, "ELSA-2015-1193", elsaName(20151193))
+	assert.Equal(t, "ELSA-2019-10001", elsaName(201910001))
+}
@@ -50,6 +50,8 @@ const (
 	DefaultLastAdvisoryDate = "1970-01-01"
 	// AdvisoryDateFormat date format for advisory dates ('magical reference date' for datetime format)
 	AdvisoryDateFormat = "2006-01-02"
+	// UpdaterName - name the updater is registered by
+	UpdaterName = "redhat"
 	// UpdaterFlag - key used for flag for updater
 	UpdaterFlag = "redHatUpdater"
 	// UpdaterFlagDateFormat - date format for updater flag dates ('magical reference date' for datetime format)
@@ -70,11 +72,16 @@ var SupportedArches = map[string]bool{"x86_64": true, "noarch": true}
 var SupportedDefinitionTypes = map[string]bool{"patch": true}
 
 func init() {
-	vulnsrc.RegisterUpdater("redhat", &updater{})
+	vulnsrc.RegisterUpdater(UpdaterName, &updater{})
 }
 
 func (u *updater) Clean() {}
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{rpm.ParserName, modulerpm.ParserName}
+}
+
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
 	// all accumulated vulnerabilities for the current updater cycle
 	var accumulatedVulnerabilities = []database.VulnerabilityWithAffected{}
@@ -736,7 +743,7 @@ func IsNewOrUpdatedManifestEntry(manifestEntry ManifestEntry, datastore database
 
 // FetchPulpManifest - fetch the PULP_MANIFEST file, return body as a string
 func FetchPulpManifest(pulpManifestURL string) (string, error) {
-	resp, err := httputil.GetWithUserAgent(pulpManifestURL)
+	resp, err := httputil.GetForUpdater(UpdaterName, pulpManifestURL)
 	if err != nil {
 		log.Error("Unable to fetch pulp manifest, caused by: " + err.Error())
 		return "", err
@@ -799,7 +806,7 @@ func ParsePulpManifestLine(srcManifestLine string) (ManifestEntry, error) {
 
 // ReadBzipOvalFile - decompress and read a bzip2-compressed oval file, return the xml content as string
 func ReadBzipOvalFile(bzipOvalFile string) (string, error) {
-	resp, err := httputil.GetWithUserAgent(bzipOvalFile)
+	resp, err := httputil.GetForUpdater(UpdaterName, bzipOvalFile)
 	if err != nil {
 		log.Error(err)
 		return "", err
@@ -18,10 +18,11 @@ package suse
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"net/http"
+	"io/ioutil"
 	"regexp"
 	"strconv"
 	"strings"
@@ -34,6 +35,9 @@ import (
 	"github.com/quay/clair/v3/ext/versionfmt/rpm"
 	"github.com/quay/clair/v3/ext/vulnsrc"
 	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/envutil"
+	"github.com/quay/clair/v3/pkg/gpgutil"
+	"github.com/quay/clair/v3/pkg/httputil"
 )
 
 const (
@@ -47,6 +51,11 @@ const (
 )
 
 var (
+	// gpgKeyring is the optional public keyring verifying the detached
+	// signatures of the OVAL files. OVAL files are not verified when it is
+	// empty.
+	gpgKeyring = envutil.GetEnv("SUSE_GPG_KEYRING", "")
+
 	ignoredCriterions                  []string
 	suseOpenSUSEInstalledCommentRegexp = regexp.MustCompile(`(SUSE Linux Enterprise |openSUSE ).*is installed`)
 	suseInstalledCommentRegexp         = regexp.MustCompile(`SUSE Linux Enterprise[A-Za-z\s]*? (\d+)[\w\s]*?(SP(\d+))? is installed`)
@@ -87,6 +96,7 @@ const (
 )
 
 type updater struct {
+	UpdaterName   string
 	Name          string
 	NamespaceName string
 	FilePrefix    string
@@ -99,12 +109,14 @@ func newUpdater(f flavor) updater {
 
 	switch f {
 	case SUSE:
+		up.UpdaterName = "suse"
 		up.Name = "SUSE Linux"
 		up.NamespaceName = "sles"
 		up.FilePrefix = "suse.linux.enterprise."
 		up.UpdaterFlag = "SUSEUpdater"
 		up.FileRegexp = regexp.MustCompile(`suse.linux.enterprise.(\d+).xml`)
 	case OpenSUSE:
+		up.UpdaterName = "opensuse"
 		up.Name = "openSUSE"
 		up.NamespaceName = "opensuse"
 		up.FilePrefix = "opensuse.leap."
@@ -120,8 +132,8 @@ func newUpdater(f flavor) updater {
 func init() {
 	suseUpdater := newUpdater(SUSE)
 	openSUSEUpdater := newUpdater(OpenSUSE)
-	vulnsrc.RegisterUpdater("suse", &suseUpdater)
-	vulnsrc.RegisterUpdater("opensuse", &openSUSEUpdater)
+	vulnsrc.RegisterUpdater(suseUpdater.UpdaterName, &suseUpdater)
+	vulnsrc.RegisterUpdater(openSUSEUpdater.UpdaterName, &openSUSEUpdater)
 }
 
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -149,7 +161,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Fetch the update list.
-	r, err := http.Get(ovalURI)
+	r, err := httputil.GetForUpdater(u.UpdaterName, ovalURI)
 	if err != nil {
 		err = fmt.Errorf("Cannot download SUSE update list: %v", err)
 		return resp, err
@@ -176,7 +188,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 		// Do not fetch the entire file to get the value of the
 		// creation time. Rely on the "latest modified time"
 		// value of the file hosted on the remote server.
-		timestamp, err := getLatestModifiedTime(ovalFile)
+		timestamp, err := getLatestModifiedTime(u.UpdaterName, ovalFile)
 		if err != nil {
 			log.WithError(err).WithField("ovalFile", ovalFile).Warning("Ignoring OVAL file")
 		}
@@ -188,7 +200,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 	for _, oval := range ovalFiles {
 		// Download the oval XML file.
-		r, err := http.Get(oval)
+		r, err := httputil.GetForUpdater(u.UpdaterName, oval)
 		if err != nil {
 			log.WithError(err).Error("could not download", u.Name, "update list")
 			return resp, commonerr.ErrCouldNotDownload
@@ -202,8 +214,21 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 		}
 		osVersion := match[1]
 
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.WithError(err).Error("could not download", u.Name, "update list")
+			return resp, commonerr.ErrCouldNotDownload
+		}
+
+		if gpgKeyring != "" {
+			if err := gpgutil.VerifyDownload(u.UpdaterName, gpgKeyring, oval, content); err != nil {
+				log.WithError(err).WithField("ovalFile", oval).Error("skipping ", u.Name, " OVAL file with unverified signature")
+				continue
+			}
+		}
+
 		// Parse the XML.
-		vs, generationTime, err := parseOval(r.Body, u.NamespaceName, osVersion)
+		vs, generationTime, err := parseOval(bytes.NewReader(content), u.NamespaceName, osVersion)
 		if err != nil {
 			return resp, err
 		}
@@ -226,8 +251,8 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 
 // Get the latest modification time of a remote file
 // expressed as unix time
-func getLatestModifiedTime(url string) (int64, error) {
-	resp, err := http.Head(url)
+func getLatestModifiedTime(updater, url string) (int64, error) {
+	resp, err := httputil.HeadForUpdater(updater, url)
 	if err != nil {
 		return 0, err
 	}
@@ -257,6 +282,11 @@ func latest(values []int64) (ret int64) {
 
 func (u *updater) Clean() {}
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{rpm.ParserName}
+}
+
 func parseOval(ovalReader io.Reader, osFlavor, osVersion string) (vulnerabilities []database.VulnerabilityWithAffected, generationTime int64, err error) {
 	// Decode the XML.
 	var ov oval
@@ -417,7 +447,7 @@ func toFeatureVersions(criteria criteria, osFlavor, osVersion string) []database
 		if featureVersion.Namespace.Name != "" && featureVersion.FeatureName != "" && featureVersion.AffectedVersion != "" && featureVersion.FixedInVersion != "" {
 			featureVersionParameters[featureVersion.Namespace.Name+":"+featureVersion.FeatureName] = featureVersion
 		} else {
-			log.WithField("criterions", fmt.Sprintf("%v", criterions)).Warning("could not determine a valid package from criterions")
+			vulnsrc.LogParseFailure(osFlavor+":"+osVersion, log.Fields{"criterions": criterions}, "could not determine a valid package from criterions")
 		}
 	}
 
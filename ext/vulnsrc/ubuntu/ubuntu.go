@@ -18,11 +18,12 @@ package ubuntu
 
 import (
 	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"net/http"
+	"io/ioutil"
 	"regexp"
 	"strconv"
 	"strings"
@@ -35,6 +36,9 @@ import (
 	"github.com/quay/clair/v3/ext/versionfmt/dpkg"
 	"github.com/quay/clair/v3/ext/vulnsrc"
 	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/envutil"
+	"github.com/quay/clair/v3/pkg/gpgutil"
+	"github.com/quay/clair/v3/pkg/httputil"
 )
 
 const (
@@ -46,12 +50,18 @@ const (
 	// timestamp format 2017-10-23T04:07:14
 	timeFormatOVAL = "2006-1-2T15:04:05"
 
+	updaterName = "ubuntu"
 	updaterFlag = "ubuntuUpdater"
 
 	ubuntuOvalFilePrefix = "com.ubuntu."
 )
 
 var (
+	// gpgKeyring is the optional public keyring verifying the detached
+	// signatures of the OVAL files. OVAL files are not verified when it is
+	// empty.
+	gpgKeyring = envutil.GetEnv("UBUNTU_GPG_KEYRING", "")
+
 	ignoredCriterions          []string
 	ubuntuPackageCommentRegexp = regexp.MustCompile(`^(.*) package in ([a-z]+) (?:(?:was vulnerable|is related to the CVE in some way) but has been fixed \(note: '(.*)'\)|is affected and needs fixing).$`)
 	ubuntuOvalFileRegexp       = regexp.MustCompile(`com.ubuntu.([a-z]+).cve.oval.xml.bz2`)
@@ -90,7 +100,7 @@ type criterion struct {
 type updater struct{}
 
 func init() {
-	vulnsrc.RegisterUpdater("ubuntu", &updater{})
+	vulnsrc.RegisterUpdater(updaterName, &updater{})
 }
 
 func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateResponse, err error) {
@@ -123,7 +133,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 	}
 
 	// Fetch the update list.
-	r, err := http.Get(ovalURI)
+	r, err := httputil.GetForUpdater(updaterName, ovalURI)
 	if err != nil {
 		err = fmt.Errorf("Cannot download Ubuntu update list: %v", err)
 		return resp, err
@@ -174,15 +184,28 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 			"updater":  "Ubuntu Linux",
 		}).Debug("downloading")
 		// Download the oval XML file.
-		r, err := http.Get(oval)
+		r, err := httputil.GetForUpdater(updaterName, oval)
 		if err != nil {
 			log.WithError(err).Error("could not download Ubuntu update list")
 			return resp, commonerr.ErrCouldNotDownload
 		}
 		defer r.Body.Close()
 
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			log.WithError(err).Error("could not download Ubuntu update list")
+			return resp, commonerr.ErrCouldNotDownload
+		}
+
+		if gpgKeyring != "" {
+			if err := gpgutil.VerifyDownload(updaterName, gpgKeyring, oval, content); err != nil {
+				log.WithError(err).WithField("ovalFile", oval).Error("skipping Ubuntu OVAL file with unverified signature")
+				continue
+			}
+		}
+
 		// Parse the XML.
-		vs, generationTime, err := parseOval(bzip2.NewReader(r.Body))
+		vs, generationTime, err := parseOval(bzip2.NewReader(bytes.NewReader(content)))
 		if err != nil {
 			return resp, err
 		}
@@ -205,7 +228,7 @@ func (u *updater) Update(datastore database.Datastore) (resp vulnsrc.UpdateRespo
 // Get the latest modification time of a remote file
 // expressed as unix time
 func getLatestModifiedTime(url string) (int64, error) {
-	resp, err := http.Head(url)
+	resp, err := httputil.HeadForUpdater(updaterName, url)
 	if err != nil {
 		return 0, err
 	}
@@ -235,6 +258,11 @@ func latest(values []int64) (ret int64) {
 
 func (u *updater) Clean() {}
 
+// VersionFormats implements vulnsrc.VersionFormatLister.
+func (u *updater) VersionFormats() []string {
+	return []string{dpkg.ParserName}
+}
+
 func parseOval(ovalReader io.Reader) (vulnerabilities []database.VulnerabilityWithAffected, generationTime int64, err error) {
 	// Decode the XML.
 	var ov oval
@@ -0,0 +1,68 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/timeutil"
+)
+
+// JanitorConfig is the configuration for the janitor service.
+type JanitorConfig struct {
+	// Interval is the duration between two sweeps of the expired key/value
+	// pairs and locks. The value 0 disables the janitor.
+	Interval time.Duration
+}
+
+// RunJanitor begins a process that removes the expired key/value pairs and
+// locks from the database at regular intervals, until the context is done.
+//
+// Expired rows are already ignored when read, the janitor only keeps them
+// from accumulating.
+func RunJanitor(ctx context.Context, config *JanitorConfig, datastore database.Datastore) {
+	if config == nil || config.Interval <= 0 {
+		log.Info("janitor service is disabled")
+		return
+	}
+
+	if ro, ok := datastore.(database.ReadOnly); ok && ro.ReadOnly() {
+		log.Info("janitor service is disabled on read-only instances")
+		return
+	}
+
+	log.Info("janitor service started")
+	defer log.Info("janitor service stopped")
+
+	for {
+		keyValues, locks, err := database.PruneExpiredAndCommit(datastore)
+		if err != nil {
+			log.WithError(err).Error("could not prune expired key/values and locks")
+		} else {
+			log.WithFields(log.Fields{
+				"key/values": keyValues,
+				"locks":      locks,
+			}).Debug("pruned expired key/values and locks")
+		}
+
+		if !timeutil.Sleep(ctx, config.Interval) {
+			return
+		}
+	}
+}
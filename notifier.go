@@ -15,16 +15,19 @@
 package clair
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"time"
 
-	"github.com/coreos/pkg/timeutil"
 	"github.com/pborman/uuid"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/notification"
-	"github.com/quay/clair/v3/pkg/stopper"
+	"github.com/quay/clair/v3/pkg/pagination"
+	"github.com/quay/clair/v3/pkg/timeutil"
 )
 
 const (
@@ -32,11 +35,16 @@ const (
 	notifierMaxBackOff          = 15 * time.Minute
 	notifierLockRefreshDuration = time.Minute * 2
 	notifierLockDuration        = time.Minute*8 + notifierLockRefreshDuration
+	notifierBatchCheckInterval  = time.Second
 
 	logSenderName = "sender name"
 	logNotiName   = "notification name"
 )
 
+// notifierLockRefreshInterval is how often the locks of the notifications
+// being collected or sent are extended, replaced by the tests.
+var notifierLockRefreshInterval = notifierLockRefreshDuration
+
 var (
 	promNotifierLatencyMilliseconds = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name: "clair_notifier_latency_milliseconds",
@@ -47,27 +55,48 @@ var (
 		Name: "clair_notifier_backend_errors_total",
 		Help: "Number of errors that notifier backends generated.",
 	}, []string{"backend"})
+
+	promNotifierFilteredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "clair_notifier_filtered_total",
+		Help: "Number of notifications that the notifier filters filtered.",
+	})
 )
 
 func init() {
 	prometheus.MustRegister(promNotifierLatencyMilliseconds)
 	prometheus.MustRegister(promNotifierBackendErrorsTotal)
+	prometheus.MustRegister(promNotifierFilteredTotal)
 }
 
-// RunNotifier begins a process that checks for new notifications that should
-// be sent out to third parties.
-func RunNotifier(config *notification.Config, datastore database.Datastore, stopper *stopper.Stopper) {
-	defer stopper.End()
-
-	// Configure registered notifiers.
+// ConfigureNotifier configures the registered senders, unregistering the ones
+// which aren't enabled. It fails when the configuration of a sender is
+// invalid, such as certificates which can't be loaded, so that Clair doesn't
+// start without it.
+func ConfigureNotifier(config *notification.Config) error {
 	for senderName, sender := range notification.Senders() {
-		if configured, err := sender.Configure(config); configured {
-			log.WithField(logSenderName, senderName).Info("sender configured")
-		} else {
+		configured, err := sender.Configure(config)
+		if err != nil {
+			return fmt.Errorf("could not configure notifier %s: %v", senderName, err)
+		}
+
+		if !configured {
 			notification.UnregisterSender(senderName)
-			if err != nil {
-				log.WithError(err).WithField(logSenderName, senderName).Error("could not configure notifier")
-			}
+			continue
+		}
+
+		log.WithField(logSenderName, senderName).Info("sender configured")
+	}
+
+	return nil
+}
+
+// RunNotifier begins a process that checks for new notifications that should
+// be sent out to third parties, until the context is done. The senders must
+// have been configured by ConfigureNotifier.
+func RunNotifier(ctx context.Context, config *notification.Config, datastore database.Datastore) {
+	for _, sender := range notification.Senders() {
+		if datastoreSender, ok := sender.(notification.DatastoreSender); ok {
+			datastoreSender.SetDatastore(datastore)
 		}
 	}
 
@@ -80,43 +109,37 @@ func RunNotifier(config *notification.Config, datastore database.Datastore, stop
 	whoAmI := uuid.New()
 	log.WithField("lock identifier", whoAmI).Info("notifier service started")
 
-	for running := true; running; {
-		// Find task.
-		notification := findTask(datastore, config.RenotifyInterval, whoAmI, stopper)
-		if notification == nil {
+	for ctx.Err() == nil {
+		// Find tasks.
+		batch := findBatch(ctx, datastore, config, whoAmI)
+		if len(batch) == 0 {
 			// Interrupted while finding a task, Clair is stopping.
 			break
 		}
 
-		// Handle task.
-		done := make(chan bool, 1)
+		// Handle tasks.
+		done := make(chan struct{})
 		go func() {
-			success, interrupted := handleTask(*notification, stopper, config.Attempts)
-			if success {
-				_, err := database.MarkNotificationAsReadAndCommit(datastore, notification.Name)
-				if err != nil {
-					log.WithError(err).Error("Failed to mark notification notified")
-				}
-				promNotifierLatencyMilliseconds.Observe(float64(time.Since(notification.Created).Nanoseconds()) / float64(time.Millisecond))
-			}
-			if interrupted {
-				running = false
+			defer close(done)
+			processTask(ctx, config, datastore, batch)
+			for _, n := range batch {
+				database.ReleaseLock(datastore, n.Name, whoAmI)
 			}
-			database.ReleaseLock(datastore, notification.Name, whoAmI)
-			done <- true
 		}()
 
-		// Refresh task lock until done.
+		// Refresh task locks until done.
 	outer:
 		for {
 			select {
 			case <-done:
 				break outer
-			case <-time.After(notifierLockRefreshDuration):
-				database.ExtendLock(datastore, notification.Name, whoAmI, notifierLockDuration)
-			case <-stopper.Chan():
-				running = false
-				break
+			case <-time.After(notifierLockRefreshInterval):
+				extendLocks(datastore, batch, whoAmI)
+			case <-ctx.Done():
+				// Sending is interrupted as well, wait for the locks to be
+				// released.
+				<-done
+				break outer
 			}
 		}
 	}
@@ -124,56 +147,239 @@ func RunNotifier(config *notification.Config, datastore database.Datastore, stop
 	log.Info("notifier service stopped")
 }
 
-func findTask(datastore database.Datastore, renotifyInterval time.Duration, whoAmI string, stopper *stopper.Stopper) *database.NotificationHook {
+func findTask(ctx context.Context, datastore database.Datastore, renotifyInterval time.Duration, whoAmI string) *database.NotificationHook {
 	for {
-		notification, ok, err := database.FindNewNotification(datastore, time.Now().Add(-renotifyInterval))
-		if err != nil || !ok {
-			if err != nil {
-				log.WithError(err).Warning("could not get notification to send")
-			}
-
+		notification, ok := tryFindTask(datastore, renotifyInterval, whoAmI)
+		if !ok {
 			// Wait.
-			if !stopper.Sleep(notifierCheckInterval) {
+			if !timeutil.Sleep(ctx, notifierCheckInterval) {
 				return nil
 			}
 
 			continue
 		}
 
-		// Lock the notification.
-		if hasLock, _ := database.AcquireLock(datastore, notification.Name, whoAmI, notifierLockDuration); hasLock {
-			log.WithField(logNotiName, notification.Name).Info("found and locked a notification")
-			return &notification
+		return notification
+	}
+}
+
+// tryFindTask finds and locks a notification to send, if there is any.
+func tryFindTask(datastore database.Datastore, renotifyInterval time.Duration, whoAmI string) (*database.NotificationHook, bool) {
+	notification, ok, err := database.FindNewNotification(datastore, time.Now().Add(-renotifyInterval))
+	if err != nil || !ok {
+		if err != nil {
+			log.WithError(err).Warning("could not get notification to send")
+		}
+
+		return nil, false
+	}
+
+	// Lock the notification.
+	if hasLock, _ := database.AcquireLock(datastore, notification.Name, whoAmI, notifierLockDuration); hasLock {
+		log.WithField(logNotiName, notification.Name).Info("found and locked a notification")
+		return &notification, true
+	}
+
+	return nil, false
+}
+
+// findBatch finds and locks the notifications to send together.
+//
+// Once a first notification is found, the notifications found within the
+// batch window are added to the batch until it is full. Without batch window,
+// the batch only has the first notification. In digest mode, every
+// notification found within the digest window is added.
+func findBatch(ctx context.Context, datastore database.Datastore, config *notification.Config, whoAmI string) []database.NotificationHook {
+	first := findTask(ctx, datastore, config.RenotifyInterval, whoAmI)
+	if first == nil {
+		return nil
+	}
+
+	window, size := config.BatchWindow, config.BatchSize
+	if config.Digest {
+		window, size = config.DigestWindow, 0
+	}
+
+	batch := []database.NotificationHook{*first}
+	if window <= 0 {
+		return batch
+	}
+
+	// The window may outlast the locks of the notifications already found,
+	// which are extended meanwhile.
+	deadline := time.Now().Add(window)
+	refresh := time.Now().Add(notifierLockRefreshInterval)
+	for size <= 0 || len(batch) < size {
+		if time.Now().After(refresh) {
+			extendLocks(datastore, batch, whoAmI)
+			refresh = time.Now().Add(notifierLockRefreshInterval)
+		}
+
+		if n, ok := tryFindTask(datastore, config.RenotifyInterval, whoAmI); ok {
+			batch = append(batch, *n)
+			continue
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			break
+		}
+
+		if wait > notifierBatchCheckInterval {
+			wait = notifierBatchCheckInterval
+		}
+
+		if !timeutil.Sleep(ctx, wait) {
+			break
+		}
+	}
+
+	log.WithField("count", len(batch)).Debug("found notification batch")
+	return batch
+}
+
+// extendLocks extends the locks of the notifications of the batch, so that no
+// other instance sends them meanwhile.
+func extendLocks(datastore database.Datastore, batch []database.NotificationHook, whoAmI string) {
+	for _, n := range batch {
+		database.ExtendLock(datastore, n.Name, whoAmI, notifierLockDuration)
+	}
+}
+
+// processTask sends a batch of notifications and marks them as read once
+// sent. If every attempt to send them failed, they are dead-lettered when
+// enabled.
+//
+// In digest mode, the batch is sent as a single digest and its notifications
+// are marked as read all at once.
+//
+// It returns whether sending the notifications was interrupted.
+func processTask(ctx context.Context, config *notification.Config, datastore database.Datastore, batch []database.NotificationHook) bool {
+	batch = filterBatch(config.Filters, datastore, batch)
+	if len(batch) == 0 {
+		return false
+	}
+
+	var digest *notification.Digest
+	if config.Digest {
+		d, err := notification.NewDigest(datastore, notificationNames(batch), time.Now())
+		if err != nil {
+			log.WithError(err).WithField(logNotiName, notificationNames(batch)).Error("could not read notification digest")
+			return false
+		}
+		digest = &d
+	}
+
+	success, interrupted, err := handleTask(ctx, batch, digest, config.Attempts)
+	if success && digest != nil {
+		if err := database.MarkNotificationsAsReadAndCommit(datastore, notificationNames(batch)); err != nil {
+			log.WithError(err).Error("Failed to mark notification digest notified")
+			return interrupted
+		}
+
+		for _, n := range batch {
+			promNotifierLatencyMilliseconds.Observe(float64(time.Since(n.Created).Nanoseconds()) / float64(time.Millisecond))
+		}
+		return interrupted
+	}
+
+	for _, n := range batch {
+		if success {
+			_, err := database.MarkNotificationAsReadAndCommit(datastore, n.Name)
+			if err != nil {
+				log.WithError(err).Error("Failed to mark notification notified")
+			}
+			promNotifierLatencyMilliseconds.Observe(float64(time.Since(n.Created).Nanoseconds()) / float64(time.Millisecond))
+		} else if !interrupted && config.DeadLetter {
+			if err := deadLetter(datastore, n, err); err != nil {
+				log.WithError(err).WithField(logNotiName, n.Name).Error("Failed to dead-letter notification")
+			}
+		}
+	}
+
+	return interrupted
+}
+
+// filterBatch marks the notifications of the batch which the filters don't
+// allow as filtered, and returns the other ones. Notifications which can't be
+// read are kept, so that the filters never lose a notification.
+func filterBatch(filters notification.Filters, datastore database.Datastore, batch []database.NotificationHook) []database.NotificationHook {
+	if !filters.Enabled() {
+		return batch
+	}
+
+	kept := make([]database.NotificationHook, 0, len(batch))
+	for _, n := range batch {
+		noti, ok, err := database.FindVulnerabilityNotificationAndRollback(datastore, n.Name, 1, pagination.FirstPageToken, pagination.FirstPageToken)
+		if err != nil || !ok {
+			log.WithError(err).WithField(logNotiName, n.Name).Warning("could not read notification to filter it")
+			kept = append(kept, n)
+			continue
+		}
+
+		var old, new *database.Vulnerability
+		if noti.Old != nil {
+			old = &noti.Old.Vulnerability
+		}
+		if noti.New != nil {
+			new = &noti.New.Vulnerability
 		}
+
+		if filters.Allow(old, new) {
+			kept = append(kept, n)
+			continue
+		}
+
+		if err := database.MarkNotificationAsFilteredAndCommit(datastore, n.Name); err != nil {
+			log.WithError(err).WithField(logNotiName, n.Name).Error("Failed to mark notification filtered")
+			continue
+		}
+
+		promNotifierFilteredTotal.Inc()
+		log.WithField(logNotiName, n.Name).Debug("filtered notification")
 	}
+
+	return kept
 }
 
-func handleTask(n database.NotificationHook, st *stopper.Stopper, maxAttempts int) (bool, bool) {
+// handleTask sends a batch of notifications with every sender and returns
+// whether it succeeded, whether it was interrupted, and the last error of the
+// sender that failed to send it.
+//
+// Senders implementing notification.BatchSender send a batch of several
+// notifications at once, other senders send them one at a time. When a digest
+// is given, the senders implementing notification.DigestSender send it
+// instead.
+func handleTask(ctx context.Context, batch []database.NotificationHook, digest *notification.Digest, maxAttempts int) (bool, bool, error) {
+	names := notificationNames(batch)
+
 	// Send notification.
 	for senderName, sender := range notification.Senders() {
 		var attempts int
 		var backOff time.Duration
+		var lastErr error
 		for {
 			// Max attempts exceeded.
 			if attempts >= maxAttempts {
-				log.WithFields(log.Fields{logNotiName: n.Name, logSenderName: senderName, "max attempts": maxAttempts}).Info("giving up on sending notification : max attempts exceeded")
-				return false, false
+				log.WithFields(log.Fields{logNotiName: names, logSenderName: senderName, "max attempts": maxAttempts}).Info("giving up on sending notification : max attempts exceeded")
+				return false, false, fmt.Errorf("%s: %v", senderName, lastErr)
 			}
 
 			// Backoff.
 			if backOff > 0 {
-				log.WithFields(log.Fields{"duration": backOff, logNotiName: n.Name, logSenderName: senderName, "attempts": attempts + 1, "max attempts": maxAttempts}).Info("waiting before retrying to send notification")
-				if !st.Sleep(backOff) {
-					return false, true
+				log.WithFields(log.Fields{"duration": backOff, logNotiName: names, logSenderName: senderName, "attempts": attempts + 1, "max attempts": maxAttempts}).Info("waiting before retrying to send notification")
+				if !timeutil.Sleep(ctx, backOff) {
+					return false, true, nil
 				}
 			}
 
 			// Send using the current notifier.
-			if err := sender.Send(n.Name); err != nil {
+			if err := send(sender, names, digest); err != nil {
 				// Send failed; increase attempts/backoff and retry.
 				promNotifierBackendErrorsTotal.WithLabelValues(senderName).Inc()
-				log.WithError(err).WithFields(log.Fields{logSenderName: senderName, logNotiName: n.Name}).Error("could not send notification via notifier")
+				log.WithError(err).WithFields(log.Fields{logSenderName: senderName, logNotiName: names}).Error("could not send notification via notifier")
 				backOff = timeutil.ExpBackoff(backOff, notifierMaxBackOff)
+				lastErr = err
 				attempts++
 				continue
 			}
@@ -183,6 +389,55 @@ func handleTask(n database.NotificationHook, st *stopper.Stopper, maxAttempts in
 		}
 	}
 
-	log.WithField(logNotiName, n.Name).Info("successfully sent notification")
-	return true, false
+	log.WithField(logNotiName, names).Info("successfully sent notification")
+	return true, false, nil
+}
+
+func send(sender notification.Sender, names []string, digest *notification.Digest) error {
+	if digestSender, ok := sender.(notification.DigestSender); ok && digest != nil {
+		return digestSender.SendDigest(*digest)
+	}
+
+	if batchSender, ok := sender.(notification.BatchSender); ok && len(names) > 1 {
+		return batchSender.SendBatch(names)
+	}
+
+	for _, name := range names {
+		if err := sender.Send(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func notificationNames(batch []database.NotificationHook) []string {
+	names := make([]string, 0, len(batch))
+	for _, n := range batch {
+		names = append(names, n.Name)
+	}
+
+	return names
+}
+
+// deadLetter stores a notification which failed to be sent so that it's not
+// sent again until it is requeued.
+func deadLetter(datastore database.Datastore, n database.NotificationHook, sendErr error) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	var lastError string
+	if sendErr != nil {
+		lastError = sendErr.Error()
+	}
+
+	log.WithField(logNotiName, n.Name).Warning("dead-lettering notification")
+	return database.InsertDeadLetterNotificationAndCommit(datastore, database.DeadLetterNotification{
+		Name:      n.Name,
+		Payload:   string(payload),
+		LastError: lastError,
+		Created:   time.Now(),
+	})
 }
@@ -0,0 +1,441 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/notification"
+	"github.com/quay/clair/v3/pkg/pagination"
+)
+
+type failingSender struct {
+	attempts int
+}
+
+func (s *failingSender) Configure(*notification.Config) (bool, error) { return true, nil }
+
+func (s *failingSender) Send(notificationName string) error {
+	s.attempts++
+	return errors.New("connection refused")
+}
+
+type batchSender struct {
+	batches [][]string
+	sent    []string
+}
+
+func (s *batchSender) Configure(*notification.Config) (bool, error) { return true, nil }
+
+func (s *batchSender) Send(notificationName string) error {
+	s.sent = append(s.sent, notificationName)
+	return nil
+}
+
+func (s *batchSender) SendBatch(notificationNames []string) error {
+	s.batches = append(s.batches, notificationNames)
+	return nil
+}
+
+type notifierDatastore struct {
+	database.MockDatastore
+
+	deadLetters []database.DeadLetterNotification
+	read        []string
+	pending     []string
+	locked      map[string]bool
+	extended    map[string]int
+}
+
+func newNotifierDatastore() *notifierDatastore {
+	store := &notifierDatastore{locked: map[string]bool{}, extended: map[string]int{}}
+	store.FctBegin = func() (database.Session, error) {
+		var deadLetters []database.DeadLetterNotification
+		var read []string
+		session := &database.MockSession{}
+		session.FctInsertDeadLetterNotification = func(deadLetter database.DeadLetterNotification) error {
+			deadLetters = append(deadLetters, deadLetter)
+			return nil
+		}
+		session.FctDeleteNotification = func(name string) error {
+			read = append(read, name)
+			return nil
+		}
+		session.FctCommit = func() error {
+			store.deadLetters = append(store.deadLetters, deadLetters...)
+			store.read = append(store.read, read...)
+			return nil
+		}
+		session.FctFindNewNotification = func(time.Time) (database.NotificationHook, bool, error) {
+			for _, name := range store.pending {
+				if !store.locked[name] {
+					return database.NotificationHook{Name: name}, true, nil
+				}
+			}
+			return database.NotificationHook{}, false, nil
+		}
+		session.FctAcquireLock = func(name, owner string, duration time.Duration) (bool, time.Time, error) {
+			if store.locked[name] {
+				return false, time.Time{}, nil
+			}
+			store.locked[name] = true
+			return true, time.Now().Add(duration), nil
+		}
+		session.FctExtendLock = func(name, owner string, duration time.Duration) (bool, time.Time, error) {
+			if !store.locked[name] {
+				return false, time.Time{}, nil
+			}
+			store.extended[name]++
+			return true, time.Now().Add(duration), nil
+		}
+		session.FctRollback = func() error { return nil }
+		return session, nil
+	}
+	return store
+}
+
+func TestProcessTaskDeadLetter(t *testing.T) {
+	sender := &failingSender{}
+	notification.RegisterSender("failing", sender)
+	defer notification.UnregisterSender("failing")
+
+	hook := database.NotificationHook{Name: "test", Created: time.Unix(1546300800, 0).UTC()}
+	ctx := context.Background()
+
+	// Without dead-lettering, the notification is given up on.
+	store := newNotifierDatastore()
+	interrupted := processTask(ctx, &notification.Config{Attempts: 1}, store, []database.NotificationHook{hook})
+	assert.False(t, interrupted)
+	assert.Equal(t, 1, sender.attempts)
+	assert.Len(t, store.deadLetters, 0)
+	assert.Len(t, store.read, 0)
+
+	// With dead-lettering, the exhausted notification is stored with the last
+	// error.
+	store = newNotifierDatastore()
+	interrupted = processTask(ctx, &notification.Config{Attempts: 1, DeadLetter: true}, store, []database.NotificationHook{hook})
+	assert.False(t, interrupted)
+	assert.Equal(t, 2, sender.attempts)
+	assert.Len(t, store.read, 0)
+	require.Len(t, store.deadLetters, 1)
+
+	deadLetter := store.deadLetters[0]
+	assert.Equal(t, "test", deadLetter.Name)
+	assert.Equal(t, "failing: connection refused", deadLetter.LastError)
+	assert.False(t, deadLetter.Created.IsZero())
+
+	var payload database.NotificationHook
+	require.Nil(t, json.Unmarshal([]byte(deadLetter.Payload), &payload))
+	assert.Equal(t, hook, payload)
+}
+
+func TestBatchNotifications(t *testing.T) {
+	sender := &batchSender{}
+	notification.RegisterSender("batch", sender)
+	defer notification.UnregisterSender("batch")
+
+	store := newNotifierDatastore()
+	for i := 0; i < 5; i++ {
+		store.pending = append(store.pending, fmt.Sprintf("notification-%d", i))
+	}
+
+	config := &notification.Config{
+		Attempts:         1,
+		RenotifyInterval: time.Hour,
+		BatchWindow:      10 * time.Millisecond,
+		BatchSize:        10,
+	}
+	ctx := context.Background()
+
+	// Every notification found within the window is sent in one batch.
+	batch := findBatch(ctx, store, config, "notifier")
+	require.Len(t, batch, 5)
+	assert.False(t, processTask(ctx, config, store, batch))
+	require.Len(t, sender.batches, 1)
+	assert.Equal(t, store.pending, sender.batches[0])
+	assert.Len(t, sender.sent, 0)
+
+	// Each notification of the batch is marked as read on its own.
+	assert.Equal(t, store.pending, store.read)
+
+	// Batches are capped to the configured size.
+	store = newNotifierDatastore()
+	store.pending = []string{"a", "b", "c"}
+	config.BatchSize = 2
+	assert.Len(t, findBatch(ctx, store, config, "notifier"), 2)
+
+	// Without batch window, notifications are sent one at a time.
+	store = newNotifierDatastore()
+	store.pending = []string{"a", "b", "c"}
+	config.BatchWindow = 0
+	batch = findBatch(ctx, store, config, "notifier")
+	require.Len(t, batch, 1)
+	assert.False(t, processTask(ctx, config, store, batch))
+	assert.Equal(t, []string{"a"}, sender.sent)
+	assert.Len(t, sender.batches, 1)
+}
+
+// withLockRefreshInterval replaces the interval at which the locks of the
+// notifications are extended.
+func withLockRefreshInterval(interval time.Duration) func() {
+	previous := notifierLockRefreshInterval
+	notifierLockRefreshInterval = interval
+	return func() { notifierLockRefreshInterval = previous }
+}
+
+func TestFindBatchExtendsLocks(t *testing.T) {
+	defer withLockRefreshInterval(time.Millisecond)()
+
+	store := newNotifierDatastore()
+	store.pending = []string{"a", "b"}
+
+	// The locks of the notifications found are extended while the batch
+	// window, which may outlast them, is still open.
+	config := &notification.Config{
+		Attempts:    1,
+		BatchWindow: 20 * time.Millisecond,
+		BatchSize:   10,
+	}
+	require.Len(t, findBatch(context.Background(), store, config, "notifier"), 2)
+	assert.NotZero(t, store.extended["a"])
+	assert.NotZero(t, store.extended["b"])
+
+	// Without batch window, there is nothing to extend.
+	store = newNotifierDatastore()
+	store.pending = []string{"a", "b"}
+	config.BatchWindow = 0
+	require.Len(t, findBatch(context.Background(), store, config, "notifier"), 1)
+	assert.Empty(t, store.extended)
+}
+
+func TestProcessTaskFilters(t *testing.T) {
+	sender := &batchSender{}
+	notification.RegisterSender("batch", sender)
+	defer notification.UnregisterSender("batch")
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+
+	namespace := database.Namespace{Name: "debian:9", VersionFormat: "dpkg"}
+	vulnerability := func(name string, severity database.Severity) *database.Vulnerability {
+		return &database.Vulnerability{Name: name, Namespace: namespace, Severity: severity}
+	}
+	low, critical := vulnerability("CVE-2019-0001", database.LowSeverity), vulnerability("CVE-2019-0001", database.CriticalSeverity)
+	other := vulnerability("CVE-2019-0002", database.LowSeverity)
+
+	// The low vulnerability is raised to critical, and an other low
+	// vulnerability is added.
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{namespace}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{Vulnerability: *low}, {Vulnerability: *other}}))
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: low.Name, Namespace: namespace.Name}}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{Vulnerability: *critical}}))
+	created := time.Now()
+	require.Nil(t, tx.InsertVulnerabilityNotifications([]database.VulnerabilityNotification{
+		{NotificationHook: database.NotificationHook{Name: "new-low", Created: created}, New: other},
+		{NotificationHook: database.NotificationHook{Name: "low-to-critical", Created: created}, Old: low, New: critical},
+		{NotificationHook: database.NotificationHook{Name: "removed-low", Created: created}, Old: low},
+	}))
+	require.Nil(t, tx.Commit())
+
+	batch := []database.NotificationHook{{Name: "new-low"}, {Name: "low-to-critical"}, {Name: "removed-low"}}
+	config := &notification.Config{
+		Attempts: 1,
+		Filters:  notification.Filters{MinSeverity: database.HighSeverity},
+	}
+	assert.False(t, processTask(context.Background(), config, store, batch))
+
+	// Raising a vulnerability above the threshold is notified.
+	assert.Equal(t, []string{"low-to-critical"}, sender.sent)
+	assert.Len(t, sender.batches, 0)
+
+	// The other notifications are flagged as filtered and never sent again.
+	tx, err = store.BeginReadOnly()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	notiPage, err := tx.FindNotifications(database.DeliveredNotification, 10, pagination.FirstPageToken)
+	require.Nil(t, err)
+	var filtered []string
+	for _, noti := range notiPage.Notifications {
+		assert.True(t, noti.Filtered)
+		filtered = append(filtered, noti.Name)
+	}
+	assert.ElementsMatch(t, []string{"new-low", "removed-low"}, filtered)
+
+	_, ok, err := tx.FindNewNotification(time.Now().Add(time.Hour))
+	require.Nil(t, err)
+	assert.False(t, ok)
+}
+
+type digestSender struct {
+	batchSender
+
+	digests []notification.Digest
+	err     error
+}
+
+func (s *digestSender) SendDigest(digest notification.Digest) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.digests = append(s.digests, digest)
+	return nil
+}
+
+func TestProcessTaskDigest(t *testing.T) {
+	sender := &digestSender{}
+	notification.RegisterSender("digest", sender)
+	defer notification.UnregisterSender("digest")
+
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+
+	namespace := database.Namespace{Name: "debian:9", VersionFormat: "dpkg"}
+	var vulnerabilities []database.VulnerabilityWithAffected
+	var notifications []database.VulnerabilityNotification
+	var batch []database.NotificationHook
+	for i, severity := range []database.Severity{database.LowSeverity, database.HighSeverity, database.CriticalSeverity} {
+		vulnerability := database.Vulnerability{Name: fmt.Sprintf("CVE-2019-000%d", i), Namespace: namespace, Severity: severity}
+		hook := database.NotificationHook{Name: fmt.Sprintf("notification-%d", i), Created: time.Now()}
+		vulnerabilities = append(vulnerabilities, database.VulnerabilityWithAffected{Vulnerability: vulnerability})
+		notifications = append(notifications, database.VulnerabilityNotification{NotificationHook: hook, New: &vulnerability})
+		batch = append(batch, hook)
+	}
+
+	tx, err := store.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{namespace}))
+	require.Nil(t, tx.InsertVulnerabilities(vulnerabilities))
+	require.Nil(t, tx.InsertVulnerabilityNotifications(notifications))
+	require.Nil(t, tx.Commit())
+
+	names := func(state database.NotificationState) []string {
+		tx, err := store.BeginReadOnly()
+		require.Nil(t, err)
+		defer tx.Rollback()
+
+		notiPage, err := tx.FindNotifications(state, 10, pagination.FirstPageToken)
+		require.Nil(t, err)
+		var names []string
+		for _, noti := range notiPage.Notifications {
+			names = append(names, noti.Name)
+		}
+		return names
+	}
+
+	config := &notification.Config{Attempts: 1, Digest: true, DigestWindow: time.Minute}
+	ctx := context.Background()
+
+	// When the digest fails to be sent, no notification is marked as read.
+	sender.err = errors.New("connection refused")
+	assert.False(t, processTask(ctx, config, store, batch))
+	assert.Len(t, sender.digests, 0)
+	assert.Len(t, names(database.PendingNotification), 3)
+
+	// Once sent, every notification of the digest is marked as read.
+	sender.err = nil
+	assert.False(t, processTask(ctx, config, store, batch))
+	require.Len(t, sender.digests, 1)
+	assert.Len(t, sender.batches, 0)
+	assert.Len(t, sender.sent, 0)
+
+	digest := sender.digests[0]
+	require.Len(t, digest.Notifications, 3)
+	for i, n := range digest.Notifications {
+		assert.Equal(t, batch[i].Name, n.Name)
+		require.NotNil(t, n.New)
+		assert.Equal(t, vulnerabilities[i].Name, n.New.Name)
+		assert.Equal(t, vulnerabilities[i].Severity, n.New.Severity)
+	}
+	assert.Len(t, names(database.PendingNotification), 0)
+	assert.Len(t, names(database.ExpiredNotification), 3)
+}
+
+func TestFindDigest(t *testing.T) {
+	store := newNotifierDatastore()
+	for i := 0; i < 5; i++ {
+		store.pending = append(store.pending, fmt.Sprintf("notification-%d", i))
+	}
+
+	// Every notification found within the digest window is gathered,
+	// regardless of the batch size.
+	config := &notification.Config{
+		Attempts:     1,
+		BatchSize:    2,
+		Digest:       true,
+		DigestWindow: 10 * time.Millisecond,
+	}
+	assert.Len(t, findBatch(context.Background(), store, config, "notifier"), 5)
+}
+
+func TestFindDigestExtendsLocks(t *testing.T) {
+	defer withLockRefreshInterval(time.Millisecond)()
+
+	store := newNotifierDatastore()
+	store.pending = []string{"a", "b", "c"}
+
+	// The digest window may outlast the locks of the notifications found,
+	// which are extended until it closes.
+	config := &notification.Config{
+		Attempts:     1,
+		Digest:       true,
+		DigestWindow: 20 * time.Millisecond,
+	}
+	require.Len(t, findBatch(context.Background(), store, config, "notifier"), 3)
+	for _, name := range store.pending {
+		assert.NotZero(t, store.extended[name], name)
+	}
+}
+
+type configuredSender struct {
+	configured bool
+	err        error
+}
+
+func (s configuredSender) Configure(*notification.Config) (bool, error) { return s.configured, s.err }
+
+func (s configuredSender) Send(notificationName string) error { return nil }
+
+func TestConfigureNotifier(t *testing.T) {
+	notification.RegisterSender("enabled", configuredSender{configured: true})
+	defer notification.UnregisterSender("enabled")
+	notification.RegisterSender("disabled", configuredSender{})
+	defer notification.UnregisterSender("disabled")
+
+	// The senders which aren't enabled are unregistered.
+	require.Nil(t, ConfigureNotifier(&notification.Config{}))
+	senders := notification.Senders()
+	assert.Contains(t, senders, "enabled")
+	assert.NotContains(t, senders, "disabled")
+
+	// An invalid configuration fails.
+	notification.RegisterSender("invalid", configuredSender{err: errors.New("could not load certificate")})
+	defer notification.UnregisterSender("invalid")
+	err := ConfigureNotifier(&notification.Config{})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid")
+}
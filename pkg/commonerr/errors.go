@@ -19,6 +19,7 @@ package commonerr
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
@@ -50,6 +51,120 @@ func (e *ErrBadRequest) Error() string {
 	return e.s
 }
 
+// TemporaryError is implemented by the errors which tell whether the failure
+// is temporary, in which case retrying the operation later may succeed, or
+// permanent.
+type TemporaryError interface {
+	error
+	Temporary() bool
+}
+
+// Retryable returns whether an operation which failed with the provided error
+// may succeed if retried. Errors which are not classified as temporary or
+// permanent are considered retryable.
+func Retryable(err error) bool {
+	var terr TemporaryError
+	if errors.As(err, &terr) {
+		return terr.Temporary()
+	}
+
+	return true
+}
+
+// DownloadError occurs when a download fails. It matches ErrCouldNotDownload.
+type DownloadError struct {
+	// URL is the location of the requested resource.
+	URL string
+	// StatusCode is the HTTP status code of the response, or 0 if no response
+	// was received.
+	StatusCode int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// NewDownloadError instantiates a DownloadError for a request which received
+// no response.
+func NewDownloadError(url string, err error) error {
+	return &DownloadError{URL: url, Err: err}
+}
+
+// NewStatusError instantiates a DownloadError for a request which received an
+// unexpected status code.
+func NewStatusError(url string, statusCode int) error {
+	return &DownloadError{URL: url, StatusCode: statusCode}
+}
+
+func (e *DownloadError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s %s: unexpected status code %d", ErrCouldNotDownload, e.URL, e.StatusCode)
+	}
+
+	if e.Err != nil {
+		return fmt.Sprintf("%s %s: %s", ErrCouldNotDownload, e.URL, e.Err)
+	}
+
+	return fmt.Sprintf("%s %s", ErrCouldNotDownload, e.URL)
+}
+
+// Unwrap returns the underlying error.
+func (e *DownloadError) Unwrap() error { return e.Err }
+
+// Is makes a DownloadError match ErrCouldNotDownload.
+func (e *DownloadError) Is(target error) bool { return target == ErrCouldNotDownload }
+
+// Temporary returns whether the download may succeed later: either no
+// response was received, or the server is failing or throttling.
+func (e *DownloadError) Temporary() bool {
+	switch {
+	case e.StatusCode == 0:
+		return true
+	case e.StatusCode >= 500:
+		return true
+	case e.StatusCode == http.StatusRequestTimeout, e.StatusCode == http.StatusTooManyRequests:
+		return true
+	}
+
+	return false
+}
+
+// ParseError occurs when update data fails to be parsed. It matches
+// ErrCouldNotParse. It is permanent: the same data fails to be parsed again.
+type ParseError struct {
+	// Source is the name of the parsed data, usually a file name or URL.
+	Source string
+	// Line is the line the parsing failed at, or 0 if unknown.
+	Line int
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// NewParseError instantiates a ParseError.
+func NewParseError(source string, line int, err error) error {
+	return &ParseError{Source: source, Line: line, Err: err}
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("%s %s", ErrCouldNotParse, e.Source)
+	if e.Line > 0 {
+		msg += fmt.Sprintf(":%d", e.Line)
+	}
+
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Is makes a ParseError match ErrCouldNotParse.
+func (e *ParseError) Is(target error) bool { return target == ErrCouldNotParse }
+
+// Temporary always returns false.
+func (e *ParseError) Temporary() bool { return false }
+
 // CombineErrors merges a slice of errors into one separated by ";". If all
 // errors are nil, return nil.
 func CombineErrors(errs ...error) error {
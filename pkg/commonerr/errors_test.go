@@ -0,0 +1,68 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadError(t *testing.T) {
+	cause := errors.New("connection reset by peer")
+	for _, test := range []struct {
+		err       error
+		temporary bool
+		message   string
+	}{
+		{NewDownloadError("https://example.com/a", cause), true, "could not download requested resource https://example.com/a: connection reset by peer"},
+		{NewStatusError("https://example.com/a", http.StatusServiceUnavailable), true, "could not download requested resource https://example.com/a: unexpected status code 503"},
+		{NewStatusError("https://example.com/a", http.StatusTooManyRequests), true, "could not download requested resource https://example.com/a: unexpected status code 429"},
+		{NewStatusError("https://example.com/a", http.StatusNotFound), false, "could not download requested resource https://example.com/a: unexpected status code 404"},
+		{NewStatusError("https://example.com/a", http.StatusForbidden), false, "could not download requested resource https://example.com/a: unexpected status code 403"},
+	} {
+		assert.Equal(t, test.message, test.err.Error())
+		assert.Equal(t, test.temporary, Retryable(test.err), test.message)
+		assert.True(t, errors.Is(test.err, ErrCouldNotDownload))
+		assert.False(t, errors.Is(test.err, ErrCouldNotParse))
+	}
+
+	assert.True(t, errors.Is(NewDownloadError("https://example.com/a", cause), cause))
+}
+
+func TestParseError(t *testing.T) {
+	cause := errors.New("unexpected EOF")
+	err := NewParseError("secdb/v3.10/main.yaml", 12, cause)
+	assert.Equal(t, "updater/fetchers: could not parse secdb/v3.10/main.yaml:12: unexpected EOF", err.Error())
+	assert.Equal(t, "updater/fetchers: could not parse data.json", NewParseError("data.json", 0, nil).Error())
+	assert.False(t, Retryable(err))
+	assert.True(t, errors.Is(err, ErrCouldNotParse))
+	assert.True(t, errors.Is(err, cause))
+
+	var perr *ParseError
+	if assert.True(t, errors.As(fmt.Errorf("updating: %w", err), &perr)) {
+		assert.Equal(t, 12, perr.Line)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	// Unclassified errors are retried, as they were before being classified.
+	assert.True(t, Retryable(errors.New("database is down")))
+	assert.True(t, Retryable(fmt.Errorf("updating: %w", NewStatusError("https://example.com/a", http.StatusBadGateway))))
+	assert.False(t, Retryable(fmt.Errorf("updating: %w", NewParseError("data.json", 0, nil))))
+}
@@ -17,10 +17,12 @@
 package gitutil
 
 import (
+	"encoding/base64"
 	"errors"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -35,23 +37,83 @@ var ErrFailedRevParse = errors.New("failed to rev-parse git repository")
 // ErrFailedPull is returned when a git pull is unsuccessful.
 var ErrFailedPull = errors.New("failed to pull git repository")
 
-// pull performs a git pull on the provided path and returns the commit SHA
-// for the HEAD reference.
-func pull(path string) (head string, err error) {
-	// Prepare a command to pull the repository.
-	cmd := exec.Command("git", "pull")
+// Options configures how a repository is cloned and updated.
+type Options struct {
+	// Depth limits the fetched history to the given number of commits. The
+	// whole history is fetched when it is 0.
+	Depth int
+
+	// Username and Token authenticate to HTTPS remotes. Username defaults to
+	// "git" when only a token is given.
+	Username string
+	Token    string
+
+	// SSHKeyPath is the private key used to authenticate to SSH remotes.
+	SSHKeyPath string
+}
+
+// env returns the environment of the git commands run with the options.
+//
+// Credentials are passed through the environment so that they are neither
+// visible in the process arguments nor stored in the repository config.
+func (o Options) env() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if o.Token != "" {
+		username := o.Username
+		if username == "" {
+			username = "git"
+		}
+
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + o.Token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+
+	if o.SSHKeyPath != "" {
+		env = append(env, "GIT_SSH_COMMAND=ssh -i '"+strings.Replace(o.SSHKeyPath, "'", "'\\''", -1)+"' -o IdentitiesOnly=yes")
+	}
+
+	return env
+}
+
+func (o Options) depthArgs() []string {
+	if o.Depth <= 0 {
+		return nil
+	}
+
+	return []string{"--depth", strconv.Itoa(o.Depth)}
+}
+
+// git runs a git command in the provided path and returns its combined
+// output.
+func git(path string, opts Options, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
 	cmd.Dir = path
+	cmd.Env = opts.env()
+	return cmd.CombinedOutput()
+}
 
-	// Execute the command.
-	var commandOutput []byte
-	commandOutput, err = cmd.CombinedOutput()
-	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"path":   path,
-			"output": string(commandOutput),
-		}).Error("failed to git rev-parse repository")
-		err = ErrFailedPull
-		return
+// pull fetches the HEAD of the remote on the provided path, resets the
+// checkout to it and returns its commit SHA.
+func pull(path string, opts Options) (head string, err error) {
+	for _, args := range [][]string{
+		append(append([]string{"fetch"}, opts.depthArgs()...), "origin", "HEAD"),
+		{"reset", "--hard", "FETCH_HEAD"},
+	} {
+		var commandOutput []byte
+		commandOutput, err = git(path, opts, args...)
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"path":    path,
+				"command": args[0],
+				"output":  string(commandOutput),
+			}).Error("failed to update git repository")
+			err = ErrFailedPull
+			return
+		}
 	}
 
 	return revParseHead(path)
@@ -63,6 +125,15 @@ func pull(path string) (head string, err error) {
 // If repoPath is left empty, a temporary directory is generated with the
 // provided prefix and returned.
 func CloneOrPull(remote, repoPath, tempDirPrefix string) (path, head string, err error) {
+	return CloneOrPullWithOptions(remote, repoPath, tempDirPrefix, Options{})
+}
+
+// CloneOrPullWithOptions is CloneOrPull cloning and updating the repository
+// with the provided options.
+//
+// The returned HEAD commit SHA can be stored by updaters as the cursor of
+// their next incremental update.
+func CloneOrPullWithOptions(remote, repoPath, tempDirPrefix string, opts Options) (path, head string, err error) {
 	// Create a temporary directory if the path is unspecified.
 	if repoPath == "" {
 		path, err = ioutil.TempDir(os.TempDir(), tempDirPrefix)
@@ -74,17 +145,17 @@ func CloneOrPull(remote, repoPath, tempDirPrefix string) (path, head string, err
 	}
 
 	if _, pathExists := os.Stat(path); repoPath == "" || os.IsNotExist(pathExists) {
-		head, err = clone(remote, path)
+		head, err = clone(remote, path, opts)
 		return
 	}
 
-	head, err = pull(path)
+	head, err = pull(path, opts)
 	return
 }
 
 // clone performs a git clone to the provided path and returns the commit SHA
 // for the HEAD reference.
-func clone(remote, path string) (head string, err error) {
+func clone(remote, path string, opts Options) (head string, err error) {
 	// Handle an invalid path.
 	if path == "" {
 		log.WithField("remote", remote).Error("attempted to git clone repository to empty path")
@@ -92,13 +163,16 @@ func clone(remote, path string) (head string, err error) {
 		return
 	}
 
-	// Prepare a command to clone the repository.
-	cmd := exec.Command("git", "clone", remote, ".")
-	cmd.Dir = path
+	if err = os.MkdirAll(path, 0755); err != nil {
+		log.WithError(err).WithField("path", path).Error("failed to create directory to git clone repository")
+		err = ErrFailedClone
+		return
+	}
 
 	// Execute the command.
 	var commandOutput []byte
-	commandOutput, err = cmd.CombinedOutput()
+	args := append(append([]string{"clone"}, opts.depthArgs()...), remote, ".")
+	commandOutput, err = git(path, opts, args...)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"remote": remote,
@@ -75,3 +75,57 @@ func TestCloneOrPull(t *testing.T) {
 	require.Equal(t, path, newPath, "No new path should be created when pulling")
 	require.Equal(t, expectedHead, newHead)
 }
+
+func countCommits(t *testing.T, repoPath string) string {
+	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
+	cmd.Dir = repoPath
+	out, err := cmd.CombinedOutput()
+	require.Nil(t, err, "Failed to count commits, output=%s", string(out))
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+func TestCloneOrPullShallow(t *testing.T) {
+	remote := createTemporaryGitRepo(t)
+	defer os.RemoveAll(remote)
+	for i := 0; i < 3; i++ {
+		createEmptyCommit(t, remote)
+	}
+
+	// Depth is ignored for local paths, unlike file URLs.
+	opts := Options{Depth: 1}
+	path, head, err := CloneOrPullWithOptions("file://"+remote, "", "9c2d4181", opts)
+	require.Nil(t, err)
+	defer os.RemoveAll(path)
+	require.Equal(t, getHeadCommitRev(t, remote), head)
+	require.Equal(t, "1", countCommits(t, path))
+
+	// Rewritten remote history is fetched and reset to.
+	cmd := exec.Command("git", "commit", "--amend", "-m", "\"amended\"", "--allow-empty")
+	cmd.Dir = remote
+	out, err := cmd.CombinedOutput()
+	require.Nil(t, err, "Failed to amend commit, output=%s", string(out))
+	createEmptyCommit(t, remote)
+
+	newPath, newHead, err := CloneOrPullWithOptions("file://"+remote, path, "9c2d4181", opts)
+	require.Nil(t, err)
+	require.Equal(t, path, newPath)
+	require.Equal(t, getHeadCommitRev(t, remote), newHead)
+	require.Equal(t, newHead, getHeadCommitRev(t, path))
+}
+
+func TestOptionsEnv(t *testing.T) {
+	env := Options{Token: "secret"}.env()
+	require.Contains(t, env, "GIT_CONFIG_KEY_0=http.extraHeader")
+	// base64("git:secret")
+	require.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Basic Z2l0OnNlY3JldA==")
+
+	env = Options{Username: "user", Token: "secret", SSHKeyPath: "/keys/id_rsa"}.env()
+	// base64("user:secret")
+	require.Contains(t, env, "GIT_CONFIG_VALUE_0=Authorization: Basic dXNlcjpzZWNyZXQ=")
+	require.Contains(t, env, "GIT_SSH_COMMAND=ssh -i '/keys/id_rsa' -o IdentitiesOnly=yes")
+
+	env = Options{}.env()
+	for _, v := range env {
+		require.False(t, strings.HasPrefix(v, "GIT_CONFIG_COUNT="))
+	}
+}
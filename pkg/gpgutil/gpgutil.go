@@ -0,0 +1,100 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gpgutil implements the verification of detached GPG signatures of
+// downloaded files.
+package gpgutil
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/pkg/httputil"
+)
+
+// ErrBadSignature is returned when a signature cannot be verified against the
+// keyring.
+var ErrBadSignature = errors.New("gpgutil: signature verification failed")
+
+// SignatureSuffix is appended to the URL of a file to get the URL of its
+// detached ASCII armored signature.
+const SignatureSuffix = ".asc"
+
+// VerifyDetached verifies the detached signature of the content against the
+// public keys of the keyring.
+//
+// Verification relies on gpgv, which is given the keyring and no other key.
+func VerifyDetached(keyring string, content, signature []byte) error {
+	dir, err := ioutil.TempDir("", "gpgutil")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	contentPath := filepath.Join(dir, "content")
+	signaturePath := filepath.Join(dir, "content"+SignatureSuffix)
+	if err := ioutil.WriteFile(contentPath, content, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(signaturePath, signature, 0600); err != nil {
+		return err
+	}
+
+	keyring, err = filepath.Abs(keyring)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("gpgv", "--keyring", keyring, signaturePath, contentPath)
+	// gpgv must not read keys from the home directory of the user.
+	cmd.Env = append(os.Environ(), "GNUPGHOME="+dir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return fmt.Errorf("gpgutil: could not run gpgv: %v", err)
+		}
+
+		log.WithField("output", string(output)).Debug("gpgv rejected signature")
+		return ErrBadSignature
+	}
+
+	return nil
+}
+
+// VerifyDownload downloads the detached signature of the file downloaded from
+// the URL on behalf of the updater and verifies the content of the file
+// against it.
+func VerifyDownload(updater, keyring, url string, content []byte) error {
+	r, err := httputil.GetForUpdater(updater, url+SignatureSuffix)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if !httputil.Status2xx(r) {
+		return fmt.Errorf("gpgutil: could not download signature: got status %d", r.StatusCode)
+	}
+
+	signature, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return VerifyDetached(keyring, content, signature)
+}
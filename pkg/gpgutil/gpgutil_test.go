@@ -0,0 +1,67 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gpgutil
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fixtures are signed by a test key exported in testdata/keyring.gpg,
+// except for oval.xml.untrusted.asc which is signed by another key.
+func readTestData(t *testing.T, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.Nil(t, err)
+	return content
+}
+
+func TestVerifyDetached(t *testing.T) {
+	if _, err := exec.LookPath("gpgv"); err != nil {
+		t.Skip("gpgv is not installed")
+	}
+
+	keyring := filepath.Join("testdata", "keyring.gpg")
+	content := readTestData(t, "oval.xml")
+
+	assert.Nil(t, VerifyDetached(keyring, content, readTestData(t, "oval.xml.asc")))
+
+	tampered := append(append([]byte{}, content...), "<garbage/>"...)
+	assert.Equal(t, ErrBadSignature, VerifyDetached(keyring, tampered, readTestData(t, "oval.xml.asc")))
+	assert.Equal(t, ErrBadSignature, VerifyDetached(keyring, content, readTestData(t, "oval.xml.untrusted.asc")))
+	assert.Equal(t, ErrBadSignature, VerifyDetached(keyring, content, []byte("not a signature")))
+}
+
+func TestVerifyDownload(t *testing.T) {
+	if _, err := exec.LookPath("gpgv"); err != nil {
+		t.Skip("gpgv is not installed")
+	}
+
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	keyring := filepath.Join("testdata", "keyring.gpg")
+	content := readTestData(t, "oval.xml")
+	assert.Nil(t, VerifyDownload("test", keyring, server.URL+"/oval.xml", content))
+
+	// A missing signature fails verification.
+	assert.NotNil(t, VerifyDownload("test", keyring, server.URL+"/missing.xml", content))
+}
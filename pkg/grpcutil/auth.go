@@ -0,0 +1,239 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// clientCommonNameKey is the metadata in which the gateway forwards the
+// common name of the verified certificate of its HTTP clients.
+const clientCommonNameKey = "x-clair-client-common-name"
+
+// healthServicePrefix is the prefix of the methods of the standard gRPC
+// health service.
+const healthServicePrefix = "/grpc.health.v1.Health/"
+
+// AuthorizationRule allows the clients presenting a verified certificate of
+// the common name, and bearing the token, to call the gRPC methods.
+//
+// A rule without a common name or a token applies to every client. The
+// methods are full method names, as in "/coreos.clair.AncestryService/PostAncestry",
+// all the methods of a service, as in "/coreos.clair.AncestryService/*", or
+// "*" for all the methods.
+type AuthorizationRule struct {
+	CommonName string
+	Token      string
+	Methods    []string
+}
+
+// Client is a client of an API, identified by the common name of its verified
+// certificate and by its bearer token, which are empty when it has none.
+type Client struct {
+	CommonName string
+	Token      string
+}
+
+func (r AuthorizationRule) matches(client Client, method string) bool {
+	if r.CommonName != "" && r.CommonName != client.CommonName {
+		return false
+	}
+
+	if r.Token != "" && subtle.ConstantTimeCompare([]byte(r.Token), []byte(client.Token)) != 1 {
+		return false
+	}
+
+	for _, pattern := range r.Methods {
+		if pattern == "*" || pattern == method || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(method, pattern[:len(pattern)-1])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorizer authorizes the clients to call the gRPC methods by rules.
+//
+// A nil Authorizer authorizes every call.
+type Authorizer struct {
+	rules []AuthorizationRule
+
+	// gateway is the certificate presented by the gateway, which is trusted
+	// to forward the common name of its clients.
+	gateway []byte
+}
+
+// NewAuthorizer returns the Authorizer of the rules, which is nil when there
+// are none.
+func NewAuthorizer(rules []AuthorizationRule) (*Authorizer, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	for _, rule := range rules {
+		if len(rule.Methods) == 0 {
+			return nil, fmt.Errorf("authorization rule of %q should allow methods", rule.CommonName)
+		}
+
+		for _, pattern := range rule.Methods {
+			if pattern != "*" && (!strings.HasPrefix(pattern, "/") || strings.Count(pattern, "/") != 2) {
+				return nil, fmt.Errorf("invalid authorized method %q", pattern)
+			}
+		}
+	}
+
+	return &Authorizer{rules: rules}, nil
+}
+
+// Authorize returns a gRPC Unauthenticated error if the anonymous client is
+// not allowed to call the method, a PermissionDenied one if the
+// authenticated client is not, and nil otherwise.
+//
+// The methods of the health service are always allowed: the probes calling
+// them are rarely able to authenticate.
+func (a *Authorizer) Authorize(client Client, method string) error {
+	if a == nil || strings.HasPrefix(method, healthServicePrefix) {
+		return nil
+	}
+
+	for _, rule := range a.rules {
+		if rule.matches(client, method) {
+			return nil
+		}
+	}
+
+	if client.CommonName == "" && client.Token == "" {
+		return status.Errorf(codes.Unauthenticated, "a client certificate or a bearer token is required to call %s", method)
+	}
+	return status.Errorf(codes.PermissionDenied, "the client is not allowed to call %s", method)
+}
+
+// ClientFromContext returns the client of a gRPC call.
+//
+// The common name forwarded by the gateway replaces its own.
+func (a *Authorizer) ClientFromContext(ctx context.Context) Client {
+	var client Client
+	md, _ := metadata.FromIncomingContext(ctx)
+	client.Token = bearerToken(md["authorization"])
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return client
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return client
+	}
+
+	certificate := verifiedCertificate(info.State.VerifiedChains)
+	if certificate == nil {
+		return client
+	}
+
+	if a != nil && a.gateway != nil && bytes.Equal(certificate.Raw, a.gateway) {
+		if forwarded := md[clientCommonNameKey]; len(forwarded) == 1 {
+			client.CommonName = forwarded[0]
+		}
+		return client
+	}
+
+	client.CommonName = certificate.Subject.CommonName
+	return client
+}
+
+// ClientFromRequest returns the client of an HTTP request.
+func ClientFromRequest(r *http.Request) Client {
+	client := Client{Token: bearerToken(r.Header["Authorization"])}
+	if r.TLS != nil {
+		if certificate := verifiedCertificate(r.TLS.VerifiedChains); certificate != nil {
+			client.CommonName = certificate.Subject.CommonName
+		}
+	}
+
+	return client
+}
+
+// UnaryServerInterceptor authorizes the unary calls.
+func (a *Authorizer) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := a.Authorize(a.ClientFromContext(ctx), info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor authorizes the streaming calls.
+func (a *Authorizer) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := a.Authorize(a.ClientFromContext(ss.Context()), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// forwardClientCommonName is the metadata annotator of the gateway
+// forwarding the common name of the verified certificate of its clients.
+func forwardClientCommonName(ctx context.Context, r *http.Request) metadata.MD {
+	client := ClientFromRequest(r)
+	if client.CommonName == "" {
+		return nil
+	}
+
+	return metadata.Pairs(clientCommonNameKey, client.CommonName)
+}
+
+// gatewayHeaderMatcher forwards the headers of the default matcher of the
+// gateway, but the one the clients could use to forward a common name.
+func gatewayHeaderMatcher(key string) (string, bool) {
+	key, ok := runtime.DefaultHeaderMatcher(key)
+	if strings.EqualFold(key, clientCommonNameKey) {
+		return "", false
+	}
+
+	return key, ok
+}
+
+func bearerToken(values []string) string {
+	for _, value := range values {
+		if strings.HasPrefix(value, "Bearer ") {
+			return strings.TrimPrefix(value, "Bearer ")
+		}
+	}
+
+	return ""
+}
+
+func verifiedCertificate(chains [][]*x509.Certificate) *x509.Certificate {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+
+	return chains[0][0]
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+)
+
+var testRules = []AuthorizationRule{
+	{Methods: []string{"/coreos.clair.StatusService/GetStatus"}},
+	{Token: "ci", Methods: []string{"/coreos.clair.StatusService/ListUpdaters"}},
+	{CommonName: "admin", Methods: []string{"/coreos.clair.StatusService/*"}},
+}
+
+func TestAuthorize(t *testing.T) {
+	authorizer, err := NewAuthorizer(testRules)
+	require.Nil(t, err)
+
+	for _, test := range []struct {
+		client Client
+		method string
+		code   codes.Code
+	}{
+		{Client{}, "/coreos.clair.StatusService/GetStatus", codes.OK},
+		{Client{}, "/coreos.clair.StatusService/ListUpdaters", codes.Unauthenticated},
+		{Client{Token: "ci"}, "/coreos.clair.StatusService/ListUpdaters", codes.OK},
+		{Client{Token: "ci"}, "/coreos.clair.StatusService/ListNamespaces", codes.PermissionDenied},
+		{Client{Token: "other"}, "/coreos.clair.StatusService/ListUpdaters", codes.PermissionDenied},
+		{Client{CommonName: "admin"}, "/coreos.clair.StatusService/ListNamespaces", codes.OK},
+		{Client{CommonName: "admin"}, "/coreos.clair.AncestryService/PostAncestry", codes.PermissionDenied},
+		{Client{CommonName: "other", Token: "ci"}, "/coreos.clair.StatusService/ListUpdaters", codes.OK},
+	} {
+		assert.Equal(t, test.code, status.Code(authorizer.Authorize(test.client, test.method)), "%+v %s", test.client, test.method)
+	}
+
+	// Everything is authorized without rules.
+	authorizer, err = NewAuthorizer(nil)
+	require.Nil(t, err)
+	assert.Nil(t, authorizer.Authorize(Client{}, "/coreos.clair.AncestryService/PostAncestry"))
+
+	for _, rules := range [][]AuthorizationRule{
+		{{CommonName: "admin"}},
+		{{CommonName: "admin", Methods: []string{"PostAncestry"}}},
+		{{CommonName: "admin", Methods: []string{"/coreos.clair.AncestryService"}}},
+	} {
+		_, err := NewAuthorizer(rules)
+		assert.NotNil(t, err, "%+v", rules)
+	}
+}
+
+func TestParseClientAuth(t *testing.T) {
+	clientAuth, err := ParseClientAuth("verify-if-given")
+	require.Nil(t, err)
+	assert.Equal(t, tls.VerifyClientCertIfGiven, clientAuth)
+	_, err = ParseClientAuth("optional")
+	assert.NotNil(t, err)
+}
+
+type statusServer struct{}
+
+func (statusServer) GetStatus(context.Context, *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
+	return &pb.GetStatusResponse{}, nil
+}
+
+func (statusServer) ListUpdaters(context.Context, *pb.ListUpdatersRequest) (*pb.ListUpdatersResponse, error) {
+	return &pb.ListUpdatersResponse{}, nil
+}
+
+func (statusServer) ListNamespaces(context.Context, *pb.ListNamespacesRequest) (*pb.ListNamespacesResponse, error) {
+	return &pb.ListNamespacesResponse{}, nil
+}
+
+func (statusServer) GetStatistics(context.Context, *pb.GetStatisticsRequest) (*pb.GetStatisticsResponse, error) {
+	return &pb.GetStatisticsResponse{}, nil
+}
+
+func TestAuthorizerInterceptors(t *testing.T) {
+	authorizer, err := NewAuthorizer(testRules)
+	require.Nil(t, err)
+
+	ca := issueCertificate(t, "ca", nil)
+	tlsConfig := NewTLSConfig(tls.VersionTLS12, nil)
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	srv := MuxedGRPCServer{
+		TLSConfig:           tlsConfig,
+		Authorizer:          authorizer,
+		ServicesFunc:        func(gsrv *grpc.Server) { pb.RegisterStatusServiceServer(gsrv, statusServer{}) },
+		ServiceHandlerFuncs: []RegisterServiceHandlerFunc{pb.RegisterStatusServiceHandler},
+	}
+	addr, stop := serveTLS(t, &srv, issueCertificate(t, "clair", &ca), ca)
+	defer stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	clientConfig := func(commonName string) *tls.Config {
+		config := &tls.Config{RootCAs: roots}
+		if commonName != "" {
+			config.Certificates = []tls.Certificate{issueCertificate(t, commonName, &ca)}
+		}
+		return config
+	}
+
+	call := func(commonName, token, forwarded string, method func(pb.StatusServiceClient, context.Context) error) codes.Code {
+		conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientConfig(commonName))))
+		require.Nil(t, err)
+		defer conn.Close()
+
+		var md []string
+		if token != "" {
+			md = append(md, "authorization", "Bearer "+token)
+		}
+		if forwarded != "" {
+			md = append(md, clientCommonNameKey, forwarded)
+		}
+		ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(md...))
+		return status.Code(method(pb.NewStatusServiceClient(conn), ctx))
+	}
+	getStatus := func(c pb.StatusServiceClient, ctx context.Context) error {
+		_, err := c.GetStatus(ctx, &pb.GetStatusRequest{})
+		return err
+	}
+	listUpdaters := func(c pb.StatusServiceClient, ctx context.Context) error {
+		_, err := c.ListUpdaters(ctx, &pb.ListUpdatersRequest{})
+		return err
+	}
+	listNamespaces := func(c pb.StatusServiceClient, ctx context.Context) error {
+		_, err := c.ListNamespaces(ctx, &pb.ListNamespacesRequest{})
+		return err
+	}
+
+	assert.Equal(t, codes.OK, call("", "", "", getStatus))
+	assert.Equal(t, codes.Unauthenticated, call("", "", "", listUpdaters))
+	assert.Equal(t, codes.OK, call("", "ci", "", listUpdaters))
+	assert.Equal(t, codes.PermissionDenied, call("other", "", "", listUpdaters))
+	assert.Equal(t, codes.PermissionDenied, call("other", "ci", "", listNamespaces))
+	assert.Equal(t, codes.OK, call("admin", "", "", listNamespaces))
+	// Only the gateway may forward a common name.
+	assert.Equal(t, codes.PermissionDenied, call("other", "", "admin", listNamespaces))
+
+	get := func(commonName, path string, header http.Header) int {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientConfig(commonName)}}
+		defer client.CloseIdleConnections()
+
+		req, err := http.NewRequest(http.MethodGet, "https://"+addr+path, nil)
+		require.Nil(t, err)
+		if header != nil {
+			req.Header = header
+		}
+
+		resp, err := client.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("", "/status", nil))
+	assert.Equal(t, http.StatusUnauthorized, get("", "/updaters", nil))
+	assert.Equal(t, http.StatusOK, get("", "/updaters", http.Header{"Authorization": {"Bearer ci"}}))
+	assert.Equal(t, http.StatusForbidden, get("other", "/namespaces", nil))
+	assert.Equal(t, http.StatusOK, get("admin", "/namespaces", nil))
+	assert.Equal(t, http.StatusForbidden, get("other", "/namespaces", http.Header{"Grpc-Metadata-X-Clair-Client-Common-Name": {"admin"}}))
+	// The gateway does not lend its own certificate to its clients.
+	assert.Equal(t, http.StatusUnauthorized, get("", "/namespaces", nil))
+}
@@ -31,9 +31,13 @@ type RegisterServiceHandlerFunc func(context.Context, *runtime.ServeMux, *grpc.C
 
 // NewGateway creates a new http.Handler and grpc.ClientConn with the provided
 // gRPC Services registered.
-func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandlerFunc) (http.Handler, *grpc.ClientConn, error) {
+//
+// The gateway forwards the common name of the verified certificate of its
+// clients, which the Authorizer trusts from the gateway only. The options are
+// applied to its connection to the gRPC server.
+func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandlerFunc, opts ...grpc.DialOption) (http.Handler, *grpc.ClientConn, error) {
 	// Configure the right DialOptions the for TLS configuration.
-	var dialOpts []grpc.DialOption
+	dialOpts := append([]grpc.DialOption(nil), opts...)
 	if tlsConfig != nil {
 		var gwTLSConfig *tls.Config
 		gwTLSConfig = tlsConfig.Clone()
@@ -49,7 +53,10 @@ func NewGateway(addr string, tlsConfig *tls.Config, funcs []RegisterServiceHandl
 	}
 
 	// Register services.
-	srvmux := runtime.NewServeMux()
+	srvmux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(forwardClientCommonName),
+	)
 	for _, fn := range funcs {
 		err = fn(context.TODO(), srvmux, conn)
 		if err != nil {
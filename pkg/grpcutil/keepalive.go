@@ -0,0 +1,66 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// KeepaliveConfig is the keepalive configuration of a gRPC server: the zero
+// values keep the defaults of gRPC.
+//
+// It only applies to the gRPC connections served without TLS, since the ones
+// over TLS are served by the HTTP/2 server of net/http.
+type KeepaliveConfig struct {
+	// MaxConnectionIdle is how long an idle connection is kept open.
+	MaxConnectionIdle time.Duration
+	// MaxConnectionAge is how long a connection is kept open, so that the
+	// clients reconnect to the new instances behind a load balancer.
+	MaxConnectionAge time.Duration
+	// MaxConnectionAgeGrace is how long the calls in progress are waited for
+	// once a connection is too old.
+	MaxConnectionAgeGrace time.Duration
+	// Time is how long a connection is idle before the server pings the
+	// client, and Timeout how long the ping is waited for.
+	Time, Timeout time.Duration
+
+	// MinTime is the minimum duration between the pings of a client, which
+	// is disconnected when it pings more often.
+	MinTime time.Duration
+	// PermitWithoutStream allows the clients to ping without calls in
+	// progress.
+	PermitWithoutStream bool
+}
+
+// ServerOptions returns the options of a gRPC server applying the
+// configuration.
+func (c KeepaliveConfig) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     c.MaxConnectionIdle,
+			MaxConnectionAge:      c.MaxConnectionAge,
+			MaxConnectionAgeGrace: c.MaxConnectionAgeGrace,
+			Time:                  c.Time,
+			Timeout:               c.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.MinTime,
+			PermitWithoutStream: c.PermitWithoutStream,
+		}),
+	}
+}
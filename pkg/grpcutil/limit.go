@@ -0,0 +1,229 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxClientBuckets is the number of clients whose rate is tracked from which
+// the clients which didn't call recently are forgotten.
+const maxClientBuckets = 10000
+
+var promLimitedRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "clair_grpc_server_limited_requests_total",
+	Help: "Number of gRPC requests rejected by the limiter, by method and reason.",
+}, []string{"method", "reason"})
+
+func init() {
+	prometheus.MustRegister(promLimitedRequestsTotal)
+}
+
+// LimitConfig limits the calls of the expensive methods of a gRPC server: the
+// zero values don't limit them.
+type LimitConfig struct {
+	// MaxConcurrent is the number of calls processed at the same time.
+	MaxConcurrent int
+	// QueueTimeout is how long the calls exceeding MaxConcurrent wait to be
+	// processed before being rejected.
+	QueueTimeout time.Duration
+
+	// ClientRate is the number of calls per second allowed to every client,
+	// and ClientBurst the number of calls it may make at once. A client is
+	// identified by the common name of its certificate, by its bearer token,
+	// or else by its address.
+	ClientRate  float64
+	ClientBurst int
+}
+
+// Limiter limits the concurrent calls of some methods, and the rate at which
+// every client calls them.
+type Limiter struct {
+	config  LimitConfig
+	methods map[string]bool
+	slots   chan struct{}
+
+	authorizer *Authorizer
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket holds the calls a client may make.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns the limiter of the given methods, whose clients are
+// identified as the authorizer does, if any.
+func NewLimiter(config LimitConfig, methods []string, authorizer *Authorizer) *Limiter {
+	l := &Limiter{
+		config:     config,
+		methods:    map[string]bool{},
+		authorizer: authorizer,
+		buckets:    map[string]*tokenBucket{},
+	}
+
+	for _, method := range methods {
+		l.methods[method] = true
+	}
+
+	if config.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrent)
+	}
+
+	if l.config.ClientBurst <= 0 {
+		l.config.ClientBurst = 1
+	}
+
+	return l
+}
+
+// clientKey returns the key identifying the client of a call.
+//
+// The calls of the gateway are identified by the address it forwards, the
+// last one of the X-Forwarded-For header it appends to, which is only trusted
+// from a loopback address.
+func (l *Limiter) clientKey(ctx context.Context) string {
+	client := l.authorizer.ClientFromContext(ctx)
+	if client.CommonName != "" {
+		return "cn:" + client.CommonName
+	}
+
+	if client.Token != "" {
+		sum := sha256.Sum256([]byte(client.Token))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "addr:"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if forwarded := md["x-forwarded-for"]; len(forwarded) != 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			host = strings.TrimSpace(addrs[len(addrs)-1])
+		}
+	}
+
+	return "addr:" + host
+}
+
+// allow takes a token from the bucket of the client, if it has any left.
+func (l *Limiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	burst := float64(l.config.ClientBurst)
+	if len(l.buckets) >= maxClientBuckets {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.config.ClientRate >= burst {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*l.config.ClientRate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// acquire waits for the call of the method to be allowed, and returns the
+// function to call once it is done.
+func (l *Limiter) acquire(ctx context.Context, method string) (func(), error) {
+	if l == nil || !l.methods[method] {
+		return func() {}, nil
+	}
+
+	if l.config.ClientRate > 0 && !l.allow(l.clientKey(ctx), time.Now()) {
+		promLimitedRequestsTotal.WithLabelValues(method, "rate").Inc()
+		return nil, status.Error(codes.ResourceExhausted, "client rate limit exceeded")
+	}
+
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	timer := time.NewTimer(l.config.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-timer.C:
+		promLimitedRequestsTotal.WithLabelValues(method, "concurrency").Inc()
+		return nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+		}
+		return nil, status.Error(codes.Canceled, ctx.Err().Error())
+	}
+}
+
+// UnaryServerInterceptor limits the unary calls.
+func (l *Limiter) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	release, err := l.acquire(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor limits the streaming calls.
+func (l *Limiter) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	release, err := l.acquire(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return handler(srv, ss)
+}
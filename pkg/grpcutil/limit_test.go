@@ -0,0 +1,88 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const limitedMethod = "/coreos.clair.AncestryService/PostAncestry"
+
+func peerContext(addr string, md metadata.MD) context.Context {
+	tcpAddr, _ := net.ResolveTCPAddr("tcp", addr)
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: tcpAddr})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func TestLimiterClientRate(t *testing.T) {
+	limiter := NewLimiter(LimitConfig{ClientRate: 1, ClientBurst: 2}, []string{limitedMethod}, nil)
+
+	now := time.Now()
+	assert.True(t, limiter.allow("client", now))
+	assert.True(t, limiter.allow("client", now))
+	assert.False(t, limiter.allow("client", now))
+
+	// The other clients have their own bucket.
+	assert.True(t, limiter.allow("other", now))
+
+	// A token is added every second, up to the burst.
+	assert.False(t, limiter.allow("client", now.Add(500*time.Millisecond)))
+	assert.True(t, limiter.allow("client", now.Add(1500*time.Millisecond)))
+	assert.True(t, limiter.allow("client", now.Add(time.Hour)))
+	assert.True(t, limiter.allow("client", now.Add(time.Hour)))
+	assert.False(t, limiter.allow("client", now.Add(time.Hour)))
+
+	handler := func(context.Context, interface{}) (interface{}, error) { return nil, nil }
+	ctx := peerContext("192.0.2.1:1234", nil)
+	for i := 0; i < 2; i++ {
+		_, err := limiter.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: limitedMethod}, handler)
+		assert.Nil(t, err)
+	}
+
+	_, err := limiter.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: limitedMethod}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The other methods are not limited.
+	_, err = limiter.UnaryServerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/coreos.clair.AncestryService/GetAncestry"}, handler)
+	assert.Nil(t, err)
+}
+
+func TestLimiterClientKey(t *testing.T) {
+	limiter := NewLimiter(LimitConfig{}, nil, nil)
+
+	assert.Equal(t, "addr:192.0.2.1", limiter.clientKey(peerContext("192.0.2.1:1234", nil)))
+
+	// The address forwarded by the gateway is only trusted from a loopback
+	// address.
+	forwarded := metadata.Pairs("x-forwarded-for", "203.0.113.1, 192.0.2.2")
+	assert.Equal(t, "addr:192.0.2.2", limiter.clientKey(peerContext("127.0.0.1:1234", forwarded)))
+	assert.Equal(t, "addr:192.0.2.1", limiter.clientKey(peerContext("192.0.2.1:1234", forwarded)))
+
+	// The authenticated clients are identified by their token.
+	token := metadata.Pairs("authorization", "Bearer secret")
+	key := limiter.clientKey(peerContext("192.0.2.1:1234", token))
+	assert.Equal(t, key, limiter.clientKey(peerContext("192.0.2.2:1234", token)))
+	assert.NotContains(t, key, "secret")
+}
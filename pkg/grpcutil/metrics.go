@@ -0,0 +1,117 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	promRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "clair_grpc_server_requests_total",
+		Help: "Number of gRPC requests handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	promRequestDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clair_grpc_server_request_duration_seconds",
+		Help:    "Time it takes to handle a gRPC request, by method and status code.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"method", "code"})
+
+	promRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "clair_grpc_server_requests_in_flight",
+		Help: "Number of gRPC requests being handled.",
+	})
+
+	promPanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "clair_grpc_server_panics_total",
+		Help: "Number of panics recovered while handling gRPC requests, by method.",
+	}, []string{"method"})
+)
+
+func init() {
+	prometheus.MustRegister(promRequestsTotal)
+	prometheus.MustRegister(promRequestDurationSeconds)
+	prometheus.MustRegister(promRequestsInFlight)
+	prometheus.MustRegister(promPanicsTotal)
+}
+
+// observeRequest records a handled request of the method, which started at
+// start and returned err.
+func observeRequest(method string, start time.Time, err error) {
+	code := status.Code(err).String()
+	promRequestsTotal.WithLabelValues(method, code).Inc()
+	promRequestDurationSeconds.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
+
+// metricsUnaryInterceptor counts the unary calls and measures their duration.
+func metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	promRequestsInFlight.Inc()
+	defer promRequestsInFlight.Dec()
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	observeRequest(info.FullMethod, start, err)
+	return resp, err
+}
+
+// metricsStreamInterceptor counts the streaming calls and measures their
+// duration.
+func metricsStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	promRequestsInFlight.Inc()
+	defer promRequestsInFlight.Dec()
+
+	start := time.Now()
+	err := handler(srv, ss)
+	observeRequest(info.FullMethod, start, err)
+	return err
+}
+
+// recoverPanic converts a panic of the handler of the method, if any, to an
+// internal error.
+func recoverPanic(method string, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	promPanicsTotal.WithLabelValues(method).Inc()
+	log.WithFields(log.Fields{
+		"method": method,
+		"panic":  r,
+		"stack":  string(debug.Stack()),
+	}).Error("recovered from a panic while handling a gRPC request")
+	*err = status.Error(codes.Internal, "internal error")
+}
+
+// recoveryUnaryInterceptor recovers from the panics of the unary calls.
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer recoverPanic(info.FullMethod, &err)
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor recovers from the panics of the streaming calls.
+func recoveryStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverPanic(info.FullMethod, &err)
+	return handler(srv, ss)
+}
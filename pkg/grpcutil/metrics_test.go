@@ -0,0 +1,145 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/quay/clair/v3/api/v3/clairpb"
+)
+
+// testStatusServer succeeds to get the status, fails to list the updaters and
+// panics while listing the namespaces.
+type testStatusServer struct{}
+
+func (testStatusServer) GetStatus(context.Context, *pb.GetStatusRequest) (*pb.GetStatusResponse, error) {
+	return &pb.GetStatusResponse{}, nil
+}
+
+func (testStatusServer) ListUpdaters(context.Context, *pb.ListUpdatersRequest) (*pb.ListUpdatersResponse, error) {
+	return nil, status.Error(codes.Unavailable, "database is down")
+}
+
+func (testStatusServer) ListNamespaces(context.Context, *pb.ListNamespacesRequest) (*pb.ListNamespacesResponse, error) {
+	panic("unexpected namespace")
+}
+
+func (testStatusServer) GetStatistics(context.Context, *pb.GetStatisticsRequest) (*pb.GetStatisticsResponse, error) {
+	return &pb.GetStatisticsResponse{}, nil
+}
+
+// metricValue returns the value of the counter, or the sample count of the
+// histogram, whose labels all have the given values.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.Nil(t, err)
+
+	var value float64
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if expected, ok := labels[label.GetName()]; ok && expected != label.GetValue() {
+					continue metrics
+				}
+			}
+
+			value += metric.GetCounter().GetValue() + float64(metric.GetHistogram().GetSampleCount())
+		}
+	}
+
+	return value
+}
+
+func TestServerMetrics(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+
+	gsrv := NewServer(nil, nil, nil, func(gsrv *grpc.Server) {
+		pb.RegisterStatusServiceServer(gsrv, testStatusServer{})
+	})
+	go gsrv.Serve(l)
+	defer gsrv.Stop()
+
+	conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.Nil(t, err)
+	defer conn.Close()
+	client := pb.NewStatusServiceClient(conn)
+
+	for _, test := range []struct {
+		method string
+		code   codes.Code
+		call   func() error
+	}{
+		{"/coreos.clair.StatusService/GetStatus", codes.OK, func() error {
+			_, err := client.GetStatus(context.Background(), &pb.GetStatusRequest{})
+			return err
+		}},
+		{"/coreos.clair.StatusService/ListUpdaters", codes.Unavailable, func() error {
+			_, err := client.ListUpdaters(context.Background(), &pb.ListUpdatersRequest{})
+			return err
+		}},
+		// The panic is converted to an internal error.
+		{"/coreos.clair.StatusService/ListNamespaces", codes.Internal, func() error {
+			_, err := client.ListNamespaces(context.Background(), &pb.ListNamespacesRequest{})
+			return err
+		}},
+	} {
+		labels := map[string]string{"method": test.method, "code": test.code.String()}
+		requests := metricValue(t, "clair_grpc_server_requests_total", labels)
+		durations := metricValue(t, "clair_grpc_server_request_duration_seconds", labels)
+		panics := metricValue(t, "clair_grpc_server_panics_total", labels)
+
+		assert.Equal(t, test.code, status.Code(test.call()), test.method)
+		assert.Equal(t, requests+1, metricValue(t, "clair_grpc_server_requests_total", labels), test.method)
+		assert.Equal(t, durations+1, metricValue(t, "clair_grpc_server_request_duration_seconds", labels), test.method)
+
+		expectedPanics := panics
+		if test.code == codes.Internal {
+			expectedPanics++
+		}
+		assert.Equal(t, expectedPanics, metricValue(t, "clair_grpc_server_panics_total", labels), test.method)
+	}
+
+	assert.Equal(t, float64(0), metricGauge(t, "clair_grpc_server_requests_in_flight"))
+}
+
+// metricGauge returns the value of the gauge.
+func metricGauge(t *testing.T, name string) float64 {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.Nil(t, err)
+
+	for _, family := range families {
+		if family.GetName() == name && len(family.GetMetric()) == 1 {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+
+	t.Fatalf("gauge %s is not registered", name)
+	return 0
+}
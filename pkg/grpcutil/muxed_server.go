@@ -16,33 +16,44 @@
 package grpcutil
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"time"
 
 	"github.com/soheilhy/cmux"
+	"google.golang.org/grpc"
 
 	"github.com/quay/clair/v3/pkg/httputil"
 )
 
+// shutdownTimeout is how long requests in progress are waited for when
+// shutting down.
+const shutdownTimeout = 10 * time.Second
+
 // MuxedGRPCServer defines the parameters for running a gRPC Server alongside
 // a Gateway server on the same port.
 type MuxedGRPCServer struct {
 	Addr                string
 	TLSConfig           *tls.Config
+	Authorizer          *Authorizer
+	Limiter             *Limiter
+	ServerOptions       []grpc.ServerOption
+	GatewayOptions      []grpc.DialOption
 	ServicesFunc        RegisterServicesFunc
 	ServiceHandlerFuncs []RegisterServiceHandlerFunc
 }
 
 // ListenAndServe listens on the TCP network address srv.Addr and handles both
-// gRPC and JSON requests over HTTP. An optional HTTP middleware can be
-// provided to wrap the output of each request.
+// gRPC and JSON requests over HTTP until the context is done. An optional
+// HTTP middleware can be provided to wrap the output of each request.
 //
 // Internally, it muxes the Listener based on whether the request is gRPC or
 // HTTP and runs multiple servers.
-func (srv *MuxedGRPCServer) ListenAndServe(mw httputil.Middleware) error {
+func (srv *MuxedGRPCServer) ListenAndServe(ctx context.Context, mw httputil.Middleware) error {
 	l, err := net.Listen("tcp", srv.Addr)
 	if err != nil {
 		return err
@@ -56,13 +67,13 @@ func (srv *MuxedGRPCServer) ListenAndServe(mw httputil.Middleware) error {
 	httpListener := tcpMux.Match(cmux.Any())
 	defer httpListener.Close()
 
-	httpHandler, conn, err := NewGateway(httpListener.Addr().String(), nil, srv.ServiceHandlerFuncs)
+	httpHandler, conn, err := NewGateway(httpListener.Addr().String(), nil, srv.ServiceHandlerFuncs, srv.GatewayOptions...)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	gsrv := NewServer(nil, srv.ServicesFunc)
+	gsrv := NewServer(nil, srv.Authorizer, srv.Limiter, srv.ServicesFunc, srv.ServerOptions...)
 	defer gsrv.Stop()
 
 	go func() { tcpMux.Serve() }()
@@ -75,10 +86,39 @@ func (srv *MuxedGRPCServer) ListenAndServe(mw httputil.Middleware) error {
 	httpsrv := &http.Server{
 		Handler: httpHandler,
 	}
-	httpsrv.Serve(httpListener)
+	stopped := shutdownOnDone(ctx, httpsrv, func() {
+		conn.Close()
+		gsrv.Stop()
+	})
+	if err := httpsrv.Serve(httpListener); err != http.ErrServerClosed && ctx.Err() == nil {
+		return err
+	}
+	<-stopped
 	return nil
 }
 
+// shutdownOnDone stops the gRPC side of the server and gracefully shuts down
+// the HTTP server once the context is done. The returned channel is closed
+// after that.
+//
+// The gateway connection must be closed first: the muxed listener waits for
+// the connections it is still sniffing, such as an idle gateway connection.
+// Stopping the gRPC server also closes the muxed listener, so the HTTP server
+// may stop serving before it is shut down.
+func shutdownOnDone(ctx context.Context, httpsrv *http.Server, stopGRPC func()) <-chan struct{} {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		stopGRPC()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		httpsrv.Shutdown(shutdownCtx)
+	}()
+	return stopped
+}
+
 func configureCA(tlsConfig *tls.Config, caPath string) error {
 	caCert, err := ioutil.ReadFile(caPath)
 	if err != nil {
@@ -89,7 +129,6 @@ func configureCA(tlsConfig *tls.Config, caPath string) error {
 	caCertPool.AppendCertsFromPEM(caCert)
 
 	tlsConfig.ClientCAs = caCertPool
-	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 
 	return nil
 }
@@ -107,65 +146,31 @@ func configureCertificate(tlsConfig *tls.Config, certFile, keyFile string) error
 }
 
 // ListenAndServeTLS listens on the TCP network address srv.Addr and handles both
-// gRPC and JSON requests over HTTP over TLS. An optional HTTP middleware can
-// be provided to wrap the output of each request.
+// gRPC and JSON requests over HTTP over TLS until the context is done. An
+// optional HTTP middleware can be provided to wrap the output of each request.
+//
+// The client certificates are verified against the CA, if any, as required
+// by srv.TLSConfig, which requires them by default.
 //
 // Internally, the same net.Listener is used because the http.Handler will
 // pivot based on whether the request is gRPC or HTTP.
-func (srv *MuxedGRPCServer) ListenAndServeTLS(certFile, keyFile, caPath string, mw httputil.Middleware) error {
+func (srv *MuxedGRPCServer) ListenAndServeTLS(ctx context.Context, certFile, keyFile, caPath string, mw httputil.Middleware) error {
 	if srv.TLSConfig == nil {
-		srv.TLSConfig = &tls.Config{
-			// This is Go's default list of cipher suites (as of go 1.8.3),
-			// with the following differences:
-			//
-			// - 3DES-based cipher suites have been removed. This cipher is
-			//   vulnerable to the Sweet32 attack and is sometimes reported by
-			//   security scanners. (This is arguably a false positive since
-			//   it will never be selected: Any TLS1.2 implementation MUST
-			//   include at least one cipher higher in the priority list, but
-			//   there's also no reason to keep it around)
-			// - AES is always prioritized over ChaCha20. Go makes this decision
-			//   by default based on the presence or absence of hardware AES
-			//   acceleration.
-			//   TODO(bdarnell): do the same detection here. See
-			//   https://github.com/golang/go/issues/21167
-			//
-			// Note that some TLS cipher suite guidance (such as Mozilla's[1])
-			// recommend replacing the CBC_SHA suites below with CBC_SHA384 or
-			// CBC_SHA256 variants. We do not do this because Go does not
-			// currently implement the CBC_SHA384 suites, and its CBC_SHA256
-			// implementation is vulnerable to the Lucky13 attack and is disabled
-			// by default.[2]
-			//
-			// [1]: https://wiki.mozilla.org/Security/Server_Side_TLS#Modern_compatibility
-			// [2]: https://github.com/golang/go/commit/48d8edb5b21db190f717e035b4d9ab61a077f9d7
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
-				tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_RSA_WITH_AES_128_CBC_SHA,
-				tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-			},
-
-			MinVersion: tls.VersionTLS12,
+		srv.TLSConfig = NewTLSConfig(tls.VersionTLS12, nil)
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if caPath != "" {
+		if err := configureCA(srv.TLSConfig, caPath); err != nil {
+			return err
 		}
 	}
-	err := configureCA(srv.TLSConfig, caPath)
+	err := configureCertificate(srv.TLSConfig, certFile, keyFile)
 	if err != nil {
 		return err
 	}
-	err = configureCertificate(srv.TLSConfig, certFile, keyFile)
-	if err != nil {
-		return err
+	if srv.Authorizer != nil {
+		// The gateway presents the certificate of the server.
+		srv.Authorizer.gateway = srv.TLSConfig.Certificates[0].Certificate[0]
 	}
 
 	listener, err := tls.Listen("tcp", srv.Addr, srv.TLSConfig)
@@ -173,13 +178,13 @@ func (srv *MuxedGRPCServer) ListenAndServeTLS(certFile, keyFile, caPath string,
 		return err
 	}
 
-	gwHandler, conn, err := NewGateway(listener.Addr().String(), srv.TLSConfig, srv.ServiceHandlerFuncs)
+	gwHandler, conn, err := NewGateway(listener.Addr().String(), srv.TLSConfig, srv.ServiceHandlerFuncs, srv.GatewayOptions...)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	gsrv := NewServer(srv.TLSConfig, srv.ServicesFunc)
+	gsrv := NewServer(srv.TLSConfig, srv.Authorizer, srv.Limiter, srv.ServicesFunc, srv.ServerOptions...)
 	defer gsrv.Stop()
 
 	httpHandler := HandlerFunc(gsrv, gwHandler)
@@ -190,6 +195,13 @@ func (srv *MuxedGRPCServer) ListenAndServeTLS(certFile, keyFile, caPath string,
 	httpsrv := &http.Server{
 		Handler: httpHandler,
 	}
-	httpsrv.Serve(listener)
+	stopped := shutdownOnDone(ctx, httpsrv, func() {
+		conn.Close()
+		gsrv.Stop()
+	})
+	if err := httpsrv.Serve(listener); err != http.ErrServerClosed && ctx.Err() == nil {
+		return err
+	}
+	<-stopped
 	return nil
 }
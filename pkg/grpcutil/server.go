@@ -15,6 +15,7 @@
 package grpcutil
 
 import (
+	"context"
 	"crypto/tls"
 
 	"github.com/grpc-ecosystem/go-grpc-prometheus"
@@ -27,20 +28,58 @@ import (
 type RegisterServicesFunc func(*grpc.Server)
 
 // NewServer allocates a new grpc.Server and handles some some boilerplate
-// configuration.
-func NewServer(tlsConfig *tls.Config, fn RegisterServicesFunc) *grpc.Server {
+// configuration. The calls are authorized by the authorizer, then limited by
+// the limiter, if any, and the options are applied after the default ones.
+//
+// The calls are instrumented, and the panics of their handlers are recovered
+// and reported as internal errors.
+func NewServer(tlsConfig *tls.Config, authorizer *Authorizer, limiter *Limiter, fn RegisterServicesFunc, opts ...grpc.ServerOption) *grpc.Server {
+	unary := chainUnaryInterceptors(metricsUnaryInterceptor,
+		chainUnaryInterceptors(grpc_prometheus.UnaryServerInterceptor, recoveryUnaryInterceptor))
+	stream := chainStreamInterceptors(metricsStreamInterceptor,
+		chainStreamInterceptors(grpc_prometheus.StreamServerInterceptor, recoveryStreamInterceptor))
+	if authorizer != nil {
+		// The denied calls are still instrumented.
+		unary = chainUnaryInterceptors(unary, authorizer.UnaryServerInterceptor)
+		stream = chainStreamInterceptors(stream, authorizer.StreamServerInterceptor)
+	}
+
+	if limiter != nil {
+		unary = chainUnaryInterceptors(unary, limiter.UnaryServerInterceptor)
+		stream = chainStreamInterceptors(stream, limiter.StreamServerInterceptor)
+	}
+
 	// Default ServerOptions
 	grpcOpts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(grpc_prometheus.UnaryServerInterceptor),
-		grpc.StreamInterceptor(grpc_prometheus.StreamServerInterceptor),
+		grpc.UnaryInterceptor(unary),
+		grpc.StreamInterceptor(stream),
 	}
 
 	if tlsConfig != nil {
 		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
+	grpcOpts = append(grpcOpts, opts...)
 
 	// Register services with a new grpc.Server.
 	gsrv := grpc.NewServer(grpcOpts...)
 	fn(gsrv)
 	return gsrv
 }
+
+// chainUnaryInterceptors returns the interceptor calling outer, then inner.
+func chainUnaryInterceptors(outer, inner grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return outer(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return inner(ctx, req, info, handler)
+		})
+	}
+}
+
+// chainStreamInterceptors returns the interceptor calling outer, then inner.
+func chainStreamInterceptors(outer, inner grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return outer(srv, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return inner(srv, ss, info, handler)
+		})
+	}
+}
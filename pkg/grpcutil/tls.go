@@ -0,0 +1,109 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// DefaultCipherSuites are the cipher suites negotiated up to TLS 1.2 when none
+// are configured.
+//
+// Only the suites with an ECDHE key exchange, for forward secrecy, and an
+// AEAD cipher are kept: the CBC suites are prone to padding oracles, and
+// 3DES to the Sweet32 attack, which security scanners report. The suites of
+// TLS 1.3 are not configurable and always secure.
+var DefaultCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// tlsVersions are the TLS versions by the name they are configured with.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the TLS version named like "1.2".
+func ParseTLSVersion(name string) (uint16, error) {
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q", name)
+	}
+
+	return version, nil
+}
+
+// clientAuthTypes are the verifications of the client certificates by the
+// name they are configured with.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":            tls.NoClientCert,
+	"verify-if-given": tls.VerifyClientCertIfGiven,
+	"require":         tls.RequireAndVerifyClientCert,
+}
+
+// ParseClientAuth returns the verification of the client certificates named
+// "none", "verify-if-given" or "require".
+func ParseClientAuth(name string) (tls.ClientAuthType, error) {
+	clientAuth, ok := clientAuthTypes[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown client authentication %q", name)
+	}
+
+	return clientAuth, nil
+}
+
+// ParseCipherSuites returns the cipher suites of the names, as in
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". The suites which Go deems insecure
+// are refused.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := suites[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// NewTLSConfig returns the TLS configuration of a server negotiating at least
+// the TLS version, with the cipher suites or DefaultCipherSuites when there
+// are none.
+func NewTLSConfig(minVersion uint16, cipherSuites []uint16) *tls.Config {
+	if len(cipherSuites) == 0 {
+		cipherSuites = DefaultCipherSuites
+	}
+
+	return &tls.Config{
+		CipherSuites: cipherSuites,
+		MinVersion:   minVersion,
+	}
+}
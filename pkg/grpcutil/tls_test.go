@@ -0,0 +1,171 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestParseTLSConfig(t *testing.T) {
+	version, err := ParseTLSVersion("1.3")
+	require.Nil(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), version)
+	_, err = ParseTLSVersion("1.4")
+	assert.NotNil(t, err)
+
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"})
+	require.Nil(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, suites)
+	_, err = ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_RC4_128_SHA"})
+	assert.NotNil(t, err)
+	_, err = ParseCipherSuites([]string{"unknown"})
+	assert.NotNil(t, err)
+
+	assert.Equal(t, DefaultCipherSuites, NewTLSConfig(tls.VersionTLS12, nil).CipherSuites)
+}
+
+// issueCertificate issues a certificate of the common name for 127.0.0.1,
+// signed by the CA, or self-signed and a CA itself when there is none.
+func issueCertificate(t *testing.T, commonName string, ca *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, parentKey := template, interface{}(key)
+	if ca == nil {
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.BasicConstraintsValid = true
+		template.IsCA = true
+	} else {
+		parent, parentKey = ca.Leaf, ca.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.Nil(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writeCertificate writes the certificate and its key to the directory, and
+// returns their paths.
+func writeCertificate(t *testing.T, dir string, certificate tls.Certificate) (certFile, keyFile string) {
+	keyDER, err := x509.MarshalECPrivateKey(certificate.PrivateKey.(*ecdsa.PrivateKey))
+	require.Nil(t, err)
+
+	name := certificate.Leaf.Subject.CommonName
+	certFile, keyFile = filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	require.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600))
+	require.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// serveTLS serves srv on a free port of 127.0.0.1 over TLS with the
+// certificate, verifying the client certificates against the CA, and returns
+// its address and a function stopping it.
+func serveTLS(t *testing.T, srv *MuxedGRPCServer, certificate, ca tls.Certificate) (string, func()) {
+	dir, err := ioutil.TempDir("", "grpcutil")
+	require.Nil(t, err)
+	certFile, keyFile := writeCertificate(t, dir, certificate)
+	caFile, _ := writeCertificate(t, dir, ca)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	srv.Addr = l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- srv.ListenAndServeTLS(ctx, certFile, keyFile, caFile, nil) }()
+	stop := func() {
+		cancel()
+		assert.Nil(t, <-served)
+		os.RemoveAll(dir)
+	}
+
+	// The listener is up once it accepts TCP connections.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", srv.Addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			stop()
+			t.Fatal("the server did not listen")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return srv.Addr, stop
+}
+
+func TestListenAndServeTLSMinVersion(t *testing.T) {
+	// The CBC suite could be negotiated over TLS 1.0, were it not the minimum.
+	srv := MuxedGRPCServer{
+		TLSConfig:    NewTLSConfig(tls.VersionTLS12, append([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA}, DefaultCipherSuites...)),
+		ServicesFunc: func(*grpc.Server) {},
+	}
+	certificate := issueCertificate(t, "clair", nil)
+	addr, stop := serveTLS(t, &srv, certificate, certificate)
+	defer stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(certificate.Leaf)
+
+	handshake := func(version uint16) error {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{
+			RootCAs:    roots,
+			MinVersion: version,
+			MaxVersion: version,
+		})
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	assert.NotNil(t, handshake(tls.VersionTLS10), "TLS 1.0 was negotiated")
+	assert.NotNil(t, handshake(tls.VersionTLS11), "TLS 1.1 was negotiated")
+	assert.Nil(t, handshake(tls.VersionTLS12))
+	assert.Nil(t, handshake(tls.VersionTLS13))
+}
@@ -18,11 +18,16 @@ package httputil
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/quay/clair/v3/pkg/version"
 )
@@ -30,20 +35,174 @@ import (
 // Middleware is a function used to wrap the logic of another http.Handler.
 type Middleware func(http.Handler) http.Handler
 
+// Config is the configuration of the HTTP client used by the updaters and
+// the vulnerability metadata appenders.
+type Config struct {
+	// DialTimeout is the maximum duration to establish a connection.
+	DialTimeout time.Duration
+	// ResponseTimeout is the maximum duration to wait for the response
+	// headers once the request is sent.
+	ResponseTimeout time.Duration
+	// Proxy is the URL of the proxy used instead of the one set by the
+	// environment.
+	Proxy string
+	// CAFile is a PEM encoded CA bundle trusted instead of the system roots.
+	CAFile string
+	// UserAgent replaces the default Clair User-Agent.
+	UserAgent string
+	// MaxBodySize is the maximum number of bytes read from a response body.
+	// The value 0 does not limit it.
+	MaxBodySize int64
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept
+	// open to reuse per host. The value 0 uses DefaultMaxIdleConnsPerHost.
+	MaxIdleConnsPerHost int
+	// Retries is the number of times a request throttled with a 429 or 503
+	// status is retried, after the delay asked by its Retry-After header or
+	// an exponential backoff. The value 0 does not retry them.
+	Retries int
+	// MaxRetryDelay is the longest delay waited before retrying a throttled
+	// request, which fails right away when asked to wait longer. The value 0
+	// uses DefaultMaxRetryDelay.
+	MaxRetryDelay time.Duration
+}
+
+// DefaultMaxIdleConnsPerHost is the number of idle connections kept open per
+// host when none is configured: the updaters download many files from the
+// same hosts, sometimes concurrently, which the default of net/http (2) would
+// reconnect to over and over.
+const DefaultMaxIdleConnsPerHost = 16
+
+// BodyTooLargeError is returned when a response body exceeds the configured
+// maximum size.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", e.Limit)
+}
+
+var defaultUserAgent = "Clair/" + version.Version + " (https://github.com/quay/clair)"
+
+var (
+	client      = &http.Client{Transport: newTransport(&net.Dialer{Timeout: 30 * time.Second}, DefaultMaxIdleConnsPerHost)}
+	userAgent   = defaultUserAgent
+	maxBodySize int64
+
+	retries       int
+	maxRetryDelay = DefaultMaxRetryDelay
+)
+
+// Configure sets up the client used by GetWithUserAgent and
+// HeadWithUserAgent.
+//
+// It must be called before any request is sent.
+func Configure(config *Config) error {
+	if config == nil {
+		return nil
+	}
+
+	if config.MaxIdleConnsPerHost < 0 {
+		return errors.New("maximum idle connections per host should not be negative")
+	}
+
+	maxIdleConnsPerHost := config.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost == 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+
+	transport := newTransport(&net.Dialer{Timeout: config.DialTimeout}, maxIdleConnsPerHost)
+	transport.ResponseHeaderTimeout = config.ResponseTimeout
+
+	if config.Proxy != "" {
+		proxyURL, err := url.ParseRequestURI(config.Proxy)
+		if err != nil {
+			return fmt.Errorf("could not parse proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if config.CAFile != "" {
+		caCert, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return err
+		}
+
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return errors.New("could not load any certificate from the CA file")
+		}
+		transport.TLSClientConfig.RootCAs = caCertPool
+	}
+
+	if config.MaxBodySize < 0 {
+		return errors.New("maximum body size should not be negative")
+	}
+
+	if config.Retries < 0 {
+		return errors.New("retries should not be negative")
+	}
+
+	if config.MaxRetryDelay < 0 {
+		return errors.New("maximum retry delay should not be negative")
+	}
+
+	client = &http.Client{Transport: transport}
+	maxBodySize = config.MaxBodySize
+	retries = config.Retries
+	maxRetryDelay = DefaultMaxRetryDelay
+	if config.MaxRetryDelay > 0 {
+		maxRetryDelay = config.MaxRetryDelay
+	}
+	userAgent = defaultUserAgent
+	if config.UserAgent != "" {
+		userAgent = config.UserAgent
+	}
+
+	return nil
+}
+
+// newTransport returns a transport keeping the connections alive between
+// requests, and negotiating HTTP/2 with the servers supporting it.
+//
+// HTTP/2 must be forced since net/http only attempts it on its default
+// transport, not on the ones with a custom dialer or TLS configuration.
+func newTransport(dialer *net.Dialer, maxIdleConnsPerHost int) *http.Transport {
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     &tls.Config{},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+}
+
 // GetWithUserAgent performs an HTTP GET with the proper Clair User-Agent.
+//
+// The body of the response fails with a *BodyTooLargeError once it exceeds
+// the configured maximum size.
 func GetWithUserAgent(url string) (*http.Response, error) {
-	client := &http.Client{}
+	return GetForUpdater("", url)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// GetForUpdater performs GetWithUserAgent on behalf of the updater, by which
+// the size and the duration of the download are recorded. Throttled requests
+// are retried, see Config.Retries.
+func GetForUpdater(updater, url string) (*http.Response, error) {
+	resp, err := doWithRetries(updater, "GET", url)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Clair/"+version.Version+" (https://github.com/quay/clair)")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	if maxBodySize > 0 {
+		if resp.ContentLength > maxBodySize {
+			resp.Body.Close()
+			return nil, &BodyTooLargeError{Limit: maxBodySize}
+		}
+		resp.Body = LimitReadCloser(resp.Body, maxBodySize)
 	}
 
 	return resp, nil
@@ -51,23 +210,62 @@ func GetWithUserAgent(url string) (*http.Response, error) {
 
 // HeadWithUserAgent performs an HTTP HEAD with the propper Clair User-Agent.
 func HeadWithUserAgent(url string) (*http.Response, error) {
-	client := &http.Client{}
+	return HeadForUpdater("", url)
+}
+
+// HeadForUpdater performs HeadWithUserAgent on behalf of the updater.
+func HeadForUpdater(updater, url string) (*http.Response, error) {
+	return doWithRetries(updater, "HEAD", url)
+}
 
-	req, err := http.NewRequest("HEAD", url, nil)
+func doWithUserAgent(updater, method, url string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Clair/"+version.Version+" (https://github.com/quay/clair)")
+	req.Header.Set("User-Agent", userAgent)
 
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
+	resp.Body = newMeteredReadCloser(resp.Body, updater, req.URL.Host, start)
 	return resp, nil
 }
 
+type limitedReadCloser struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// LimitReadCloser wraps a ReadCloser so that reading more than limit bytes
+// from it fails with a *BodyTooLargeError.
+func LimitReadCloser(rc io.ReadCloser, limit int64) io.ReadCloser {
+	return &limitedReadCloser{ReadCloser: rc, limit: limit, remaining: limit}
+}
+
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	// Read one byte past the limit to tell a body of exactly limit bytes
+	// from a larger one.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.ReadCloser.Read(p)
+	if int64(n) > l.remaining {
+		n = int(l.remaining)
+		l.remaining = 0
+		return n, &BodyTooLargeError{Limit: l.limit}
+	}
+
+	l.remaining -= int64(n)
+	return n, err
+}
+
 // GetClientAddr returns the first value in X-Forwarded-For if it exists
 // otherwise fall back to use RemoteAddr
 func GetClientAddr(r *http.Request) string {
@@ -88,6 +286,17 @@ func GetClientAddr(r *http.Request) string {
 // GetWithContext do HTTP GET to the URI with headers and returns response blob
 // reader.
 func GetWithContext(ctx context.Context, uri string, headers http.Header) (io.ReadCloser, error) {
+	tr := &http.Transport{
+		TLSClientConfig: &tls.Config{},
+		Proxy:           http.ProxyFromEnvironment,
+	}
+
+	return GetWithTransport(ctx, tr, uri, headers)
+}
+
+// GetWithTransport do HTTP GET to the URI with headers using the provided
+// transport and returns response blob reader.
+func GetWithTransport(ctx context.Context, tr http.RoundTripper, uri string, headers http.Header) (io.ReadCloser, error) {
 	request, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
 		return nil, err
@@ -97,11 +306,6 @@ func GetWithContext(ctx context.Context, uri string, headers http.Header) (io.Re
 		request.Header = headers
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{},
-		Proxy:           http.ProxyFromEnvironment,
-	}
-
 	client := &http.Client{Transport: tr}
 	request = request.WithContext(ctx)
 	r, err := client.Do(request)
@@ -111,6 +315,7 @@ func GetWithContext(ctx context.Context, uri string, headers http.Header) (io.Re
 
 	// Fail if we don't receive a 2xx HTTP status code.
 	if !Status2xx(r) {
+		r.Body.Close()
 		return nil, fmt.Errorf("failed HTTP GET: expected 2XX, got %d", r.StatusCode)
 	}
 
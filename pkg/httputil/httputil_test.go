@@ -0,0 +1,157 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitReadCloser(t *testing.T) {
+	body := LimitReadCloser(ioutil.NopCloser(strings.NewReader("0123456789")), 10)
+	content, err := ioutil.ReadAll(body)
+	assert.Nil(t, err)
+	assert.Equal(t, "0123456789", string(content))
+
+	body = LimitReadCloser(ioutil.NopCloser(strings.NewReader("0123456789")), 4)
+	content, err = ioutil.ReadAll(body)
+	assert.Equal(t, &BodyTooLargeError{Limit: 4}, err)
+	assert.Equal(t, "0123", string(content))
+}
+
+func TestGetWithUserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		if r.URL.Path == "/chunked" {
+			// Flushing before writing the body omits the Content-Length.
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(strings.Repeat("a", 64)))
+	}))
+	defer server.Close()
+	defer Configure(&Config{})
+
+	require.Nil(t, Configure(&Config{UserAgent: "test-agent", MaxBodySize: 64}))
+	resp, err := GetWithUserAgent(server.URL)
+	require.Nil(t, err)
+	content, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Len(t, content, 64)
+	assert.Equal(t, "test-agent", userAgent)
+
+	// Oversized responses are aborted, either from their announced length or
+	// while reading them.
+	require.Nil(t, Configure(&Config{MaxBodySize: 32}))
+	_, err = GetWithUserAgent(server.URL)
+	assert.IsType(t, &BodyTooLargeError{}, err)
+	assert.Equal(t, defaultUserAgent, userAgent)
+
+	resp, err = GetWithUserAgent(server.URL + "/chunked")
+	require.Nil(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, &BodyTooLargeError{Limit: 32}, err)
+}
+
+func TestConfigureInvalid(t *testing.T) {
+	defer Configure(&Config{})
+
+	assert.NotNil(t, Configure(&Config{Proxy: "not a url"}))
+	assert.NotNil(t, Configure(&Config{CAFile: "/does/not/exist"}))
+	assert.NotNil(t, Configure(&Config{MaxBodySize: -1}))
+	assert.NotNil(t, Configure(&Config{MaxIdleConnsPerHost: -1}))
+	assert.NotNil(t, Configure(&Config{Retries: -1}))
+	assert.NotNil(t, Configure(&Config{MaxRetryDelay: -time.Second}))
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, strings.Repeat("not found ", 100), http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(strings.Repeat("a", 1024)))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	defer Configure(&Config{})
+
+	require.Nil(t, Configure(&Config{}))
+
+	// The bodies of the error statuses are usually closed without being read,
+	// and the ones of HEAD requests are empty.
+	for i := 0; i < 10; i++ {
+		resp, err := GetWithUserAgent(server.URL)
+		require.Nil(t, err)
+		_, err = ioutil.ReadAll(resp.Body)
+		require.Nil(t, err)
+		resp.Body.Close()
+
+		resp, err = GetWithUserAgent(server.URL + "/missing")
+		require.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp.Body.Close()
+
+		resp, err = HeadWithUserAgent(server.URL)
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&connections))
+}
+
+func TestHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	defer Configure(&Config{})
+
+	caFile, err := ioutil.TempFile("", "httputil")
+	require.Nil(t, err)
+	defer os.Remove(caFile.Name())
+	require.Nil(t, pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	require.Nil(t, caFile.Close())
+
+	// HTTP/2 is negotiated despite the custom TLS configuration.
+	require.Nil(t, Configure(&Config{CAFile: caFile.Name()}))
+	resp, err := GetWithUserAgent(server.URL)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	proto, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Equal(t, "HTTP/2.0", string(proto))
+}
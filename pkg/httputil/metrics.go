@@ -0,0 +1,90 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	promDownloadBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clair_updater_download_bytes",
+		Help:    "Size of the response bodies downloaded by the updaters, by updater and host.",
+		Buckets: prometheus.ExponentialBuckets(1024, 4, 10),
+	}, []string{"updater", "host"})
+
+	promDownloadDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "clair_updater_download_duration_seconds",
+		Help:    "Time it takes the updaters to download a response, from the request to the end of its body, by updater and host.",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"updater", "host"})
+)
+
+func init() {
+	prometheus.MustRegister(promDownloadBytes)
+	prometheus.MustRegister(promDownloadDurationSeconds)
+}
+
+// maxDrainBytes is the size up to which an unread body is discarded before
+// being closed, so that its keep-alive connection is reused rather than
+// closed. Aborting a larger body is cheaper than reading it.
+const maxDrainBytes = 4 << 10
+
+// drain discards the rest of a body, up to maxDrainBytes.
+func drain(body io.Reader) {
+	io.CopyN(ioutil.Discard, body, maxDrainBytes)
+}
+
+// meteredReadCloser records the size and the duration of a download once its
+// body is read to the end or closed.
+type meteredReadCloser struct {
+	io.ReadCloser
+	updater, host string
+	start         time.Time
+	bytes         int64
+	once          sync.Once
+}
+
+func newMeteredReadCloser(rc io.ReadCloser, updater, host string, start time.Time) *meteredReadCloser {
+	return &meteredReadCloser{ReadCloser: rc, updater: updater, host: host, start: start}
+}
+
+func (m *meteredReadCloser) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	m.bytes += int64(n)
+	if err == io.EOF {
+		m.observe()
+	}
+	return n, err
+}
+
+func (m *meteredReadCloser) Close() error {
+	drain(m)
+	err := m.ReadCloser.Close()
+	m.observe()
+	return err
+}
+
+func (m *meteredReadCloser) observe() {
+	m.once.Do(func() {
+		promDownloadBytes.WithLabelValues(m.updater, m.host).Observe(float64(m.bytes))
+		promDownloadDurationSeconds.WithLabelValues(m.updater, m.host).Observe(time.Since(m.start).Seconds())
+	})
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// histogram returns the sample count and sum of the histogram with the given
+// labels.
+func histogram(t *testing.T, name string, labels map[string]string) (uint64, float64) {
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.Nil(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+
+			return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+		}
+	}
+
+	return 0, 0
+}
+
+func TestDownloadMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.Nil(t, err)
+	labels := map[string]string{"updater": "test", "host": u.Host}
+
+	resp, err := GetForUpdater("test", server.URL)
+	require.Nil(t, err)
+	content, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	assert.Len(t, content, 1000)
+
+	// The download is observed once, when its body is read to the end, even
+	// if it is closed afterwards.
+	resp.Body.Close()
+	count, size := histogram(t, "clair_updater_download_bytes", labels)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, float64(1000), size)
+
+	count, duration := histogram(t, "clair_updater_download_duration_seconds", labels)
+	assert.Equal(t, uint64(1), count)
+	assert.True(t, duration > 0)
+
+	// A body closed before its end is observed when it is closed.
+	resp, err = HeadForUpdater("test", server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	count, size = histogram(t, "clair_updater_download_bytes", labels)
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, float64(1000), size)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/pkg/timeutil"
+)
+
+// DefaultMaxRetryDelay is the longest delay waited before retrying a
+// throttled request when none is configured.
+const DefaultMaxRetryDelay = 5 * time.Minute
+
+// sleep waits before retrying a throttled request, replaced by the tests.
+var sleep = timeutil.Sleep
+
+// retryContext interrupts the waits before retrying throttled requests.
+var retryContext = context.Background()
+
+// SetContext sets the context interrupting the waits before retrying
+// throttled requests, e.g. the one cancelled when Clair shuts down, so that a
+// throttled updater doesn't delay it.
+//
+// It must be called before any request is sent.
+func SetContext(ctx context.Context) {
+	retryContext = ctx
+}
+
+// throttled returns whether the server asked to retry the request later.
+func throttled(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// retryDelay returns the delay before retrying a throttled request: the one
+// asked by its Retry-After header, otherwise twice the previous delay.
+func retryDelay(resp *http.Response, prev time.Duration, now time.Time) time.Duration {
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now); ok {
+		return delay
+	}
+
+	return timeutil.ExpBackoff(prev, maxRetryDelay)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of
+// seconds or an HTTP-date, into the delay to wait from now.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		if seconds > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64, true
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// doWithRetries performs the request, retrying it as long as it's throttled
+// and the configured retries aren't exhausted. The last throttled response is
+// returned when the server asks to wait longer than the maximum delay, and the
+// error of the context set by SetContext when it's done while waiting.
+func doWithRetries(updater, method, url string) (*http.Response, error) {
+	var delay time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, err := doWithUserAgent(updater, method, url)
+		if err != nil || attempt >= retries || !throttled(resp) {
+			return resp, err
+		}
+
+		delay = retryDelay(resp, delay, time.Now())
+		if delay > maxRetryDelay {
+			return resp, nil
+		}
+		drain(resp.Body)
+		resp.Body.Close()
+
+		log.WithFields(log.Fields{"updater": updater, "url": url, "status": resp.StatusCode, "delay": delay}).Warning("request throttled, retrying")
+		if !sleep(retryContext, delay) {
+			return nil, retryContext.Err()
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/pkg/timeutil"
+)
+
+// recordSleeps replaces the sleep between the retries, recording the delays.
+func recordSleeps() (delays *[]time.Duration, restore func()) {
+	delays = &[]time.Duration{}
+	sleep = func(_ context.Context, d time.Duration) bool {
+		*delays = append(*delays, d)
+		return true
+	}
+	return delays, func() { sleep = timeutil.Sleep }
+}
+
+func TestRetryAfter(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "2")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	defer Configure(&Config{})
+	delays, restore := recordSleeps()
+	defer restore()
+
+	require.Nil(t, Configure(&Config{Retries: 3}))
+	resp, err := GetForUpdater("test", server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *delays)
+}
+
+func TestRetryThrottled(t *testing.T) {
+	var retryAfter string
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	defer Configure(&Config{})
+	delays, restore := recordSleeps()
+	defer restore()
+
+	// Without Retry-After, the delay is doubled at each retry and the last
+	// throttled response is returned.
+	require.Nil(t, Configure(&Config{Retries: 3}))
+	resp, err := HeadForUpdater("test", server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 4, requests)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, *delays)
+
+	// Asking to wait longer than the maximum delay fails right away.
+	requests, *delays = 0, nil
+	retryAfter = "120"
+	require.Nil(t, Configure(&Config{Retries: 3, MaxRetryDelay: time.Minute}))
+	resp, err = GetForUpdater("test", server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 1, requests)
+	assert.Len(t, *delays, 0)
+
+	// Without retries, throttled requests aren't retried.
+	requests = 0
+	require.Nil(t, Configure(&Config{}))
+	resp, err = GetForUpdater("test", server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, requests)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2019, time.October, 21, 7, 28, 0, 0, time.UTC)
+	for _, test := range []struct {
+		value string
+		delay time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Mon, 21 Oct 2019 07:28:30 GMT", 30 * time.Second, true},
+		{"Mon, 21 Oct 2019 07:27:00 GMT", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	} {
+		delay, ok := parseRetryAfter(test.value, now)
+		assert.Equal(t, test.ok, ok, test.value)
+		assert.Equal(t, test.delay, delay, test.value)
+	}
+}
+
+func TestRetryCancelled(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	defer Configure(&Config{})
+	defer SetContext(context.Background())
+
+	// The wait before retrying is interrupted once the context is done.
+	ctx, cancel := context.WithCancel(context.Background())
+	SetContext(ctx)
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	require.Nil(t, Configure(&Config{Retries: 3}))
+	start := time.Now()
+	_, err := GetForUpdater("test", server.URL)
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, time.Since(start) < 10*time.Second)
+	assert.Equal(t, 1, requests)
+}
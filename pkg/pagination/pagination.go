@@ -26,16 +26,31 @@ import (
 )
 
 // ErrInvalidToken is returned when a token fails to Unmarshal because it was
-// invalid or expired.
-var ErrInvalidToken = errors.New("invalid or expired pagination token")
+// malformed, tampered with, or secured by an unknown key.
+var ErrInvalidToken = errors.New("invalid pagination token")
+
+// ErrExpiredToken is returned when a token fails to Unmarshal because it
+// outlived the time to live of the key which produced it.
+var ErrExpiredToken = errors.New("expired pagination token")
 
 // ErrInvalidKeyString is returned when the string representing a key is malformed.
 var ErrInvalidKeyString = errors.New("invalid pagination key string: must be 32-byte URL-safe base64")
 
+// now returns the current time, replaced in tests.
+var now = time.Now
+
+// tokenVersion is the version of the content of the tokens produced.
+const tokenVersion = 1
+
 // Key represents the key used to cryptographically secure the token
 // being used to keep track of pages.
 type Key struct {
 	fkey *fernet.Key
+
+	// previous are the keys which were rotated out, still accepted to
+	// decrypt tokens.
+	previous []*fernet.Key
+	ttl      time.Duration
 }
 
 // Token represents an opaque pagination token keeping track of a user's
@@ -45,6 +60,13 @@ type Token string
 // FirstPageToken is used to represent the first page of content.
 var FirstPageToken = Token("")
 
+// tokenContent is the versioned content of a token.
+type tokenContent struct {
+	Version int             `json:"v"`
+	Expires int64           `json:"e,omitempty"`
+	Value   json.RawMessage `json:"d"`
+}
+
 // NewKey generates a new random pagination key.
 func NewKey() (k Key, err error) {
 	k.fkey = new(fernet.Key)
@@ -61,7 +83,7 @@ func KeyFromString(keyString string) (k Key, err error) {
 	if err != nil {
 		return Key{}, ErrInvalidKeyString
 	}
-	return Key{fkey}, err
+	return Key{fkey: fkey}, err
 }
 
 // Must is a helper that wraps calls returning a Key and and error and panics
@@ -73,6 +95,24 @@ func Must(k Key, err error) Key {
 	return k
 }
 
+// WithTTL returns a copy of the key producing tokens which expire after the
+// given duration. Tokens never expire when it is 0.
+func (k Key) WithTTL(ttl time.Duration) Key {
+	k.ttl = ttl
+	return k
+}
+
+// WithPreviousKeys returns a copy of the key also accepting the tokens
+// produced by the given keys, so that in-flight tokens remain valid while
+// rotating keys.
+func (k Key) WithPreviousKeys(previous ...Key) Key {
+	k.previous = nil
+	for _, p := range previous {
+		k.previous = append(k.previous, p.fkey)
+	}
+	return k
+}
+
 // String implements the fmt.Stringer interface for Key.
 func (k Key) String() string {
 	return k.fkey.Encode()
@@ -80,23 +120,43 @@ func (k Key) String() string {
 
 // MarshalToken encodes an interface into JSON bytes and produces a Token.
 func (k Key) MarshalToken(v interface{}) (Token, error) {
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(v)
+	value, err := json.Marshal(v)
 	if err != nil {
 		return Token(""), err
 	}
 
+	content := tokenContent{Version: tokenVersion, Value: value}
+	if k.ttl > 0 {
+		content.Expires = now().Add(k.ttl).Unix()
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(content); err != nil {
+		return Token(""), err
+	}
+
 	tokenBytes, err := fernet.EncryptAndSign(buf.Bytes(), k.fkey)
 	return Token(tokenBytes), err
 }
 
-// UnmarshalToken decrypts a Token using provided key and decodes the result
-// into the provided interface.
+// UnmarshalToken decrypts a Token using provided key, or any of the previous
+// keys, and decodes the result into the provided interface.
 func (k Key) UnmarshalToken(t Token, v interface{}) error {
-	msg := fernet.VerifyAndDecrypt([]byte(t), time.Hour, []*fernet.Key{k.fkey})
+	// The expiration is embedded in the token rather than checked by fernet
+	// so that expired tokens can be told apart from invalid ones.
+	msg := fernet.VerifyAndDecrypt([]byte(t), -1, append([]*fernet.Key{k.fkey}, k.previous...))
 	if msg == nil {
 		return ErrInvalidToken
 	}
 
-	return json.NewDecoder(bytes.NewBuffer(msg)).Decode(&v)
+	var content tokenContent
+	if err := json.Unmarshal(msg, &content); err != nil || content.Version != tokenVersion {
+		return ErrInvalidToken
+	}
+
+	if content.Expires != 0 && now().Unix() > content.Expires {
+		return ErrExpiredToken
+	}
+
+	return json.NewDecoder(bytes.NewBuffer(content.Value)).Decode(&v)
 }
@@ -0,0 +1,93 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fernet/fernet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPage struct {
+	StartID int64
+}
+
+func TestToken(t *testing.T) {
+	key := Must(NewKey())
+	token, err := key.MarshalToken(testPage{42})
+	require.Nil(t, err)
+
+	var page testPage
+	require.Nil(t, key.UnmarshalToken(token, &page))
+	assert.Equal(t, testPage{42}, page)
+}
+
+func TestTokenExpiry(t *testing.T) {
+	key := Must(NewKey())
+
+	token, err := key.WithTTL(time.Hour).MarshalToken(testPage{42})
+	require.Nil(t, err)
+	var page testPage
+	assert.Nil(t, key.UnmarshalToken(token, &page))
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	assert.Equal(t, ErrExpiredToken, key.UnmarshalToken(token, &page))
+
+	// Tokens without TTL never expire.
+	token, err = key.MarshalToken(testPage{42})
+	require.Nil(t, err)
+	now = func() time.Time { return time.Now().Add(24 * 365 * time.Hour) }
+	assert.Nil(t, key.WithTTL(time.Hour).UnmarshalToken(token, &page))
+}
+
+func TestTokenRotation(t *testing.T) {
+	oldKey := Must(NewKey())
+	newKey := Must(NewKey())
+
+	token, err := oldKey.MarshalToken(testPage{42})
+	require.Nil(t, err)
+
+	var page testPage
+	assert.Equal(t, ErrInvalidToken, newKey.UnmarshalToken(token, &page))
+	require.Nil(t, newKey.WithPreviousKeys(oldKey).UnmarshalToken(token, &page))
+	assert.Equal(t, testPage{42}, page)
+
+	// New tokens are produced with the current key only.
+	token, err = newKey.WithPreviousKeys(oldKey).MarshalToken(testPage{43})
+	require.Nil(t, err)
+	assert.Equal(t, ErrInvalidToken, oldKey.UnmarshalToken(token, &page))
+	assert.Nil(t, newKey.UnmarshalToken(token, &page))
+}
+
+func TestTokenTampering(t *testing.T) {
+	key := Must(NewKey())
+	token, err := key.MarshalToken(testPage{42})
+	require.Nil(t, err)
+
+	var page testPage
+	tampered := []byte(token)
+	tampered[len(tampered)/2] ^= 1
+	assert.Equal(t, ErrInvalidToken, key.UnmarshalToken(Token(tampered), &page))
+	assert.Equal(t, ErrInvalidToken, key.UnmarshalToken(Token("garbage"), &page))
+
+	// Tokens of another version are rejected.
+	unversioned, err := fernet.EncryptAndSign([]byte(`{"StartID":42}`), key.fkey)
+	require.Nil(t, err)
+	assert.Equal(t, ErrInvalidToken, key.UnmarshalToken(Token(unversioned), &page))
+}
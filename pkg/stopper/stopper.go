@@ -15,14 +15,16 @@
 package stopper
 
 import (
+	"context"
 	"sync"
 	"time"
 )
 
 // Stopper eases the graceful termination of a group of goroutines
 type Stopper struct {
-	wg   sync.WaitGroup
-	stop chan struct{}
+	wg       sync.WaitGroup
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // NewStopper initializes a new Stopper instance
@@ -30,6 +32,23 @@ func NewStopper() *Stopper {
 	return &Stopper{stop: make(chan struct{}, 0)}
 }
 
+// FromContext initializes a new Stopper instance which asks every goroutine to
+// end once the context is done.
+//
+// It bridges the code still relying on a Stopper to the context based
+// shutdown of Clair's services.
+func FromContext(ctx context.Context) *Stopper {
+	s := NewStopper()
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.close()
+		case <-s.stop:
+		}
+	}()
+	return s
+}
+
 // Begin indicates that a new goroutine has started.
 func (s *Stopper) Begin() {
 	s.wg.Add(1)
@@ -60,6 +79,10 @@ func (s *Stopper) Sleep(d time.Duration) bool {
 
 // Stop asks every goroutine to end.
 func (s *Stopper) Stop() {
-	close(s.stop)
+	s.close()
 	s.wg.Wait()
 }
+
+func (s *Stopper) close() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
@@ -41,6 +41,23 @@ var (
 	// may used in an attempt to perform a Denial of Service attack.
 	MaxExtractableFileSize int64 = 200 * 1024 * 1024 // 200 MiB
 
+	// ErrTooManyExtractedFiles occurs when more files than allowed match the
+	// files to extract.
+	ErrTooManyExtractedFiles = errors.New("tarutil: could not extract the archive: too many matching files")
+
+	// ErrExtractedFilesTooBig occurs when the files to extract are too big
+	// altogether.
+	ErrExtractedFilesTooBig = errors.New("tarutil: could not extract the archive: matching files too big")
+
+	// MaxExtractedFiles enforces the maximum number of files extracted from a
+	// single tarball, which protects against patterns matching whole
+	// directories of unbounded size.
+	MaxExtractedFiles = 10000
+
+	// MaxExtractedSize enforces the maximum total size of the files extracted
+	// from a single tarball.
+	MaxExtractedSize int64 = 512 * 1024 * 1024 // 512 MiB
+
 	readLen     = 6 // max bytes to sniff
 	gzipHeader  = []byte{0x1f, 0x8b}
 	bzip2Header = []byte{0x42, 0x5a, 0x68}
@@ -52,10 +69,26 @@ type FilesMap map[string][]byte
 
 // ExtractFiles decompresses and extracts only the specified files from an
 // io.Reader representing an archive. The files to be extracted are specified
-// by regexp
+// by regexp, which can be built from glob patterns with GlobPattern.
+//
+// A specified file which is a symbolic link or a hard link yields the content
+// of the file it designates once every link of the archive is followed, as
+// long as that file is itself specified or comes after the link in the
+// archive. Other links, e.g. designating files outside of the archive or going
+// through too many links, are extracted with empty content.
+//
+// The extraction fails once the matching files exceed MaxExtractedFiles or
+// MaxExtractedSize.
 func ExtractFiles(r io.Reader, filenames []string) (FilesMap, error) {
 	data := make(map[string][]byte)
 
+	patterns := make([]*regexp.Regexp, 0, len(filenames))
+	for _, s := range filenames {
+		if pattern, err := regexp.Compile(s); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+
 	// Decompress the archive.
 	tr, err := NewTarReadCloser(r)
 	if err != nil {
@@ -63,6 +96,16 @@ func ExtractFiles(r io.Reader, filenames []string) (FilesMap, error) {
 	}
 	defer tr.Close()
 
+	var (
+		extractedSize int64
+		links         = newArchiveLinks()
+		// linkNames are the specified files which are links.
+		linkNames = make(map[string]struct{})
+		// linkTargets are the contents of the files designated by links which
+		// aren't specified themselves.
+		linkTargets = make(map[string][]byte)
+	)
+
 	// For each element in the archive
 	for {
 		hdr, err := tr.Next()
@@ -74,35 +117,231 @@ func ExtractFiles(r io.Reader, filenames []string) (FilesMap, error) {
 		}
 
 		// Get element filename
-		filename := hdr.Name
-		filename = strings.TrimPrefix(filename, "./")
+		filename := cleanArchivePath(hdr.Name)
 
 		// Determine if we should extract the element
 		toBeExtracted := false
-		for _, s := range filenames {
-			if match, err := regexp.MatchString(s, filename); err == nil && match {
+		for _, pattern := range patterns {
+			if pattern.MatchString(filename) {
 				toBeExtracted = true
 				break
 			}
 		}
 
-		if toBeExtracted {
+		switch hdr.Typeflag {
+		case tar.TypeSymlink, tar.TypeLink:
+			links.add(filename, hdr)
+			if !toBeExtracted {
+				continue
+			}
+
+			if _, ok := data[filename]; !ok && len(data)+len(linkTargets) >= MaxExtractedFiles {
+				return data, ErrTooManyExtractedFiles
+			}
+
+			// The link is present even if what it designates isn't found.
+			data[filename] = []byte{}
+			linkNames[filename] = struct{}{}
+		case tar.TypeReg:
+			links.remove(filename)
+			delete(linkNames, filename)
+
+			// Keep the content of the files designated by the specified
+			// links, as far as the links found so far go.
+			if links.changed {
+				links.want(linkNames)
+			}
+
+			_, wanted := links.wanted[filename]
+			if !toBeExtracted && !wanted {
+				continue
+			}
+
 			// File size limit
 			if hdr.Size > MaxExtractableFileSize {
 				return data, ErrExtractedFileTooBig
 			}
 
+			if _, ok := data[filename]; !ok && len(data)+len(linkTargets) >= MaxExtractedFiles {
+				return data, ErrTooManyExtractedFiles
+			}
+
+			extractedSize += hdr.Size
+			if extractedSize > MaxExtractedSize {
+				return data, ErrExtractedFilesTooBig
+			}
+
 			// Extract the element
-			if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink || hdr.Typeflag == tar.TypeReg {
-				d, _ := ioutil.ReadAll(tr)
+			d, _ := ioutil.ReadAll(tr)
+			if toBeExtracted {
 				data[filename] = d
+			} else {
+				linkTargets[filename] = d
 			}
 		}
 	}
 
+	// Resolve the links once every link of the archive is known, so that they
+	// may designate files coming before them.
+	for name := range linkNames {
+		target, ok := links.resolve(name)
+		if !ok {
+			continue
+		}
+
+		content, ok := data[target]
+		if !ok {
+			content, ok = linkTargets[target]
+		}
+
+		if ok {
+			data[name] = content
+		}
+	}
+
 	return data, nil
 }
 
+// maxLinkHops is the maximum number of links followed to resolve a path,
+// which protects against link loops.
+const maxLinkHops = 16
+
+// archiveLinks records the links found in an archive.
+type archiveLinks struct {
+	// symlinks maps symbolic links to their raw target.
+	symlinks map[string]string
+	// hardlinks maps hard links to the archive path of the file they link to.
+	hardlinks map[string]string
+	// wanted are the paths of the files designated by specified links.
+	wanted map[string]struct{}
+	// changed is whether links were added or removed since wanted was
+	// computed.
+	changed bool
+}
+
+func newArchiveLinks() *archiveLinks {
+	return &archiveLinks{
+		symlinks:  make(map[string]string),
+		hardlinks: make(map[string]string),
+		wanted:    make(map[string]struct{}),
+	}
+}
+
+// add records the link of the header found at the given archive path.
+func (l *archiveLinks) add(name string, hdr *tar.Header) {
+	l.remove(name)
+	if hdr.Typeflag == tar.TypeSymlink {
+		l.symlinks[name] = hdr.Linkname
+	} else {
+		l.hardlinks[name] = cleanArchivePath(hdr.Linkname)
+	}
+	l.changed = true
+}
+
+// remove forgets the link found at the given archive path, which has been
+// replaced by a later entry of the archive.
+func (l *archiveLinks) remove(name string) {
+	_, symlink := l.symlinks[name]
+	_, hardlink := l.hardlinks[name]
+	if symlink || hardlink {
+		delete(l.symlinks, name)
+		delete(l.hardlinks, name)
+		l.changed = true
+	}
+}
+
+// want sets the wanted files to the files designated by the given links.
+func (l *archiveLinks) want(names map[string]struct{}) {
+	l.wanted = make(map[string]struct{}, len(names))
+	for name := range names {
+		if target, ok := l.resolve(name); ok {
+			l.wanted[target] = struct{}{}
+		}
+	}
+	l.changed = false
+}
+
+// resolve returns the archive path of the file designated by the given path
+// once every link known so far is followed.
+//
+// Symbolic links are followed as if the archive were the root of the
+// filesystem, so absolute targets are relative to the archive. It returns
+// false if the path escapes the archive or goes through more than
+// maxLinkHops links.
+func (l *archiveLinks) resolve(name string) (string, bool) {
+	var (
+		resolved []string
+		pending  = strings.Split(name, "/")
+		hops     int
+	)
+
+	for len(pending) > 0 {
+		element := pending[0]
+		pending = pending[1:]
+
+		switch element {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", false
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, element)
+		current := strings.Join(resolved, "/")
+
+		if target, ok := l.symlinks[current]; ok {
+			if hops++; hops > maxLinkHops {
+				return "", false
+			}
+
+			resolved = resolved[:len(resolved)-1]
+			if strings.HasPrefix(target, "/") {
+				resolved = nil
+			}
+			pending = append(strings.Split(target, "/"), pending...)
+		} else if target, ok := l.hardlinks[current]; ok && len(pending) == 0 {
+			if hops++; hops > maxLinkHops {
+				return "", false
+			}
+
+			resolved = nil
+			pending = strings.Split(target, "/")
+		}
+	}
+
+	return strings.Join(resolved, "/"), true
+}
+
+// cleanArchivePath returns the path of an archive entry without its leading
+// "./" and trailing "/".
+func cleanArchivePath(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "./"), "/")
+}
+
+// GlobPattern returns the regexp matching the files described by a glob
+// pattern, in which "*" matches any sequence of characters but "/".
+//
+// A pattern ending with "/" matches every file under that directory of the
+// archive. Any other pattern containing "*" matches the end of the paths at a
+// directory boundary, so that "*.dist-info/METADATA" matches the metadata of
+// every installed Python distribution. Otherwise, the pattern matches the
+// exact path.
+func GlobPattern(pattern string) string {
+	expr := strings.Replace(regexp.QuoteMeta(pattern), `\*`, `[^/]*`, -1)
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+		return "^" + expr
+	case strings.Contains(pattern, "*"):
+		return "(^|/)" + expr + "$"
+	default:
+		return "^" + expr + "$"
+	}
+}
+
 // XzReader implements io.ReadCloser for data compressed via `xz`.
 type XzReader struct {
 	io.ReadCloser
@@ -15,6 +15,7 @@
 package tarutil
 
 import (
+	"archive/tar"
 	"bytes"
 	"os"
 	"path/filepath"
@@ -98,3 +99,227 @@ func TestMaxExtractableFileSize(t *testing.T) {
 		assert.Equal(t, ErrExtractedFileTooBig, err)
 	}
 }
+
+func newTestTarball(t *testing.T, files map[string]string, order []string) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range order {
+		content := files[name]
+		assert.Nil(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	return &buf
+}
+
+var globTestFiles = map[string]string{
+	"var/lib/dpkg/status.d/base":                                  "base",
+	"var/lib/dpkg/status.d/libc6":                                 "libc6",
+	"var/lib/dpkg/status":                                         "status",
+	"usr/lib/python3/site-packages/six-1.12.0.dist-info/METADATA": "six",
+	"usr/lib/python3/site-packages/six-1.12.0.dist-info/RECORD":   "record",
+	"requests-2.0.dist-info/METADATA":                             "requests",
+	"usr/lib/python3/site-packages/notdist-info/METADATA":         "notdist",
+	"etc/os-release":                                              "os-release",
+}
+
+var globTestOrder = []string{
+	"var/lib/dpkg/status.d/base",
+	"var/lib/dpkg/status.d/libc6",
+	"var/lib/dpkg/status",
+	"usr/lib/python3/site-packages/six-1.12.0.dist-info/METADATA",
+	"usr/lib/python3/site-packages/six-1.12.0.dist-info/RECORD",
+	"requests-2.0.dist-info/METADATA",
+	"usr/lib/python3/site-packages/notdist-info/METADATA",
+	"etc/os-release",
+}
+
+func TestExtractGlobPrefix(t *testing.T) {
+	data, err := ExtractFiles(newTestTarball(t, globTestFiles, globTestOrder), []string{GlobPattern("var/lib/dpkg/status.d/")})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"var/lib/dpkg/status.d/base":  []byte("base"),
+		"var/lib/dpkg/status.d/libc6": []byte("libc6"),
+	}, data)
+}
+
+func TestExtractGlobSuffix(t *testing.T) {
+	data, err := ExtractFiles(newTestTarball(t, globTestFiles, globTestOrder), []string{GlobPattern("*.dist-info/METADATA")})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"usr/lib/python3/site-packages/six-1.12.0.dist-info/METADATA": []byte("six"),
+		"requests-2.0.dist-info/METADATA":                             []byte("requests"),
+	}, data)
+}
+
+func TestExtractGlobExact(t *testing.T) {
+	data, err := ExtractFiles(newTestTarball(t, globTestFiles, globTestOrder), []string{GlobPattern("var/lib/dpkg/status"), "^etc/os-release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"var/lib/dpkg/status": []byte("status"),
+		"etc/os-release":      []byte("os-release"),
+	}, data)
+}
+
+func TestMaxExtractedFiles(t *testing.T) {
+	defer func(max int) { MaxExtractedFiles = max }(MaxExtractedFiles)
+	MaxExtractedFiles = 1
+
+	data, err := ExtractFiles(newTestTarball(t, globTestFiles, globTestOrder), []string{GlobPattern("var/lib/dpkg/status.d/")})
+	assert.Equal(t, ErrTooManyExtractedFiles, err)
+	assert.Len(t, data, 1)
+}
+
+func TestMaxExtractedSize(t *testing.T) {
+	defer func(max int64) { MaxExtractedSize = max }(MaxExtractedSize)
+	MaxExtractedSize = int64(len("six") + len("record"))
+
+	// The cap is hit by the third matching file, in the middle of the archive.
+	data, err := ExtractFiles(newTestTarball(t, globTestFiles, globTestOrder), []string{GlobPattern("*.dist-info/METADATA"), GlobPattern("*.dist-info/RECORD")})
+	assert.Equal(t, ErrExtractedFilesTooBig, err)
+	assert.Len(t, data, 2)
+}
+
+type testEntry struct {
+	name     string
+	typeflag byte
+	content  string
+	linkname string
+}
+
+func newLinkTestTarball(t *testing.T, entries []testEntry) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		assert.Nil(t, tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+		}))
+		_, err := tw.Write([]byte(entry.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, tw.Close())
+	return &buf
+}
+
+func TestExtractSymlinkedOSRelease(t *testing.T) {
+	for _, linkname := range []string{"../usr/lib/os-release", "/usr/lib/os-release"} {
+		// As on Fedora, etc/os-release comes first and links to a file which
+		// isn't specified.
+		data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+			{name: "etc/", typeflag: tar.TypeDir},
+			{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: linkname},
+			{name: "usr/lib/", typeflag: tar.TypeDir},
+			{name: "usr/lib/os-release", typeflag: tar.TypeReg, content: "ID=fedora"},
+		}), []string{"^etc/os-release"})
+		assert.Nil(t, err)
+		assert.Equal(t, FilesMap{"etc/os-release": []byte("ID=fedora")}, data, linkname)
+	}
+}
+
+func TestExtractSymlinkChain(t *testing.T) {
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "./etc/os-release", typeflag: tar.TypeSymlink, linkname: "system-release"},
+		{name: "./etc/system-release", typeflag: tar.TypeSymlink, linkname: "../usr/etc/release"},
+		{name: "./usr", typeflag: tar.TypeSymlink, linkname: "opt"},
+		{name: "./opt/etc/release", typeflag: tar.TypeReg, content: "ID=chained"},
+	}), []string{"^etc/os-release", "^opt/etc/release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release":  []byte("ID=chained"),
+		"opt/etc/release": []byte("ID=chained"),
+	}, data)
+}
+
+func TestExtractHardlink(t *testing.T) {
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "usr/lib/os-release", typeflag: tar.TypeReg, content: "ID=debian"},
+		{name: "etc/os-release", typeflag: tar.TypeLink, linkname: "./usr/lib/os-release"},
+	}), []string{"^(etc|usr/lib)/os-release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release":     []byte("ID=debian"),
+		"usr/lib/os-release": []byte("ID=debian"),
+	}, data)
+}
+
+func TestExtractMaliciousLinks(t *testing.T) {
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../../../../etc/passwd"},
+		{name: "etc/lsb-release", typeflag: tar.TypeSymlink, linkname: "/../../etc/shadow"},
+		{name: "etc/redhat-release", typeflag: tar.TypeLink, linkname: "../etc/passwd"},
+		{name: "etc/loop-a", typeflag: tar.TypeSymlink, linkname: "loop-b"},
+		{name: "etc/loop-b", typeflag: tar.TypeSymlink, linkname: "loop-a"},
+		{name: "etc/passwd", typeflag: tar.TypeReg, content: "root:x:0:0"},
+	}), []string{"^etc/os-release", "^etc/lsb-release", "^etc/redhat-release", "^etc/loop-a"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release":     []byte{},
+		"etc/lsb-release":    []byte{},
+		"etc/redhat-release": []byte{},
+		"etc/loop-a":         []byte{},
+	}, data)
+}
+
+func TestExtractLinkToEarlierFile(t *testing.T) {
+	// The designated file comes first, and the directory link through which
+	// it's designated comes last.
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "opt/etc/release", typeflag: tar.TypeReg, content: "ID=earlier"},
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../usr/etc/release"},
+		{name: "usr", typeflag: tar.TypeSymlink, linkname: "opt"},
+	}), []string{"^etc/os-release", "^opt/etc/release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release":  []byte("ID=earlier"),
+		"opt/etc/release": []byte("ID=earlier"),
+	}, data)
+}
+
+func TestExtractLinkThroughLaterDirectoryLink(t *testing.T) {
+	// The directory link changes the file designated by etc/os-release before
+	// that file is found.
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../lib/os-release"},
+		{name: "lib", typeflag: tar.TypeSymlink, linkname: "usr/lib"},
+		{name: "lib/os-release", typeflag: tar.TypeReg, content: "ID=shadowed"},
+		{name: "usr/lib/os-release", typeflag: tar.TypeReg, content: "ID=fedora"},
+	}), []string{"^etc/os-release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{"etc/os-release": []byte("ID=fedora")}, data)
+}
+
+func TestExtractUnresolvedLink(t *testing.T) {
+	// As before links were followed, a link designating a file which isn't in
+	// the archive is extracted with empty content.
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../usr/lib/os-release"},
+		{name: "etc/redhat-release", typeflag: tar.TypeLink, linkname: "etc/fedora-release"},
+	}), []string{"^etc/(os|redhat)-release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{
+		"etc/os-release":     []byte{},
+		"etc/redhat-release": []byte{},
+	}, data)
+}
+
+func TestExtractLinkReplacedByFile(t *testing.T) {
+	// A later entry of the same path replaces the link, as when layers are
+	// flattened.
+	data, err := ExtractFiles(newLinkTestTarball(t, []testEntry{
+		{name: "etc/os-release", typeflag: tar.TypeSymlink, linkname: "../usr/lib/os-release"},
+		{name: "etc/os-release", typeflag: tar.TypeReg, content: "ID=alpine"},
+		{name: "usr/lib/os-release", typeflag: tar.TypeReg, content: "ID=fedora"},
+	}), []string{"^etc/os-release"})
+	assert.Nil(t, err)
+	assert.Equal(t, FilesMap{"etc/os-release": []byte("ID=alpine")}, data)
+}
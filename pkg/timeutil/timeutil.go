@@ -17,27 +17,41 @@
 package timeutil
 
 import (
+	"context"
 	"math"
 	"math/rand"
 	"time"
 
 	log "github.com/sirupsen/logrus"
-
-	"github.com/quay/clair/v3/pkg/stopper"
 )
 
-// ApproxSleep is a stoppable time.Sleep that adds a slight random variation to
-// the wakeup time in order to prevent thundering herds.
-func ApproxSleep(approxWakeup time.Time, st *stopper.Stopper) (stopped bool) {
+// Sleep puts the current goroutine on sleep during a duration d.
+// Sleep is interrupted when the context is done, in which case Sleep returns
+// false.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// ApproxSleep is a cancellable time.Sleep that adds a slight random variation
+// to the wakeup time in order to prevent thundering herds.
+func ApproxSleep(ctx context.Context, approxWakeup time.Time) (stopped bool) {
 	waitUntil := approxWakeup.Add(time.Duration(rand.ExpFloat64()/0.5) * time.Second)
 	log.WithField("wakeup", waitUntil).Debug("updater sleeping")
 	now := time.Now().UTC()
 	if !waitUntil.Before(now) {
-		if !st.Sleep(waitUntil.Sub(now)) {
+		if !Sleep(ctx, waitUntil.Sub(now)) {
 			return true
 		}
 	}
-	return false
+	return ctx.Err() != nil
 }
 
 // ExpBackoff doubles the backoff time, if the result is longer than the
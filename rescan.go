@@ -0,0 +1,142 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+// DefaultRescanConcurrency is the number of ancestries rescanned at the same
+// time when it is not configured.
+const DefaultRescanConcurrency = 2
+
+// RescanAncestry analyzes an ancestry again from its stored layer sources with
+// the enabled detectors, and replaces its features.
+//
+// The blobs are only downloaded for the layers missing a detector. The
+// ancestry is kept as is when a blob cannot be retrieved anymore.
+func RescanAncestry(ctx context.Context, store database.Datastore, name string) error {
+	_, found, err := database.FindAncestryAndRollback(store, name)
+	if err != nil {
+		log.WithError(err).WithField("ancestry.Name", name).Error("failed to query ancestry in database")
+		return StorageError
+	}
+
+	if !found {
+		return commonerr.ErrNotFound
+	}
+
+	source, found, err := database.FindAncestrySourceAndRollback(store, name)
+	if err != nil {
+		log.WithError(err).WithField("ancestry.Name", name).Error("failed to query ancestry source in database")
+		return StorageError
+	}
+
+	if !found {
+		return AncestrySourceNotStoredError
+	}
+
+	layers := make([]LayerBlob, 0, len(source.Layers))
+	for _, layer := range source.Layers {
+		layers = append(layers, LayerBlob{
+			Hash:    layer.Hash,
+			Path:    layer.Path,
+			Headers: layer.Headers,
+		})
+	}
+
+	return ProcessAncestry(ctx, store, source.Format, name, layers)
+}
+
+// RescanOutdatedAncestries rescans every ancestry which wasn't analyzed by
+// exactly the enabled detectors, at most concurrency ancestries at a time.
+//
+// The ancestries whose layer sources are not stored are skipped. A failed
+// ancestry doesn't stop the others from being rescanned, and an error is
+// returned when any failed.
+func RescanOutdatedAncestries(ctx context.Context, store database.Datastore, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultRescanConcurrency
+	}
+
+	names, err := database.FindOutdatedAncestriesAndRollback(store, EnabledDetectors())
+	if err != nil {
+		log.WithError(err).Error("failed to query outdated ancestries in database")
+		return StorageError
+	}
+
+	log.WithField("count", len(names)).Info("rescanning outdated ancestries")
+
+	var (
+		wg                    sync.WaitGroup
+		mu                    sync.Mutex
+		done, skipped, failed int
+		sem                   = make(chan struct{}, concurrency)
+	)
+
+	for _, name := range names {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := RescanAncestry(ctx, store, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			done++
+			fields := log.Fields{"ancestry.Name": name, "done": done, "total": len(names)}
+			switch err {
+			case nil:
+				log.WithFields(fields).Info("rescanned ancestry")
+			case AncestrySourceNotStoredError, commonerr.ErrNotFound:
+				skipped++
+				log.WithFields(fields).WithError(err).Warning("skipped ancestry")
+			default:
+				failed++
+				log.WithFields(fields).WithError(err).Error("failed to rescan ancestry")
+			}
+		}(name)
+	}
+
+	wg.Wait()
+
+	log.WithFields(log.Fields{
+		"total":   len(names),
+		"skipped": skipped,
+		"failed":  failed,
+	}).Info("finished rescanning outdated ancestries")
+
+	if failed != 0 {
+		return fmt.Errorf("failed to rescan %d of %d ancestries", failed, len(names))
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func openRescanDatastore(t *testing.T) database.Datastore {
+	store, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	RegisterConfiguredDetectors(store)
+	return store
+}
+
+func TestRescanAncestry(t *testing.T) {
+	ts := httptest.NewServer(&slowBlobServer{})
+	defer ts.Close()
+
+	store := openRescanDatastore(t)
+	defer store.Close()
+
+	storeLayerSources = true
+	defer func() { storeLayerSources = false }()
+
+	layers := []LayerBlob{{Hash: "layer-1", Path: ts.URL + "/1"}, {Hash: "layer-2", Path: ts.URL + "/2"}}
+	require.Nil(t, ProcessAncestry(context.Background(), store, "docker", "ancestry", layers))
+
+	source, ok, err := database.FindAncestrySourceAndRollback(store, "ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "docker", source.Format)
+	assert.Len(t, source.Layers, 2)
+
+	require.Nil(t, RescanAncestry(context.Background(), store, "ancestry"))
+	ancestry, ok, err := database.FindAncestryAndRollback(store, "ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Len(t, ancestry.Layers, 2)
+
+	assert.Equal(t, commonerr.ErrNotFound, RescanAncestry(context.Background(), store, "unknown"))
+}
+
+func TestRescanAncestryWithoutSource(t *testing.T) {
+	ts := httptest.NewServer(&slowBlobServer{})
+	defer ts.Close()
+
+	store := openRescanDatastore(t)
+	defer store.Close()
+
+	layers := []LayerBlob{{Hash: "layer-1", Path: ts.URL + "/1"}}
+	require.Nil(t, ProcessAncestry(context.Background(), store, "docker", "ancestry", layers))
+
+	assert.Equal(t, AncestrySourceNotStoredError, RescanAncestry(context.Background(), store, "ancestry"))
+}
+
+func TestRescanAncestryMissingBlob(t *testing.T) {
+	ts := httptest.NewServer(&slowBlobServer{})
+	defer ts.Close()
+
+	store := openRescanDatastore(t)
+	defer store.Close()
+
+	layers := []LayerBlob{{Hash: "layer-1", Path: ts.URL + "/1"}}
+	require.Nil(t, ProcessAncestry(context.Background(), store, "docker", "ancestry", layers))
+
+	// The source references a layer which was never analyzed, and whose blob
+	// is gone.
+	require.Nil(t, database.UpsertAncestrySourceAndCommit(store, database.AncestrySource{
+		Name:   "ancestry",
+		Format: "docker",
+		Layers: []database.LayerSource{{Hash: "layer-missing", Path: ts.URL + "/missing"}},
+	}))
+
+	assert.Equal(t, RetrieveBlobError, RescanAncestry(context.Background(), store, "ancestry"))
+
+	ancestry, ok, err := database.FindAncestryAndRollback(store, "ancestry")
+	require.Nil(t, err)
+	require.True(t, ok)
+	if assert.Len(t, ancestry.Layers, 1) {
+		assert.Equal(t, "layer-1", ancestry.Layers[0].Hash)
+	}
+}
+
+func TestRescanOutdatedAncestries(t *testing.T) {
+	ts := httptest.NewServer(&slowBlobServer{})
+	defer ts.Close()
+
+	store := openRescanDatastore(t)
+	defer store.Close()
+
+	storeLayerSources = true
+	defer func() { storeLayerSources = false }()
+
+	// Ancestries scanned by an older detector are outdated.
+	previous := database.NewNamespaceDetector("os-release", "0.1")
+	require.Nil(t, database.PersistDetectorsAndCommit(store, []database.Detector{previous}))
+	for _, name := range []string{"ancestry-1", "ancestry-2"} {
+		require.Nil(t, ProcessAncestry(context.Background(), store, "docker", name, []LayerBlob{{Hash: name, Path: ts.URL + "/1"}}))
+
+		tx, err := store.Begin()
+		require.Nil(t, err)
+		ancestry, _, err := tx.FindAncestry(name)
+		require.Nil(t, err)
+		ancestry.By = []database.Detector{previous}
+		require.Nil(t, tx.UpsertAncestry(ancestry))
+		require.Nil(t, tx.Commit())
+	}
+
+	names, err := database.FindOutdatedAncestriesAndRollback(store, EnabledDetectors())
+	require.Nil(t, err)
+	assert.Len(t, names, 2)
+
+	require.Nil(t, RescanOutdatedAncestries(context.Background(), store, 2))
+
+	names, err = database.FindOutdatedAncestriesAndRollback(store, EnabledDetectors())
+	require.Nil(t, err)
+	assert.Empty(t, names)
+}
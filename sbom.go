@@ -0,0 +1,95 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/sbom"
+)
+
+// IndexSBOM saves the components listed by an SBOM as an ancestry made of a
+// single synthetic layer, named after the digest of the SBOM, instead of
+// analyzing the layers of the image. The ancestry replaces any ancestry of
+// the same name.
+//
+// The components which couldn't be mapped to the features of a known
+// distribution are returned along with the reason. Invalid SBOMs fail with a
+// *commonerr.ParseError.
+func IndexSBOM(store database.Datastore, name string, document []byte) ([]sbom.Unmatched, error) {
+	components, err := sbom.Parse(document)
+	if err != nil {
+		return nil, err
+	}
+
+	features, unmatched := sbom.Features(components)
+	log.WithFields(log.Fields{
+		"ancestry.Name": name,
+		"features":      len(features),
+		"unmatched":     len(unmatched),
+	}).Debug("indexing SBOM")
+
+	digest := sha256.Sum256(document)
+	layer := &database.Layer{
+		Hash:     "sbom:sha256:" + hex.EncodeToString(digest[:]),
+		By:       sbom.Detectors,
+		Features: features,
+	}
+
+	ancestryLayer := database.AncestryLayer{Hash: layer.Hash}
+	namespaces := make(map[database.Namespace]struct{})
+	for _, feature := range features {
+		if _, ok := namespaces[feature.PotentialNamespace]; !ok {
+			namespaces[feature.PotentialNamespace] = struct{}{}
+			layer.Namespaces = append(layer.Namespaces, database.LayerNamespace{
+				Namespace: feature.PotentialNamespace,
+				By:        sbom.NamespaceDetector,
+			})
+		}
+
+		ancestryLayer.Features = append(ancestryLayer.Features, database.AncestryFeature{
+			NamespacedFeature: database.NamespacedFeature{
+				Feature:   feature.Feature,
+				Namespace: feature.PotentialNamespace,
+			},
+			FeatureBy:   sbom.FeatureDetector,
+			NamespaceBy: sbom.NamespaceDetector,
+		})
+	}
+
+	if err := database.PersistDetectorsAndCommit(store, sbom.Detectors); err != nil {
+		return nil, StorageError
+	}
+
+	if err := SaveLayerChange(store, layer); err != nil {
+		log.WithError(err).WithField("layer.Hash", layer.Hash).Error("failed to store SBOM layer")
+		return nil, StorageError
+	}
+
+	ancestry := &database.Ancestry{
+		Name:   name,
+		By:     sbom.Detectors,
+		Layers: []database.AncestryLayer{ancestryLayer},
+	}
+	if err := SaveAncestry(store, ancestry); err != nil {
+		return nil, err
+	}
+
+	return unmatched, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/sbom"
+	"github.com/quay/clair/v3/pkg/commonerr"
+)
+
+func TestIndexSBOM(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	// As the Debian updater does, the vulnerability affects the source
+	// package.
+	ns := database.Namespace{Name: "debian:10", VersionFormat: "dpkg"}
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.InsertVulnerabilities([]database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{Name: "CVE-2019-5436", Namespace: ns, Severity: database.HighSeverity},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.SourcePackage,
+			Namespace:       ns,
+			FeatureName:     "curl",
+			AffectedVersion: "7.64.0-4+deb10u1",
+			FixedInVersion:  "7.64.0-4+deb10u1",
+		}},
+	}}))
+	require.Nil(t, tx.Commit())
+
+	document := []byte(`{"bomFormat": "CycloneDX", "specVersion": "1.5", "components": [
+		{"name": "libcurl4", "version": "7.64.0-4", "purl": "pkg:deb/debian/libcurl4@7.64.0-4?arch=amd64&upstream=curl&distro=debian-10"},
+		{"name": "musl", "version": "1.1.24-r2", "purl": "pkg:apk/alpine/musl@1.1.24-r2?distro=alpine-3.11.6"},
+		{"name": "lodash", "version": "4.17.15", "purl": "pkg:npm/lodash@4.17.15"}
+	]}`)
+
+	unmatched, err := IndexSBOM(datastore, "sbom", document)
+	require.Nil(t, err)
+	if assert.Len(t, unmatched, 1) {
+		assert.Equal(t, sbom.Component{Name: "lodash", Version: "4.17.15", PURL: "pkg:npm/lodash@4.17.15"}, unmatched[0].Component)
+	}
+
+	// The ancestry is made of a single layer holding the features.
+	ancestry, ok, err := database.FindAncestryAndRollback(datastore, "sbom")
+	require.Nil(t, err)
+	require.True(t, ok)
+	assert.Equal(t, sbom.Detectors, ancestry.By)
+	require.Len(t, ancestry.Layers, 1)
+
+	namespaces := make(map[string]string)
+	var features []database.NamespacedFeature
+	for _, feature := range ancestry.Layers[0].Features {
+		namespaces[feature.Name] = feature.Namespace.Name
+		features = append(features, feature.NamespacedFeature)
+		assert.Equal(t, sbom.FeatureDetector, feature.FeatureBy)
+		assert.Equal(t, sbom.NamespaceDetector, feature.NamespaceBy)
+	}
+	assert.Equal(t, map[string]string{"libcurl4": "debian:10", "curl": "debian:10", "musl": "alpine:v3.11"}, namespaces)
+
+	// The Debian vulnerability is reported through the source package of
+	// libcurl4.
+	affected, err := database.FindAffectedNamespacedFeaturesAndRollback(datastore, features)
+	require.Nil(t, err)
+	vulnerabilities := make(map[string][]string)
+	for _, feature := range affected {
+		require.True(t, feature.Valid)
+		for _, vulnerability := range feature.AffectedBy {
+			key := feature.Name + " " + string(feature.Type)
+			vulnerabilities[key] = append(vulnerabilities[key], vulnerability.Name)
+		}
+	}
+	assert.Equal(t, map[string][]string{"curl source": {"CVE-2019-5436"}}, vulnerabilities)
+
+	// Indexing another SBOM replaces the ancestry.
+	_, err = IndexSBOM(datastore, "sbom", []byte(`{"spdxVersion": "SPDX-2.3", "packages": []}`))
+	require.Nil(t, err)
+	ancestry, ok, err = database.FindAncestryAndRollback(datastore, "sbom")
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Len(t, ancestry.Layers, 1)
+	assert.Empty(t, ancestry.Layers[0].Features)
+
+	// Invalid SBOMs fail to parse.
+	_, err = IndexSBOM(datastore, "sbom", []byte(`{}`))
+	var perr *commonerr.ParseError
+	assert.True(t, errors.As(err, &perr))
+}
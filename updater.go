@@ -16,9 +16,15 @@ package clair
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,7 +36,7 @@ import (
 	"github.com/quay/clair/v3/database"
 	"github.com/quay/clair/v3/ext/vulnmdsrc"
 	"github.com/quay/clair/v3/ext/vulnsrc"
-	"github.com/quay/clair/v3/pkg/stopper"
+	"github.com/quay/clair/v3/pkg/commonerr"
 	"github.com/quay/clair/v3/pkg/timeutil"
 )
 
@@ -40,9 +46,26 @@ const (
 	updaterLockDuration              = updaterLockRefreshDuration + time.Minute*2
 	updaterLockRefreshDuration       = time.Minute * 8
 	updaterSleepBetweenLoopsDuration = time.Minute
+
+	// updaterFlagTTL is how long the updater flags are kept without being
+	// refreshed, so that the flags of removed updaters eventually expire.
+	updaterFlagTTL = 30 * 24 * time.Hour
+
+	// updaterFlagKeysPrefix prefixes the flag listing the keys of the flags
+	// set by an updater, which are refreshed by each of its successful runs.
+	updaterFlagKeysPrefix = "updater/flags/"
 )
 
+// MaxIntervalJitter is the largest percentage of the update interval by which
+// the updates are jittered, so that they are always at least half an interval
+// apart rather than hammering the vulnerability sources.
+const MaxIntervalJitter = 50
+
 var (
+	// updaterStopTimeout is how long an update in progress is waited for once
+	// the updater is stopped.
+	updaterStopTimeout = 30 * time.Second
+
 	promUpdaterErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "clair_updater_errors_total",
 		Help: "Numbers of errors that the updater generated.",
@@ -72,6 +95,45 @@ func init() {
 type UpdaterConfig struct {
 	EnabledUpdaters []string
 	Interval        time.Duration
+
+	// IntervalJitter is the percentage of the interval by which every update
+	// is randomly scheduled earlier or later, so that the Clair instances
+	// sharing a configuration spread out their updates. It is between 0 and
+	// MaxIntervalJitter, 0 scheduling the updates at exact intervals.
+	IntervalJitter int
+
+	// AllowedNamespaces, if not empty, restricts the stored vulnerabilities to
+	// the namespaces whose name starts with one of its prefixes.
+	AllowedNamespaces []string
+
+	// DeniedNamespaces discards the vulnerabilities of the namespaces whose
+	// name starts with one of its prefixes.
+	DeniedNamespaces []string
+
+	// MaxConcurrentUpdaters is the number of updaters fetching updates at the
+	// same time. The updaters run one after the other when it is not
+	// positive.
+	MaxConcurrentUpdaters int
+
+	// ParseFailureLog, if not empty, is the path of the file the diagnostics
+	// of the vulnerability data the updaters could not parse are appended
+	// to, as JSON lines, instead of the main log.
+	ParseFailureLog string
+
+	// Suppressions are the vulnerabilities whose risk is accepted, which are
+	// not stored: the affected features they match are discarded from the
+	// fetched vulnerabilities, and the vulnerabilities left without any are
+	// removed from the database. Their changes aren't notified.
+	Suppressions database.SuppressionRules
+
+	// DiffExportDir, if not empty, is the directory where the changes of the
+	// vulnerabilities of every updater run are exported as JSON, each updater
+	// keeping the snapshot of its previous run in its own subdirectory.
+	DiffExportDir string
+
+	// Params are the configurations of the updaters requiring one, such as
+	// the CSV updater, by their name.
+	Params map[string]interface{} `yaml:",inline"`
 }
 
 type vulnerabilityChange struct {
@@ -80,10 +142,8 @@ type vulnerabilityChange struct {
 }
 
 // RunUpdater begins a process that updates the vulnerability database at
-// regular intervals.
-func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper.Stopper) {
-	defer st.End()
-
+// regular intervals, until the context is done.
+func RunUpdater(ctx context.Context, config *UpdaterConfig, datastore database.Datastore) {
 	// Do not run the updater if there is no config or if the interval is 0.
 	if config == nil || config.Interval == 0 || len(config.EnabledUpdaters) == 0 {
 		log.Info("updater service is disabled.")
@@ -101,7 +161,12 @@ func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper
 	whoAmI := uuid.New()
 	log.WithField("owner", whoAmI).Info("updater service started")
 
+	lockDuration, refreshDuration := updaterLockDurations(config.Interval)
 	sleepDuration := updaterSleepBetweenLoopsDuration
+
+	// The interval is drawn again after every update, and kept in between so
+	// that the next update time doesn't move while waiting for it.
+	interval := jitterInterval(config.Interval, config.IntervalJitter)
 	for {
 		// Determine if this is the first update and define the next update time.
 		// The next update time is (last update time + interval) or now if this is the first update.
@@ -109,7 +174,7 @@ func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper
 		lastUpdate, isFirstUpdate, err := GetLastUpdateTime(datastore)
 		if err != nil {
 			log.WithError(err).Error("an error occurred while getting the last update time")
-			nextUpdate = nextUpdate.Add(config.Interval)
+			nextUpdate = nextUpdate.Add(interval)
 		}
 
 		log.WithFields(log.Fields{
@@ -117,107 +182,166 @@ func RunUpdater(config *UpdaterConfig, datastore database.Datastore, st *stopper
 			"nextUpdate":  nextUpdate,
 		}).Debug("fetched last update time")
 		if !isFirstUpdate {
-			nextUpdate = lastUpdate.Add(config.Interval)
+			nextUpdate = lastUpdate.Add(interval)
 		}
 
 		// If the next update timer is in the past, then try to update.
 		if nextUpdate.Before(time.Now().UTC()) {
 			// Attempt to get a lock on the update.
 			log.Debug("attempting to obtain update lock")
-			acquiredLock, lockExpiration := database.AcquireLock(datastore, updaterLockName, whoAmI, updaterLockDuration)
-			if lockExpiration.IsZero() {
-				// Any failures to acquire the lock should instantly expire.
-				var instantExpiration time.Duration
-				sleepDuration = instantExpiration
-			}
-
+			acquiredLock, lockExpiration := database.AcquireLock(datastore, updaterLockName, whoAmI, lockDuration)
 			if acquiredLock {
-				err = updateWhileRenewingLock(datastore, whoAmI, isFirstUpdate, st)
+				err = updateWhileRenewingLock(ctx, datastore, whoAmI, refreshDuration, func(ctx context.Context) error {
+					return update(ctx, config, datastore, isFirstUpdate)
+				})
+				interval = jitterInterval(config.Interval, config.IntervalJitter)
 				if err != nil {
-					if err == errReceivedStopSignal {
+					if ctx.Err() != nil {
 						log.Debug("updater received stop signal")
 						return
 					}
-					log.WithError(err).Debug("failed to acquired lock")
-					sleepDuration = timeutil.ExpBackoff(sleepDuration, config.Interval)
+					log.WithError(err).WithField("retryable", commonerr.Retryable(err)).Warning("update failed")
+					sleepDuration = retryDelay(err, sleepDuration, config.Interval)
 				} else {
-					sleepDuration = config.Interval
+					sleepDuration = interval
 				}
 			} else {
+				// Retry as soon as the lock expires, in case its owner crashed.
 				sleepDuration = updaterSleepBetweenLoopsDuration
+				if !lockExpiration.IsZero() && time.Until(lockExpiration) < sleepDuration {
+					sleepDuration = time.Until(lockExpiration)
+				}
 			}
 		} else {
 			sleepDuration = time.Until(nextUpdate)
 		}
 
-		if stopped := timeutil.ApproxSleep(time.Now().Add(sleepDuration), st); stopped {
+		if stopped := timeutil.ApproxSleep(ctx, time.Now().Add(sleepDuration)); stopped {
 			return
 		}
 	}
 }
 
-var errReceivedStopSignal = errors.New("stopped")
+// updaterLockDurations returns for how long the updater lock is acquired and
+// then extended at once. They are bounded by the update interval so that the
+// lock of a crashed updater is reclaimed within one interval.
+func updaterLockDurations(interval time.Duration) (lockDuration, refreshDuration time.Duration) {
+	lockDuration = updaterLockDuration
+	if interval < lockDuration {
+		lockDuration = interval
+	}
 
-func updateWhileRenewingLock(datastore database.Datastore, whoAmI string, isFirstUpdate bool, st *stopper.Stopper) (err error) {
-	g, ctx := errgroup.WithContext(context.Background())
-	// done context is used when updater finishes and all other
-	// go rutines in group should finish too
-	doneCtx, done := context.WithCancel(context.Background())
-	g.Go(func() error {
-		defer done()
-		return update(ctx, datastore, isFirstUpdate)
-	})
+	fraction := float64(updaterLockRefreshDuration) / float64(updaterLockDuration)
+	return lockDuration, timeutil.FractionalDuration(fraction, lockDuration)
+}
 
-	g.Go(func() error {
-		var refreshDuration = updaterLockRefreshDuration
-		for {
-			select {
-			case <-time.After(timeutil.FractionalDuration(0.9, refreshDuration)):
-				success, lockExpiration := database.ExtendLock(datastore, updaterLockName, whoAmI, updaterLockRefreshDuration)
-				if !success {
-					return errors.New("failed to extend lock")
-				}
-				refreshDuration = time.Until(lockExpiration)
-			case <-ctx.Done():
-				database.ReleaseLock(datastore, updaterLockName, whoAmI)
-				return ctx.Err()
-			case <-doneCtx.Done():
-				database.ReleaseLock(datastore, updaterLockName, whoAmI)
-				return nil
-			}
-		}
-	})
+// jitterInterval returns the interval moved randomly by up to the given
+// percentage of it, earlier or later.
+func jitterInterval(interval time.Duration, percent int) time.Duration {
+	if percent <= 0 {
+		return interval
+	}
 
-	g.Go(func() error {
+	if percent > MaxIntervalJitter {
+		percent = MaxIntervalJitter
+	}
+
+	maxJitter := float64(percent) / 100
+	return timeutil.FractionalDuration(1+maxJitter*(2*rand.Float64()-1), interval)
+}
+
+// updateWhileRenewingLock runs the update function while renewing the updater
+// lock by refreshDuration, and releases the lock once the update is done.
+//
+// The update is given the context, but vulnerability sources cannot be
+// interrupted yet: once the context is done, the update in progress is waited
+// for up to updaterStopTimeout, then abandoned with the lock left to expire so
+// that no other update starts while it may still be running.
+func updateWhileRenewingLock(ctx context.Context, datastore database.Datastore, whoAmI string, refreshDuration time.Duration, updateFunc func(context.Context) error) error {
+	updateCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	updated := make(chan error, 1)
+	go func() { updated <- updateFunc(updateCtx) }()
+
+	var (
+		lockErr  error
+		refresh  = time.After(timeutil.FractionalDuration(0.9, refreshDuration))
+		stopping = ctx.Done()
+		abandon  <-chan time.Time
+	)
+	for {
 		select {
-		case <-st.Chan():
-			return errReceivedStopSignal
-		case <-ctx.Done():
+		case err := <-updated:
+			if lockErr != nil {
+				return lockErr
+			}
+			database.ReleaseLock(datastore, updaterLockName, whoAmI)
+			return err
+		case <-refresh:
+			success, lockExpiration := database.ExtendLock(datastore, updaterLockName, whoAmI, refreshDuration)
+			if !success {
+				// The lock is lost: the update is stopped as soon as it can be.
+				lockErr = errors.New("failed to extend lock")
+				refresh = nil
+				cancel()
+				continue
+			}
+			refresh = time.After(timeutil.FractionalDuration(0.9, time.Until(lockExpiration)))
+		case <-stopping:
+			stopping = nil
+			abandon = time.After(updaterStopTimeout)
+		case <-abandon:
+			log.Warning("abandoning the update in progress, the updater lock is left to expire")
 			return ctx.Err()
-		case <-doneCtx.Done():
-			return nil
 		}
-	})
-
-	err = g.Wait()
-	return
+	}
 }
 
-// update fetches all the vulnerabilities from the registered fetchers, updates
+// update fetches all the vulnerabilities from the enabled fetchers, updates
 // vulnerabilities, and updater flags, and logs notes from updaters.
-func update(ctx context.Context, datastore database.Datastore, firstUpdate bool) error {
+func update(ctx context.Context, config *UpdaterConfig, datastore database.Datastore, firstUpdate bool) error {
 	defer setUpdaterDuration(time.Now())
 
 	log.Info("updating vulnerabilities")
 
+	if _, err := updateFrom(ctx, config, datastore, enabledUpdaters(), firstUpdate); err != nil {
+		return err
+	}
+
+	err := setLastUpdateTime(datastore)
+	if err != nil {
+		log.WithError(err).Error("Unable to set last update time")
+		return err
+	}
+
+	log.Info("update finished")
+	return nil
+}
+
+// updateFrom fetches the vulnerabilities from the provided updaters, updates
+// vulnerabilities, and updater flags, and logs notes from updaters.
+//
+// The summaries of the runs of the updaters are recorded and returned.
+func updateFrom(ctx context.Context, config *UpdaterConfig, datastore database.Datastore, updaters map[string]vulnsrc.Updater, firstUpdate bool) ([]database.UpdaterRun, error) {
 	// Fetch updates.
-	success, vulnerabilities, flags, notes := fetchUpdates(ctx, datastore)
+	vulnerabilities, withdrawn, flags, notes, runs, fetchErr := fetchUpdates(ctx, datastore, updaters, config.MaxConcurrentUpdaters, newDiffExporter(config.DiffExportDir))
+	if err := database.InsertUpdaterRunsAndCommit(datastore, runs); err != nil {
+		// The audit trail of the runs doesn't hold back the update.
+		log.WithError(err).Error("Unable to record updater runs")
+	}
+
+	vulnerabilities = filterNamespaces(vulnerabilities, config.AllowedNamespaces, config.DeniedNamespaces)
 
 	namespaces, vulnerabilities := deduplicate(vulnerabilities)
 
+	suppressions := config.Suppressions.Active(time.Now())
+	vulnerabilities, suppressed := suppressVulnerabilities(vulnerabilities, suppressions)
+	withdrawn = append(withdrawn, suppressed...)
+
 	if err := database.PersistNamespacesAndCommit(datastore, namespaces); err != nil {
 		log.WithError(err).Error("Unable to insert namespaces")
-		return err
+		return runs, err
 	}
 
 	changes, err := updateVulnerabilities(ctx, datastore, vulnerabilities)
@@ -230,21 +354,28 @@ func update(ctx context.Context, datastore database.Datastore, firstUpdate bool)
 
 	if err != nil {
 		log.WithError(err).Error("Unable to update vulnerabilities")
-		return err
+		return runs, err
+	}
+
+	withdrawals, err := withdrawVulnerabilities(ctx, datastore, withdrawn, vulnerabilities)
+	if err != nil {
+		log.WithError(err).Error("Unable to withdraw vulnerabilities")
+		return runs, err
 	}
+	changes = append(changes, withdrawals...)
 
 	if !firstUpdate {
-		err = createVulnerabilityNotifications(datastore, changes)
+		err = createVulnerabilityNotifications(datastore, changes, suppressions)
 		if err != nil {
 			log.WithError(err).Error("Unable to create notifications")
-			return err
+			return runs, err
 		}
 	}
 
 	err = updateUpdaterFlags(datastore, flags)
 	if err != nil {
 		log.WithError(err).Error("Unable to update updater flags")
-		return err
+		return runs, err
 	}
 
 	for _, note := range notes {
@@ -252,16 +383,53 @@ func update(ctx context.Context, datastore database.Datastore, firstUpdate bool)
 	}
 	promUpdaterNotesTotal.Set(float64(len(notes)))
 
-	if success {
-		err = setLastUpdateTime(datastore)
-		if err != nil {
-			log.WithError(err).Error("Unable to set last update time")
-			return err
+	// The updates fetched are stored, but the update is not complete if any
+	// updater failed.
+	return runs, fetchErr
+}
+
+// retryDelay returns the duration to wait before retrying an update which
+// failed with the provided error.
+//
+// Temporary failures are retried with an exponential backoff, while
+// permanent ones, such as a vulnerability source which cannot be parsed, are
+// only retried at the next interval.
+func retryDelay(err error, prev, interval time.Duration) time.Duration {
+	if !commonerr.Retryable(err) {
+		return interval
+	}
+
+	return timeutil.ExpBackoff(prev, interval)
+}
+
+// updaterErrors are the errors of the Updaters which failed to fetch updates.
+type updaterErrors map[string]error
+
+func (e updaterErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, name+": "+e[name].Error())
+	}
+
+	return "could not fetch updates: " + strings.Join(msgs, "; ")
+}
+
+// Temporary returns whether any of the failed Updaters may succeed if
+// retried.
+func (e updaterErrors) Temporary() bool {
+	for _, err := range e {
+		if commonerr.Retryable(err) {
+			return true
 		}
 	}
 
-	log.Info("update finished")
-	return nil
+	return false
 }
 
 func deduplicate(vulns []database.VulnerabilityWithAffected) ([]database.Namespace, []database.VulnerabilityWithAffected) {
@@ -282,45 +450,159 @@ func deduplicate(vulns []database.VulnerabilityWithAffected) ([]database.Namespa
 	return namespaces, vulnerabilities
 }
 
+// filterNamespaces discards the namespaced vulnerabilities whose namespace
+// isn't matched by any allowed prefix, when there is any, or is matched by a
+// denied prefix.
+func filterNamespaces(vulns []database.VulnerabilityWithAffected, allowed, denied []string) []database.VulnerabilityWithAffected {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return vulns
+	}
+
+	filtered := make([]database.VulnerabilityWithAffected, 0, len(vulns))
+	for _, vuln := range vulns {
+		name := vuln.Namespace.Name
+		if len(allowed) != 0 && !hasAnyPrefix(name, allowed) {
+			continue
+		}
+
+		if hasAnyPrefix(name, denied) {
+			continue
+		}
+
+		filtered = append(filtered, vuln)
+	}
+
+	log.WithFields(log.Fields{
+		"kept":      len(filtered),
+		"discarded": len(vulns) - len(filtered),
+	}).Debug("filtered vulnerabilities by namespace")
+	return filtered
+}
+
+// suppressVulnerabilities discards the affected features of the namespaced
+// vulnerabilities which are suppressed by the rules, and the vulnerabilities
+// left without any, whose IDs are returned so that they are withdrawn.
+func suppressVulnerabilities(vulns []database.VulnerabilityWithAffected, rules database.SuppressionRules) ([]database.VulnerabilityWithAffected, []database.VulnerabilityID) {
+	if len(rules) == 0 {
+		return vulns, nil
+	}
+
+	var (
+		kept       = make([]database.VulnerabilityWithAffected, 0, len(vulns))
+		suppressed []database.VulnerabilityID
+	)
+
+	for _, vuln := range vulns {
+		if rules.SuppressesVulnerability(vuln) {
+			suppressed = append(suppressed, database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name})
+			continue
+		}
+
+		affected := make([]database.AffectedFeature, 0, len(vuln.Affected))
+		for _, feature := range vuln.Affected {
+			if !rules.Suppresses(vuln.Name, vuln.Namespace.Name, feature.FeatureName) {
+				affected = append(affected, feature)
+			}
+		}
+
+		vuln.Affected = affected
+		kept = append(kept, vuln)
+	}
+
+	log.WithField("count", len(suppressed)).Debug("suppressed vulnerabilities")
+	return kept, suppressed
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func setUpdaterDuration(start time.Time) {
 	promUpdaterDurationSeconds.Set(time.Since(start).Seconds())
 }
 
-// fetchUpdates asynchronously runs all of the enabled Updaters, aggregates
-// their results, and appends metadata to the vulnerabilities found.
-func fetchUpdates(ctx context.Context, datastore database.Datastore) (success bool, vulns []database.VulnerabilityWithAffected, flags map[string]string, notes []string) {
-	flags = make(map[string]string)
+// fetchUpdates asynchronously runs the provided Updaters, at most
+// concurrency of them at the same time, aggregates their results, and appends
+// metadata to the vulnerabilities found. The summary of every run is returned
+// along with the results, whether the Updater failed or not.
+//
+// The changes of the vulnerabilities fetched by every Updater are exported
+// when a diff exporter is provided.
+//
+// The flags are returned by the name of the Updater which set them, and every
+// Updater which succeeded has an entry, even when it set no flag.
+//
+// The returned error, if any, holds the error of every Updater which failed.
+func fetchUpdates(ctx context.Context, datastore database.Datastore, updaters map[string]vulnsrc.Updater, concurrency int, diffs *diffExporter) (vulns []database.VulnerabilityWithAffected, withdrawn []database.VulnerabilityID, flags map[string]map[string]string, notes []string, runs []database.UpdaterRun, err error) {
+	flags = make(map[string]map[string]string)
+	errs := make(updaterErrors)
 
 	log.Info("fetching vulnerability updates")
 
-	var mu sync.RWMutex
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		mu  sync.RWMutex
+		sem = make(chan struct{}, concurrency)
+	)
 	g, ctx := errgroup.WithContext(ctx)
-	for updaterName, updater := range vulnsrc.Updaters() {
+	for updaterName, updater := range updaters {
 		// Shadow the loop variables to avoid closing over the wrong thing.
 		// See: https://golang.org/doc/faq#closures_and_goroutines
 		updaterName := updaterName
 		updater := updater
 
 		g.Go(func() error {
-			if !updaterEnabled(updaterName) {
-				return nil
-			}
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			run := database.UpdaterRun{Updater: updaterName, Started: time.Now().UTC()}
 
 			// TODO(jzelinskie): add context to Update()
 			response, err := updater.Update(datastore)
+			run.Finished = time.Now().UTC()
 			if err != nil {
 				promUpdaterErrorsTotal.Inc()
 				log.WithError(err).WithField("updater", updaterName).Error("an error occurred when fetching an update")
+				run.Error = err.Error()
+				mu.Lock()
+				errs[updaterName] = err
+				runs = append(runs, run)
+				mu.Unlock()
 				return err
 			}
+			run.Vulnerabilities = len(response.Vulnerabilities)
 
+			for i := range response.Vulnerabilities {
+				response.Vulnerabilities[i].Updater = updaterName
+			}
 			namespacedVulns := doVulnerabilitiesNamespacing(response.Vulnerabilities)
 
+			if diffs != nil {
+				diff, err := diffs.export(updaterName, run.Finished, namespacedVulns, response.Withdrawn)
+				if err != nil {
+					log.WithError(err).WithField("updater", updaterName).Error("could not export vulnerability diff")
+				} else {
+					log.WithFields(log.Fields{"updater": updaterName, "added": len(diff.Added), "changed": len(diff.Changed), "removed": len(diff.Removed)}).Debug("exported vulnerability diff")
+				}
+			}
+
 			mu.Lock()
+			runs = append(runs, run)
 			vulns = append(vulns, namespacedVulns...)
+			withdrawn = append(withdrawn, response.Withdrawn...)
 			notes = append(notes, response.Notes...)
+			flags[updaterName] = make(map[string]string, len(response.Flags))
 			for flagKey, flagValue := range response.Flags {
-				flags[flagKey] = flagValue
+				flags[updaterName][flagKey] = flagValue
 			}
 			mu.Unlock()
 
@@ -328,8 +610,8 @@ func fetchUpdates(ctx context.Context, datastore database.Datastore) (success bo
 		})
 	}
 
-	if err := g.Wait(); err == nil {
-		success = true
+	if g.Wait() != nil {
+		err = errs
 	}
 
 	vulns = addMetadata(ctx, datastore, vulns)
@@ -502,6 +784,20 @@ func doVulnerabilitiesNamespacing(vulnerabilities []database.VulnerabilityWithAf
 		namespacedFeatures := v.Affected
 		v.Affected = []database.AffectedFeature{}
 
+		// A vulnerability affecting no feature, such as an advisory kept
+		// along with the CVEs it fixes, is only kept in its own namespace.
+		if len(namespacedFeatures) == 0 && v.Namespace.Name != "" {
+			index := v.Namespace.Name + ":" + v.Name
+			if vulnerability, ok := vulnerabilitiesMap[index]; ok {
+				vulnerability.Updater = mergeUpdaters(vulnerability.Updater, v.Updater)
+				vulnerability.Aliases = mergeAliases(vulnerability.Aliases, v.Aliases)
+			} else {
+				newVulnerability := v
+				vulnerabilitiesMap[index] = &newVulnerability
+			}
+			continue
+		}
+
 		for _, fv := range namespacedFeatures {
 			// validate vulnerabilities, throw out the invalid vulnerabilities
 			if fv.FeatureType == "" || fv.AffectedVersion == "" || fv.FeatureName == "" || fv.Namespace.Name == "" || fv.Namespace.VersionFormat == "" {
@@ -522,6 +818,8 @@ func doVulnerabilitiesNamespacing(vulnerabilities []database.VulnerabilityWithAf
 				vulnerabilitiesMap[index] = &newVulnerability
 			} else {
 				vulnerability.Affected = append(vulnerability.Affected, fv)
+				vulnerability.Updater = mergeUpdaters(vulnerability.Updater, v.Updater)
+				vulnerability.Aliases = mergeAliases(vulnerability.Aliases, v.Aliases)
 			}
 		}
 	}
@@ -544,9 +842,96 @@ func doVulnerabilitiesNamespacing(vulnerabilities []database.VulnerabilityWithAf
 	return response
 }
 
-func updateUpdaterFlags(datastore database.Datastore, flags map[string]string) error {
-	for key, value := range flags {
-		if err := database.UpdateKeyValueAndCommit(datastore, key, value); err != nil {
+// mergeUpdaters returns the sorted comma-separated names of the updaters of
+// both lists, without duplicates.
+func mergeUpdaters(a, b string) string {
+	if a == b || b == "" {
+		return a
+	} else if a == "" {
+		return b
+	}
+
+	names := make(map[string]struct{})
+	for _, name := range strings.Split(a+","+b, ",") {
+		names[name] = struct{}{}
+	}
+
+	merged := make([]string, 0, len(names))
+	for name := range names {
+		merged = append(merged, name)
+	}
+	sort.Strings(merged)
+
+	return strings.Join(merged, ",")
+}
+
+// mergeAliases returns the sorted aliases of both lists, without duplicates.
+func mergeAliases(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+
+	aliases := make(map[string]struct{}, len(a)+len(b))
+	for _, list := range [][]string{a, b} {
+		for _, alias := range list {
+			aliases[alias] = struct{}{}
+		}
+	}
+
+	merged := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		merged = append(merged, alias)
+	}
+	sort.Strings(merged)
+
+	return merged
+}
+
+// updateUpdaterFlags stores the flags set by every Updater which succeeded,
+// and refreshes the flags they set in earlier runs, so that only the flags of
+// the Updaters which stopped running expire.
+func updateUpdaterFlags(datastore database.Datastore, flags map[string]map[string]string) error {
+	for updaterName, updaterFlags := range flags {
+		keysKey := updaterFlagKeysPrefix + updaterName
+		stored, _, err := database.FindKeyValueAndRollback(datastore, keysKey)
+		if err != nil {
+			return err
+		}
+
+		refreshed := make(map[string]string, len(updaterFlags))
+		for _, key := range strings.Split(stored, ",") {
+			if _, ok := updaterFlags[key]; ok || key == "" {
+				continue
+			}
+
+			value, ok, err := database.FindKeyValueAndRollback(datastore, key)
+			if err != nil {
+				return err
+			}
+
+			if ok {
+				refreshed[key] = value
+			}
+		}
+
+		for key, value := range updaterFlags {
+			refreshed[key] = value
+		}
+
+		if len(refreshed) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(refreshed))
+		for key, value := range refreshed {
+			if err := database.UpdateKeyValueWithTTLAndCommit(datastore, key, value, updaterFlagTTL); err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if err := database.UpdateKeyValueWithTTLAndCommit(datastore, keysKey, strings.Join(keys, ","), updaterFlagTTL); err != nil {
 			return err
 		}
 	}
@@ -626,14 +1011,27 @@ func findVulnerabilityChanges(old []database.VulnerabilityWithAffected, new []da
 
 // createVulnerabilityNotifications makes notifications out of vulnerability
 // changes and insert them into database.
-func createVulnerabilityNotifications(datastore database.Datastore, changes []vulnerabilityChange) error {
+//
+// The changes of the vulnerabilities suppressed by the active suppression
+// rules, stored or configured, are not notified.
+func createVulnerabilityNotifications(datastore database.Datastore, changes []vulnerabilityChange, configured database.SuppressionRules) error {
 	log.WithField("count", len(changes)).Debug("creating vulnerability notifications")
 	if len(changes) == 0 {
 		return nil
 	}
 
+	rules, err := database.FindSuppressionRulesAndRollback(datastore)
+	if err != nil {
+		return err
+	}
+
+	rules = append(rules.Active(time.Now()), configured...)
 	notifications := make([]database.VulnerabilityNotification, 0, len(changes))
 	for _, change := range changes {
+		if suppressedChange(rules, change) || !affectingChange(change) {
+			continue
+		}
+
 		var oldVuln, newVuln *database.Vulnerability
 		if change.old != nil {
 			oldVuln = &change.old.Vulnerability
@@ -653,9 +1051,31 @@ func createVulnerabilityNotifications(datastore database.Datastore, changes []vu
 		})
 	}
 
+	log.WithField("count", len(changes)-len(notifications)).Debug("skipped notifications of suppressed vulnerabilities and of those affecting nothing")
 	return database.InsertVulnerabilityNotificationsAndCommit(datastore, notifications)
 }
 
+// affectingChange returns true if the vulnerability on either side of a change
+// affects any feature. The advisories kept along with the CVEs they fix affect
+// none, and concern no ancestry.
+func affectingChange(change vulnerabilityChange) bool {
+	return (change.old != nil && len(change.old.Affected) > 0) || (change.new != nil && len(change.new.Affected) > 0)
+}
+
+// suppressedChange returns true if the rules suppress the findings of the
+// vulnerabilities on both sides of a change.
+func suppressedChange(rules database.SuppressionRules, change vulnerabilityChange) bool {
+	if change.old != nil && !rules.SuppressesVulnerability(*change.old) {
+		return false
+	}
+
+	if change.new != nil && !rules.SuppressesVulnerability(*change.new) {
+		return false
+	}
+
+	return change.old != nil || change.new != nil
+}
+
 // updateVulnerabilities upserts unique vulnerabilities into the database and
 // computes vulnerability changes.
 func updateVulnerabilities(ctx context.Context, datastore database.Datastore, vulnerabilities []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
@@ -684,8 +1104,25 @@ func updateVulnerabilities(ctx context.Context, datastore database.Datastore, vu
 	}
 
 	oldVuln := []database.VulnerabilityWithAffected{}
+	oldHashes := map[database.VulnerabilityID]string{}
+	oldUpdaters := map[database.VulnerabilityID]string{}
 	for _, vuln := range oldVulnNullable {
 		if vuln.Valid {
+			// Vulnerabilities stored before content hashes were recorded are
+			// hashed from their stored content.
+			hash := vuln.ContentHash
+			if hash == "" {
+				if hash, err = hashVulnerability(vuln.VulnerabilityWithAffected); err != nil {
+					return nil, err
+				}
+			}
+
+			id := database.VulnerabilityID{
+				Name:      vuln.Name,
+				Namespace: vuln.Namespace.Name,
+			}
+			oldHashes[id] = hash
+			oldUpdaters[id] = vuln.Updater
 			oldVuln = append(oldVuln, vuln.VulnerabilityWithAffected)
 		}
 	}
@@ -695,31 +1132,230 @@ func updateVulnerabilities(ctx context.Context, datastore database.Datastore, vu
 		return nil, err
 	}
 
+	if err := findReintroducedVulnerabilities(datastore, changes); err != nil {
+		return nil, err
+	}
+
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
 
+	// Only the vulnerabilities whose content differs from what is stored are
+	// rewritten, even if the change doesn't deserve a notification.
 	toRemove := []database.VulnerabilityID{}
 	toAdd := []database.VulnerabilityWithAffected{}
-	for _, change := range changes {
-		if change.old != nil {
-			toRemove = append(toRemove, database.VulnerabilityID{
-				Name:      change.old.Name,
-				Namespace: change.old.Namespace.Name,
-			})
+	for _, vuln := range vulnerabilities {
+		hash, err := hashVulnerability(vuln)
+		if err != nil {
+			return nil, err
 		}
 
-		if change.new != nil {
-			toAdd = append(toAdd, *change.new)
+		id := database.VulnerabilityID{
+			Name:      vuln.Name,
+			Namespace: vuln.Namespace.Name,
 		}
+
+		if oldHash, ok := oldHashes[id]; ok {
+			// The updaters aren't part of the content, but are kept up to
+			// date.
+			if oldHash == hash && oldUpdaters[id] == vuln.Updater {
+				continue
+			}
+
+			toRemove = append(toRemove, id)
+		}
+
+		vuln.ContentHash = hash
+		toAdd = append(toAdd, vuln)
 	}
 
 	log.Debugf("there are %d vulnerability changes", len(changes))
+	log.WithFields(log.Fields{
+		"removed":  len(toRemove),
+		"inserted": len(toAdd),
+	}).Debug("writing vulnerabilities")
+	if len(toRemove) == 0 && len(toAdd) == 0 {
+		return changes, nil
+	}
+
 	return changes, database.UpdateVulnerabilitiesAndCommit(datastore, toRemove, toAdd)
 }
 
+// withdrawVulnerabilities removes the withdrawn vulnerabilities from the
+// database and returns their removal as changes. The vulnerabilities fetched
+// by the same update, such as a CVE fixed again by another advisory, are kept.
+func withdrawVulnerabilities(ctx context.Context, datastore database.Datastore, withdrawn []database.VulnerabilityID, fetched []database.VulnerabilityWithAffected) ([]vulnerabilityChange, error) {
+	if len(withdrawn) == 0 {
+		return nil, nil
+	}
+
+	kept := make(map[database.VulnerabilityID]struct{}, len(fetched))
+	for _, vuln := range fetched {
+		kept[database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name}] = struct{}{}
+	}
+
+	ids := make([]database.VulnerabilityID, 0, len(withdrawn))
+	for _, id := range withdrawn {
+		if _, ok := kept[id]; !ok {
+			kept[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	var (
+		changes  []vulnerabilityChange
+		toRemove []database.VulnerabilityID
+	)
+	for _, vuln := range stored {
+		if !vuln.Valid {
+			continue
+		}
+
+		vuln := vuln.VulnerabilityWithAffected
+		changes = append(changes, vulnerabilityChange{old: &vuln})
+		toRemove = append(toRemove, database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name})
+	}
+
+	log.WithField("count", len(toRemove)).Debug("withdrawing vulnerabilities")
+	if len(toRemove) == 0 {
+		return nil, nil
+	}
+
+	return changes, database.UpdateVulnerabilitiesAndCommit(datastore, toRemove, nil)
+}
+
+// findReintroducedVulnerabilities makes the changes of the new
+// vulnerabilities which were deleted before changes from their deleted
+// version, so that their reintroduction is notified as such.
+func findReintroducedVulnerabilities(datastore database.Datastore, changes []vulnerabilityChange) error {
+	var ids []database.VulnerabilityID
+	for _, change := range changes {
+		if change.old == nil {
+			ids = append(ids, database.VulnerabilityID{
+				Name:      change.new.Name,
+				Namespace: change.new.Namespace.Name,
+			})
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	deleted, err := database.FindDeletedVulnerabilitiesAndRollback(datastore, ids)
+	if err != nil {
+		return err
+	}
+
+	reintroduced := make(map[database.VulnerabilityID]*database.VulnerabilityWithAffected, len(deleted))
+	for i := range deleted {
+		if deleted[i].Valid {
+			reintroduced[ids[i]] = &deleted[i].VulnerabilityWithAffected
+		}
+	}
+
+	for i, change := range changes {
+		if change.old != nil {
+			continue
+		}
+
+		id := database.VulnerabilityID{Name: change.new.Name, Namespace: change.new.Namespace.Name}
+		if old, ok := reintroduced[id]; ok {
+			changes[i].old = old
+		}
+	}
+
+	log.WithField("count", len(reintroduced)).Debug("found reintroduced vulnerabilities")
+	return nil
+}
+
+// hashedVulnerability is the content of a vulnerability which is hashed. The
+// aliases are left out when there is none, so that the hashes stored before
+// aliases were recorded remain valid.
+type hashedVulnerability struct {
+	Name        string
+	Namespace   database.Namespace
+	Description string
+	Link        string
+	Severity    database.Severity
+	Metadata    database.MetadataMap
+	Aliases     []string `json:",omitempty"`
+}
+
+// hashVulnerability returns the digest of a vulnerability's content, which
+// doesn't depend on the order of its affected features.
+func hashVulnerability(vuln database.VulnerabilityWithAffected) (string, error) {
+	affected := make([]database.AffectedFeature, len(vuln.Affected))
+	copy(affected, vuln.Affected)
+	sort.Slice(affected, func(i, j int) bool {
+		return affectedFeatureLess(affected[i], affected[j])
+	})
+
+	content, err := json.Marshal(struct {
+		Vulnerability hashedVulnerability
+		Affected      []database.AffectedFeature
+	}{hashedVulnerability{
+		Name:        vuln.Name,
+		Namespace:   vuln.Namespace,
+		Description: vuln.Description,
+		Link:        vuln.Link,
+		Severity:    vuln.Severity,
+		Metadata:    vuln.Metadata,
+		Aliases:     vuln.Aliases,
+	}, affected})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func affectedFeatureLess(a, b database.AffectedFeature) bool {
+	for _, field := range [][2]string{
+		{a.Namespace.Name, b.Namespace.Name},
+		{a.Namespace.VersionFormat, b.Namespace.VersionFormat},
+		{a.FeatureName, b.FeatureName},
+		{string(a.FeatureType), string(b.FeatureType)},
+		{a.AffectedVersion, b.AffectedVersion},
+		{a.FixedInVersion, b.FixedInVersion},
+	} {
+		if field[0] != field[1] {
+			return field[0] < field[1]
+		}
+	}
+
+	return false
+}
+
+// enabledUpdaters returns the registered Updaters which are enabled.
+func enabledUpdaters() map[string]vulnsrc.Updater {
+	updaters := make(map[string]vulnsrc.Updater)
+	for name, updater := range vulnsrc.Updaters() {
+		if updaterEnabled(name) {
+			updaters[name] = updater
+		}
+	}
+	return updaters
+}
+
 func updaterEnabled(updaterName string) bool {
 	for _, u := range EnabledUpdaters {
 		if u == updaterName {
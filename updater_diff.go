@@ -0,0 +1,215 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/quay/clair/v3/database"
+)
+
+const (
+	diffSnapshotFile = "snapshot.json"
+	diffTimeFormat   = "20060102T150405.000000000Z"
+)
+
+// UpdaterDiff is the document exported after a run of an updater, listing
+// the changes of its vulnerabilities since its previous run.
+type UpdaterDiff struct {
+	Updater string
+	// Since is the time of the previous run, zero for the first run whose
+	// vulnerabilities are all added.
+	Since time.Time
+	Until time.Time
+
+	Added   []database.VulnerabilityWithAffected
+	Changed []database.VulnerabilityWithAffected
+	Removed []database.VulnerabilityID
+}
+
+// Empty returns whether the diff doesn't list any change.
+func (d UpdaterDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// updaterSnapshot is the content hash of every vulnerability of an updater
+// after one of its runs.
+type updaterSnapshot struct {
+	Updater         string
+	Taken           time.Time
+	Vulnerabilities []snapshotEntry
+}
+
+type snapshotEntry struct {
+	database.VulnerabilityID
+	Hash string
+}
+
+// diffUpdate returns the diff of the vulnerabilities fetched and withdrawn by
+// an updater from its previous snapshot, and the snapshot after the run.
+//
+// The updaters may only fetch the vulnerabilities which changed upstream, the
+// other ones being kept in the database: only the withdrawn vulnerabilities
+// are removed.
+func diffUpdate(previous updaterSnapshot, updater string, taken time.Time, vulns []database.VulnerabilityWithAffected, withdrawn []database.VulnerabilityID) (UpdaterDiff, updaterSnapshot, error) {
+	hashes := make(map[database.VulnerabilityID]string, len(previous.Vulnerabilities))
+	for _, entry := range previous.Vulnerabilities {
+		hashes[entry.VulnerabilityID] = entry.Hash
+	}
+
+	diff := UpdaterDiff{Updater: updater, Since: previous.Taken, Until: taken}
+	fetched := make(map[database.VulnerabilityID]struct{}, len(vulns))
+	for _, vuln := range vulns {
+		hash, err := hashVulnerability(vuln)
+		if err != nil {
+			return UpdaterDiff{}, updaterSnapshot{}, err
+		}
+
+		id := database.VulnerabilityID{Name: vuln.Name, Namespace: vuln.Namespace.Name}
+		if _, ok := fetched[id]; ok {
+			continue
+		}
+		fetched[id] = struct{}{}
+
+		if oldHash, ok := hashes[id]; !ok {
+			diff.Added = append(diff.Added, vuln)
+		} else if oldHash != hash {
+			diff.Changed = append(diff.Changed, vuln)
+		}
+		hashes[id] = hash
+	}
+
+	for _, id := range withdrawn {
+		if _, ok := fetched[id]; ok {
+			continue
+		}
+
+		if _, ok := hashes[id]; ok {
+			diff.Removed = append(diff.Removed, id)
+			delete(hashes, id)
+		}
+	}
+
+	sortVulnerabilities(diff.Added)
+	sortVulnerabilities(diff.Changed)
+	sort.Slice(diff.Removed, func(i, j int) bool { return vulnerabilityIDLess(diff.Removed[i], diff.Removed[j]) })
+
+	snapshot := updaterSnapshot{Updater: updater, Taken: taken, Vulnerabilities: make([]snapshotEntry, 0, len(hashes))}
+	for id, hash := range hashes {
+		snapshot.Vulnerabilities = append(snapshot.Vulnerabilities, snapshotEntry{id, hash})
+	}
+	sort.Slice(snapshot.Vulnerabilities, func(i, j int) bool {
+		return vulnerabilityIDLess(snapshot.Vulnerabilities[i].VulnerabilityID, snapshot.Vulnerabilities[j].VulnerabilityID)
+	})
+
+	return diff, snapshot, nil
+}
+
+func sortVulnerabilities(vulns []database.VulnerabilityWithAffected) {
+	sort.Slice(vulns, func(i, j int) bool {
+		return vulnerabilityIDLess(
+			database.VulnerabilityID{Name: vulns[i].Name, Namespace: vulns[i].Namespace.Name},
+			database.VulnerabilityID{Name: vulns[j].Name, Namespace: vulns[j].Namespace.Name},
+		)
+	})
+}
+
+func vulnerabilityIDLess(a, b database.VulnerabilityID) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// diffExporter keeps the snapshot of every updater in its own directory,
+// where it writes the diff of each run changing its vulnerabilities as
+// diff-<time>.json.
+type diffExporter struct {
+	dir string
+}
+
+// newDiffExporter returns the exporter of the diffs to the directory, or nil
+// when it is empty.
+func newDiffExporter(dir string) *diffExporter {
+	if dir == "" {
+		return nil
+	}
+
+	return &diffExporter{dir: dir}
+}
+
+// export writes the diff of a run of the updater and replaces its snapshot.
+func (e *diffExporter) export(updater string, taken time.Time, vulns []database.VulnerabilityWithAffected, withdrawn []database.VulnerabilityID) (UpdaterDiff, error) {
+	dir := filepath.Join(e.dir, updater)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return UpdaterDiff{}, err
+	}
+
+	var previous updaterSnapshot
+	content, err := ioutil.ReadFile(filepath.Join(dir, diffSnapshotFile))
+	if err == nil {
+		err = json.Unmarshal(content, &previous)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return UpdaterDiff{}, err
+	}
+
+	taken = taken.UTC()
+	diff, snapshot, err := diffUpdate(previous, updater, taken, vulns, withdrawn)
+	if err != nil {
+		return UpdaterDiff{}, err
+	}
+
+	// The diff is written first so that the changes are exported again with
+	// the next run if the snapshot can't be replaced.
+	if !diff.Empty() {
+		if err := writeJSONFile(filepath.Join(dir, "diff-"+taken.Format(diffTimeFormat)+".json"), diff); err != nil {
+			return UpdaterDiff{}, err
+		}
+	}
+
+	return diff, writeJSONFile(filepath.Join(dir, diffSnapshotFile), snapshot)
+}
+
+// writeJSONFile atomically replaces the file with the JSON encoding of v.
+func writeJSONFile(path string, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+)
+
+func diffVulnerability(name string, severity database.Severity, features ...string) database.VulnerabilityWithAffected {
+	ns := database.Namespace{Name: "debian:9", VersionFormat: "dpkg"}
+	vuln := database.VulnerabilityWithAffected{Vulnerability: database.Vulnerability{Name: name, Namespace: ns, Severity: severity}}
+	for _, feature := range features {
+		vuln.Affected = append(vuln.Affected, database.AffectedFeature{
+			FeatureType:     database.SourcePackage,
+			Namespace:       ns,
+			FeatureName:     feature,
+			AffectedVersion: "1.0",
+			FixedInVersion:  "1.0",
+		})
+	}
+	return vuln
+}
+
+// readDiffs returns the diffs exported for the updater, oldest first.
+func readDiffs(t *testing.T, dir string) []UpdaterDiff {
+	paths, err := filepath.Glob(filepath.Join(dir, "diff-*.json"))
+	require.Nil(t, err)
+
+	var diffs []UpdaterDiff
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		require.Nil(t, err)
+		var diff UpdaterDiff
+		require.Nil(t, json.Unmarshal(content, &diff))
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+func TestExportUpdaterDiff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clair-diff")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	updater := &withdrawingUpdater{}
+	updaters := map[string]vulnsrc.Updater{"test": updater}
+	run := func(response vulnsrc.UpdateResponse) {
+		updater.response = response
+		_, _, _, _, _, err := fetchUpdates(context.Background(), nil, updaters, 1, newDiffExporter(dir))
+		require.Nil(t, err)
+	}
+
+	// The first run adds every vulnerability.
+	run(vulnsrc.UpdateResponse{Vulnerabilities: []database.VulnerabilityWithAffected{
+		diffVulnerability("CVE-2019-0002", database.HighSeverity, "openssl", "libssl"),
+		diffVulnerability("CVE-2019-0001", database.LowSeverity, "openssl"),
+	}})
+	diffs := readDiffs(t, filepath.Join(dir, "test"))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "test", diffs[0].Updater)
+	assert.True(t, diffs[0].Since.IsZero())
+	require.Len(t, diffs[0].Added, 2)
+	assert.Equal(t, "CVE-2019-0001", diffs[0].Added[0].Name)
+	assert.Equal(t, "CVE-2019-0002", diffs[0].Added[1].Name)
+	assert.Len(t, diffs[0].Changed, 0)
+	assert.Len(t, diffs[0].Removed, 0)
+
+	// The second run changes a vulnerability and adds another one. The
+	// vulnerabilities it doesn't fetch again are kept, and so are the ones
+	// whose affected features are only reordered.
+	run(vulnsrc.UpdateResponse{
+		Vulnerabilities: []database.VulnerabilityWithAffected{
+			diffVulnerability("CVE-2019-0001", database.CriticalSeverity, "openssl"),
+			diffVulnerability("CVE-2019-0002", database.HighSeverity, "libssl", "openssl"),
+			diffVulnerability("CVE-2019-0003", database.MediumSeverity, "curl"),
+		},
+		Withdrawn: []database.VulnerabilityID{{Name: "CVE-2019-9999", Namespace: "debian:9"}},
+	})
+	run(vulnsrc.UpdateResponse{})
+	diffs = readDiffs(t, filepath.Join(dir, "test"))
+	require.Len(t, diffs, 2)
+	diff := diffs[1]
+	assert.Equal(t, diffs[0].Until, diff.Since)
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, "CVE-2019-0003", diff.Added[0].Name)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, "CVE-2019-0001", diff.Changed[0].Name)
+	assert.Equal(t, database.CriticalSeverity, diff.Changed[0].Severity)
+	assert.Len(t, diff.Removed, 0)
+
+	// The withdrawn vulnerabilities are removed.
+	run(vulnsrc.UpdateResponse{Withdrawn: []database.VulnerabilityID{{Name: "CVE-2019-0002", Namespace: "debian:9"}}})
+	diffs = readDiffs(t, filepath.Join(dir, "test"))
+	require.Len(t, diffs, 3)
+	assert.Equal(t, []database.VulnerabilityID{{Name: "CVE-2019-0002", Namespace: "debian:9"}}, diffs[2].Removed)
+	assert.Len(t, diffs[2].Added, 0)
+	assert.Len(t, diffs[2].Changed, 0)
+
+	var snapshot updaterSnapshot
+	content, err := ioutil.ReadFile(filepath.Join(dir, "test", diffSnapshotFile))
+	require.Nil(t, err)
+	require.Nil(t, json.Unmarshal(content, &snapshot))
+	require.Len(t, snapshot.Vulnerabilities, 2)
+	assert.Equal(t, "CVE-2019-0001", snapshot.Vulnerabilities[0].Name)
+	assert.Equal(t, "CVE-2019-0003", snapshot.Vulnerabilities[1].Name)
+}
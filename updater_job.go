@@ -0,0 +1,199 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pborman/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/quay/clair/v3/database"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+)
+
+// updaterJobTTL is how long the finished updater jobs are kept.
+const updaterJobTTL = 24 * time.Hour
+
+var (
+	// ErrUpdaterNotEnabled is returned when triggering an updater which is
+	// not registered or not enabled.
+	ErrUpdaterNotEnabled = errors.New("updater: updater is not enabled")
+
+	// ErrUpdateInProgress is returned when triggering an updater while the
+	// updater lock is held by another update.
+	ErrUpdateInProgress = errors.New("updater: an update is already in progress")
+)
+
+// UpdaterJobStatus is the state of an UpdaterJob.
+type UpdaterJobStatus string
+
+const (
+	// UpdaterJobRunning is the status of an UpdaterJob in progress.
+	UpdaterJobRunning UpdaterJobStatus = "running"
+	// UpdaterJobSucceeded is the status of an UpdaterJob whose updates are
+	// stored.
+	UpdaterJobSucceeded UpdaterJobStatus = "succeeded"
+	// UpdaterJobFailed is the status of an UpdaterJob which failed to fetch
+	// or store its updates.
+	UpdaterJobFailed UpdaterJobStatus = "failed"
+)
+
+// UpdaterJob is a run of a single updater triggered on demand.
+type UpdaterJob struct {
+	ID       string
+	Updater  string
+	Status   UpdaterJobStatus
+	Started  time.Time
+	Finished time.Time
+
+	// Run is the summary of the run of the updater, recorded like the ones of
+	// the periodic updates. It is nil until the updater is done fetching.
+	Run *database.UpdaterRun
+
+	// Error is the error the job failed with, which may come from storing the
+	// updates after the updater itself succeeded.
+	Error string
+}
+
+// UpdaterJobs runs the enabled updaters on demand, in the background, and
+// keeps the state of the jobs it started for updaterJobTTL after they finish.
+//
+// The jobs hold the updater lock, so they never run concurrently with the
+// periodic updates of any Clair instance, but they are only known by the
+// instance which started them.
+type UpdaterJobs struct {
+	ctx       context.Context
+	config    *UpdaterConfig
+	datastore database.Datastore
+
+	mu   sync.Mutex
+	jobs map[string]*UpdaterJob
+}
+
+// NewUpdaterJobs returns UpdaterJobs running updaters until the context is
+// done.
+func NewUpdaterJobs(ctx context.Context, config *UpdaterConfig, datastore database.Datastore) *UpdaterJobs {
+	if config == nil {
+		config = &UpdaterConfig{}
+	}
+
+	return &UpdaterJobs{
+		ctx:       ctx,
+		config:    config,
+		datastore: datastore,
+		jobs:      make(map[string]*UpdaterJob),
+	}
+}
+
+// Trigger starts a job running the named updater and storing its updates like
+// the periodic updates do, and returns the job as started.
+func (j *UpdaterJobs) Trigger(name string) (UpdaterJob, error) {
+	updater, ok := vulnsrc.Updaters()[name]
+	if !ok || !updaterEnabled(name) {
+		return UpdaterJob{}, ErrUpdaterNotEnabled
+	}
+
+	_, isFirstUpdate, err := GetLastUpdateTime(j.datastore)
+	if err != nil {
+		return UpdaterJob{}, err
+	}
+
+	interval := j.config.Interval
+	if interval <= 0 {
+		// The periodic updates are disabled.
+		interval = updaterLockDuration
+	}
+	lockDuration, refreshDuration := updaterLockDurations(interval)
+
+	job := &UpdaterJob{
+		ID:      uuid.New(),
+		Updater: name,
+		Status:  UpdaterJobRunning,
+		Started: time.Now().UTC(),
+	}
+
+	if acquired, _ := database.AcquireLock(j.datastore, updaterLockName, job.ID, lockDuration); !acquired {
+		return UpdaterJob{}, ErrUpdateInProgress
+	}
+
+	j.mu.Lock()
+	j.pruneLocked()
+	j.jobs[job.ID] = job
+	started := *job
+	j.mu.Unlock()
+
+	log.WithFields(log.Fields{"updater": name, "job": job.ID}).Info("updater job started")
+	go func() {
+		ran := make(chan []database.UpdaterRun, 1)
+		err := updateWhileRenewingLock(j.ctx, j.datastore, job.ID, refreshDuration, func(ctx context.Context) error {
+			runs, err := updateFrom(ctx, j.config, j.datastore, map[string]vulnsrc.Updater{name: updater}, isFirstUpdate)
+			ran <- runs
+			return err
+		})
+
+		// The runs of an abandoned update are not known yet.
+		var runs []database.UpdaterRun
+		select {
+		case runs = <-ran:
+		default:
+		}
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		job.Finished = time.Now().UTC()
+		if len(runs) > 0 {
+			job.Run = &runs[0]
+		}
+
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{"updater": name, "job": job.ID}).Warning("updater job failed")
+			job.Status = UpdaterJobFailed
+			job.Error = err.Error()
+			return
+		}
+
+		log.WithFields(log.Fields{"updater": name, "job": job.ID}).Info("updater job finished")
+		job.Status = UpdaterJobSucceeded
+	}()
+
+	return started, nil
+}
+
+// Get returns the job with the provided id and whether it is known.
+func (j *UpdaterJobs) Get(id string) (UpdaterJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return UpdaterJob{}, false
+	}
+
+	return *job, true
+}
+
+// pruneLocked forgets the jobs finished for longer than updaterJobTTL.
+func (j *UpdaterJobs) pruneLocked() {
+	for id, job := range j.jobs {
+		if !job.Finished.IsZero() && time.Since(job.Finished) > updaterJobTTL {
+			delete(j.jobs, id)
+		}
+	}
+}
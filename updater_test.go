@@ -18,10 +18,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/quay/clair/v3/database"
+	_ "github.com/quay/clair/v3/database/memory"
+	"github.com/quay/clair/v3/ext/vulnsrc"
+	"github.com/quay/clair/v3/pkg/commonerr"
+	"github.com/quay/clair/v3/pkg/pagination"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type mockUpdaterDatastore struct {
@@ -31,6 +40,13 @@ type mockUpdaterDatastore struct {
 	vulnerabilities  map[database.VulnerabilityID]database.VulnerabilityWithAffected
 	vulnNotification map[string]database.VulnerabilityNotification
 	keyValues        map[string]string
+	suppressionRules database.SuppressionRules
+
+	// ttls records the ttl of the key values last written with one.
+	ttls map[string]time.Duration
+
+	// writes counts the vulnerabilities deleted or inserted.
+	writes int
 }
 
 type mockUpdaterSession struct {
@@ -68,11 +84,17 @@ func copyUpdaterDatastore(md *mockUpdaterDatastore) mockUpdaterDatastore {
 		kv[key] = value
 	}
 
+	ttls := map[string]time.Duration{}
+	for key, ttl := range md.ttls {
+		ttls[key] = ttl
+	}
+
 	return mockUpdaterDatastore{
 		namespaces:       namespaces,
 		vulnerabilities:  vulnerabilities,
 		vulnNotification: vulnNoti,
 		keyValues:        kv,
+		ttls:             ttls,
 	}
 }
 
@@ -83,6 +105,7 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 		vulnerabilities:  make(map[database.VulnerabilityID]database.VulnerabilityWithAffected),
 		vulnNotification: make(map[string]database.VulnerabilityNotification),
 		keyValues:        make(map[string]string),
+		ttls:             make(map[string]time.Duration),
 	}
 
 	md.FctBegin = func() (database.Session, error) {
@@ -100,6 +123,7 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			session.store.vulnerabilities = session.copy.vulnerabilities
 			session.store.vulnNotification = session.copy.vulnNotification
 			session.store.keyValues = session.copy.keyValues
+			session.store.ttls = session.copy.ttls
 			session.terminated = true
 			return nil
 		}
@@ -138,7 +162,17 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			return r, nil
 		}
 
+		session.FctFindSuppressionRules = func() (database.SuppressionRules, error) {
+			return md.suppressionRules, nil
+		}
+
+		// Deleted vulnerabilities are not kept.
+		session.FctFindDeletedVulnerabilities = func(ids []database.VulnerabilityID) ([]database.NullableVulnerability, error) {
+			return make([]database.NullableVulnerability, len(ids)), nil
+		}
+
 		session.FctDeleteVulnerabilities = func(ids []database.VulnerabilityID) error {
+			md.writes += len(ids)
 			for _, id := range ids {
 				delete(session.copy.vulnerabilities, id)
 			}
@@ -146,6 +180,7 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 		}
 
 		session.FctInsertVulnerabilities = func(vulnerabilities []database.VulnerabilityWithAffected) error {
+			md.writes += len(vulnerabilities)
 			for _, vuln := range vulnerabilities {
 				id := database.VulnerabilityID{
 					Name:      vuln.Name,
@@ -164,6 +199,11 @@ func newmockUpdaterDatastore() *mockUpdaterDatastore {
 			return nil
 		}
 
+		session.FctUpdateKeyValueWithTTL = func(key, value string, ttl time.Duration) error {
+			session.copy.ttls[key] = ttl
+			return session.FctUpdateKeyValue(key, value)
+		}
+
 		session.FctFindKeyValue = func(key string) (string, bool, error) {
 			s, b := session.copy.keyValues[key]
 			return s, b, nil
@@ -230,6 +270,51 @@ func TestDoVulnerabilitiesNamespacing(t *testing.T) {
 	}
 }
 
+func TestDoVulnerabilitiesNamespacingAdvisories(t *testing.T) {
+	ns := database.Namespace{Name: "oracle:7", VersionFormat: "rpm"}
+	advisory := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      "ELSA-2020-0001",
+			Namespace: ns,
+			Severity:  database.HighSeverity,
+			Aliases:   []string{"CVE-2020-0001"},
+		},
+		Updater: "oracle",
+	}
+	fixedAgain := advisory
+	fixedAgain.Aliases = []string{"CVE-2020-0002"}
+	fixedAgain.Updater = "other"
+
+	// A vulnerability affecting no feature is kept in its own namespace, and
+	// merged with its duplicates.
+	vulnerabilities := doVulnerabilitiesNamespacing([]database.VulnerabilityWithAffected{advisory, fixedAgain})
+	if assert.Len(t, vulnerabilities, 1) {
+		assert.Equal(t, ns, vulnerabilities[0].Namespace)
+		assert.Empty(t, vulnerabilities[0].Affected)
+		assert.Equal(t, []string{"CVE-2020-0001", "CVE-2020-0002"}, vulnerabilities[0].Aliases)
+		assert.Equal(t, "oracle,other", vulnerabilities[0].Updater)
+	}
+
+	// It is stored, so that it can be looked up along with its aliases.
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	require.Nil(t, database.PersistNamespacesAndCommit(datastore, []database.Namespace{ns}))
+	_, err = updateVulnerabilities(context.Background(), datastore, vulnerabilities)
+	require.Nil(t, err)
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, []database.VulnerabilityID{{Name: "ELSA-2020-0001", Namespace: "oracle:7"}})
+	require.Nil(t, err)
+	if assert.True(t, stored[0].Valid) {
+		assert.Equal(t, []string{"CVE-2020-0001", "CVE-2020-0002"}, stored[0].Aliases)
+	}
+
+	// Without a namespace, it is still thrown out.
+	advisory.Namespace = database.Namespace{}
+	assert.Empty(t, doVulnerabilitiesNamespacing([]database.VulnerabilityWithAffected{advisory}))
+}
+
 func TestCreatVulnerabilityNotification(t *testing.T) {
 	vf1 := "VersionFormat1"
 	ns1 := database.Namespace{
@@ -296,7 +381,7 @@ func TestCreatVulnerabilityNotification(t *testing.T) {
 	assertVulnerability(t, *change[0].new, v3)
 	assertVulnerability(t, *change[0].old, v2)
 
-	err = createVulnerabilityNotifications(datastore, change)
+	err = createVulnerabilityNotifications(datastore, change, nil)
 	assert.Nil(t, err)
 	assert.Len(t, datastore.vulnNotification, 1)
 	for _, noti := range datastore.vulnNotification {
@@ -305,10 +390,488 @@ func TestCreatVulnerabilityNotification(t *testing.T) {
 	}
 }
 
+func TestCreateVulnerabilityNotificationsSuppressed(t *testing.T) {
+	ns := database.Namespace{Name: "debian:10", VersionFormat: "dpkg"}
+	vulnerability := func(name string, features ...string) *database.VulnerabilityWithAffected {
+		v := &database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: name, Namespace: ns, Severity: database.HighSeverity},
+		}
+
+		for _, feature := range features {
+			v.Affected = append(v.Affected, database.AffectedFeature{
+				FeatureType:     database.BinaryPackage,
+				Namespace:       ns,
+				FeatureName:     feature,
+				AffectedVersion: "2.0",
+				FixedInVersion:  "2.0",
+			})
+		}
+
+		return v
+	}
+
+	datastore := newmockUpdaterDatastore()
+	datastore.suppressionRules = database.SuppressionRules{
+		{ID: 1, Vulnerability: "CVE-2020-0001"},
+		{ID: 2, Vulnerability: "CVE-2020-0002", Namespace: "debian:10", Feature: "openssl"},
+		{ID: 3, Vulnerability: "CVE-2020-0003", Expires: time.Now().Add(-time.Minute)},
+		{ID: 4, Vulnerability: "CVE-2020-0004", Namespace: "debian:9"},
+	}
+
+	changes := []vulnerabilityChange{
+		// Suppressed in every namespace, for every feature.
+		{new: vulnerability("CVE-2020-0001", "openssl", "curl")},
+		// Suppressed for the only feature it affects.
+		{old: vulnerability("CVE-2020-0002", "openssl"), new: vulnerability("CVE-2020-0002", "openssl")},
+		// Also affects a feature which isn't suppressed.
+		{new: vulnerability("CVE-2020-0002", "openssl", "curl")},
+		// The rule expired.
+		{new: vulnerability("CVE-2020-0003", "openssl")},
+		// The rule applies to another namespace.
+		{new: vulnerability("CVE-2020-0004", "openssl")},
+		// Affects no feature, like an advisory kept along with its CVEs.
+		{new: vulnerability("ELSA-2020-0001")},
+	}
+
+	require.Nil(t, createVulnerabilityNotifications(datastore, changes, nil))
+
+	notified := []string{}
+	for _, notification := range datastore.vulnNotification {
+		notified = append(notified, notification.New.Name)
+	}
+
+	assert.ElementsMatch(t, []string{"CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004"}, notified)
+}
+
+func TestUpdateVulnerabilitiesSkipsUnchanged(t *testing.T) {
+	ns := database.Namespace{
+		Name:          "namespace 1",
+		VersionFormat: "VersionFormat1",
+	}
+
+	af1 := database.AffectedFeature{
+		FeatureType:     database.SourcePackage,
+		Namespace:       ns,
+		FeatureName:     "feature 1",
+		AffectedVersion: "1.0",
+		FixedInVersion:  "1.0",
+	}
+
+	af2 := database.AffectedFeature{
+		FeatureType:     database.BinaryPackage,
+		Namespace:       ns,
+		FeatureName:     "feature 2",
+		AffectedVersion: "2.0",
+		FixedInVersion:  "2.0",
+	}
+
+	v1 := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:      "vulnerability 1",
+			Namespace: ns,
+			Severity:  database.HighSeverity,
+			Metadata:  database.MetadataMap{"key": "value"},
+		},
+		Affected: []database.AffectedFeature{af1, af2},
+	}
+
+	v2 := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        "vulnerability 2",
+			Namespace:   ns,
+			Description: "description",
+			Severity:    database.LowSeverity,
+		},
+		Affected: []database.AffectedFeature{af1},
+	}
+
+	datastore := newmockUpdaterDatastore()
+	_, err := updateVulnerabilities(context.TODO(), datastore, []database.VulnerabilityWithAffected{v1, v2})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, datastore.writes)
+	for _, vuln := range datastore.vulnerabilities {
+		assert.NotEmpty(t, vuln.ContentHash)
+	}
+
+	// An identical run, even with affected features reordered, doesn't write.
+	datastore.writes = 0
+	reordered := v1
+	reordered.Affected = []database.AffectedFeature{af2, af1}
+	change, err := updateVulnerabilities(context.TODO(), datastore, []database.VulnerabilityWithAffected{reordered, v2})
+	assert.Nil(t, err)
+	assert.Len(t, change, 0)
+	assert.Equal(t, 0, datastore.writes)
+
+	// A content change that doesn't deserve a notification is still written.
+	datastore.writes = 0
+	described := v2
+	described.Description = "new description"
+	change, err = updateVulnerabilities(context.TODO(), datastore, []database.VulnerabilityWithAffected{v1, described})
+	assert.Nil(t, err)
+	assert.Len(t, change, 0)
+	assert.Equal(t, 2, datastore.writes)
+	assert.Equal(t, "new description", datastore.vulnerabilities[database.VulnerabilityID{Name: v2.Name, Namespace: ns.Name}].Description)
+}
+
+func TestUpdateVulnerabilitiesNotifiesReintroduced(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	// An ancestry features openssl 1.0.
+	var (
+		ns        = database.Namespace{Name: "debian:10", VersionFormat: "dpkg"}
+		feature   = database.Feature{Name: "openssl", Version: "1.0", VersionFormat: "dpkg", Type: database.BinaryPackage}
+		nsFeature = database.NamespacedFeature{Feature: feature, Namespace: ns}
+	)
+
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	require.Nil(t, tx.PersistDetectors([]database.Detector{osrelease, dpkg}))
+	require.Nil(t, tx.PersistNamespaces([]database.Namespace{ns}))
+	require.Nil(t, tx.PersistFeatures([]database.Feature{feature}))
+	require.Nil(t, tx.PersistNamespacedFeatures([]database.NamespacedFeature{nsFeature}))
+	require.Nil(t, tx.PersistLayer("layer",
+		[]database.LayerFeature{{Feature: feature, By: dpkg}},
+		[]database.LayerNamespace{{Namespace: ns, By: osrelease}},
+		[]database.Detector{osrelease, dpkg},
+	))
+	require.Nil(t, tx.UpsertAncestry(database.Ancestry{
+		Name: "ancestry",
+		By:   []database.Detector{osrelease, dpkg},
+		Layers: []database.AncestryLayer{{
+			Hash: "layer",
+			Features: []database.AncestryFeature{{
+				NamespacedFeature: nsFeature,
+				FeatureBy:         dpkg,
+				NamespaceBy:       osrelease,
+			}},
+		}},
+	}))
+	require.Nil(t, tx.Commit())
+
+	vuln := func(fixedIn string) database.VulnerabilityWithAffected {
+		return database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: "CVE-2020-0001", Namespace: ns, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{{
+				FeatureType:     database.BinaryPackage,
+				Namespace:       ns,
+				FeatureName:     "openssl",
+				AffectedVersion: fixedIn,
+				FixedInVersion:  fixedIn,
+			}},
+		}
+	}
+
+	// A first update cycle introduces the vulnerability, whose notification
+	// is then dismissed.
+	changes, err := updateVulnerabilities(context.Background(), datastore, []database.VulnerabilityWithAffected{vuln("2.0")})
+	require.Nil(t, err)
+	require.Nil(t, createVulnerabilityNotifications(datastore, changes, nil))
+
+	tx, err = datastore.Begin()
+	require.Nil(t, err)
+	hook, ok, err := tx.FindNewNotification(time.Now())
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Nil(t, tx.DeleteNotification(hook.Name))
+
+	// The source drops the vulnerability.
+	require.Nil(t, tx.DeleteVulnerabilities([]database.VulnerabilityID{{Name: "CVE-2020-0001", Namespace: ns.Name}}))
+	require.Nil(t, tx.Commit())
+
+	// A second update cycle reintroduces it with different affected
+	// features, which is notified as a change from the deleted version.
+	changes, err = updateVulnerabilities(context.Background(), datastore, []database.VulnerabilityWithAffected{vuln("3.0")})
+	require.Nil(t, err)
+	require.Len(t, changes, 1)
+	require.NotNil(t, changes[0].old)
+	assert.Equal(t, "2.0", changes[0].old.Affected[0].FixedInVersion)
+	assert.Equal(t, "3.0", changes[0].new.Affected[0].FixedInVersion)
+	require.Nil(t, createVulnerabilityNotifications(datastore, changes, nil))
+
+	tx, err = datastore.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	hook, ok, err = tx.FindNewNotification(time.Now())
+	require.Nil(t, err)
+	require.True(t, ok)
+
+	noti, ok, err := tx.FindVulnerabilityNotification(hook.Name, 10, pagination.FirstPageToken, pagination.FirstPageToken)
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.NotNil(t, noti.Old)
+	require.NotNil(t, noti.New)
+	assert.Equal(t, "CVE-2020-0001", noti.Old.Name)
+
+	// The ancestry affected by the deleted version is still listed.
+	for _, page := range []*database.PagedVulnerableAncestries{noti.Old, noti.New} {
+		if assert.Len(t, page.Affected, 1) {
+			for _, name := range page.Affected {
+				assert.Equal(t, "ancestry", name)
+			}
+		}
+	}
+}
+
+// withdrawingUpdater is an Updater returning the configured response.
+type withdrawingUpdater struct {
+	response vulnsrc.UpdateResponse
+}
+
+func (u *withdrawingUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	return u.response, nil
+}
+
+func (u *withdrawingUpdater) Clean() {}
+
+func TestUpdateWithdrawsVulnerabilities(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	ns := database.Namespace{Name: "withdrawn:1", VersionFormat: "dpkg"}
+	vuln := func(name string) database.VulnerabilityWithAffected {
+		return database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: name, Severity: database.HighSeverity},
+			Affected: []database.AffectedFeature{{
+				FeatureType:     database.BinaryPackage,
+				Namespace:       ns,
+				FeatureName:     "openssl",
+				AffectedVersion: "2.0",
+				FixedInVersion:  "2.0",
+			}},
+		}
+	}
+
+	updater := &withdrawingUpdater{}
+	updaters := map[string]vulnsrc.Updater{"withdrawing": updater}
+
+	updater.response = vulnsrc.UpdateResponse{Vulnerabilities: []database.VulnerabilityWithAffected{vuln("CVE-2020-0001"), vuln("CVE-2020-0002")}}
+	_, err = updateFrom(context.Background(), &UpdaterConfig{}, datastore, updaters, true)
+	require.Nil(t, err)
+
+	// The withdrawn vulnerability is removed, but not the one fetched again
+	// by the same update, and unknown ones are ignored.
+	updater.response = vulnsrc.UpdateResponse{
+		Vulnerabilities: []database.VulnerabilityWithAffected{vuln("CVE-2020-0003")},
+		Withdrawn: []database.VulnerabilityID{
+			{Name: "CVE-2020-0001", Namespace: ns.Name},
+			{Name: "CVE-2020-0003", Namespace: ns.Name},
+			{Name: "CVE-2020-0004", Namespace: ns.Name},
+		},
+	}
+	_, err = updateFrom(context.Background(), &UpdaterConfig{}, datastore, updaters, false)
+	require.Nil(t, err)
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, []database.VulnerabilityID{
+		{Name: "CVE-2020-0001", Namespace: ns.Name},
+		{Name: "CVE-2020-0002", Namespace: ns.Name},
+		{Name: "CVE-2020-0003", Namespace: ns.Name},
+	})
+	require.Nil(t, err)
+	require.Len(t, stored, 3)
+	assert.False(t, stored[0].Valid)
+	assert.True(t, stored[1].Valid)
+	assert.True(t, stored[2].Valid)
+
+	// The withdrawal is notified as a removal.
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	removed := []string{}
+	for {
+		hook, ok, err := tx.FindNewNotification(time.Now())
+		require.Nil(t, err)
+		if !ok {
+			break
+		}
+
+		noti, ok, err := tx.FindVulnerabilityNotification(hook.Name, 10, pagination.FirstPageToken, pagination.FirstPageToken)
+		require.Nil(t, err)
+		require.True(t, ok)
+		if noti.New == nil {
+			removed = append(removed, noti.Old.Name)
+		}
+		require.Nil(t, tx.DeleteNotification(hook.Name))
+	}
+	assert.Equal(t, []string{"CVE-2020-0001"}, removed)
+}
+
+func TestUpdateSuppressesVulnerabilities(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	// The Oracle updater names its vulnerabilities after the CVEs fixed by
+	// the advisories, affecting the packages of several releases.
+	affected := func(release int, name string) database.AffectedFeature {
+		return database.AffectedFeature{
+			FeatureType:     database.BinaryPackage,
+			Namespace:       database.Namespace{Name: fmt.Sprintf("oracle:%d", release), VersionFormat: "rpm"},
+			FeatureName:     name,
+			AffectedVersion: "1:1.1.1g-15.el8_3",
+			FixedInVersion:  "1:1.1.1g-15.el8_3",
+		}
+	}
+	vuln := func(name string) database.VulnerabilityWithAffected {
+		return database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{Name: name, Severity: database.HighSeverity},
+			Affected:      []database.AffectedFeature{affected(7, "openssl"), affected(8, "openssl"), affected(8, "openssl-libs")},
+		}
+	}
+
+	updater := &withdrawingUpdater{response: vulnsrc.UpdateResponse{Vulnerabilities: []database.VulnerabilityWithAffected{
+		vuln("CVE-2020-0001"), vuln("CVE-2020-0002"), vuln("CVE-2020-0003"),
+	}}}
+	updaters := map[string]vulnsrc.Updater{"oracle": updater}
+	_, err = updateFrom(context.Background(), &UpdaterConfig{}, datastore, updaters, true)
+	require.Nil(t, err)
+
+	config := &UpdaterConfig{Suppressions: database.SuppressionRules{
+		{Vulnerability: "CVE-2020-0001", Namespace: "oracle:8"},
+		{Vulnerability: "CVE-2020-0002", Feature: "openssl-libs"},
+		{Vulnerability: "CVE-2020-0003", Expires: time.Now().Add(-time.Hour)},
+		{Vulnerability: "CVE-2020-0004"},
+	}}
+	updater.response.Vulnerabilities = append(updater.response.Vulnerabilities, vuln("CVE-2020-0004"))
+	_, err = updateFrom(context.Background(), config, datastore, updaters, false)
+	require.Nil(t, err)
+
+	ids := []database.VulnerabilityID{}
+	for _, name := range []string{"CVE-2020-0001", "CVE-2020-0002", "CVE-2020-0003", "CVE-2020-0004"} {
+		for _, release := range []string{"oracle:7", "oracle:8"} {
+			ids = append(ids, database.VulnerabilityID{Name: name, Namespace: release})
+		}
+	}
+
+	stored, err := database.FindVulnerabilitiesAndRollback(datastore, ids)
+	require.Nil(t, err)
+	require.Len(t, stored, len(ids))
+
+	// The suppressed vulnerability of oracle:8 is removed, the suppressed
+	// package isn't affected anymore, and the expired rule doesn't apply.
+	features := func(vuln database.NullableVulnerability) []string {
+		names := []string{}
+		for _, affected := range vuln.Affected {
+			names = append(names, affected.FeatureName)
+		}
+		return names
+	}
+	assert.True(t, stored[0].Valid)
+	assert.False(t, stored[1].Valid)
+	assert.Equal(t, []string{"openssl"}, features(stored[2]))
+	assert.Equal(t, []string{"openssl"}, features(stored[3]))
+	assert.ElementsMatch(t, []string{"openssl", "openssl-libs"}, features(stored[5]))
+	assert.False(t, stored[6].Valid)
+	assert.False(t, stored[7].Valid)
+
+	// Only the changes of the vulnerabilities which aren't suppressed are
+	// notified.
+	tx, err := datastore.Begin()
+	require.Nil(t, err)
+	defer tx.Rollback()
+
+	notified := []string{}
+	for {
+		hook, ok, err := tx.FindNewNotification(time.Now())
+		require.Nil(t, err)
+		if !ok {
+			break
+		}
+
+		noti, ok, err := tx.FindVulnerabilityNotification(hook.Name, 10, pagination.FirstPageToken, pagination.FirstPageToken)
+		require.Nil(t, err)
+		require.True(t, ok)
+		notified = append(notified, noti.Old.Name+" "+noti.Old.Namespace.Name)
+		require.Nil(t, tx.DeleteNotification(hook.Name))
+	}
+	assert.Equal(t, []string{"CVE-2020-0002 oracle:8"}, notified)
+}
+
+func TestHashVulnerability(t *testing.T) {
+	vuln := database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:     "vulnerability 1",
+			Severity: database.HighSeverity,
+		},
+	}
+
+	hash, err := hashVulnerability(vuln)
+	assert.Nil(t, err)
+
+	// The recorded hash is not part of the content.
+	vuln.ContentHash = "previous"
+	rehash, err := hashVulnerability(vuln)
+	assert.Nil(t, err)
+	assert.Equal(t, hash, rehash)
+
+	vuln.Link = "https://example.com"
+	changed, err := hashVulnerability(vuln)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, changed)
+
+	// Aliases are part of the content, but the hash of a vulnerability
+	// without any is the one recorded before aliases existed.
+	vuln = database.VulnerabilityWithAffected{
+		Vulnerability: database.Vulnerability{
+			Name:        "CVE-2020-0001",
+			Namespace:   database.Namespace{Name: "oracle:7", VersionFormat: "rpm"},
+			Description: "description",
+			Link:        "https://example.com",
+			Severity:    database.HighSeverity,
+			Metadata:    database.MetadataMap{"NVD": map[string]interface{}{"CVSSv2": map[string]interface{}{"Score": 5.0}}},
+		},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.BinaryPackage,
+			Namespace:       database.Namespace{Name: "oracle:7", VersionFormat: "rpm"},
+			FeatureName:     "curl",
+			AffectedVersion: "1.0",
+			FixedInVersion:  "1.0",
+		}},
+	}
+	hash, err = hashVulnerability(vuln)
+	assert.Nil(t, err)
+	assert.Equal(t, "1750d976af01141aeeb7acce53f3cb1d72895bda5891b3f1f804e57f3af5370a", hash)
+
+	vuln.Aliases = []string{"ELSA-2020-0001"}
+	changed, err = hashVulnerability(vuln)
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, changed)
+}
+
+func TestFilterNamespaces(t *testing.T) {
+	var vulns []database.VulnerabilityWithAffected
+	for _, name := range []string{"oracle:5", "oracle:6", "oracle:7", "oracle:8", "oracle:9", "debian:10"} {
+		vulns = append(vulns, database.VulnerabilityWithAffected{
+			Vulnerability: database.Vulnerability{
+				Name:      "ELSA-2019-0001",
+				Namespace: database.Namespace{Name: name, VersionFormat: "rpm"},
+			},
+		})
+	}
+
+	namespaces := func(vulns []database.VulnerabilityWithAffected) []string {
+		var names []string
+		for _, vuln := range vulns {
+			names = append(names, vuln.Namespace.Name)
+		}
+		return names
+	}
+
+	assert.Len(t, filterNamespaces(vulns, nil, nil), len(vulns))
+	assert.Equal(t, []string{"oracle:8", "oracle:9"}, namespaces(filterNamespaces(vulns, []string{"oracle:8", "oracle:9"}, nil)))
+	assert.Equal(t, []string{"oracle:7", "oracle:8", "oracle:9", "debian:10"}, namespaces(filterNamespaces(vulns, nil, []string{"oracle:5", "oracle:6"})))
+	assert.Equal(t, []string{"oracle:7", "oracle:8", "oracle:9"}, namespaces(filterNamespaces(vulns, []string{"oracle:"}, []string{"oracle:5", "oracle:6"})))
+}
+
 func assertVulnerability(t *testing.T, expected database.VulnerabilityWithAffected, actual database.VulnerabilityWithAffected) bool {
 	expectedAF := expected.Affected
 	actualAF := actual.Affected
 	expected.Affected, actual.Affected = nil, nil
+	expected.ContentHash, actual.ContentHash = "", ""
 
 	assert.Equal(t, expected, actual)
 	assert.Len(t, actualAF, len(expectedAF))
@@ -325,3 +888,529 @@ func assertVulnerability(t *testing.T, expected database.VulnerabilityWithAffect
 	}
 	return true
 }
+
+func TestRetryDelay(t *testing.T) {
+	interval := 2 * time.Hour
+	temporary := commonerr.NewStatusError("https://example.com/oval", http.StatusServiceUnavailable)
+	permanent := commonerr.NewParseError("https://example.com/oval", 1, nil)
+
+	// Temporary failures back off exponentially up to the interval.
+	assert.Equal(t, time.Second, retryDelay(temporary, 0, interval))
+	assert.Equal(t, 2*time.Minute, retryDelay(temporary, time.Minute, interval))
+	assert.Equal(t, interval, retryDelay(temporary, 90*time.Minute, interval))
+
+	// Permanent failures wait for the next interval.
+	assert.Equal(t, interval, retryDelay(permanent, time.Minute, interval))
+
+	// Unclassified failures back off as well.
+	assert.Equal(t, 2*time.Minute, retryDelay(errors.New("database is down"), time.Minute, interval))
+
+	// Updates are retried soon when any updater may succeed if retried.
+	assert.Equal(t, 2*time.Minute, retryDelay(updaterErrors{"oracle": permanent, "debian": temporary}, time.Minute, interval))
+	assert.Equal(t, interval, retryDelay(updaterErrors{"oracle": permanent}, time.Minute, interval))
+}
+
+var (
+	registerConcurrentUpdaters sync.Once
+	concurrentUpdatersRunning  int32
+	concurrentUpdatersMax      int32
+)
+
+// concurrentUpdater is an Updater recording the highest number of
+// concurrentUpdaters running at the same time.
+type concurrentUpdater string
+
+func (u concurrentUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	running := atomic.AddInt32(&concurrentUpdatersRunning, 1)
+	defer atomic.AddInt32(&concurrentUpdatersRunning, -1)
+
+	for {
+		max := atomic.LoadInt32(&concurrentUpdatersMax)
+		if running <= max || atomic.CompareAndSwapInt32(&concurrentUpdatersMax, max, running) {
+			break
+		}
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	return vulnsrc.UpdateResponse{Flags: map[string]string{string(u): "done"}}, nil
+}
+
+func (u concurrentUpdater) Clean() {}
+
+func TestFetchUpdatesConcurrency(t *testing.T) {
+	names := []string{"concurrent-1", "concurrent-2", "concurrent-3", "concurrent-4", "concurrent-5"}
+	registerConcurrentUpdaters.Do(func() {
+		for _, name := range names {
+			vulnsrc.RegisterUpdater(name, concurrentUpdater(name))
+		}
+	})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+	EnabledUpdaters = names
+
+	for _, concurrency := range []int{0, 1, 2, 5} {
+		atomic.StoreInt32(&concurrentUpdatersMax, 0)
+
+		_, _, flags, _, _, err := fetchUpdates(context.Background(), nil, enabledUpdaters(), concurrency, nil)
+		if assert.Nil(t, err) {
+			assert.Len(t, flags, len(names))
+		}
+
+		limit := int32(concurrency)
+		if limit <= 0 {
+			// Updaters run one after the other by default.
+			limit = 1
+		}
+
+		max := atomic.LoadInt32(&concurrentUpdatersMax)
+		assert.True(t, max <= limit, "%d updaters ran at the same time, expected at most %d", max, limit)
+	}
+}
+
+// taggedUpdater is an Updater returning a vulnerability affecting its own
+// feature.
+type taggedUpdater string
+
+func (u taggedUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	return vulnsrc.UpdateResponse{Vulnerabilities: []database.VulnerabilityWithAffected{{
+		Vulnerability: database.Vulnerability{Name: "CVE-2020-0001", Severity: database.HighSeverity},
+		Affected: []database.AffectedFeature{{
+			FeatureType:     database.SourcePackage,
+			Namespace:       database.Namespace{Name: "tagged:1", VersionFormat: "dpkg"},
+			FeatureName:     string(u),
+			AffectedVersion: "1.0",
+			FixedInVersion:  "1.0",
+		}},
+	}}}, nil
+}
+
+func (u taggedUpdater) Clean() {}
+
+var registerTaggedUpdaters sync.Once
+
+func TestFetchUpdatesTagsUpdater(t *testing.T) {
+	registerTaggedUpdaters.Do(func() {
+		vulnsrc.RegisterUpdater("tagged-1", taggedUpdater("tagged-1"))
+		vulnsrc.RegisterUpdater("tagged-2", taggedUpdater("tagged-2"))
+	})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+
+	// Vulnerabilities are tagged with the name of their updater.
+	EnabledUpdaters = []string{"tagged-1"}
+	vulns, _, _, _, _, err := fetchUpdates(context.Background(), nil, enabledUpdaters(), 1, nil)
+	if assert.Nil(t, err) && assert.Len(t, vulns, 1) {
+		assert.Equal(t, "tagged-1", vulns[0].Updater)
+	}
+
+	// Vulnerabilities fetched by several updaters are tagged with all of
+	// them.
+	EnabledUpdaters = []string{"tagged-1", "tagged-2"}
+	vulns, _, _, _, _, err = fetchUpdates(context.Background(), nil, enabledUpdaters(), 1, nil)
+	if assert.Nil(t, err) && assert.Len(t, vulns, 2) {
+		_, vulns = deduplicate(vulns)
+		if assert.Len(t, vulns, 1) {
+			assert.Len(t, vulns[0].Affected, 2)
+			assert.Equal(t, "tagged-1,tagged-2", vulns[0].Updater)
+		}
+	}
+}
+
+// failingUpdater is an Updater failing to fetch its vulnerabilities.
+type failingUpdater string
+
+func (u failingUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	return vulnsrc.UpdateResponse{}, errors.New("could not download")
+}
+
+func (u failingUpdater) Clean() {}
+
+var registerFailingUpdater sync.Once
+
+// blockingUpdater is an Updater which cannot be interrupted, and returns once
+// its release channel is closed.
+type blockingUpdater struct {
+	release chan struct{}
+}
+
+func (u *blockingUpdater) Update(database.Datastore) (vulnsrc.UpdateResponse, error) {
+	<-u.release
+	return vulnsrc.UpdateResponse{}, nil
+}
+
+func (u *blockingUpdater) Clean() {}
+
+var (
+	blocking                = &blockingUpdater{}
+	registerBlockingUpdater sync.Once
+)
+
+func TestUpdateRecordsUpdaterRuns(t *testing.T) {
+	registerTaggedUpdaters.Do(func() {
+		vulnsrc.RegisterUpdater("tagged-1", taggedUpdater("tagged-1"))
+		vulnsrc.RegisterUpdater("tagged-2", taggedUpdater("tagged-2"))
+	})
+	registerFailingUpdater.Do(func() {
+		vulnsrc.RegisterUpdater("failing", failingUpdater("failing"))
+	})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+	EnabledUpdaters = []string{"tagged-1", "failing"}
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer datastore.Close()
+
+	before := time.Now().UTC()
+	assert.Error(t, update(context.Background(), &UpdaterConfig{}, datastore, true))
+
+	// A successful run records the number of vulnerabilities fetched.
+	runs, err := database.FindUpdaterRunsAndRollback(datastore, "tagged-1", 10)
+	if assert.Nil(t, err) && assert.Len(t, runs, 1) {
+		assert.Equal(t, "tagged-1", runs[0].Updater)
+		assert.Equal(t, 1, runs[0].Vulnerabilities)
+		assert.Empty(t, runs[0].Error)
+		assert.False(t, runs[0].Started.Before(before))
+		assert.False(t, runs[0].Finished.Before(runs[0].Started))
+	}
+
+	// A failed run records its error.
+	runs, err = database.FindUpdaterRunsAndRollback(datastore, "failing", 10)
+	if assert.Nil(t, err) && assert.Len(t, runs, 1) {
+		assert.Equal(t, 0, runs[0].Vulnerabilities)
+		assert.Equal(t, "could not download", runs[0].Error)
+	}
+
+	// Every run is recorded, the most recent first.
+	assert.Error(t, update(context.Background(), &UpdaterConfig{}, datastore, false))
+	runs, err = database.FindUpdaterRunsAndRollback(datastore, "failing", 10)
+	if assert.Nil(t, err) && assert.Len(t, runs, 2) {
+		assert.False(t, runs[0].Started.Before(runs[1].Started))
+	}
+}
+
+func TestUpdateUpdaterFlagsRefreshesFlags(t *testing.T) {
+	datastore := newmockUpdaterDatastore()
+
+	require.Nil(t, updateUpdaterFlags(datastore, map[string]map[string]string{
+		"quiet": {"quietFlag": "1"},
+	}))
+	assert.Equal(t, "1", datastore.keyValues["quietFlag"])
+	assert.Equal(t, updaterFlagTTL, datastore.ttls["quietFlag"])
+
+	// A successful run setting no flag refreshes the flags set earlier.
+	datastore.ttls = map[string]time.Duration{}
+	require.Nil(t, updateUpdaterFlags(datastore, map[string]map[string]string{
+		"quiet": {},
+	}))
+	assert.Equal(t, "1", datastore.keyValues["quietFlag"])
+	assert.Equal(t, updaterFlagTTL, datastore.ttls["quietFlag"])
+
+	// The flags of an updater which did not run are left to expire.
+	datastore.ttls = map[string]time.Duration{}
+	require.Nil(t, updateUpdaterFlags(datastore, map[string]map[string]string{
+		"other": {"otherFlag": "1"},
+	}))
+	assert.NotContains(t, datastore.ttls, "quietFlag")
+
+	// New flags are tracked along with the earlier ones.
+	datastore.ttls = map[string]time.Duration{}
+	require.Nil(t, updateUpdaterFlags(datastore, map[string]map[string]string{
+		"quiet": {"newFlag": "2"},
+	}))
+	assert.Contains(t, datastore.ttls, "quietFlag")
+	assert.Contains(t, datastore.ttls, "newFlag")
+	assert.Equal(t, "newFlag,quietFlag", datastore.keyValues[updaterFlagKeysPrefix+"quiet"])
+}
+
+func TestUpdateWhileRenewingLockWaitsForUpdate(t *testing.T) {
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	acquired, _ := database.AcquireLock(datastore, updaterLockName, "updater", time.Minute)
+	require.True(t, acquired)
+
+	// The lock is held until the stopped update returns.
+	ctx, cancel := context.WithCancel(context.Background())
+	var finished int32
+	go cancel()
+	err = updateWhileRenewingLock(ctx, datastore, "updater", time.Minute, func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return ctx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+
+	acquired, _ = database.AcquireLock(datastore, updaterLockName, "other", time.Minute)
+	assert.True(t, acquired, "the lock should be released once the update returns")
+	database.ReleaseLock(datastore, updaterLockName, "other")
+}
+
+func TestUpdateWhileRenewingLockAbandonsUpdate(t *testing.T) {
+	timeout := updaterStopTimeout
+	defer func() { updaterStopTimeout = timeout }()
+	updaterStopTimeout = 10 * time.Millisecond
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	acquired, _ := database.AcquireLock(datastore, updaterLockName, "updater", time.Minute)
+	require.True(t, acquired)
+
+	// An update which cannot be interrupted is abandoned, but keeps the lock.
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	defer close(release)
+	go cancel()
+	err = updateWhileRenewingLock(ctx, datastore, "updater", time.Minute, func(context.Context) error {
+		<-release
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+
+	acquired, _ = database.AcquireLock(datastore, updaterLockName, "other", time.Minute)
+	assert.False(t, acquired, "the lock should be left to expire")
+}
+
+func TestRunUpdaterReclaimsCrashedLock(t *testing.T) {
+	registerTaggedUpdaters.Do(func() {
+		vulnsrc.RegisterUpdater("tagged-1", taggedUpdater("tagged-1"))
+		vulnsrc.RegisterUpdater("tagged-2", taggedUpdater("tagged-2"))
+	})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+	EnabledUpdaters = []string{"tagged-1"}
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer datastore.Close()
+
+	// The lock holder crashes right after acquiring the lock: it never
+	// extends nor releases it.
+	acquired, _ := database.AcquireLock(datastore, updaterLockName, "crashed", 100*time.Millisecond)
+	if !assert.True(t, acquired) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		RunUpdater(ctx, &UpdaterConfig{EnabledUpdaters: EnabledUpdaters, Interval: time.Minute}, datastore)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	// The lock is reclaimed once it expires, well within one interval.
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		_, isFirstUpdate, err := GetLastUpdateTime(datastore)
+		if !assert.Nil(t, err) || !isFirstUpdate {
+			break
+		}
+
+		if !assert.True(t, time.Now().Before(deadline), "the crashed updater lock was not reclaimed") {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestUpdaterLockDurations(t *testing.T) {
+	lockDuration, refreshDuration := updaterLockDurations(time.Hour)
+	assert.Equal(t, updaterLockDuration, lockDuration)
+	assert.Equal(t, updaterLockRefreshDuration, refreshDuration)
+
+	// The lock never outlives an interval.
+	lockDuration, refreshDuration = updaterLockDurations(time.Minute)
+	assert.Equal(t, time.Minute, lockDuration)
+	assert.Equal(t, 48*time.Second, refreshDuration)
+}
+
+func TestJitterInterval(t *testing.T) {
+	assert.Equal(t, time.Hour, jitterInterval(time.Hour, 0))
+
+	intervals := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		interval := jitterInterval(time.Hour, 10)
+		assert.True(t, interval >= 54*time.Minute && interval <= 66*time.Minute, "%s is out of the jitter bound", interval)
+		intervals[interval] = true
+	}
+	assert.True(t, len(intervals) > 1, "the intervals should vary")
+
+	// The jitter is capped, so that updates are always at least half an
+	// interval apart.
+	for i := 0; i < 100; i++ {
+		interval := jitterInterval(time.Hour, 150)
+		assert.True(t, interval >= 30*time.Minute && interval <= 90*time.Minute, "%s is out of the jitter bound", interval)
+	}
+}
+
+func TestMergeUpdaters(t *testing.T) {
+	assert.Equal(t, "oracle", mergeUpdaters("oracle", ""))
+	assert.Equal(t, "oracle", mergeUpdaters("", "oracle"))
+	assert.Equal(t, "oracle", mergeUpdaters("oracle", "oracle"))
+	assert.Equal(t, "oracle,redhat", mergeUpdaters("redhat", "oracle"))
+	assert.Equal(t, "debian,oracle,redhat", mergeUpdaters("oracle,redhat", "debian,oracle"))
+}
+
+func TestMergeAliases(t *testing.T) {
+	assert.Equal(t, []string{"ELSA-2019-1"}, mergeAliases([]string{"ELSA-2019-1"}, nil))
+	assert.Equal(t, []string{"ELSA-2019-1"}, mergeAliases(nil, []string{"ELSA-2019-1"}))
+	assert.Equal(t, []string{"ELSA-2019-1", "ELSA-2019-2"}, mergeAliases([]string{"ELSA-2019-2"}, []string{"ELSA-2019-1", "ELSA-2019-2"}))
+}
+
+// waitUpdaterJob polls the job until it is finished.
+func waitUpdaterJob(t *testing.T, jobs *UpdaterJobs, id string) UpdaterJob {
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		job, ok := jobs.Get(id)
+		require.True(t, ok)
+		if job.Status != UpdaterJobRunning {
+			return job
+		}
+
+		require.True(t, time.Now().Before(deadline), "the updater job did not finish")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUpdaterJobs(t *testing.T) {
+	registerTaggedUpdaters.Do(func() {
+		vulnsrc.RegisterUpdater("tagged-1", taggedUpdater("tagged-1"))
+		vulnsrc.RegisterUpdater("tagged-2", taggedUpdater("tagged-2"))
+	})
+	registerFailingUpdater.Do(func() {
+		vulnsrc.RegisterUpdater("failing", failingUpdater("failing"))
+	})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+	EnabledUpdaters = []string{"tagged-1", "failing"}
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	jobs := NewUpdaterJobs(ctx, &UpdaterConfig{}, datastore)
+
+	// Only the triggered updater runs, and its updates are stored.
+	started, err := jobs.Trigger("tagged-1")
+	require.Nil(t, err)
+	assert.Equal(t, "tagged-1", started.Updater)
+	assert.Equal(t, UpdaterJobRunning, started.Status)
+	assert.Nil(t, started.Run)
+
+	job := waitUpdaterJob(t, jobs, started.ID)
+	assert.Equal(t, UpdaterJobSucceeded, job.Status)
+	assert.Empty(t, job.Error)
+	assert.False(t, job.Finished.Before(job.Started))
+	if assert.NotNil(t, job.Run) {
+		assert.Equal(t, "tagged-1", job.Run.Updater)
+		assert.Equal(t, 1, job.Run.Vulnerabilities)
+	}
+
+	vulns, err := database.FindVulnerabilitiesAndRollback(datastore, []database.VulnerabilityID{{Name: "CVE-2020-0001", Namespace: "tagged:1"}})
+	require.Nil(t, err)
+	assert.True(t, vulns[0].Valid)
+
+	runs, err := database.FindUpdaterRunsAndRollback(datastore, "tagged-1", 10)
+	require.Nil(t, err)
+	assert.Len(t, runs, 1)
+	runs, err = database.FindUpdaterRunsAndRollback(datastore, "failing", 10)
+	require.Nil(t, err)
+	assert.Empty(t, runs)
+
+	// A single updater doesn't complete the periodic update.
+	_, isFirstUpdate, err := GetLastUpdateTime(datastore)
+	require.Nil(t, err)
+	assert.True(t, isFirstUpdate)
+
+	// The job released the updater lock, and jobs aren't started while
+	// another update holds it.
+	acquired, _ := database.AcquireLock(datastore, updaterLockName, "periodic", time.Minute)
+	require.True(t, acquired)
+	_, err = jobs.Trigger("tagged-1")
+	assert.Equal(t, ErrUpdateInProgress, err)
+	database.ReleaseLock(datastore, updaterLockName, "periodic")
+
+	// Failed runs are reported with their error.
+	started, err = jobs.Trigger("failing")
+	require.Nil(t, err)
+	job = waitUpdaterJob(t, jobs, started.ID)
+	assert.Equal(t, UpdaterJobFailed, job.Status)
+	assert.Contains(t, job.Error, "could not download")
+	if assert.NotNil(t, job.Run) {
+		assert.Equal(t, "could not download", job.Run.Error)
+	}
+
+	for _, name := range []string{"tagged-2", "unknown"} {
+		_, err = jobs.Trigger(name)
+		assert.Equal(t, ErrUpdaterNotEnabled, err)
+	}
+
+	_, ok := jobs.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestUpdaterJobsStopped(t *testing.T) {
+	registerBlockingUpdater.Do(func() {
+		vulnsrc.RegisterUpdater("blocking", blocking)
+	})
+	blocking.release = make(chan struct{})
+
+	enabled := EnabledUpdaters
+	defer func() { EnabledUpdaters = enabled }()
+	EnabledUpdaters = []string{"blocking"}
+
+	timeout := updaterStopTimeout
+	defer func() { updaterStopTimeout = timeout }()
+	updaterStopTimeout = 10 * time.Millisecond
+
+	datastore, err := database.Open(database.RegistrableComponentConfig{Type: "memory"})
+	require.Nil(t, err)
+	defer datastore.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := NewUpdaterJobs(ctx, &UpdaterConfig{}, datastore)
+
+	// A job whose update is abandoned fails without any run.
+	started, err := jobs.Trigger("blocking")
+	require.Nil(t, err)
+	cancel()
+
+	job := waitUpdaterJob(t, jobs, started.ID)
+	assert.Equal(t, UpdaterJobFailed, job.Status)
+	assert.Equal(t, context.Canceled.Error(), job.Error)
+	assert.Nil(t, job.Run)
+
+	// The abandoned update still records its run once it returns.
+	close(blocking.release)
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		runs, err := database.FindUpdaterRunsAndRollback(datastore, "blocking", 10)
+		require.Nil(t, err)
+		if len(runs) > 0 {
+			break
+		}
+
+		require.True(t, time.Now().Before(deadline), "the abandoned update did not return")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
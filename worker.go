@@ -0,0 +1,76 @@
+// Copyright 2019 clair authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clair
+
+import (
+	"fmt"
+
+	"github.com/quay/clair/v3/ext/imagefmt"
+)
+
+// DefaultLayerConcurrency is the number of layers of an ancestry analyzed at
+// the same time when it is not configured.
+const DefaultLayerConcurrency = 4
+
+// layerConcurrency is the number of layers of an ancestry analyzed at the same
+// time.
+var layerConcurrency = DefaultLayerConcurrency
+
+// storeLayerSources tells whether the layer sources of the processed
+// ancestries are stored to rescan them.
+var storeLayerSources bool
+
+// WorkerConfig is the configuration for the layer analysis worker.
+type WorkerConfig struct {
+	// Registries is the TLS configuration of the registries layers are
+	// pulled from.
+	Registries []imagefmt.RegistryConfig
+
+	// LocalLayerRoot is the directory layers given as local file paths are
+	// confined to. Local file paths are rejected when it is empty.
+	LocalLayerRoot string
+
+	// ConcurrentLayers is the number of layers of an ancestry downloaded and
+	// analyzed at the same time. DefaultLayerConcurrency is used when it is
+	// not positive.
+	ConcurrentLayers int
+
+	// StoreLayerSources stores the layer paths and headers of every processed
+	// ancestry, so that it can be rescanned with newer detectors. The headers
+	// are stored as given, including any credentials they carry.
+	StoreLayerSources bool
+}
+
+// ConfigureWorker applies the worker configuration to the layer fetcher.
+func ConfigureWorker(config *WorkerConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	root, err := resolveLocalLayerRoot(config.LocalLayerRoot)
+	if err != nil {
+		return fmt.Errorf("invalid local layer root: %s", err)
+	}
+	localLayerRoot = root
+
+	layerConcurrency = DefaultLayerConcurrency
+	if config.ConcurrentLayers > 0 {
+		layerConcurrency = config.ConcurrentLayers
+	}
+
+	storeLayerSources = config.StoreLayerSources
+
+	return imagefmt.ConfigureRegistries(config.Registries)
+}